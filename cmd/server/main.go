@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Caqil/vyrall/internal/api/routes"
+	"github.com/Caqil/vyrall/internal/api/websocket"
+	"github.com/Caqil/vyrall/internal/database"
+	dbredis "github.com/Caqil/vyrall/internal/database/redis"
+	"github.com/Caqil/vyrall/internal/repository/mongodb"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+	"github.com/Caqil/vyrall/pkg/config"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run wires the application together, serves until SIGINT/SIGTERM and then shuts down in order
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	log, err := logger.New(cfg.Logging.Level, cfg.Logging.Format)
+	if err != nil {
+		return err
+	}
+	defer log.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to the databases
+	db, err := database.Connect(ctx, cfg.MongoDB.URI, cfg.MongoDB.Database)
+	if err != nil {
+		return err
+	}
+	cache, err := database.ConnectRedis(ctx, dbredis.Options{
+		Addr:         cfg.Redis.Addr,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		PoolSize:     cfg.Redis.PoolSize,
+		MinIdleConns: cfg.Redis.MinIdleConns,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+	})
+	if err != nil {
+		_ = db.Close(context.Background())
+		return err
+	}
+
+	if cfg.MongoDB.AutoMigrate {
+		applied, err := database.EnsureMigrated(ctx, db.DB())
+		if err != nil {
+			_ = cache.Close()
+			_ = db.Close(context.Background())
+			return err
+		}
+		if len(applied) > 0 {
+			log.Info("Applied database migrations", "versions", applied)
+		}
+	}
+
+	// Build repositories and services
+	repos := mongodb.NewRepositories(db.DB())
+
+	keys, err := newKeyring(ctx, cfg, repos, log)
+	if err != nil {
+		_ = cache.Close()
+		_ = db.Close(context.Background())
+		return err
+	}
+
+	jobs := queue.NewRedisQueue(cache.Client(), queue.Options{})
+	svc, err := newServices(cfg, db, cache, repos, jobs, keys, log)
+	if err != nil {
+		_ = cache.Close()
+		_ = db.Close(context.Background())
+		return err
+	}
+
+	// Start the websocket hub and HTTP server
+	wsHandler := websocket.NewWebSocketHandler(svc)
+	router := routes.SetupRouter(cfg, svc, log)
+	router.GET("/ws", gin.WrapF(wsHandler.HandleWebSocket))
+
+	server := &http.Server{
+		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("HTTP server listening", "addr", server.Addr, "environment", cfg.Environment)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	startWorkers(workerCtx, &workers, jobs, repos, svc, log)
+
+	// Wait for a signal or a fatal server error
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Info("Shutdown signal received")
+	case runErr = <-serverErr:
+		log.Error("HTTP server failed", "error", runErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// 1. Stop accepting requests and let in-flight ones finish
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error("HTTP server shutdown incomplete", "error", err)
+	}
+
+	// 2. Drain websocket clients
+	if err := wsHandler.Shutdown(shutdownCtx); err != nil {
+		log.Error("Websocket drain incomplete", "error", err)
+	}
+
+	// 3. Stop background workers; running jobs finish before Wait returns
+	stopWorkers()
+	workers.Wait()
+
+	// 4. Flush buffered service state such as real-time analytics counters
+	svc.Close()
+
+	// 5. Close connection pools
+	if err := cache.Close(); err != nil {
+		log.Error("Failed to close Redis", "error", err)
+	}
+	if err := db.Close(shutdownCtx); err != nil {
+		log.Error("Failed to close MongoDB", "error", err)
+	}
+
+	log.Info("Shutdown complete")
+	return runErr
+}
+
+// newKeyring loads the token signing keys, creating the first one on a fresh
+// database, so a key is active before the server takes requests
+func newKeyring(ctx context.Context, cfg *config.Config, repos *mongodb.Repositories, log *logger.Logger) (*keyring.Keyring, error) {
+	keys, err := keyring.New(repos.SigningKeys, keyring.Options{
+		Algorithm:        cfg.JWT.Algorithm,
+		Secret:           cfg.JWT.Secret,
+		RotationInterval: cfg.JWT.KeyRotationInterval,
+		GracePeriod:      cfg.JWT.KeyGracePeriod,
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.Rotate(ctx); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Caqil/vyrall/internal/database"
+	"github.com/Caqil/vyrall/pkg/config"
+)
+
+// runMigrate handles the migrate subcommand and returns the process exit code
+func runMigrate(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	db, err := database.Connect(ctx, cfg.MongoDB.URI, cfg.MongoDB.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close(context.Background())
+
+	if err := database.RunMigrate(ctx, db.DB(), args, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
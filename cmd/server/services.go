@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Caqil/vyrall/internal/database"
+	"github.com/Caqil/vyrall/internal/repository/mongodb"
+	redisrepo "github.com/Caqil/vyrall/internal/repository/redis"
+	"github.com/Caqil/vyrall/internal/services"
+	"github.com/Caqil/vyrall/internal/services/analytics"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/services/comment"
+	"github.com/Caqil/vyrall/internal/services/event"
+	"github.com/Caqil/vyrall/internal/services/media"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/internal/services/post"
+	"github.com/Caqil/vyrall/internal/services/user"
+	"github.com/Caqil/vyrall/internal/utils/email"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/geoip"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/pwned"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/metrics"
+)
+
+// newServices builds every application service on top of the repositories.
+// A dependency that is misconfigured or cannot be opened fails startup here
+// rather than leaving a service unset for the handlers and workers to trip on.
+// Subscribers are registered with the event bus before it is returned.
+func newServices(cfg *config.Config, db *database.Database, cache *database.RedisClient, repos *mongodb.Repositories, jobs *queue.RedisQueue, keys *keyring.Keyring, log *logger.Logger) (*services.Services, error) {
+	events := eventbus.NewOutbox(repos.Outbox)
+	collector := metrics.NewCollector()
+
+	mailer, err := email.NewSender(&cfg.Email, cfg.AWS.Region, log)
+	if err != nil {
+		return nil, err
+	}
+	files, err := media.NewS3Store(&cfg.AWS)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    cfg.WebAuthn.RPID,
+		RPName:  cfg.WebAuthn.RPName,
+		Origins: cfg.WebAuthn.Origins,
+		Timeout: cfg.WebAuthn.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: %w", err)
+	}
+
+	// Location checks and breached-password screening are optional; the auth
+	// services take a nil interface when they are not configured
+	var geoIP auth.GeoIPService
+	if cfg.LoginRisk.GeoIPDatabase != "" {
+		locations, err := geoip.Open(cfg.LoginRisk.GeoIPDatabase)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: %w", err)
+		}
+		geoIP = locations
+	}
+	var breached auth.BreachedPasswordChecker
+	if cfg.Password.BreachedCorpus != "" {
+		corpus, err := pwned.OpenDir(cfg.Password.BreachedCorpus)
+		if err != nil {
+			return nil, fmt.Errorf("breached password corpus: %w", err)
+		}
+		breached = pwned.New(corpus, cfg.Password.BreachedMinCount)
+	}
+
+	notifier := notification.NewService(repos.Notifications)
+	authService := auth.NewService(&auth.Repositories{
+		Users:          auth.NewUserRepository(repos.Users),
+		Sessions:       repos.Sessions,
+		Verifications:  repos.Verifications,
+		TwoFactor:      repos.TwoFactor,
+		Passkeys:       repos.Passkeys,
+		LoginAttempts:  repos.LoginAttempts,
+		LoginHistory:   repos.LoginHistory,
+		OAuthApps:      repos.OAuthApps,
+		OAuthGrants:    repos.OAuthGrants,
+		PersonalTokens: repos.PersonalTokens,
+		AdminAudit:     repos.AdminAudit,
+	}, keys, rp, geoIP, breached, mailer, notifier, repos.Transactor, events, cfg, log)
+	commentService := comment.NewService(repos.Comments, repos.Likes, repos.Posts, repos.Users, repos.Reports, notifier, repos.Transactor, events, &cfg.Comment, collector, log)
+	eventService := event.NewService(repos.Events, repos.Users, repos.Follows, repos.Groups, notifier, repos.Transactor, events, &cfg.Event, collector, log)
+
+	svc := &services.Services{
+		AnalyticsService:    analytics.NewService(db, cache, log, cfg),
+		AuthService:         authService,
+		CommentService:      commentService,
+		EventService:        eventService,
+		NotificationService: notifier,
+		PermissionService:   permission.NewService(repos.Users, repos.Groups, repos.Posts, repos.Comments, repos.LiveStreams, repos.Events),
+		PostService:         post.NewService(repos.Posts, repos.Comments, repos.Likes, repos.Bookmarks, repos.Media, repos.Users, repos.Transactor, events),
+		UserService:         user.NewService(repos.Users, repos.Follows, repos.Transactor, events),
+		JobQueue:            jobs,
+		EventBus:            eventbus.NewBus(),
+		OAuthServer:         authService.OAuthServer(),
+		Keyring:             keys,
+		AccountPurgeService: user.NewPurgeService(repos.Users, repos.Media, repos.AccountPurges, files),
+		TimelineService:     post.NewTimelineService(repos.Posts, repos.Users, repos.Follows, redisrepo.NewTimelineRepository(cache.Client()), &cfg.Timeline),
+	}
+
+	authService.Subscribe(svc.EventBus)
+	commentService.Subscribe(svc.EventBus)
+	eventService.Subscribe(svc.EventBus)
+	svc.TimelineService.Subscribe(svc.EventBus)
+	return svc, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/repository/mongodb"
+	redisrepo "github.com/Caqil/vyrall/internal/repository/redis"
+	"github.com/Caqil/vyrall/internal/services"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+const (
+	// scheduledPostInterval is how often due scheduled posts are published
+	scheduledPostInterval = 30 * time.Second
+
+	// scheduledReportInterval is how often due scheduled reports are run
+	scheduledReportInterval = time.Minute
+
+	// expiredMessageInterval is how often expired messages are swept
+	expiredMessageInterval = 5 * time.Minute
+
+	// reminderInterval is how often due event reminders are sent
+	reminderInterval = time.Minute
+
+	// outboxRelayInterval is how often recorded domain events are moved onto the queue
+	outboxRelayInterval = time.Second
+
+	// sessionCleanupInterval is how often expired sessions are removed
+	sessionCleanupInterval = time.Hour
+
+	// accountPurgeInterval is how often accounts whose deletion grace period
+	// has ended are erased
+	accountPurgeInterval = time.Hour
+
+	// keyRotationInterval is how often the signing keys are checked for a due
+	// rotation; keys themselves rotate on the configured, much longer schedule
+	keyRotationInterval = time.Hour
+
+	// dailyAggregationOffset is when after midnight UTC the daily aggregation runs
+	dailyAggregationOffset = 15 * time.Minute
+
+	// counterReconciliationOffset is when after midnight UTC counters are reconciled,
+	// kept clear of the daily aggregation
+	counterReconciliationOffset = 3 * time.Hour
+
+	// leaderLeaseTTL bounds how long a job goes unrun after its leader dies
+	leaderLeaseTTL = 30 * time.Second
+)
+
+// startWorkers registers the job handlers and recurring jobs, then runs the
+// queue worker, the scheduler and the leader-elected cron jobs until ctx is
+// cancelled. wg is released once all of them have returned and every running
+// job has finished.
+func startWorkers(ctx context.Context, wg *sync.WaitGroup, jobs *queue.RedisQueue, repos *mongodb.Repositories, svc *services.Services, log *logger.Logger) {
+	worker := queue.NewWorker(jobs, log, queue.WorkerOptions{})
+	scheduler := queue.NewScheduler(jobs, log)
+
+	worker.Handle(queue.JobPublishScheduledPosts, queue.ScheduledPostsSweepProcessor(jobs, repos.Posts))
+	worker.Handle(queue.JobPublishScheduledPost, queue.PublishScheduledPostProcessor(repos.Posts))
+	worker.Handle(queue.JobDeleteExpiredMessages, queue.ExpiredMessagesProcessor(repos.Messages, log))
+	worker.Handle(queue.JobRunScheduledReports, queue.ScheduledReportsSweepProcessor(jobs, svc.AnalyticsService.Reporting))
+	worker.Handle(queue.JobRunScheduledReport, queue.RunScheduledReportProcessor(svc.AnalyticsService.Reporting))
+	worker.Handle(queue.JobDeliverEvent, svc.EventBus.DeliveryProcessor(log))
+
+	scheduler.Every(queue.JobPublishScheduledPosts, scheduledPostInterval, nil)
+	scheduler.Every(queue.JobRunScheduledReports, scheduledReportInterval, nil)
+	scheduler.Every(queue.JobDeleteExpiredMessages, expiredMessageInterval, nil)
+	worker.Handle(queue.JobPurgeDueAccounts, queue.DueAccountsSweepProcessor(jobs, svc.AccountPurgeService))
+	worker.Handle(queue.JobPurgeAccount, queue.PurgeAccountProcessor(svc.AccountPurgeService, log))
+	scheduler.Every(queue.JobPurgeDueAccounts, accountPurgeInterval, nil)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		worker.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		scheduler.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		svc.Keyring.Run(ctx)
+	}()
+
+	// Cron-style jobs that must not double-fire run only on the elected leader
+	locks := redisrepo.NewLockManager(jobs.Client())
+	relay := eventbus.NewRelay(repos.Outbox, jobs, svc.EventBus, log)
+	runAsLeader(ctx, wg, locks, log, "outbox_relay", outboxRelayInterval, 0, relay.Run)
+	runAsLeader(ctx, wg, locks, log, "event_reminders", reminderInterval, 0, func(ctx context.Context) error {
+		_, err := svc.EventService.ProcessDueReminders(ctx)
+		return err
+	})
+	runAsLeader(ctx, wg, locks, log, "session_cleanup", sessionCleanupInterval, 0, svc.AuthService.CleanupExpiredSessions)
+	runAsLeader(ctx, wg, locks, log, "signing_key_rotation", keyRotationInterval, 0, svc.Keyring.Rotate)
+	runAsLeader(ctx, wg, locks, log, "daily_aggregation", 24*time.Hour, dailyAggregationOffset, svc.AnalyticsService.RunDailyAggregation)
+	runAsLeader(ctx, wg, locks, log, "counter_reconciliation", 24*time.Hour, counterReconciliationOffset, func(ctx context.Context) error {
+		return reconcileCounters(ctx, repos.Counters, log)
+	})
+}
+
+// reconcileCounters repairs denormalised counters that drifted from their
+// source collections and logs what it found, since drift points at a write
+// path that bypasses the transactional flows
+func reconcileCounters(ctx context.Context, counters *mongodb.CounterReconciler, log *logger.Logger) error {
+	reports, err := counters.Reconcile(ctx, true)
+	for _, report := range reports {
+		if report.Drifted == 0 {
+			continue
+		}
+		log.Warn("Counter drift repaired",
+			"counter", report.Counter,
+			"drifted", report.Drifted,
+			"total_delta", report.TotalDelta,
+			"repaired", report.Repaired,
+			"samples", report.Samples,
+		)
+	}
+	return err
+}
+
+// runAsLeader runs fn every interval (aligned to the clock, shifted by offset)
+// on whichever instance currently holds the leadership lease for name
+func runAsLeader(ctx context.Context, wg *sync.WaitGroup, locks *redisrepo.LockManager, log *logger.Logger, name string, interval, offset time.Duration, fn func(ctx context.Context) error) {
+	elector := redisrepo.NewLeaderElector(locks, name, leaderLeaseTTL, log)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		elector.RunPeriodically(ctx, interval, offset, fn)
+	}()
+}
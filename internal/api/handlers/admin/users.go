@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -28,6 +29,25 @@ type UserService interface {
 	AssignUserRole(userID primitive.ObjectID, role string) error
 }
 
+// LoginLockoutService interface for managing login lockouts
+type LoginLockoutService interface {
+	GetLoginLockout(ctx context.Context, userID primitive.ObjectID) (*models.LoginLockout, error)
+	UnlockAccount(ctx context.Context, userID, adminID primitive.ObjectID) error
+}
+
+// ImpersonationService interface for signing in as a user to see the app as they do
+type ImpersonationService interface {
+	StartImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error)
+	ElevateImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error)
+	EndImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, userAgent, ipAddress string) error
+}
+
+// AccountPurgeService interface for reading the records of erased accounts
+type AccountPurgeService interface {
+	ListReports(ctx context.Context, limit, offset int) ([]*models.AccountPurgeReport, int, error)
+	GetReport(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error)
+}
+
 // ListUsers returns a list of users with filtering and pagination
 func ListUsers(c *gin.Context) {
 	// Get query parameters
@@ -214,6 +234,150 @@ func UnsuspendUser(c *gin.Context) {
 	response.Success(c, http.StatusOK, "User unsuspended successfully", nil)
 }
 
+// GetUserLockout returns the login lockout in force on a user's account
+func GetUserLockout(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	lockoutService := c.MustGet("authService").(LoginLockoutService)
+
+	lockout, err := lockoutService.GetLoginLockout(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "User is not locked out", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Lockout retrieved successfully", lockout)
+}
+
+// UnlockUser lifts the login lockout on a user's account after repeated failed logins
+func UnlockUser(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	adminID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	lockoutService := c.MustGet("authService").(LoginLockoutService)
+
+	if err := lockoutService.UnlockAccount(c.Request.Context(), id, adminID.(primitive.ObjectID)); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to unlock user", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "User unlocked successfully", nil)
+}
+
+// ImpersonateUser opens a short-lived, read-only session as a user so staff
+// can see the app as they do. The user sees the session in their session list.
+func ImpersonateUser(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	adminID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	impersonationService := c.MustGet("authService").(ImpersonationService)
+
+	session, err := impersonationService.StartImpersonation(c.Request.Context(), adminID.(primitive.ObjectID), id, req.Reason, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to impersonate user", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Impersonation started", gin.H{
+		"token":      session.Token,
+		"expires_at": session.ExpiresAt,
+		"session_id": session.ID.Hex(),
+		"user_id":    session.UserID.Hex(),
+		"write":      session.Impersonation.Write,
+	})
+}
+
+// ElevateImpersonation lets the open impersonation of a user make changes as them
+func ElevateImpersonation(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	adminID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	impersonationService := c.MustGet("authService").(ImpersonationService)
+
+	session, err := impersonationService.ElevateImpersonation(c.Request.Context(), adminID.(primitive.ObjectID), id, req.Reason, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to elevate impersonation", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Impersonation can now make changes", gin.H{
+		"session_id":  session.ID.Hex(),
+		"write":       session.Impersonation.Write,
+		"elevated_at": session.Impersonation.ElevatedAt,
+	})
+}
+
+// EndImpersonation closes the open impersonation of a user
+func EndImpersonation(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	adminID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	impersonationService := c.MustGet("authService").(ImpersonationService)
+
+	if err := impersonationService.EndImpersonation(c.Request.Context(), adminID.(primitive.ObjectID), id, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to end impersonation", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Impersonation ended", nil)
+}
+
 // GetUserActivity returns a user's activity history
 func GetUserActivity(c *gin.Context) {
 	idStr := c.Param("id")
@@ -421,3 +585,37 @@ func AssignUserRole(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "Role assigned successfully", nil)
 }
+
+// ListAccountPurges returns the records of erased accounts, most recent first
+func ListAccountPurges(c *gin.Context) {
+	limit, offset := getPaginationParams(c)
+
+	purgeService := c.MustGet("accountPurgeService").(AccountPurgeService)
+
+	reports, total, err := purgeService.ListReports(c.Request.Context(), limit, offset)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve account purges", err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, "Account purges retrieved successfully", reports, limit, offset, total)
+}
+
+// GetAccountPurge returns what was erased when a user's account was deleted
+func GetAccountPurge(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	purgeService := c.MustGet("accountPurgeService").(AccountPurgeService)
+
+	report, err := purgeService.GetReport(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Account has not been purged", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Account purge retrieved successfully", report)
+}
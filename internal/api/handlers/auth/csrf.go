@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/Caqil/vyrall/internal/utils/csrf"
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCSRFToken mints a CSRF token for the current session. The token is set
+// in the CSRF cookie and returned in the body; cookie-authenticated clients
+// echo it in the X-CSRF-Token header of state-changing requests.
+func GetCSRFToken(c *gin.Context) {
+	value, _ := c.Get("sessionID")
+	sessionID, _ := value.(string)
+	if sessionID == "" {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	protector := c.MustGet("csrfProtector").(*csrf.Protector)
+
+	token, err := protector.Token(sessionID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to generate CSRF token", err)
+		return
+	}
+
+	http.SetCookie(c.Writer, protector.Cookie(token))
+	response.Success(c, http.StatusOK, "CSRF token generated", gin.H{
+		"csrf_token": token,
+		"header":     csrf.HeaderName,
+	})
+}
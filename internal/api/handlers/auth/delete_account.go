@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountDeletionService defines the interface for deleting accounts
+type AccountDeletionService interface {
+	RequestAccountDeletion(ctx context.Context, userID primitive.ObjectID, password string) (time.Time, error)
+}
+
+// DeleteAccount schedules the authenticated user's account for deletion and
+// logs it out everywhere. Logging in again before the returned time cancels
+// the deletion.
+func DeleteAccount(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	deletionService := c.MustGet("authService").(AccountDeletionService)
+
+	scheduledAt, err := deletionService.RequestAccountDeletion(c.Request.Context(), userID.(primitive.ObjectID), req.Password)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to delete account", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Account scheduled for deletion; log in before then to keep it", gin.H{
+		"deletion_scheduled_at": scheduledAt,
+	})
+}
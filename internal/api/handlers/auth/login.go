@@ -1,18 +1,32 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Caqil/vyrall/internal/models"
 	"github.com/Caqil/vyrall/internal/utils/response"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // AuthService defines the interface for authentication operations (just the relevant methods for login)
 type AuthService interface {
-	Login(email, password string) (*models.Session, error)
+	Login(ctx context.Context, email, password, userAgent, ipAddress string) (*models.Session, error)
 	ValidateToken(token string) (*models.User, error)
 	VerifyTwoFactor(userID primitive.ObjectID, code string) error
+	VerifyLoginCode(ctx context.Context, pendingToken, code string) (*models.Session, error)
+}
+
+// throttledError is implemented by errors that tell the client to come back later
+type throttledError interface {
+	error
+	RetryAfter() time.Duration
 }
 
 // LoginRequest represents a login request body
@@ -31,16 +45,30 @@ func Login(c *gin.Context) {
 
 	authService := c.MustGet("authService").(AuthService)
 
-	session, err := authService.Login(req.Email, req.Password)
+	session, err := authService.Login(c.Request.Context(), req.Email, req.Password, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
+		var throttled throttledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter().Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+			return
+		}
 		response.Error(c, http.StatusUnauthorized, "Authentication failed", err)
 		return
 	}
 
-	// Check if two-factor authentication is required
-	if session.UserID != primitive.NilObjectID && session.Token == "" {
-		response.Success(c, http.StatusOK, "Two-factor authentication required", gin.H{
-			"two_factor_required": true,
+	// Check if a second step is required. The pending token is exchanged for
+	// a session with a TOTP code or a passkey, or for a risky login with the
+	// code emailed to the user.
+	if strings.HasPrefix(session.Token, "pending_") {
+		message := "Two-factor authentication required"
+		if session.PendingFactor == models.PendingFactorEmailCode {
+			message = "Enter the code sent to your email to finish signing in"
+		}
+		response.Success(c, http.StatusOK, message, gin.H{
+			"two_factor_required": session.PendingFactor != models.PendingFactorEmailCode,
+			"pending_factor":      session.PendingFactor,
+			"pending_token":       session.Token,
 			"user_id":             session.UserID.Hex(),
 		})
 		return
@@ -81,7 +109,7 @@ func VerifyTwoFactorLogin(c *gin.Context) {
 	}
 
 	// Generate a session now that 2FA is verified
-	session, err := authService.Login("", "") // Using empty credentials here as 2FA is already verified
+	session, err := authService.Login(c.Request.Context(), "", "", c.Request.UserAgent(), c.ClientIP()) // Using empty credentials here as 2FA is already verified
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to create session after 2FA verification", err)
 		return
@@ -94,3 +122,37 @@ func VerifyTwoFactorLogin(c *gin.Context) {
 		"user_id":       session.UserID.Hex(),
 	})
 }
+
+// VerifyLoginCode completes a risky login with the code emailed to the user
+func VerifyLoginCode(c *gin.Context) {
+	var req struct {
+		PendingToken string `json:"pending_token" binding:"required"`
+		Code         string `json:"code" binding:"required,len=6"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	authService := c.MustGet("authService").(AuthService)
+
+	session, err := authService.VerifyLoginCode(c.Request.Context(), req.PendingToken, req.Code)
+	if err != nil {
+		var throttled throttledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter().Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+			return
+		}
+		response.Error(c, http.StatusUnauthorized, "Invalid verification code", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Login verified", gin.H{
+		"token":         session.Token,
+		"refresh_token": session.RefreshToken,
+		"expires_at":    session.ExpiresAt,
+		"user_id":       session.UserID.Hex(),
+	})
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+)
+
+// LoginAlertService defines the interface for acting on login alerts
+type LoginAlertService interface {
+	RevokeFlaggedSession(ctx context.Context, token string) error
+}
+
+// RevokeFlaggedSession signs out the session a "was this you?" alert was
+// sent about. The token from the alert's link is the only credential, so it
+// works without being signed in.
+func RevokeFlaggedSession(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	alertService := c.MustGet("authService").(LoginAlertService)
+
+	if err := alertService.RevokeFlaggedSession(c.Request.Context(), req.Token); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to sign out session", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Session signed out. Change your password if you did not sign in.", nil)
+}
@@ -0,0 +1,432 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Caqil/vyrall/internal/models"
+	authservice "github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OAuthServer defines the interface for the authorization server that
+// third-party apps use
+type OAuthServer interface {
+	RegisterApp(ctx context.Context, ownerID primitive.ObjectID, input *authservice.AppInput) (*models.OAuthApp, string, error)
+	ListApps(ctx context.Context, ownerID primitive.ObjectID) ([]*models.OAuthApp, error)
+	GetApp(ctx context.Context, ownerID, appID primitive.ObjectID) (*models.OAuthApp, error)
+	UpdateApp(ctx context.Context, ownerID, appID primitive.ObjectID, input *authservice.AppInput) (*models.OAuthApp, error)
+	RotateAppSecret(ctx context.Context, ownerID, appID primitive.ObjectID) (string, error)
+	DeleteApp(ctx context.Context, ownerID, appID primitive.ObjectID) error
+	ConsentURL(rawQuery string) string
+	GetAuthorization(ctx context.Context, userID primitive.ObjectID, req *authservice.AuthorizationRequest) (*authservice.ConsentPrompt, error)
+	DecideAuthorization(ctx context.Context, userID primitive.ObjectID, req *authservice.AuthorizationRequest, approved bool) (string, error)
+	AuthorizationRedirect(req *authservice.AuthorizationRequest, err error) string
+	ExchangeToken(ctx context.Context, req *authservice.TokenRequest) (*authservice.TokenResponse, error)
+	Introspect(ctx context.Context, clientID, clientSecret, token string) (*authservice.Introspection, error)
+	Revoke(ctx context.Context, clientID, clientSecret, token string) error
+	UserInfo(ctx context.Context, token string) (map[string]interface{}, error)
+	ListAuthorizations(ctx context.Context, userID primitive.ObjectID) ([]*authservice.Authorization, error)
+	RevokeAuthorization(ctx context.Context, userID, appID primitive.ObjectID) error
+	Discovery() map[string]interface{}
+}
+
+// AuthorizationDecisionRequest is the user's answer on the consent screen
+type AuthorizationDecisionRequest struct {
+	authservice.AuthorizationRequest
+	Approved bool `json:"approved"`
+}
+
+// OAuthAuthorize sends the user from an app's authorization request to the
+// consent screen, which drives the rest of the flow through the consent API
+func OAuthAuthorize(c *gin.Context) {
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+	c.Redirect(http.StatusFound, oauthServer.ConsentURL(c.Request.URL.RawQuery))
+}
+
+// GetOAuthAuthorization validates an authorization request and returns what
+// the consent screen should show. When the request is one the app must be
+// told about, redirect_to holds the URL to send the user back with instead.
+func GetOAuthAuthorization(c *gin.Context) {
+	var req authservice.AuthorizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid authorization request", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	prompt, err := oauthServer.GetAuthorization(c.Request.Context(), userID.(primitive.ObjectID), &req)
+	if err != nil {
+		var oauthErr *authservice.OAuthError
+		if errors.As(err, &oauthErr) {
+			response.Success(c, http.StatusOK, "Authorization request rejected", gin.H{
+				"redirect_to": oauthServer.AuthorizationRedirect(&req, err),
+			})
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Invalid authorization request", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Authorization request retrieved successfully", prompt)
+}
+
+// DecideOAuthAuthorization records the user's answer to an authorization
+// request and returns the URL to send them back to the app with
+func DecideOAuthAuthorization(c *gin.Context) {
+	var req AuthorizationDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	redirectTo, err := oauthServer.DecideAuthorization(c.Request.Context(), userID.(primitive.ObjectID), &req.AuthorizationRequest, req.Approved)
+	if err != nil {
+		var oauthErr *authservice.OAuthError
+		if errors.As(err, &oauthErr) {
+			redirectTo = oauthServer.AuthorizationRedirect(&req.AuthorizationRequest, err)
+		} else {
+			response.Error(c, http.StatusBadRequest, "Failed to authorize app", err)
+			return
+		}
+	}
+
+	response.Success(c, http.StatusOK, "Authorization recorded", gin.H{"redirect_to": redirectTo})
+}
+
+// OAuthToken is the token endpoint. Clients authenticate with HTTP Basic or
+// client_id and client_secret in the form.
+func OAuthToken(c *gin.Context) {
+	var req authservice.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oauthErrorResponse(c, &authservice.OAuthError{Code: authservice.OAuthErrInvalidRequest, Description: "Invalid token request"})
+		return
+	}
+	req.ClientID, req.ClientSecret = oauthClientCredentials(c, req.ClientID, req.ClientSecret)
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	token, err := oauthServer.ExchangeToken(c.Request.Context(), &req)
+	if err != nil {
+		oauthErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, token)
+}
+
+// OAuthIntrospect is the token introspection endpoint (RFC 7662)
+func OAuthIntrospect(c *gin.Context) {
+	clientID, clientSecret := oauthClientCredentials(c, c.PostForm("client_id"), c.PostForm("client_secret"))
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	introspection, err := oauthServer.Introspect(c.Request.Context(), clientID, clientSecret, c.PostForm("token"))
+	if err != nil {
+		oauthErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, introspection)
+}
+
+// OAuthRevoke is the token revocation endpoint (RFC 7009)
+func OAuthRevoke(c *gin.Context) {
+	clientID, clientSecret := oauthClientCredentials(c, c.PostForm("client_id"), c.PostForm("client_secret"))
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	if err := oauthServer.Revoke(c.Request.Context(), clientID, clientSecret, c.PostForm("token")); err != nil {
+		oauthErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// OAuthUserInfo is the OpenID Connect userinfo endpoint
+func OAuthUserInfo(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" || token == c.GetHeader("Authorization") {
+		c.Header("WWW-Authenticate", `Bearer`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "A bearer token is required"})
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	claims, err := oauthServer.UserInfo(c.Request.Context(), token)
+	if err != nil {
+		var oauthErr *authservice.OAuthError
+		if errors.As(err, &oauthErr) {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			c.JSON(http.StatusForbidden, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "The access token is invalid"})
+		return
+	}
+
+	c.JSON(http.StatusOK, claims)
+}
+
+// OpenIDConfiguration serves the OpenID Connect discovery document
+func OpenIDConfiguration(c *gin.Context) {
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+	c.JSON(http.StatusOK, oauthServer.Discovery())
+}
+
+// ListOAuthAuthorizations returns the third-party apps the user has authorized
+func ListOAuthAuthorizations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	authorizations, err := oauthServer.ListAuthorizations(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve authorized apps", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Authorized apps retrieved successfully", authorizations)
+}
+
+// RevokeOAuthAuthorization removes a third-party app's access to the user's account
+func RevokeOAuthAuthorization(c *gin.Context) {
+	appID, err := primitive.ObjectIDFromHex(c.Param("appId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid app ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	if err := oauthServer.RevokeAuthorization(c.Request.Context(), userID.(primitive.ObjectID), appID); err != nil {
+		response.Error(c, http.StatusNotFound, "Failed to revoke app access", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "App access revoked successfully", nil)
+}
+
+// CreateOAuthApp registers a third-party app. The client secret is only
+// returned here and when it is rotated.
+func CreateOAuthApp(c *gin.Context) {
+	var req authservice.AppInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	app, secret, err := oauthServer.RegisterApp(c.Request.Context(), userID.(primitive.ObjectID), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to register app", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "App registered successfully", gin.H{
+		"app":           app,
+		"client_secret": secret,
+	})
+}
+
+// ListOAuthApps returns the third-party apps the user has registered
+func ListOAuthApps(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	apps, err := oauthServer.ListApps(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve apps", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Apps retrieved successfully", apps)
+}
+
+// GetOAuthApp returns one of the user's registered apps
+func GetOAuthApp(c *gin.Context) {
+	appID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid app ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	app, err := oauthServer.GetApp(c.Request.Context(), userID.(primitive.ObjectID), appID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "App not found", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "App retrieved successfully", app)
+}
+
+// UpdateOAuthApp changes one of the user's registered apps
+func UpdateOAuthApp(c *gin.Context) {
+	var req authservice.AppInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	appID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid app ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	app, err := oauthServer.UpdateApp(c.Request.Context(), userID.(primitive.ObjectID), appID, &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update app", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "App updated successfully", app)
+}
+
+// RotateOAuthAppSecret replaces the client secret of one of the user's apps
+func RotateOAuthAppSecret(c *gin.Context) {
+	appID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid app ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	secret, err := oauthServer.RotateAppSecret(c.Request.Context(), userID.(primitive.ObjectID), appID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to rotate client secret", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Client secret rotated successfully", gin.H{"client_secret": secret})
+}
+
+// DeleteOAuthApp removes one of the user's apps and revokes its tokens
+func DeleteOAuthApp(c *gin.Context) {
+	appID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid app ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	oauthServer := c.MustGet("oauthServer").(OAuthServer)
+
+	if err := oauthServer.DeleteApp(c.Request.Context(), userID.(primitive.ObjectID), appID); err != nil {
+		response.Error(c, http.StatusNotFound, "Failed to delete app", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "App deleted successfully", nil)
+}
+
+// oauthClientCredentials takes the client credentials from HTTP Basic
+// authentication if present, otherwise from the form. Basic credentials are
+// form-encoded first, as RFC 6749 section 2.3.1 requires.
+func oauthClientCredentials(c *gin.Context, clientID, clientSecret string) (string, string) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return clientID, clientSecret
+	}
+	if id, err := url.QueryUnescape(username); err == nil {
+		username = id
+	}
+	if secret, err := url.QueryUnescape(password); err == nil {
+		password = secret
+	}
+	return username, password
+}
+
+// oauthErrorResponse sends an error from the token, introspection or
+// revocation endpoint in the shape of RFC 6749 section 5.2
+func oauthErrorResponse(c *gin.Context, err error) {
+	var oauthErr *authservice.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "The authorization server failed"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == authservice.OAuthErrInvalidClient {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="vyrall"`)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ParentalConsentService defines the interface for a guardian approving a
+// young user's account
+type ParentalConsentService interface {
+	GrantParentalConsent(ctx context.Context, token string) error
+	ResendParentalConsent(ctx context.Context, email, password, ipAddress string) error
+}
+
+// GrantParentalConsent activates an account with the token from the link
+// emailed to the user's parent or guardian
+func GrantParentalConsent(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	consentService := c.MustGet("authService").(ParentalConsentService)
+
+	if err := consentService.GrantParentalConsent(c.Request.Context(), req.Token); err != nil {
+		response.Error(c, http.StatusBadRequest, "Parental consent failed", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Account approved", nil)
+}
+
+// ResendParentalConsent emails the parent or guardian of an account
+// awaiting their approval a new link
+func ResendParentalConsent(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	consentService := c.MustGet("authService").(ParentalConsentService)
+
+	if err := consentService.ResendParentalConsent(c.Request.Context(), req.Email, req.Password, c.ClientIP()); err != nil {
+		var throttled throttledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter().Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to resend parental consent email", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Parental consent email sent", nil)
+}
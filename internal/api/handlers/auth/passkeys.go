@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasskeyService defines the interface for passkey operations
+type PasskeyService interface {
+	BeginPasskeyRegistration(ctx context.Context, userID primitive.ObjectID) (*webauthn.CreationOptions, error)
+	FinishPasskeyRegistration(ctx context.Context, userID primitive.ObjectID, name, userAgent string, response *webauthn.CredentialCreationResponse) (*models.Passkey, error)
+	ListPasskeys(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error)
+	RenamePasskey(ctx context.Context, userID, passkeyID primitive.ObjectID, name string) error
+	DeletePasskey(ctx context.Context, userID, passkeyID primitive.ObjectID) error
+	BeginPasskeyLogin(ctx context.Context) (*webauthn.RequestOptions, error)
+	FinishPasskeyLogin(ctx context.Context, response *webauthn.CredentialAssertionResponse, userAgent, ipAddress string) (*models.Session, error)
+	BeginPasskeyTwoFactor(ctx context.Context, pendingToken string) (*webauthn.RequestOptions, error)
+	FinishPasskeyTwoFactor(ctx context.Context, pendingToken string, response *webauthn.CredentialAssertionResponse) (*models.Session, error)
+}
+
+// FinishPasskeyRegistrationRequest carries the credential created by navigator.credentials.create()
+type FinishPasskeyRegistrationRequest struct {
+	Name       string                              `json:"name" binding:"max=64"`
+	Credential webauthn.CredentialCreationResponse `json:"credential"`
+}
+
+// PasskeyTwoFactorRequest identifies the login waiting for its second factor
+type PasskeyTwoFactorRequest struct {
+	PendingToken string                                `json:"pending_token" binding:"required"`
+	Credential   *webauthn.CredentialAssertionResponse `json:"credential"`
+}
+
+// BeginPasskeyRegistration returns the options for navigator.credentials.create()
+func BeginPasskeyRegistration(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	options, err := passkeyService.BeginPasskeyRegistration(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to start passkey registration", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Passkey registration started", options)
+}
+
+// FinishPasskeyRegistration verifies and stores a new passkey
+func FinishPasskeyRegistration(c *gin.Context) {
+	var req FinishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	passkey, err := passkeyService.FinishPasskeyRegistration(c.Request.Context(), userID.(primitive.ObjectID), req.Name, c.Request.UserAgent(), &req.Credential)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to register passkey", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Passkey registered successfully", passkey)
+}
+
+// ListPasskeys returns the authenticated user's passkeys
+func ListPasskeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	passkeys, err := passkeyService.ListPasskeys(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve passkeys", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Passkeys retrieved successfully", passkeys)
+}
+
+// RenamePasskey changes the name of one of the user's passkeys
+func RenamePasskey(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required,max=64"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passkeyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid passkey ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	if err := passkeyService.RenamePasskey(c.Request.Context(), userID.(primitive.ObjectID), passkeyID, req.Name); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to rename passkey", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Passkey renamed successfully", nil)
+}
+
+// DeletePasskey removes one of the user's passkeys
+func DeletePasskey(c *gin.Context) {
+	passkeyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid passkey ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	if err := passkeyService.DeletePasskey(c.Request.Context(), userID.(primitive.ObjectID), passkeyID); err != nil {
+		response.Error(c, http.StatusNotFound, "Failed to delete passkey", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Passkey deleted successfully", nil)
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get() for a passwordless login
+func BeginPasskeyLogin(c *gin.Context) {
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	options, err := passkeyService.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to start passkey login", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Passkey login started", options)
+}
+
+// FinishPasskeyLogin signs the user in with the passkey they chose
+func FinishPasskeyLogin(c *gin.Context) {
+	var req webauthn.CredentialAssertionResponse
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	session, err := passkeyService.FinishPasskeyLogin(c.Request.Context(), &req, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "Authentication failed", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Login successful", gin.H{
+		"token":         session.Token,
+		"refresh_token": session.RefreshToken,
+		"expires_at":    session.ExpiresAt,
+		"user_id":       session.UserID.Hex(),
+	})
+}
+
+// BeginPasskeyTwoFactor returns the options for using a passkey as the second factor of a login
+func BeginPasskeyTwoFactor(c *gin.Context) {
+	var req PasskeyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	options, err := passkeyService.BeginPasskeyTwoFactor(c.Request.Context(), req.PendingToken)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "Failed to start passkey verification", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Passkey verification started", options)
+}
+
+// FinishPasskeyTwoFactor completes a login with a passkey in place of a TOTP code
+func FinishPasskeyTwoFactor(c *gin.Context) {
+	var req PasskeyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Credential == nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passkeyService := c.MustGet("authService").(PasskeyService)
+
+	session, err := passkeyService.FinishPasskeyTwoFactor(c.Request.Context(), req.PendingToken, req.Credential)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "Invalid passkey", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor authentication verified", gin.H{
+		"token":         session.Token,
+		"refresh_token": session.RefreshToken,
+		"expires_at":    session.ExpiresAt,
+		"user_id":       session.UserID.Hex(),
+	})
+}
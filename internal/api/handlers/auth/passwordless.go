@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordlessService defines the interface for login by emailed link or code
+type PasswordlessService interface {
+	RequestPasswordlessLogin(ctx context.Context, email, ipAddress string) error
+	LoginWithLink(ctx context.Context, token, userAgent, ipAddress string) (*models.Session, error)
+	LoginWithCode(ctx context.Context, email, code, userAgent, ipAddress string) (*models.Session, error)
+	DisablePassword(ctx context.Context, userID primitive.ObjectID, password string) error
+	EnablePassword(ctx context.Context, userID primitive.ObjectID, newPassword string) error
+}
+
+// RequestPasswordlessLogin emails a sign-in link and code. The response is
+// the same whether or not the email has an account.
+func RequestPasswordlessLogin(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passwordlessService := c.MustGet("authService").(PasswordlessService)
+
+	if err := passwordlessService.RequestPasswordlessLogin(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		var throttled throttledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter().Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to send sign-in email", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "If the email has an account, a sign-in link and code have been sent to it", nil)
+}
+
+// LoginWithLink signs a user in with the token from an emailed link
+func LoginWithLink(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passwordlessService := c.MustGet("authService").(PasswordlessService)
+
+	session, err := passwordlessService.LoginWithLink(c.Request.Context(), req.Token, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "Authentication failed", err)
+		return
+	}
+
+	passwordlessLoginResponse(c, session)
+}
+
+// LoginWithCode signs a user in with an emailed code
+func LoginWithCode(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Code  string `json:"code" binding:"required,len=6"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	passwordlessService := c.MustGet("authService").(PasswordlessService)
+
+	session, err := passwordlessService.LoginWithCode(c.Request.Context(), req.Email, req.Code, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		var throttled throttledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter().Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+			return
+		}
+		response.Error(c, http.StatusUnauthorized, "Authentication failed", err)
+		return
+	}
+
+	passwordlessLoginResponse(c, session)
+}
+
+// DisablePassword turns password login off for the authenticated user
+func DisablePassword(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passwordlessService := c.MustGet("authService").(PasswordlessService)
+
+	if err := passwordlessService.DisablePassword(c.Request.Context(), userID.(primitive.ObjectID), req.Password); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to turn password login off", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Password login turned off", nil)
+}
+
+// EnablePassword turns password login back on with a new password
+func EnablePassword(c *gin.Context) {
+	var req struct {
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	passwordlessService := c.MustGet("authService").(PasswordlessService)
+
+	if err := passwordlessService.EnablePassword(c.Request.Context(), userID.(primitive.ObjectID), req.NewPassword); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to turn password login on", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Password login turned on", nil)
+}
+
+// passwordlessLoginResponse returns the session, or the pending token for
+// an account that must still enter its second factor
+func passwordlessLoginResponse(c *gin.Context, session *models.Session) {
+	if strings.HasPrefix(session.Token, "pending_") {
+		response.Success(c, http.StatusOK, "Two-factor authentication required", gin.H{
+			"two_factor_required": true,
+			"pending_factor":      session.PendingFactor,
+			"pending_token":       session.Token,
+			"user_id":             session.UserID.Hex(),
+		})
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Login successful", gin.H{
+		"token":         session.Token,
+		"refresh_token": session.RefreshToken,
+		"expires_at":    session.ExpiresAt,
+		"user_id":       session.UserID.Hex(),
+	})
+}
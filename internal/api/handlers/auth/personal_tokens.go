@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Caqil/vyrall/internal/models"
+	authservice "github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalTokenService defines the interface for personal access token operations
+type PersonalTokenService interface {
+	CreatePersonalToken(ctx context.Context, userID primitive.ObjectID, input *authservice.PersonalTokenInput) (*models.PersonalAccessToken, string, error)
+	ListPersonalTokens(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error)
+	PersonalTokenUsage(ctx context.Context, userID, tokenID primitive.ObjectID) ([]*models.PersonalAccessTokenUsage, error)
+	RevokePersonalToken(ctx context.Context, userID, tokenID primitive.ObjectID) error
+}
+
+// CreatePersonalToken issues a personal access token. The token itself is
+// only ever returned here.
+func CreatePersonalToken(c *gin.Context) {
+	var req authservice.PersonalTokenInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	tokenService := c.MustGet("authService").(PersonalTokenService)
+
+	record, token, err := tokenService.CreatePersonalToken(c.Request.Context(), userID.(primitive.ObjectID), &req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create personal access token", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Personal access token created successfully", gin.H{
+		"token":          token,
+		"personal_token": record,
+	})
+}
+
+// ListPersonalTokens returns the authenticated user's personal access tokens
+func ListPersonalTokens(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	tokenService := c.MustGet("authService").(PersonalTokenService)
+
+	tokens, err := tokenService.ListPersonalTokens(c.Request.Context(), userID.(primitive.ObjectID))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve personal access tokens", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Personal access tokens retrieved successfully", tokens)
+}
+
+// GetPersonalTokenUsage returns the recent daily usage of one of the user's
+// personal access tokens
+func GetPersonalTokenUsage(c *gin.Context) {
+	tokenID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid token ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	tokenService := c.MustGet("authService").(PersonalTokenService)
+
+	usage, err := tokenService.PersonalTokenUsage(c.Request.Context(), userID.(primitive.ObjectID), tokenID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "Failed to retrieve personal access token usage", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Personal access token usage retrieved successfully", usage)
+}
+
+// RevokePersonalToken revokes one of the user's personal access tokens
+func RevokePersonalToken(c *gin.Context) {
+	tokenID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid token ID", err)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	tokenService := c.MustGet("authService").(PersonalTokenService)
+
+	if err := tokenService.RevokePersonalToken(c.Request.Context(), userID.(primitive.ObjectID), tokenID); err != nil {
+		response.Error(c, http.StatusNotFound, "Failed to revoke personal access token", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Personal access token revoked successfully", nil)
+}
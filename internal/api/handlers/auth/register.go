@@ -22,8 +22,11 @@ type RegisterRequest struct {
 	Username    string    `json:"username" binding:"required,min=3,max=30"`
 	FirstName   string    `json:"first_name"`
 	LastName    string    `json:"last_name"`
-	DateOfBirth time.Time `json:"date_of_birth"`
+	DateOfBirth time.Time `json:"date_of_birth" binding:"required"`
 	Gender      string    `json:"gender"`
+
+	// Needed when the user is young enough to need a guardian's consent
+	GuardianEmail string `json:"guardian_email" binding:"omitempty,email"`
 }
 
 // Register creates a new user account
@@ -108,6 +111,10 @@ func Register(c *gin.Context) {
 		},
 	}
 
+	if req.GuardianEmail != "" {
+		user.ParentalConsent = &models.ParentalConsent{GuardianEmail: req.GuardianEmail}
+	}
+
 	userID, err := authService.Register(user, req.Password)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Registration failed", err)
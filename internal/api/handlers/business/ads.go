@@ -80,6 +80,12 @@ func CreateAd(c *gin.Context) {
 		return
 	}
 
+	// Ads that can reach teens may not be targeted by interests or audiences
+	if personalizedForTeens(req.TargetAudience) {
+		response.Error(c, http.StatusBadRequest, fmt.Sprintf("Ads shown to users under %d can only be targeted by age, gender, location, language and device", models.AdultAge), nil)
+		return
+	}
+
 	// Convert media file strings to Media objects
 	var mediaFiles []models.Media
 	for _, mediaID := range req.MediaFiles {
@@ -189,6 +195,12 @@ func UpdateAd(c *gin.Context) {
 		return
 	}
 
+	// Ads that can reach teens may not be targeted by interests or audiences
+	if personalizedForTeens(req.TargetAudience) {
+		response.Error(c, http.StatusBadRequest, fmt.Sprintf("Ads shown to users under %d can only be targeted by age, gender, location, language and device", models.AdultAge), nil)
+		return
+	}
+
 	// Convert media file strings to Media objects
 	var mediaFiles []models.Media
 	for _, mediaID := range req.MediaFiles {
@@ -406,6 +418,19 @@ func GetAdPlacements(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Ad placements retrieved successfully", placements)
 }
 
+// personalizedForTeens reports whether targeting picks users by interests,
+// keywords, audiences or custom attributes while its age range reaches
+// teen accounts
+func personalizedForTeens(targeting models.AdTargeting) bool {
+	if len(targeting.AgeRange) > 0 && targeting.AgeRange[0] >= models.AdultAge {
+		return false
+	}
+	return len(targeting.Interests) > 0 ||
+		len(targeting.IncludeKeywords) > 0 || len(targeting.ExcludeKeywords) > 0 ||
+		len(targeting.IncludeAudiences) > 0 || len(targeting.ExcludeAudiences) > 0 ||
+		len(targeting.CustomAttributes) > 0
+}
+
 // Helper function to get pagination parameters
 func getPaginationParams(c *gin.Context) (int, int) {
 	limitStr := c.DefaultQuery("limit", "10")
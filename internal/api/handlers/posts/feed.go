@@ -12,17 +12,21 @@ import (
 
 // FeedHandler handles post feed operations
 type FeedHandler struct {
-	postService *post.Service
+	postService     *post.Service
+	timelineService *post.TimelineService
 }
 
 // NewFeedHandler creates a new feed handler
-func NewFeedHandler(postService *post.Service) *FeedHandler {
+func NewFeedHandler(postService *post.Service, timelineService *post.TimelineService) *FeedHandler {
 	return &FeedHandler{
-		postService: postService,
+		postService:     postService,
+		timelineService: timelineService,
 	}
 }
 
-// GetFeed handles the request to get the user's personalized feed
+// GetFeed handles the request to get the user's home feed. Pages are chained
+// by cursor: the next_cursor of a page is passed as before for the next one,
+// and is empty on the last page.
 func (h *FeedHandler) GetFeed(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -31,21 +35,28 @@ func (h *FeedHandler) GetFeed(c *gin.Context) {
 		return
 	}
 
-	// Get query parameters
-	feedType := c.DefaultQuery("type", "mixed") // following, for_you, mixed
+	// Get the cursor of the page to start after
+	before, err := post.ParseCursor(c.Query("before"))
+	if err != nil {
+		response.ValidationError(c, "Invalid feed cursor", nil)
+		return
+	}
 
 	// Get pagination parameters
-	limit, offset := response.GetPaginationParams(c)
+	limit, _ := response.GetPaginationParams(c)
 
 	// Get feed
-	posts, total, err := h.postService.GetFeed(c.Request.Context(), userID.(primitive.ObjectID), feedType, limit, offset)
+	posts, next, err := h.timelineService.HomeFeed(c.Request.Context(), userID.(primitive.ObjectID), before, limit)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to retrieve feed", err)
 		return
 	}
 
-	// Return paginated response
-	response.SuccessWithPagination(c, http.StatusOK, "Feed retrieved successfully", posts, limit, offset, total)
+	// Return success response
+	response.OK(c, "Feed retrieved successfully", gin.H{
+		"posts":       posts,
+		"next_cursor": post.EncodeCursor(next),
+	})
 }
 
 // GetDiscoverFeed handles the request to get the discovery feed
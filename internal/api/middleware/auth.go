@@ -4,16 +4,34 @@ import (
 	"strings"
 
 	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/internal/utils/csrf"
 	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/Caqil/vyrall/pkg/constants"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Auth is the authentication middleware
-func Auth(authService *auth.Service) gin.HandlerFunc {
+// SessionCookie carries the access token for the web client. Requests
+// authenticated by it are subject to CSRF checks; see CSRF.
+const SessionCookie = "vyrall_session"
+
+// Auth is the authentication middleware. It accepts session tokens, tokens
+// issued to third-party apps and personal access tokens, which start with
+// auth.PersonalTokenPrefix. App and personal tokens are only accepted on
+// route groups that name the resource they serve, and need the resource's
+// read scope for safe methods and its write scope for everything else, e.g.
+// "posts:read" to list posts. Requests in sessions staff opened as a user
+// are read-only until elevated and are audited.
+func Auth(authService auth.Service, resource ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get Authorization header
+		// Get Authorization header, falling back to the session cookie
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			if cookie, err := c.Cookie(SessionCookie); err == nil && cookie != "" {
+				authHeader = "Bearer " + cookie
+			}
+		}
 		if authHeader == "" {
 			response.UnauthorizedError(c, "Authorization header is required")
 			c.Abort()
@@ -35,25 +53,39 @@ func Auth(authService *auth.Service) gin.HandlerFunc {
 			return
 		}
 
-		// Validate token and get user ID
-		userID, err := authService.ValidateToken(c.Request.Context(), token)
+		// Validate token and get user and session IDs
+		accessToken, err := authenticate(c, authService, token)
 		if err != nil {
 			response.UnauthorizedError(c, "Invalid or expired token")
 			c.Abort()
 			return
 		}
 
-		// Set user ID in context
-		c.Set("userID", userID)
+		if scope, ok := scopeAllowed(c, accessToken, resource); !ok {
+			insufficientScope(c, scope)
+			return
+		}
+
+		setAccessToken(c, accessToken)
+		if accessToken.IsImpersonation() {
+			serveImpersonated(c, authService, accessToken)
+			return
+		}
 		c.Next()
 	}
 }
 
-// OptionalAuth is a middleware that tries to authenticate the user but continues regardless
-func OptionalAuth(authService *auth.Service) gin.HandlerFunc {
+// OptionalAuth is a middleware that tries to authenticate the user but continues regardless.
+// A scoped token without the scope Auth would require is treated as absent.
+func OptionalAuth(authService auth.Service, resource ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get Authorization header
+		// Get Authorization header, falling back to the session cookie
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			if cookie, err := c.Cookie(SessionCookie); err == nil && cookie != "" {
+				authHeader = "Bearer " + cookie
+			}
+		}
 		if authHeader == "" {
 			c.Next()
 			return
@@ -72,21 +104,119 @@ func OptionalAuth(authService *auth.Service) gin.HandlerFunc {
 			return
 		}
 
-		// Validate token and get user ID
-		userID, err := authService.ValidateToken(c.Request.Context(), token)
+		// Validate token and get user and session IDs
+		accessToken, err := authenticate(c, authService, token)
 		if err != nil {
 			c.Next()
 			return
 		}
 
-		// Set user ID in context
-		c.Set("userID", userID)
+		if _, ok := scopeAllowed(c, accessToken, resource); !ok {
+			c.Next()
+			return
+		}
+
+		setAccessToken(c, accessToken)
+		if accessToken.IsImpersonation() {
+			serveImpersonated(c, authService, accessToken)
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticate validates a token, telling personal access tokens apart by
+// their prefix. Their allowlists are checked against the client's address,
+// which the router only reads from X-Forwarded-For behind a trusted proxy.
+func authenticate(c *gin.Context, authService auth.Service, token string) (*auth.AccessToken, error) {
+	if strings.HasPrefix(token, auth.PersonalTokenPrefix) {
+		return authService.AuthenticatePersonalToken(c.Request.Context(), token, c.ClientIP(), c.Request.UserAgent())
+	}
+	return authService.Authenticate(c.Request.Context(), token)
+}
+
+// setAccessToken sets the user and session IDs in context, the scopes of a
+// scoped token, the app's client ID or the personal token's ID, and who is
+// impersonating the user
+func setAccessToken(c *gin.Context, accessToken *auth.AccessToken) {
+	c.Set("userID", accessToken.UserID)
+	c.Set("sessionID", accessToken.SessionID)
+	if accessToken.IsScoped() {
+		c.Set("scopes", accessToken.Scopes)
+	}
+	if accessToken.IsThirdParty() {
+		c.Set("clientID", accessToken.ClientID)
+	}
+	if accessToken.IsPersonal() {
+		c.Set("personalTokenID", accessToken.PersonalTokenID)
+	}
+	if accessToken.IsImpersonation() {
+		c.Set("impersonatorID", accessToken.ImpersonatorID)
+	}
+}
+
+// serveImpersonated serves a request in a session staff opened as the user.
+// Until the session is elevated only safe methods are allowed. Every
+// request, refused or not, is written to the admin audit log.
+func serveImpersonated(c *gin.Context, authService auth.Service, accessToken *auth.AccessToken) {
+	if !accessToken.ImpersonationWrite && !csrf.Safe(c.Request.Method) {
+		response.ForbiddenError(c, "Impersonation sessions are read-only")
+		c.Abort()
+	} else {
 		c.Next()
 	}
+
+	authService.RecordImpersonatedRequest(c.Request.Context(), accessToken, c.Request.Method, c.Request.URL.Path,
+		c.Writer.Status(), c.Request.UserAgent(), c.ClientIP())
 }
 
-// AdminOnly ensures that the authenticated user has admin privileges
-func AdminOnly() gin.HandlerFunc {
+// NoImpersonation keeps sessions staff opened as a user, even elevated ones,
+// away from routes that manage the account's sign-in methods, sessions and
+// app grants
+func NoImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := c.Get("impersonatorID"); impersonating {
+			response.ForbiddenError(c, "Not available while impersonating a user")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// scopeAllowed reports whether a token may be used on the current route, and
+// if not, the scope it lacks. Session tokens may be used anywhere.
+func scopeAllowed(c *gin.Context, accessToken *auth.AccessToken, resource []string) (string, bool) {
+	if !accessToken.IsScoped() {
+		return "", true
+	}
+	if len(resource) == 0 {
+		return "", false
+	}
+
+	scope := resource[0] + ":write"
+	if csrf.Safe(c.Request.Method) {
+		scope = resource[0] + ":read"
+	}
+	return scope, accessToken.HasScope(scope)
+}
+
+// insufficientScope rejects a scoped token as RFC 6750 describes
+func insufficientScope(c *gin.Context, scope string) {
+	if scope == "" {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", error_description="This endpoint requires a session token"`)
+		response.ForbiddenError(c, "This endpoint is not available to third-party apps or personal access tokens")
+	} else {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+		response.ForbiddenError(c, "The token is missing the "+scope+" scope")
+	}
+	c.Abort()
+}
+
+// AdminOnly ensures that the authenticated user may access the admin panel.
+// Staff roles differ in what they may do there, so routes also check their
+// own permission.
+func AdminOnly(permissionService *permission.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context (set by Auth middleware)
 		userIDValue, exists := c.Get("userID")
@@ -98,8 +228,7 @@ func AdminOnly() gin.HandlerFunc {
 
 		userID := userIDValue.(primitive.ObjectID)
 
-		// Check if user is an admin (usually this would be done by checking a role in the database)
-		isAdmin, err := checkUserIsAdmin(c, userID)
+		isAdmin, err := permissionService.Can(c.Request.Context(), userID, constants.ActionAccess, permission.Resource{Type: constants.ResourceTypeAdmin})
 		if err != nil || !isAdmin {
 			response.ForbiddenError(c, "Admin privileges required")
 			c.Abort()
@@ -111,16 +240,3 @@ func AdminOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
-
-// checkUserIsAdmin checks if the user has admin privileges
-func checkUserIsAdmin(c *gin.Context, userID primitive.ObjectID) (bool, error) {
-	// In a real application, this would check the user's role in the database
-	// For example, you might have a user service that can check if a user has a specific role
-	// This is just a placeholder implementation
-
-	// userService := c.MustGet("userService").(*user.Service)
-	// return userService.IsAdmin(c.Request.Context(), userID)
-
-	// For demonstration purposes, I'm returning a placeholder
-	return true, nil
-}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// impersonatedRequest is a request the fake recorded to the audit log
+type impersonatedRequest struct {
+	method     string
+	path       string
+	statusCode int
+	write      bool
+}
+
+// impersonationAuthService authenticates "read-only" and "elevated" as
+// impersonation tokens and "own" as the user's own session, recording the
+// impersonated requests it is told about. The rest of auth.Service is left
+// unimplemented.
+type impersonationAuthService struct {
+	auth.Service
+	recorded []impersonatedRequest
+}
+
+func (s *impersonationAuthService) Authenticate(ctx context.Context, token string) (*auth.AccessToken, error) {
+	accessToken := &auth.AccessToken{UserID: primitive.NewObjectID(), SessionID: primitive.NewObjectID().Hex()}
+	switch token {
+	case "own":
+	case "read-only", "elevated":
+		accessToken.ImpersonatorID = primitive.NewObjectID()
+		accessToken.ImpersonationWrite = token == "elevated"
+	default:
+		return nil, errors.New("unknown token")
+	}
+	return accessToken, nil
+}
+
+func (s *impersonationAuthService) RecordImpersonatedRequest(ctx context.Context, token *auth.AccessToken, method, path string, statusCode int, userAgent, ipAddress string) {
+	s.recorded = append(s.recorded, impersonatedRequest{method, path, statusCode, token.ImpersonationWrite})
+}
+
+func TestAuthImpersonation(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		path     string
+		status   int
+		recorded bool
+	}{
+		{name: "read-only reads", token: "read-only", method: http.MethodGet, path: "/api/posts", status: http.StatusNoContent, recorded: true},
+		{name: "read-only writes", token: "read-only", method: http.MethodPost, path: "/api/posts", status: http.StatusForbidden, recorded: true},
+		{name: "read-only deletes", token: "read-only", method: http.MethodDelete, path: "/api/posts", status: http.StatusForbidden, recorded: true},
+		{name: "elevated writes", token: "elevated", method: http.MethodPost, path: "/api/posts", status: http.StatusNoContent, recorded: true},
+		{name: "elevated manages sessions", token: "elevated", method: http.MethodDelete, path: "/api/auth/sessions", status: http.StatusForbidden, recorded: true},
+		{name: "user writes", token: "own", method: http.MethodPost, path: "/api/posts", status: http.StatusNoContent},
+		{name: "user manages sessions", token: "own", method: http.MethodDelete, path: "/api/auth/sessions", status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			authService := &impersonationAuthService{}
+			handled := false
+			ok := func(c *gin.Context) {
+				handled = true
+				c.Status(http.StatusNoContent)
+			}
+
+			router := gin.New()
+			api := router.Group("/api", Auth(authService))
+			api.GET("/posts", ok)
+			api.POST("/posts", ok)
+			api.DELETE("/posts", ok)
+			api.DELETE("/auth/sessions", NoImpersonation(), ok)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if handled != (tt.status == http.StatusNoContent) {
+				t.Fatalf("handler ran = %v with status %d", handled, rec.Code)
+			}
+			if !tt.recorded {
+				if len(authService.recorded) != 0 {
+					t.Fatalf("recorded %+v for the user's own session", authService.recorded)
+				}
+				return
+			}
+			want := impersonatedRequest{tt.method, tt.path, tt.status, tt.token == "elevated"}
+			if len(authService.recorded) != 1 || authService.recorded[0] != want {
+				t.Fatalf("recorded %+v, want %+v", authService.recorded, want)
+			}
+		})
+	}
+}
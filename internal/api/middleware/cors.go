@@ -6,14 +6,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// CORS middleware for handling Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
+// CORS middleware for handling Cross-Origin Resource Sharing. Credentialed
+// requests are only allowed from allowedOrigins, which are the origins the
+// CSRF check trusts, so a page CORS lets in is never rejected as cross-site.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get origin from request
 		origin := c.Request.Header.Get("Origin")
 
 		// Check if the origin is allowed
-		if origin != "" && isAllowedOrigin(origin) {
+		if origin != "" && isAllowedOrigin(origin, allowedOrigins) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
@@ -31,26 +33,11 @@ func CORS() gin.HandlerFunc {
 }
 
 // isAllowedOrigin checks if the origin is allowed
-func isAllowedOrigin(origin string) bool {
-	// Define allowed origins
-	allowedOrigins := []string{
-		"http://localhost:3000",
-		"https://yourapp.com",
-		"https://*.yourapp.com",
-	}
-
+func isAllowedOrigin(origin string, allowedOrigins []string) bool {
 	for _, allowed := range allowedOrigins {
-		if allowed == origin {
+		if strings.TrimSuffix(allowed, "/") == origin {
 			return true
 		}
-
-		// Handle wildcard domains
-		if strings.HasPrefix(allowed, "https://*.") {
-			domain := strings.TrimPrefix(allowed, "https://*.")
-			if strings.HasSuffix(origin, domain) && strings.HasPrefix(origin, "https://") {
-				return true
-			}
-		}
 	}
 
 	return false
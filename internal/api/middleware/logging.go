@@ -7,6 +7,7 @@ import (
 
 	"github.com/Caqil/vyrall/internal/utils/logger"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
@@ -57,7 +58,7 @@ func Logging(log *logger.Logger) gin.HandlerFunc {
 			logFunc = log.Info
 		}
 
-		logFunc("Request completed",
+		fields := []zap.Field{
 			zap.String("request_id", requestID),
 			zap.String("method", method),
 			zap.String("path", path),
@@ -65,7 +66,18 @@ func Logging(log *logger.Logger) gin.HandlerFunc {
 			zap.Int("size", bodySize),
 			zap.Duration("latency", latency),
 			zap.Int("errors", len(c.Errors)),
-		)
+		}
+
+		// Tag requests staff made while impersonating a user
+		if impersonatorID, ok := c.Get("impersonatorID"); ok {
+			fields = append(fields,
+				zap.Bool("impersonated", true),
+				zap.String("impersonator_id", impersonatorID.(primitive.ObjectID).Hex()),
+				zap.String("user_id", c.MustGet("userID").(primitive.ObjectID).Hex()),
+			)
+		}
+
+		logFunc("Request completed", fields...)
 	}
 }
 
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"errors"
+
 	"github.com/Caqil/vyrall/internal/services/permission"
 	"github.com/Caqil/vyrall/internal/utils/response"
+	"github.com/Caqil/vyrall/pkg/constants"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -68,6 +71,11 @@ func ResourceOwner(permissionService *permission.Service, resourceType string) g
 
 		// Check if user owns the resource
 		isOwner, err := permissionService.IsResourceOwner(c.Request.Context(), userID, resourceType, resourceID)
+		if errors.Is(err, permission.ErrResourceNotFound) {
+			response.NotFoundError(c, "Resource not found")
+			c.Abort()
+			return
+		}
 		if err != nil {
 			response.Error(c, 500, "Failed to check resource ownership", err)
 			c.Abort()
@@ -75,9 +83,10 @@ func ResourceOwner(permissionService *permission.Service, resourceType string) g
 		}
 
 		if !isOwner {
-			// Check if user is an admin (has permission to access any resource)
-			isAdmin, err := permissionService.HasPermission(c.Request.Context(), userID, "admin")
-			if err != nil || !isAdmin {
+			// Check if user may moderate the resource, as staff or through a
+			// role such as group admin
+			canModerate, err := permissionService.Can(c.Request.Context(), userID, constants.ActionModerate, permission.Resource{Type: resourceType, ID: resourceID})
+			if err != nil || !canModerate {
 				response.ForbiddenError(c, "You don't have permission to access this resource")
 				c.Abort()
 				return
@@ -118,3 +127,47 @@ func RoleCheck(permissionService *permission.Service, requiredRoles []string) gi
 		c.Next()
 	}
 }
+
+// Authorize middleware to check if the user may perform an action on the
+// resource named by the id URL parameter
+func Authorize(permissionService *permission.Service, resourceType, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from context (set by Auth middleware)
+		userIDValue, exists := c.Get("userID")
+		if !exists {
+			response.UnauthorizedError(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		userID := userIDValue.(primitive.ObjectID)
+
+		// Get resource ID from URL parameter
+		resourceID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			response.ValidationError(c, "Invalid resource ID", nil)
+			c.Abort()
+			return
+		}
+
+		allowed, err := permissionService.Can(c.Request.Context(), userID, action, permission.Resource{Type: resourceType, ID: resourceID})
+		if errors.Is(err, permission.ErrResourceNotFound) {
+			response.NotFoundError(c, "Resource not found")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			response.Error(c, 500, "Failed to check permission", err)
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			response.ForbiddenError(c, "Permission denied")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
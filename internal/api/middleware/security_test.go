@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/utils/csrf"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testSecret       = "test-secret-that-is-long-enough-for-tests"
+	testOrigin       = "https://app.example.com"
+	testSessionToken = "session-token-a"
+)
+
+// sessionAuthService authenticates the session cookies it knows. The rest of
+// auth.Service is left unimplemented.
+type sessionAuthService struct {
+	auth.Service
+	sessions map[string]string // Session cookie to session ID
+}
+
+func (s sessionAuthService) Authenticate(ctx context.Context, token string) (*auth.AccessToken, error) {
+	sessionID, ok := s.sessions[token]
+	if !ok {
+		return nil, errors.New("unknown session")
+	}
+	return &auth.AccessToken{SessionID: sessionID}, nil
+}
+
+func newCSRFRouter(protector *csrf.Protector) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authService := sessionAuthService{sessions: map[string]string{
+		testSessionToken:  "session-a",
+		"session-token-b": "session-b",
+	}}
+
+	router := gin.New()
+	router.Use(CSRF(protector, authService, "POST /api/auth/refresh-token"))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/api/posts", ok)
+	router.POST("/api/posts", ok)
+	router.POST("/api/auth/refresh-token", ok)
+	return router
+}
+
+func newProtector(ttl time.Duration) *csrf.Protector {
+	return csrf.New(testSecret, csrf.Config{TrustedOrigins: []string{testOrigin}, TTL: ttl})
+}
+
+func mintToken(t *testing.T, protector *csrf.Protector, sessionID string) string {
+	t.Helper()
+	token, err := protector.Token(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestCSRF(t *testing.T) {
+	protector := newProtector(time.Hour)
+	router := newCSRFRouter(protector)
+
+	token := mintToken(t, protector, "session-a")
+	otherToken := mintToken(t, protector, "session-a")
+	foreignToken := mintToken(t, protector, "session-b")
+	expiredToken := mintToken(t, newProtector(-time.Minute), "session-a")
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		session string
+		origin  string
+		referer string
+		header  string
+		cookie  string
+		want    int
+	}{
+		{name: "valid token", session: testSessionToken, origin: testOrigin, header: token, cookie: token, want: http.StatusNoContent},
+		{name: "valid token without origin", session: testSessionToken, header: token, cookie: token, want: http.StatusNoContent},
+		{name: "no session cookie", want: http.StatusNoContent},
+		{name: "unknown session cookie", session: "stale", want: http.StatusNoContent},
+		{name: "safe method", method: http.MethodGet, session: testSessionToken, origin: "https://evil.example", want: http.StatusNoContent},
+		{name: "exempt route", path: "/api/auth/refresh-token", session: testSessionToken, origin: "https://evil.example", want: http.StatusNoContent},
+		{name: "foreign origin", session: testSessionToken, origin: "https://evil.example", header: token, cookie: token, want: http.StatusForbidden},
+		{name: "foreign referer", session: testSessionToken, referer: "https://evil.example/page", header: token, cookie: token, want: http.StatusForbidden},
+		{name: "missing csrf cookie", session: testSessionToken, origin: testOrigin, header: token, want: http.StatusForbidden},
+		{name: "missing csrf header", session: testSessionToken, origin: testOrigin, cookie: token, want: http.StatusForbidden},
+		{name: "header and cookie differ", session: testSessionToken, origin: testOrigin, header: token, cookie: otherToken, want: http.StatusForbidden},
+		{name: "token for another session", session: testSessionToken, origin: testOrigin, header: foreignToken, cookie: foreignToken, want: http.StatusForbidden},
+		{name: "expired token", session: testSessionToken, origin: testOrigin, header: expiredToken, cookie: expiredToken, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, path := tt.method, tt.path
+			if method == "" {
+				method = http.MethodPost
+			}
+			if path == "" {
+				path = "/api/posts"
+			}
+
+			req := httptest.NewRequest(method, path, nil)
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.session})
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrf.CookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(csrf.HeaderName, tt.header)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCORSAllowsTrustedOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS([]string{testOrigin + "/"}))
+	router.POST("/api/posts", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for origin, allowed := range map[string]bool{
+		testOrigin:                     true,
+		"https://evil.example":         false,
+		"https://evilapp.example.com":  false,
+		"https://app.example.com.evil": false,
+	} {
+		req := httptest.NewRequest(http.MethodOptions, "/api/posts", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		got := rec.Header().Get("Access-Control-Allow-Origin")
+		if allowed && got != origin {
+			t.Errorf("origin %s: Access-Control-Allow-Origin = %q, want it allowed", origin, got)
+		}
+		if !allowed && got != "" {
+			t.Errorf("origin %s: Access-Control-Allow-Origin = %q, want it refused", origin, got)
+		}
+	}
+}
@@ -2,60 +2,89 @@ package routes
 
 import (
 	"github.com/Caqil/vyrall/internal/api/handlers/admin"
-	"github.com/Caqil/vyrall/internal/middleware"
+	"github.com/Caqil/vyrall/internal/api/middleware"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/pkg/constants"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupAdminRoutes configures the admin routes
-func SetupAdminRoutes(router *gin.Engine, adminHandler *admin.Handler, authMiddleware gin.HandlerFunc) {
+func SetupAdminRoutes(router *gin.Engine, adminHandler *admin.Handler, authMiddleware gin.HandlerFunc, permissionService *permission.Service) {
 	// Admin routes group
 	adminGroup := router.Group("/api/admin")
 
 	// Apply authentication and admin-only middleware to all admin routes
 	adminGroup.Use(authMiddleware)
-	adminGroup.Use(middleware.AdminOnly())
+	adminGroup.Use(middleware.AdminOnly(permissionService))
+
+	// Each staff role may only use the sections its permissions cover
+	can := func(required string) gin.HandlerFunc {
+		return middleware.Permission(permissionService, required)
+	}
+
+	// Changes to a user are checked against the user, as staff accounts
+	// cannot be changed from here and nobody may impersonate themselves or
+	// another member of staff
+	canOnUser := func(action string) gin.HandlerFunc {
+		return middleware.Authorize(permissionService, constants.ResourceTypeUser, action)
+	}
 
 	// Dashboard
 	adminGroup.GET("/dashboard", adminHandler.GetDashboard)
 
 	// Users management
-	adminGroup.GET("/users", adminHandler.ListUsers)
-	adminGroup.GET("/users/:id", adminHandler.GetUser)
-	adminGroup.PUT("/users/:id", adminHandler.UpdateUser)
-	adminGroup.DELETE("/users/:id", adminHandler.DeleteUser)
-	adminGroup.POST("/users/:id/ban", adminHandler.BanUser)
-	adminGroup.POST("/users/:id/unban", adminHandler.UnbanUser)
-	adminGroup.POST("/users/:id/verify", adminHandler.VerifyUser)
-	adminGroup.POST("/users/:id/unverify", adminHandler.UnverifyUser)
+	adminGroup.GET("/users", can(constants.PermissionUserRead), adminHandler.ListUsers)
+	adminGroup.GET("/users/:id", can(constants.PermissionUserRead), adminHandler.GetUser)
+	adminGroup.PUT("/users/:id", canOnUser(constants.ActionUpdate), adminHandler.UpdateUser)
+	adminGroup.DELETE("/users/:id", can(constants.PermissionUserDelete), adminHandler.DeleteUser)
+	adminGroup.POST("/users/:id/ban", canOnUser(constants.ActionModerate), adminHandler.BanUser)
+	adminGroup.POST("/users/:id/unban", canOnUser(constants.ActionModerate), adminHandler.UnbanUser)
+	adminGroup.POST("/users/:id/verify", canOnUser(constants.ActionUpdate), adminHandler.VerifyUser)
+	adminGroup.POST("/users/:id/unverify", canOnUser(constants.ActionUpdate), adminHandler.UnverifyUser)
+	adminGroup.GET("/users/:id/lockout", can(constants.PermissionUserRead), adminHandler.GetUserLockout)
+	adminGroup.POST("/users/:id/unlock", canOnUser(constants.ActionUpdate), adminHandler.UnlockUser)
+
+	// Impersonation, read-only until elevated
+	adminGroup.POST("/users/:id/impersonate", canOnUser(constants.ActionImpersonate), adminHandler.ImpersonateUser)
+	adminGroup.POST("/users/:id/impersonate/elevate", canOnUser(constants.ActionImpersonateWrite), adminHandler.ElevateImpersonation)
+	adminGroup.DELETE("/users/:id/impersonate", canOnUser(constants.ActionImpersonate), adminHandler.EndImpersonation)
+
+	// Records of what was erased from deleted accounts, kept for compliance
+	adminGroup.GET("/account-purges", can(constants.PermissionUserRead), adminHandler.ListAccountPurges)
+	adminGroup.GET("/account-purges/:id", can(constants.PermissionUserRead), adminHandler.GetAccountPurge)
 
 	// Content moderation
-	adminGroup.GET("/reports", adminHandler.ListReports)
-	adminGroup.GET("/reports/:id", adminHandler.GetReport)
-	adminGroup.POST("/reports/:id/resolve", adminHandler.ResolveReport)
-	adminGroup.POST("/reports/:id/dismiss", adminHandler.DismissReport)
+	adminGroup.GET("/reports", can(constants.PermissionReportRead), adminHandler.ListReports)
+	adminGroup.GET("/reports/:id", can(constants.PermissionReportRead), adminHandler.GetReport)
+	adminGroup.POST("/reports/:id/resolve", can(constants.PermissionReportModerate), adminHandler.ResolveReport)
+	adminGroup.POST("/reports/:id/dismiss", can(constants.PermissionReportModerate), adminHandler.DismissReport)
 
 	// Posts management
-	adminGroup.GET("/posts", adminHandler.ListPosts)
-	adminGroup.DELETE("/posts/:id", adminHandler.DeletePost)
-	adminGroup.POST("/posts/:id/feature", adminHandler.FeaturePost)
-	adminGroup.POST("/posts/:id/unfeature", adminHandler.UnfeaturePost)
+	adminGroup.GET("/posts", can(constants.PermissionPostModerate), adminHandler.ListPosts)
+	adminGroup.DELETE("/posts/:id", can(constants.PermissionPostDelete), adminHandler.DeletePost)
+	adminGroup.POST("/posts/:id/feature", can(constants.PermissionPostModerate), adminHandler.FeaturePost)
+	adminGroup.POST("/posts/:id/unfeature", can(constants.PermissionPostModerate), adminHandler.UnfeaturePost)
 
 	// Comments management
-	adminGroup.GET("/comments", adminHandler.ListComments)
-	adminGroup.DELETE("/comments/:id", adminHandler.DeleteComment)
+	adminGroup.GET("/comments", can(constants.PermissionCommentModerate), adminHandler.ListComments)
+	adminGroup.DELETE("/comments/:id", can(constants.PermissionCommentDelete), adminHandler.DeleteComment)
 
 	// System settings
-	adminGroup.GET("/settings", adminHandler.GetSettings)
-	adminGroup.PUT("/settings", adminHandler.UpdateSettings)
+	adminGroup.GET("/settings", can(constants.PermissionSystemRead), adminHandler.GetSettings)
+	adminGroup.PUT("/settings", can(constants.PermissionSystemManage), adminHandler.UpdateSettings)
+
+	// Background job queue
+	adminGroup.GET("/system/jobs", can(constants.PermissionSystemRead), admin.GetJobQueueStats)
+	adminGroup.POST("/system/jobs", can(constants.PermissionSystemManage), admin.ManageJobQueue)
 
 	// Analytics
-	adminGroup.GET("/analytics/overview", adminHandler.GetAnalyticsOverview)
-	adminGroup.GET("/analytics/users", adminHandler.GetUserAnalytics)
-	adminGroup.GET("/analytics/content", adminHandler.GetContentAnalytics)
-	adminGroup.GET("/analytics/engagement", adminHandler.GetEngagementAnalytics)
+	adminGroup.GET("/analytics/overview", can(constants.PermissionSystemRead), adminHandler.GetAnalyticsOverview)
+	adminGroup.GET("/analytics/users", can(constants.PermissionSystemRead), adminHandler.GetUserAnalytics)
+	adminGroup.GET("/analytics/content", can(constants.PermissionSystemRead), adminHandler.GetContentAnalytics)
+	adminGroup.GET("/analytics/engagement", can(constants.PermissionSystemRead), adminHandler.GetEngagementAnalytics)
 
 	// Logs
-	adminGroup.GET("/logs", adminHandler.GetLogs)
-	adminGroup.GET("/logs/errors", adminHandler.GetErrorLogs)
-	adminGroup.GET("/logs/access", adminHandler.GetAccessLogs)
+	adminGroup.GET("/logs", can(constants.PermissionSystemRead), adminHandler.GetLogs)
+	adminGroup.GET("/logs/errors", can(constants.PermissionSystemRead), adminHandler.GetErrorLogs)
+	adminGroup.GET("/logs/access", can(constants.PermissionSystemRead), adminHandler.GetAccessLogs)
 }
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/Caqil/vyrall/internal/api/handlers/auth"
+	"github.com/Caqil/vyrall/internal/api/middleware"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,12 +14,29 @@ func SetupAuthRoutes(router *gin.Engine, authHandler *auth.Handler, authMiddlewa
 	// Public authentication endpoints
 	authGroup.POST("/register", authHandler.Register)
 	authGroup.POST("/login", authHandler.Login)
+	authGroup.POST("/login/verify-code", authHandler.VerifyLoginCode)
 	authGroup.POST("/refresh-token", authHandler.RefreshToken)
 	authGroup.POST("/forgot-password", authHandler.ForgotPassword)
 	authGroup.POST("/reset-password", authHandler.ResetPassword)
 	authGroup.POST("/verify-email", authHandler.VerifyEmail)
 	authGroup.POST("/resend-verification", authHandler.ResendVerification)
 	authGroup.POST("/validate-token", authHandler.ValidateToken)
+	authGroup.POST("/sessions/revoke-flagged", authHandler.RevokeFlaggedSession)
+
+	// A parent or guardian approving a young user's account
+	authGroup.POST("/parental-consent", authHandler.GrantParentalConsent)
+	authGroup.POST("/parental-consent/resend", authHandler.ResendParentalConsent)
+
+	// Login with an emailed link or code instead of a password
+	authGroup.POST("/passwordless/request", authHandler.RequestPasswordlessLogin)
+	authGroup.POST("/passwordless/link", authHandler.LoginWithLink)
+	authGroup.POST("/passwordless/code", authHandler.LoginWithCode)
+
+	// Passkey login, passwordless or as the second factor of a password login
+	authGroup.POST("/passkeys/login/begin", authHandler.BeginPasskeyLogin)
+	authGroup.POST("/passkeys/login/finish", authHandler.FinishPasskeyLogin)
+	authGroup.POST("/passkeys/two-factor/begin", authHandler.BeginPasskeyTwoFactor)
+	authGroup.POST("/passkeys/two-factor/finish", authHandler.FinishPasskeyTwoFactor)
 
 	// OAuth routes
 	authGroup.GET("/oauth/google", authHandler.GoogleOAuthRedirect)
@@ -30,12 +48,15 @@ func SetupAuthRoutes(router *gin.Engine, authHandler *auth.Handler, authMiddlewa
 	authGroup.GET("/oauth/apple", authHandler.AppleOAuthRedirect)
 	authGroup.GET("/oauth/apple/callback", authHandler.AppleOAuthCallback)
 
-	// Protected authentication endpoints (require authentication)
+	// Protected authentication endpoints (require authentication). Staff
+	// impersonating the user may not change how the account signs in.
 	protectedAuthGroup := authGroup.Group("")
-	protectedAuthGroup.Use(authMiddleware)
+	protectedAuthGroup.Use(authMiddleware, middleware.NoImpersonation())
 
 	protectedAuthGroup.POST("/logout", authHandler.Logout)
 	protectedAuthGroup.POST("/change-password", authHandler.ChangePassword)
+	protectedAuthGroup.POST("/password/disable", authHandler.DisablePassword)
+	protectedAuthGroup.POST("/password/enable", authHandler.EnablePassword)
 	protectedAuthGroup.GET("/sessions", authHandler.ListSessions)
 	protectedAuthGroup.DELETE("/sessions/:id", authHandler.DeleteSession)
 	protectedAuthGroup.POST("/two-factor/setup", authHandler.SetupTwoFactor)
@@ -44,4 +65,15 @@ func SetupAuthRoutes(router *gin.Engine, authHandler *auth.Handler, authMiddlewa
 	protectedAuthGroup.POST("/two-factor/verify", authHandler.VerifyTwoFactor)
 	protectedAuthGroup.GET("/two-factor/backup-codes", authHandler.GetTwoFactorBackupCodes)
 	protectedAuthGroup.POST("/two-factor/regenerate-backup-codes", authHandler.RegenerateTwoFactorBackupCodes)
+	protectedAuthGroup.GET("/csrf", authHandler.GetCSRFToken)
+	protectedAuthGroup.GET("/passkeys", authHandler.ListPasskeys)
+	protectedAuthGroup.POST("/passkeys/register/begin", authHandler.BeginPasskeyRegistration)
+	protectedAuthGroup.POST("/passkeys/register/finish", authHandler.FinishPasskeyRegistration)
+	protectedAuthGroup.PATCH("/passkeys/:id", authHandler.RenamePasskey)
+	protectedAuthGroup.DELETE("/passkeys/:id", authHandler.DeletePasskey)
+	protectedAuthGroup.GET("/tokens", authHandler.ListPersonalTokens)
+	protectedAuthGroup.POST("/tokens", authHandler.CreatePersonalToken)
+	protectedAuthGroup.GET("/tokens/:id/usage", authHandler.GetPersonalTokenUsage)
+	protectedAuthGroup.DELETE("/tokens/:id", authHandler.RevokePersonalToken)
+	protectedAuthGroup.POST("/account/delete", authHandler.DeleteAccount)
 }
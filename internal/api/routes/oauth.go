@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"github.com/Caqil/vyrall/internal/api/handlers/auth"
+	"github.com/Caqil/vyrall/internal/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOAuthRoutes configures the authorization server used by third-party
+// apps, the consent screen API and the developer app registration
+func SetupOAuthRoutes(router *gin.Engine, authHandler *auth.Handler, authMiddleware gin.HandlerFunc) {
+	router.GET("/.well-known/openid-configuration", authHandler.OpenIDConfiguration)
+
+	// Protocol endpoints called by apps; the token, introspection and
+	// revocation endpoints authenticate the app itself
+	oauthGroup := router.Group("/oauth")
+	oauthGroup.GET("/authorize", authHandler.OAuthAuthorize)
+	oauthGroup.POST("/token", authHandler.OAuthToken)
+	oauthGroup.POST("/introspect", authHandler.OAuthIntrospect)
+	oauthGroup.POST("/revoke", authHandler.OAuthRevoke)
+	oauthGroup.GET("/userinfo", authHandler.OAuthUserInfo)
+	oauthGroup.POST("/userinfo", authHandler.OAuthUserInfo)
+
+	// Consent screen and authorized apps of the signed-in user. Neither
+	// these nor the developer apps are open to staff impersonating the user.
+	consentGroup := router.Group("/api/oauth")
+	consentGroup.Use(authMiddleware, middleware.NoImpersonation())
+	consentGroup.GET("/authorize", authHandler.GetOAuthAuthorization)
+	consentGroup.POST("/authorize", authHandler.DecideOAuthAuthorization)
+	consentGroup.GET("/authorizations", authHandler.ListOAuthAuthorizations)
+	consentGroup.DELETE("/authorizations/:appId", authHandler.RevokeOAuthAuthorization)
+
+	// Apps registered by the signed-in developer
+	developerGroup := router.Group("/api/developer/apps")
+	developerGroup.Use(authMiddleware, middleware.NoImpersonation())
+	developerGroup.GET("", authHandler.ListOAuthApps)
+	developerGroup.POST("", authHandler.CreateOAuthApp)
+	developerGroup.GET("/:id", authHandler.GetOAuthApp)
+	developerGroup.PUT("/:id", authHandler.UpdateOAuthApp)
+	developerGroup.DELETE("/:id", authHandler.DeleteOAuthApp)
+	developerGroup.POST("/:id/secret", authHandler.RotateOAuthAppSecret)
+}
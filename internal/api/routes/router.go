@@ -2,22 +2,31 @@ package routes
 
 import (
 	"github.com/Caqil/vyrall/internal/api/handlers"
-	"github.com/Caqil/vyrall/internal/config"
-	"github.com/Caqil/vyrall/internal/middleware"
+	"github.com/Caqil/vyrall/internal/api/middleware"
 	"github.com/Caqil/vyrall/internal/services"
+	"github.com/Caqil/vyrall/internal/utils/csrf"
 	"github.com/Caqil/vyrall/internal/utils/logger"
+	configpkg "github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/constants"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRouter initializes and configures the API router
-func SetupRouter(config *config.Config, services *services.Services, log *logger.Logger) *gin.Engine {
+func SetupRouter(config *configpkg.Config, services *services.Services, log *logger.Logger) *gin.Engine {
 	// Create router
 	router := gin.New()
 
+	// Only believe X-Forwarded-For from our own proxies, so the address the
+	// login guard and token allowlists see is not the client's choice. The
+	// addresses were checked when the configuration was loaded.
+	if err := router.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies", "error", err)
+	}
+
 	// Apply global middleware
 	router.Use(middleware.Recovery(log))
 	router.Use(middleware.Logging(log))
-	router.Use(middleware.CORS())
+	router.Use(middleware.CORS(config.CSRF.TrustedOrigins))
 	router.Use(middleware.Security())
 	router.Use(middleware.ErrorHandler())
 
@@ -30,33 +39,82 @@ func SetupRouter(config *config.Config, services *services.Services, log *logger
 		router.Use(middleware.Metrics(services.MetricsService))
 	}
 
+	// Expose the redacted configuration to the admin handlers, the CSRF
+	// protector to the handler that mints tokens, the authorization server
+	// to the OAuth handlers, the records of erased accounts to the admin
+	// handlers and the job queue to the admin system endpoints
+	configService := configpkg.NewService(config)
+	systemService := jobQueueSystem{services.JobQueue}
+	csrfProtector := csrf.New(config.CSRF.SigningSecret(config.JWT.Secret), csrf.Config{
+		TrustedOrigins: config.CSRF.TrustedOrigins,
+		TTL:            config.CSRF.TokenTTL,
+		SecureCookie:   config.CSRF.SecureCookie,
+	})
+	router.Use(func(c *gin.Context) {
+		c.Set("configService", configService)
+		c.Set("csrfProtector", csrfProtector)
+		c.Set("oauthServer", services.OAuthServer)
+		c.Set("accountPurgeService", services.AccountPurgeService)
+		c.Set("systemService", systemService)
+		c.Next()
+	})
+
+	// Check cookie-authenticated requests for forgery. Endpoints that take
+	// their credentials in the body rather than from the session cookie opt out.
+	router.Use(middleware.CSRF(csrfProtector, services.AuthService,
+		"POST /api/auth/refresh-token",
+		"POST /api/auth/validate-token",
+		"POST /api/auth/sessions/revoke-flagged",
+		"POST /oauth/token",
+		"POST /oauth/introspect",
+		"POST /oauth/revoke",
+	))
+
 	// Create handlers
 	handlers := handlers.NewHandlers(services)
 
-	// Create authentication middleware
+	// Create authentication middleware. Scoped tokens, issued to third-party
+	// apps or created as personal access tokens, are only accepted by the route
+	// groups of the resources they can be scoped to.
 	authMiddleware := middleware.Auth(services.AuthService)
 	optionalAuth := middleware.OptionalAuth(services.AuthService)
+	scopedAuth := func(resource string) (gin.HandlerFunc, gin.HandlerFunc) {
+		return middleware.Auth(services.AuthService, resource), middleware.OptionalAuth(services.AuthService, resource)
+	}
+	postAuth, postOptionalAuth := scopedAuth(constants.ResourcePosts)
+	commentAuth, commentOptionalAuth := scopedAuth(constants.ResourceComments)
+	messageAuth, _ := scopedAuth(constants.ResourceMessages)
+	notificationAuth, _ := scopedAuth(constants.ResourceNotifications)
+	userAuth, userOptionalAuth := scopedAuth(constants.ResourceUsers)
+	mediaAuth, mediaOptionalAuth := scopedAuth(constants.ResourceMedia)
+	businessAuth, businessOptionalAuth := scopedAuth(constants.ResourceBusiness)
 
 	// Setup API routes
 	router.GET("/api/health", handlers.Health.Check)
 	router.GET("/api/version", handlers.Health.Version)
 
+	// Publish the token verification keys
+	if services.Keyring != nil {
+		router.GET("/.well-known/jwks.json", gin.WrapF(services.Keyring.Handler()))
+	}
+
 	// Setup domain-specific routes
-	SetupAdminRoutes(router, handlers.Admin, authMiddleware)
+	SetupAdminRoutes(router, handlers.Admin, authMiddleware, services.PermissionService)
 	SetupAuthRoutes(router, handlers.Auth, authMiddleware)
-	SetupBusinessRoutes(router, handlers.Business, authMiddleware, optionalAuth)
-	SetupCommentRoutes(router, handlers.Comments, authMiddleware, optionalAuth)
+	SetupOAuthRoutes(router, handlers.Auth, authMiddleware)
+	SetupBusinessRoutes(router, handlers.Business, businessAuth, businessOptionalAuth)
+	SetupCommentRoutes(router, handlers.Comments, commentAuth, commentOptionalAuth)
 	SetupEventRoutes(router, handlers.Events, authMiddleware, optionalAuth)
 	SetupGroupRoutes(router, handlers.Groups, authMiddleware, optionalAuth)
 	SetupHashtagRoutes(router, handlers.Hashtags, authMiddleware, optionalAuth)
 	SetupLiveRoutes(router, handlers.Live, authMiddleware, optionalAuth)
-	SetupMediaRoutes(router, handlers.Media, authMiddleware, optionalAuth)
-	SetupMessageRoutes(router, handlers.Messages, authMiddleware)
-	SetupNotificationRoutes(router, handlers.Notifications, authMiddleware)
-	SetupPostRoutes(router, handlers.Posts, authMiddleware, optionalAuth)
+	SetupMediaRoutes(router, handlers.Media, mediaAuth, mediaOptionalAuth)
+	SetupMessageRoutes(router, handlers.Messages, messageAuth)
+	SetupNotificationRoutes(router, handlers.Notifications, notificationAuth)
+	SetupPostRoutes(router, handlers.Posts, postAuth, postOptionalAuth)
 	SetupSearchRoutes(router, handlers.Search, authMiddleware, optionalAuth)
 	SetupStoryRoutes(router, handlers.Stories, authMiddleware, optionalAuth)
-	SetupUserRoutes(router, handlers.Users, authMiddleware, optionalAuth)
+	SetupUserRoutes(router, handlers.Users, userAuth, userOptionalAuth)
 
 	// Setup static file serving if enabled
 	if config.ServeStaticFiles {
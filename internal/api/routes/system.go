@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/Caqil/vyrall/internal/api/handlers/admin"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+// errSystemUnsupported is returned by the system operations that have no
+// backing service yet
+var errSystemUnsupported = errors.New("system operation not supported")
+
+// jobQueueSystem serves the admin system endpoints from the job queue. Only
+// the job queue operations are backed; the rest report that they are not
+// supported, and their routes are not registered.
+type jobQueueSystem struct {
+	*queue.RedisQueue
+}
+
+var _ admin.SystemService = jobQueueSystem{}
+
+func (jobQueueSystem) GetSystemInfo() (map[string]interface{}, error) {
+	return nil, errSystemUnsupported
+}
+
+func (jobQueueSystem) GetServiceStatus() (map[string]interface{}, error) {
+	return nil, errSystemUnsupported
+}
+
+func (jobQueueSystem) RestartService(service string) error {
+	return errSystemUnsupported
+}
+
+func (jobQueueSystem) GetSystemLogs(service, level string, limit, offset int) ([]map[string]interface{}, int, error) {
+	return nil, 0, errSystemUnsupported
+}
+
+func (jobQueueSystem) GetSystemMetrics(metric string, duration string) ([]map[string]interface{}, error) {
+	return nil, errSystemUnsupported
+}
+
+func (jobQueueSystem) GetDatabaseStats() (map[string]interface{}, error) {
+	return nil, errSystemUnsupported
+}
+
+func (jobQueueSystem) RunHealthCheck() (map[string]interface{}, error) {
+	return nil, errSystemUnsupported
+}
+
+func (jobQueueSystem) GetCacheStats() (map[string]interface{}, error) {
+	return nil, errSystemUnsupported
+}
+
+func (jobQueueSystem) ClearCache(cache string) error {
+	return errSystemUnsupported
+}
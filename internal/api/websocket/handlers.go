@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -15,7 +16,7 @@ import (
 // WebSocketHandler manages the websocket connections and handlers
 type WebSocketHandler struct {
 	hub         *Hub
-	authService *auth.Service
+	authService auth.Service
 	services    *services.Services
 	upgrader    websocket.Upgrader
 }
@@ -57,7 +58,12 @@ func (h *WebSocketHandler) initializeHandlers() {
 	h.hub.presenceHandler = NewPresenceHandler(h.hub, h.services.UserService)
 	h.hub.roomsHandler = NewRoomsHandler(h.hub)
 	h.hub.notificationsHandler = NewNotificationsHandler(h.hub, h.services.NotificationService)
-	h.hub.liveStreamHandler = NewLiveStreamHandler(h.hub, h.services.LiveStreamService)
+	h.hub.liveStreamHandler = NewLiveStreamHandler(h.hub, h.services.LiveStreamService, h.services.PermissionService)
+}
+
+// Shutdown drains connected clients and stops the hub
+func (h *WebSocketHandler) Shutdown(ctx context.Context) error {
+	return h.hub.Shutdown(ctx)
 }
 
 // HandleWebSocket handles the websocket connections
@@ -118,13 +124,14 @@ func (h *WebSocketHandler) extractUserID(r *http.Request) (primitive.ObjectID, e
 		return primitive.NilObjectID, ErrUnauthorized
 	}
 
-	// Validate the token and get the user ID
-	userID, err := h.authService.ValidateToken(r.Context(), token)
-	if err != nil {
+	// Validate the token and get the user ID. Messages on a socket cannot be
+	// audited one by one, so staff impersonating a user may not connect.
+	accessToken, err := h.authService.Authenticate(r.Context(), token)
+	if err != nil || accessToken.IsImpersonation() {
 		return primitive.NilObjectID, ErrUnauthorized
 	}
 
-	return userID, nil
+	return accessToken.UserID, nil
 }
 
 // sendInitialData sends initial data to the client
@@ -1,12 +1,14 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -36,6 +38,13 @@ type Hub struct {
 	// Mutex to protect the maps
 	mu sync.RWMutex
 
+	// Set once shutdown starts; new clients are turned away
+	draining bool
+
+	// Closed to stop the run loop
+	done     chan struct{}
+	stopOnce sync.Once
+
 	// Handlers
 	chatHandler          *ChatHandler
 	typingHandler        *TypingHandler
@@ -53,6 +62,7 @@ func NewHub() *Hub {
 		Unregister:  make(chan *Client),
 		clients:     make(map[*Client]bool),
 		userClients: make(map[primitive.ObjectID][]*Client),
+		done:        make(chan struct{}),
 	}
 }
 
@@ -66,8 +76,60 @@ func (h *Hub) Run() {
 			h.unregisterClient(client)
 		case message := <-h.Broadcast:
 			h.broadcastMessage(message)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// Shutdown drains the hub: it stops accepting clients, asks every connected
+// client to close, and waits for them to unregister until ctx expires.
+// Connections still open after that are closed forcibly.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	// Ask clients to go away; their read pumps then unregister them
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, client := range clients {
+		_ = client.Conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait))
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	var err error
+	for h.ClientCount() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+			h.mu.RLock()
+			for client := range h.clients {
+				client.Conn.Close()
+			}
+			h.mu.RUnlock()
+		}
+		if err != nil {
+			break
 		}
 	}
+
+	h.stopOnce.Do(func() { close(h.done) })
+	return err
+}
+
+// ClientCount returns the number of connected clients
+func (h *Hub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.clients)
 }
 
 // registerClient registers a client
@@ -75,6 +137,12 @@ func (h *Hub) registerClient(client *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	// Turn clients away while shutting down
+	if h.draining {
+		client.Conn.Close()
+		return
+	}
+
 	// Register the client
 	h.clients[client] = true
 
@@ -2,10 +2,13 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/Caqil/vyrall/internal/services/livestream"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/pkg/constants"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -13,13 +16,15 @@ import (
 type LiveStreamHandler struct {
 	hub               *Hub
 	liveStreamService *livestream.Service
+	permissionService *permission.Service
 }
 
 // NewLiveStreamHandler creates a new live stream handler
-func NewLiveStreamHandler(hub *Hub, liveStreamService *livestream.Service) *LiveStreamHandler {
+func NewLiveStreamHandler(hub *Hub, liveStreamService *livestream.Service, permissionService *permission.Service) *LiveStreamHandler {
 	return &LiveStreamHandler{
 		hub:               hub,
 		liveStreamService: liveStreamService,
+		permissionService: permissionService,
 	}
 }
 
@@ -53,6 +58,14 @@ func (h *LiveStreamHandler) ProcessStreamEvent(client *Client, data []byte) {
 		return
 	}
 
+	// Viewers banned from the stream may not join, comment or react
+	switch event.Action {
+	case constants.ActionJoin, constants.ActionComment, constants.ActionReact:
+		if !h.authorize(client, streamID, event.Action) {
+			return
+		}
+	}
+
 	// Process different event types
 	switch event.Action {
 	case "join":
@@ -68,6 +81,26 @@ func (h *LiveStreamHandler) ProcessStreamEvent(client *Client, data []byte) {
 	}
 }
 
+// authorize checks that the client may perform action on a stream, and
+// tells the client when it may not
+func (h *LiveStreamHandler) authorize(client *Client, streamID primitive.ObjectID, action string) bool {
+	allowed, err := h.permissionService.Can(client.ctx, client.UserID, action, permission.Resource{Type: constants.ResourceTypeLiveStream, ID: streamID})
+	if errors.Is(err, permission.ErrResourceNotFound) {
+		client.SendErrorMessage("Stream not found")
+		return false
+	}
+	if err != nil {
+		log.Printf("Error checking stream permission: %v", err)
+		client.SendErrorMessage("Failed to check permission")
+		return false
+	}
+	if !allowed {
+		client.SendErrorMessage("Permission denied")
+		return false
+	}
+	return true
+}
+
 // handleJoinStream processes a user joining a stream
 func (h *LiveStreamHandler) handleJoinStream(client *Client, streamID primitive.ObjectID) {
 	// Add user to stream viewers
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+)
+
+// Database wraps a MongoDB database with collection-name based helpers
+type Database struct {
+	client *mongo.Client
+	db     *mongo.Database
+}
+
+// Connect opens a MongoDB connection and selects the named database
+func Connect(ctx context.Context, uri, name string) (*Database, error) {
+	client, err := dbmongo.Connect(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Database{
+		client: client,
+		db:     client.Database(name),
+	}, nil
+}
+
+// DB returns the underlying database handle
+func (d *Database) DB() *mongo.Database {
+	return d.db
+}
+
+// Client returns the underlying client, e.g. for starting sessions
+func (d *Database) Client() *mongo.Client {
+	return d.client
+}
+
+// Collection returns a handle to the named collection
+func (d *Database) Collection(name string) *mongo.Collection {
+	return d.db.Collection(name)
+}
+
+// InsertOne inserts a single document
+func (d *Database) InsertOne(ctx context.Context, collection string, document interface{}) (*mongo.InsertOneResult, error) {
+	return d.db.Collection(collection).InsertOne(ctx, document)
+}
+
+// InsertMany inserts several documents
+func (d *Database) InsertMany(ctx context.Context, collection string, documents []interface{}) error {
+	_, err := d.db.Collection(collection).InsertMany(ctx, documents)
+	return err
+}
+
+// FindOne decodes the first document matching filter into result
+func (d *Database) FindOne(ctx context.Context, collection string, filter interface{}, result interface{}) error {
+	return d.db.Collection(collection).FindOne(ctx, filter).Decode(result)
+}
+
+// Find decodes every document matching filter into results, which must be a pointer to a slice
+func (d *Database) Find(ctx context.Context, collection string, filter interface{}, results interface{}) error {
+	cursor, err := d.db.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// CountDocuments counts the documents matching filter
+func (d *Database) CountDocuments(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	return d.db.Collection(collection).CountDocuments(ctx, filter)
+}
+
+// Aggregate runs a pipeline and returns every resulting document
+func (d *Database) Aggregate(ctx context.Context, collection string, pipeline interface{}) ([]bson.M, error) {
+	cursor, err := d.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []bson.M{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateOne applies update to the first document matching filter
+func (d *Database) UpdateOne(ctx context.Context, collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return d.db.Collection(collection).UpdateOne(ctx, filter, update)
+}
+
+// DeleteOne deletes the first document matching filter
+func (d *Database) DeleteOne(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	return d.db.Collection(collection).DeleteOne(ctx, filter)
+}
+
+// Close disconnects the client and releases its connection pool
+func (d *Database) Close(ctx context.Context) error {
+	return d.client.Disconnect(ctx)
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+)
+
+// MigrateUsage describes the migrate subcommand
+const MigrateUsage = `usage: migrate <command> [argument]
+
+commands:
+  status          list migrations and whether they are applied
+  up [version]    apply pending migrations, optionally stopping at version
+  down [steps]    roll back the last applied migration, or the last steps migrations
+`
+
+// RunMigrate executes a migrate subcommand against db and writes a report to out
+func RunMigrate(ctx context.Context, db *mongo.Database, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", MigrateUsage)
+	}
+
+	migrator, err := dbmongo.NewMigrator(db, dbmongo.Migrations)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(ctx, migrator, out)
+
+	case "up":
+		target, err := optionalIntArg(args, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, target)
+		for _, version := range applied {
+			fmt.Fprintf(out, "applied %d\n", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return nil
+
+	case "down":
+		steps, err := optionalIntArg(args, 1)
+		if err != nil {
+			return err
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, version := range rolledBack {
+			fmt.Fprintf(out, "rolled back %d\n", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			fmt.Fprintln(out, "no applied migrations")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], MigrateUsage)
+	}
+}
+
+// EnsureMigrated applies any pending migrations, for use at server startup
+func EnsureMigrated(ctx context.Context, db *mongo.Database) ([]int, error) {
+	migrator, err := dbmongo.NewMigrator(db, dbmongo.Migrations)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.Up(ctx, 0)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *dbmongo.Migrator, out io.Writer) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, state, appliedAt, status.Description)
+	}
+	return w.Flush()
+}
+
+func optionalIntArg(args []string, fallback int) (int, error) {
+	if len(args) < 2 {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(args[1])
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %s argument %q: must be a non-negative number", args[0], args[1])
+	}
+	return value, nil
+}
@@ -0,0 +1,378 @@
+package mongodb
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// baselineCollections are the collections migration 1 put validators on.
+// Collections added since are set up by the migration that introduced them.
+var baselineCollections = []string{
+	constants.CollectionUsers,
+	constants.CollectionSessions,
+	constants.CollectionUserSessions,
+	constants.CollectionVerifications,
+	constants.CollectionPosts,
+	constants.CollectionComments,
+	constants.CollectionLikes,
+	constants.CollectionShares,
+	constants.CollectionBookmarks,
+	constants.CollectionFollows,
+	constants.CollectionFriendships,
+	constants.CollectionHashtags,
+	constants.CollectionHashtagFollows,
+	constants.CollectionMedia,
+	constants.CollectionStories,
+	constants.CollectionConversations,
+	constants.CollectionMessages,
+	constants.CollectionNotifications,
+	constants.CollectionGroups,
+	constants.CollectionGroupMembers,
+	constants.CollectionEvents,
+	constants.CollectionEventAttendees,
+	constants.CollectionEventReminders,
+	constants.CollectionLiveStreams,
+	constants.CollectionReports,
+	constants.CollectionAnalyticsEvents,
+}
+
+// baselineIndexes are the indexes migration 2 created, as they were declared
+// then. Later migrations add and replace indexes of their own, so migration 2
+// keeps this copy rather than reading Indexes.
+var baselineIndexes = map[string][]mongo.IndexModel{
+	constants.CollectionUsers: {
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "username", Value: "text"}, {Key: "display_name", Value: "text"}, {Key: "bio", Value: "text"}},
+			Options: options.Index().
+				SetName("users_text").
+				SetWeights(bson.D{{Key: "username", Value: 10}, {Key: "display_name", Value: 5}, {Key: "bio", Value: 1}}),
+		},
+	},
+	constants.CollectionSessions: {
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "refresh_token", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "is_active", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("sessions_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionUserSessions: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "start_time", Value: -1}}},
+		{Keys: bson.D{{Key: "session_id", Value: 1}}},
+	},
+	constants.CollectionVerifications: {
+		{Keys: bson.D{{Key: "verification_code", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	},
+	constants.CollectionPosts: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "published_at", Value: -1}}},
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "published_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "hashtags", Value: 1}, {Key: "published_at", Value: -1}}},
+		{Keys: bson.D{{Key: "published_at", Value: -1}}},
+		{Keys: bson.D{{Key: "scheduled_for", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "location.coordinates", Value: "2dsphere"}}},
+		{
+			Keys: bson.D{{Key: "content", Value: "text"}, {Key: "hashtags", Value: "text"}},
+			Options: options.Index().
+				SetName("posts_text").
+				SetWeights(bson.D{{Key: "hashtags", Value: 5}, {Key: "content", Value: 1}}),
+		},
+	},
+	constants.CollectionComments: {
+		{Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionLikes: {
+		{Keys: bson.D{{Key: "content_id", Value: 1}, {Key: "content_type", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionShares: {
+		{Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionBookmarks: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "post_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "collection_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionFollows: {
+		{Keys: bson.D{{Key: "follower_id", Value: 1}, {Key: "following_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "following_id", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	constants.CollectionFriendships: {
+		{Keys: bson.D{{Key: "user_id_1", Value: 1}, {Key: "user_id_2", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id_2", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	constants.CollectionHashtags: {
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "is_trending", Value: 1}, {Key: "trending_rank", Value: 1}}},
+	},
+	constants.CollectionHashtagFollows: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "hashtag_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "hashtag_id", Value: 1}}},
+	},
+	constants.CollectionMedia: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionStories: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "expires_at", Value: -1}}},
+		{Keys: bson.D{{Key: "highlight_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	constants.CollectionConversations: {
+		{Keys: bson.D{{Key: "participants.user_id", Value: 1}, {Key: "last_message_at", Value: -1}}},
+	},
+	constants.CollectionMessages: {
+		{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "reply_to_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// Disappearing messages are removed by the TTL monitor once expires_at has passed
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("messages_ttl").SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "content", Value: "text"}}, Options: options.Index().SetName("messages_text")},
+	},
+	constants.CollectionNotifications: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "is_read", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "group_key", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("notifications_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionGroups: {
+		{Keys: bson.D{{Key: "creator_id", Value: 1}}},
+		{Keys: bson.D{{Key: "categories", Value: 1}, {Key: "member_count", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}, {Key: "tags", Value: "text"}},
+			Options: options.Index().SetName("groups_text"),
+		},
+	},
+	constants.CollectionGroupMembers: {
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "is_active", Value: 1}}},
+	},
+	constants.CollectionEvents: {
+		{Keys: bson.D{{Key: "host_id", Value: 1}, {Key: "start_time", Value: -1}}},
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "start_time", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "location.coordinates", Value: "2dsphere"}}, Options: options.Index().SetSparse(true)},
+	},
+	constants.CollectionEventAttendees: {
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "rsvp", Value: 1}}},
+	},
+	constants.CollectionEventReminders: {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "reminder_time", Value: 1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "user_id", Value: 1}}},
+	},
+	constants.CollectionLiveStreams: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "viewer_count", Value: -1}}},
+		{Keys: bson.D{{Key: "stream_key", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	},
+	constants.CollectionReports: {
+		{Keys: bson.D{{Key: "content_type", Value: 1}, {Key: "status", Value: 1}, {Key: "content_id", Value: 1}}},
+		{Keys: bson.D{{Key: "reporter_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionAnalyticsEvents: {
+		{Keys: bson.D{{Key: "event_type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+}
+
+// refreshTokenHashIndexes are the session indexes migration 5 created. Like
+// the baseline, indexes a migration adds to an existing collection are
+// pinned here rather than read from Indexes.
+var refreshTokenHashIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	{Keys: bson.D{{Key: "rotated_token_hashes", Value: 1}}},
+}
+
+// deletionScheduledIndexes are the user indexes migration 13 created
+var deletionScheduledIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "deletion_scheduled_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+}
+
+// collectionSchema is a collection as the migration that created it left it:
+// the model its validator was generated from and the indexes it was given
+type collectionSchema struct {
+	model   interface{}
+	indexes []mongo.IndexModel
+}
+
+// bookmarkFolderSchemas are the collections migration 3 created
+var bookmarkFolderSchemas = map[string]collectionSchema{
+	constants.CollectionBookmarkFolders: {
+		model: models.BookmarkCollection{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	},
+}
+
+// outboxSchemas are the collections migration 4 created
+var outboxSchemas = map[string]collectionSchema{
+	constants.CollectionOutbox: {
+		model: models.OutboxMessage{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "available_at", Value: 1}, {Key: "occurred_at", Value: 1}}},
+			// Published messages are kept for a week to help trace deliveries, then removed
+			{Keys: bson.D{{Key: "published_at", Value: 1}}, Options: options.Index().SetName("outbox_ttl").SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+		},
+	},
+}
+
+// signingKeySchemas are the collections migration 6 created
+var signingKeySchemas = map[string]collectionSchema{
+	constants.CollectionSigningKeys: {
+		model: models.SigningKey{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("signing_keys_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+}
+
+// passkeySchemas are the collections migration 7 created
+var passkeySchemas = map[string]collectionSchema{
+	constants.CollectionPasskeys: {
+		model: models.Passkey{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "credential_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+	},
+	constants.CollectionPasskeyChallenges: {
+		model: models.PasskeyChallenge{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("passkey_challenges_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+}
+
+// loginGuardSchemas are the collections migration 8 created
+var loginGuardSchemas = map[string]collectionSchema{
+	constants.CollectionLoginFailures: {
+		model: models.LoginFailure{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "ip_address", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "subnet", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("login_failures_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+	constants.CollectionLoginLockouts: {
+		model: models.LoginLockout{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			// Lockouts are removed once they lapse; the failures that caused them expire on their own
+			{Keys: bson.D{{Key: "locked_until", Value: 1}}, Options: options.Index().SetName("login_lockouts_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+	constants.CollectionModerationLog: {
+		model: models.ModerationLogEntry{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+	},
+}
+
+// oauthServerSchemas are the collections migration 9 created
+var oauthServerSchemas = map[string]collectionSchema{
+	constants.CollectionOAuthApps: {
+		model: models.OAuthApp{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+	},
+	constants.CollectionOAuthCodes: {
+		model: models.OAuthAuthorizationCode{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("oauth_codes_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+	constants.CollectionOAuthConsents: {
+		model: models.OAuthConsent{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+		},
+	},
+	constants.CollectionOAuthTokens: {
+		model: models.OAuthToken{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+			{Keys: bson.D{{Key: "rotated_token_hashes", Value: 1}}},
+			{Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "user_id", Value: 1}}},
+			// Tokens are kept for a day after they lapse so a replayed refresh token is still recognised
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("oauth_tokens_ttl").SetExpireAfterSeconds(24 * 60 * 60)},
+		},
+	},
+}
+
+// personalTokenSchemas are the collections migration 10 created
+var personalTokenSchemas = map[string]collectionSchema{
+	constants.CollectionPersonalTokens: {
+		model: models.PersonalAccessToken{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+	},
+	constants.CollectionPersonalTokenUsage: {
+		model: models.PersonalAccessTokenUsage{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "token_id", Value: 1}, {Key: "day", Value: -1}, {Key: "ip_address", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("personal_token_usage_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+}
+
+// loginHistorySchemas are the collections migration 11 created
+var loginHistorySchemas = map[string]collectionSchema{
+	constants.CollectionLoginHistory: {
+		model: models.LoginRecord{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "device_key", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "country_code", Value: 1}}, Options: options.Index().SetSparse(true)},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("login_history_ttl").SetExpireAfterSeconds(0)},
+		},
+	},
+}
+
+// adminAuditSchemas are the collections migration 12 created
+var adminAuditSchemas = map[string]collectionSchema{
+	constants.CollectionAdminAudit: {
+		model: models.AdminAuditEntry{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+	},
+}
+
+// accountPurgeSchemas are the collections migration 13 created
+var accountPurgeSchemas = map[string]collectionSchema{
+	constants.CollectionAccountPurgeReports: {
+		model: models.AccountPurgeReport{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "started_at", Value: -1}}},
+		},
+	},
+}
+
+// twoFactorSchemas are the collections migration 14 created
+var twoFactorSchemas = map[string]collectionSchema{
+	constants.CollectionTwoFactorSecrets: {
+		model: models.TwoFactorSecret{},
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	},
+}
@@ -0,0 +1,306 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Indexes declares the indexes each collection needs, keyed by collection name
+var Indexes = map[string][]mongo.IndexModel{
+	constants.CollectionUsers: {
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "deletion_scheduled_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{
+			Keys: bson.D{{Key: "username", Value: "text"}, {Key: "display_name", Value: "text"}, {Key: "bio", Value: "text"}},
+			Options: options.Index().
+				SetName("users_text").
+				SetWeights(bson.D{{Key: "username", Value: 10}, {Key: "display_name", Value: 5}, {Key: "bio", Value: 1}}),
+		},
+	},
+	constants.CollectionSessions: {
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		{Keys: bson.D{{Key: "rotated_token_hashes", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "is_active", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("sessions_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionUserSessions: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "start_time", Value: -1}}},
+		{Keys: bson.D{{Key: "session_id", Value: 1}}},
+	},
+	constants.CollectionVerifications: {
+		{Keys: bson.D{{Key: "verification_code", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	},
+	constants.CollectionPosts: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "published_at", Value: -1}}},
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "published_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "hashtags", Value: 1}, {Key: "published_at", Value: -1}}},
+		{Keys: bson.D{{Key: "published_at", Value: -1}}},
+		{Keys: bson.D{{Key: "scheduled_for", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "location.coordinates", Value: "2dsphere"}}},
+		{
+			Keys: bson.D{{Key: "content", Value: "text"}, {Key: "hashtags", Value: "text"}},
+			Options: options.Index().
+				SetName("posts_text").
+				SetWeights(bson.D{{Key: "hashtags", Value: 5}, {Key: "content", Value: 1}}),
+		},
+	},
+	constants.CollectionComments: {
+		{Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionLikes: {
+		{Keys: bson.D{{Key: "content_id", Value: 1}, {Key: "content_type", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionShares: {
+		{Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionBookmarks: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "post_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "collection_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionBookmarkFolders: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	constants.CollectionFollows: {
+		{Keys: bson.D{{Key: "follower_id", Value: 1}, {Key: "following_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "following_id", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	constants.CollectionFriendships: {
+		{Keys: bson.D{{Key: "user_id_1", Value: 1}, {Key: "user_id_2", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id_2", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	constants.CollectionHashtags: {
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "is_trending", Value: 1}, {Key: "trending_rank", Value: 1}}},
+	},
+	constants.CollectionHashtagFollows: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "hashtag_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "hashtag_id", Value: 1}}},
+	},
+	constants.CollectionMedia: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionStories: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "expires_at", Value: -1}}},
+		{Keys: bson.D{{Key: "highlight_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	constants.CollectionConversations: {
+		{Keys: bson.D{{Key: "participants.user_id", Value: 1}, {Key: "last_message_at", Value: -1}}},
+	},
+	constants.CollectionMessages: {
+		{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "reply_to_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// Disappearing messages are removed by the TTL monitor once expires_at has passed
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("messages_ttl").SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "content", Value: "text"}}, Options: options.Index().SetName("messages_text")},
+	},
+	constants.CollectionNotifications: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "is_read", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "group_key", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("notifications_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionGroups: {
+		{Keys: bson.D{{Key: "creator_id", Value: 1}}},
+		{Keys: bson.D{{Key: "categories", Value: 1}, {Key: "member_count", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}, {Key: "tags", Value: "text"}},
+			Options: options.Index().SetName("groups_text"),
+		},
+	},
+	constants.CollectionGroupMembers: {
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "is_active", Value: 1}}},
+	},
+	constants.CollectionEvents: {
+		{Keys: bson.D{{Key: "host_id", Value: 1}, {Key: "start_time", Value: -1}}},
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "start_time", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "location.coordinates", Value: "2dsphere"}}, Options: options.Index().SetSparse(true)},
+	},
+	constants.CollectionEventAttendees: {
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "rsvp", Value: 1}}},
+	},
+	constants.CollectionEventReminders: {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "reminder_time", Value: 1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "user_id", Value: 1}}},
+	},
+	constants.CollectionLiveStreams: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "viewer_count", Value: -1}}},
+		{Keys: bson.D{{Key: "stream_key", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	},
+	constants.CollectionReports: {
+		{Keys: bson.D{{Key: "content_type", Value: 1}, {Key: "status", Value: 1}, {Key: "content_id", Value: 1}}},
+		{Keys: bson.D{{Key: "reporter_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionAnalyticsEvents: {
+		{Keys: bson.D{{Key: "event_type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionOutbox: {
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "available_at", Value: 1}, {Key: "occurred_at", Value: 1}}},
+		// Published messages are kept for a week to help trace deliveries, then removed
+		{Keys: bson.D{{Key: "published_at", Value: 1}}, Options: options.Index().SetName("outbox_ttl").SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+	},
+	constants.CollectionSigningKeys: {
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("signing_keys_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionPasskeys: {
+		{Keys: bson.D{{Key: "credential_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	constants.CollectionPasskeyChallenges: {
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("passkey_challenges_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionLoginFailures: {
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "ip_address", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "subnet", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("login_failures_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionLoginLockouts: {
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		// Lockouts are removed once they lapse; the failures that caused them expire on their own
+		{Keys: bson.D{{Key: "locked_until", Value: 1}}, Options: options.Index().SetName("login_lockouts_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionModerationLog: {
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionOAuthApps: {
+		{Keys: bson.D{{Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	constants.CollectionOAuthCodes: {
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("oauth_codes_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionOAuthConsents: {
+		{Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+	},
+	constants.CollectionOAuthTokens: {
+		{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		{Keys: bson.D{{Key: "rotated_token_hashes", Value: 1}}},
+		{Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "user_id", Value: 1}}},
+		// Tokens are kept for a day after they lapse so a replayed refresh token is still recognised
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("oauth_tokens_ttl").SetExpireAfterSeconds(24 * 60 * 60)},
+	},
+	constants.CollectionPersonalTokens: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionPersonalTokenUsage: {
+		{Keys: bson.D{{Key: "token_id", Value: 1}, {Key: "day", Value: -1}, {Key: "ip_address", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("personal_token_usage_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionLoginHistory: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "device_key", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "country_code", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("login_history_ttl").SetExpireAfterSeconds(0)},
+	},
+	constants.CollectionAdminAudit: {
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	constants.CollectionAccountPurgeReports: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "started_at", Value: -1}}},
+	},
+	constants.CollectionTwoFactorSecrets: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+}
+
+// EnsureIndexes creates the declared indexes for a collection.
+// Creating an index that already exists with the same definition is a no-op.
+func EnsureIndexes(ctx context.Context, db *mongo.Database, collection string) error {
+	return createIndexes(ctx, db, collection, Indexes[collection])
+}
+
+// DropIndexes removes the declared indexes from a collection, leaving any others in place
+func DropIndexes(ctx context.Context, db *mongo.Database, collection string) error {
+	return dropIndexes(ctx, db, collection, Indexes[collection])
+}
+
+// createIndexes creates the given indexes on a collection
+func createIndexes(ctx context.Context, db *mongo.Database, collection string, models []mongo.IndexModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("create indexes on %s: %w", collection, err)
+	}
+
+	return nil
+}
+
+// dropIndexes removes the given indexes from a collection, ignoring any that do not exist
+func dropIndexes(ctx context.Context, db *mongo.Database, collection string, models []mongo.IndexModel) error {
+	for _, model := range models {
+		name, err := IndexName(model)
+		if err != nil {
+			return err
+		}
+		if err := dropIndex(ctx, db, collection, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropIndex removes one index by name, ignoring it if it does not exist
+func dropIndex(ctx context.Context, db *mongo.Database, collection, name string) error {
+	if _, err := db.Collection(collection).Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("drop index %s on %s: %w", name, collection, err)
+	}
+	return nil
+}
+
+// IndexName returns the name MongoDB gives an index: the explicit name when set,
+// otherwise the keys and directions joined with underscores
+func IndexName(model mongo.IndexModel) (string, error) {
+	if model.Options != nil && model.Options.Name != nil {
+		return *model.Options.Name, nil
+	}
+
+	keys, ok := model.Keys.(bson.D)
+	if !ok {
+		return "", fmt.Errorf("index keys must be a bson.D, got %T", model.Keys)
+	}
+
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_"), nil
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 27 || cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound"
+	}
+	return false
+}
@@ -0,0 +1,524 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+const (
+	// MigrationsCollection records which migrations have been applied
+	MigrationsCollection = "schema_migrations"
+
+	// migrationLockID is the document used to stop two migrators running at once
+	migrationLockID = "lock"
+
+	// migrationLockTTL is how long a lock is honoured before it is considered abandoned
+	migrationLockTTL = 10 * time.Minute
+)
+
+// ErrMigrationLocked is returned when another process is already running migrations
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+// migrationRecord is the document stored for each applied migration
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Migrations is the ordered list of schema changes. Append new migrations
+// with the next version number; never renumber or edit an applied one.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create collections with JSON-schema validators",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			for _, collection := range baselineCollections {
+				if err := ApplyValidator(ctx, db, collection); err != nil {
+					return fmt.Errorf("apply validator on %s: %w", collection, err)
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, collection := range baselineCollections {
+				if err := RemoveValidator(ctx, db, collection); err != nil {
+					return fmt.Errorf("remove validator on %s: %w", collection, err)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "create collection indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			for _, collection := range sortedKeys(baselineIndexes) {
+				if err := createIndexes(ctx, db, collection, baselineIndexes[collection]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, collection := range sortedKeys(baselineIndexes) {
+				if err := dropIndexes(ctx, db, collection, baselineIndexes[collection]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "create bookmark collections with validator and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, bookmarkFolderSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, bookmarkFolderSchemas)
+		},
+	},
+	{
+		// Transactions cannot always create collections, so the outbox must exist up front
+		Version:     4,
+		Description: "create event outbox with validator and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, outboxSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, outboxSchemas)
+		},
+	},
+	{
+		// Hashes cannot be reversed, so after rolling back every session must sign in again
+		Version:     5,
+		Description: "store refresh tokens as hashes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := applyValidator(ctx, db, constants.CollectionSessions, models.Session{}); err != nil {
+				return fmt.Errorf("apply validator on %s: %w", constants.CollectionSessions, err)
+			}
+			if err := hashRefreshTokens(ctx, db.Collection(constants.CollectionSessions)); err != nil {
+				return fmt.Errorf("hash refresh tokens: %w", err)
+			}
+			if err := dropIndex(ctx, db, constants.CollectionSessions, "refresh_token_1"); err != nil {
+				return err
+			}
+			return createIndexes(ctx, db, constants.CollectionSessions, refreshTokenHashIndexes)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, constants.CollectionSessions, refreshTokenHashIndexes); err != nil {
+				return err
+			}
+			sessions := db.Collection(constants.CollectionSessions)
+			if _, err := sessions.UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"refresh_token_hash": "", "rotated_token_hashes": ""}},
+			); err != nil {
+				return err
+			}
+			_, err := sessions.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "refresh_token", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		Version:     6,
+		Description: "create token signing keys with validator and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, signingKeySchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, signingKeySchemas)
+		},
+	},
+	{
+		Version:     7,
+		Description: "create passkeys and passkey challenges with validators and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, passkeySchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, passkeySchemas)
+		},
+	},
+	{
+		Version:     8,
+		Description: "create login failures, login lockouts and the moderation log with validators and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, loginGuardSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, loginGuardSchemas)
+		},
+	},
+	{
+		Version:     9,
+		Description: "create third-party apps, authorization codes, consents and tokens with validators and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, oauthServerSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, oauthServerSchemas)
+		},
+	},
+	{
+		Version:     10,
+		Description: "create personal access tokens and their usage log with validators and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, personalTokenSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, personalTokenSchemas)
+		},
+	},
+	{
+		Version:     11,
+		Description: "create login history with validator and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, loginHistorySchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, loginHistorySchemas)
+		},
+	},
+	{
+		Version:     12,
+		Description: "create the admin audit log with validator and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, adminAuditSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, adminAuditSchemas)
+		},
+	},
+	{
+		Version:     13,
+		Description: "index users awaiting deletion and create the account purge reports",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := createIndexes(ctx, db, constants.CollectionUsers, deletionScheduledIndexes); err != nil {
+				return err
+			}
+			return applySchemas(ctx, db, accountPurgeSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, constants.CollectionUsers, deletionScheduledIndexes); err != nil {
+				return err
+			}
+			return removeSchemas(ctx, db, accountPurgeSchemas)
+		},
+	},
+	{
+		Version:     14,
+		Description: "create two-factor secrets with validator and indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applySchemas(ctx, db, twoFactorSchemas)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return removeSchemas(ctx, db, twoFactorSchemas)
+		},
+	},
+}
+
+// Migrator applies and rolls back migrations, recording progress in the database
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+	collection *mongo.Collection
+	locks      *mongo.Collection
+}
+
+// NewMigrator creates a migrator for the given migrations, sorted by version
+func NewMigrator(db *mongo.Database, migrations []Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", migration.Description, migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+		if migration.Up == nil || migration.Down == nil {
+			return nil, fmt.Errorf("migration %d must define both up and down steps", migration.Version)
+		}
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		collection: db.Collection(MigrationsCollection),
+		locks:      db.Collection(MigrationsCollection + "_lock"),
+	}, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Up applies pending migrations in order up to and including target.
+// A target of 0 applies everything. It returns the versions that were applied.
+func (m *Migrator) Up(ctx context.Context, target int) ([]int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) up: %w", migration.Version, migration.Description, err)
+		}
+
+		record := migrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		if _, err := m.collection.InsertOne(ctx, record); err != nil {
+			return done, fmt.Errorf("record migration %d: %w", migration.Version, err)
+		}
+
+		done = append(done, migration.Version)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the most recently applied migrations, newest first.
+// It returns the versions that were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	if steps <= 0 {
+		return nil, nil
+	}
+
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if err := migration.Down(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) down: %w", migration.Version, migration.Description, err)
+		}
+
+		if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+			return done, fmt.Errorf("unrecord migration %d: %w", migration.Version, err)
+		}
+
+		done = append(done, migration.Version)
+	}
+
+	return done, nil
+}
+
+// Pending reports how many known migrations have not been applied
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]migrationRecord, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]migrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// lock takes the migration lock, replacing it if the previous holder abandoned it
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	now := time.Now()
+	owner, _ := os.Hostname()
+	owner = fmt.Sprintf("%s:%d:%d", owner, os.Getpid(), now.UnixNano())
+
+	_, err := m.locks.UpdateOne(ctx,
+		bson.M{"_id": migrationLockID, "locked_at": bson.M{"$lt": now.Add(-migrationLockTTL)}},
+		bson.M{"$set": bson.M{"owner": owner, "locked_at": now}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrMigrationLocked
+		}
+		return nil, err
+	}
+
+	return func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, _ = m.locks.DeleteOne(releaseCtx, bson.M{"_id": migrationLockID, "owner": owner})
+	}, nil
+}
+
+// hashRefreshTokens replaces plaintext refresh tokens with their SHA-256 hex
+// digest, the form the auth service looks them up by
+func hashRefreshTokens(ctx context.Context, sessions *mongo.Collection) error {
+	cursor, err := sessions.Find(ctx, bson.M{"refresh_token": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"refresh_token": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var session struct {
+			ID           interface{} `bson:"_id"`
+			RefreshToken string      `bson:"refresh_token"`
+		}
+		if err := cursor.Decode(&session); err != nil {
+			return err
+		}
+
+		update := bson.M{"$unset": bson.M{"refresh_token": ""}}
+		if session.RefreshToken != "" {
+			sum := sha256.Sum256([]byte(session.RefreshToken))
+			update["$set"] = bson.M{"refresh_token_hash": hex.EncodeToString(sum[:])}
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": session.ID}).SetUpdate(update))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	_, err = sessions.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// applySchemas installs the validators and creates the indexes of collections
+// as a migration introduced them
+func applySchemas(ctx context.Context, db *mongo.Database, schemas map[string]collectionSchema) error {
+	for _, collection := range sortedKeys(schemas) {
+		schema := schemas[collection]
+		if err := applyValidator(ctx, db, collection, schema.model); err != nil {
+			return fmt.Errorf("apply validator on %s: %w", collection, err)
+		}
+		if err := createIndexes(ctx, db, collection, schema.indexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSchemas undoes applySchemas, dropping the indexes and validators it added
+func removeSchemas(ctx context.Context, db *mongo.Database, schemas map[string]collectionSchema) error {
+	for _, collection := range sortedKeys(schemas) {
+		if err := dropIndexes(ctx, db, collection, schemas[collection].indexes); err != nil {
+			return err
+		}
+		if err := RemoveValidator(ctx, db, collection); err != nil {
+			return fmt.Errorf("remove validator on %s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
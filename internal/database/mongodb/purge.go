@@ -0,0 +1,136 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// PurgeStep is one write that erases part of a deleted account. A step with
+// no Update deletes every document Filter matches; the others strip the
+// account out of documents that belong to other users.
+type PurgeStep struct {
+	Collection string
+	Filter     bson.M
+	Update     bson.M
+}
+
+// AccountPurgeSteps lists the writes that erase userID's data, in the order
+// they run. Every step is safe to run twice and the user document goes last,
+// so a purge that fails part way is retried from the start. Denormalised
+// counters on other users' content, such as like and follower counts, are
+// settled by the nightly counter reconciliation. The moderation log and the
+// admin audit log are kept, as are login failures and lockouts, which expire.
+func AccountPurgeSteps(userID primitive.ObjectID, now time.Time) []PurgeStep {
+	byUser := bson.M{"user_id": userID}
+	deliveryStatus := "delivery_status." + userID.Hex()
+
+	return []PurgeStep{
+		{Collection: constants.CollectionPosts, Filter: byUser},
+		{Collection: constants.CollectionComments, Filter: byUser},
+		{Collection: constants.CollectionLikes, Filter: byUser},
+		{Collection: constants.CollectionShares, Filter: byUser},
+		{Collection: constants.CollectionBookmarks, Filter: byUser},
+		{Collection: constants.CollectionBookmarkFolders, Filter: byUser},
+		{Collection: constants.CollectionStories, Filter: byUser},
+		{
+			// Views of and reactions to other users' stories
+			Collection: constants.CollectionStories,
+			Filter: bson.M{"$or": bson.A{
+				bson.M{"viewers.user_id": userID},
+				bson.M{"reactions.user_id": userID},
+			}},
+			Update: bson.M{"$pull": bson.M{
+				"viewers":   bson.M{"user_id": userID},
+				"reactions": bson.M{"user_id": userID},
+			}},
+		},
+		{Collection: constants.CollectionMedia, Filter: byUser},
+		{
+			// Messages the user sent are emptied and deleted for everyone,
+			// leaving a tombstone so replies in the thread still line up
+			Collection: constants.CollectionMessages,
+			Filter:     bson.M{"sender_id": userID},
+			Update: bson.M{
+				"$set": bson.M{"is_deleted": true, "deleted_at": now, "updated_at": now},
+				"$unset": bson.M{
+					"content":            "",
+					"media_files":        "",
+					"edit_history":       "",
+					"encryption_details": "",
+					"mentioned_users":    "",
+				},
+				"$addToSet": bson.M{"deleted_for": userID},
+			},
+		},
+		{
+			// Messages the user received are deleted for them, and their
+			// reactions, read receipts and delivery status are removed
+			Collection: constants.CollectionMessages,
+			Filter: bson.M{
+				"sender_id": bson.M{"$ne": userID},
+				"$or": bson.A{
+					bson.M{deliveryStatus: bson.M{"$exists": true}},
+					bson.M{"reactions.user_id": userID},
+					bson.M{"read_by_users.user_id": userID},
+				},
+			},
+			Update: bson.M{
+				"$addToSet": bson.M{"deleted_for": userID},
+				"$pull": bson.M{
+					"reactions":     bson.M{"user_id": userID},
+					"read_by_users": bson.M{"user_id": userID},
+				},
+				"$unset": bson.M{deliveryStatus: ""},
+			},
+		},
+		{
+			Collection: constants.CollectionFollows,
+			Filter: bson.M{"$or": bson.A{
+				bson.M{"follower_id": userID},
+				bson.M{"following_id": userID},
+			}},
+		},
+		{
+			Collection: constants.CollectionFriendships,
+			Filter: bson.M{"$or": bson.A{
+				bson.M{"user_id_1": userID},
+				bson.M{"user_id_2": userID},
+			}},
+		},
+		{Collection: constants.CollectionGroupMembers, Filter: byUser},
+		{Collection: constants.CollectionEventAttendees, Filter: byUser},
+		{Collection: constants.CollectionHashtagFollows, Filter: byUser},
+		{
+			// Notifications to the user and about what the user did
+			Collection: constants.CollectionNotifications,
+			Filter: bson.M{"$or": bson.A{
+				bson.M{"user_id": userID},
+				bson.M{"actor": userID},
+			}},
+		},
+		{Collection: constants.CollectionSessions, Filter: byUser},
+		{Collection: constants.CollectionVerifications, Filter: byUser},
+		{Collection: constants.CollectionTwoFactorSecrets, Filter: byUser},
+		{Collection: constants.CollectionPasskeys, Filter: byUser},
+		{Collection: constants.CollectionPersonalTokens, Filter: byUser},
+		{Collection: constants.CollectionOAuthConsents, Filter: byUser},
+		{Collection: constants.CollectionOAuthTokens, Filter: byUser},
+		{Collection: constants.CollectionLoginHistory, Filter: byUser},
+		{
+			// Analytics keep their counts but can no longer be tied to the user
+			Collection: constants.CollectionAnalyticsEvents,
+			Filter:     byUser,
+			Update:     bson.M{"$unset": bson.M{"user_id": ""}, "$set": bson.M{"ip_address": ""}},
+		},
+		{
+			Collection: constants.CollectionUserSessions,
+			Filter:     byUser,
+			Update:     bson.M{"$unset": bson.M{"user_id": ""}, "$set": bson.M{"ip_address": ""}},
+		},
+		{Collection: constants.CollectionUsers, Filter: bson.M{"_id": userID}},
+	}
+}
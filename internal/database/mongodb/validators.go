@@ -0,0 +1,270 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Validators maps each collection to the model whose shape its documents must follow
+var Validators = map[string]interface{}{
+	constants.CollectionUsers:           models.User{},
+	constants.CollectionSessions:        models.Session{},
+	constants.CollectionUserSessions:    models.UserSession{},
+	constants.CollectionVerifications:   models.Verification{},
+	constants.CollectionPosts:           models.Post{},
+	constants.CollectionComments:        models.Comment{},
+	constants.CollectionLikes:           models.Like{},
+	constants.CollectionShares:          models.Share{},
+	constants.CollectionBookmarks:       models.Bookmark{},
+	constants.CollectionBookmarkFolders: models.BookmarkCollection{},
+	constants.CollectionFollows:         models.Follow{},
+	constants.CollectionFriendships:     models.Friendship{},
+	constants.CollectionHashtags:        models.Hashtag{},
+	constants.CollectionHashtagFollows:  models.HashtagFollow{},
+	constants.CollectionMedia:           models.Media{},
+	constants.CollectionStories:         models.Story{},
+	constants.CollectionConversations:   models.Conversation{},
+	constants.CollectionMessages:        models.Message{},
+	constants.CollectionNotifications:   models.Notification{},
+	constants.CollectionGroups:          models.Group{},
+	constants.CollectionGroupMembers:    models.GroupMember{},
+	constants.CollectionEvents:          models.Event{},
+	constants.CollectionEventAttendees:  models.EventAttendee{},
+	constants.CollectionEventReminders:  models.EventReminder{},
+	constants.CollectionLiveStreams:     models.LiveStream{},
+	constants.CollectionReports:         models.Report{},
+	constants.CollectionAnalyticsEvents: models.AnalyticsEvent{},
+	constants.CollectionOutbox:          models.OutboxMessage{},
+	constants.CollectionSigningKeys:     models.SigningKey{},
+
+	constants.CollectionPasskeys:          models.Passkey{},
+	constants.CollectionPasskeyChallenges: models.PasskeyChallenge{},
+
+	constants.CollectionLoginFailures: models.LoginFailure{},
+	constants.CollectionLoginLockouts: models.LoginLockout{},
+	constants.CollectionModerationLog: models.ModerationLogEntry{},
+
+	constants.CollectionOAuthApps:     models.OAuthApp{},
+	constants.CollectionOAuthCodes:    models.OAuthAuthorizationCode{},
+	constants.CollectionOAuthConsents: models.OAuthConsent{},
+	constants.CollectionOAuthTokens:   models.OAuthToken{},
+
+	constants.CollectionPersonalTokens:     models.PersonalAccessToken{},
+	constants.CollectionPersonalTokenUsage: models.PersonalAccessTokenUsage{},
+
+	constants.CollectionLoginHistory: models.LoginRecord{},
+
+	constants.CollectionAdminAudit: models.AdminAuditEntry{},
+
+	constants.CollectionAccountPurgeReports: models.AccountPurgeReport{},
+
+	constants.CollectionTwoFactorSecrets: models.TwoFactorSecret{},
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	dateTimeType = reflect.TypeOf(primitive.DateTime(0))
+)
+
+// Schema generates a $jsonSchema document from a model struct.
+// Field names come from bson tags. Fields without omitempty are always
+// written by the driver, so they are required; pointers, slices and maps
+// may also be stored as null.
+func Schema(model interface{}) bson.M {
+	return schemaForType(reflect.TypeOf(model), map[reflect.Type]bool{})
+}
+
+// ApplyValidator installs the generated validator on a collection, creating the collection if needed.
+// Validation is moderate so existing documents that predate the schema can still be updated.
+func ApplyValidator(ctx context.Context, db *mongo.Database, collection string) error {
+	model, ok := Validators[collection]
+	if !ok {
+		return nil
+	}
+	return applyValidator(ctx, db, collection, model)
+}
+
+// applyValidator installs the validator generated from model on a collection
+func applyValidator(ctx context.Context, db *mongo.Database, collection string, model interface{}) error {
+	validator := bson.M{"$jsonSchema": Schema(model)}
+	return setValidator(ctx, db, collection, validator, "moderate")
+}
+
+// RemoveValidator drops the validator from a collection
+func RemoveValidator(ctx context.Context, db *mongo.Database, collection string) error {
+	return setValidator(ctx, db, collection, bson.M{}, "off")
+}
+
+func setValidator(ctx context.Context, db *mongo.Database, collection string, validator bson.M, level string) error {
+	exists, err := collectionExists(ctx, db, collection)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		opts := options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel(level).
+			SetValidationAction("error")
+		return db.CreateCollection(ctx, collection, opts)
+	}
+
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: level},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+}
+
+func collectionExists(ctx context.Context, db *mongo.Database, collection string) (bool, error) {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": collection})
+	if err != nil {
+		return false, err
+	}
+	return len(names) > 0, nil
+}
+
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) bson.M {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema bson.M
+	switch {
+	case t == timeType || t == dateTimeType:
+		schema = bson.M{"bsonType": "date"}
+	case t == objectIDType:
+		schema = bson.M{"bsonType": "objectId"}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			schema = bson.M{"bsonType": "string"}
+		case reflect.Bool:
+			schema = bson.M{"bsonType": "bool"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema = bson.M{"bsonType": bson.A{"int", "long"}}
+		case reflect.Float32, reflect.Float64:
+			schema = bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}}
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				schema = bson.M{"bsonType": "binData"}
+			} else {
+				schema = bson.M{"bsonType": "array", "items": schemaForType(t.Elem(), seen)}
+			}
+			nullable = true
+		case reflect.Map:
+			schema = bson.M{"bsonType": "object"}
+			nullable = true
+		case reflect.Struct:
+			schema = structSchema(t, seen)
+		default:
+			// interface{} and anything else is left unconstrained
+			return bson.M{}
+		}
+	}
+
+	if nullable {
+		if bsonType, ok := schema["bsonType"]; ok {
+			schema["bsonType"] = withNull(bsonType)
+		}
+	}
+
+	return schema
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) bson.M {
+	// Self-referencing types are only described at the first level
+	if seen[t] {
+		return bson.M{"bsonType": "object"}
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	properties := bson.M{}
+	required := bson.A{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, inline, skip := parseBSONTag(field)
+		if skip {
+			continue
+		}
+
+		if inline && field.Type.Kind() == reflect.Struct {
+			nested := structSchema(field.Type, seen)
+			if props, ok := nested["properties"].(bson.M); ok {
+				for key, value := range props {
+					properties[key] = value
+				}
+			}
+			if req, ok := nested["required"].(bson.A); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+
+		properties[name] = schemaForType(field.Type, seen)
+		if !omitEmpty && name != "_id" {
+			required = append(required, name)
+		}
+	}
+
+	schema := bson.M{"bsonType": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func parseBSONTag(field reflect.StructField) (name string, omitEmpty, inline, skip bool) {
+	tag, ok := field.Tag.Lookup("bson")
+	if !ok {
+		return strings.ToLower(field.Name), false, false, false
+	}
+	if tag == "-" {
+		return "", false, false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			omitEmpty = true
+		case "inline":
+			inline = true
+		}
+	}
+	return name, omitEmpty, inline, false
+}
+
+func withNull(bsonType interface{}) bson.A {
+	switch value := bsonType.(type) {
+	case bson.A:
+		return append(append(bson.A{}, value...), "null")
+	default:
+		return bson.A{value, "null"}
+	}
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	dbredis "github.com/Caqil/vyrall/internal/database/redis"
+)
+
+// ErrCacheMiss is returned by RedisClient.Get when the key does not exist
+var ErrCacheMiss = errors.New("cache miss")
+
+// RedisClient wraps a Redis client with the string cache helpers services use
+type RedisClient struct {
+	client *goredis.Client
+}
+
+// ConnectRedis opens a Redis connection
+func ConnectRedis(ctx context.Context, opts dbredis.Options) (*RedisClient, error) {
+	client, err := dbredis.Connect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisClient{client: client}, nil
+}
+
+// Client returns the underlying Redis client
+func (r *RedisClient) Client() *goredis.Client {
+	return r.client
+}
+
+// Get returns the value stored at key, or ErrCacheMiss
+func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", ErrCacheMiss
+	}
+	return value, err
+}
+
+// SetWithExpiration stores value at key for the given duration
+func (r *RedisClient) SetWithExpiration(ctx context.Context, key, value string, expiration time.Duration) error {
+	return r.client.Set(ctx, key, value, expiration).Err()
+}
+
+// Delete removes keys
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// Keys returns the keys matching pattern. It uses SCAN so it does not block the server.
+func (r *RedisClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// Close releases the connection pool
+func (r *RedisClient) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountPurgeReport records what was erased when a deleted account's grace
+// period ended. It is kept for compliance after the account itself is gone.
+type AccountPurgeReport struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RequestedAt time.Time          `bson:"requested_at" json:"requested_at"` // When the user asked for deletion
+	ScheduledAt time.Time          `bson:"scheduled_at" json:"scheduled_at"` // When the grace period ended
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Collections []PurgedCollection `bson:"collections" json:"collections"`
+	MediaFiles  int                `bson:"media_files" json:"media_files"` // Stored files removed
+}
+
+// PurgedCollection counts the documents erased from one collection. Deleted
+// documents were removed outright; updated ones belong to other users and
+// had the account's data stripped from them.
+type PurgedCollection struct {
+	Collection string `bson:"collection" json:"collection"`
+	Deleted    int64  `bson:"deleted" json:"deleted"`
+	Updated    int64  `bson:"updated" json:"updated"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminAuditEntry records an impersonation of a user by staff, or a request
+// made under one
+type AdminAuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Action     string             `bson:"action" json:"action"`     // impersonation_started, impersonated_request, ...
+	ActorID    primitive.ObjectID `bson:"actor_id" json:"actor_id"` // Member of staff
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`   // User impersonated
+	SessionID  primitive.ObjectID `bson:"session_id" json:"session_id"`
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Write      bool               `bson:"write" json:"write"` // Whether the session could make changes
+	Method     string             `bson:"method,omitempty" json:"method,omitempty"`
+	Path       string             `bson:"path,omitempty" json:"path,omitempty"`
+	StatusCode int                `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	IPAddress  string             `bson:"ip_address" json:"ip_address"`
+	UserAgent  string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
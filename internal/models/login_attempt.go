@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginFailure is a failed password login. Failures are counted per account,
+// IP address and subnet to slow down guessing and spot credential stuffing,
+// and are removed once they are too old to count.
+type LoginFailure struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Email     string              `bson:"email" json:"email"` // Normalised; recorded whether or not the account exists
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	IPAddress string              `bson:"ip_address" json:"ip_address"`
+	Subnet    string              `bson:"subnet" json:"subnet"` // /24 for IPv4, /64 for IPv6
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time           `bson:"expires_at" json:"-"`
+}
+
+// LoginLockout blocks password logins to an account until LockedUntil. An
+// account has at most one lockout; locking it again replaces it.
+type LoginLockout struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Email         string              `bson:"email" json:"email"`
+	UserID        *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Failures      int                 `bson:"failures" json:"failures"` // Failures in the window that triggered it
+	LastIPAddress string              `bson:"last_ip_address" json:"last_ip_address"`
+	LockedAt      time.Time           `bson:"locked_at" json:"locked_at"`
+	LockedUntil   time.Time           `bson:"locked_until" json:"locked_until"`
+}
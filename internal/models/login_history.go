@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginRecord is a completed login. Records are kept for a while so each new
+// login can be compared with the devices and places the account usually
+// signs in from, and are removed once they are too old to count.
+type LoginRecord struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	SessionID   primitive.ObjectID `bson:"session_id" json:"session_id"`
+	IPAddress   string             `bson:"ip_address" json:"ip_address"`
+	Device      string             `bson:"device" json:"device"`
+	DeviceKey   string             `bson:"device_key" json:"-"` // Device type, browser and OS, without versions
+	Location    string             `bson:"location" json:"location"`
+	CountryCode string             `bson:"country_code,omitempty" json:"country_code,omitempty"`
+	Coordinates *GeoPoint          `bson:"coordinates,omitempty" json:"coordinates,omitempty"`
+	Risks       []string           `bson:"risks,omitempty" json:"risks,omitempty"` // Why the login was flagged
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"-"`
+}
+
+// Reasons a login is flagged
+const (
+	LoginRiskNewDevice        = "new_device"        // No earlier login from this kind of device
+	LoginRiskNewCountry       = "new_country"       // No earlier login from this country
+	LoginRiskImpossibleTravel = "impossible_travel" // Too far from the previous login to have travelled
+)
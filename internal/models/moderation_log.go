@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModerationLogEntry records a moderation or account security action for the
+// admin moderation log
+type ModerationLogEntry struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Action     string              `bson:"action" json:"action"`           // account_locked, account_unlocked, ...
+	TargetType string              `bson:"target_type" json:"target_type"` // user, post, comment, ...
+	TargetID   *primitive.ObjectID `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	ActorID    *primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"` // Admin who acted; empty for automatic actions
+	Reason     string              `bson:"reason" json:"reason"`
+	Details    map[string]string   `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OAuthApp is a third-party app registered by a developer to act on behalf of
+// users who authorize it
+type OAuthApp struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID          primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Name             string             `bson:"name" json:"name"`
+	Description      string             `bson:"description" json:"description,omitempty"`
+	Website          string             `bson:"website" json:"website,omitempty"`
+	ClientID         string             `bson:"client_id" json:"client_id"`
+	ClientSecretHash string             `bson:"client_secret_hash,omitempty" json:"-"` // Empty for public clients
+	Public           bool               `bson:"public" json:"public"`                  // Native or browser app that cannot keep a secret
+	RedirectURIs     []string           `bson:"redirect_uris" json:"redirect_uris"`
+	Scopes           []string           `bson:"scopes" json:"scopes"` // Scopes the app may request
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OAuthAuthorizationCode is a single-use code issued when a user approves an
+// authorization request, exchanged by the app for tokens
+type OAuthAuthorizationCode struct {
+	ID            string             `bson:"_id" json:"-"` // SHA-256 of the code
+	AppID         primitive.ObjectID `bson:"app_id" json:"app_id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RedirectURI   string             `bson:"redirect_uri" json:"redirect_uri"`
+	Scopes        []string           `bson:"scopes" json:"scopes"`
+	CodeChallenge string             `bson:"code_challenge" json:"-"` // PKCE S256 challenge
+	Nonce         string             `bson:"nonce,omitempty" json:"-"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"expires_at"`
+}
+
+// OAuthConsent records the scopes a user has granted an app, so the consent
+// screen is only shown again when the app asks for more
+type OAuthConsent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AppID     primitive.ObjectID `bson:"app_id" json:"app_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Scopes    []string           `bson:"scopes" json:"scopes"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OAuthToken is a grant of access to an app, created when a code is
+// exchanged. Access tokens name it in their jti claim, so revoking it
+// revokes them along with its refresh token.
+type OAuthToken struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AppID              primitive.ObjectID `bson:"app_id" json:"app_id"`
+	ClientID           string             `bson:"client_id" json:"client_id"`
+	UserID             primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Scopes             []string           `bson:"scopes" json:"scopes"`
+	RefreshTokenHash   string             `bson:"refresh_token_hash,omitempty" json:"-"`
+	RotatedTokenHashes []string           `bson:"rotated_token_hashes,omitempty" json:"-"` // Refresh tokens already exchanged
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt         time.Time          `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt          time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt          *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
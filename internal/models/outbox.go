@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxMessage is a domain event waiting to be handed to its subscribers. It
+// is written in the same transaction as the change it describes, so an event
+// is recorded if and only if the change commits.
+type OutboxMessage struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	Payload     string             `bson:"payload" json:"payload"` // JSON-encoded event
+	Status      string             `bson:"status" json:"status"`   // pending, published
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	LastError   string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	OccurredAt  time.Time          `bson:"occurred_at" json:"occurred_at"`
+	AvailableAt time.Time          `bson:"available_at" json:"available_at"`
+	PublishedAt *time.Time         `bson:"published_at,omitempty" json:"published_at,omitempty"`
+}
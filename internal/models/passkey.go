@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Passkey is a WebAuthn credential a user signs in with, either instead of a
+// password or as a second factor
+type Passkey struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name           string             `bson:"name" json:"name"`
+	CredentialID   []byte             `bson:"credential_id" json:"-"`
+	PublicKey      []byte             `bson:"public_key" json:"-"`        // COSE_Key
+	Algorithm      int64              `bson:"algorithm" json:"algorithm"` // COSE algorithm, e.g. -7 for ES256
+	SignCount      uint32             `bson:"sign_count" json:"-"`
+	AAGUID         []byte             `bson:"aaguid,omitempty" json:"-"`
+	Transports     []string           `bson:"transports,omitempty" json:"transports,omitempty"` // usb, nfc, ble, internal, hybrid
+	BackupEligible bool               `bson:"backup_eligible" json:"backup_eligible"`           // Synced across the user's devices
+	BackedUp       bool               `bson:"backed_up" json:"backed_up"`
+	Device         string             `bson:"device" json:"device"` // Device it was registered from
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt     *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+}
+
+// PasskeyChallenge is an open WebAuthn ceremony. It is consumed by the
+// response that answers it, so each challenge is accepted at most once.
+type PasskeyChallenge struct {
+	ID        string              `bson:"_id" json:"-"`     // The challenge, base64url encoded
+	Purpose   string              `bson:"purpose" json:"-"` // register, login, second_factor
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty" json:"-"`
+	SessionID *primitive.ObjectID `bson:"session_id,omitempty" json:"-"` // Pending two-factor session
+	CreatedAt time.Time           `bson:"created_at" json:"-"`
+	ExpiresAt time.Time           `bson:"expires_at" json:"-"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalAccessToken is a long-lived, scoped token a user creates to call
+// the API from scripts. Only a hash of the token is stored; the prefix tells
+// tokens apart in listings.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	TokenHash  string             `bson:"token_hash" json:"-"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // The start of the token, e.g. vyp_3fA9xQ
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	AllowedIPs []string           `bson:"allowed_ips,omitempty" json:"allowed_ips,omitempty"` // Addresses or CIDR ranges; any address when empty
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	LastUsedIP string             `bson:"last_used_ip,omitempty" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// PersonalAccessTokenUsage counts the requests a personal access token made
+// from one address on one day, so its owner can audit where it is used
+type PersonalAccessTokenUsage struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	TokenID     primitive.ObjectID `bson:"token_id" json:"token_id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"-"`
+	Day         time.Time          `bson:"day" json:"day"` // Midnight UTC
+	IPAddress   string             `bson:"ip_address" json:"ip_address"`
+	UserAgent   string             `bson:"user_agent" json:"user_agent"` // Of the latest request
+	Requests    int64              `bson:"requests" json:"requests"`
+	FirstUsedAt time.Time          `bson:"first_used_at" json:"first_used_at"`
+	LastUsedAt  time.Time          `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"-"` // Removed once the retention period has passed
+}
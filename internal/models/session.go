@@ -6,22 +6,48 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Session represents a user login session
+// Session represents a user login session. A session is one refresh token
+// family: every refresh replaces its refresh token and remembers the hash of
+// the one it replaced, so a replayed token can be traced back to its family.
+// Only hashes of refresh tokens are stored.
 type Session struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Token        string             `bson:"token" json:"token"`
-	RefreshToken string             `bson:"refresh_token" json:"refresh_token"`
-	UserAgent    string             `bson:"user_agent" json:"user_agent"`
-	IPAddress    string             `bson:"ip_address" json:"ip_address"`
-	Device       string             `bson:"device" json:"device"`
-	Location     string             `bson:"location" json:"location"`
-	ExpiresAt    time.Time          `bson:"expires_at" json:"expires_at"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	LastUsedAt   time.Time          `bson:"last_used_at" json:"last_used_at"`
-	IsActive     bool               `bson:"is_active" json:"is_active"`
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID             primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Token              string             `bson:"token" json:"token"`
+	RefreshToken       string             `bson:"-" json:"refresh_token,omitempty"` // Plaintext, only set when the token is issued
+	RefreshTokenHash   string             `bson:"refresh_token_hash,omitempty" json:"-"`
+	RotatedTokenHashes []string           `bson:"rotated_token_hashes,omitempty" json:"-"` // Hashes of refresh tokens already exchanged
+	UserAgent          string             `bson:"user_agent" json:"user_agent"`
+	IPAddress          string             `bson:"ip_address" json:"ip_address"`
+	Device             string             `bson:"device" json:"device"`
+	Location           string             `bson:"location" json:"location"`
+	ExpiresAt          time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt         time.Time          `bson:"last_used_at" json:"last_used_at"`
+	IsActive           bool               `bson:"is_active" json:"is_active"`
+	RevokedAt          *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedReason      string             `bson:"revoked_reason,omitempty" json:"revoked_reason,omitempty"` // logout, reuse_detected, etc.
+	PendingFactor      string             `bson:"pending_factor,omitempty" json:"pending_factor,omitempty"` // Second step a pending login waits for
+	StepUpCodeHash     string             `bson:"step_up_code_hash,omitempty" json:"-"`
+	Impersonation      *Impersonation     `bson:"impersonation,omitempty" json:"impersonation,omitempty"` // Set when staff opened the session as the user
 }
 
+// Impersonation describes a session a member of staff opened to see the app
+// as the user does. It is read-only until elevated. It is listed with the
+// user's sessions, without naming the member of staff.
+type Impersonation struct {
+	ImpersonatorID primitive.ObjectID `bson:"impersonator_id" json:"-"`
+	Reason         string             `bson:"reason" json:"reason"`
+	Write          bool               `bson:"write" json:"write"`
+	ElevatedAt     *time.Time         `bson:"elevated_at,omitempty" json:"elevated_at,omitempty"`
+}
+
+// Second steps a pending login can wait for
+const (
+	PendingFactorTwoFactor = "two_factor" // TOTP code or passkey
+	PendingFactorEmailCode = "email_code" // Code emailed because the login looked risky
+)
+
 // UserSession represents a user browsing session for analytics
 type UserSession struct {
 	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
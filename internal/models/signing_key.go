@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningKey is an asymmetric key that signs access tokens. A key is published
+// before it starts signing, signs until it retires and stays published for a
+// grace period after that, so tokens it signed can still be verified.
+type SigningKey struct {
+	ID          string    `bson:"_id" json:"kid"`
+	Algorithm   string    `bson:"algorithm" json:"alg"`         // RS256, EdDSA
+	PrivateKey  []byte    `bson:"private_key" json:"-"`         // PKCS#8 DER, encrypted with the JWT secret
+	PublicKey   []byte    `bson:"public_key" json:"public_key"` // PKIX DER
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	ActivatesAt time.Time `bson:"activates_at" json:"activates_at"` // Starts signing
+	RetiresAt   time.Time `bson:"retires_at" json:"retires_at"`     // Stops signing
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`     // Unpublished and removed
+}
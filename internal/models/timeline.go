@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimelineEntry is a post in a user's cached home timeline. Timelines are
+// ordered by PublishedAt, newest first, and then by PostID, highest first.
+type TimelineEntry struct {
+	PostID      primitive.ObjectID `json:"post_id"`
+	AuthorID    primitive.ObjectID `json:"author_id"`
+	PublishedAt time.Time          `json:"published_at"`
+}
+
+// TimelineCursor is a position in a timeline. The entries after it are those
+// published in an earlier millisecond than PublishedAt, or in the same one
+// with a lower PostID, so a page can end between posts published together.
+// The zero cursor is the start of the timeline.
+type TimelineCursor struct {
+	PublishedAt time.Time
+	PostID      primitive.ObjectID
+}
@@ -8,36 +8,52 @@ import (
 
 // User represents a user account in the social media platform
 type User struct {
-	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Username         string             `bson:"username" json:"username"`
-	Email            string             `bson:"email" json:"email"`
-	PasswordHash     string             `bson:"password_hash" json:"-"`
-	FirstName        string             `bson:"first_name" json:"first_name,omitempty"`
-	LastName         string             `bson:"last_name" json:"last_name,omitempty"`
-	DisplayName      string             `bson:"display_name" json:"display_name"`
-	Bio              string             `bson:"bio" json:"bio,omitempty"`
-	ProfilePicture   string             `bson:"profile_picture" json:"profile_picture,omitempty"`
-	CoverPhoto       string             `bson:"cover_photo" json:"cover_photo,omitempty"`
-	PhoneNumber      string             `bson:"phone_number" json:"phone_number,omitempty"`
-	Website          string             `bson:"website" json:"website,omitempty"`
-	Location         string             `bson:"location" json:"location,omitempty"`
-	DateOfBirth      time.Time          `bson:"date_of_birth" json:"date_of_birth,omitempty"`
-	Gender           string             `bson:"gender" json:"gender,omitempty"`
-	IsVerified       bool               `bson:"is_verified" json:"is_verified"`
-	IsPrivate        bool               `bson:"is_private" json:"is_private"`
-	Role             string             `bson:"role" json:"role"`
-	Status           string             `bson:"status" json:"status"`
-	LastActive       time.Time          `bson:"last_active" json:"last_active"`
-	JoinedAt         time.Time          `bson:"joined_at" json:"joined_at"`
-	EmailVerified    bool               `bson:"email_verified" json:"email_verified"`
-	TwoFactorEnabled bool               `bson:"two_factor_enabled" json:"two_factor_enabled"`
-	FollowerCount    int                `bson:"follower_count" json:"follower_count"`
-	FollowingCount   int                `bson:"following_count" json:"following_count"`
-	PostCount        int                `bson:"post_count" json:"post_count"`
-	Settings         UserSettings       `bson:"settings" json:"settings"`
-	DeletedAt        *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
-	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
-	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Username            string             `bson:"username" json:"username"`
+	Email               string             `bson:"email" json:"email"`
+	PasswordHash        string             `bson:"password_hash" json:"-"`
+	FirstName           string             `bson:"first_name" json:"first_name,omitempty"`
+	LastName            string             `bson:"last_name" json:"last_name,omitempty"`
+	DisplayName         string             `bson:"display_name" json:"display_name"`
+	Bio                 string             `bson:"bio" json:"bio,omitempty"`
+	ProfilePicture      string             `bson:"profile_picture" json:"profile_picture,omitempty"`
+	CoverPhoto          string             `bson:"cover_photo" json:"cover_photo,omitempty"`
+	PhoneNumber         string             `bson:"phone_number" json:"phone_number,omitempty"`
+	Website             string             `bson:"website" json:"website,omitempty"`
+	Location            string             `bson:"location" json:"location,omitempty"`
+	DateOfBirth         time.Time          `bson:"date_of_birth" json:"date_of_birth,omitempty"`
+	Gender              string             `bson:"gender" json:"gender,omitempty"`
+	IsVerified          bool               `bson:"is_verified" json:"is_verified"`
+	IsPrivate           bool               `bson:"is_private" json:"is_private"`
+	Role                string             `bson:"role" json:"role"`
+	Status              string             `bson:"status" json:"status"`
+	LastActive          time.Time          `bson:"last_active" json:"last_active"`
+	JoinedAt            time.Time          `bson:"joined_at" json:"joined_at"`
+	EmailVerified       bool               `bson:"email_verified" json:"email_verified"`
+	TwoFactorEnabled    bool               `bson:"two_factor_enabled" json:"two_factor_enabled"`
+	PasswordDisabled    bool               `bson:"password_disabled,omitempty" json:"password_disabled"` // Signs in by emailed link or code only
+	PasswordBreached    bool               `bson:"password_breached,omitempty" json:"-"`                 // Found in a breached-password corpus; must be reset to log in
+	FollowerCount       int                `bson:"follower_count" json:"follower_count"`
+	FollowingCount      int                `bson:"following_count" json:"following_count"`
+	PostCount           int                `bson:"post_count" json:"post_count"`
+	Settings            UserSettings       `bson:"settings" json:"settings"`
+	DeletedAt           *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletionRequestedAt *time.Time         `bson:"deletion_requested_at,omitempty" json:"deletion_requested_at,omitempty"`
+	DeletionScheduledAt *time.Time         `bson:"deletion_scheduled_at,omitempty" json:"deletion_scheduled_at,omitempty"` // Erased after this unless the user logs in first
+	ParentalConsent     *ParentalConsent   `bson:"parental_consent,omitempty" json:"-"`
+	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
+	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// AdultAge is the age from which an account is no longer a teen account
+const AdultAge = 18
+
+// ParentalConsent records the guardian who must approve a young user's
+// account before it is activated
+type ParentalConsent struct {
+	GuardianEmail string     `bson:"guardian_email" json:"guardian_email"`
+	RequestedAt   time.Time  `bson:"requested_at" json:"requested_at"`
+	GrantedAt     *time.Time `bson:"granted_at,omitempty" json:"granted_at,omitempty"`
 }
 
 // UserSettings contains user customizable settings
@@ -90,4 +106,5 @@ type PrivacySettings struct {
 	HideMyOnlineStatus      bool     `bson:"hide_my_online_status" json:"hide_my_online_status"`
 	HideMyLastSeen          bool     `bson:"hide_my_last_seen" json:"hide_my_last_seen"`
 	HideMyProfileFromSearch bool     `bson:"hide_my_profile_from_search" json:"hide_my_profile_from_search"`
+	HideFromSuggestions     bool     `bson:"hide_from_suggestions" json:"hide_from_suggestions"` // Left out of suggested and popular accounts
 }
@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountPurgeRepository erases deleted accounts and keeps the record of
+// what was erased
+type AccountPurgeRepository interface {
+	// Purge erases everything userID left behind, including the user
+	// document, and counts what went from each collection. It is safe to
+	// run again after a failure.
+	Purge(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.PurgedCollection, error)
+
+	// SaveReport inserts or replaces the report for the report's user
+	SaveReport(ctx context.Context, report *models.AccountPurgeReport) error
+	GetReport(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error)
+
+	// ListReports returns reports newest first with the total count
+	ListReports(ctx context.Context, limit, offset int) ([]*models.AccountPurgeReport, int, error)
+}
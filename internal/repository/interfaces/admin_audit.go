@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminAuditRepository defines the interface for admin audit log data access
+type AdminAuditRepository interface {
+	Create(ctx context.Context, entry *models.AdminAuditEntry) error
+
+	// List returns entries newest first with the total count. A non-zero
+	// userID returns only the entries about that user.
+	List(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.AdminAuditEntry, int, error)
+}
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BookmarkRepository defines the interface for bookmark data access
+type BookmarkRepository interface {
+	// Basic CRUD operations
+	Create(ctx context.Context, bookmark *models.Bookmark) (primitive.ObjectID, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// Query operations
+	GetByUserAndPost(ctx context.Context, userID, postID primitive.ObjectID) (*models.Bookmark, error)
+
+	// Collection operations
+	GetCollectionByName(ctx context.Context, userID primitive.ObjectID, name string) (*models.BookmarkCollection, error)
+	IncrementCollectionItemCount(ctx context.Context, collectionID primitive.ObjectID, amount int) error
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/Caqil/vyrall/internal/models"
 
@@ -46,4 +47,6 @@ type CommentRepository interface {
 	// Analytics
 	GetMostLikedComments(ctx context.Context, postID primitive.ObjectID, limit int) ([]*models.Comment, error)
 	GetUserCommentActivity(ctx context.Context, userID primitive.ObjectID, startDate, endDate string) (int, error)
+	GetTopCommenters(ctx context.Context, since time.Time, limit int) ([]primitive.ObjectID, error)
+	GetMostCommentedPosts(ctx context.Context, since time.Time, limit int) ([]primitive.ObjectID, error)
 }
@@ -38,6 +38,8 @@ type EventRepository interface {
 	GetAttendees(ctx context.Context, eventID primitive.ObjectID, rsvp string, limit, offset int) ([]*models.EventAttendee, int, error)
 	GetAttendeeByID(ctx context.Context, eventID, userID primitive.ObjectID) (*models.EventAttendee, error)
 	UpdateRSVPCounts(ctx context.Context, eventID primitive.ObjectID) error
+	UpdateAttendee(ctx context.Context, attendee *models.EventAttendee) error
+	ListAttendees(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.EventAttendee, int, error)
 
 	// Event status management
 	UpdateStatus(ctx context.Context, eventID primitive.ObjectID, status string) error
@@ -58,9 +60,15 @@ type EventRepository interface {
 	CreateReminder(ctx context.Context, reminder *models.EventReminder) (primitive.ObjectID, error)
 	GetRemindersToSend(ctx context.Context, before time.Time) ([]*models.EventReminder, error)
 	MarkReminderSent(ctx context.Context, reminderID primitive.ObjectID) error
+	UpdateReminder(ctx context.Context, reminder *models.EventReminder) error
+	DeleteReminder(ctx context.Context, reminderID primitive.ObjectID) error
+	ListReminders(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.EventReminder, int, error)
 
 	// Analytics
 	GetEventStats(ctx context.Context, eventID primitive.ObjectID) (models.EventAnalytics, error)
+	SaveEventStats(ctx context.Context, stats *models.EventAnalytics) error
+	HasViewer(ctx context.Context, eventID primitive.ObjectID, viewer string) (bool, error)
+	AddViewer(ctx context.Context, eventID primitive.ObjectID, viewer string) error
 
 	// Search and discovery
 	GetRecommendedEvents(ctx context.Context, userID primitive.ObjectID, limit int) ([]*models.Event, error)
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginAttemptRepository defines the interface for failed login and lockout data access
+type LoginAttemptRepository interface {
+	// Failures
+	RecordFailure(ctx context.Context, failure *models.LoginFailure) error
+	CountByEmail(ctx context.Context, email string, since time.Time) (int, error)
+	CountByIP(ctx context.Context, ipAddress string, since time.Time) (int, error)
+	CountBySubnet(ctx context.Context, subnet string, since time.Time) (int, error)
+	CountEmailsByIP(ctx context.Context, ipAddress string, since time.Time) (int, error) // Distinct accounts tried
+	LastFailure(ctx context.Context, email string) (*models.LoginFailure, error)
+	ClearFailures(ctx context.Context, email string) error // Failures still count against their IP address and subnet
+
+	// Lockouts. The Find methods only return lockouts still in force at now.
+	SaveLockout(ctx context.Context, lockout *models.LoginLockout) error
+	FindLockout(ctx context.Context, email string, now time.Time) (*models.LoginLockout, error)
+	FindLockoutByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.LoginLockout, error)
+	DeleteLockout(ctx context.Context, email string) error
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginHistoryRepository defines the interface for login history data access
+type LoginHistoryRepository interface {
+	Create(ctx context.Context, record *models.LoginRecord) error
+	FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.LoginRecord, error) // mongo.ErrNoDocuments for a first login
+	HasDevice(ctx context.Context, userID primitive.ObjectID, deviceKey string) (bool, error)
+	HasCountry(ctx context.Context, userID primitive.ObjectID, countryCode string) (bool, error)
+}
@@ -19,7 +19,7 @@ type MessageRepository interface {
 	SoftDelete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
 
 	// Query operations
-	GetByConversationID(ctx context.Context, conversationID primitive.ObjectID, limit, offset int) ([]*models.Message, int, error)
+	GetByConversationID(ctx context.Context, conversationID, userID primitive.ObjectID, limit, offset int) ([]*models.Message, int, error)
 	GetUnreadMessagesCount(ctx context.Context, userID, conversationID primitive.ObjectID) (int, error)
 	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Message, error)
 
@@ -35,7 +35,7 @@ type MessageRepository interface {
 
 	// Message forwarding and replies
 	ForwardMessage(ctx context.Context, messageID, toConversationID, senderID primitive.ObjectID) (primitive.ObjectID, error)
-	GetReplies(ctx context.Context, messageID primitive.ObjectID) ([]*models.Message, error)
+	GetReplies(ctx context.Context, messageID, userID primitive.ObjectID) ([]*models.Message, error)
 
 	// Encryption
 	UpdateEncryptionDetails(ctx context.Context, id primitive.ObjectID, details models.EncryptionDetails) error
@@ -44,11 +44,11 @@ type MessageRepository interface {
 	CreateSystemMessage(ctx context.Context, conversationID primitive.ObjectID, msgType string, params map[string]interface{}) (primitive.ObjectID, error)
 
 	// Time-based operations
-	GetMessagesByTimeRange(ctx context.Context, conversationID primitive.ObjectID, startTime, endTime time.Time, limit, offset int) ([]*models.Message, int, error)
+	GetMessagesByTimeRange(ctx context.Context, conversationID, userID primitive.ObjectID, startTime, endTime time.Time, limit, offset int) ([]*models.Message, int, error)
 	DeleteExpiredMessages(ctx context.Context) (int, error)
 
 	// Voice and media messages
-	GetMediaMessages(ctx context.Context, conversationID primitive.ObjectID, mediaType string, limit, offset int) ([]*models.Message, int, error)
+	GetMediaMessages(ctx context.Context, conversationID, userID primitive.ObjectID, mediaType string, limit, offset int) ([]*models.Message, int, error)
 
 	// Bulk operations
 	BulkDelete(ctx context.Context, ids []primitive.ObjectID, userID primitive.ObjectID) (int, error)
@@ -57,5 +57,5 @@ type MessageRepository interface {
 	List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Message, int, error)
 
 	// Search
-	SearchMessages(ctx context.Context, conversationID primitive.ObjectID, query string, limit, offset int) ([]*models.Message, int, error)
+	SearchMessages(ctx context.Context, conversationID, userID primitive.ObjectID, query string, limit, offset int) ([]*models.Message, int, error)
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Caqil/vyrall/internal/models"
+)
+
+// ModerationLogRepository defines the interface for moderation log data access
+type ModerationLogRepository interface {
+	// Create stores an entry. An entry whose ID is already stored fails with a
+	// duplicate key error, so redelivered events are recorded once.
+	Create(ctx context.Context, entry *models.ModerationLogEntry) error
+
+	// List returns entries newest first with the total count
+	List(ctx context.Context, limit, offset int) ([]*models.ModerationLogEntry, int, error)
+}
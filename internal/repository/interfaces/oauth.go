@@ -0,0 +1,54 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OAuthAppRepository defines the interface for third-party app data access
+type OAuthAppRepository interface {
+	Create(ctx context.Context, app *models.OAuthApp) (*models.OAuthApp, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthApp, error)
+	FindByClientID(ctx context.Context, clientID string) (*models.OAuthApp, error)
+	FindByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]*models.OAuthApp, error)
+	CountByOwnerID(ctx context.Context, ownerID primitive.ObjectID) (int, error)
+
+	// Update and Delete only apply to an app owned by app.OwnerID / ownerID
+	Update(ctx context.Context, app *models.OAuthApp) error
+	Delete(ctx context.Context, id, ownerID primitive.ObjectID) error
+}
+
+// OAuthGrantRepository defines the interface for authorization codes,
+// consents and tokens issued to third-party apps
+type OAuthGrantRepository interface {
+	// Authorization codes. ConsumeCode removes and returns an unexpired code;
+	// only one caller can consume it, the rest get mongo.ErrNoDocuments.
+	CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error
+	ConsumeCode(ctx context.Context, id string) (*models.OAuthAuthorizationCode, error)
+
+	// Consents, one per app and user
+	SaveConsent(ctx context.Context, consent *models.OAuthConsent) error
+	FindConsent(ctx context.Context, appID, userID primitive.ObjectID) (*models.OAuthConsent, error)
+	FindConsentsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.OAuthConsent, error)
+	DeleteConsent(ctx context.Context, appID, userID primitive.ObjectID) error
+	DeleteConsentsByAppID(ctx context.Context, appID primitive.ObjectID) error
+
+	// Tokens
+	CreateToken(ctx context.Context, token *models.OAuthToken) (*models.OAuthToken, error)
+	FindTokenByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthToken, error)
+	FindTokenByRefreshTokenHash(ctx context.Context, hash string) (*models.OAuthToken, error)
+	FindTokenByRotatedHash(ctx context.Context, hash string) (*models.OAuthToken, error)
+
+	// RotateRefreshToken replaces the refresh token of an unrevoked token
+	// while it is still previousHash, keeping previousHash to detect reuse;
+	// otherwise it returns mongo.ErrNoDocuments
+	RotateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, hash string, expiresAt time.Time) error
+
+	// Revocation. Revoking an already revoked token is a no-op.
+	RevokeToken(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error
+	RevokeTokensByApp(ctx context.Context, appID primitive.ObjectID, userID *primitive.ObjectID, revokedAt time.Time) error // All users when userID is nil
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxRepository defines the interface for transactional outbox data access
+type OutboxRepository interface {
+	// Add stores messages as pending. Call it with the context of the
+	// transaction that makes the change the messages describe.
+	Add(ctx context.Context, messages []*models.OutboxMessage) error
+
+	// Relay operations
+	GetPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id primitive.ObjectID) error
+	MarkRetry(ctx context.Context, id primitive.ObjectID, cause string, retryAt time.Time) error
+}
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasskeyRepository defines the interface for WebAuthn credential data access
+type PasskeyRepository interface {
+	// Basic CRUD operations
+	Create(ctx context.Context, passkey *models.Passkey) (*models.Passkey, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Passkey, error)
+	FindByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error)
+
+	// RecordUse stores the sign count of a successful login. It only applies
+	// if the stored count is still previousCount, so two logins replaying the
+	// same count cannot both succeed; otherwise it returns mongo.ErrNoDocuments.
+	RecordUse(ctx context.Context, id primitive.ObjectID, previousCount, signCount uint32, backedUp bool, usedAt time.Time) error
+
+	// Rename and Delete only apply to a passkey owned by userID
+	Rename(ctx context.Context, id, userID primitive.ObjectID, name string) error
+	Delete(ctx context.Context, id, userID primitive.ObjectID) error
+	DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error
+
+	// Ceremony challenges
+	CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error
+	ConsumeChallenge(ctx context.Context, id string) (*models.PasskeyChallenge, error)
+}
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalTokenRepository defines the interface for personal access token data access
+type PersonalTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.PersonalAccessToken, error)
+	FindByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error)
+
+	// CountActiveByUserID counts a user's tokens that are neither revoked nor expired at now
+	CountActiveByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (int, error)
+
+	// RecordUse sets the last-used time and address of a token
+	RecordUse(ctx context.Context, id primitive.ObjectID, usedAt time.Time, ipAddress string) error
+
+	// Revoke only applies to a user's token that is not revoked yet;
+	// otherwise it returns mongo.ErrNoDocuments
+	Revoke(ctx context.Context, id, userID primitive.ObjectID, revokedAt time.Time) error
+
+	// RecordUsage adds a request to the token's usage for the day and address,
+	// creating the entry on the first request
+	RecordUsage(ctx context.Context, usage *models.PersonalAccessTokenUsage) error
+	FindUsage(ctx context.Context, tokenID primitive.ObjectID, since time.Time) ([]*models.PersonalAccessTokenUsage, error)
+}
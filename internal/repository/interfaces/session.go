@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SessionRepository defines the interface for login session data access.
+// Refresh tokens are looked up by hash; the plaintext is never stored.
+type SessionRepository interface {
+	// Basic CRUD operations
+	Create(ctx context.Context, session *models.Session) (*models.Session, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	Update(ctx context.Context, session *models.Session) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// Query operations
+	FindByToken(ctx context.Context, token string) (*models.Session, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error)
+
+	// Refresh token families
+	FindByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error)
+	FindByRotatedTokenHash(ctx context.Context, hash string) (*models.Session, error)
+	RotateRefreshToken(ctx context.Context, session *models.Session, previousHash string) error
+	Revoke(ctx context.Context, id primitive.ObjectID, reason string) error
+
+	// ElevateImpersonation lets an active impersonation session make changes
+	ElevateImpersonation(ctx context.Context, id primitive.ObjectID, elevatedAt time.Time) error
+
+	// Cleanup operations
+	DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+)
+
+// SigningKeyRepository defines the interface for token signing key data access
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *models.SigningKey) error
+
+	// GetUnexpired retrieves every key still published at now, oldest first
+	GetUnexpired(ctx context.Context, now time.Time) ([]*models.SigningKey, error)
+}
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimelineRepository caches users' home timelines. A timeline exists once it
+// has been built and is dropped when it has not been read for its TTL; writes
+// to a timeline that does not exist are ignored, so it is only ever filled by
+// a full rebuild. Every write keeps the newest maxSize entries.
+type TimelineRepository interface {
+	// Exists reports whether userID's timeline is built
+	Exists(ctx context.Context, userID primitive.ObjectID) (bool, error)
+
+	// Rebuild replaces userID's timeline with entries and keeps it for ttl
+	Rebuild(ctx context.Context, userID primitive.ObjectID, entries []models.TimelineEntry, maxSize int, ttl time.Duration) error
+
+	// Add puts entries into the timeline of each of userIDs that exists.
+	// Adding an entry twice has no further effect.
+	Add(ctx context.Context, userIDs []primitive.ObjectID, entries []models.TimelineEntry, maxSize int) error
+
+	// Range returns up to limit entries after the cursor after, newest
+	// first, and keeps the timeline for another ttl
+	Range(ctx context.Context, userID primitive.ObjectID, after models.TimelineCursor, limit int, ttl time.Duration) ([]models.TimelineEntry, error)
+
+	// RemoveAuthor removes authorID's posts from userID's timeline
+	RemoveAuthor(ctx context.Context, userID, authorID primitive.ObjectID) error
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+)
+
+// Transactor runs a unit of work atomically. Repository calls made with the
+// context passed to fn take part in the transaction, and calls made inside an
+// existing transaction join it.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TwoFactorRepository defines the interface for authenticator app secrets and
+// recovery codes, one set per user
+type TwoFactorRepository interface {
+	SaveSecret(ctx context.Context, userID primitive.ObjectID, secret string) error
+	GetSecret(ctx context.Context, userID primitive.ObjectID) (string, error)
+	// DeleteSecret removes the secret together with the recovery codes
+	DeleteSecret(ctx context.Context, userID primitive.ObjectID) error
+
+	SaveRecoveryCodes(ctx context.Context, userID primitive.ObjectID, codes []string) error
+	GetRecoveryCodes(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+	// MarkRecoveryCodeUsed removes a code so it cannot be used again. It
+	// returns mongo.ErrNoDocuments if the code was already used.
+	MarkRecoveryCodeUsed(ctx context.Context, userID primitive.ObjectID, code string) error
+}
@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VerificationRepository defines the interface for emailed codes and links
+// such as email verification, password resets and passwordless sign-in
+type VerificationRepository interface {
+	// Basic CRUD operations
+	Create(ctx context.Context, verification *models.Verification) (*models.Verification, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Verification, error)
+	Update(ctx context.Context, verification *models.Verification) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// Query operations
+	FindByToken(ctx context.Context, token, type_ string) (*models.Verification, error)
+	// FindByUserID returns the user's most recent record of a type
+	FindByUserID(ctx context.Context, userID primitive.ObjectID, type_ string) (*models.Verification, error)
+	CountSince(ctx context.Context, userID primitive.ObjectID, type_ string, since time.Time) (int, error)
+
+	// Redeem marks a pending record that has not expired by now used and
+	// returns it. Only one caller can redeem a record; the rest get
+	// mongo.ErrNoDocuments, as do callers with a used or expired record.
+	Redeem(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Verification, error)
+}
@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// AccountPurgeRepository implements interfaces.AccountPurgeRepository using MongoDB
+type AccountPurgeRepository struct {
+	db      *mongo.Database
+	reports *mongo.Collection
+}
+
+var _ interfaces.AccountPurgeRepository = (*AccountPurgeRepository)(nil)
+
+// NewAccountPurgeRepository creates a new MongoDB account purge repository
+func NewAccountPurgeRepository(db *mongo.Database) *AccountPurgeRepository {
+	return &AccountPurgeRepository{
+		db:      db,
+		reports: db.Collection(constants.CollectionAccountPurgeReports),
+	}
+}
+
+// Purge runs every account purge step and counts, per collection, the
+// documents deleted and the documents of other users that were changed
+func (r *AccountPurgeRepository) Purge(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.PurgedCollection, error) {
+	var purged []models.PurgedCollection
+	for _, step := range dbmongo.AccountPurgeSteps(userID, now) {
+		if len(purged) == 0 || purged[len(purged)-1].Collection != step.Collection {
+			purged = append(purged, models.PurgedCollection{Collection: step.Collection})
+		}
+		counts := &purged[len(purged)-1]
+
+		collection := r.db.Collection(step.Collection)
+		if step.Update == nil {
+			result, err := collection.DeleteMany(ctx, step.Filter)
+			if err != nil {
+				return purged, fmt.Errorf("purge %s: %w", step.Collection, err)
+			}
+			counts.Deleted += result.DeletedCount
+			continue
+		}
+
+		result, err := collection.UpdateMany(ctx, step.Filter, step.Update)
+		if err != nil {
+			return purged, fmt.Errorf("purge %s: %w", step.Collection, err)
+		}
+		counts.Updated += result.ModifiedCount
+	}
+	return purged, nil
+}
+
+// SaveReport inserts or replaces the report for the report's user
+func (r *AccountPurgeRepository) SaveReport(ctx context.Context, report *models.AccountPurgeReport) error {
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+	_, err := r.reports.ReplaceOne(ctx, bson.M{"user_id": report.UserID}, report, options.Replace().SetUpsert(true))
+	return err
+}
+
+// GetReport retrieves the report for a purged or purging account
+func (r *AccountPurgeRepository) GetReport(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error) {
+	var report models.AccountPurgeReport
+	if err := r.reports.FindOne(ctx, bson.M{"user_id": userID}).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports retrieves reports, most recently started first
+func (r *AccountPurgeRepository) ListReports(ctx context.Context, limit, offset int) ([]*models.AccountPurgeReport, int, error) {
+	total, err := r.reports.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.reports.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.AccountPurgeReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, 0, err
+	}
+	return reports, int(total), nil
+}
@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// AdminAuditRepository implements interfaces.AdminAuditRepository using MongoDB
+type AdminAuditRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.AdminAuditRepository = (*AdminAuditRepository)(nil)
+
+// NewAdminAuditRepository creates a new MongoDB admin audit log repository
+func NewAdminAuditRepository(db *mongo.Database) *AdminAuditRepository {
+	return &AdminAuditRepository{
+		collection: db.Collection(constants.CollectionAdminAudit),
+	}
+}
+
+// Create inserts an audit entry
+func (r *AdminAuditRepository) Create(ctx context.Context, entry *models.AdminAuditEntry) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// List retrieves audit entries newest first, optionally about one user
+func (r *AdminAuditRepository) List(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.AdminAuditEntry, int, error) {
+	filter := bson.M{}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AdminAuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, int(total), nil
+}
@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// BookmarkRepository implements interfaces.BookmarkRepository using MongoDB
+type BookmarkRepository struct {
+	collection *mongo.Collection
+	folders    *mongo.Collection
+}
+
+var _ interfaces.BookmarkRepository = (*BookmarkRepository)(nil)
+
+// NewBookmarkRepository creates a new MongoDB bookmark repository
+func NewBookmarkRepository(db *mongo.Database) *BookmarkRepository {
+	return &BookmarkRepository{
+		collection: db.Collection(constants.CollectionBookmarks),
+		folders:    db.Collection(constants.CollectionBookmarkFolders),
+	}
+}
+
+// Create inserts a new bookmark. A user can bookmark a post only once.
+func (r *BookmarkRepository) Create(ctx context.Context, bookmark *models.Bookmark) (primitive.ObjectID, error) {
+	if bookmark.ID.IsZero() {
+		bookmark.ID = primitive.NewObjectID()
+	}
+	if bookmark.CreatedAt.IsZero() {
+		bookmark.CreatedAt = time.Now()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, bookmark); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return bookmark.ID, nil
+}
+
+// Delete permanently removes a bookmark
+func (r *BookmarkRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GetByUserAndPost retrieves a user's bookmark of a post
+func (r *BookmarkRepository) GetByUserAndPost(ctx context.Context, userID, postID primitive.ObjectID) (*models.Bookmark, error) {
+	var bookmark models.Bookmark
+	if err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "post_id": postID}).Decode(&bookmark); err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// GetCollectionByName retrieves one of a user's bookmark collections by name
+func (r *BookmarkRepository) GetCollectionByName(ctx context.Context, userID primitive.ObjectID, name string) (*models.BookmarkCollection, error) {
+	var collection models.BookmarkCollection
+	if err := r.folders.FindOne(ctx, bson.M{"user_id": userID, "name": name}).Decode(&collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// IncrementCollectionItemCount atomically adjusts the item counter of a bookmark collection
+func (r *BookmarkRepository) IncrementCollectionItemCount(ctx context.Context, collectionID primitive.ObjectID, amount int) error {
+	result, err := r.folders.UpdateOne(ctx,
+		bson.M{"_id": collectionID},
+		bson.M{
+			"$inc": bson.M{"item_count": amount},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
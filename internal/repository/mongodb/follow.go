@@ -0,0 +1,167 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// FollowRepository implements interfaces.FollowRepository using MongoDB
+type FollowRepository struct {
+	store
+}
+
+var _ interfaces.FollowRepository = (*FollowRepository)(nil)
+
+// NewFollowRepository creates a new MongoDB follow repository
+func NewFollowRepository(db *mongo.Database) *FollowRepository {
+	return &FollowRepository{store: newStore(db, constants.CollectionFollows)}
+}
+
+// Create inserts a new follow. Following the same user twice is a duplicate key error.
+func (r *FollowRepository) Create(ctx context.Context, follow *models.Follow) (primitive.ObjectID, error) {
+	now := time.Now()
+	if follow.ID.IsZero() {
+		follow.ID = primitive.NewObjectID()
+	}
+	if follow.CreatedAt.IsZero() {
+		follow.CreatedAt = now
+	}
+	follow.UpdatedAt = now
+	if follow.Status == "" {
+		follow.Status = "accepted"
+	}
+	return r.insert(ctx, follow.ID, follow)
+}
+
+// GetByID retrieves a follow by ID
+func (r *FollowRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Follow, error) {
+	return findOne[models.Follow](ctx, r.collection, byID(id), nil)
+}
+
+// Update replaces a follow document
+func (r *FollowRepository) Update(ctx context.Context, follow *models.Follow) error {
+	follow.UpdatedAt = time.Now()
+	return r.replace(ctx, follow.ID, follow)
+}
+
+// Delete permanently removes a follow
+func (r *FollowRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(ctx, id)
+}
+
+// GetByFollowerAndFollowing retrieves the follow between two users in one direction
+func (r *FollowRepository) GetByFollowerAndFollowing(ctx context.Context, followerID, followingID primitive.ObjectID) (*models.Follow, error) {
+	return findOne[models.Follow](ctx, r.collection, bson.M{"follower_id": followerID, "following_id": followingID}, nil)
+}
+
+// GetFollowers retrieves the accepted follows of a user, newest first
+func (r *FollowRepository) GetFollowers(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	filter := bson.M{"following_id": userID, "status": "accepted"}
+	return findPage[models.Follow](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetFollowing retrieves the accepted follows by a user, newest first
+func (r *FollowRepository) GetFollowing(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	filter := bson.M{"follower_id": userID, "status": "accepted"}
+	return findPage[models.Follow](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// UpdateStatus sets the status of the follow between two users
+func (r *FollowRepository) UpdateStatus(ctx context.Context, followerID, followingID primitive.ObjectID, status string) error {
+	return r.updateFields(ctx, bson.M{"follower_id": followerID, "following_id": followingID}, bson.M{"status": status})
+}
+
+// GetPendingFollowRequests retrieves the follow requests awaiting a user's approval
+func (r *FollowRepository) GetPendingFollowRequests(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	filter := bson.M{"following_id": userID, "status": "pending"}
+	return findPage[models.Follow](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetMutualFollows retrieves the follows by user1 of accounts user2 also follows
+func (r *FollowRepository) GetMutualFollows(ctx context.Context, user1ID, user2ID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	shared, err := r.GetFollowingIDs(ctx, user2ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{"follower_id": user1ID, "following_id": bson.M{"$in": shared}, "status": "accepted"}
+	return findPage[models.Follow](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetFollowerIDs returns the IDs of a user's accepted followers
+func (r *FollowRepository) GetFollowerIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return r.distinctIDs(ctx, "follower_id", bson.M{"following_id": userID, "status": "accepted"})
+}
+
+// GetFollowingIDs returns the IDs of the users a user follows
+func (r *FollowRepository) GetFollowingIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return r.distinctIDs(ctx, "following_id", bson.M{"follower_id": userID, "status": "accepted"})
+}
+
+// GetFollowSuggestions suggests the accounts most followed by the people a user
+// follows, leaving out the user and accounts they already follow
+func (r *FollowRepository) GetFollowSuggestions(ctx context.Context, userID primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	following, err := r.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return topIDs(ctx, r.collection, bson.M{
+		"follower_id":  bson.M{"$in": following},
+		"following_id": bson.M{"$nin": append(following, userID)},
+		"status":       "accepted",
+	}, "following_id", limit)
+}
+
+// UpdateNotifyPosts turns new-post notifications for a followed account on or off
+func (r *FollowRepository) UpdateNotifyPosts(ctx context.Context, followerID, followingID primitive.ObjectID, notify bool) error {
+	return r.updateFields(ctx, bson.M{"follower_id": followerID, "following_id": followingID}, bson.M{"notify_posts": notify})
+}
+
+// BulkCreate inserts several follows, stopping at the first error
+func (r *FollowRepository) BulkCreate(ctx context.Context, follows []*models.Follow) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(follows))
+	for _, follow := range follows {
+		id, err := r.Create(ctx, follow)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkDelete removes several follows and returns how many were removed
+func (r *FollowRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	return r.removeMany(ctx, ids)
+}
+
+// CountFollowers counts a user's accepted followers
+func (r *FollowRepository) CountFollowers(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.count(ctx, bson.M{"following_id": userID, "status": "accepted"})
+}
+
+// CountFollowing counts the users a user follows
+func (r *FollowRepository) CountFollowing(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.count(ctx, bson.M{"follower_id": userID, "status": "accepted"})
+}
+
+// IsFollowing reports whether followerID follows followingID with an accepted follow
+func (r *FollowRepository) IsFollowing(ctx context.Context, followerID, followingID primitive.ObjectID) (bool, error) {
+	count, err := r.count(ctx, bson.M{"follower_id": followerID, "following_id": followingID, "status": "accepted"})
+	return count > 0, err
+}
+
+// List retrieves follows with an arbitrary filter and sort
+func (r *FollowRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Follow, int, error) {
+	return findPage[models.Follow](ctx, r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
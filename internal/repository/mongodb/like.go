@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// LikeRepository implements interfaces.LikeRepository using MongoDB
+type LikeRepository struct {
+	store
+}
+
+var _ interfaces.LikeRepository = (*LikeRepository)(nil)
+
+// NewLikeRepository creates a new MongoDB like repository
+func NewLikeRepository(db *mongo.Database) *LikeRepository {
+	return &LikeRepository{store: newStore(db, constants.CollectionLikes)}
+}
+
+// Create inserts a new like. Liking the same content twice is a duplicate key error.
+func (r *LikeRepository) Create(ctx context.Context, like *models.Like) (primitive.ObjectID, error) {
+	if like.ID.IsZero() {
+		like.ID = primitive.NewObjectID()
+	}
+	if like.CreatedAt.IsZero() {
+		like.CreatedAt = time.Now()
+	}
+	if like.ReactionType == "" {
+		like.ReactionType = "like"
+	}
+	return r.insert(ctx, like.ID, like)
+}
+
+// GetByID retrieves a like by ID
+func (r *LikeRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Like, error) {
+	return findOne[models.Like](ctx, r.collection, byID(id), nil)
+}
+
+// Delete permanently removes a like
+func (r *LikeRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(ctx, id)
+}
+
+// GetByUserAndContent retrieves a user's like on a piece of content
+func (r *LikeRepository) GetByUserAndContent(ctx context.Context, userID, contentID primitive.ObjectID, contentType string) (*models.Like, error) {
+	return findOne[models.Like](ctx, r.collection, likeOf(userID, contentID, contentType), nil)
+}
+
+// GetByContentID retrieves the likes on a piece of content, newest first
+func (r *LikeRepository) GetByContentID(ctx context.Context, contentID primitive.ObjectID, contentType string, limit, offset int) ([]*models.Like, int, error) {
+	filter := bson.M{"content_id": contentID, "content_type": contentType}
+	return findPage[models.Like](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByUserID retrieves the likes a user made, newest first
+func (r *LikeRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Like, int, error) {
+	return findPage[models.Like](ctx, r.collection, bson.M{"user_id": userID}, newestFirst, limit, offset)
+}
+
+// UpdateReactionType changes the reaction of a like
+func (r *LikeRepository) UpdateReactionType(ctx context.Context, id primitive.ObjectID, reactionType string) error {
+	return matchedOne(r.collection.UpdateOne(ctx, byID(id), bson.M{"$set": bson.M{"reaction_type": reactionType}}))
+}
+
+// GetContentLikeCount counts the likes on a piece of content
+func (r *LikeRepository) GetContentLikeCount(ctx context.Context, contentID primitive.ObjectID, contentType string) (int, error) {
+	return r.count(ctx, bson.M{"content_id": contentID, "content_type": contentType})
+}
+
+// GetContentReactionCounts counts the likes on a piece of content per reaction
+func (r *LikeRepository) GetContentReactionCounts(ctx context.Context, contentID primitive.ObjectID, contentType string) (map[string]int, error) {
+	return r.reactionCounts(ctx, bson.M{"content_id": contentID, "content_type": contentType})
+}
+
+// GetUserLikedContent returns the IDs of the content of a type a user liked, most recent first
+func (r *LikeRepository) GetUserLikedContent(ctx context.Context, userID primitive.ObjectID, contentType string, limit, offset int) ([]primitive.ObjectID, int, error) {
+	filter := bson.M{"user_id": userID, "content_type": contentType}
+	likes, total, err := findPage[models.Like](ctx, r.collection, filter, newestFirst, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(likes))
+	for _, like := range likes {
+		ids = append(ids, like.ContentID)
+	}
+	return ids, total, nil
+}
+
+// CheckIfUserLiked reports whether a user liked a piece of content and with which reaction
+func (r *LikeRepository) CheckIfUserLiked(ctx context.Context, userID, contentID primitive.ObjectID, contentType string) (bool, string, error) {
+	like, err := r.GetByUserAndContent(ctx, userID, contentID, contentType)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return true, like.ReactionType, nil
+}
+
+// GetTopReactedContent returns the IDs of the content of a type with the most likes
+func (r *LikeRepository) GetTopReactedContent(ctx context.Context, contentType string, limit int) ([]primitive.ObjectID, error) {
+	return topIDs(ctx, r.collection, bson.M{"content_type": contentType}, "content_id", limit)
+}
+
+// GetUserMostUsedReactions counts the likes a user made per reaction
+func (r *LikeRepository) GetUserMostUsedReactions(ctx context.Context, userID primitive.ObjectID) (map[string]int, error) {
+	return r.reactionCounts(ctx, bson.M{"user_id": userID})
+}
+
+// BulkCreate inserts several likes, stopping at the first error
+func (r *LikeRepository) BulkCreate(ctx context.Context, likes []*models.Like) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(likes))
+	for _, like := range likes {
+		id, err := r.Create(ctx, like)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkDelete removes several likes and returns how many were removed
+func (r *LikeRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	return r.removeMany(ctx, ids)
+}
+
+// DeleteByUserAndContent removes a user's like on a piece of content
+func (r *LikeRepository) DeleteByUserAndContent(ctx context.Context, userID, contentID primitive.ObjectID, contentType string) error {
+	return r.removeWhere(ctx, likeOf(userID, contentID, contentType))
+}
+
+// List retrieves likes with an arbitrary filter and sort
+func (r *LikeRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Like, int, error) {
+	return findPage[models.Like](ctx, r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// reactionCounts counts the likes matching filter per reaction
+func (r *LikeRepository) reactionCounts(ctx context.Context, filter bson.M) (map[string]int, error) {
+	return countBy(ctx, r.collection, filter, "reaction_type")
+}
+
+// likeOf matches a user's like on a piece of content
+func likeOf(userID, contentID primitive.ObjectID, contentType string) bson.M {
+	return bson.M{"user_id": userID, "content_id": contentID, "content_type": contentType}
+}
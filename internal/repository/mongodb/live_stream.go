@@ -0,0 +1,526 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Collections holding the viewers, chat and reactions of live streams
+const (
+	collectionLiveStreamViewers   = "live_stream_viewers"
+	collectionLiveStreamComments  = "live_stream_comments"
+	collectionLiveStreamReactions = "live_stream_reactions"
+)
+
+// LiveStreamRepository implements interfaces.LiveStreamRepository using MongoDB
+type LiveStreamRepository struct {
+	store
+	viewers   store
+	comments  store
+	reactions store
+	follows   *mongo.Collection
+}
+
+var _ interfaces.LiveStreamRepository = (*LiveStreamRepository)(nil)
+
+// NewLiveStreamRepository creates a new MongoDB live stream repository
+func NewLiveStreamRepository(db *mongo.Database) *LiveStreamRepository {
+	return &LiveStreamRepository{
+		store:     newStore(db, constants.CollectionLiveStreams),
+		viewers:   newStore(db, collectionLiveStreamViewers),
+		comments:  newStore(db, collectionLiveStreamComments),
+		reactions: newStore(db, collectionLiveStreamReactions),
+		follows:   db.Collection(constants.CollectionFollows),
+	}
+}
+
+// Create inserts a new live stream, scheduled unless a status is given.
+// Stream keys are unique.
+func (r *LiveStreamRepository) Create(ctx context.Context, liveStream *models.LiveStream) (primitive.ObjectID, error) {
+	now := time.Now()
+	if liveStream.ID.IsZero() {
+		liveStream.ID = primitive.NewObjectID()
+	}
+	if liveStream.Status == "" {
+		liveStream.Status = "scheduled"
+	}
+	if liveStream.CreatedAt.IsZero() {
+		liveStream.CreatedAt = now
+	}
+	liveStream.UpdatedAt = now
+	return r.insert(ctx, liveStream.ID, liveStream)
+}
+
+// GetByID retrieves a live stream by ID
+func (r *LiveStreamRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.LiveStream, error) {
+	return findOne[models.LiveStream](ctx, r.collection, byID(id), nil)
+}
+
+// Update replaces a live stream document
+func (r *LiveStreamRepository) Update(ctx context.Context, liveStream *models.LiveStream) error {
+	liveStream.UpdatedAt = time.Now()
+	return r.replace(ctx, liveStream.ID, liveStream)
+}
+
+// Delete permanently removes a live stream with its viewers, comments and reactions
+func (r *LiveStreamRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := r.remove(ctx, id); err != nil {
+		return err
+	}
+	for _, s := range []store{r.viewers, r.comments, r.reactions} {
+		if _, err := s.collection.DeleteMany(ctx, bson.M{"live_stream_id": id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByUserID retrieves a user's streams, optionally by status, newest first
+func (r *LiveStreamRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, status string, limit, offset int) ([]*models.LiveStream, int, error) {
+	filter := bson.M{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+	return findPage[models.LiveStream](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetActive retrieves the public streams that are live, most watched first
+func (r *LiveStreamRepository) GetActive(ctx context.Context, limit, offset int) ([]*models.LiveStream, int, error) {
+	return findPage[models.LiveStream](ctx, r.collection, liveNow(), byViewers, limit, offset)
+}
+
+// GetScheduled retrieves the public streams scheduled to start in the future, soonest first
+func (r *LiveStreamRepository) GetScheduled(ctx context.Context, limit, offset int) ([]*models.LiveStream, int, error) {
+	filter := bson.M{"status": "scheduled", "privacy": "public", "scheduled_start_time": bson.M{"$gte": time.Now()}}
+	sort := bson.D{{Key: "scheduled_start_time", Value: 1}, {Key: "_id", Value: 1}}
+	return findPage[models.LiveStream](ctx, r.collection, filter, sort, limit, offset)
+}
+
+// GetByIDs retrieves several live streams by ID
+func (r *LiveStreamRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.LiveStream, error) {
+	if len(ids) == 0 {
+		return []*models.LiveStream{}, nil
+	}
+	return findAll[models.LiveStream](ctx, r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// UpdateStatus sets the status of a live stream
+func (r *LiveStreamRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"status": status})
+}
+
+// StartStream moves a scheduled or failed stream to live
+func (r *LiveStreamRepository) StartStream(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "status": bson.M{"$in": bson.A{"scheduled", "failed"}}}
+	return r.updateFields(ctx, filter, bson.M{"status": "live", "actual_start_time": time.Now()})
+}
+
+// EndStream ends a live stream, records its duration and signs off every active viewer
+func (r *LiveStreamRepository) EndStream(ctx context.Context, id primitive.ObjectID) error {
+	stream, err := findOne[models.LiveStream](ctx, r.collection, bson.M{"_id": id, "status": "live"}, nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	fields := bson.M{"status": "ended", "end_time": now, "viewer_count": 0}
+	if stream.ActualStartTime != nil {
+		fields["duration"] = int(now.Sub(*stream.ActualStartTime).Seconds())
+	}
+	if err := r.updateFields(ctx, bson.M{"_id": id, "status": "live"}, fields); err != nil {
+		return err
+	}
+
+	viewers, err := findAll[models.LiveStreamViewer](ctx, r.viewers.collection, bson.M{"live_stream_id": id, "is_active": true}, nil)
+	if err != nil {
+		return err
+	}
+	for _, viewer := range viewers {
+		if err := r.signOff(ctx, viewer, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IncrementViewerCount atomically increments the current viewer counter
+func (r *LiveStreamRepository) IncrementViewerCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(ctx, id, "viewer_count", 1)
+}
+
+// DecrementViewerCount atomically decrements the current viewer counter
+func (r *LiveStreamRepository) DecrementViewerCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(ctx, id, "viewer_count", -1)
+}
+
+// UpdatePeakViewerCount raises the peak viewer counter to the current viewer count
+func (r *LiveStreamRepository) UpdatePeakViewerCount(ctx context.Context, id primitive.ObjectID) error {
+	return matchedOne(r.collection.UpdateOne(ctx, byID(id), mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"peak_viewer_count": bson.M{"$max": bson.A{"$peak_viewer_count", "$viewer_count"}},
+			"updated_at":        time.Now(),
+		}}},
+	}))
+}
+
+// IncrementTotalViews atomically increments the total view counter
+func (r *LiveStreamRepository) IncrementTotalViews(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(ctx, id, "total_views", 1)
+}
+
+// IncrementLikeCount atomically increments the like counter
+func (r *LiveStreamRepository) IncrementLikeCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(ctx, id, "like_count", 1)
+}
+
+// IncrementCommentCount atomically increments the comment counter
+func (r *LiveStreamRepository) IncrementCommentCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(ctx, id, "comment_count", 1)
+}
+
+// IncrementShareCount atomically increments the share counter
+func (r *LiveStreamRepository) IncrementShareCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(ctx, id, "share_count", 1)
+}
+
+// AddViewer records a user joining a stream and updates the viewer counters.
+// A user already watching keeps their existing viewer record.
+func (r *LiveStreamRepository) AddViewer(ctx context.Context, streamID, userID primitive.ObjectID, device, platform string) (primitive.ObjectID, error) {
+	existing, err := findOne[models.LiveStreamViewer](ctx, r.viewers.collection, activeViewer(streamID, userID), nil)
+	if err == nil {
+		return existing.ID, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, err
+	}
+
+	viewer := &models.LiveStreamViewer{
+		ID:           primitive.NewObjectID(),
+		LiveStreamID: streamID,
+		UserID:       userID,
+		JoinedAt:     time.Now(),
+		Device:       device,
+		Platform:     platform,
+		IsActive:     true,
+	}
+	id, err := r.viewers.insert(ctx, viewer.ID, viewer)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if err := r.update(ctx, byID(streamID), bson.M{"$inc": bson.M{"viewer_count": 1, "total_views": 1}}); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return id, r.UpdatePeakViewerCount(ctx, streamID)
+}
+
+// RemoveViewer records a user leaving a stream
+func (r *LiveStreamRepository) RemoveViewer(ctx context.Context, streamID, userID primitive.ObjectID) error {
+	viewer, err := findOne[models.LiveStreamViewer](ctx, r.viewers.collection, activeViewer(streamID, userID), nil)
+	if err != nil {
+		return err
+	}
+	if err := r.signOff(ctx, viewer, time.Now()); err != nil {
+		return err
+	}
+	return r.increment(ctx, streamID, "viewer_count", -1)
+}
+
+// GetViewers retrieves everyone who watched a stream, in join order
+func (r *LiveStreamRepository) GetViewers(ctx context.Context, streamID primitive.ObjectID, limit, offset int) ([]*models.LiveStreamViewer, int, error) {
+	return findPage[models.LiveStreamViewer](ctx, r.viewers.collection, bson.M{"live_stream_id": streamID}, byJoinTime, limit, offset)
+}
+
+// GetActiveViewers retrieves everyone currently watching a stream
+func (r *LiveStreamRepository) GetActiveViewers(ctx context.Context, streamID primitive.ObjectID) ([]*models.LiveStreamViewer, error) {
+	filter := bson.M{"live_stream_id": streamID, "is_active": true}
+	return findAll[models.LiveStreamViewer](ctx, r.viewers.collection, filter, byJoinTime)
+}
+
+// AddComment posts a chat comment and increments the stream's comment counter.
+// The offset from the stream start is filled in when missing.
+func (r *LiveStreamRepository) AddComment(ctx context.Context, comment *models.LiveStreamComment) (primitive.ObjectID, error) {
+	stream, err := r.GetByID(ctx, comment.LiveStreamID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	now := time.Now()
+	if comment.ID.IsZero() {
+		comment.ID = primitive.NewObjectID()
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = now
+	}
+	if comment.TimestampSec == 0 && stream.ActualStartTime != nil {
+		comment.TimestampSec = int(comment.CreatedAt.Sub(*stream.ActualStartTime).Seconds())
+	}
+
+	id, err := r.comments.insert(ctx, comment.ID, comment)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if _, err := r.viewers.collection.UpdateOne(ctx,
+		activeViewer(comment.LiveStreamID, comment.UserID),
+		bson.M{"$inc": bson.M{"comment_count": 1}},
+	); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return id, r.increment(ctx, comment.LiveStreamID, "comment_count", 1)
+}
+
+// GetComments retrieves the visible chat of a stream in the order it was posted
+func (r *LiveStreamRepository) GetComments(ctx context.Context, streamID primitive.ObjectID, limit, offset int) ([]*models.LiveStreamComment, int, error) {
+	filter := bson.M{"live_stream_id": streamID, "is_hidden": false}
+	return findPage[models.LiveStreamComment](ctx, r.comments.collection, filter, oldestFirst, limit, offset)
+}
+
+// DeleteComment removes a chat comment and decrements the stream's comment counter
+func (r *LiveStreamRepository) DeleteComment(ctx context.Context, commentID primitive.ObjectID) error {
+	comment, err := findOne[models.LiveStreamComment](ctx, r.comments.collection, byID(commentID), nil)
+	if err != nil {
+		return err
+	}
+	if err := r.comments.remove(ctx, commentID); err != nil {
+		return err
+	}
+	return r.increment(ctx, comment.LiveStreamID, "comment_count", -1)
+}
+
+// PinComment pins or unpins a chat comment
+func (r *LiveStreamRepository) PinComment(ctx context.Context, commentID primitive.ObjectID, isPinned bool) error {
+	return matchedOne(r.comments.collection.UpdateOne(ctx, byID(commentID), bson.M{"$set": bson.M{"is_pinned": isPinned}}))
+}
+
+// ModerateComment hides or unhides a chat comment and records who moderated it and why
+func (r *LiveStreamRepository) ModerateComment(ctx context.Context, commentID primitive.ObjectID, isHidden bool, moderatorID primitive.ObjectID, reason string) error {
+	return matchedOne(r.comments.collection.UpdateOne(ctx, byID(commentID), bson.M{"$set": bson.M{
+		"is_hidden":        isHidden,
+		"is_moderated":     true,
+		"moderated_by":     moderatorID,
+		"moderated_at":     time.Now(),
+		"moderated_reason": reason,
+	}}))
+}
+
+// AddReaction records a reaction to a stream or to one of its comments
+func (r *LiveStreamRepository) AddReaction(ctx context.Context, reaction *models.LiveStreamReaction) (primitive.ObjectID, error) {
+	if reaction.ID.IsZero() {
+		reaction.ID = primitive.NewObjectID()
+	}
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now()
+	}
+	if reaction.TargetType == "" {
+		reaction.TargetType = "stream"
+	}
+
+	if reaction.TargetType == "comment" && reaction.TargetID != nil {
+		if err := matchedOne(r.comments.collection.UpdateOne(ctx,
+			bson.M{"_id": *reaction.TargetID, "live_stream_id": reaction.LiveStreamID},
+			bson.M{"$inc": bson.M{"reaction_count": 1}},
+		)); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+
+	id, err := r.reactions.insert(ctx, reaction.ID, reaction)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	_, err = r.viewers.collection.UpdateOne(ctx,
+		activeViewer(reaction.LiveStreamID, reaction.UserID),
+		bson.M{"$inc": bson.M{"reaction_count": 1}},
+	)
+	return id, err
+}
+
+// GetReactions retrieves the reactions to a stream, newest first
+func (r *LiveStreamRepository) GetReactions(ctx context.Context, streamID primitive.ObjectID, limit, offset int) ([]*models.LiveStreamReaction, int, error) {
+	return findPage[models.LiveStreamReaction](ctx, r.reactions.collection, bson.M{"live_stream_id": streamID}, newestFirst, limit, offset)
+}
+
+// UpdateChatSettings replaces the chat settings of a stream
+func (r *LiveStreamRepository) UpdateChatSettings(ctx context.Context, streamID primitive.ObjectID, settings models.LiveStreamChatSettings) error {
+	return r.updateFields(ctx, byID(streamID), bson.M{"chat_settings": settings})
+}
+
+// AddModerator makes a user a chat moderator of a stream
+func (r *LiveStreamRepository) AddModerator(ctx context.Context, streamID, userID primitive.ObjectID) error {
+	return r.update(ctx, byID(streamID), bson.M{"$addToSet": bson.M{"chat_settings.moderator_ids": userID}})
+}
+
+// RemoveModerator removes a chat moderator from a stream
+func (r *LiveStreamRepository) RemoveModerator(ctx context.Context, streamID, userID primitive.ObjectID) error {
+	return r.update(ctx, byID(streamID), bson.M{"$pull": bson.M{"chat_settings.moderator_ids": userID}})
+}
+
+// BanUser bans a user from a stream's chat
+func (r *LiveStreamRepository) BanUser(ctx context.Context, streamID, userID primitive.ObjectID) error {
+	return r.update(ctx, byID(streamID), bson.M{"$addToSet": bson.M{"chat_settings.banned_users": userID}})
+}
+
+// UnbanUser lifts a user's chat ban
+func (r *LiveStreamRepository) UnbanUser(ctx context.Context, streamID, userID primitive.ObjectID) error {
+	return r.update(ctx, byID(streamID), bson.M{"$pull": bson.M{"chat_settings.banned_users": userID}})
+}
+
+// EnableRecording turns recording on for a stream
+func (r *LiveStreamRepository) EnableRecording(ctx context.Context, streamID primitive.ObjectID) error {
+	return r.updateFields(ctx, byID(streamID), bson.M{"recording_enabled": true})
+}
+
+// DisableRecording turns recording off for a stream
+func (r *LiveStreamRepository) DisableRecording(ctx context.Context, streamID primitive.ObjectID) error {
+	return r.updateFields(ctx, byID(streamID), bson.M{"recording_enabled": false})
+}
+
+// UpdateRecordingURL stores where the recording of a stream can be played back
+func (r *LiveStreamRepository) UpdateRecordingURL(ctx context.Context, streamID primitive.ObjectID, url string) error {
+	return r.updateFields(ctx, byID(streamID), bson.M{"recording_url": url})
+}
+
+// GetTopStreams retrieves the most watched public streams that are live
+func (r *LiveStreamRepository) GetTopStreams(ctx context.Context, limit int) ([]*models.LiveStream, error) {
+	return findLimit[models.LiveStream](ctx, r.collection, liveNow(), byViewers, limit)
+}
+
+// GetRecommendedStreams retrieves public live streams by other users, streams
+// from accounts the user follows first and then the most watched
+func (r *LiveStreamRepository) GetRecommendedStreams(ctx context.Context, userID primitive.ObjectID, limit int) ([]*models.LiveStream, error) {
+	limit, _ = mongoutil.NormalizePagination(limit, 0)
+
+	values, err := r.follows.Distinct(ctx, "following_id", bson.M{"follower_id": userID, "status": "accepted"})
+	if err != nil {
+		return nil, err
+	}
+	following := objectIDs(values)
+
+	streams, err := findLimit[models.LiveStream](ctx, r.collection,
+		mongoutil.Merge(liveNow(), bson.M{"user_id": bson.M{"$in": following, "$ne": userID}}),
+		byViewers, limit)
+	if err != nil || len(streams) >= limit {
+		return streams, err
+	}
+
+	others, err := findLimit[models.LiveStream](ctx, r.collection,
+		mongoutil.Merge(liveNow(), bson.M{"user_id": bson.M{"$nin": append(following, userID)}}),
+		byViewers, limit-len(streams))
+	if err != nil {
+		return nil, err
+	}
+	return append(streams, others...), nil
+}
+
+// GetStreamsByCategories retrieves public streams in any of the categories, newest first
+func (r *LiveStreamRepository) GetStreamsByCategories(ctx context.Context, categories []string, limit, offset int) ([]*models.LiveStream, int, error) {
+	filter := bson.M{"categories": bson.M{"$in": categories}, "privacy": "public"}
+	return findPage[models.LiveStream](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetStreamsByTags retrieves public streams with any of the tags, newest first
+func (r *LiveStreamRepository) GetStreamsByTags(ctx context.Context, tags []string, limit, offset int) ([]*models.LiveStream, int, error) {
+	filter := bson.M{"tags": bson.M{"$in": tags}, "privacy": "public"}
+	return findPage[models.LiveStream](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetStreamAnalytics summarises the audience and engagement of a stream
+func (r *LiveStreamRepository) GetStreamAnalytics(ctx context.Context, streamID primitive.ObjectID) (map[string]interface{}, error) {
+	stream, err := r.GetByID(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	audience, err := aggregate[struct {
+		Visits  int `bson:"visits"`
+		Watched int `bson:"watched"`
+		Users   int `bson:"users"`
+	}](ctx, r.viewers.collection, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"live_stream_id": streamID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"visits":  bson.M{"$sum": 1},
+			"watched": bson.M{"$sum": "$duration"},
+			"users":   bson.M{"$addToSet": "$user_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{"visits": 1, "watched": 1, "users": bson.M{"$size": "$users"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	unique, averageWatch := 0, 0.0
+	if len(audience) > 0 && audience[0].Visits > 0 {
+		unique = audience[0].Users
+		averageWatch = float64(audience[0].Watched) / float64(audience[0].Visits)
+	}
+
+	reactionCounts, err := countBy(ctx, r.reactions.collection, bson.M{"live_stream_id": streamID}, "type")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"stream_id":              streamID,
+		"status":                 stream.Status,
+		"duration":               stream.Duration,
+		"viewer_count":           stream.ViewerCount,
+		"peak_viewer_count":      stream.PeakViewerCount,
+		"total_views":            stream.TotalViews,
+		"unique_viewers":         unique,
+		"average_watch_duration": averageWatch,
+		"like_count":             stream.LikeCount,
+		"comment_count":          stream.CommentCount,
+		"share_count":            stream.ShareCount,
+		"reactions":              reactionCounts,
+	}, nil
+}
+
+// GetStreamsByTimeRange retrieves the streams that started within a time range, newest first
+func (r *LiveStreamRepository) GetStreamsByTimeRange(ctx context.Context, startTime, endTime time.Time, limit, offset int) ([]*models.LiveStream, int, error) {
+	filter := inRange(bson.M{"actual_start_time": bson.M{"$ne": nil}}, "actual_start_time", startTime, endTime)
+	sort := bson.D{{Key: "actual_start_time", Value: -1}, {Key: "_id", Value: -1}}
+	return findPage[models.LiveStream](ctx, r.collection, filter, sort, limit, offset)
+}
+
+// List retrieves live streams with an arbitrary filter and sort
+func (r *LiveStreamRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.LiveStream, int, error) {
+	return findPage[models.LiveStream](ctx, r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// Search finds public streams whose title, description or tags contain the query
+func (r *LiveStreamRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.LiveStream, int, error) {
+	filter := and(bson.M{"privacy": "public"}, textFilter(query, "title", "description", "tags"))
+	return findPage[models.LiveStream](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// signOff marks a viewer as gone and records how long they watched
+func (r *LiveStreamRepository) signOff(ctx context.Context, viewer *models.LiveStreamViewer, at time.Time) error {
+	return matchedOne(r.viewers.collection.UpdateOne(ctx, byID(viewer.ID), bson.M{"$set": bson.M{
+		"is_active": false,
+		"left_at":   at,
+		"duration":  int(at.Sub(viewer.JoinedAt).Seconds()),
+	}}))
+}
+
+// activeViewer matches a user's current viewer record for a stream
+func activeViewer(streamID, userID primitive.ObjectID) bson.M {
+	return bson.M{"live_stream_id": streamID, "user_id": userID, "is_active": true}
+}
+
+// liveNow matches public streams that are live
+func liveNow() bson.M {
+	return bson.M{"status": "live", "privacy": "public"}
+}
+
+// byViewers sorts streams by current viewers, most first
+var byViewers = bson.D{{Key: "viewer_count", Value: -1}, {Key: "_id", Value: -1}}
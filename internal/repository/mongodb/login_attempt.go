@@ -0,0 +1,151 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// LoginAttemptRepository implements interfaces.LoginAttemptRepository using MongoDB
+type LoginAttemptRepository struct {
+	failures *mongo.Collection
+	lockouts *mongo.Collection
+}
+
+var _ interfaces.LoginAttemptRepository = (*LoginAttemptRepository)(nil)
+
+// NewLoginAttemptRepository creates a new MongoDB login attempt repository
+func NewLoginAttemptRepository(db *mongo.Database) *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		failures: db.Collection(constants.CollectionLoginFailures),
+		lockouts: db.Collection(constants.CollectionLoginLockouts),
+	}
+}
+
+// RecordFailure inserts a failed login
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, failure *models.LoginFailure) error {
+	if failure.ID.IsZero() {
+		failure.ID = primitive.NewObjectID()
+	}
+	if failure.CreatedAt.IsZero() {
+		failure.CreatedAt = time.Now()
+	}
+	_, err := r.failures.InsertOne(ctx, failure)
+	return err
+}
+
+// CountByEmail counts the failures for an account since a time
+func (r *LoginAttemptRepository) CountByEmail(ctx context.Context, email string, since time.Time) (int, error) {
+	return r.count(ctx, bson.M{"email": email, "created_at": bson.M{"$gte": since}})
+}
+
+// CountByIP counts the failures from an IP address since a time
+func (r *LoginAttemptRepository) CountByIP(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	return r.count(ctx, bson.M{"ip_address": ipAddress, "created_at": bson.M{"$gte": since}})
+}
+
+// CountBySubnet counts the failures from a subnet since a time
+func (r *LoginAttemptRepository) CountBySubnet(ctx context.Context, subnet string, since time.Time) (int, error) {
+	return r.count(ctx, bson.M{"subnet": subnet, "created_at": bson.M{"$gte": since}})
+}
+
+// CountEmailsByIP counts the distinct accounts an IP address failed to log in to since a time
+func (r *LoginAttemptRepository) CountEmailsByIP(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	emails, err := r.failures.Distinct(ctx, "email", bson.M{
+		"ip_address": ipAddress,
+		"email":      bson.M{"$ne": ""},
+		"created_at": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(emails), nil
+}
+
+// LastFailure retrieves the most recent failure for an account
+func (r *LoginAttemptRepository) LastFailure(ctx context.Context, email string) (*models.LoginFailure, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var failure models.LoginFailure
+	if err := r.failures.FindOne(ctx, bson.M{"email": email}, opts).Decode(&failure); err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// ClearFailures detaches the failures from an account so they stop counting
+// against it. They still count against the IP addresses and subnets they came
+// from until they expire.
+func (r *LoginAttemptRepository) ClearFailures(ctx context.Context, email string) error {
+	_, err := r.failures.UpdateMany(ctx,
+		bson.M{"email": email},
+		bson.M{"$set": bson.M{"email": ""}},
+	)
+	return err
+}
+
+// SaveLockout stores a lockout, replacing any earlier lockout of the account
+func (r *LoginAttemptRepository) SaveLockout(ctx context.Context, lockout *models.LoginLockout) error {
+	if lockout.ID.IsZero() {
+		lockout.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.lockouts.UpdateOne(ctx,
+		bson.M{"email": lockout.Email},
+		bson.M{
+			"$set": bson.M{
+				"user_id":         lockout.UserID,
+				"failures":        lockout.Failures,
+				"last_ip_address": lockout.LastIPAddress,
+				"locked_at":       lockout.LockedAt,
+				"locked_until":    lockout.LockedUntil,
+			},
+			"$setOnInsert": bson.M{"_id": lockout.ID},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// FindLockout retrieves the lockout of an account if it is still in force
+func (r *LoginAttemptRepository) FindLockout(ctx context.Context, email string, now time.Time) (*models.LoginLockout, error) {
+	return r.findLockout(ctx, bson.M{"email": email, "locked_until": bson.M{"$gt": now}})
+}
+
+// FindLockoutByUserID retrieves the lockout of a user if it is still in force
+func (r *LoginAttemptRepository) FindLockoutByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.LoginLockout, error) {
+	return r.findLockout(ctx, bson.M{"user_id": userID, "locked_until": bson.M{"$gt": now}})
+}
+
+// DeleteLockout lifts the lockout of an account
+func (r *LoginAttemptRepository) DeleteLockout(ctx context.Context, email string) error {
+	result, err := r.lockouts.DeleteOne(ctx, bson.M{"email": email})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) count(ctx context.Context, filter bson.M) (int, error) {
+	count, err := r.failures.CountDocuments(ctx, filter)
+	return int(count), err
+}
+
+func (r *LoginAttemptRepository) findLockout(ctx context.Context, filter bson.M) (*models.LoginLockout, error) {
+	var lockout models.LoginLockout
+	if err := r.lockouts.FindOne(ctx, filter).Decode(&lockout); err != nil {
+		return nil, err
+	}
+	return &lockout, nil
+}
@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// LoginHistoryRepository implements interfaces.LoginHistoryRepository using MongoDB
+type LoginHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.LoginHistoryRepository = (*LoginHistoryRepository)(nil)
+
+// NewLoginHistoryRepository creates a new MongoDB login history repository
+func NewLoginHistoryRepository(db *mongo.Database) *LoginHistoryRepository {
+	return &LoginHistoryRepository{
+		collection: db.Collection(constants.CollectionLoginHistory),
+	}
+}
+
+// Create inserts a login record
+func (r *LoginHistoryRepository) Create(ctx context.Context, record *models.LoginRecord) error {
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, record)
+	return err
+}
+
+// FindLatest retrieves a user's most recent login
+func (r *LoginHistoryRepository) FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.LoginRecord, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var record models.LoginRecord
+	if err := r.collection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// HasDevice reports whether a user has logged in from a kind of device before
+func (r *LoginHistoryRepository) HasDevice(ctx context.Context, userID primitive.ObjectID, deviceKey string) (bool, error) {
+	return r.exists(ctx, bson.M{"user_id": userID, "device_key": deviceKey})
+}
+
+// HasCountry reports whether a user has logged in from a country before
+func (r *LoginHistoryRepository) HasCountry(ctx context.Context, userID primitive.ObjectID, countryCode string) (bool, error) {
+	return r.exists(ctx, bson.M{"user_id": userID, "country_code": countryCode})
+}
+
+func (r *LoginHistoryRepository) exists(ctx context.Context, filter bson.M) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,287 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// collectionMediaAssociations links media to the content that uses it
+const collectionMediaAssociations = "media_associations"
+
+// MediaRepository implements interfaces.MediaRepository using MongoDB. The
+// Media model has no record of where it is used, so content associations live
+// in their own collection and survive Update.
+type MediaRepository struct {
+	store
+	associations *mongo.Collection
+}
+
+var _ interfaces.MediaRepository = (*MediaRepository)(nil)
+
+// NewMediaRepository creates a new MongoDB media repository
+func NewMediaRepository(db *mongo.Database) *MediaRepository {
+	return &MediaRepository{
+		store:        newStore(db, constants.CollectionMedia),
+		associations: db.Collection(collectionMediaAssociations),
+	}
+}
+
+// Create inserts a new media item, pending processing unless a status is given
+func (r *MediaRepository) Create(ctx context.Context, media *models.Media) (primitive.ObjectID, error) {
+	now := time.Now()
+	if media.ID.IsZero() {
+		media.ID = primitive.NewObjectID()
+	}
+	if media.ProcessingStatus == "" {
+		media.ProcessingStatus = "pending"
+	}
+	if media.UploadedAt.IsZero() {
+		media.UploadedAt = now
+	}
+	if media.CreatedAt.IsZero() {
+		media.CreatedAt = now
+	}
+	media.UpdatedAt = now
+	return r.insert(ctx, media.ID, media)
+}
+
+// GetByID retrieves a media item by ID
+func (r *MediaRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Media, error) {
+	return findOne[models.Media](ctx, r.collection, byID(id), nil)
+}
+
+// Update replaces a media document
+func (r *MediaRepository) Update(ctx context.Context, media *models.Media) error {
+	media.UpdatedAt = time.Now()
+	return r.replace(ctx, media.ID, media)
+}
+
+// Delete permanently removes a media item and its content associations
+func (r *MediaRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := r.remove(ctx, id); err != nil {
+		return err
+	}
+	_, err := r.associations.DeleteMany(ctx, bson.M{"media_id": id})
+	return err
+}
+
+// GetByUserID retrieves a user's uploads, optionally of one type, newest first
+func (r *MediaRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, mediaType string, limit, offset int) ([]*models.Media, int, error) {
+	filter := bson.M{"user_id": userID}
+	if mediaType != "" {
+		filter["type"] = mediaType
+	}
+	return findPage[models.Media](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByIDs retrieves several media items by ID
+func (r *MediaRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Media, error) {
+	if len(ids) == 0 {
+		return []*models.Media{}, nil
+	}
+	return findAll[models.Media](ctx, r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// UpdateProcessingStatus records the processing state of a media item
+func (r *MediaRepository) UpdateProcessingStatus(ctx context.Context, id primitive.ObjectID, status string, isProcessed bool) error {
+	fields := bson.M{"processing_status": status, "is_processed": isProcessed}
+	if isProcessed {
+		fields["processed_at"] = time.Now()
+	}
+	return r.updateFields(ctx, byID(id), fields)
+}
+
+// MarkAsProcessed marks a media item as successfully processed
+func (r *MediaRepository) MarkAsProcessed(ctx context.Context, id primitive.ObjectID) error {
+	return r.UpdateProcessingStatus(ctx, id, "completed", true)
+}
+
+// GetUnprocessedMedia retrieves media still waiting for processing, oldest upload first
+func (r *MediaRepository) GetUnprocessedMedia(ctx context.Context, limit int) ([]*models.Media, error) {
+	filter := bson.M{"is_processed": false, "processing_status": bson.M{"$ne": "failed"}}
+	sort := bson.D{{Key: "uploaded_at", Value: 1}, {Key: "_id", Value: 1}}
+	return findLimit[models.Media](ctx, r.collection, filter, sort, limit)
+}
+
+// UpdateMetadata merges keys into the metadata of a media item
+func (r *MediaRepository) UpdateMetadata(ctx context.Context, id primitive.ObjectID, metadata map[string]interface{}) error {
+	fields := bson.M{}
+	for key, value := range metadata {
+		fields["metadata."+key] = value
+	}
+	return r.updateFields(ctx, byID(id), fields)
+}
+
+// UpdateDimensions records the width and height of a media item
+func (r *MediaRepository) UpdateDimensions(ctx context.Context, id primitive.ObjectID, width, height int) error {
+	return r.updateFields(ctx, byID(id), bson.M{"width": width, "height": height})
+}
+
+// UpdateDuration records the playing time of an audio or video item
+func (r *MediaRepository) UpdateDuration(ctx context.Context, id primitive.ObjectID, duration float64) error {
+	return r.updateFields(ctx, byID(id), bson.M{"duration": duration})
+}
+
+// UpdateAltText sets the alternative text of a media item
+func (r *MediaRepository) UpdateAltText(ctx context.Context, id primitive.ObjectID, altText string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"alt_text": altText})
+}
+
+// UpdateCaption sets the caption of a media item
+func (r *MediaRepository) UpdateCaption(ctx context.Context, id primitive.ObjectID, caption string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"caption": caption})
+}
+
+// UpdateURL sets where a media item is served from
+func (r *MediaRepository) UpdateURL(ctx context.Context, id primitive.ObjectID, url string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"url": url})
+}
+
+// UpdateThumbnailURL sets where the thumbnail of a media item is served from
+func (r *MediaRepository) UpdateThumbnailURL(ctx context.Context, id primitive.ObjectID, thumbnailURL string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"thumbnail_url": thumbnailURL})
+}
+
+// GetMediaUsage returns the total size in bytes of a user's uploads
+func (r *MediaRepository) GetMediaUsage(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	totals, err := aggregate[struct {
+		Total int64 `bson:"total"`
+	}](ctx, r.collection, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$file_size"}}}},
+	})
+	if err != nil || len(totals) == 0 {
+		return 0, err
+	}
+	return totals[0].Total, nil
+}
+
+// GetMediaByType retrieves media of one type, newest first
+func (r *MediaRepository) GetMediaByType(ctx context.Context, mediaType string, limit, offset int) ([]*models.Media, int, error) {
+	return findPage[models.Media](ctx, r.collection, bson.M{"type": mediaType}, newestFirst, limit, offset)
+}
+
+// BulkCreate inserts several media items, stopping at the first error
+func (r *MediaRepository) BulkCreate(ctx context.Context, medias []*models.Media) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(medias))
+	for _, media := range medias {
+		id, err := r.Create(ctx, media)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkDelete removes several media items and returns how many were removed
+func (r *MediaRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	deleted, err := r.removeMany(ctx, ids)
+	if err != nil || deleted == 0 {
+		return deleted, err
+	}
+	_, err = r.associations.DeleteMany(ctx, bson.M{"media_id": bson.M{"$in": ids}})
+	return deleted, err
+}
+
+// GetUserGallery retrieves a user's processed uploads of the given types, newest first
+func (r *MediaRepository) GetUserGallery(ctx context.Context, userID primitive.ObjectID, mediaTypes []string, limit, offset int) ([]*models.Media, int, error) {
+	filter := bson.M{"user_id": userID, "is_processed": true}
+	if len(mediaTypes) > 0 {
+		filter["type"] = bson.M{"$in": mediaTypes}
+	}
+	return findPage[models.Media](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetMediaByContentID retrieves the media attached to a piece of content, in attachment order
+func (r *MediaRepository) GetMediaByContentID(ctx context.Context, contentType string, contentID primitive.ObjectID) ([]*models.Media, error) {
+	links, err := findAll[mediaAssociation](ctx, r.associations, bson.M{"content_type": contentType, "content_id": contentID}, oldestFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(links))
+	for i, link := range links {
+		ids[i] = link.MediaID
+	}
+	found, err := r.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Media, len(found))
+	for _, item := range found {
+		byID[item.ID] = item
+	}
+	media := make([]*models.Media, 0, len(ids))
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			media = append(media, item)
+		}
+	}
+	return media, nil
+}
+
+// AssociateWithContent attaches a media item to a piece of content. Attaching
+// it twice has no further effect.
+func (r *MediaRepository) AssociateWithContent(ctx context.Context, mediaID primitive.ObjectID, contentType string, contentID primitive.ObjectID) error {
+	if _, err := r.GetByID(ctx, mediaID); err != nil {
+		return err
+	}
+	_, err := r.associations.UpdateOne(ctx,
+		bson.M{"media_id": mediaID, "content_type": contentType, "content_id": contentID},
+		bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// DisassociateFromContent detaches a media item from a piece of content
+func (r *MediaRepository) DisassociateFromContent(ctx context.Context, mediaID primitive.ObjectID, contentType string, contentID primitive.ObjectID) error {
+	result, err := r.associations.DeleteOne(ctx, bson.M{
+		"media_id":     mediaID,
+		"content_type": contentType,
+		"content_id":   contentID,
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Search finds media whose file name, caption or alt text contain the query
+func (r *MediaRepository) Search(ctx context.Context, query string, mediaTypes []string, limit, offset int) ([]*models.Media, int, error) {
+	filter := bson.M{}
+	if len(mediaTypes) > 0 {
+		filter["type"] = bson.M{"$in": mediaTypes}
+	}
+	search := and(filter, textFilter(query, "file_name", "caption", "alt_text"))
+	return findPage[models.Media](ctx, r.collection, search, newestFirst, limit, offset)
+}
+
+// List retrieves media with an arbitrary filter and sort
+func (r *MediaRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Media, int, error) {
+	return findPage[models.Media](ctx, r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// mediaAssociation records that a media item is attached to a piece of content
+type mediaAssociation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	MediaID     primitive.ObjectID `bson:"media_id"`
+	ContentType string             `bson:"content_type"`
+	ContentID   primitive.ObjectID `bson:"content_id"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,477 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// MessageRepository implements interfaces.MessageRepository using MongoDB
+type MessageRepository struct {
+	db            *mongo.Database
+	collection    *mongo.Collection
+	conversations *mongo.Collection
+}
+
+var _ interfaces.MessageRepository = (*MessageRepository)(nil)
+
+// NewMessageRepository creates a new MongoDB message repository
+func NewMessageRepository(db *mongo.Database) *MessageRepository {
+	return &MessageRepository{
+		db:            db,
+		collection:    db.Collection(constants.CollectionMessages),
+		conversations: db.Collection(constants.CollectionConversations),
+	}
+}
+
+// EnsureIndexes creates the lookup, TTL and text indexes the repository relies on
+func (r *MessageRepository) EnsureIndexes(ctx context.Context) error {
+	return dbmongo.EnsureIndexes(ctx, r.db, constants.CollectionMessages)
+}
+
+// Create inserts a new message
+func (r *MessageRepository) Create(ctx context.Context, message *models.Message) (primitive.ObjectID, error) {
+	now := time.Now()
+	if message.ID.IsZero() {
+		message.ID = primitive.NewObjectID()
+	}
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = now
+	}
+	message.UpdatedAt = now
+
+	if message.DeliveryStatus == nil {
+		message.DeliveryStatus = map[string]string{}
+	}
+	if !message.SenderID.IsZero() {
+		message.DeliveryStatus[message.SenderID.Hex()] = "sent"
+	}
+
+	if _, err := r.collection.InsertOne(ctx, message); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return message.ID, nil
+}
+
+// GetByID retrieves a message by ID, ignoring expired messages
+func (r *MessageRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Message, error) {
+	var message models.Message
+	filter := mongoutil.Merge(bson.M{"_id": id}, notExpired())
+	if err := r.collection.FindOne(ctx, filter).Decode(&message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Update replaces a message document
+func (r *MessageRepository) Update(ctx context.Context, message *models.Message) error {
+	message.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": message.ID}, message)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete permanently removes a message
+func (r *MessageRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SoftDelete hides a message for a single user ("delete for me").
+// Other participants keep seeing the message.
+func (r *MessageRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"deleted_for": userID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GetByConversationID retrieves the messages in a conversation as seen by
+// userID, newest first. Messages deleted for everyone, deleted by userID for
+// themselves or already expired are excluded.
+func (r *MessageRepository) GetByConversationID(ctx context.Context, conversationID, userID primitive.ObjectID, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(bson.M{"conversation_id": conversationID}, visibleMessagesFor(userID))
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, mongoutil.NewestFirst))
+}
+
+// GetUnreadMessagesCount counts messages from other participants newer than the user's read marker.
+// ObjectIDs increase monotonically, so the count is a range scan on the
+// conversation_id/_id index starting at Participant.LastReadMessageID.
+func (r *MessageRepository) GetUnreadMessagesCount(ctx context.Context, userID, conversationID primitive.ObjectID) (int, error) {
+	var conversation models.Conversation
+	err := r.conversations.FindOne(ctx,
+		bson.M{"_id": conversationID, "participants.user_id": userID},
+		options.FindOne().SetProjection(bson.M{"participants.$": 1}),
+	).Decode(&conversation)
+	if err != nil {
+		return 0, err
+	}
+	if len(conversation.Participants) == 0 {
+		return 0, mongo.ErrNoDocuments
+	}
+	participant := conversation.Participants[0]
+
+	filter := mongoutil.Merge(visibleMessagesFor(userID), bson.M{
+		"conversation_id": conversationID,
+		"sender_id":       bson.M{"$ne": userID},
+	})
+	if participant.LastReadMessageID != nil {
+		filter["_id"] = bson.M{"$gt": *participant.LastReadMessageID}
+	} else if !participant.JoinedAt.IsZero() {
+		filter["_id"] = bson.M{"$gte": primitive.NewObjectIDFromTimestamp(participant.JoinedAt)}
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// GetByIDs retrieves several messages by ID
+func (r *MessageRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Message, error) {
+	if len(ids) == 0 {
+		return []*models.Message{}, nil
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, notExpired())
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decodeAll(ctx, cursor)
+}
+
+// MarkAsDelivered records delivery of a message to a user.
+// A message that was already read is left untouched.
+func (r *MessageRepository) MarkAsDelivered(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	statusKey := "delivery_status." + userID.Hex()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, statusKey: bson.M{"$ne": "read"}},
+		bson.M{"$set": bson.M{statusKey: "delivered", "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// MarkAsRead records that a user read a message and advances their read marker in the conversation
+func (r *MessageRepository) MarkAsRead(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	message, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	statusKey := "delivery_status." + userID.Hex()
+
+	// Set the status and add a single read receipt for the user
+	if _, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{statusKey: "read", "updated_at": now}},
+	); err != nil {
+		return err
+	}
+	if _, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "read_by_users.user_id": bson.M{"$ne": userID}},
+		bson.M{"$push": bson.M{"read_by_users": models.ReadReceipt{UserID: userID, ReadAt: now}}},
+	); err != nil {
+		return err
+	}
+
+	// Only move the read marker forward
+	_, err = r.conversations.UpdateOne(ctx,
+		bson.M{"_id": message.ConversationID},
+		bson.M{"$set": bson.M{
+			"participants.$[p].last_read_message_id": id,
+			"participants.$[p].last_read_at":         now,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{
+				"p.user_id": userID,
+				"$or": bson.A{
+					bson.M{"p.last_read_message_id": nil},
+					bson.M{"p.last_read_message_id": bson.M{"$lt": id}},
+				},
+			},
+		}}),
+	)
+	return err
+}
+
+// AddReaction adds a reaction, ignoring duplicates of the same reaction by the same user
+func (r *MessageRepository) AddReaction(ctx context.Context, id primitive.ObjectID, reaction models.MessageReaction) error {
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{
+			"_id": id,
+			"reactions": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+				"user_id":  reaction.UserID,
+				"reaction": reaction.Reaction,
+			}}},
+		},
+		bson.M{
+			"$push": bson.M{"reactions": reaction},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+// RemoveReaction removes a user's reaction from a message
+func (r *MessageRepository) RemoveReaction(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, reaction string) error {
+	return r.update(ctx, id, bson.M{
+		"$pull": bson.M{"reactions": bson.M{"user_id": userID, "reaction": reaction}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+// EditMessage replaces the content of a message and marks it as edited
+func (r *MessageRepository) EditMessage(ctx context.Context, id primitive.ObjectID, content string) error {
+	return r.update(ctx, id, bson.M{
+		"$set": bson.M{"content": content, "is_edited": true, "updated_at": time.Now()},
+	})
+}
+
+// AddToEditHistory appends an entry to the edit history of a message
+func (r *MessageRepository) AddToEditHistory(ctx context.Context, id primitive.ObjectID, edit models.MessageEdit) error {
+	if edit.EditedAt.IsZero() {
+		edit.EditedAt = time.Now()
+	}
+	return r.update(ctx, id, bson.M{
+		"$push": bson.M{"edit_history": edit},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+// ForwardMessage copies a message into another conversation on behalf of senderID
+func (r *MessageRepository) ForwardMessage(ctx context.Context, messageID, toConversationID, senderID primitive.ObjectID) (primitive.ObjectID, error) {
+	original, err := r.GetByID(ctx, messageID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	originalSender := original.SenderID
+	originalID := original.ID
+	forwarded := &models.Message{
+		ConversationID:     toConversationID,
+		SenderID:           senderID,
+		Content:            original.Content,
+		MediaFiles:         original.MediaFiles,
+		ForwardedFrom:      &originalSender,
+		ForwardedMessageID: &originalID,
+		MessageType:        original.MessageType,
+	}
+
+	return r.Create(ctx, forwarded)
+}
+
+// GetReplies retrieves the replies to a message as seen by userID, in the
+// order they were sent
+func (r *MessageRepository) GetReplies(ctx context.Context, messageID, userID primitive.ObjectID) ([]*models.Message, error) {
+	filter := mongoutil.Merge(bson.M{"reply_to_id": messageID}, visibleMessagesFor(userID))
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decodeAll(ctx, cursor)
+}
+
+// UpdateEncryptionDetails sets the encryption details of a message
+func (r *MessageRepository) UpdateEncryptionDetails(ctx context.Context, id primitive.ObjectID, details models.EncryptionDetails) error {
+	return r.update(ctx, id, bson.M{
+		"$set": bson.M{"encryption_details": details, "is_encrypted": true, "updated_at": time.Now()},
+	})
+}
+
+// CreateSystemMessage inserts an automated message such as "user added" into a conversation
+func (r *MessageRepository) CreateSystemMessage(ctx context.Context, conversationID primitive.ObjectID, msgType string, params map[string]interface{}) (primitive.ObjectID, error) {
+	message := &models.Message{
+		ConversationID: conversationID,
+		MessageType:    "system",
+		SystemMessage: &models.SystemMessage{
+			Type:       msgType,
+			Parameters: params,
+		},
+	}
+
+	return r.Create(ctx, message)
+}
+
+// GetMessagesByTimeRange retrieves messages sent between startTime and
+// endTime as seen by userID, newest first
+func (r *MessageRepository) GetMessagesByTimeRange(ctx context.Context, conversationID, userID primitive.ObjectID, startTime, endTime time.Time, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(visibleMessagesFor(userID), bson.M{
+		"conversation_id": conversationID,
+		"created_at":      bson.M{"$gte": startTime, "$lte": endTime},
+	})
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, mongoutil.NewestFirst))
+}
+
+// DeleteExpiredMessages removes disappearing messages whose expiry has passed.
+// The TTL index on expires_at does this in the background; this sweeps up
+// anything the TTL monitor has not reached yet (it runs about once a minute).
+func (r *MessageRepository) DeleteExpiredMessages(ctx context.Context) (int, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{
+		"expires_at": bson.M{"$lte": time.Now()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(result.DeletedCount), nil
+}
+
+// GetMediaMessages retrieves messages carrying media of the given type as
+// seen by userID. An empty mediaType matches messages with any media.
+func (r *MessageRepository) GetMediaMessages(ctx context.Context, conversationID, userID primitive.ObjectID, mediaType string, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(bson.M{"conversation_id": conversationID}, visibleMessagesFor(userID))
+	if mediaType != "" {
+		filter["media_files.type"] = mediaType
+	} else {
+		filter["media_files.0"] = bson.M{"$exists": true}
+	}
+
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, mongoutil.NewestFirst))
+}
+
+// BulkDelete hides several messages for a single user and returns how many were affected
+func (r *MessageRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID, userID primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{
+			"$addToSet": bson.M{"deleted_for": userID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// List retrieves messages with an arbitrary filter and sort.
+// Expired messages are always excluded.
+func (r *MessageRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Message, int, error) {
+	query := mongoutil.Merge(mongoutil.ToFilter(filter), notExpired())
+	opts := mongoutil.FindPage(limit, offset, mongoutil.ToSort(sort, mongoutil.NewestFirst))
+	return r.findPage(ctx, query, opts)
+}
+
+// SearchMessages performs a full-text search within a conversation as seen
+// by userID. Encrypted messages are skipped because their content is ciphertext.
+func (r *MessageRepository) SearchMessages(ctx context.Context, conversationID, userID primitive.ObjectID, query string, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(visibleMessagesFor(userID), bson.M{
+		"conversation_id": conversationID,
+		"is_encrypted":    false,
+		"$text":           bson.M{"$search": query},
+	})
+
+	opts := mongoutil.FindPage(limit, offset, nil).
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "created_at", Value: -1}})
+
+	return r.findPage(ctx, filter, opts)
+}
+
+// Helper methods
+
+// notExpired matches messages without an expiry or whose expiry is still in the future
+func notExpired() bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+}
+
+// visibleMessages matches messages that have not been deleted for everyone and have not expired
+func visibleMessages() bson.M {
+	return mongoutil.Merge(notExpired(), bson.M{"is_deleted": false})
+}
+
+// visibleMessagesFor additionally hides messages the user deleted for themselves
+func visibleMessagesFor(userID primitive.ObjectID) bson.M {
+	return mongoutil.Merge(visibleMessages(), bson.M{"deleted_for": bson.M{"$ne": userID}})
+}
+
+func (r *MessageRepository) update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *MessageRepository) findPage(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Message, int, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, err := r.decodeAll(ctx, cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, int(total), nil
+}
+
+func (r *MessageRepository) decodeAll(ctx context.Context, cursor *mongo.Cursor) ([]*models.Message, error) {
+	defer cursor.Close(ctx)
+
+	messages := []*models.Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
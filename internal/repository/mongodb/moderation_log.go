@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// ModerationLogRepository implements interfaces.ModerationLogRepository using MongoDB
+type ModerationLogRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.ModerationLogRepository = (*ModerationLogRepository)(nil)
+
+// NewModerationLogRepository creates a new MongoDB moderation log repository
+func NewModerationLogRepository(db *mongo.Database) *ModerationLogRepository {
+	return &ModerationLogRepository{
+		collection: db.Collection(constants.CollectionModerationLog),
+	}
+}
+
+// Create inserts a log entry
+func (r *ModerationLogRepository) Create(ctx context.Context, entry *models.ModerationLogEntry) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// List retrieves log entries newest first
+func (r *ModerationLogRepository) List(ctx context.Context, limit, offset int) ([]*models.ModerationLogEntry, int, error) {
+	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.ModerationLogEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, int(total), nil
+}
@@ -0,0 +1,236 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// defaultPreferencesKey is the key GetUserNotificationPreferences stores the
+// user's preferences under
+const defaultPreferencesKey = "default"
+
+// NotificationRepository implements interfaces.NotificationRepository using MongoDB
+type NotificationRepository struct {
+	store
+	users *mongo.Collection
+}
+
+var _ interfaces.NotificationRepository = (*NotificationRepository)(nil)
+
+// NewNotificationRepository creates a new MongoDB notification repository
+func NewNotificationRepository(db *mongo.Database) *NotificationRepository {
+	return &NotificationRepository{
+		store: newStore(db, constants.CollectionNotifications),
+		users: db.Collection(constants.CollectionUsers),
+	}
+}
+
+// Create inserts a new notification with normal priority unless one is given
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) (primitive.ObjectID, error) {
+	now := time.Now()
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	if notification.Priority == "" {
+		notification.Priority = "normal"
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = now
+	}
+	notification.UpdatedAt = now
+	return r.insert(ctx, notification.ID, notification)
+}
+
+// GetByID retrieves a notification by ID
+func (r *NotificationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Notification, error) {
+	return findOne[models.Notification](ctx, r.collection, byID(id), nil)
+}
+
+// Update replaces a notification document
+func (r *NotificationRepository) Update(ctx context.Context, notification *models.Notification) error {
+	notification.UpdatedAt = time.Now()
+	return r.replace(ctx, notification.ID, notification)
+}
+
+// Delete permanently removes a notification
+func (r *NotificationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(ctx, id)
+}
+
+// GetByUserID retrieves a user's visible notifications, newest first
+func (r *NotificationRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Notification, int, error) {
+	return findPage[models.Notification](ctx, r.collection, visibleNotificationsFor(userID), newestFirst, limit, offset)
+}
+
+// GetUnreadByUserID retrieves a user's visible unread notifications, newest first
+func (r *NotificationRepository) GetUnreadByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Notification, int, error) {
+	filter := mongoutil.Merge(visibleNotificationsFor(userID), bson.M{"is_read": false})
+	return findPage[models.Notification](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByIDs retrieves several notifications by ID
+func (r *NotificationRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Notification, error) {
+	if len(ids) == 0 {
+		return []*models.Notification{}, nil
+	}
+	return findAll[models.Notification](ctx, r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// MarkAsRead marks a notification as read
+func (r *NotificationRepository) MarkAsRead(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(ctx, byID(id), bson.M{"is_read": true, "read_at": time.Now()})
+}
+
+// MarkAllAsRead marks every unread notification of a user as read and returns how many changed
+func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.markRead(ctx, bson.M{"user_id": userID, "is_read": false})
+}
+
+// MarkAsSent records that a notification was pushed out
+func (r *NotificationRepository) MarkAsSent(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(ctx, byID(id), bson.M{"is_sent": true, "sent_at": time.Now()})
+}
+
+// UpdateDeliveryStatus records the delivery status of a notification on one channel
+func (r *NotificationRepository) UpdateDeliveryStatus(ctx context.Context, id primitive.ObjectID, channel string, status string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"delivery_status." + channel: status})
+}
+
+// GetGroupedNotifications retrieves the latest notification of each group of a
+// user's visible notifications, newest first. Notifications without a group key
+// form a group of their own.
+func (r *NotificationRepository) GetGroupedNotifications(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Notification, int, error) {
+	limit, offset = mongoutil.NormalizePagination(limit, offset)
+
+	results, err := aggregate[struct {
+		Total []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		Page []*models.Notification `bson:"page"`
+	}](ctx, r.collection, mongo.Pipeline{
+		{{Key: "$match", Value: visibleNotificationsFor(userID)}},
+		{{Key: "$sort", Value: newestFirst}},
+		// Missing and empty group keys sort below any other string
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$group_key", ""}}, "$group_key", "$_id"}},
+			"latest": bson.M{"$first": "$$ROOT"},
+		}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$latest"}}},
+		{{Key: "$sort", Value: newestFirst}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "count"}},
+			"page":  bson.A{bson.M{"$skip": offset}, bson.M{"$limit": limit}},
+		}}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	latest := []*models.Notification{}
+	total := 0
+	if len(results) > 0 {
+		if results[0].Page != nil {
+			latest = results[0].Page
+		}
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Count
+		}
+	}
+	return latest, total, nil
+}
+
+// GetByGroupKey retrieves a user's visible notifications sharing a group key, newest first
+func (r *NotificationRepository) GetByGroupKey(ctx context.Context, userID primitive.ObjectID, groupKey string) ([]*models.Notification, error) {
+	filter := mongoutil.Merge(visibleNotificationsFor(userID), bson.M{"group_key": groupKey})
+	return findAll[models.Notification](ctx, r.collection, filter, newestFirst)
+}
+
+// GetNotificationsByTimeRange retrieves a user's visible notifications created within a time range, newest first
+func (r *NotificationRepository) GetNotificationsByTimeRange(ctx context.Context, userID primitive.ObjectID, startTime, endTime time.Time, limit, offset int) ([]*models.Notification, int, error) {
+	filter := inRange(visibleNotificationsFor(userID), "created_at", startTime, endTime)
+	return findPage[models.Notification](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// DeleteExpiredNotifications removes notifications whose expiry has passed
+func (r *NotificationRepository) DeleteExpiredNotifications(ctx context.Context) (int, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return int(result.DeletedCount), nil
+}
+
+// GetUnreadCount counts a user's visible unread notifications
+func (r *NotificationRepository) GetUnreadCount(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.count(ctx, mongoutil.Merge(visibleNotificationsFor(userID), bson.M{"is_read": false}))
+}
+
+// HideNotification hides a notification from the user's lists
+func (r *NotificationRepository) HideNotification(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(ctx, byID(id), bson.M{"is_hidden": true})
+}
+
+// UpdateExpiryTime sets when a notification expires
+func (r *NotificationRepository) UpdateExpiryTime(ctx context.Context, id primitive.ObjectID, expiresAt time.Time) error {
+	return r.updateFields(ctx, byID(id), bson.M{"expires_at": expiresAt})
+}
+
+// GetUserNotificationPreferences returns the preferences stored on the user
+// document under the "default" key
+func (r *NotificationRepository) GetUserNotificationPreferences(ctx context.Context, userID primitive.ObjectID) (map[string]models.NotificationPreferences, error) {
+	user, err := findOne[models.User](ctx, r.users, notDeleted(userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]models.NotificationPreferences{defaultPreferencesKey: user.Settings.NotificationPreferences}, nil
+}
+
+// CreateBatch inserts several notifications, stopping at the first error
+func (r *NotificationRepository) CreateBatch(ctx context.Context, notifications []*models.Notification) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(notifications))
+	for _, notification := range notifications {
+		id, err := r.Create(ctx, notification)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// MarkMultipleAsRead marks several notifications as read and returns how many changed
+func (r *NotificationRepository) MarkMultipleAsRead(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.markRead(ctx, bson.M{"_id": bson.M{"$in": ids}, "is_read": false})
+}
+
+// DeleteMultiple removes several notifications and returns how many were removed
+func (r *NotificationRepository) DeleteMultiple(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	return r.removeMany(ctx, ids)
+}
+
+// List retrieves notifications with an arbitrary filter and sort
+func (r *NotificationRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Notification, int, error) {
+	return findPage[models.Notification](ctx, r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// markRead marks every notification matching filter as read
+func (r *NotificationRepository) markRead(ctx context.Context, filter bson.M) (int, error) {
+	return r.updateMany(ctx, filter, bson.M{"$set": bson.M{"is_read": true, "read_at": time.Now()}})
+}
+
+// visibleNotificationsFor matches a user's notifications that are neither hidden nor expired
+func visibleNotificationsFor(userID primitive.ObjectID) bson.M {
+	return mongoutil.Merge(notExpired(), bson.M{"user_id": userID, "is_hidden": false})
+}
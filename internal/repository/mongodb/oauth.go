@@ -0,0 +1,292 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// OAuthAppRepository implements interfaces.OAuthAppRepository using MongoDB
+type OAuthAppRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.OAuthAppRepository = (*OAuthAppRepository)(nil)
+
+// NewOAuthAppRepository creates a new MongoDB third-party app repository
+func NewOAuthAppRepository(db *mongo.Database) *OAuthAppRepository {
+	return &OAuthAppRepository{
+		collection: db.Collection(constants.CollectionOAuthApps),
+	}
+}
+
+// Create inserts a new app
+func (r *OAuthAppRepository) Create(ctx context.Context, app *models.OAuthApp) (*models.OAuthApp, error) {
+	if app.ID.IsZero() {
+		app.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	if app.CreatedAt.IsZero() {
+		app.CreatedAt = now
+	}
+	app.UpdatedAt = now
+
+	if _, err := r.collection.InsertOne(ctx, app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// FindByID retrieves an app by ID
+func (r *OAuthAppRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthApp, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByClientID retrieves an app by its OAuth client ID
+func (r *OAuthAppRepository) FindByClientID(ctx context.Context, clientID string) (*models.OAuthApp, error) {
+	return r.findOne(ctx, bson.M{"client_id": clientID})
+}
+
+// FindByOwnerID retrieves the apps a developer registered, oldest first
+func (r *OAuthAppRepository) FindByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]*models.OAuthApp, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"owner_id": ownerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var apps []*models.OAuthApp
+	if err := cursor.All(ctx, &apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// CountByOwnerID counts the apps a developer registered
+func (r *OAuthAppRepository) CountByOwnerID(ctx context.Context, ownerID primitive.ObjectID) (int, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"owner_id": ownerID})
+	return int(count), err
+}
+
+// Update replaces an app owned by app.OwnerID
+func (r *OAuthAppRepository) Update(ctx context.Context, app *models.OAuthApp) error {
+	app.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": app.ID, "owner_id": app.OwnerID}, app)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes an app owned by ownerID
+func (r *OAuthAppRepository) Delete(ctx context.Context, id, ownerID primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "owner_id": ownerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *OAuthAppRepository) findOne(ctx context.Context, filter bson.M) (*models.OAuthApp, error) {
+	var app models.OAuthApp
+	if err := r.collection.FindOne(ctx, filter).Decode(&app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// OAuthGrantRepository implements interfaces.OAuthGrantRepository using MongoDB
+type OAuthGrantRepository struct {
+	codes    *mongo.Collection
+	consents *mongo.Collection
+	tokens   *mongo.Collection
+}
+
+var _ interfaces.OAuthGrantRepository = (*OAuthGrantRepository)(nil)
+
+// NewOAuthGrantRepository creates a new MongoDB OAuth grant repository
+func NewOAuthGrantRepository(db *mongo.Database) *OAuthGrantRepository {
+	return &OAuthGrantRepository{
+		codes:    db.Collection(constants.CollectionOAuthCodes),
+		consents: db.Collection(constants.CollectionOAuthConsents),
+		tokens:   db.Collection(constants.CollectionOAuthTokens),
+	}
+}
+
+// CreateCode stores an authorization code
+func (r *OAuthGrantRepository) CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	if code.CreatedAt.IsZero() {
+		code.CreatedAt = time.Now()
+	}
+	_, err := r.codes.InsertOne(ctx, code)
+	return err
+}
+
+// ConsumeCode removes and returns an unexpired authorization code
+func (r *OAuthGrantRepository) ConsumeCode(ctx context.Context, id string) (*models.OAuthAuthorizationCode, error) {
+	var code models.OAuthAuthorizationCode
+	err := r.codes.FindOneAndDelete(ctx, bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&code)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// SaveConsent stores the scopes a user granted an app, replacing earlier ones
+func (r *OAuthGrantRepository) SaveConsent(ctx context.Context, consent *models.OAuthConsent) error {
+	if consent.ID.IsZero() {
+		consent.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	consent.UpdatedAt = now
+
+	_, err := r.consents.UpdateOne(ctx,
+		bson.M{"app_id": consent.AppID, "user_id": consent.UserID},
+		bson.M{
+			"$set":         bson.M{"scopes": consent.Scopes, "updated_at": now},
+			"$setOnInsert": bson.M{"_id": consent.ID, "created_at": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// FindConsent retrieves the consent a user gave an app
+func (r *OAuthGrantRepository) FindConsent(ctx context.Context, appID, userID primitive.ObjectID) (*models.OAuthConsent, error) {
+	var consent models.OAuthConsent
+	if err := r.consents.FindOne(ctx, bson.M{"app_id": appID, "user_id": userID}).Decode(&consent); err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+// FindConsentsByUserID retrieves the apps a user has authorized, most recent first
+func (r *OAuthGrantRepository) FindConsentsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.OAuthConsent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+
+	cursor, err := r.consents.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var consents []*models.OAuthConsent
+	if err := cursor.All(ctx, &consents); err != nil {
+		return nil, err
+	}
+	return consents, nil
+}
+
+// DeleteConsent removes the consent a user gave an app
+func (r *OAuthGrantRepository) DeleteConsent(ctx context.Context, appID, userID primitive.ObjectID) error {
+	result, err := r.consents.DeleteOne(ctx, bson.M{"app_id": appID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteConsentsByAppID removes every consent given to an app
+func (r *OAuthGrantRepository) DeleteConsentsByAppID(ctx context.Context, appID primitive.ObjectID) error {
+	_, err := r.consents.DeleteMany(ctx, bson.M{"app_id": appID})
+	return err
+}
+
+// CreateToken inserts a new token
+func (r *OAuthGrantRepository) CreateToken(ctx context.Context, token *models.OAuthToken) (*models.OAuthToken, error) {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	if _, err := r.tokens.InsertOne(ctx, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindTokenByID retrieves a token by ID
+func (r *OAuthGrantRepository) FindTokenByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthToken, error) {
+	return r.findToken(ctx, bson.M{"_id": id})
+}
+
+// FindTokenByRefreshTokenHash retrieves the token whose current refresh token has the given hash
+func (r *OAuthGrantRepository) FindTokenByRefreshTokenHash(ctx context.Context, hash string) (*models.OAuthToken, error) {
+	return r.findToken(ctx, bson.M{"refresh_token_hash": hash})
+}
+
+// FindTokenByRotatedHash retrieves the token that already exchanged the refresh token with the given hash
+func (r *OAuthGrantRepository) FindTokenByRotatedHash(ctx context.Context, hash string) (*models.OAuthToken, error) {
+	return r.findToken(ctx, bson.M{"rotated_token_hashes": hash})
+}
+
+// RotateRefreshToken replaces the refresh token of an unrevoked token
+func (r *OAuthGrantRepository) RotateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, hash string, expiresAt time.Time) error {
+	result, err := r.tokens.UpdateOne(ctx,
+		bson.M{"_id": id, "refresh_token_hash": previousHash, "revoked_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"refresh_token_hash": hash,
+				"last_used_at":       time.Now(),
+				"expires_at":         expiresAt,
+			},
+			"$push": bson.M{"rotated_token_hashes": previousHash},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RevokeToken revokes a token
+func (r *OAuthGrantRepository) RevokeToken(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error {
+	_, err := r.tokens.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": revokedAt}},
+	)
+	return err
+}
+
+// RevokeTokensByApp revokes the tokens of an app, for one user or all of them
+func (r *OAuthGrantRepository) RevokeTokensByApp(ctx context.Context, appID primitive.ObjectID, userID *primitive.ObjectID, revokedAt time.Time) error {
+	filter := bson.M{"app_id": appID, "revoked_at": nil}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+	_, err := r.tokens.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+	return err
+}
+
+func (r *OAuthGrantRepository) findToken(ctx context.Context, filter bson.M) (*models.OAuthToken, error) {
+	var token models.OAuthToken
+	if err := r.tokens.FindOne(ctx, filter).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
@@ -0,0 +1,117 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Outbox message statuses
+const (
+	outboxPending   = "pending"
+	outboxPublished = "published"
+)
+
+// OutboxRepository implements interfaces.OutboxRepository using MongoDB
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.OutboxRepository = (*OutboxRepository)(nil)
+
+// NewOutboxRepository creates a new MongoDB outbox repository
+func NewOutboxRepository(db *mongo.Database) *OutboxRepository {
+	return &OutboxRepository{
+		collection: db.Collection(constants.CollectionOutbox),
+	}
+}
+
+// Add stores messages as pending and immediately available
+func (r *OutboxRepository) Add(ctx context.Context, messages []*models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	documents := make([]interface{}, len(messages))
+	for i, message := range messages {
+		if message.ID.IsZero() {
+			message.ID = primitive.NewObjectID()
+		}
+		if message.OccurredAt.IsZero() {
+			message.OccurredAt = now
+		}
+		if message.AvailableAt.IsZero() {
+			message.AvailableAt = message.OccurredAt
+		}
+		message.Status = outboxPending
+		documents[i] = message
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return err
+}
+
+// GetPending retrieves pending messages that are available by now, oldest first
+func (r *OutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"status":       outboxPending,
+		"available_at": bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []*models.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkPublished records that a message was handed to its subscribers.
+// Published messages are removed by the TTL index after a retention period.
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": outboxPending},
+		bson.M{"$set": bson.M{"status": outboxPublished, "published_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// MarkRetry records a failed relay attempt and hides the message until retryAt
+func (r *OutboxRepository) MarkRetry(ctx context.Context, id primitive.ObjectID, cause string, retryAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": outboxPending},
+		bson.M{
+			"$inc": bson.M{"attempts": 1},
+			"$set": bson.M{"last_error": cause, "available_at": retryAt},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// PasskeyRepository implements interfaces.PasskeyRepository using MongoDB
+type PasskeyRepository struct {
+	collection *mongo.Collection
+	challenges *mongo.Collection
+}
+
+var _ interfaces.PasskeyRepository = (*PasskeyRepository)(nil)
+
+// NewPasskeyRepository creates a new MongoDB passkey repository
+func NewPasskeyRepository(db *mongo.Database) *PasskeyRepository {
+	return &PasskeyRepository{
+		collection: db.Collection(constants.CollectionPasskeys),
+		challenges: db.Collection(constants.CollectionPasskeyChallenges),
+	}
+}
+
+// Create inserts a new passkey. Registering a credential that is already
+// registered fails with a duplicate key error.
+func (r *PasskeyRepository) Create(ctx context.Context, passkey *models.Passkey) (*models.Passkey, error) {
+	if passkey.ID.IsZero() {
+		passkey.ID = primitive.NewObjectID()
+	}
+	if passkey.CreatedAt.IsZero() {
+		passkey.CreatedAt = time.Now()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, passkey); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+// FindByID retrieves a passkey by ID
+func (r *PasskeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Passkey, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByCredentialID retrieves a passkey by its WebAuthn credential ID
+func (r *PasskeyRepository) FindByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	return r.findOne(ctx, bson.M{"credential_id": credentialID})
+}
+
+// FindByUserID retrieves every passkey of a user, oldest first
+func (r *PasskeyRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var passkeys []*models.Passkey
+	if err := cursor.All(ctx, &passkeys); err != nil {
+		return nil, err
+	}
+	return passkeys, nil
+}
+
+// RecordUse stores the sign count and backup state of a successful login
+// while the stored count is still previousCount
+func (r *PasskeyRepository) RecordUse(ctx context.Context, id primitive.ObjectID, previousCount, signCount uint32, backedUp bool, usedAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "sign_count": previousCount},
+		bson.M{"$set": bson.M{
+			"sign_count":   signCount,
+			"backed_up":    backedUp,
+			"last_used_at": usedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Rename changes the name of a user's passkey
+func (r *PasskeyRepository) Rename(ctx context.Context, id, userID primitive.ObjectID, name string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"name": name}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes a user's passkey
+func (r *PasskeyRepository) Delete(ctx context.Context, id, userID primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteByUserID removes every passkey of a user
+func (r *PasskeyRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// CreateChallenge stores the challenge of a ceremony that has just begun
+func (r *PasskeyRepository) CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error {
+	if challenge.CreatedAt.IsZero() {
+		challenge.CreatedAt = time.Now()
+	}
+	_, err := r.challenges.InsertOne(ctx, challenge)
+	return err
+}
+
+// ConsumeChallenge removes and returns an unexpired challenge. Only one
+// caller can consume a challenge; the rest get mongo.ErrNoDocuments.
+func (r *PasskeyRepository) ConsumeChallenge(ctx context.Context, id string) (*models.PasskeyChallenge, error) {
+	var challenge models.PasskeyChallenge
+	err := r.challenges.FindOneAndDelete(ctx, bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&challenge)
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *PasskeyRepository) findOne(ctx context.Context, filter bson.M) (*models.Passkey, error) {
+	var passkey models.Passkey
+	if err := r.collection.FindOne(ctx, filter).Decode(&passkey); err != nil {
+		return nil, err
+	}
+	return &passkey, nil
+}
@@ -0,0 +1,157 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// PersonalTokenRepository implements interfaces.PersonalTokenRepository using MongoDB
+type PersonalTokenRepository struct {
+	collection *mongo.Collection
+	usage      *mongo.Collection
+}
+
+var _ interfaces.PersonalTokenRepository = (*PersonalTokenRepository)(nil)
+
+// NewPersonalTokenRepository creates a new MongoDB personal access token repository
+func NewPersonalTokenRepository(db *mongo.Database) *PersonalTokenRepository {
+	return &PersonalTokenRepository{
+		collection: db.Collection(constants.CollectionPersonalTokens),
+		usage:      db.Collection(constants.CollectionPersonalTokenUsage),
+	}
+}
+
+// Create inserts a new personal access token
+func (r *PersonalTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error) {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindByID retrieves a personal access token by ID
+func (r *PersonalTokenRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.PersonalAccessToken, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByHash retrieves a personal access token by the hash of the token
+func (r *PersonalTokenRepository) FindByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error) {
+	return r.findOne(ctx, bson.M{"token_hash": hash})
+}
+
+// FindByUserID retrieves every personal access token of a user, newest first
+func (r *PersonalTokenRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*models.PersonalAccessToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CountActiveByUserID counts a user's tokens that are neither revoked nor expired
+func (r *PersonalTokenRepository) CountActiveByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (int, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": now},
+	})
+	return int(count), err
+}
+
+// RecordUse sets the last-used time and address of a token
+func (r *PersonalTokenRepository) RecordUse(ctx context.Context, id primitive.ObjectID, usedAt time.Time, ipAddress string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_used_at": usedAt, "last_used_ip": ipAddress}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Revoke marks a user's token as revoked. The token is kept so its usage
+// can still be audited.
+func (r *PersonalTokenRepository) Revoke(ctx context.Context, id, userID primitive.ObjectID, revokedAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": revokedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RecordUsage adds a request to the token's usage for the day and address
+func (r *PersonalTokenRepository) RecordUsage(ctx context.Context, usage *models.PersonalAccessTokenUsage) error {
+	_, err := r.usage.UpdateOne(ctx,
+		bson.M{"token_id": usage.TokenID, "day": usage.Day, "ip_address": usage.IPAddress},
+		bson.M{
+			"$inc": bson.M{"requests": 1},
+			"$set": bson.M{"last_used_at": usage.LastUsedAt, "user_agent": usage.UserAgent},
+			"$setOnInsert": bson.M{
+				"user_id":       usage.UserID,
+				"first_used_at": usage.LastUsedAt,
+				"expires_at":    usage.ExpiresAt,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// FindUsage retrieves a token's usage since a day, newest first
+func (r *PersonalTokenRepository) FindUsage(ctx context.Context, tokenID primitive.ObjectID, since time.Time) ([]*models.PersonalAccessTokenUsage, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "day", Value: -1}, {Key: "last_used_at", Value: -1}})
+
+	cursor, err := r.usage.Find(ctx, bson.M{"token_id": tokenID, "day": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var usage []*models.PersonalAccessTokenUsage
+	if err := cursor.All(ctx, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (r *PersonalTokenRepository) findOne(ctx context.Context, filter bson.M) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.collection.FindOne(ctx, filter).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
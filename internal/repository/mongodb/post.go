@@ -0,0 +1,499 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// earthRadiusKm is the mean radius of the earth used to convert distances to radians
+const earthRadiusKm = 6378.1
+
+// exploreWindow limits explore results to recently published posts
+const exploreWindow = 7 * 24 * time.Hour
+
+// PostRepository implements interfaces.PostRepository using MongoDB
+type PostRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+var _ interfaces.PostRepository = (*PostRepository)(nil)
+
+// NewPostRepository creates a new MongoDB post repository
+func NewPostRepository(db *mongo.Database) *PostRepository {
+	return &PostRepository{
+		db:         db,
+		collection: db.Collection(constants.CollectionPosts),
+	}
+}
+
+// EnsureIndexes creates the geo, text and lookup indexes the repository relies on
+func (r *PostRepository) EnsureIndexes(ctx context.Context) error {
+	return dbmongo.EnsureIndexes(ctx, r.db, constants.CollectionPosts)
+}
+
+// Create inserts a new post
+func (r *PostRepository) Create(ctx context.Context, post *models.Post) (primitive.ObjectID, error) {
+	now := time.Now()
+	if post.ID.IsZero() {
+		post.ID = primitive.NewObjectID()
+	}
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = now
+	}
+	post.UpdatedAt = now
+
+	// Scheduled posts are published at their scheduled time and the rest
+	// immediately, so visibility filters on published_at hide them until then
+	if post.ScheduledFor != nil {
+		post.PublishedAt = *post.ScheduledFor
+	} else if post.PublishedAt.IsZero() {
+		post.PublishedAt = now
+	}
+
+	if _, err := r.collection.InsertOne(ctx, post); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return post.ID, nil
+}
+
+// GetByID retrieves a post by ID, ignoring soft-deleted posts
+func (r *PostRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	var post models.Post
+	filter := mongoutil.Merge(bson.M{"_id": id}, mongoutil.NotDeleted())
+	if err := r.collection.FindOne(ctx, filter).Decode(&post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Update replaces a post document
+func (r *PostRepository) Update(ctx context.Context, post *models.Post) error {
+	post.UpdatedAt = time.Now()
+	if post.ScheduledFor != nil {
+		post.PublishedAt = *post.ScheduledFor
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": post.ID}, post)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete permanently removes a post
+func (r *PostRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SoftDelete marks a post as deleted without removing it
+func (r *PostRepository) SoftDelete(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	return r.updateFields(ctx, id, bson.M{"deleted_at": now})
+}
+
+// GetByIDs retrieves several posts by ID
+func (r *PostRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Post, error) {
+	if len(ids) == 0 {
+		return []*models.Post{}, nil
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decodeAll(ctx, cursor)
+}
+
+// GetByUserID retrieves posts authored by a user
+func (r *PostRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"user_id": userID}, r.visibleFilter())
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, byPublishedAt))
+}
+
+// GetByGroupID retrieves posts published in a group
+func (r *PostRepository) GetByGroupID(ctx context.Context, groupID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"group_id": groupID}, r.visibleFilter())
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, byPublishedAt))
+}
+
+// GetByHashtag retrieves posts tagged with a hashtag
+func (r *PostRepository) GetByHashtag(ctx context.Context, hashtag string, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"hashtags": hashtag}, r.visibleFilter(), bson.M{"privacy": "public"})
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, byPublishedAt))
+}
+
+// GetByLocation retrieves public posts within radiusKm of a point, nearest first.
+// It relies on the 2dsphere index on location.coordinates.
+func (r *PostRepository) GetByLocation(ctx context.Context, latitude, longitude float64, radiusKm float64, limit, offset int) ([]*models.Post, int, error) {
+	point := bson.A{longitude, latitude}
+	base := mongoutil.Merge(r.visibleFilter(), bson.M{"privacy": "public"})
+
+	// $nearSphere sorts by distance but cannot be used for counting,
+	// so the total is computed with the equivalent $geoWithin query.
+	countFilter := mongoutil.Merge(base, bson.M{
+		"location.coordinates": bson.M{
+			"$geoWithin": bson.M{"$centerSphere": bson.A{point, radiusKm / earthRadiusKm}},
+		},
+	})
+	total, err := r.collection.CountDocuments(ctx, countFilter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findFilter := mongoutil.Merge(base, bson.M{
+		"location.coordinates": bson.M{
+			"$nearSphere": bson.M{
+				"$geometry":    bson.M{"type": "Point", "coordinates": point},
+				"$maxDistance": radiusKm * 1000,
+			},
+		},
+	})
+	cursor, err := r.collection.Find(ctx, findFilter, mongoutil.FindPage(limit, offset, nil))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, err := r.decodeAll(ctx, cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, int(total), nil
+}
+
+// GetFeedForUser retrieves posts from the accounts a user follows, plus their own posts
+func (r *PostRepository) GetFeedForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	followingIDs, err := r.followingIDs(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := mongoutil.Merge(r.visibleFilter(), bson.M{
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{
+				"user_id": bson.M{"$in": followingIDs},
+				"privacy": bson.M{"$ne": "private"},
+			},
+		},
+	})
+
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, byPublishedAt))
+}
+
+// GetTimelineForUser retrieves a user's own posts, pinned posts first
+func (r *PostRepository) GetTimelineForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"user_id": userID}, r.visibleFilter())
+	sort := bson.D{{Key: "is_pinned", Value: -1}, {Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, sort))
+}
+
+// GetExploreForUser retrieves popular recent public posts from accounts the user does not follow
+func (r *PostRepository) GetExploreForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	followingIDs, err := r.followingIDs(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	excluded := append(followingIDs, userID)
+	filter := mongoutil.Merge(r.visibleFilter(), bson.M{
+		"user_id":      bson.M{"$nin": excluded},
+		"privacy":      "public",
+		"nsfw":         false,
+		"published_at": bson.M{"$gte": time.Now().Add(-exploreWindow), "$lte": time.Now()},
+	})
+	sort := bson.D{
+		{Key: "like_count", Value: -1},
+		{Key: "comment_count", Value: -1},
+		{Key: "published_at", Value: -1},
+		{Key: "_id", Value: -1},
+	}
+
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, sort))
+}
+
+// GetReportedPosts retrieves posts that have reports in the given status.
+// An empty status or "all" matches reports in any status.
+func (r *PostRepository) GetReportedPosts(ctx context.Context, status string, limit, offset int) ([]*models.Post, int, error) {
+	reportFilter := bson.M{"content_type": "post"}
+	if status != "" && status != "all" {
+		reportFilter["status"] = status
+	}
+
+	ids, err := r.db.Collection(constants.CollectionReports).Distinct(ctx, "content_id", reportFilter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(ids) == 0 {
+		return []*models.Post{}, 0, nil
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, mongoutil.NewestFirst))
+}
+
+// UpdatePostVisibility hides or unhides a post
+func (r *PostRepository) UpdatePostVisibility(ctx context.Context, id primitive.ObjectID, isHidden bool) error {
+	return r.updateFields(ctx, id, bson.M{"is_hidden": isHidden})
+}
+
+// FlagAsInappropriate hides a post and files a system report so it lands in the moderation queue
+func (r *PostRepository) FlagAsInappropriate(ctx context.Context, id primitive.ObjectID, reason string) error {
+	if err := r.updateFields(ctx, id, bson.M{"is_hidden": true}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	report := &models.Report{
+		ContentID:   id,
+		ContentType: "post",
+		ReasonCode:  "inappropriate",
+		Description: reason,
+		Status:      "pending",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	_, err := r.db.Collection(constants.CollectionReports).InsertOne(ctx, report)
+	return err
+}
+
+// GetScheduledPosts retrieves posts scheduled between startTime and endTime.
+// A zero userID returns scheduled posts for every user, which is what the publisher job uses.
+func (r *PostRepository) GetScheduledPosts(ctx context.Context, userID primitive.ObjectID, startTime, endTime time.Time) ([]*models.Post, error) {
+	filter := mongoutil.Merge(mongoutil.NotDeleted(), bson.M{
+		"scheduled_for": bson.M{"$gte": startTime, "$lte": endTime},
+	})
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "scheduled_for", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decodeAll(ctx, cursor)
+}
+
+// PublishScheduledPost publishes a scheduled post now
+func (r *PostRepository) PublishScheduledPost(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		mongoutil.Merge(bson.M{"_id": id}, mongoutil.NotDeleted()),
+		bson.M{
+			"$set":   bson.M{"published_at": now, "updated_at": now},
+			"$unset": bson.M{"scheduled_for": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// IncrementLikeCount atomically adjusts the like counter
+func (r *PostRepository) IncrementLikeCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(ctx, id, "like_count", amount)
+}
+
+// IncrementCommentCount atomically adjusts the comment counter
+func (r *PostRepository) IncrementCommentCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(ctx, id, "comment_count", amount)
+}
+
+// IncrementShareCount atomically adjusts the share counter
+func (r *PostRepository) IncrementShareCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(ctx, id, "share_count", amount)
+}
+
+// IncrementViewCount atomically adjusts the view counter
+func (r *PostRepository) IncrementViewCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(ctx, id, "view_count", amount)
+}
+
+// UpdateReactionCounts replaces the per-reaction counters
+func (r *PostRepository) UpdateReactionCounts(ctx context.Context, id primitive.ObjectID, reactionCounts map[string]int) error {
+	return r.updateFields(ctx, id, bson.M{"reaction_counts": reactionCounts})
+}
+
+// PinPost pins or unpins a post on its author's timeline
+func (r *PostRepository) PinPost(ctx context.Context, id primitive.ObjectID, isPinned bool) error {
+	return r.updateFields(ctx, id, bson.M{"is_pinned": isPinned})
+}
+
+// ArchivePost archives or unarchives a post
+func (r *PostRepository) ArchivePost(ctx context.Context, id primitive.ObjectID, isArchived bool) error {
+	return r.updateFields(ctx, id, bson.M{"is_archived": isArchived})
+}
+
+// FeaturePost marks or unmarks a post as featured
+func (r *PostRepository) FeaturePost(ctx context.Context, id primitive.ObjectID, isFeatured bool) error {
+	return r.updateFields(ctx, id, bson.M{"is_featured": isFeatured})
+}
+
+// Search performs a full-text search over post content and hashtags, best matches first.
+// It relies on the text index on the posts collection.
+func (r *PostRepository) Search(ctx context.Context, query string, filter map[string]interface{}, limit, offset int) ([]*models.Post, int, error) {
+	searchFilter := mongoutil.Merge(
+		mongoutil.ToFilter(filter),
+		r.visibleFilter(),
+		bson.M{"$text": bson.M{"$search": query}},
+	)
+
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	opts := mongoutil.FindPage(limit, offset, nil).
+		SetProjection(score).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "published_at", Value: -1}})
+
+	return r.findPage(ctx, searchFilter, opts)
+}
+
+// List retrieves posts with an arbitrary filter and sort.
+// Soft-deleted posts are excluded unless the filter mentions deleted_at.
+func (r *PostRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Post, int, error) {
+	query := mongoutil.ToFilter(filter)
+	if _, ok := query["deleted_at"]; !ok {
+		query = mongoutil.Merge(query, mongoutil.NotDeleted())
+	}
+
+	opts := mongoutil.FindPage(limit, offset, mongoutil.ToSort(sort, mongoutil.NewestFirst))
+	return r.findPage(ctx, query, opts)
+}
+
+// GetPostsWithActivePoll retrieves posts whose poll has not yet expired
+func (r *PostRepository) GetPostsWithActivePoll(ctx context.Context, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(r.visibleFilter(), bson.M{
+		"poll":            bson.M{"$exists": true},
+		"poll.expires_at": bson.M{"$gt": time.Now()},
+	})
+	return r.findPage(ctx, filter, mongoutil.FindPage(limit, offset, byPublishedAt))
+}
+
+// UpdatePoll replaces the poll attached to a post
+func (r *PostRepository) UpdatePoll(ctx context.Context, postID primitive.ObjectID, poll *models.Poll) error {
+	return r.updateFields(ctx, postID, bson.M{"poll": poll})
+}
+
+// Helper methods
+
+// byPublishedAt sorts posts newest published first
+var byPublishedAt = bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// visibleFilter matches posts that are published and can be shown in listings
+func (r *PostRepository) visibleFilter() bson.M {
+	return bson.M{
+		"deleted_at":   nil,
+		"is_hidden":    false,
+		"is_archived":  false,
+		"published_at": bson.M{"$lte": time.Now()},
+		// Posts stored before scheduling set published_at have none
+		"scheduled_for": bson.M{"$not": bson.M{"$gt": time.Now()}},
+	}
+}
+
+// followingIDs returns the IDs of users the given user follows
+func (r *PostRepository) followingIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	values, err := r.db.Collection(constants.CollectionFollows).Distinct(ctx, "following_id", bson.M{
+		"follower_id": userID,
+		"status":      "accepted",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *PostRepository) increment(ctx context.Context, id primitive.ObjectID, field string, amount int) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{field: amount},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *PostRepository) updateFields(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	fields["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *PostRepository) findPage(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Post, int, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, err := r.decodeAll(ctx, cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, int(total), nil
+}
+
+func (r *PostRepository) decodeAll(ctx context.Context, cursor *mongo.Cursor) ([]*models.Post, error) {
+	defer cursor.Close(ctx)
+
+	posts := []*models.Post{}
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
@@ -0,0 +1,188 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// maxDriftSamples bounds how many drifted documents a counter report lists
+const maxDriftSamples = 20
+
+// counterSpec describes a denormalised counter and the source documents it counts
+type counterSpec struct {
+	name       string
+	collection string
+	field      string
+	source     string
+	foreignKey string
+	match      bson.M
+}
+
+// counterSpecs lists every counter the reconciler checks
+var counterSpecs = []counterSpec{
+	{
+		name:       "post.like_count",
+		collection: constants.CollectionPosts,
+		field:      "like_count",
+		source:     constants.CollectionLikes,
+		foreignKey: "content_id",
+		match:      bson.M{"content_type": "post"},
+	},
+	{
+		name:       "comment.like_count",
+		collection: constants.CollectionComments,
+		field:      "like_count",
+		source:     constants.CollectionLikes,
+		foreignKey: "content_id",
+		match:      bson.M{"content_type": "comment"},
+	},
+	{
+		name:       "post.comment_count",
+		collection: constants.CollectionPosts,
+		field:      "comment_count",
+		source:     constants.CollectionComments,
+		foreignKey: "post_id",
+		match:      bson.M{"deleted_at": nil},
+	},
+	{
+		name:       "user.follower_count",
+		collection: constants.CollectionUsers,
+		field:      "follower_count",
+		source:     constants.CollectionFollows,
+		foreignKey: "following_id",
+		match:      bson.M{"status": "accepted"},
+	},
+	{
+		name:       "user.following_count",
+		collection: constants.CollectionUsers,
+		field:      "following_count",
+		source:     constants.CollectionFollows,
+		foreignKey: "follower_id",
+		match:      bson.M{"status": "accepted"},
+	},
+	{
+		name:       "user.post_count",
+		collection: constants.CollectionUsers,
+		field:      "post_count",
+		source:     constants.CollectionPosts,
+		foreignKey: "user_id",
+		match:      bson.M{"deleted_at": nil},
+	},
+}
+
+// CounterDrift is one document whose stored counter disagrees with its source
+type CounterDrift struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id"`
+	Stored int                `json:"stored" bson:"stored"`
+	Actual int                `json:"actual" bson:"actual"`
+}
+
+// CounterReport summarises the drift found in one counter
+type CounterReport struct {
+	Counter    string         `json:"counter"`
+	Drifted    int            `json:"drifted"`
+	TotalDelta int            `json:"total_delta"`
+	Repaired   int            `json:"repaired"`
+	Samples    []CounterDrift `json:"samples,omitempty"`
+}
+
+// CounterReconciler recomputes denormalised counters from their source
+// collections and reports, and optionally repairs, any drift
+type CounterReconciler struct {
+	db *mongo.Database
+}
+
+// NewCounterReconciler creates a counter reconciler
+func NewCounterReconciler(db *mongo.Database) *CounterReconciler {
+	return &CounterReconciler{db: db}
+}
+
+// Reconcile checks every counter. When repair is true, drifted counters are
+// overwritten with the recomputed value. Counters can move while the check runs,
+// so a small drift on busy documents is expected and settles on the next run.
+func (r *CounterReconciler) Reconcile(ctx context.Context, repair bool) ([]CounterReport, error) {
+	reports := make([]CounterReport, 0, len(counterSpecs))
+	for _, spec := range counterSpecs {
+		report, err := r.reconcile(ctx, spec, repair)
+		if err != nil {
+			return reports, fmt.Errorf("reconcile %s: %w", spec.name, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (r *CounterReconciler) reconcile(ctx context.Context, spec counterSpec, repair bool) (CounterReport, error) {
+	report := CounterReport{Counter: spec.name}
+
+	cursor, err := r.db.Collection(spec.collection).Aggregate(ctx, driftPipeline(spec))
+	if err != nil {
+		return report, err
+	}
+	defer cursor.Close(ctx)
+
+	var repairs []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var drift CounterDrift
+		if err := cursor.Decode(&drift); err != nil {
+			return report, err
+		}
+
+		report.Drifted++
+		delta := drift.Actual - drift.Stored
+		if delta < 0 {
+			delta = -delta
+		}
+		report.TotalDelta += delta
+		if len(report.Samples) < maxDriftSamples {
+			report.Samples = append(report.Samples, drift)
+		}
+
+		if repair {
+			repairs = append(repairs, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": drift.ID, spec.field: drift.Stored}).
+				SetUpdate(bson.M{"$set": bson.M{spec.field: drift.Actual}}))
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return report, err
+	}
+
+	if len(repairs) > 0 {
+		result, err := r.db.Collection(spec.collection).BulkWrite(ctx, repairs)
+		if err != nil {
+			return report, err
+		}
+		report.Repaired = int(result.ModifiedCount)
+	}
+
+	return report, nil
+}
+
+// driftPipeline counts the source documents of each counter and keeps the
+// documents whose stored value differs. A missing counter counts as zero.
+func driftPipeline(spec counterSpec) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from": spec.source,
+			"let":  bson.M{"id": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": spec.match},
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$" + spec.foreignKey, "$$id"}}}},
+				bson.M{"$count": "n"},
+			},
+			"as": "source",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"stored": bson.M{"$ifNull": bson.A{"$" + spec.field, 0}},
+			"actual": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$source.n", 0}}, 0}},
+		}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$ne": bson.A{"$stored", "$actual"}}}}},
+	}
+}
@@ -0,0 +1,251 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// ReportRepository implements interfaces.ReportRepository using MongoDB. The
+// Report model has no appeal fields, so appeals are kept in an "appeal"
+// sub-document that GetAppealedReports filters on.
+type ReportRepository struct {
+	store
+}
+
+var _ interfaces.ReportRepository = (*ReportRepository)(nil)
+
+// NewReportRepository creates a new MongoDB report repository
+func NewReportRepository(db *mongo.Database) *ReportRepository {
+	return &ReportRepository{store: newStore(db, constants.CollectionReports)}
+}
+
+// Create inserts a new report, pending review unless a status is given
+func (r *ReportRepository) Create(ctx context.Context, report *models.Report) (primitive.ObjectID, error) {
+	now := time.Now()
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+	if report.Status == "" {
+		report.Status = "pending"
+	}
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = now
+	}
+	report.UpdatedAt = now
+	return r.insert(ctx, report.ID, report)
+}
+
+// GetByID retrieves a report by ID
+func (r *ReportRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Report, error) {
+	return findOne[models.Report](ctx, r.collection, byID(id), nil)
+}
+
+// Update replaces a report document
+func (r *ReportRepository) Update(ctx context.Context, report *models.Report) error {
+	report.UpdatedAt = time.Now()
+	return r.replace(ctx, report.ID, report)
+}
+
+// Delete permanently removes a report
+func (r *ReportRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(ctx, id)
+}
+
+// GetByReporterID retrieves the reports a user filed, newest first
+func (r *ReportRepository) GetByReporterID(ctx context.Context, reporterID primitive.ObjectID, limit, offset int) ([]*models.Report, int, error) {
+	return findPage[models.Report](ctx, r.collection, bson.M{"reporter_id": reporterID}, newestFirst, limit, offset)
+}
+
+// GetByContentID retrieves every report on a piece of content, newest first
+func (r *ReportRepository) GetByContentID(ctx context.Context, contentID primitive.ObjectID, contentType string) ([]*models.Report, error) {
+	filter := bson.M{"content_id": contentID, "content_type": contentType}
+	return findAll[models.Report](ctx, r.collection, filter, newestFirst)
+}
+
+// GetByStatus retrieves reports in a status, oldest first so the queue is worked in order
+func (r *ReportRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]*models.Report, int, error) {
+	return findPage[models.Report](ctx, r.collection, bson.M{"status": status}, oldestFirst, limit, offset)
+}
+
+// GetByIDs retrieves several reports by ID
+func (r *ReportRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Report, error) {
+	if len(ids) == 0 {
+		return []*models.Report{}, nil
+	}
+	return findAll[models.Report](ctx, r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// UpdateStatus moves a report to a new status, stamping resolved_at when it is closed
+func (r *ReportRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	return r.updateFields(ctx, byID(id), statusFields(status))
+}
+
+// AssignModerator assigns a report to a moderator
+func (r *ReportRepository) AssignModerator(ctx context.Context, id primitive.ObjectID, moderatorID primitive.ObjectID) error {
+	return r.updateFields(ctx, byID(id), bson.M{"moderator_id": moderatorID})
+}
+
+// AddModeratorNotes sets the moderator notes of a report
+func (r *ReportRepository) AddModeratorNotes(ctx context.Context, id primitive.ObjectID, notes string) error {
+	return r.updateFields(ctx, byID(id), bson.M{"moderator_notes": notes})
+}
+
+// TakeAction records the action a moderator took and closes the report
+func (r *ReportRepository) TakeAction(ctx context.Context, id primitive.ObjectID, action string, moderatorID primitive.ObjectID, notes string) error {
+	fields := statusFields("actioned")
+	fields["action_taken"] = action
+	fields["moderator_id"] = moderatorID
+	fields["moderator_notes"] = notes
+	return r.updateFields(ctx, byID(id), fields)
+}
+
+// GetMostReportedContent returns the IDs of the most reported content of a type
+func (r *ReportRepository) GetMostReportedContent(ctx context.Context, contentType string, limit int) ([]primitive.ObjectID, error) {
+	return topIDs(ctx, r.collection, bson.M{"content_type": contentType}, "content_id", limit)
+}
+
+// GetReportCountByContent counts the reports on a piece of content
+func (r *ReportRepository) GetReportCountByContent(ctx context.Context, contentID primitive.ObjectID, contentType string) (int, error) {
+	return r.count(ctx, bson.M{"content_id": contentID, "content_type": contentType})
+}
+
+// GetReportsByReasonCode retrieves reports filed for a reason, newest first
+func (r *ReportRepository) GetReportsByReasonCode(ctx context.Context, reasonCode string, limit, offset int) ([]*models.Report, int, error) {
+	return findPage[models.Report](ctx, r.collection, bson.M{"reason_code": reasonCode}, newestFirst, limit, offset)
+}
+
+// GetReportStats breaks down the reports filed within a time range by status,
+// content type and reason
+func (r *ReportRepository) GetReportStats(ctx context.Context, startDate, endDate time.Time) (map[string]interface{}, error) {
+	filter := inRange(bson.M{}, "created_at", startDate, endDate)
+	total, err := r.count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]interface{}{"total": total}
+	for key, field := range map[string]string{
+		"by_status":       "status",
+		"by_content_type": "content_type",
+		"by_reason":       "reason_code",
+	} {
+		counts, err := countBy(ctx, r.collection, filter, field)
+		if err != nil {
+			return nil, err
+		}
+		stats[key] = counts
+	}
+	return stats, nil
+}
+
+// GetReportCountByUser counts the reports a user filed
+func (r *ReportRepository) GetReportCountByUser(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.count(ctx, bson.M{"reporter_id": userID})
+}
+
+// GetReportedUserStats breaks down the reports filed against a user account
+func (r *ReportRepository) GetReportedUserStats(ctx context.Context, userID primitive.ObjectID) (map[string]interface{}, error) {
+	filter := bson.M{"content_id": userID, "content_type": "user"}
+	total, err := r.count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	reporters, err := r.distinctIDs(ctx, "reporter_id", filter)
+	if err != nil {
+		return nil, err
+	}
+	byStatus, err := countBy(ctx, r.collection, filter, "status")
+	if err != nil {
+		return nil, err
+	}
+	byReason, err := countBy(ctx, r.collection, filter, "reason_code")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total":            total,
+		"unique_reporters": len(reporters),
+		"by_status":        byStatus,
+		"by_reason":        byReason,
+	}, nil
+}
+
+// BulkUpdateStatus moves several reports to a new status and returns how many changed
+func (r *ReportRepository) BulkUpdateStatus(ctx context.Context, ids []primitive.ObjectID, status string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.updateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": statusFields(status)})
+}
+
+// BulkAssignModerator assigns several reports to a moderator and returns how many changed
+func (r *ReportRepository) BulkAssignModerator(ctx context.Context, ids []primitive.ObjectID, moderatorID primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.updateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"moderator_id": moderatorID}})
+}
+
+// GetReportsByTimeRange retrieves reports filed within a time range, newest first
+func (r *ReportRepository) GetReportsByTimeRange(ctx context.Context, startTime, endTime time.Time, limit, offset int) ([]*models.Report, int, error) {
+	filter := inRange(bson.M{}, "created_at", startTime, endTime)
+	return findPage[models.Report](ctx, r.collection, filter, newestFirst, limit, offset)
+}
+
+// SubmitAppeal opens an appeal against the outcome of a report
+func (r *ReportRepository) SubmitAppeal(ctx context.Context, reportID primitive.ObjectID, reason string) error {
+	return r.updateFields(ctx, byID(reportID), bson.M{
+		"appeal": bson.M{
+			"status":       "pending",
+			"reason":       reason,
+			"submitted_at": time.Now(),
+		},
+	})
+}
+
+// ReviewAppeal settles a pending appeal. An approved appeal dismisses the report.
+func (r *ReportRepository) ReviewAppeal(ctx context.Context, reportID primitive.ObjectID, approved bool, reviewerID primitive.ObjectID, notes string) error {
+	fields := bson.M{
+		"appeal.status":      "rejected",
+		"appeal.reviewer_id": reviewerID,
+		"appeal.notes":       notes,
+		"appeal.reviewed_at": time.Now(),
+	}
+	if approved {
+		fields["appeal.status"] = "approved"
+		for key, value := range statusFields("dismissed") {
+			fields[key] = value
+		}
+	}
+	return r.updateFields(ctx, bson.M{"_id": reportID, "appeal.status": "pending"}, fields)
+}
+
+// GetAppealedReports retrieves reports with a pending appeal, oldest appeal first
+func (r *ReportRepository) GetAppealedReports(ctx context.Context, limit, offset int) ([]*models.Report, int, error) {
+	sort := bson.D{{Key: "appeal.submitted_at", Value: 1}, {Key: "_id", Value: 1}}
+	return findPage[models.Report](ctx, r.collection, bson.M{"appeal.status": "pending"}, sort, limit, offset)
+}
+
+// List retrieves reports with an arbitrary filter and sort
+func (r *ReportRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Report, int, error) {
+	return findPage[models.Report](ctx, r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// statusFields returns the fields to set when a report moves to status
+func statusFields(status string) bson.M {
+	fields := bson.M{"status": status}
+	if status == "actioned" || status == "dismissed" {
+		fields["resolved_at"] = time.Now()
+	}
+	return fields
+}
@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repositories holds the MongoDB-backed repositories
+type Repositories struct {
+	Users         *UserRepository
+	Follows       *FollowRepository
+	Posts         *PostRepository
+	Comments      *CommentRepository
+	Likes         *LikeRepository
+	Media         *MediaRepository
+	Reports       *ReportRepository
+	Groups        *GroupRepository
+	Events        *EventRepository
+	LiveStreams   *LiveStreamRepository
+	Notifications *NotificationRepository
+
+	Messages    *MessageRepository
+	Bookmarks   *BookmarkRepository
+	Outbox      *OutboxRepository
+	Passkeys    *PasskeyRepository
+	Sessions    *SessionRepository
+	SigningKeys *SigningKeyRepository
+
+	AccountPurges  *AccountPurgeRepository
+	AdminAudit     *AdminAuditRepository
+	LoginAttempts  *LoginAttemptRepository
+	LoginHistory   *LoginHistoryRepository
+	ModerationLog  *ModerationLogRepository
+	OAuthApps      *OAuthAppRepository
+	OAuthGrants    *OAuthGrantRepository
+	PersonalTokens *PersonalTokenRepository
+	TwoFactor      *TwoFactorRepository
+	Verifications  *VerificationRepository
+
+	// Transactor runs multi-document writes, such as a like and its counter, atomically
+	Transactor *Transactor
+
+	// Counters recomputes denormalised counters and reports drift
+	Counters *CounterReconciler
+}
+
+// NewRepositories builds every MongoDB repository on top of db
+func NewRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Users:         NewUserRepository(db),
+		Follows:       NewFollowRepository(db),
+		Posts:         NewPostRepository(db),
+		Comments:      NewCommentRepository(db),
+		Likes:         NewLikeRepository(db),
+		Media:         NewMediaRepository(db),
+		Reports:       NewReportRepository(db),
+		Groups:        NewGroupRepository(db),
+		Events:        NewEventRepository(db),
+		LiveStreams:   NewLiveStreamRepository(db),
+		Notifications: NewNotificationRepository(db),
+
+		Messages:    NewMessageRepository(db),
+		Bookmarks:   NewBookmarkRepository(db),
+		Outbox:      NewOutboxRepository(db),
+		Passkeys:    NewPasskeyRepository(db),
+		Sessions:    NewSessionRepository(db),
+		SigningKeys: NewSigningKeyRepository(db),
+		Transactor:  NewTransactor(db),
+		Counters:    NewCounterReconciler(db),
+
+		AccountPurges:  NewAccountPurgeRepository(db),
+		AdminAudit:     NewAdminAuditRepository(db),
+		LoginAttempts:  NewLoginAttemptRepository(db),
+		LoginHistory:   NewLoginHistoryRepository(db),
+		ModerationLog:  NewModerationLogRepository(db),
+		OAuthApps:      NewOAuthAppRepository(db),
+		OAuthGrants:    NewOAuthGrantRepository(db),
+		PersonalTokens: NewPersonalTokenRepository(db),
+		TwoFactor:      NewTwoFactorRepository(db),
+		Verifications:  NewVerificationRepository(db),
+	}
+}
@@ -0,0 +1,222 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// maxRotatedTokenHashes bounds how many exchanged refresh tokens a session
+// remembers. Replaying a token older than that is rejected but not detected.
+const maxRotatedTokenHashes = 200
+
+// SessionRepository implements interfaces.SessionRepository using MongoDB
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.SessionRepository = (*SessionRepository)(nil)
+
+// NewSessionRepository creates a new MongoDB session repository
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection(constants.CollectionSessions),
+	}
+}
+
+// Create inserts a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) (*models.Session, error) {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// FindByID retrieves a session by ID
+func (r *SessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// Update saves a session's access token, client details, expiry and status.
+// Refresh token hashes are only changed by RotateRefreshToken, so a stale copy
+// of the session cannot bring back a token that has already been exchanged.
+func (r *SessionRepository) Update(ctx context.Context, session *models.Session) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": session.ID},
+		bson.M{"$set": bson.M{
+			"token":        session.Token,
+			"user_agent":   session.UserAgent,
+			"ip_address":   session.IPAddress,
+			"device":       session.Device,
+			"location":     session.Location,
+			"expires_at":   session.ExpiresAt,
+			"last_used_at": session.LastUsedAt,
+			"is_active":    session.IsActive,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete permanently removes a session
+func (r *SessionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindByToken retrieves a session by its access token
+func (r *SessionRepository) FindByToken(ctx context.Context, token string) (*models.Session, error) {
+	return r.findOne(ctx, bson.M{"token": token})
+}
+
+// FindByUserID retrieves every session of a user, newest first
+func (r *SessionRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindByRefreshTokenHash retrieves the session whose current refresh token has the given hash
+func (r *SessionRepository) FindByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	return r.findOne(ctx, bson.M{"refresh_token_hash": hash})
+}
+
+// FindByRotatedTokenHash retrieves the session that has already exchanged the
+// refresh token with the given hash
+func (r *SessionRepository) FindByRotatedTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	return r.findOne(ctx, bson.M{"rotated_token_hashes": hash})
+}
+
+// RotateRefreshToken replaces the session's refresh token hash with
+// session.RefreshTokenHash and saves its new access token and expiry, moving
+// previousHash to the rotated hashes. An empty previousHash issues the first
+// refresh token of a session, which also clears what a pending login was
+// waiting for. The swap only happens while previousHash is
+// still current, so when the same token is exchanged twice concurrently one
+// caller gets mongo.ErrNoDocuments.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, session *models.Session, previousHash string) error {
+	filter := bson.M{"_id": session.ID, "is_active": true}
+	update := bson.M{
+		"$set": bson.M{
+			"token":              session.Token,
+			"refresh_token_hash": session.RefreshTokenHash,
+			"expires_at":         session.ExpiresAt,
+			"last_used_at":       session.LastUsedAt,
+		},
+	}
+	if previousHash == "" {
+		filter["refresh_token_hash"] = bson.M{"$exists": false}
+		update["$unset"] = bson.M{"pending_factor": "", "step_up_code_hash": ""}
+	} else {
+		filter["refresh_token_hash"] = previousHash
+		update["$push"] = bson.M{"rotated_token_hashes": bson.M{
+			"$each":  bson.A{previousHash},
+			"$slice": -maxRotatedTokenHashes,
+		}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Revoke deactivates a session and records why. The session is kept until it
+// expires so its rotated refresh tokens are still recognised if replayed.
+// Revoking a session that is already revoked returns mongo.ErrNoDocuments.
+func (r *SessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, reason string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"is_active":      false,
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ElevateImpersonation lets an active impersonation session make changes.
+// A session already elevated is not matched.
+func (r *SessionRepository) ElevateImpersonation(ctx context.Context, id primitive.ObjectID, elevatedAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "is_active": true, "impersonation.write": false},
+		bson.M{"$set": bson.M{
+			"impersonation.write":       true,
+			"impersonation.elevated_at": elevatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteByUserID permanently removes every session of a user
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// DeleteExpired removes sessions past their expiry that the TTL index has not reaped yet
+func (r *SessionRepository) DeleteExpired(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lt": time.Now()}})
+	return err
+}
+
+func (r *SessionRepository) findOne(ctx context.Context, filter bson.M) (*models.Session, error) {
+	var session models.Session
+	if err := r.collection.FindOne(ctx, filter).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,55 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// SigningKeyRepository implements interfaces.SigningKeyRepository using MongoDB
+type SigningKeyRepository struct {
+	collection *mongo.Collection
+}
+
+var _ interfaces.SigningKeyRepository = (*SigningKeyRepository)(nil)
+
+// NewSigningKeyRepository creates a new MongoDB signing key repository
+func NewSigningKeyRepository(db *mongo.Database) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		collection: db.Collection(constants.CollectionSigningKeys),
+	}
+}
+
+// Create inserts a new signing key
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+// GetUnexpired retrieves every key still published at now, oldest first. The
+// TTL index removes expired keys, but only about once a minute.
+func (r *SigningKeyRepository) GetUnexpired(ctx context.Context, now time.Time) ([]*models.SigningKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "activates_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"expires_at": bson.M{"$gt": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []*models.SigningKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
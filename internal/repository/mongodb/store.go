@@ -0,0 +1,336 @@
+package mongodb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+)
+
+// store is the plumbing shared by the repositories that keep one model per
+// collection
+type store struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func newStore(db *mongo.Database, collection string) store {
+	return store{db: db, collection: db.Collection(collection)}
+}
+
+// EnsureIndexes creates the indexes declared for the collection
+func (s store) EnsureIndexes(ctx context.Context) error {
+	return dbmongo.EnsureIndexes(ctx, s.db, s.collection.Name())
+}
+
+// byID matches a document by ID
+func byID(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id}
+}
+
+// notDeleted matches a document by ID unless it was soft deleted
+func notDeleted(id primitive.ObjectID) bson.M {
+	return mongoutil.Merge(byID(id), mongoutil.NotDeleted())
+}
+
+// listFilter converts a caller filter, excluding soft-deleted documents unless
+// the filter mentions deleted_at
+func listFilter(filter map[string]interface{}) bson.M {
+	query := mongoutil.ToFilter(filter)
+	if _, ok := query["deleted_at"]; !ok {
+		query = mongoutil.Merge(query, mongoutil.NotDeleted())
+	}
+	return query
+}
+
+// insert stores a model and returns its ID
+func (s store) insert(ctx context.Context, id primitive.ObjectID, v interface{}) (primitive.ObjectID, error) {
+	if _, err := s.collection.InsertOne(ctx, v); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return id, nil
+}
+
+// replace overwrites the document with the given ID
+func (s store) replace(ctx context.Context, id primitive.ObjectID, v interface{}) error {
+	return matchedOne(s.collection.ReplaceOne(ctx, byID(id), v))
+}
+
+// remove permanently deletes the document with the given ID
+func (s store) remove(ctx context.Context, id primitive.ObjectID) error {
+	return s.removeWhere(ctx, byID(id))
+}
+
+// removeWhere permanently deletes the first document matching filter
+func (s store) removeWhere(ctx context.Context, filter bson.M) error {
+	result, err := s.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// removeMany permanently deletes the documents with the given IDs and returns
+// how many were deleted
+func (s store) removeMany(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return int(result.DeletedCount), nil
+}
+
+// softDelete stamps deleted_at on the document with the given ID
+func (s store) softDelete(ctx context.Context, id primitive.ObjectID) error {
+	return s.updateFields(ctx, notDeleted(id), bson.M{"deleted_at": time.Now()})
+}
+
+// updateFields sets fields on the first document matching filter and bumps updated_at
+func (s store) updateFields(ctx context.Context, filter bson.M, fields bson.M) error {
+	return s.update(ctx, filter, bson.M{"$set": fields})
+}
+
+// update applies an update document to the first document matching filter and bumps updated_at
+func (s store) update(ctx context.Context, filter bson.M, update bson.M) error {
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = time.Now()
+	return matchedOne(s.collection.UpdateOne(ctx, filter, update))
+}
+
+// increment atomically adjusts a counter on the document with the given ID
+func (s store) increment(ctx context.Context, id primitive.ObjectID, field string, amount int) error {
+	return s.update(ctx, byID(id), bson.M{"$inc": bson.M{field: amount}})
+}
+
+// distinctIDs returns the distinct object IDs stored in field across the
+// documents matching filter
+func (s store) distinctIDs(ctx context.Context, field string, filter bson.M) ([]primitive.ObjectID, error) {
+	values, err := s.collection.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, err
+	}
+	return objectIDs(values), nil
+}
+
+// updateMany applies an update document to every document matching filter,
+// bumps updated_at and returns how many were modified
+func (s store) updateMany(ctx context.Context, filter bson.M, update bson.M) (int, error) {
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = time.Now()
+	result, err := s.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// count counts the documents matching filter
+func (s store) count(ctx context.Context, filter bson.M) (int, error) {
+	count, err := s.collection.CountDocuments(ctx, filter)
+	return int(count), err
+}
+
+// objectIDs converts distinct values into object IDs, skipping anything else
+func objectIDs(values []interface{}) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// inRange restricts a time field of filter to [start, end]. Zero bounds are open.
+func inRange(filter bson.M, field string, start, end time.Time) bson.M {
+	condition := bson.M{}
+	if !start.IsZero() {
+		condition["$gte"] = start
+	}
+	if !end.IsZero() {
+		condition["$lte"] = end
+	}
+	if len(condition) > 0 {
+		filter[field] = condition
+	}
+	return filter
+}
+
+// textFilter matches documents where any of the fields contains any word of
+// the query, ignoring case, for collections without a text index
+func textFilter(query string, fields ...string) bson.M {
+	clauses := bson.A{}
+	for _, word := range strings.Fields(query) {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(word), Options: "i"}
+		for _, field := range fields {
+			clauses = append(clauses, bson.M{field: pattern})
+		}
+	}
+	if len(clauses) == 0 {
+		return bson.M{"_id": bson.M{"$in": bson.A{}}}
+	}
+	return bson.M{"$or": clauses}
+}
+
+// and combines filters that may share keys such as $or
+func and(filters ...bson.M) bson.M {
+	clauses := make(bson.A, len(filters))
+	for i, filter := range filters {
+		clauses[i] = filter
+	}
+	return bson.M{"$and": clauses}
+}
+
+// matchedOne turns an update that matched nothing into mongo.ErrNoDocuments
+func matchedOne(result *mongo.UpdateResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// findOne decodes the first document matching filter in sort order
+func findOne[T any](ctx context.Context, c *mongo.Collection, filter bson.M, sort bson.D) (*T, error) {
+	opts := options.FindOne()
+	if len(sort) > 0 {
+		opts.SetSort(sort)
+	}
+
+	var v T
+	if err := c.FindOne(ctx, filter, opts).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// findAll decodes every document matching filter in sort order
+func findAll[T any](ctx context.Context, c *mongo.Collection, filter bson.M, sort bson.D) ([]*T, error) {
+	opts := options.Find()
+	if len(sort) > 0 {
+		opts.SetSort(sort)
+	}
+	return find[T](ctx, c, filter, opts)
+}
+
+// findLimit decodes up to limit documents matching filter in sort order
+func findLimit[T any](ctx context.Context, c *mongo.Collection, filter bson.M, sort bson.D, limit int) ([]*T, error) {
+	return find[T](ctx, c, filter, mongoutil.FindPage(limit, 0, sort))
+}
+
+// findPage decodes one page of the documents matching filter plus their total
+func findPage[T any](ctx context.Context, c *mongo.Collection, filter bson.M, sort bson.D, limit, offset int) ([]*T, int, error) {
+	total, err := c.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items, err := find[T](ctx, c, filter, mongoutil.FindPage(limit, offset, sort))
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, int(total), nil
+}
+
+func find[T any](ctx context.Context, c *mongo.Collection, filter bson.M, opts *options.FindOptions) ([]*T, error) {
+	cursor, err := c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []*T{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// aggregate runs pipeline and decodes every result
+func aggregate[T any](ctx context.Context, c *mongo.Collection, pipeline mongo.Pipeline) ([]T, error) {
+	cursor, err := c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// topIDs returns the object IDs stored in field that occur most often across
+// the documents matching filter, most frequent first
+func topIDs(ctx context.Context, c *mongo.Collection, filter bson.M, field string, limit int) ([]primitive.ObjectID, error) {
+	limit, _ = mongoutil.NormalizePagination(limit, 0)
+
+	ranked, err := aggregate[struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}](ctx, c, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(ranked))
+	for i, entry := range ranked {
+		ids[i] = entry.ID
+	}
+	return ids, nil
+}
+
+// countBy counts the documents matching filter per value of a string field
+func countBy(ctx context.Context, c *mongo.Collection, filter bson.M, field string) (map[string]int, error) {
+	groups, err := aggregate[struct {
+		Value string `bson:"_id"`
+		Count int    `bson:"count"`
+	}](ctx, c, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(groups))
+	for _, group := range groups {
+		counts[group.Value] = group.Count
+	}
+	return counts, nil
+}
+
+// newestFirst sorts documents by creation time, newest first
+var newestFirst = mongoutil.NewestFirst
+
+// oldestFirst sorts documents by creation time, oldest first
+var oldestFirst = bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
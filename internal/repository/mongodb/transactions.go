@@ -0,0 +1,51 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+)
+
+// Transactor runs units of work in MongoDB transactions. Transactions need a
+// replica set or sharded cluster; a single-node replica set is enough locally.
+type Transactor struct {
+	client *mongo.Client
+}
+
+var _ interfaces.Transactor = (*Transactor)(nil)
+
+// NewTransactor creates a transactor for the client behind db
+func NewTransactor(db *mongo.Database) *Transactor {
+	return &Transactor{client: db.Client()}
+}
+
+// WithTransaction runs fn in a transaction, committing when it returns nil and
+// aborting otherwise. The context passed to fn carries the session, so
+// repository calls made with it take part in the transaction. The driver
+// retries fn on transient errors, so fn must not have side effects outside the
+// database. Calls made inside an existing transaction join it.
+func (t *Transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	opts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	}, opts)
+	return err
+}
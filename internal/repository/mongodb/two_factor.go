@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// TwoFactorRepository implements interfaces.TwoFactorRepository using MongoDB
+type TwoFactorRepository struct {
+	store
+}
+
+var _ interfaces.TwoFactorRepository = (*TwoFactorRepository)(nil)
+
+// NewTwoFactorRepository creates a new MongoDB two-factor repository
+func NewTwoFactorRepository(db *mongo.Database) *TwoFactorRepository {
+	return &TwoFactorRepository{store: newStore(db, constants.CollectionTwoFactorSecrets)}
+}
+
+// SaveSecret stores the user's TOTP secret, replacing any earlier one
+func (r *TwoFactorRepository) SaveSecret(ctx context.Context, userID primitive.ObjectID, secret string) error {
+	return r.upsert(ctx, userID, bson.M{"secret": secret})
+}
+
+// GetSecret returns the user's TOTP secret
+func (r *TwoFactorRepository) GetSecret(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	record, err := findOne[models.TwoFactorSecret](ctx, r.collection, bson.M{"user_id": userID}, nil)
+	if err != nil {
+		return "", err
+	}
+	return record.Secret, nil
+}
+
+// DeleteSecret removes the user's secret and recovery codes
+func (r *TwoFactorRepository) DeleteSecret(ctx context.Context, userID primitive.ObjectID) error {
+	return r.removeWhere(ctx, bson.M{"user_id": userID})
+}
+
+// SaveRecoveryCodes replaces the user's recovery codes
+func (r *TwoFactorRepository) SaveRecoveryCodes(ctx context.Context, userID primitive.ObjectID, codes []string) error {
+	return r.upsert(ctx, userID, bson.M{"recovery_codes": codes})
+}
+
+// GetRecoveryCodes returns the recovery codes the user has not used yet
+func (r *TwoFactorRepository) GetRecoveryCodes(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	record, err := findOne[models.TwoFactorSecret](ctx, r.collection, bson.M{"user_id": userID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return record.RecoveryCodes, nil
+}
+
+// MarkRecoveryCodeUsed removes a recovery code. Matching on the code makes
+// two logins racing with the same code succeed only once.
+func (r *TwoFactorRepository) MarkRecoveryCodeUsed(ctx context.Context, userID primitive.ObjectID, code string) error {
+	return r.update(ctx, bson.M{"user_id": userID, "recovery_codes": code}, bson.M{"$pull": bson.M{"recovery_codes": code}})
+}
+
+// upsert sets fields on the user's record, creating it on first use with the
+// fields not being set left empty
+func (r *TwoFactorRepository) upsert(ctx context.Context, userID primitive.ObjectID, fields bson.M) error {
+	now := time.Now()
+	fields["updated_at"] = now
+	onInsert := bson.M{"_id": primitive.NewObjectID(), "secret": "", "recovery_codes": bson.A{}, "created_at": now}
+	for field := range fields {
+		delete(onInsert, field)
+	}
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": fields, "$setOnInsert": onInsert},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
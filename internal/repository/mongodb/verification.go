@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Verification statuses a record is redeemed between
+const (
+	verificationPending = "pending"
+	verificationUsed    = "used"
+)
+
+// VerificationRepository implements interfaces.VerificationRepository using MongoDB
+type VerificationRepository struct {
+	store
+}
+
+var _ interfaces.VerificationRepository = (*VerificationRepository)(nil)
+
+// NewVerificationRepository creates a new MongoDB verification repository
+func NewVerificationRepository(db *mongo.Database) *VerificationRepository {
+	return &VerificationRepository{store: newStore(db, constants.CollectionVerifications)}
+}
+
+// Create inserts a new verification record
+func (r *VerificationRepository) Create(ctx context.Context, verification *models.Verification) (*models.Verification, error) {
+	if verification.ID.IsZero() {
+		verification.ID = primitive.NewObjectID()
+	}
+	if verification.CreatedAt.IsZero() {
+		verification.CreatedAt = time.Now()
+	}
+	if _, err := r.insert(ctx, verification.ID, verification); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// FindByID retrieves a verification record by ID
+func (r *VerificationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Verification, error) {
+	return findOne[models.Verification](ctx, r.collection, byID(id), nil)
+}
+
+// Update replaces a verification record
+func (r *VerificationRepository) Update(ctx context.Context, verification *models.Verification) error {
+	return r.replace(ctx, verification.ID, verification)
+}
+
+// Delete permanently removes a verification record
+func (r *VerificationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(ctx, id)
+}
+
+// FindByToken retrieves the record of a type carrying a code or link token
+func (r *VerificationRepository) FindByToken(ctx context.Context, token, type_ string) (*models.Verification, error) {
+	return findOne[models.Verification](ctx, r.collection, bson.M{"verification_code": token, "type": type_}, newestFirst)
+}
+
+// FindByUserID retrieves the user's most recent record of a type
+func (r *VerificationRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, type_ string) (*models.Verification, error) {
+	return findOne[models.Verification](ctx, r.collection, bson.M{"user_id": userID, "type": type_}, newestFirst)
+}
+
+// CountSince counts the user's records of a type created since a time
+func (r *VerificationRepository) CountSince(ctx context.Context, userID primitive.ObjectID, type_ string, since time.Time) (int, error) {
+	return r.count(ctx, bson.M{"user_id": userID, "type": type_, "created_at": bson.M{"$gte": since}})
+}
+
+// Redeem marks a pending, unexpired record used in a single update, so only
+// one caller can redeem it
+func (r *VerificationRepository) Redeem(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Verification, error) {
+	var verification models.Verification
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": verificationPending, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"status": verificationUsed, "verified_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&verification)
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
@@ -0,0 +1,276 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/Caqil/vyrall/internal/utils/logger"
+)
+
+// lockPrefix namespaces the lock keys
+const lockPrefix = "lock:"
+
+var (
+	// ErrLockNotAcquired is returned when the lock is held by someone else
+	ErrLockNotAcquired = errors.New("lock is held by another owner")
+
+	// ErrLockLost is returned when the lease expired or was taken over before
+	// the holder refreshed or released it
+	ErrLockLost = errors.New("lock lost")
+)
+
+// LockManager hands out leased locks stored in Redis. Each successful acquire
+// also returns a fencing token that increases monotonically per lock name, so
+// a resource can reject writes from a holder whose lease has since expired.
+type LockManager struct {
+	client *goredis.Client
+}
+
+// NewLockManager creates a lock manager on the given Redis client
+func NewLockManager(client *goredis.Client) *LockManager {
+	return &LockManager{client: client}
+}
+
+func lockKey(name string) string  { return lockPrefix + name }
+func fenceKey(name string) string { return lockPrefix + name + ":fence" }
+
+// Lock is a held lease on a named lock
+type Lock struct {
+	manager *LockManager
+	name    string
+	owner   string
+	token   int64
+	ttl     time.Duration
+}
+
+var acquireScript = goredis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// Acquire takes the lock for ttl without waiting. It returns ErrLockNotAcquired
+// when another owner holds it.
+func (m *LockManager) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := acquireScript.Run(ctx, m.client,
+		[]string{lockKey(name), fenceKey(name)},
+		owner, ttl.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("acquire lock %s: %w", name, err)
+	}
+	if token == 0 {
+		return nil, ErrLockNotAcquired
+	}
+
+	return &Lock{manager: m, name: name, owner: owner, token: token, ttl: ttl}, nil
+}
+
+// AcquireWait retries Acquire every retry interval until it succeeds or ctx is done
+func (m *LockManager) AcquireWait(ctx context.Context, name string, ttl, retry time.Duration) (*Lock, error) {
+	for {
+		lock, err := m.Acquire(ctx, name, ttl)
+		if !errors.Is(err, ErrLockNotAcquired) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// Name returns the lock name
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Token returns the fencing token of this lease. Tokens only ever increase, so
+// storing the highest token seen and rejecting lower ones makes writes from a
+// stale holder harmless.
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+var refreshScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Refresh extends the lease by the lock's ttl. It returns ErrLockLost if the
+// lease has already expired or been taken over.
+func (l *Lock) Refresh(ctx context.Context) error {
+	refreshed, err := refreshScript.Run(ctx, l.manager.client, []string{lockKey(l.name)}, l.owner, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("refresh lock %s: %w", l.name, err)
+	}
+	if refreshed == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+var releaseScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Release gives the lock up. Only the current owner can release it, so a holder
+// whose lease expired cannot delete a lock someone else has since acquired.
+func (l *Lock) Release(ctx context.Context) error {
+	released, err := releaseScript.Run(ctx, l.manager.client, []string{lockKey(l.name)}, l.owner).Int()
+	if err != nil {
+		return fmt.Errorf("release lock %s: %w", l.name, err)
+	}
+	if released == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// KeepAlive refreshes the lease every third of its ttl until ctx is done. The
+// returned context is cancelled when ctx is done or the lease is lost, so work
+// done under the lock should use it. Call stop to end renewal.
+func (l *Lock) KeepAlive(ctx context.Context) (held context.Context, stop context.CancelFunc) {
+	held, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer cancel()
+
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-held.Done():
+				return
+			case <-ticker.C:
+				err := l.Refresh(held)
+				if errors.Is(err, ErrLockLost) {
+					return
+				}
+				// Transient errors are retried on the next tick; the lease
+				// survives two missed refreshes
+			}
+		}
+	}()
+
+	return held, cancel
+}
+
+func newOwner() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate lock owner: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// fencingTokenKey is the context key holding the leader's fencing token
+type fencingTokenKey struct{}
+
+// FencingToken returns the fencing token of the leadership lease a job runs under
+func FencingToken(ctx context.Context) (int64, bool) {
+	token, ok := ctx.Value(fencingTokenKey{}).(int64)
+	return token, ok
+}
+
+// LeaderElector makes one instance in the cluster the leader for a name. The
+// leader holds a renewed lease; if it dies, another instance takes over once
+// the lease expires.
+type LeaderElector struct {
+	locks *LockManager
+	name  string
+	ttl   time.Duration
+	log   *logger.Logger
+}
+
+// NewLeaderElector creates an elector for name. ttl bounds how long the cluster
+// can be without a leader after the leader dies.
+func NewLeaderElector(locks *LockManager, name string, ttl time.Duration, log *logger.Logger) *LeaderElector {
+	return &LeaderElector{
+		locks: locks,
+		name:  "leader:" + name,
+		ttl:   ttl,
+		log:   log.With("election", name),
+	}
+}
+
+// Run campaigns for leadership until ctx is done. Each time this instance
+// becomes leader, lead is called with a context that is cancelled when
+// leadership is lost; lead should return promptly once it is.
+func (e *LeaderElector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for ctx.Err() == nil {
+		lock, err := e.locks.AcquireWait(ctx, e.name, e.ttl, e.ttl/2)
+		if err != nil {
+			if ctx.Err() == nil {
+				e.log.Error("Leader election failed", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(e.ttl / 2):
+				}
+			}
+			continue
+		}
+
+		e.log.Info("Became leader", "fencing_token", lock.Token())
+		held, stop := lock.KeepAlive(ctx)
+		lead(context.WithValue(held, fencingTokenKey{}, lock.Token()))
+		lost := held.Err() != nil && ctx.Err() == nil
+		stop()
+
+		// Step down so another instance can take over immediately
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := lock.Release(releaseCtx); err != nil && !errors.Is(err, ErrLockLost) {
+			e.log.Warn("Failed to release leadership", "error", err)
+		}
+		cancel()
+
+		if lost {
+			e.log.Warn("Lost leadership")
+		}
+	}
+}
+
+// RunPeriodically runs fn on the leader at every multiple of interval since
+// the Unix epoch, shifted by offset. Because runs are aligned to the wall clock
+// rather than to when leadership was won, a failover does not cause an extra run.
+func (e *LeaderElector) RunPeriodically(ctx context.Context, interval, offset time.Duration, fn func(ctx context.Context) error) {
+	e.Run(ctx, func(ctx context.Context) {
+		for {
+			now := time.Now()
+			next := now.Truncate(interval).Add(offset)
+			for !next.After(now) {
+				next = next.Add(interval)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+
+			if err := fn(ctx); err != nil && ctx.Err() == nil {
+				e.log.Error("Leader job failed", "error", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package redis_test
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/Caqil/vyrall/internal/repository/redis"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+)
+
+// newLockManager returns a lock manager on a fresh in-memory Redis, whose
+// clock the test moves forward
+func newLockManager(t *testing.T) (*redis.LockManager, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return redis.NewLockManager(client), server
+}
+
+// acquire takes the named lock, failing the test on error
+func acquire(t *testing.T, locks *redis.LockManager, name string) *redis.Lock {
+	t.Helper()
+	lock, err := locks.Acquire(context.Background(), name, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire %s: %v", name, err)
+	}
+	return lock
+}
+
+func TestLockIsExclusive(t *testing.T) {
+	locks, _ := newLockManager(t)
+	ctx := context.Background()
+
+	first := acquire(t, locks, "reminders")
+	if _, err := locks.Acquire(ctx, "reminders", time.Minute); !stderrors.Is(err, redis.ErrLockNotAcquired) {
+		t.Fatalf("second acquire = %v, want the lock held", err)
+	}
+	acquire(t, locks, "sessions")
+
+	if err := first.Release(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	second := acquire(t, locks, "reminders")
+	if second.Token() <= first.Token() {
+		t.Fatalf("fencing token %d after %d, want it to increase", second.Token(), first.Token())
+	}
+}
+
+func TestExpiredLockHolderCannotTouchTheNextLease(t *testing.T) {
+	locks, server := newLockManager(t)
+	ctx := context.Background()
+
+	stale := acquire(t, locks, "aggregation")
+	server.FastForward(2 * time.Minute)
+	current := acquire(t, locks, "aggregation")
+	if current.Token() <= stale.Token() {
+		t.Fatalf("fencing token %d after %d, want it to increase", current.Token(), stale.Token())
+	}
+
+	if err := stale.Refresh(ctx); !stderrors.Is(err, redis.ErrLockLost) {
+		t.Fatalf("stale refresh = %v, want the lock lost", err)
+	}
+	if err := stale.Release(ctx); !stderrors.Is(err, redis.ErrLockLost) {
+		t.Fatalf("stale release = %v, want the lock lost", err)
+	}
+	if _, err := locks.Acquire(ctx, "aggregation", time.Minute); !stderrors.Is(err, redis.ErrLockNotAcquired) {
+		t.Fatalf("acquire after a stale release = %v, want the lock still held", err)
+	}
+
+	// Refreshing keeps the lease past its original ttl
+	if err := current.Refresh(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	server.FastForward(50 * time.Second)
+	if err := current.Refresh(ctx); err != nil {
+		t.Fatalf("refresh a refreshed lease: %v", err)
+	}
+}
+
+func TestLeaderElectionHasOneLeader(t *testing.T) {
+	locks, _ := newLockManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		leading int
+		tokens  []int64
+	)
+	lead := func(ctx context.Context) {
+		mu.Lock()
+		leading++
+		overlap := leading > 1
+		token, _ := redis.FencingToken(ctx)
+		tokens = append(tokens, token)
+		mu.Unlock()
+		if overlap {
+			t.Error("two instances led at once")
+		}
+
+		// Lead briefly, then step down for the other instance
+		select {
+		case <-ctx.Done():
+		case <-time.After(20 * time.Millisecond):
+		}
+		mu.Lock()
+		leading--
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for _, instance := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(instance string) {
+			defer wg.Done()
+			elector := redis.NewLeaderElector(locks, "cron", 100*time.Millisecond, logger.NewNop().With("instance", instance))
+			elector.Run(ctx, lead)
+		}(instance)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		terms := len(tokens)
+		mu.Unlock()
+		if terms >= 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d terms of leadership, want leadership to pass between instances", terms)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i] <= tokens[i-1] {
+			t.Fatalf("fencing tokens %v, want every term's token higher", tokens)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+)
+
+// timelinePrefix namespaces the timeline keys
+const timelinePrefix = "timeline:"
+
+// timelineBatchSize bounds how many timelines one pipeline writes to
+const timelineBatchSize = 500
+
+// TimelineRepository keeps each home timeline in a sorted set of
+// "<post>:<author>" members scored by publish time in milliseconds, so
+// members with the same score are ordered by post ID. A separate marker key records that the timeline is built, so an empty
+// timeline is told apart from one that was never built or has expired. Both
+// keys share a hash tag, so the scripts work on a cluster.
+type TimelineRepository struct {
+	client *goredis.Client
+}
+
+// NewTimelineRepository creates a timeline repository on the given Redis client
+func NewTimelineRepository(client *goredis.Client) *TimelineRepository {
+	return &TimelineRepository{client: client}
+}
+
+func timelineKey(userID primitive.ObjectID) string {
+	return timelinePrefix + "{" + userID.Hex() + "}"
+}
+
+func timelineBuiltKey(userID primitive.ObjectID) string {
+	return timelineKey(userID) + ":built"
+}
+
+// Exists reports whether userID's timeline is built
+func (r *TimelineRepository) Exists(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	n, err := r.client.Exists(ctx, timelineBuiltKey(userID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("check timeline: %w", err)
+	}
+	return n > 0, nil
+}
+
+var rebuildTimelineScript = goredis.NewScript(`
+redis.call('DEL', KEYS[1])
+for i = 3, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[2]) - 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+redis.call('SET', KEYS[2], '1', 'PX', ARGV[1])
+return 1
+`)
+
+// Rebuild replaces userID's timeline with entries and keeps it for ttl
+func (r *TimelineRepository) Rebuild(ctx context.Context, userID primitive.ObjectID, entries []models.TimelineEntry, maxSize int, ttl time.Duration) error {
+	args := append([]interface{}{ttl.Milliseconds(), maxSize}, timelineMembers(entries)...)
+	keys := []string{timelineKey(userID), timelineBuiltKey(userID)}
+	if err := rebuildTimelineScript.Run(ctx, r.client, keys, args...).Err(); err != nil {
+		return fmt.Errorf("rebuild timeline: %w", err)
+	}
+	return nil
+}
+
+var addTimelineScript = goredis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[2])
+if ttl <= 0 then
+	return 0
+end
+for i = 2, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[1]) - 1)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return 1
+`)
+
+// Add puts entries into the timeline of each of userIDs that exists. The
+// writes go out in pipelined batches, so a large fan-out takes a few round
+// trips rather than one per follower.
+func (r *TimelineRepository) Add(ctx context.Context, userIDs []primitive.ObjectID, entries []models.TimelineEntry, maxSize int) error {
+	if len(userIDs) == 0 || len(entries) == 0 {
+		return nil
+	}
+	if err := addTimelineScript.Load(ctx, r.client).Err(); err != nil {
+		return fmt.Errorf("load timeline script: %w", err)
+	}
+
+	args := append([]interface{}{maxSize}, timelineMembers(entries)...)
+	for start := 0; start < len(userIDs); start += timelineBatchSize {
+		end := start + timelineBatchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+
+		pipe := r.client.Pipeline()
+		for _, userID := range userIDs[start:end] {
+			addTimelineScript.EvalSha(ctx, pipe, []string{timelineKey(userID), timelineBuiltKey(userID)}, args...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("add to timelines: %w", err)
+		}
+	}
+	return nil
+}
+
+// Range returns up to limit entries after the cursor after, newest first,
+// and keeps the timeline for another ttl. The entries published in the
+// cursor's millisecond are read in full and those up to its post dropped;
+// there are only ever a few of them.
+func (r *TimelineRepository) Range(ctx context.Context, userID primitive.ObjectID, after models.TimelineCursor, limit int, ttl time.Duration) ([]models.TimelineEntry, error) {
+	key := timelineKey(userID)
+	score := strconv.FormatInt(after.PublishedAt.UnixMilli(), 10)
+
+	pipe := r.client.Pipeline()
+	tiedCmd := pipe.ZRangeArgsWithScores(ctx, goredis.ZRangeArgs{
+		Key:     key,
+		Start:   score,
+		Stop:    score,
+		ByScore: true,
+		Rev:     true,
+	})
+	olderCmd := pipe.ZRangeArgsWithScores(ctx, goredis.ZRangeArgs{
+		Key:     key,
+		Start:   "-inf",
+		Stop:    "(" + score,
+		ByScore: true,
+		Rev:     true,
+		Count:   int64(limit),
+	})
+	pipe.PExpire(ctx, key, ttl)
+	pipe.PExpire(ctx, timelineBuiltKey(userID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("read timeline: %w", err)
+	}
+
+	entries := make([]models.TimelineEntry, 0, limit)
+	for _, member := range append(tiedCmd.Val(), olderCmd.Val()...) {
+		if len(entries) >= limit {
+			break
+		}
+		entry, ok := parseTimelineMember(member)
+		if !ok {
+			continue
+		}
+		if entry.PublishedAt.UnixMilli() == after.PublishedAt.UnixMilli() && entry.PostID.Hex() >= after.PostID.Hex() {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+var removeTimelineAuthorScript = goredis.NewScript(`
+local removed = 0
+for _, member in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	if string.sub(member, -#ARGV[1]) == ARGV[1] then
+		removed = removed + redis.call('ZREM', KEYS[1], member)
+	end
+end
+return removed
+`)
+
+// RemoveAuthor removes authorID's posts from userID's timeline
+func (r *TimelineRepository) RemoveAuthor(ctx context.Context, userID, authorID primitive.ObjectID) error {
+	err := removeTimelineAuthorScript.Run(ctx, r.client, []string{timelineKey(userID)}, ":"+authorID.Hex()).Err()
+	if err != nil {
+		return fmt.Errorf("remove author from timeline: %w", err)
+	}
+	return nil
+}
+
+// timelineMembers flattens entries into score, member script arguments
+func timelineMembers(entries []models.TimelineEntry) []interface{} {
+	args := make([]interface{}, 0, 2*len(entries))
+	for _, entry := range entries {
+		args = append(args, entry.PublishedAt.UnixMilli(), entry.PostID.Hex()+":"+entry.AuthorID.Hex())
+	}
+	return args
+}
+
+func parseTimelineMember(z goredis.Z) (models.TimelineEntry, bool) {
+	member, _ := z.Member.(string)
+	post, author, found := strings.Cut(member, ":")
+	if !found {
+		return models.TimelineEntry{}, false
+	}
+	authorID, err := primitive.ObjectIDFromHex(author)
+	if err != nil {
+		return models.TimelineEntry{}, false
+	}
+	postID, err := primitive.ObjectIDFromHex(post)
+	if err != nil {
+		return models.TimelineEntry{}, false
+	}
+	return models.TimelineEntry{
+		PostID:      postID,
+		AuthorID:    authorID,
+		PublishedAt: time.UnixMilli(int64(z.Score)),
+	}, true
+}
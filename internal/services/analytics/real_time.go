@@ -20,6 +20,9 @@ type RealTimeService struct {
 	counterMutex sync.RWMutex
 	// Channel for publishing real-time events
 	eventChan chan *RealTimeEvent
+	// Closed to stop the background goroutines
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // RealTimeEvent represents a real-time analytics event
@@ -40,6 +43,7 @@ func NewRealTimeService(db *database.Database, cache *database.RedisClient, log
 		log:       log,
 		counters:  make(map[string]int),
 		eventChan: make(chan *RealTimeEvent, 1000), // Buffer size of 1000
+		done:      make(chan struct{}),
 	}
 
 	// Start the event processor
@@ -234,11 +238,24 @@ func (s *RealTimeService) flushCounters() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		s.doFlushCounters()
+	for {
+		select {
+		case <-ticker.C:
+			s.doFlushCounters()
+		case <-s.done:
+			return
+		}
 	}
 }
 
+// Close stops the background goroutines and flushes any pending counters to the cache
+func (s *RealTimeService) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.doFlushCounters()
+	})
+}
+
 // doFlushCounters performs the actual counter flushing
 func (s *RealTimeService) doFlushCounters() {
 	// Get the current counters
@@ -276,9 +293,14 @@ func (s *RealTimeService) doFlushCounters() {
 
 // processEvents processes events from the event channel
 func (s *RealTimeService) processEvents() {
-	for event := range s.eventChan {
-		// Process the event (e.g., publish to subscribers)
-		s.publishEvent(event)
+	for {
+		select {
+		case event := <-s.eventChan:
+			// Process the event (e.g., publish to subscribers)
+			s.publishEvent(event)
+		case <-s.done:
+			return
+		}
 	}
 }
 
@@ -247,6 +247,59 @@ func (s *ReportingService) DeleteScheduledReport(ctx context.Context, reportID p
 	return nil
 }
 
+// DueScheduledReports returns the IDs of active scheduled reports whose next run has passed
+func (s *ReportingService) DueScheduledReports(ctx context.Context, now time.Time) ([]primitive.ObjectID, error) {
+	var reports []*ScheduledReport
+	filter := bson.M{
+		"status":   "active",
+		"next_run": bson.M{"$lte": now},
+	}
+
+	if err := s.db.Find(ctx, "scheduled_reports", filter, &reports); err != nil {
+		s.log.Error("Failed to find due scheduled reports", "error", err)
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(reports))
+	for i, report := range reports {
+		ids[i] = report.ID
+	}
+	return ids, nil
+}
+
+// RunScheduledReport generates a scheduled report and advances its next run time
+func (s *ReportingService) RunScheduledReport(ctx context.Context, reportID primitive.ObjectID) error {
+	scheduled, err := s.GetScheduledReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.GenerateReport(ctx, scheduled.Options)
+	if err != nil {
+		s.log.Error("Failed to generate scheduled report", "error", err, "report_id", reportID.Hex())
+		return err
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"last_run":   now,
+		"next_run":   s.calculateNextRunTime(scheduled.Schedule),
+		"updated_at": now,
+	}
+	if !report.ID.IsZero() {
+		set["last_report_id"] = report.ID
+	}
+
+	// Only advance a schedule that was not already advanced by another run
+	filter := bson.M{"_id": reportID, "next_run": scheduled.NextRun}
+	if _, err := s.db.UpdateOne(ctx, "scheduled_reports", filter, bson.M{"$set": set}); err != nil {
+		s.log.Error("Failed to update scheduled report", "error", err, "report_id", reportID.Hex())
+		return err
+	}
+
+	return nil
+}
+
 // ExportReport exports a report in the specified format
 func (s *ReportingService) ExportReport(ctx context.Context, report *Report, format string) ([]byte, error) {
 	switch format {
@@ -3,9 +3,9 @@ package analytics
 import (
 	"context"
 
-	"github.com/Caqil/vyrall/internal/config"
 	"github.com/Caqil/vyrall/internal/database"
 	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
 )
 
 // Service provides analytics functionality
@@ -46,10 +46,15 @@ func NewService(db *database.Database, cache *database.RedisClient, log *logger.
 	return service
 }
 
+// Close stops background processing and flushes real-time counters
+func (s *Service) Close() {
+	s.RealTime.Close()
+}
+
 // TrackEvent records an analytics event
 func (s *Service) TrackEvent(ctx context.Context, event *Event) error {
 	// Record the event in the database
-	if err := s.db.InsertOne(ctx, "analytics_events", event); err != nil {
+	if _, err := s.db.InsertOne(ctx, "analytics_events", event); err != nil {
 		s.log.Error("Failed to record analytics event", "error", err)
 		return err
 	}
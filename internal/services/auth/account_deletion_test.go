@@ -0,0 +1,51 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+func TestAccountDeletionCancelledByLogin(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := registerUser(t, env, "leaving")
+	session := login(t, env, user)
+
+	if _, err := env.auth.RequestAccountDeletion(ctx, user.ID, "wrong-password"); errors.Code(err) != errors.CodeInvalidCredentials {
+		t.Fatalf("request with a wrong password = %v, want it refused", err)
+	}
+	scheduledAt, err := env.auth.RequestAccountDeletion(ctx, user.ID, testPassword)
+	if err != nil {
+		t.Fatalf("request deletion: %v", err)
+	}
+	if want := time.Now().Add(env.cfg.Auth.DeletionGracePeriod); scheduledAt.Before(want.Add(-time.Minute)) || scheduledAt.After(want) {
+		t.Fatalf("deletion scheduled at %v, want after the %v grace period", scheduledAt, env.cfg.Auth.DeletionGracePeriod)
+	}
+	if _, err := env.auth.RequestAccountDeletion(ctx, user.ID, testPassword); errors.Code(err) != errors.CodeInvalidOperation {
+		t.Fatalf("second request = %v, want it refused", err)
+	}
+
+	// Every session is signed out, so only a deliberate login cancels
+	if _, err := env.auth.RefreshToken(ctx, session.RefreshToken); err == nil {
+		t.Fatal("session was refreshed after the deletion request")
+	}
+	stored, err := env.repos.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if stored.DeletionScheduledAt == nil {
+		t.Fatal("deletion not scheduled")
+	}
+
+	login(t, env, user)
+	stored, err = env.repos.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if stored.DeletionScheduledAt != nil || stored.DeletionRequestedAt != nil {
+		t.Fatal("login left the deletion scheduled")
+	}
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// loginRiskDescriptions explain in an alert why a login was flagged
+var loginRiskDescriptions = map[string]string{
+	models.LoginRiskNewDevice:        "It came from a device you haven't signed in with before",
+	models.LoginRiskNewCountry:       "It came from a country you haven't signed in from before",
+	models.LoginRiskImpossibleTravel: "It came from too far away from your previous sign-in to have travelled there since",
+}
+
+// AlertsService tells users about security events on their account
+type AlertsService struct {
+	notifier     *notification.Service
+	emailService EmailService
+	userRepo     UserRepository
+	risk         *LoginRiskService
+	logger       *logger.Logger
+}
+
+// NewAlertsService creates a new security alerts service
+func NewAlertsService(notifier *notification.Service, emailService EmailService, userRepo UserRepository, risk *LoginRiskService, logger *logger.Logger) *AlertsService {
+	return &AlertsService{
+		notifier:     notifier,
+		emailService: emailService,
+		userRepo:     userRepo,
+		risk:         risk,
+		logger:       logger,
+	}
+}
+
+// Subscribe registers the security alerts with the event bus
+func (s *AlertsService) Subscribe(bus *eventbus.Bus) {
+	eventbus.On(bus, constants.SubscriberNotifications, s.handleSessionTokenReused)
+	eventbus.On(bus, constants.SubscriberNotifications, s.handleSessionFlagged)
+}
+
+// handleSessionTokenReused alerts a user that one of their sessions was signed
+// out because its refresh token was replayed
+func (s *AlertsService) handleSessionTokenReused(ctx context.Context, event eventbus.SessionTokenReused) error {
+	message := "We signed out one of your sessions because its sign-in token was used twice, which can mean it was stolen. If this wasn't you, change your password."
+	if event.Device != "" {
+		message = "We signed out your session on " + event.Device
+		if event.Location != "" && event.Location != "unknown" {
+			message += " (" + event.Location + ")"
+		}
+		message += " because its sign-in token was used twice, which can mean it was stolen. If this wasn't you, change your password."
+	}
+
+	notif := &models.Notification{
+		UserID:    event.UserID,
+		Type:      "security_alert",
+		Actor:     event.UserID,
+		Subject:   "session",
+		SubjectID: event.SessionID,
+		Message:   message,
+		ActionURL: "/settings/security/sessions",
+		Priority:  "high",
+		IsRead:    false,
+	}
+
+	// Returning the error retries the delivery; a lost alert is worse than a duplicate
+	if err := s.notifier.Send(ctx, notif); err != nil {
+		s.logger.Warn("Failed to send session reuse alert", "userId", event.UserID.Hex(), "error", err)
+		return errors.Wrap(err, "Failed to send security alert")
+	}
+	return nil
+}
+
+// handleSessionFlagged asks a user whether an unfamiliar login was them, by
+// notification and by email, with a link that signs the session out
+func (s *AlertsService) handleSessionFlagged(ctx context.Context, event eventbus.SessionFlagged) error {
+	user, err := s.userRepo.FindByID(ctx, event.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to find user for login alert", "userId", event.UserID.Hex(), "error", err)
+		return errors.Wrap(err, "Failed to find user")
+	}
+
+	device, location := event.Device, event.Location
+	if device == "" || device == "unknown" {
+		device = "an unrecognised device"
+	}
+	if location == "" || location == "unknown" || location == "local" {
+		location = event.IPAddress
+	}
+
+	reasons := make([]string, 0, len(event.Risks))
+	for _, risk := range event.Risks {
+		if description, ok := loginRiskDescriptions[risk]; ok {
+			reasons = append(reasons, description)
+		}
+	}
+	revokeURL := s.risk.RevokeLink(event.SessionID, event.LoggedInAt)
+
+	notif := &models.Notification{
+		UserID:    event.UserID,
+		Type:      "security_alert",
+		Actor:     event.UserID,
+		Subject:   "session",
+		SubjectID: event.SessionID,
+		Message:   "New sign-in on " + device + " (" + location + "). Was this you? If not, sign it out and change your password.",
+		ActionURL: revokeURL,
+		Priority:  "high",
+		IsRead:    false,
+	}
+
+	// Returning an error retries the delivery; a lost alert is worse than a duplicate
+	if err := s.notifier.Send(ctx, notif); err != nil {
+		s.logger.Warn("Failed to send login alert", "userId", event.UserID.Hex(), "error", err)
+		return errors.Wrap(err, "Failed to send security alert")
+	}
+
+	emailData := map[string]interface{}{
+		"Username":  user.Username,
+		"Device":    device,
+		"Location":  location,
+		"IPAddress": event.IPAddress,
+		"Time":      event.LoggedInAt.UTC().Format(time.RFC1123),
+		"Reasons":   reasons,
+		"RevokeURL": revokeURL,
+	}
+	if err := s.emailService.SendTemplatedEmail(user.Email, "Was this you? New sign-in to your account", "login_alert", emailData); err != nil {
+		s.logger.Warn("Failed to email login alert", "userId", event.UserID.Hex(), "error", err)
+		return errors.Wrap(err, "Failed to send security alert")
+	}
+	return nil
+}
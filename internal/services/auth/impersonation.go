@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Actions recorded in the admin audit log
+const (
+	AuditImpersonationStarted  = "impersonation_started"
+	AuditImpersonationElevated = "impersonation_elevated"
+	AuditImpersonationEnded    = "impersonation_ended"
+	AuditImpersonatedRequest   = "impersonated_request"
+)
+
+// maxImpersonationReasonLength bounds the reason staff give for an impersonation
+const maxImpersonationReasonLength = 500
+
+// ImpersonationService opens sessions in which a member of staff sees the
+// app as a user does. The sessions are short-lived, have no refresh token
+// and are read-only until explicitly elevated. Starting, elevating and
+// ending one, and every request made in one, is written to the admin audit
+// log; a session whose start cannot be recorded is not opened.
+type ImpersonationService struct {
+	sessionRepo SessionRepository
+	auditRepo   AdminAuditRepository
+	jwtService  *JWTService
+	session     *SessionService
+	config      *config.SessionConfig
+	logger      *logger.Logger
+	now         func() time.Time
+}
+
+// AdminAuditRepository handles admin audit log storage
+type AdminAuditRepository interface {
+	Create(ctx context.Context, entry *models.AdminAuditEntry) error
+}
+
+// NewImpersonationService creates a new impersonation service
+func NewImpersonationService(
+	sessionRepo SessionRepository,
+	auditRepo AdminAuditRepository,
+	jwtService *JWTService,
+	session *SessionService,
+	config *config.SessionConfig,
+	logger *logger.Logger,
+) *ImpersonationService {
+	return &ImpersonationService{
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+		jwtService:  jwtService,
+		session:     session,
+		config:      config,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// Start opens a read-only session as userID for impersonatorID, ending any
+// impersonation of the user the same member of staff still has open
+func (s *ImpersonationService) Start(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error) {
+	reason, err := impersonationReason(reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous, err := s.active(ctx, impersonatorID, userID); err == nil {
+		if err := s.end(ctx, previous, userAgent, ipAddress); err != nil {
+			return nil, err
+		}
+	} else if errors.Code(err) != errors.CodeNotFound {
+		return nil, err
+	}
+
+	sessionID := primitive.NewObjectID()
+	token, expiresAt, err := s.jwtService.GenerateImpersonationToken(userID, sessionID, impersonatorID, s.config.ImpersonationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	session, err := s.sessionRepo.Create(ctx, &models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Token:      token,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		Device:     s.session.DetectDevice(userAgent),
+		Location:   s.session.GetLocationFromIP(ipAddress),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+		IsActive:   true,
+		Impersonation: &models.Impersonation{
+			ImpersonatorID: impersonatorID,
+			Reason:         reason,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create impersonation session")
+	}
+
+	if err := s.audit(ctx, AuditImpersonationStarted, session, reason, "", "", 0, userAgent, ipAddress); err != nil {
+		if revokeErr := s.sessionRepo.Revoke(ctx, session.ID, RevokedReasonImpersonationEnded); revokeErr != nil {
+			s.logger.Error("Failed to revoke unaudited impersonation session", "error", revokeErr, "sessionId", session.ID.Hex())
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Elevate lets the open impersonation of userID by impersonatorID make
+// changes as the user. The elevation is audited before it takes effect.
+func (s *ImpersonationService) Elevate(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error) {
+	reason, err := impersonationReason(reason)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.active(ctx, impersonatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Impersonation.Write {
+		return nil, errors.New(errors.CodeInvalidOperation, "Impersonation can already make changes")
+	}
+
+	now := s.now()
+	session.Impersonation.Write = true
+	session.Impersonation.ElevatedAt = &now
+
+	if err := s.audit(ctx, AuditImpersonationElevated, session, reason, "", "", 0, userAgent, ipAddress); err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.ElevateImpersonation(ctx, session.ID, now); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New(errors.CodeInvalidOperation, "Impersonation can already make changes")
+		}
+		return nil, errors.Wrap(err, "Failed to elevate impersonation")
+	}
+
+	return session, nil
+}
+
+// End closes the open impersonation of userID by impersonatorID
+func (s *ImpersonationService) End(ctx context.Context, impersonatorID, userID primitive.ObjectID, userAgent, ipAddress string) error {
+	session, err := s.active(ctx, impersonatorID, userID)
+	if err != nil {
+		return err
+	}
+	return s.end(ctx, session, userAgent, ipAddress)
+}
+
+// Check confirms an impersonation token's session is still open and sets
+// whether it may make changes. Sessions are looked up on every request, so
+// ending or elevating one takes effect at once.
+func (s *ImpersonationService) Check(ctx context.Context, token *AccessToken) error {
+	sessionID, err := primitive.ObjectIDFromHex(token.SessionID)
+	if err != nil {
+		return errors.New(errors.CodeInvalidToken, "Invalid token")
+	}
+
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return errors.New(errors.CodeInvalidToken, "Impersonation has ended")
+	}
+	if !session.IsActive || !s.now().Before(session.ExpiresAt) || session.Impersonation == nil ||
+		session.UserID != token.UserID || session.Impersonation.ImpersonatorID != token.ImpersonatorID {
+		return errors.New(errors.CodeInvalidToken, "Impersonation has ended")
+	}
+
+	token.ImpersonationWrite = session.Impersonation.Write
+	return nil
+}
+
+// RecordRequest writes a request made under impersonation to the audit log.
+// The request has already been served, so a failure is only logged.
+func (s *ImpersonationService) RecordRequest(ctx context.Context, token *AccessToken, method, path string, statusCode int, userAgent, ipAddress string) {
+	sessionID, _ := primitive.ObjectIDFromHex(token.SessionID)
+	session := &models.Session{
+		ID:            sessionID,
+		UserID:        token.UserID,
+		Impersonation: &models.Impersonation{ImpersonatorID: token.ImpersonatorID, Write: token.ImpersonationWrite},
+	}
+
+	if err := s.audit(ctx, AuditImpersonatedRequest, session, "", method, path, statusCode, userAgent, ipAddress); err != nil {
+		s.logger.Error("Failed to audit impersonated request", "error", err,
+			"userId", token.UserID.Hex(), "impersonatorId", token.ImpersonatorID.Hex(), "method", method, "path", path)
+	}
+}
+
+// active returns the open impersonation of userID by impersonatorID
+func (s *ImpersonationService) active(ctx context.Context, impersonatorID, userID primitive.ObjectID) (*models.Session, error) {
+	sessions, err := s.sessionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find sessions")
+	}
+
+	now := s.now()
+	for i := range sessions {
+		session := &sessions[i]
+		if session.Impersonation != nil && session.Impersonation.ImpersonatorID == impersonatorID &&
+			session.IsActive && now.Before(session.ExpiresAt) {
+			return session, nil
+		}
+	}
+	return nil, errors.New(errors.CodeNotFound, "No impersonation in progress")
+}
+
+func (s *ImpersonationService) end(ctx context.Context, session *models.Session, userAgent, ipAddress string) error {
+	if err := s.sessionRepo.Revoke(ctx, session.ID, RevokedReasonImpersonationEnded); err != nil {
+		return errors.Wrap(err, "Failed to end impersonation")
+	}
+	return s.audit(ctx, AuditImpersonationEnded, session, "", "", "", 0, userAgent, ipAddress)
+}
+
+func (s *ImpersonationService) audit(ctx context.Context, action string, session *models.Session, reason, method, path string, statusCode int, userAgent, ipAddress string) error {
+	err := s.auditRepo.Create(ctx, &models.AdminAuditEntry{
+		Action:     action,
+		ActorID:    session.Impersonation.ImpersonatorID,
+		UserID:     session.UserID,
+		SessionID:  session.ID,
+		Reason:     reason,
+		Write:      session.Impersonation.Write,
+		Method:     method,
+		Path:       path,
+		StatusCode: statusCode,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  s.now(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to record impersonation")
+	}
+	return nil
+}
+
+// impersonationReason returns the trimmed reason staff gave, which is required
+func impersonationReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxImpersonationReasonLength {
+		return "", errors.New(errors.CodeInvalidArgument, "A reason of at most 500 characters is required")
+	}
+	return reason, nil
+}
@@ -0,0 +1,192 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+const testImpersonationReason = "Investigating a report of missing posts"
+
+// setStatus changes the status of a user's account
+func setStatus(t *testing.T, env *testEnv, user *models.User, status string) {
+	t.Helper()
+	stored, err := env.repos.Users.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	stored.Status = status
+	if err := env.repos.Users.Update(context.Background(), stored); err != nil {
+		t.Fatalf("set status %s: %v", status, err)
+	}
+}
+
+// impersonate has staff open an impersonation of user
+func impersonate(t *testing.T, env *testEnv, staff, user *models.User) *models.Session {
+	t.Helper()
+	session, err := env.auth.StartImpersonation(context.Background(), staff.ID, user.ID, testImpersonationReason, testUserAgent, testIP)
+	if err != nil {
+		t.Fatalf("start impersonation: %v", err)
+	}
+	return session
+}
+
+// audited returns how many admin audit entries for user record action
+func audited(t *testing.T, env *testEnv, user *models.User, action string) int {
+	t.Helper()
+	return env.count(t, constants.CollectionAdminAudit, bson.M{"user_id": user.ID, "action": action})
+}
+
+func TestImpersonationStartsReadOnly(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	staff := registerUser(t, env, "support")
+	user := registerUser(t, env, "member")
+
+	session := impersonate(t, env, staff, user)
+	if session.RefreshToken != "" {
+		t.Fatal("impersonation session has a refresh token")
+	}
+	token, err := env.auth.Authenticate(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if token.UserID != user.ID || token.ImpersonatorID != staff.ID {
+		t.Fatalf("token for %s by %s, want %s by %s", token.UserID.Hex(), token.ImpersonatorID.Hex(), user.ID.Hex(), staff.ID.Hex())
+	}
+	if token.ImpersonationWrite {
+		t.Fatal("new impersonation may make changes")
+	}
+
+	if n := env.count(t, constants.CollectionAdminAudit, bson.M{
+		"action":   auth.AuditImpersonationStarted,
+		"actor_id": staff.ID,
+		"user_id":  user.ID,
+		"reason":   testImpersonationReason,
+		"write":    false,
+	}); n != 1 {
+		t.Fatalf("%d start audit entries, want 1", n)
+	}
+
+	env.auth.RecordImpersonatedRequest(ctx, token, "GET", "/api/v1/posts", 200, testUserAgent, testIP)
+	if n := env.count(t, constants.CollectionAdminAudit, bson.M{
+		"action":      auth.AuditImpersonatedRequest,
+		"actor_id":    staff.ID,
+		"session_id":  session.ID,
+		"path":        "/api/v1/posts",
+		"status_code": 200,
+	}); n != 1 {
+		t.Fatalf("%d request audit entries, want 1", n)
+	}
+}
+
+func TestImpersonationElevation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	staff := registerUser(t, env, "support")
+	user := registerUser(t, env, "member")
+	session := impersonate(t, env, staff, user)
+
+	if _, err := env.auth.ElevateImpersonation(ctx, staff.ID, user.ID, " ", testUserAgent, testIP); errors.Code(err) != errors.CodeInvalidArgument {
+		t.Fatalf("elevate without a reason = %v, want it refused", err)
+	}
+	if _, err := env.auth.ElevateImpersonation(ctx, staff.ID, user.ID, "Restoring a deleted post", testUserAgent, testIP); err != nil {
+		t.Fatalf("elevate: %v", err)
+	}
+
+	// The open session's token may now make changes
+	token, err := env.auth.Authenticate(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if !token.ImpersonationWrite {
+		t.Fatal("elevated impersonation may not make changes")
+	}
+	if n := audited(t, env, user, auth.AuditImpersonationElevated); n != 1 {
+		t.Fatalf("%d elevation audit entries, want 1", n)
+	}
+
+	if _, err := env.auth.ElevateImpersonation(ctx, staff.ID, user.ID, "Restoring a deleted post", testUserAgent, testIP); errors.Code(err) != errors.CodeInvalidOperation {
+		t.Fatalf("second elevate = %v, want it refused", err)
+	}
+	if n := audited(t, env, user, auth.AuditImpersonationElevated); n != 1 {
+		t.Fatalf("%d elevation audit entries after a refused elevation, want 1", n)
+	}
+
+	// Other staff have nothing to elevate
+	other := registerUser(t, env, "othersupport")
+	if _, err := env.auth.ElevateImpersonation(ctx, other.ID, user.ID, "Restoring a deleted post", testUserAgent, testIP); errors.Code(err) != errors.CodeNotFound {
+		t.Fatalf("elevate by other staff = %v, want no impersonation found", err)
+	}
+}
+
+func TestImpersonationEnds(t *testing.T) {
+	tests := map[string]func(t *testing.T, env *testEnv, staff, user *models.User){
+		"ended by staff": func(t *testing.T, env *testEnv, staff, user *models.User) {
+			if err := env.auth.EndImpersonation(context.Background(), staff.ID, user.ID, testUserAgent, testIP); err != nil {
+				t.Fatalf("end impersonation: %v", err)
+			}
+			if n := audited(t, env, user, auth.AuditImpersonationEnded); n != 1 {
+				t.Fatalf("%d end audit entries, want 1", n)
+			}
+		},
+		"restarted": func(t *testing.T, env *testEnv, staff, user *models.User) {
+			impersonate(t, env, staff, user)
+		},
+		"staff member suspended": func(t *testing.T, env *testEnv, staff, _ *models.User) {
+			setStatus(t, env, staff, "suspended")
+		},
+	}
+	for name, end := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			staff := registerUser(t, env, "support")
+			user := registerUser(t, env, "member")
+			session := impersonate(t, env, staff, user)
+
+			end(t, env, staff, user)
+			if _, err := env.auth.Authenticate(context.Background(), session.Token); err == nil {
+				t.Fatal("impersonation token still authenticates")
+			}
+		})
+	}
+}
+
+func TestImpersonationRefused(t *testing.T) {
+	tests := map[string]struct {
+		reason string
+		status string
+		code   string
+	}{
+		"no reason":          {reason: "", code: errors.CodeInvalidArgument},
+		"blank reason":       {reason: "   ", code: errors.CodeInvalidArgument},
+		"overlong reason":    {reason: strings.Repeat("a", 501), code: errors.CodeInvalidArgument},
+		"suspended user":     {reason: testImpersonationReason, status: "suspended", code: errors.CodeInvalidOperation},
+		"user being deleted": {reason: testImpersonationReason, status: "deleted", code: errors.CodeInvalidOperation},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			staff := registerUser(t, env, "support")
+			user := registerUser(t, env, "member")
+			if tt.status != "" {
+				setStatus(t, env, user, tt.status)
+			}
+
+			_, err := env.auth.StartImpersonation(context.Background(), staff.ID, user.ID, tt.reason, testUserAgent, testIP)
+			if errors.Code(err) != tt.code {
+				t.Fatalf("start impersonation = %v, want code %s", err, tt.code)
+			}
+			if n := audited(t, env, user, auth.AuditImpersonationStarted); n != 0 {
+				t.Fatalf("%d start audit entries for a refused impersonation", n)
+			}
+		})
+	}
+}
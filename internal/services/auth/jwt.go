@@ -1,54 +1,138 @@
 package auth
 
 import (
-	"fmt"
+	"context"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
-// JWTService handles JWT token generation and validation
+// JWTService handles JWT token generation and validation. Tokens are signed
+// with the keyring's active key; anything holding the published keys can
+// verify them without being able to mint new ones.
 type JWTService struct {
-	config *config.JWTConfig
+	config   *config.JWTConfig
+	keys     *keyring.Keyring
+	verifier *keyring.Verifier
 }
 
-// JWTClaims represents the claims in a JWT token
+// JWTClaims represents the claims in a JWT token. Tokens issued to
+// third-party apps carry the app's client ID and scopes, and name the grant
+// they were issued under in jti. Tokens of sessions staff open as a user
+// name the member of staff as the actor.
 type JWTClaims struct {
-	UserID string `json:"user_id"`
+	UserID    string       `json:"user_id"`
+	SessionID string       `json:"sid,omitempty"`
+	ClientID  string       `json:"client_id,omitempty"`
+	Scope     string       `json:"scope,omitempty"` // Space separated, as in RFC 8693
+	Actor     *ActorClaims `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ActorClaims identifies who acts for the subject of a token, as in RFC 8693
+type ActorClaims struct {
+	Subject string `json:"sub"`
+}
+
+// AccessToken is what a validated access token identifies
+type AccessToken struct {
+	UserID    primitive.ObjectID
+	SessionID string // The session the token was issued for
+	ExpiresAt time.Time
+
+	// Set for tokens issued to third-party apps
+	ClientID string
+	Scopes   []string // Also set for personal access tokens
+	GrantID  string
+
+	// Set for personal access tokens
+	PersonalTokenID string
+
+	// Set for sessions staff opened as the user. Write is read from the
+	// session, as it can be elevated after the token is issued.
+	ImpersonatorID     primitive.ObjectID
+	ImpersonationWrite bool
+}
+
+// IsThirdParty reports whether the token was issued to a third-party app
+func (t *AccessToken) IsThirdParty() bool {
+	return t.ClientID != ""
+}
+
+// IsPersonal reports whether the token is a personal access token
+func (t *AccessToken) IsPersonal() bool {
+	return t.PersonalTokenID != ""
+}
+
+// IsImpersonation reports whether the token is for a session staff opened
+// as the user
+func (t *AccessToken) IsImpersonation() bool {
+	return !t.ImpersonatorID.IsZero()
+}
+
+// IsScoped reports whether the token may only be used within its scopes.
+// Tokens of third-party apps and personal access tokens are; session tokens
+// may be used anywhere.
+func (t *AccessToken) IsScoped() bool {
+	return t.IsThirdParty() || t.IsPersonal()
+}
+
+// HasScope reports whether a scoped token was granted scope
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IDTokenClaims represents the claims in an OpenID Connect ID token
+type IDTokenClaims struct {
+	Nonce             string `json:"nonce,omitempty"`
+	AuthTime          int64  `json:"auth_time,omitempty"`
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // NewJWTService creates a new JWT service
-func NewJWTService(config *config.JWTConfig) *JWTService {
+func NewJWTService(config *config.JWTConfig, keys *keyring.Keyring) *JWTService {
 	return &JWTService{
-		config: config,
+		config:   config,
+		keys:     keys,
+		verifier: keyring.NewVerifier(keys, config.Issuer, config.Audience),
 	}
 }
 
-// GenerateToken creates a new JWT token for a user
-func (s *JWTService) GenerateToken(userID primitive.ObjectID) (string, error) {
+// GenerateToken creates a new JWT token for a user's session
+func (s *JWTService) GenerateToken(userID, sessionID primitive.ObjectID) (string, error) {
 	// Set claims
 	expirationTime := time.Now().Add(time.Duration(s.config.ExpirationHours) * time.Hour)
 	claims := JWTClaims{
-		UserID: userID.Hex(),
+		UserID:    userID.Hex(),
+		SessionID: sessionID.Hex(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    s.config.Issuer,
+			Audience:  jwt.ClaimStrings{s.config.Audience},
 			Subject:   userID.Hex(),
 		},
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign token
-	signedToken, err := token.SignedString([]byte(s.config.Secret))
+	// Sign token with the active key
+	signedToken, err := s.keys.Sign(claims)
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to sign JWT token")
 	}
@@ -56,30 +140,99 @@ func (s *JWTService) GenerateToken(userID primitive.ObjectID) (string, error) {
 	return signedToken, nil
 }
 
-// ValidateToken validates a JWT token and returns the user ID
-func (s *JWTService) ValidateToken(tokenString string) (*primitive.ObjectID, error) {
-	// Parse token
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.Secret), nil
-	})
+// GenerateImpersonationToken creates a token for a session impersonatorID
+// opened as userID, valid for ttl
+func (s *JWTService) GenerateImpersonationToken(userID, sessionID, impersonatorID primitive.ObjectID, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(ttl)
+	claims := JWTClaims{
+		UserID:    userID.Hex(),
+		SessionID: sessionID.Hex(),
+		Actor:     &ActorClaims{Subject: impersonatorID.Hex()},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Audience:  jwt.ClaimStrings{s.config.Audience},
+			Subject:   userID.Hex(),
+		},
+	}
+
+	signedToken, err := s.keys.Sign(claims)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "Failed to sign JWT token")
+	}
+
+	return signedToken, expirationTime, nil
+}
+
+// GenerateAppToken creates an access token for a third-party app, valid for ttl
+func (s *JWTService) GenerateAppToken(userID, grantID primitive.ObjectID, clientID string, scopes []string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(ttl)
+	claims := JWTClaims{
+		UserID:   userID.Hex(),
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Audience:  jwt.ClaimStrings{s.config.Audience},
+			Subject:   userID.Hex(),
+			ID:        grantID.Hex(),
+		},
+	}
 
+	signedToken, err := s.keys.Sign(claims)
 	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "Failed to sign JWT token")
+	}
+
+	return signedToken, expirationTime, nil
+}
+
+// GenerateIDToken signs an OpenID Connect ID token. Its audience is the app,
+// so it is never accepted as an access token.
+func (s *JWTService) GenerateIDToken(claims *IDTokenClaims) (string, error) {
+	signedToken, err := s.keys.Sign(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to sign ID token")
+	}
+	return signedToken, nil
+}
+
+// ValidateToken validates a JWT token and returns the user and session it
+// belongs to. The key is selected by the token's kid, and the issuer and
+// audience must match.
+func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*AccessToken, error) {
+	claims := &JWTClaims{}
+	if err := s.verifier.Verify(ctx, tokenString, claims); err != nil {
 		return nil, errors.New(errors.CodeInvalidToken, "Invalid token: "+err.Error())
 	}
 
-	// Validate claims
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		// Convert user ID string to ObjectID
-		userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	// Convert user ID string to ObjectID
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid user ID in token")
+	}
+	accessToken := &AccessToken{UserID: userID, SessionID: claims.SessionID}
+	if claims.ExpiresAt != nil {
+		accessToken.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.ClientID != "" {
+		accessToken.ClientID = claims.ClientID
+		accessToken.Scopes = strings.Fields(claims.Scope)
+		accessToken.GrantID = claims.ID
+	}
+	if claims.Actor != nil {
+		impersonatorID, err := primitive.ObjectIDFromHex(claims.Actor.Subject)
 		if err != nil {
-			return nil, errors.New(errors.CodeInvalidToken, "Invalid user ID in token")
+			return nil, errors.New(errors.CodeInvalidToken, "Invalid actor in token")
 		}
-		return &userID, nil
+		accessToken.ImpersonatorID = impersonatorID
 	}
-
-	return nil, errors.New(errors.CodeInvalidToken, "Invalid token claims")
+	return accessToken, nil
 }
@@ -0,0 +1,361 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// LoginThrottledError is returned when an account is locked, or must wait
+// before its next login attempt
+type LoginThrottledError struct {
+	Wait   time.Duration
+	Locked bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "Account is temporarily locked after too many failed logins"
+	}
+	return "Too many failed logins, try again shortly"
+}
+
+// RetryAfter reports how long the client should wait before trying again
+func (e *LoginThrottledError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
+// LoginRisk is the assessment of a login attempt before the password is checked
+type LoginRisk struct {
+	// High means the login must be confirmed with an emailed code
+	High   bool
+	Reason string
+}
+
+// LoginGuardService protects password logins. Failures slow an account down
+// and eventually lock it; failures from one IP address or subnet, or against
+// many accounts from one IP address, mark logins from there as risky.
+type LoginGuardService struct {
+	attemptRepo  LoginAttemptRepository
+	emailService EmailService
+	tx           interfaces.Transactor
+	events       eventbus.Publisher
+	config       *config.LoginGuardConfig
+	logger       *logger.Logger
+	now          func() time.Time
+}
+
+// LoginAttemptRepository handles failed login and lockout storage
+type LoginAttemptRepository interface {
+	RecordFailure(ctx context.Context, failure *models.LoginFailure) error
+	CountByEmail(ctx context.Context, email string, since time.Time) (int, error)
+	CountByIP(ctx context.Context, ipAddress string, since time.Time) (int, error)
+	CountBySubnet(ctx context.Context, subnet string, since time.Time) (int, error)
+	CountEmailsByIP(ctx context.Context, ipAddress string, since time.Time) (int, error)
+	LastFailure(ctx context.Context, email string) (*models.LoginFailure, error)
+	ClearFailures(ctx context.Context, email string) error
+	SaveLockout(ctx context.Context, lockout *models.LoginLockout) error
+	FindLockout(ctx context.Context, email string, now time.Time) (*models.LoginLockout, error)
+	FindLockoutByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.LoginLockout, error)
+	DeleteLockout(ctx context.Context, email string) error
+}
+
+// NewLoginGuardService creates a new login guard service
+func NewLoginGuardService(
+	attemptRepo LoginAttemptRepository,
+	emailService EmailService,
+	tx interfaces.Transactor,
+	events eventbus.Publisher,
+	config *config.LoginGuardConfig,
+	logger *logger.Logger,
+) *LoginGuardService {
+	return &LoginGuardService{
+		attemptRepo:  attemptRepo,
+		emailService: emailService,
+		tx:           tx,
+		events:       events,
+		config:       config,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Check is called before a password is verified. It returns a
+// *LoginThrottledError if the account is locked or still inside its backoff
+// delay, and otherwise the risk of the attempt.
+func (s *LoginGuardService) Check(ctx context.Context, email, ipAddress string) (*LoginRisk, error) {
+	email = normalizeEmail(email)
+	now := s.now()
+
+	if err := s.checkAccount(ctx, email, now); err != nil {
+		return nil, err
+	}
+
+	since := now.Add(-s.config.FailureWindow)
+	risk := &LoginRisk{}
+
+	ipFailures, err := s.attemptRepo.CountByIP(ctx, ipAddress, since)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to count login failures")
+	}
+	if ipFailures >= s.config.IPFailureThreshold {
+		risk.High, risk.Reason = true, "ip_failures"
+	}
+
+	if subnet := subnetOf(ipAddress); !risk.High && subnet != "" {
+		subnetFailures, err := s.attemptRepo.CountBySubnet(ctx, subnet, since)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to count login failures")
+		}
+		if subnetFailures >= s.config.SubnetFailureThreshold {
+			risk.High, risk.Reason = true, "subnet_failures"
+		}
+	}
+
+	accounts, err := s.attemptRepo.CountEmailsByIP(ctx, ipAddress, since)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to count login failures")
+	}
+	if accounts >= s.config.IPAccountThreshold {
+		s.logger.Warn("Possible credential stuffing", "ip", ipAddress, "accounts", accounts)
+		risk.High, risk.Reason = true, "credential_stuffing"
+	}
+
+	return risk, nil
+}
+
+// CheckAccount returns a *LoginThrottledError if the account may not attempt
+// a login right now
+func (s *LoginGuardService) CheckAccount(ctx context.Context, email string) error {
+	return s.checkAccount(ctx, normalizeEmail(email), s.now())
+}
+
+func (s *LoginGuardService) checkAccount(ctx context.Context, email string, now time.Time) error {
+	lockout, err := s.attemptRepo.FindLockout(ctx, email, now)
+	if err == nil {
+		return &LoginThrottledError{Wait: lockout.LockedUntil.Sub(now), Locked: true}
+	}
+	if !stderrors.Is(err, mongo.ErrNoDocuments) {
+		return errors.Wrap(err, "Failed to check login lockout")
+	}
+
+	failures, err := s.attemptRepo.CountByEmail(ctx, email, now.Add(-s.config.FailureWindow))
+	if err != nil {
+		return errors.Wrap(err, "Failed to count login failures")
+	}
+	if failures < s.config.DelayAfter {
+		return nil
+	}
+
+	last, err := s.attemptRepo.LastFailure(ctx, email)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find last login failure")
+	}
+	if wait := last.CreatedAt.Add(s.delay(failures)).Sub(now); wait > 0 {
+		return &LoginThrottledError{Wait: wait}
+	}
+	return nil
+}
+
+// RecordFailure records a failed login and locks the account once it reaches
+// the lockout threshold. userID is nil when no account has the email; such
+// attempts are counted and locked the same way, so a lockout reveals nothing.
+func (s *LoginGuardService) RecordFailure(ctx context.Context, email string, userID *primitive.ObjectID, ipAddress string) error {
+	email = normalizeEmail(email)
+	now := s.now()
+
+	failure := &models.LoginFailure{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		Subnet:    subnetOf(ipAddress),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.FailureWindow),
+	}
+	if err := s.attemptRepo.RecordFailure(ctx, failure); err != nil {
+		return errors.Wrap(err, "Failed to record login failure")
+	}
+
+	failures, err := s.attemptRepo.CountByEmail(ctx, email, now.Add(-s.config.FailureWindow))
+	if err != nil {
+		return errors.Wrap(err, "Failed to count login failures")
+	}
+	if failures < s.config.LockoutThreshold {
+		return nil
+	}
+
+	lockout := &models.LoginLockout{
+		Email:         email,
+		UserID:        userID,
+		Failures:      failures,
+		LastIPAddress: ipAddress,
+		LockedAt:      now,
+		LockedUntil:   now.Add(s.config.LockoutDuration),
+	}
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.attemptRepo.SaveLockout(ctx, lockout); err != nil {
+			return err
+		}
+		if userID == nil {
+			return nil
+		}
+		return s.events.Publish(ctx, eventbus.AccountLockedOut{
+			UserID:      *userID,
+			Email:       email,
+			Failures:    failures,
+			IPAddress:   ipAddress,
+			LockedUntil: lockout.LockedUntil,
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to lock account")
+	}
+
+	s.logger.Warn("Account locked after failed logins", "email", email, "failures", failures, "ip", ipAddress)
+	return nil
+}
+
+// RecordSuccess stops earlier failures counting against the account
+func (s *LoginGuardService) RecordSuccess(ctx context.Context, email string) error {
+	if err := s.attemptRepo.ClearFailures(ctx, normalizeEmail(email)); err != nil {
+		return errors.Wrap(err, "Failed to clear login failures")
+	}
+	return nil
+}
+
+// FindLockout returns the lockout in force on a user's account
+func (s *LoginGuardService) FindLockout(ctx context.Context, userID primitive.ObjectID) (*models.LoginLockout, error) {
+	lockout, err := s.attemptRepo.FindLockoutByUserID(ctx, userID, s.now())
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New(errors.CodeNotFound, "Account is not locked")
+		}
+		return nil, errors.Wrap(err, "Failed to find login lockout")
+	}
+	return lockout, nil
+}
+
+// Unlock lifts the lockout on an account and clears its failures, so the
+// user starts again without a backoff delay
+func (s *LoginGuardService) Unlock(ctx context.Context, user *models.User, adminID primitive.ObjectID) error {
+	email := normalizeEmail(user.Email)
+
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.attemptRepo.DeleteLockout(ctx, email); err != nil {
+			if stderrors.Is(err, mongo.ErrNoDocuments) {
+				return errors.New(errors.CodeNotFound, "Account is not locked")
+			}
+			return err
+		}
+		if err := s.attemptRepo.ClearFailures(ctx, email); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, eventbus.AccountUnlocked{
+			UserID:  user.ID,
+			AdminID: adminID,
+			Email:   email,
+		})
+	})
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			return err
+		}
+		return errors.Wrap(err, "Failed to unlock account")
+	}
+
+	s.logger.Info("Account unlocked", "userId", user.ID.Hex(), "adminId", adminID.Hex())
+	return nil
+}
+
+// SendStepUpCode emails a 6-digit code confirming a risky login and returns
+// the hash to store with the pending login
+func (s *LoginGuardService) SendStepUpCode(user *models.User, ipAddress string) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to generate login code")
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	emailData := map[string]interface{}{
+		"Username":  user.Username,
+		"Code":      code,
+		"IPAddress": ipAddress,
+		"ExpiresIn": int(s.config.StepUpCodeTTL.Minutes()),
+	}
+	if err := s.emailService.SendTemplatedEmail(user.Email, "Your Login Code", "login_code", emailData); err != nil {
+		return "", errors.Wrap(err, "Failed to send login code")
+	}
+
+	return hashStepUpCode(code), nil
+}
+
+// VerifyStepUpCode checks an emailed code against a pending login
+func (s *LoginGuardService) VerifyStepUpCode(session *models.Session, code string) error {
+	if session.PendingFactor != models.PendingFactorEmailCode || session.StepUpCodeHash == "" {
+		return errors.New(errors.CodeInvalidOperation, "Login is not waiting for an email code")
+	}
+	if s.now().After(session.CreatedAt.Add(s.config.StepUpCodeTTL)) {
+		return errors.New(errors.CodeInvalidToken, "Code has expired, sign in again")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashStepUpCode(strings.TrimSpace(code))), []byte(session.StepUpCodeHash)) != 1 {
+		return errors.New(errors.CodeInvalidCredentials, "Invalid verification code")
+	}
+	return nil
+}
+
+// delay is the backoff after a number of failures: BaseDelay at DelayAfter,
+// doubling with each further failure up to MaxDelay
+func (s *LoginGuardService) delay(failures int) time.Duration {
+	delay := s.config.BaseDelay
+	for i := s.config.DelayAfter; i < failures && delay < s.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.MaxDelay {
+		delay = s.config.MaxDelay
+	}
+	return delay
+}
+
+func hashStepUpCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail makes failures against "Alice@x.com " and "alice@x.com" count together
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// subnetOf returns the /24 of an IPv4 address or the /64 of an IPv6 address,
+// or "" when ipAddress is not an IP address
+func subnetOf(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
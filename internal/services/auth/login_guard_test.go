@@ -0,0 +1,213 @@
+package auth_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// failLogin signs in with a wrong password and fails the test unless the
+// password is what was refused
+func failLogin(t *testing.T, env *testEnv, email, ipAddress string) {
+	t.Helper()
+	_, err := env.auth.Login(context.Background(), email, "wrong-password", testUserAgent, ipAddress)
+	var throttled *auth.LoginThrottledError
+	if err == nil || stderrors.As(err, &throttled) {
+		t.Fatalf("login with a wrong password = %v, want invalid credentials", err)
+	}
+}
+
+// throttled returns the throttling error a login with the right password
+// gets, or nil when the login goes through
+func throttled(t *testing.T, env *testEnv, user *models.User) *auth.LoginThrottledError {
+	t.Helper()
+	_, err := env.auth.Login(context.Background(), user.Email, testPassword, testUserAgent, testIP)
+	if err == nil {
+		return nil
+	}
+	var throttled *auth.LoginThrottledError
+	if !stderrors.As(err, &throttled) {
+		t.Fatalf("login = %v, want it throttled or accepted", err)
+	}
+	return throttled
+}
+
+func TestLoginBacksOffAfterFailures(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.LoginGuard.DelayAfter = 2
+	env.cfg.LoginGuard.BaseDelay = time.Hour
+	env.cfg.LoginGuard.MaxDelay = 2 * time.Hour
+	user := registerUser(t, env, "guessed")
+
+	failLogin(t, env, user.Email, testIP)
+	if err := throttled(t, env, user); err != nil {
+		t.Fatalf("login after one failure = %v, want it accepted", err)
+	}
+
+	// The correct password cleared the failures, so two more are needed
+	failLogin(t, env, user.Email, testIP)
+	failLogin(t, env, user.Email, testIP)
+	err := throttled(t, env, user)
+	if err == nil || err.Locked || err.RetryAfter() <= 0 || err.RetryAfter() > time.Hour {
+		t.Fatalf("login after two failures = %+v, want a backoff of up to an hour", err)
+	}
+}
+
+func TestLoginLocksAccount(t *testing.T) {
+	for name, username := range map[string]string{
+		"existing account": "locked",
+		"unknown email":    "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cfg.LoginGuard.DelayAfter = 10
+			env.cfg.LoginGuard.LockoutThreshold = 3
+
+			email := "nobody@example.com"
+			var user *models.User
+			if username != "" {
+				user = registerUser(t, env, username)
+				email = user.Email
+			}
+			for i := 0; i < 3; i++ {
+				failLogin(t, env, email, testIP)
+			}
+
+			// Unknown emails lock the same way, so a lockout reveals nothing
+			if got := env.count(t, constants.CollectionLoginLockouts, bson.M{"email": email}); got != 1 {
+				t.Fatalf("%d lockouts, want 1", got)
+			}
+			if user == nil {
+				return
+			}
+
+			if err := throttled(t, env, user); err == nil || !err.Locked {
+				t.Fatalf("login with the right password = %+v, want the account locked", err)
+			}
+			lockout, err := env.auth.GetLoginLockout(context.Background(), user.ID)
+			if err != nil {
+				t.Fatalf("get lockout: %v", err)
+			}
+			if lockout.Failures != 3 || lockout.LastIPAddress != testIP {
+				t.Fatalf("lockout = %+v, want 3 failures from %s", lockout, testIP)
+			}
+			if got := env.count(t, constants.CollectionOutbox, bson.M{"type": constants.EventAccountLockedOut}); got != 1 {
+				t.Fatalf("%d lockout events, want 1", got)
+			}
+		})
+	}
+}
+
+func TestLoginFailuresCountUntilSecondFactor(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user, authenticator := registerPasskeyUser(t, env, "twofactor")
+	enableTwoFactor(t, env, user)
+
+	failLogin(t, env, user.Email, testIP)
+	failLogin(t, env, user.Email, testIP)
+
+	// The password alone does not clear the failures
+	pending, err := env.auth.Login(ctx, user.Email, testPassword, testUserAgent, testIP)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if got := env.count(t, constants.CollectionLoginFailures, bson.M{"email": user.Email}); got != 2 {
+		t.Fatalf("%d failures after the password, want 2", got)
+	}
+
+	options, err := env.auth.BeginPasskeyTwoFactor(ctx, pending.Token)
+	if err != nil {
+		t.Fatalf("begin second factor: %v", err)
+	}
+	response, err := authenticator.Login(options)
+	if err != nil {
+		t.Fatalf("authenticator login: %v", err)
+	}
+	if _, err := env.auth.FinishPasskeyTwoFactor(ctx, pending.Token, response); err != nil {
+		t.Fatalf("finish second factor: %v", err)
+	}
+	if got := env.count(t, constants.CollectionLoginFailures, bson.M{"email": user.Email}); got != 0 {
+		t.Fatalf("%d failures after the second factor, want 0", got)
+	}
+}
+
+func TestRiskyLoginMustBeConfirmedByEmail(t *testing.T) {
+	tests := map[string]struct {
+		configure func(env *testEnv)
+		failures  map[string]string // email to the IP address it fails from
+		stepUp    bool
+	}{
+		"failures from the IP address": {
+			configure: func(env *testEnv) { env.cfg.LoginGuard.IPFailureThreshold = 2 },
+			failures:  map[string]string{"a@example.com": testIP, "b@example.com": testIP},
+			stepUp:    true,
+		},
+		"failures from the subnet": {
+			configure: func(env *testEnv) { env.cfg.LoginGuard.SubnetFailureThreshold = 2 },
+			failures:  map[string]string{"a@example.com": "192.0.2.11", "b@example.com": "192.0.2.12"},
+			stepUp:    true,
+		},
+		"one IP address failing against many accounts": {
+			configure: func(env *testEnv) { env.cfg.LoginGuard.IPAccountThreshold = 2 },
+			failures:  map[string]string{"a@example.com": testIP, "b@example.com": testIP},
+			stepUp:    true,
+		},
+		"failures from elsewhere": {
+			configure: func(env *testEnv) {
+				env.cfg.LoginGuard.IPFailureThreshold = 2
+				env.cfg.LoginGuard.SubnetFailureThreshold = 2
+				env.cfg.LoginGuard.IPAccountThreshold = 2
+			},
+			failures: map[string]string{"a@example.com": "198.51.100.7", "b@example.com": "198.51.100.8"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			env.cfg.LoginGuard.IPFailureThreshold = 100
+			env.cfg.LoginGuard.SubnetFailureThreshold = 100
+			env.cfg.LoginGuard.IPAccountThreshold = 100
+			tt.configure(env)
+			user := registerUser(t, env, "risky")
+
+			for email, ipAddress := range tt.failures {
+				failLogin(t, env, email, ipAddress)
+			}
+
+			session, err := env.auth.Login(ctx, user.Email, testPassword, testUserAgent, testIP)
+			if err != nil {
+				t.Fatalf("login: %v", err)
+			}
+			if !tt.stepUp {
+				if session.PendingFactor != "" {
+					t.Fatalf("pending factor = %q, want a full session", session.PendingFactor)
+				}
+				return
+			}
+			if session.PendingFactor != models.PendingFactorEmailCode {
+				t.Fatalf("pending factor = %q, want %q", session.PendingFactor, models.PendingFactorEmailCode)
+			}
+
+			if _, err := env.auth.VerifyLoginCode(ctx, session.Token, "not-the-code"); err == nil {
+				t.Fatal("wrong login code was accepted")
+			}
+			code, _ := env.mail.Last(t, user.Email).Data["Code"].(string)
+			confirmed, err := env.auth.VerifyLoginCode(ctx, session.Token, code)
+			if err != nil {
+				t.Fatalf("verify login code: %v", err)
+			}
+			if confirmed.PendingFactor != "" || confirmed.Token == session.Token {
+				t.Fatalf("session = %+v, want a full session after the code", confirmed)
+			}
+		})
+	}
+}
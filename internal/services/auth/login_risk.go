@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	stderrors "errors"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/geoip"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// revokeKeyLabel separates the revoke link key from other keys derived from the same secret
+const revokeKeyLabel = "vyrall session revoke link key v1"
+
+// LoginRiskService compares each completed login with the account's history
+// and flags the ones that look unfamiliar: a kind of device not seen before,
+// a new country, or a login too far from the previous one to have travelled
+// in between. A flagged login is recorded as a SessionFlagged event, which
+// sends the user a "was this you?" alert with a link that signs the session
+// out. Locations come from a local GeoIP database.
+type LoginRiskService struct {
+	historyRepo LoginHistoryRepository
+	geoIP       GeoIPService
+	tx          interfaces.Transactor
+	events      eventbus.Publisher
+	config      *config.LoginRiskConfig
+	key         []byte
+	logger      *logger.Logger
+	now         func() time.Time
+}
+
+// LoginHistoryRepository handles login history storage
+type LoginHistoryRepository interface {
+	Create(ctx context.Context, record *models.LoginRecord) error
+	FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.LoginRecord, error)
+	HasDevice(ctx context.Context, userID primitive.ObjectID, deviceKey string) (bool, error)
+	HasCountry(ctx context.Context, userID primitive.ObjectID, countryCode string) (bool, error)
+}
+
+// NewLoginRiskService creates a new login risk service. Revoke links are
+// signed with a key derived from secret.
+func NewLoginRiskService(
+	historyRepo LoginHistoryRepository,
+	geoIP GeoIPService,
+	tx interfaces.Transactor,
+	events eventbus.Publisher,
+	secret string,
+	config *config.LoginRiskConfig,
+	logger *logger.Logger,
+) *LoginRiskService {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(revokeKeyLabel))
+
+	return &LoginRiskService{
+		historyRepo: historyRepo,
+		geoIP:       geoIP,
+		tx:          tx,
+		events:      events,
+		config:      config,
+		key:         mac.Sum(nil),
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// Evaluate records a completed login in the account's history and returns
+// why it was flagged, if it was. The first login of an account has nothing
+// to be compared with and is never flagged.
+func (s *LoginRiskService) Evaluate(ctx context.Context, session *models.Session) ([]string, error) {
+	now := s.now()
+	record := &models.LoginRecord{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		IPAddress: session.IPAddress,
+		Device:    session.Device,
+		DeviceKey: deviceKey(session.UserAgent),
+		Location:  session.Location,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.HistoryRetention),
+	}
+	if location := s.locate(session.IPAddress); location != nil {
+		record.CountryCode = location.CountryCode
+		if location.HasCoordinates {
+			record.Coordinates = &models.GeoPoint{Type: "Point", Coordinates: []float64{location.Longitude, location.Latitude}}
+		}
+	}
+
+	previous, err := s.historyRepo.FindLatest(ctx, session.UserID)
+	switch {
+	case err == nil:
+		if record.Risks, err = s.assess(ctx, record, previous); err != nil {
+			return nil, err
+		}
+	case !stderrors.Is(err, mongo.ErrNoDocuments):
+		return nil, errors.Wrap(err, "Failed to retrieve login history")
+	}
+
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.historyRepo.Create(ctx, record); err != nil {
+			return err
+		}
+		if len(record.Risks) == 0 {
+			return nil
+		}
+		return s.events.Publish(ctx, eventbus.SessionFlagged{
+			SessionID:  session.ID,
+			UserID:     session.UserID,
+			Device:     session.Device,
+			Location:   session.Location,
+			IPAddress:  session.IPAddress,
+			Risks:      record.Risks,
+			LoggedInAt: now,
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to record login")
+	}
+
+	if len(record.Risks) > 0 {
+		s.logger.Warn("Unfamiliar login flagged", "userId", session.UserID.Hex(), "sessionId", session.ID.Hex(), "ip", session.IPAddress, "risks", record.Risks)
+	}
+	return record.Risks, nil
+}
+
+// RevokeLink returns the link that signs a flagged session out. It expires
+// the configured time after the login, however often the alert is resent.
+func (s *LoginRiskService) RevokeLink(sessionID primitive.ObjectID, loggedInAt time.Time) string {
+	token := s.revokeToken(sessionID, loggedInAt.Add(s.config.RevokeLinkTTL))
+
+	link, err := url.Parse(s.config.RevokeURL)
+	if err != nil {
+		return s.config.RevokeURL + "?token=" + token
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// VerifyRevokeToken returns the session a revoke link was made for
+func (s *LoginRiskService) VerifyRevokeToken(token string) (primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != 12+8+sha256.Size {
+		return primitive.NilObjectID, errors.New(errors.CodeInvalidToken, "Invalid link")
+	}
+
+	payload, signature := raw[:12+8], raw[12+8:]
+	if !hmac.Equal(signature, s.sign(payload)) {
+		return primitive.NilObjectID, errors.New(errors.CodeInvalidToken, "Invalid link")
+	}
+	if !s.now().Before(time.Unix(int64(binary.BigEndian.Uint64(payload[12:])), 0)) {
+		return primitive.NilObjectID, errors.New(errors.CodeInvalidToken, "Link has expired")
+	}
+
+	var sessionID primitive.ObjectID
+	copy(sessionID[:], payload[:12])
+	return sessionID, nil
+}
+
+// assess compares a login with the account's earlier ones
+func (s *LoginRiskService) assess(ctx context.Context, record, previous *models.LoginRecord) ([]string, error) {
+	var risks []string
+
+	known, err := s.historyRepo.HasDevice(ctx, record.UserID, record.DeviceKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to check login history")
+	}
+	if !known {
+		risks = append(risks, models.LoginRiskNewDevice)
+	}
+
+	if record.CountryCode != "" {
+		known, err := s.historyRepo.HasCountry(ctx, record.UserID, record.CountryCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to check login history")
+		}
+		if !known {
+			risks = append(risks, models.LoginRiskNewCountry)
+		}
+	}
+
+	if s.impossibleTravel(record, previous) {
+		risks = append(risks, models.LoginRiskImpossibleTravel)
+	}
+
+	return risks, nil
+}
+
+// impossibleTravel reports whether getting from the previous login to this
+// one would have taken faster travel than the configured speed
+func (s *LoginRiskService) impossibleTravel(record, previous *models.LoginRecord) bool {
+	distance, ok := pointLocation(previous.Coordinates).DistanceKm(pointLocation(record.Coordinates))
+	if !ok || distance < s.config.MinTravelDistance {
+		return false
+	}
+
+	hours := record.CreatedAt.Sub(previous.CreatedAt).Hours()
+	return hours <= 0 || distance/hours > s.config.MaxTravelSpeed
+}
+
+// locate looks an address up, returning nil when it cannot be located
+func (s *LoginRiskService) locate(ipAddress string) *geoip.Location {
+	if s.geoIP == nil {
+		return nil
+	}
+	location, err := s.geoIP.Lookup(ipAddress)
+	if err != nil {
+		return nil
+	}
+	return location
+}
+
+func (s *LoginRiskService) revokeToken(sessionID primitive.ObjectID, expiresAt time.Time) string {
+	raw := make([]byte, 0, 12+8+sha256.Size)
+	raw = append(raw, sessionID[:]...)
+	raw = binary.BigEndian.AppendUint64(raw, uint64(expiresAt.Unix()))
+	raw = append(raw, s.sign(raw)...)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (s *LoginRiskService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// pointLocation converts stored coordinates for a distance calculation
+func pointLocation(point *models.GeoPoint) *geoip.Location {
+	if point == nil || len(point.Coordinates) != 2 {
+		return nil
+	}
+	return &geoip.Location{Longitude: point.Coordinates[0], Latitude: point.Coordinates[1], HasCoordinates: true}
+}
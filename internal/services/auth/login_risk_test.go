@@ -0,0 +1,262 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/tests/helpers/fakes"
+)
+
+// Addresses in the GeoIP fixture
+const (
+	ipLondon     = "192.0.2.10"
+	ipManchester = "192.0.2.200"
+	ipNewYork    = "198.51.100.7"
+	ipSydney     = "203.0.113.9"
+)
+
+const (
+	uaFirefoxLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	uaSafariIPhone = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+)
+
+func newLoginRiskService(t *testing.T, env *testEnv) *auth.LoginRiskService {
+	t.Helper()
+	return auth.NewLoginRiskService(
+		env.repos.LoginHistory,
+		fakes.GeoIP(t),
+		env.repos.Transactor,
+		eventbus.NewOutbox(env.repos.Outbox),
+		env.cfg.JWT.Secret,
+		&env.cfg.LoginRisk,
+		env.log,
+	)
+}
+
+// evaluateLogin evaluates a login by userID from userAgent at ipAddress
+func evaluateLogin(t *testing.T, risk *auth.LoginRiskService, userID primitive.ObjectID, userAgent, ipAddress string) []string {
+	t.Helper()
+	risks, err := risk.Evaluate(context.Background(), &models.Session{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return risks
+}
+
+// seedLogin adds an earlier login to userID's history. The record is made by
+// evaluating the login for another account, so it is located and keyed as a
+// real one, then moved to userID and back to at.
+func seedLogin(t *testing.T, env *testEnv, risk *auth.LoginRiskService, userID primitive.ObjectID, userAgent, ipAddress string, at time.Time) {
+	t.Helper()
+	ctx := context.Background()
+
+	scratch := primitive.NewObjectID()
+	evaluateLogin(t, risk, scratch, userAgent, ipAddress)
+	record, err := env.repos.LoginHistory.FindLatest(ctx, scratch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record.ID = primitive.NilObjectID
+	record.UserID = userID
+	record.CreatedAt = at
+	if err := env.repos.LoginHistory.Create(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertRisks(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("risks = %v, want %v", got, want)
+	}
+}
+
+func TestLoginRiskFamiliarLoginsAreNotFlagged(t *testing.T) {
+	env := newTestEnv(t)
+	risk := newLoginRiskService(t, env)
+	userID := primitive.NewObjectID()
+
+	// The first login has nothing to be compared with
+	assertRisks(t, evaluateLogin(t, risk, userID, uaFirefoxLinux, ipLondon))
+
+	// Manchester is in the same country and too close for impossible travel
+	assertRisks(t, evaluateLogin(t, risk, userID, uaFirefoxLinux, ipManchester))
+	assertRisks(t, evaluateLogin(t, risk, userID, uaFirefoxLinux, ipLondon))
+
+	if got := env.count(t, constants.CollectionLoginHistory, bson.M{"user_id": userID}); got != 3 {
+		t.Fatalf("%s: got %d documents, want 3", constants.CollectionLoginHistory, got)
+	}
+	if got := env.count(t, constants.CollectionOutbox, bson.M{"type": constants.EventSessionFlagged}); got != 0 {
+		t.Fatalf("%s: got %d documents, want 0", constants.CollectionOutbox, got)
+	}
+}
+
+func TestLoginRiskFlagsNewDevice(t *testing.T) {
+	env := newTestEnv(t)
+	risk := newLoginRiskService(t, env)
+	userID := primitive.NewObjectID()
+
+	evaluateLogin(t, risk, userID, uaFirefoxLinux, ipLondon)
+	assertRisks(t, evaluateLogin(t, risk, userID, uaSafariIPhone, ipLondon), models.LoginRiskNewDevice)
+
+	// Once seen, the device is familiar
+	assertRisks(t, evaluateLogin(t, risk, userID, uaSafariIPhone, ipManchester))
+
+	if got := env.count(t, constants.CollectionOutbox, bson.M{"type": constants.EventSessionFlagged}); got != 1 {
+		t.Fatalf("%s: got %d documents, want 1", constants.CollectionOutbox, got)
+	}
+}
+
+func TestLoginRiskFlagsNewCountry(t *testing.T) {
+	env := newTestEnv(t)
+	risk := newLoginRiskService(t, env)
+	userID := primitive.NewObjectID()
+
+	// Two days is long enough to fly from London to New York
+	seedLogin(t, env, risk, userID, uaFirefoxLinux, ipLondon, time.Now().Add(-48*time.Hour))
+	assertRisks(t, evaluateLogin(t, risk, userID, uaFirefoxLinux, ipNewYork), models.LoginRiskNewCountry)
+
+	if got := env.count(t, constants.CollectionOutbox, bson.M{"type": constants.EventSessionFlagged}); got != 1 {
+		t.Fatalf("%s: got %d documents, want 1", constants.CollectionOutbox, got)
+	}
+}
+
+func TestLoginRiskFlagsImpossibleTravel(t *testing.T) {
+	env := newTestEnv(t)
+	risk := newLoginRiskService(t, env)
+	userID := primitive.NewObjectID()
+
+	// New York is a familiar country, but not an hour after a login in London
+	seedLogin(t, env, risk, userID, uaFirefoxLinux, ipNewYork, time.Now().Add(-30*24*time.Hour))
+	seedLogin(t, env, risk, userID, uaFirefoxLinux, ipLondon, time.Now().Add(-time.Hour))
+	assertRisks(t, evaluateLogin(t, risk, userID, uaFirefoxLinux, ipNewYork), models.LoginRiskImpossibleTravel)
+
+	// Every reason is reported together
+	assertRisks(t, evaluateLogin(t, risk, userID, uaSafariIPhone, ipSydney),
+		models.LoginRiskNewDevice, models.LoginRiskNewCountry, models.LoginRiskImpossibleTravel)
+}
+
+// revokeToken returns the token of the revoke link for sessionID
+func revokeToken(t *testing.T, risk *auth.LoginRiskService, sessionID primitive.ObjectID, loggedInAt time.Time) string {
+	t.Helper()
+	link, err := url.Parse(risk.RevokeLink(sessionID, loggedInAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+func newActiveSession(t *testing.T, env *testEnv) *models.Session {
+	t.Helper()
+	session, err := env.repos.Sessions.Create(context.Background(), &models.Session{
+		UserID:    primitive.NewObjectID(),
+		Token:     "access-token",
+		IPAddress: ipSydney,
+		ExpiresAt: time.Now().Add(time.Hour),
+		IsActive:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestLoginRiskRevokeLinkSignsSessionOut(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	risk := newLoginRiskService(t, env)
+	session := newActiveSession(t, env)
+
+	link, err := url.Parse(risk.RevokeLink(session.ID, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := link.Scheme + "://" + link.Host + link.Path; got != env.cfg.LoginRisk.RevokeURL {
+		t.Fatalf("revoke link points at %s, want %s", got, env.cfg.LoginRisk.RevokeURL)
+	}
+
+	token := link.Query().Get("token")
+	sessionID, err := risk.VerifyRevokeToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionID != session.ID {
+		t.Fatalf("revoke token is for session %s, want %s", sessionID.Hex(), session.ID.Hex())
+	}
+
+	if err := env.auth.RevokeFlaggedSession(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := env.repos.Sessions.FindByID(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked.IsActive || revoked.RevokedAt == nil {
+		t.Fatalf("session = %+v, want it revoked", revoked)
+	}
+
+	// Following the link again is harmless
+	if err := env.auth.RevokeFlaggedSession(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginRiskRevokeLinkRejectsForgedToken(t *testing.T) {
+	env := newTestEnv(t)
+	session := newActiveSession(t, env)
+
+	other := auth.NewLoginRiskService(env.repos.LoginHistory, nil, env.repos.Transactor,
+		eventbus.NewOutbox(env.repos.Outbox), "another-secret", &env.cfg.LoginRisk, env.log)
+	token := revokeToken(t, other, session.ID, time.Now())
+
+	if _, err := newLoginRiskService(t, env).VerifyRevokeToken(token); err == nil {
+		t.Fatal("revoke link signed with another secret was accepted")
+	}
+	if err := env.auth.RevokeFlaggedSession(context.Background(), token); err == nil {
+		t.Fatal("session revoked with a forged link")
+	}
+}
+
+func TestLoginRiskRevokeLinkExpires(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	risk := newLoginRiskService(t, env)
+	session := newActiveSession(t, env)
+
+	loggedInAt := time.Now().Add(-env.cfg.LoginRisk.RevokeLinkTTL - time.Minute)
+	token := revokeToken(t, risk, session.ID, loggedInAt)
+
+	if _, err := risk.VerifyRevokeToken(token); err == nil {
+		t.Fatal("expired revoke link was accepted")
+	}
+	if err := env.auth.RevokeFlaggedSession(ctx, token); err == nil {
+		t.Fatal("session revoked with an expired link")
+	}
+
+	stillActive, err := env.repos.Sessions.FindByID(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stillActive.IsActive {
+		t.Fatal("session was revoked with an expired link")
+	}
+}
@@ -7,14 +7,15 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/facebook"
 	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/google"
 
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // OAuthService handles OAuth authentication
@@ -24,10 +25,11 @@ type OAuthService struct {
 
 // OAuthUserInfo contains user information from OAuth providers
 type OAuthUserInfo struct {
-	ID       string
-	Email    string
-	Name     string
-	Username string
+	ID          string
+	Email       string
+	Name        string
+	Username    string
+	DateOfBirth time.Time // Zero when the provider does not share it
 }
 
 // NewOAuthService creates a new OAuth service
@@ -35,7 +37,7 @@ func NewOAuthService(cfg *config.OAuthConfig) *OAuthService {
 	configs := make(map[string]*oauth2.Config)
 
 	// Set up Google OAuth
-	if cfg.Google.ClientID != "" && cfg.Google.ClientSecret != "" {
+	if cfg.Google.Enabled() {
 		configs["google"] = &oauth2.Config{
 			ClientID:     cfg.Google.ClientID,
 			ClientSecret: cfg.Google.ClientSecret,
@@ -46,7 +48,7 @@ func NewOAuthService(cfg *config.OAuthConfig) *OAuthService {
 	}
 
 	// Set up GitHub OAuth
-	if cfg.GitHub.ClientID != "" && cfg.GitHub.ClientSecret != "" {
+	if cfg.GitHub.Enabled() {
 		configs["github"] = &oauth2.Config{
 			ClientID:     cfg.GitHub.ClientID,
 			ClientSecret: cfg.GitHub.ClientSecret,
@@ -57,7 +59,7 @@ func NewOAuthService(cfg *config.OAuthConfig) *OAuthService {
 	}
 
 	// Set up Facebook OAuth
-	if cfg.Facebook.ClientID != "" && cfg.Facebook.ClientSecret != "" {
+	if cfg.Facebook.Enabled() {
 		configs["facebook"] = &oauth2.Config{
 			ClientID:     cfg.Facebook.ClientID,
 			ClientSecret: cfg.Facebook.ClientSecret,
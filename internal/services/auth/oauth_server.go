@@ -0,0 +1,1047 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// OAuth error codes from RFC 6749 and RFC 7009
+const (
+	OAuthErrInvalidRequest          = "invalid_request"
+	OAuthErrInvalidClient           = "invalid_client"
+	OAuthErrInvalidGrant            = "invalid_grant"
+	OAuthErrUnauthorizedClient      = "unauthorized_client"
+	OAuthErrUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrInvalidScope            = "invalid_scope"
+	OAuthErrAccessDenied            = "access_denied"
+	OAuthErrInsufficientScope       = "insufficient_scope"
+)
+
+// Grant types the token endpoint accepts
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+const (
+	// maxRedirectURIs bounds how many redirect URIs an app can register
+	maxRedirectURIs = 10
+
+	// maxAppNameLength bounds the name shown on the consent screen
+	maxAppNameLength = 64
+)
+
+// ScopeDescriptions are shown on the consent screen for each scope an app can request
+var ScopeDescriptions = map[string]string{
+	constants.ScopeOpenID:             "Sign you in with your Vyrall account",
+	constants.ScopeProfile:            "See your name, username and profile picture",
+	constants.ScopeEmail:              "See your email address",
+	constants.ScopeOfflineAccess:      "Keep access when you're not using the app",
+	constants.ScopePostsRead:          "See posts, including ones only you can see",
+	constants.ScopePostsWrite:         "Create, edit and delete posts for you",
+	constants.ScopeCommentsRead:       "See comments",
+	constants.ScopeCommentsWrite:      "Comment and reply for you",
+	constants.ScopeMessagesRead:       "Read your direct messages",
+	constants.ScopeMessagesWrite:      "Send direct messages for you",
+	constants.ScopeUsersRead:          "See profiles, followers and who you follow",
+	constants.ScopeUsersWrite:         "Update your profile and follow or unfollow people",
+	constants.ScopeNotificationsRead:  "See your notifications",
+	constants.ScopeNotificationsWrite: "Mark your notifications as read",
+	constants.ScopeMediaRead:          "See your uploaded photos and videos",
+	constants.ScopeMediaWrite:         "Upload photos and videos for you",
+}
+
+// OAuthError is an error in the shape of RFC 6749 section 5.2. The token,
+// introspection and revocation endpoints return it as is, and authorization
+// requests pass it back to the app on its redirect URI.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) error {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// AuthorizationRequest is an authorization code request from a third-party
+// app, as received by the authorization endpoint
+type AuthorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Nonce               string `form:"nonce" json:"nonce"`
+}
+
+// ConsentPrompt is what the consent screen shows for an authorization request
+type ConsentPrompt struct {
+	App            *models.OAuthApp      `json:"app"`
+	Scopes         []ScopeConsent        `json:"scopes"`
+	RedirectURI    string                `json:"redirect_uri"`
+	AlreadyGranted bool                  `json:"already_granted"` // Every scope was granted before, so the screen can be skipped
+	Request        *AuthorizationRequest `json:"request"`
+}
+
+// ScopeConsent describes one requested scope
+type ScopeConsent struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+	Granted     bool   `json:"granted"` // Granted before
+}
+
+// TokenRequest is a request to the token endpoint
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// TokenResponse is the successful response of the token endpoint
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// Introspection is the response of the introspection endpoint (RFC 7662)
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+}
+
+// AppInput is what a developer supplies when registering or updating an app
+type AppInput struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Website      string   `json:"website"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"` // Ignored on update
+}
+
+// Authorization is an app a user has authorized
+type Authorization struct {
+	App       *models.OAuthApp `json:"app"`
+	Scopes    []string         `json:"scopes"`
+	GrantedAt time.Time        `json:"granted_at"`
+}
+
+// OAuthServerService is the authorization server third-party apps use to act
+// for users: app registration, the authorization code flow with PKCE, token
+// refresh, introspection and revocation, and the OpenID Connect userinfo and
+// discovery documents. Access tokens are JWTs signed like first-party ones,
+// carrying the app's scopes and naming the grant they were issued under so
+// revoking the grant revokes them.
+type OAuthServerService struct {
+	appRepo   OAuthAppRepository
+	grantRepo OAuthGrantRepository
+	userRepo  UserRepository
+	jwt       *JWTService
+	tx        interfaces.Transactor
+	config    *config.OAuthServerConfig
+	logger    *logger.Logger
+}
+
+// OAuthAppRepository handles third-party app storage
+type OAuthAppRepository interface {
+	Create(ctx context.Context, app *models.OAuthApp) (*models.OAuthApp, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthApp, error)
+	FindByClientID(ctx context.Context, clientID string) (*models.OAuthApp, error)
+	FindByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]*models.OAuthApp, error)
+	CountByOwnerID(ctx context.Context, ownerID primitive.ObjectID) (int, error)
+	Update(ctx context.Context, app *models.OAuthApp) error
+	Delete(ctx context.Context, id, ownerID primitive.ObjectID) error
+}
+
+// OAuthGrantRepository handles authorization code, consent and token storage
+type OAuthGrantRepository interface {
+	CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error
+	ConsumeCode(ctx context.Context, id string) (*models.OAuthAuthorizationCode, error)
+	SaveConsent(ctx context.Context, consent *models.OAuthConsent) error
+	FindConsent(ctx context.Context, appID, userID primitive.ObjectID) (*models.OAuthConsent, error)
+	FindConsentsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.OAuthConsent, error)
+	DeleteConsent(ctx context.Context, appID, userID primitive.ObjectID) error
+	DeleteConsentsByAppID(ctx context.Context, appID primitive.ObjectID) error
+	CreateToken(ctx context.Context, token *models.OAuthToken) (*models.OAuthToken, error)
+	FindTokenByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthToken, error)
+	FindTokenByRefreshTokenHash(ctx context.Context, hash string) (*models.OAuthToken, error)
+	FindTokenByRotatedHash(ctx context.Context, hash string) (*models.OAuthToken, error)
+	RotateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, hash string, expiresAt time.Time) error
+	RevokeToken(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error
+	RevokeTokensByApp(ctx context.Context, appID primitive.ObjectID, userID *primitive.ObjectID, revokedAt time.Time) error
+}
+
+// NewOAuthServerService creates a new authorization server service
+func NewOAuthServerService(
+	appRepo OAuthAppRepository,
+	grantRepo OAuthGrantRepository,
+	userRepo UserRepository,
+	jwt *JWTService,
+	tx interfaces.Transactor,
+	config *config.OAuthServerConfig,
+	logger *logger.Logger,
+) *OAuthServerService {
+	return &OAuthServerService{
+		appRepo:   appRepo,
+		grantRepo: grantRepo,
+		userRepo:  userRepo,
+		jwt:       jwt,
+		tx:        tx,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// RegisterApp registers a third-party app for a developer. The client secret
+// of a confidential app is returned once and only its hash is kept.
+func (s *OAuthServerService) RegisterApp(ctx context.Context, ownerID primitive.ObjectID, input *AppInput) (*models.OAuthApp, string, error) {
+	if err := validateAppInput(input); err != nil {
+		return nil, "", err
+	}
+
+	count, err := s.appRepo.CountByOwnerID(ctx, ownerID)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to count apps")
+	}
+	if count >= s.config.MaxAppsPerUser {
+		return nil, "", errors.New(errors.CodeInvalidOperation, "You have registered the maximum number of apps")
+	}
+
+	clientID, err := randomToken(16)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to generate client ID")
+	}
+
+	app := &models.OAuthApp{
+		OwnerID:      ownerID,
+		Name:         strings.TrimSpace(input.Name),
+		Description:  strings.TrimSpace(input.Description),
+		Website:      input.Website,
+		ClientID:     clientID,
+		Public:       input.Public,
+		RedirectURIs: input.RedirectURIs,
+		Scopes:       normalizeScopes(input.Scopes),
+	}
+
+	var secret string
+	if !app.Public {
+		if secret, err = randomToken(32); err != nil {
+			return nil, "", errors.Wrap(err, "Failed to generate client secret")
+		}
+		app.ClientSecretHash = hashOAuthToken(secret)
+	}
+
+	created, err := s.appRepo.Create(ctx, app)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to register app")
+	}
+
+	s.logger.Info("OAuth app registered", "appId", created.ID.Hex(), "ownerId", ownerID.Hex(), "public", created.Public)
+	return created, secret, nil
+}
+
+// ListApps returns the apps a developer registered
+func (s *OAuthServerService) ListApps(ctx context.Context, ownerID primitive.ObjectID) ([]*models.OAuthApp, error) {
+	apps, err := s.appRepo.FindByOwnerID(ctx, ownerID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve apps")
+	}
+	return apps, nil
+}
+
+// GetApp returns one of a developer's apps
+func (s *OAuthServerService) GetApp(ctx context.Context, ownerID, appID primitive.ObjectID) (*models.OAuthApp, error) {
+	app, err := s.appRepo.FindByID(ctx, appID)
+	if err != nil || app.OwnerID != ownerID {
+		return nil, errors.New(errors.CodeNotFound, "App not found")
+	}
+	return app, nil
+}
+
+// UpdateApp changes the details, redirect URIs and scopes of a developer's
+// app. Scopes removed from the app stay on grants already issued until the
+// user authorizes the app again.
+func (s *OAuthServerService) UpdateApp(ctx context.Context, ownerID, appID primitive.ObjectID, input *AppInput) (*models.OAuthApp, error) {
+	app, err := s.GetApp(ctx, ownerID, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	input.Public = app.Public
+	if err := validateAppInput(input); err != nil {
+		return nil, err
+	}
+
+	app.Name = strings.TrimSpace(input.Name)
+	app.Description = strings.TrimSpace(input.Description)
+	app.Website = input.Website
+	app.RedirectURIs = input.RedirectURIs
+	app.Scopes = normalizeScopes(input.Scopes)
+
+	if err := s.appRepo.Update(ctx, app); err != nil {
+		return nil, errors.Wrap(err, "Failed to update app")
+	}
+	return app, nil
+}
+
+// RotateAppSecret replaces the client secret of a confidential app. The old
+// secret stops working immediately.
+func (s *OAuthServerService) RotateAppSecret(ctx context.Context, ownerID, appID primitive.ObjectID) (string, error) {
+	app, err := s.GetApp(ctx, ownerID, appID)
+	if err != nil {
+		return "", err
+	}
+	if app.Public {
+		return "", errors.New(errors.CodeInvalidOperation, "Public apps have no client secret")
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to generate client secret")
+	}
+	app.ClientSecretHash = hashOAuthToken(secret)
+
+	if err := s.appRepo.Update(ctx, app); err != nil {
+		return "", errors.Wrap(err, "Failed to update app")
+	}
+
+	s.logger.Info("OAuth app secret rotated", "appId", app.ID.Hex())
+	return secret, nil
+}
+
+// DeleteApp removes a developer's app, revoking every token issued to it
+func (s *OAuthServerService) DeleteApp(ctx context.Context, ownerID, appID primitive.ObjectID) error {
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.appRepo.Delete(ctx, appID, ownerID); err != nil {
+			if stderrors.Is(err, mongo.ErrNoDocuments) {
+				return errors.New(errors.CodeNotFound, "App not found")
+			}
+			return err
+		}
+		if err := s.grantRepo.RevokeTokensByApp(ctx, appID, nil, time.Now()); err != nil {
+			return err
+		}
+		return s.grantRepo.DeleteConsentsByAppID(ctx, appID)
+	})
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			return err
+		}
+		return errors.Wrap(err, "Failed to delete app")
+	}
+
+	s.logger.Info("OAuth app deleted", "appId", appID.Hex(), "ownerId", ownerID.Hex())
+	return nil
+}
+
+// GetAuthorization validates an authorization request and returns what the
+// consent screen should show. Problems with the client or redirect URI are
+// returned as ordinary errors and must be shown to the user; anything else is
+// an *OAuthError to send back to the app with AuthorizationRedirect.
+func (s *OAuthServerService) GetAuthorization(ctx context.Context, userID primitive.ObjectID, req *AuthorizationRequest) (*ConsentPrompt, error) {
+	app, scopes, err := s.validateAuthorization(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+	if consent, err := s.grantRepo.FindConsent(ctx, app.ID, userID); err == nil {
+		for _, scope := range consent.Scopes {
+			granted[scope] = true
+		}
+	}
+
+	prompt := &ConsentPrompt{App: app, RedirectURI: req.RedirectURI, AlreadyGranted: true, Request: req}
+	for _, scope := range scopes {
+		prompt.Scopes = append(prompt.Scopes, ScopeConsent{
+			Scope:       scope,
+			Description: ScopeDescriptions[scope],
+			Granted:     granted[scope],
+		})
+		prompt.AlreadyGranted = prompt.AlreadyGranted && granted[scope]
+	}
+	return prompt, nil
+}
+
+// DecideAuthorization records the user's answer to an authorization request
+// and returns the URL to send the user back to the app with. On approval the
+// URL carries a single-use code; on denial, an access_denied error.
+func (s *OAuthServerService) DecideAuthorization(ctx context.Context, userID primitive.ObjectID, req *AuthorizationRequest, approved bool) (string, error) {
+	app, scopes, err := s.validateAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if !approved {
+		return s.AuthorizationRedirect(req, oauthError(OAuthErrAccessDenied, "The user denied the request")), nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || user.Status != "active" {
+		return "", errors.New(errors.CodeAccountDisabled, "Account is not active")
+	}
+
+	// Remember every scope granted so far, so the screen is skipped next time
+	consentScopes := scopes
+	if consent, err := s.grantRepo.FindConsent(ctx, app.ID, userID); err == nil {
+		consentScopes = normalizeScopes(append(append([]string{}, consent.Scopes...), scopes...))
+	}
+	if err := s.grantRepo.SaveConsent(ctx, &models.OAuthConsent{AppID: app.ID, UserID: userID, Scopes: consentScopes}); err != nil {
+		return "", errors.Wrap(err, "Failed to save consent")
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to generate authorization code")
+	}
+	now := time.Now()
+	err = s.grantRepo.CreateCode(ctx, &models.OAuthAuthorizationCode{
+		ID:            hashOAuthToken(code),
+		AppID:         app.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(s.config.CodeTTL),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to store authorization code")
+	}
+
+	s.logger.Info("OAuth authorization approved", "appId", app.ID.Hex(), "userId", userID.Hex(), "scope", strings.Join(scopes, " "))
+	return redirectWith(req.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {req.State},
+		"iss":   {s.config.IssuerURL},
+	}), nil
+}
+
+// ConsentURL returns the consent screen URL for an authorization request,
+// which carries the request's query string over unchanged
+func (s *OAuthServerService) ConsentURL(rawQuery string) string {
+	if rawQuery == "" {
+		return s.config.ConsentURL
+	}
+	return s.config.ConsentURL + "?" + rawQuery
+}
+
+// AuthorizationRedirect returns the URL that passes an *OAuthError back to
+// the app that made an authorization request. The request's redirect URI must
+// already have been validated.
+func (s *OAuthServerService) AuthorizationRedirect(req *AuthorizationRequest, err error) string {
+	var oauthErr *OAuthError
+	if !stderrors.As(err, &oauthErr) {
+		oauthErr = &OAuthError{Code: "server_error", Description: "The authorization server failed"}
+	}
+	return redirectWith(req.RedirectURI, url.Values{
+		"error":             {oauthErr.Code},
+		"error_description": {oauthErr.Description},
+		"state":             {req.State},
+		"iss":               {s.config.IssuerURL},
+	})
+}
+
+// ExchangeToken implements the token endpoint: it exchanges an authorization
+// code or a refresh token for an access token. Refresh tokens are rotated on
+// every use; presenting one again revokes the grant, as it means it was copied.
+func (s *OAuthServerService) ExchangeToken(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	app, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, app, req)
+	case GrantTypeRefreshToken:
+		return s.exchangeRefreshToken(ctx, app, req)
+	case "":
+		return nil, oauthError(OAuthErrInvalidRequest, "grant_type is required")
+	default:
+		return nil, oauthError(OAuthErrUnsupportedGrantType, "Supported grant types are authorization_code and refresh_token")
+	}
+}
+
+// Introspect implements the introspection endpoint. An app can only
+// introspect tokens issued to it; anything else is reported inactive.
+func (s *OAuthServerService) Introspect(ctx context.Context, clientID, clientSecret, token string) (*Introspection, error) {
+	app, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if accessToken, err := s.jwt.ValidateToken(ctx, token); err == nil {
+		if accessToken.ClientID != app.ClientID || s.CheckGrant(ctx, accessToken) != nil {
+			return &Introspection{Active: false}, nil
+		}
+		return &Introspection{
+			Active:    true,
+			Scope:     strings.Join(accessToken.Scopes, " "),
+			ClientID:  accessToken.ClientID,
+			Subject:   accessToken.UserID.Hex(),
+			TokenType: "Bearer",
+			ExpiresAt: accessToken.ExpiresAt.Unix(),
+			Issuer:    s.jwt.config.Issuer,
+		}, nil
+	}
+
+	grant, err := s.grantRepo.FindTokenByRefreshTokenHash(ctx, hashOAuthToken(token))
+	if err != nil || grant.AppID != app.ID || !grantActive(grant, time.Now()) {
+		return &Introspection{Active: false}, nil
+	}
+	return &Introspection{
+		Active:    true,
+		Scope:     strings.Join(grant.Scopes, " "),
+		ClientID:  grant.ClientID,
+		Subject:   grant.UserID.Hex(),
+		TokenType: "refresh_token",
+		ExpiresAt: grant.ExpiresAt.Unix(),
+		IssuedAt:  grant.CreatedAt.Unix(),
+	}, nil
+}
+
+// Revoke implements the revocation endpoint (RFC 7009). Revoking either the
+// access token or the refresh token of a grant revokes both. Unknown tokens
+// and tokens of other apps are ignored, as the RFC requires.
+func (s *OAuthServerService) Revoke(ctx context.Context, clientID, clientSecret, token string) error {
+	app, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	var grantID primitive.ObjectID
+	if grant, err := s.grantRepo.FindTokenByRefreshTokenHash(ctx, hashOAuthToken(token)); err == nil {
+		if grant.AppID != app.ID {
+			return nil
+		}
+		grantID = grant.ID
+	} else if accessToken, err := s.jwt.ValidateToken(ctx, token); err == nil && accessToken.ClientID == app.ClientID {
+		if grantID, err = primitive.ObjectIDFromHex(accessToken.GrantID); err != nil {
+			return nil
+		}
+	} else {
+		return nil
+	}
+
+	if err := s.grantRepo.RevokeToken(ctx, grantID, time.Now()); err != nil {
+		return errors.Wrap(err, "Failed to revoke token")
+	}
+	s.logger.Info("OAuth token revoked", "appId", app.ID.Hex(), "grantId", grantID.Hex())
+	return nil
+}
+
+// CheckGrant confirms that the grant a third-party access token was issued
+// under has not been revoked
+func (s *OAuthServerService) CheckGrant(ctx context.Context, accessToken *AccessToken) error {
+	grantID, err := primitive.ObjectIDFromHex(accessToken.GrantID)
+	if err != nil {
+		return errors.New(errors.CodeInvalidToken, "Invalid token")
+	}
+
+	grant, err := s.grantRepo.FindTokenByID(ctx, grantID)
+	if err != nil || grant.ClientID != accessToken.ClientID || grant.UserID != accessToken.UserID || grant.RevokedAt != nil {
+		return errors.New(errors.CodeInvalidToken, "Token has been revoked")
+	}
+	return nil
+}
+
+// UserInfo returns the OpenID Connect claims about the user an access token
+// was issued for, limited to the scopes it was granted
+func (s *OAuthServerService) UserInfo(ctx context.Context, token string) (map[string]interface{}, error) {
+	accessToken, err := s.jwt.ValidateToken(ctx, token)
+	if err != nil || !accessToken.IsThirdParty() || s.CheckGrant(ctx, accessToken) != nil {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid or expired token")
+	}
+	if !accessToken.HasScope(constants.ScopeOpenID) {
+		return nil, oauthError(OAuthErrInsufficientScope, "The openid scope is required")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, accessToken.UserID)
+	if err != nil || user.Status != "active" {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid or expired token")
+	}
+
+	claims := map[string]interface{}{"sub": user.ID.Hex()}
+	if accessToken.HasScope(constants.ScopeProfile) {
+		claims["name"] = user.DisplayName
+		claims["preferred_username"] = user.Username
+		if user.ProfilePicture != "" {
+			claims["picture"] = user.ProfilePicture
+		}
+	}
+	if accessToken.HasScope(constants.ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerified
+	}
+	return claims, nil
+}
+
+// ListAuthorizations returns the apps a user has authorized
+func (s *OAuthServerService) ListAuthorizations(ctx context.Context, userID primitive.ObjectID) ([]*Authorization, error) {
+	consents, err := s.grantRepo.FindConsentsByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve authorized apps")
+	}
+
+	authorizations := make([]*Authorization, 0, len(consents))
+	for _, consent := range consents {
+		app, err := s.appRepo.FindByID(ctx, consent.AppID)
+		if err != nil {
+			continue // Deleted since
+		}
+		authorizations = append(authorizations, &Authorization{App: app, Scopes: consent.Scopes, GrantedAt: consent.CreatedAt})
+	}
+	return authorizations, nil
+}
+
+// RevokeAuthorization removes a user's consent for an app and revokes every
+// token it holds for them
+func (s *OAuthServerService) RevokeAuthorization(ctx context.Context, userID, appID primitive.ObjectID) error {
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.grantRepo.DeleteConsent(ctx, appID, userID); err != nil {
+			if stderrors.Is(err, mongo.ErrNoDocuments) {
+				return errors.New(errors.CodeNotFound, "App is not authorized")
+			}
+			return err
+		}
+		return s.grantRepo.RevokeTokensByApp(ctx, appID, &userID, time.Now())
+	})
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			return err
+		}
+		return errors.Wrap(err, "Failed to revoke app access")
+	}
+
+	s.logger.Info("OAuth authorization revoked", "appId", appID.Hex(), "userId", userID.Hex())
+	return nil
+}
+
+// Discovery returns the OpenID Connect discovery document
+func (s *OAuthServerService) Discovery() map[string]interface{} {
+	issuer := strings.TrimSuffix(s.config.IssuerURL, "/")
+
+	scopes := make([]string, 0, len(ScopeDescriptions))
+	for scope := range ScopeDescriptions {
+		scopes = append(scopes, scope)
+	}
+
+	return map[string]interface{}{
+		"issuer":                                         issuer,
+		"authorization_endpoint":                         issuer + "/oauth/authorize",
+		"token_endpoint":                                 issuer + "/oauth/token",
+		"introspection_endpoint":                         issuer + "/oauth/introspect",
+		"revocation_endpoint":                            issuer + "/oauth/revoke",
+		"userinfo_endpoint":                              issuer + "/oauth/userinfo",
+		"jwks_uri":                                       issuer + "/.well-known/jwks.json",
+		"scopes_supported":                               normalizeScopes(scopes),
+		"response_types_supported":                       []string{"code"},
+		"grant_types_supported":                          []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken},
+		"subject_types_supported":                        []string{"public"},
+		"id_token_signing_alg_values_supported":          []string{s.jwt.config.Algorithm},
+		"code_challenge_methods_supported":               []string{"S256"},
+		"token_endpoint_auth_methods_supported":          []string{"client_secret_basic", "client_secret_post", "none"},
+		"claims_supported":                               []string{"sub", "name", "preferred_username", "picture", "email", "email_verified", "nonce", "auth_time"},
+		"authorization_response_iss_parameter_supported": true,
+	}
+}
+
+// validateAuthorization checks an authorization request and returns the app
+// and the scopes requested
+func (s *OAuthServerService) validateAuthorization(ctx context.Context, req *AuthorizationRequest) (*models.OAuthApp, []string, error) {
+	if req.ClientID == "" {
+		return nil, nil, errors.New(errors.CodeInvalidArgument, "client_id is required")
+	}
+	app, err := s.appRepo.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		return nil, nil, errors.New(errors.CodeInvalidArgument, "Unknown client")
+	}
+
+	// The redirect URI must match a registered one exactly. It may only be
+	// left out when the app registered a single one.
+	if req.RedirectURI == "" && len(app.RedirectURIs) == 1 {
+		req.RedirectURI = app.RedirectURIs[0]
+	}
+	if !containsString(app.RedirectURIs, req.RedirectURI) {
+		return nil, nil, errors.New(errors.CodeInvalidArgument, "redirect_uri is not registered for this app")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, oauthError(OAuthErrUnsupportedResponseType, "Only the code response type is supported")
+	}
+
+	// PKCE is required of every app, confidential ones included
+	if req.CodeChallengeMethod != "S256" {
+		return nil, nil, oauthError(OAuthErrInvalidRequest, "code_challenge_method must be S256")
+	}
+	if len(req.CodeChallenge) != 43 {
+		return nil, nil, oauthError(OAuthErrInvalidRequest, "code_challenge must be a base64url encoded SHA-256 hash")
+	}
+
+	scopes := normalizeScopes(strings.Fields(req.Scope))
+	if len(scopes) == 0 {
+		return nil, nil, oauthError(OAuthErrInvalidScope, "scope is required")
+	}
+	for _, scope := range scopes {
+		if !containsString(app.Scopes, scope) {
+			return nil, nil, oauthError(OAuthErrInvalidScope, "The app may not request "+scope)
+		}
+	}
+
+	return app, scopes, nil
+}
+
+// authenticateClient identifies the app calling the token, introspection or
+// revocation endpoint. Confidential apps must present their secret.
+func (s *OAuthServerService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthApp, error) {
+	if clientID == "" {
+		return nil, oauthError(OAuthErrInvalidClient, "Client authentication is required")
+	}
+
+	app, err := s.appRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, oauthError(OAuthErrInvalidClient, "Client authentication failed")
+	}
+
+	if !app.Public {
+		hash := hashOAuthToken(clientSecret)
+		if clientSecret == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(app.ClientSecretHash)) != 1 {
+			return nil, oauthError(OAuthErrInvalidClient, "Client authentication failed")
+		}
+	}
+	return app, nil
+}
+
+// exchangeCode redeems an authorization code
+func (s *OAuthServerService) exchangeCode(ctx context.Context, app *models.OAuthApp, req *TokenRequest) (*TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauthError(OAuthErrInvalidRequest, "code and code_verifier are required")
+	}
+
+	code, err := s.grantRepo.ConsumeCode(ctx, hashOAuthToken(req.Code))
+	if err != nil {
+		return nil, oauthError(OAuthErrInvalidGrant, "The authorization code is invalid or has expired")
+	}
+	if code.AppID != app.ID || code.RedirectURI != req.RedirectURI {
+		return nil, oauthError(OAuthErrInvalidGrant, "The authorization code was issued to another client or redirect URI")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, oauthError(OAuthErrInvalidGrant, "code_verifier does not match the code challenge")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, code.UserID)
+	if err != nil || user.Status != "active" {
+		return nil, oauthError(OAuthErrInvalidGrant, "The user is no longer active")
+	}
+
+	now := time.Now()
+	grant := &models.OAuthToken{
+		AppID:      app.ID,
+		ClientID:   app.ClientID,
+		UserID:     user.ID,
+		Scopes:     code.Scopes,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.config.AccessTokenTTL),
+	}
+
+	var refreshToken string
+	if containsString(code.Scopes, constants.ScopeOfflineAccess) {
+		if refreshToken, err = randomToken(32); err != nil {
+			return nil, errors.Wrap(err, "Failed to generate refresh token")
+		}
+		grant.RefreshTokenHash = hashOAuthToken(refreshToken)
+		grant.ExpiresAt = now.Add(s.config.RefreshTokenTTL)
+	}
+
+	if grant, err = s.grantRepo.CreateToken(ctx, grant); err != nil {
+		return nil, errors.Wrap(err, "Failed to store token")
+	}
+
+	response, err := s.issue(grant, grant.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	response.RefreshToken = refreshToken
+
+	if containsString(code.Scopes, constants.ScopeOpenID) {
+		if response.IDToken, err = s.idToken(user, app, code, now); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("OAuth code exchanged", "appId", app.ID.Hex(), "userId", user.ID.Hex(), "grantId", grant.ID.Hex())
+	return response, nil
+}
+
+// exchangeRefreshToken rotates a refresh token and issues a new access
+// token, optionally narrowed to some of the granted scopes
+func (s *OAuthServerService) exchangeRefreshToken(ctx context.Context, app *models.OAuthApp, req *TokenRequest) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, oauthError(OAuthErrInvalidRequest, "refresh_token is required")
+	}
+	hash := hashOAuthToken(req.RefreshToken)
+	now := time.Now()
+
+	grant, err := s.grantRepo.FindTokenByRefreshTokenHash(ctx, hash)
+	if err != nil {
+		// A refresh token that was already exchanged has been copied; revoke
+		// the grant so neither copy works any more
+		if reused, err := s.grantRepo.FindTokenByRotatedHash(ctx, hash); err == nil && reused.AppID == app.ID {
+			s.logger.Warn("OAuth refresh token reused, revoking grant", "appId", app.ID.Hex(), "grantId", reused.ID.Hex())
+			if err := s.grantRepo.RevokeToken(ctx, reused.ID, now); err != nil {
+				s.logger.Error("Failed to revoke reused OAuth grant", "grantId", reused.ID.Hex(), "error", err)
+			}
+		}
+		return nil, oauthError(OAuthErrInvalidGrant, "The refresh token is invalid")
+	}
+	if grant.AppID != app.ID || !grantActive(grant, now) {
+		return nil, oauthError(OAuthErrInvalidGrant, "The refresh token is invalid")
+	}
+
+	scopes := grant.Scopes
+	if req.Scope != "" {
+		scopes = normalizeScopes(strings.Fields(req.Scope))
+		for _, scope := range scopes {
+			if !containsString(grant.Scopes, scope) {
+				return nil, oauthError(OAuthErrInvalidScope, "The refresh token was not granted "+scope)
+			}
+		}
+	}
+
+	user, err := s.userRepo.FindByID(ctx, grant.UserID)
+	if err != nil || user.Status != "active" {
+		return nil, oauthError(OAuthErrInvalidGrant, "The user is no longer active")
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate refresh token")
+	}
+	if err := s.grantRepo.RotateRefreshToken(ctx, grant.ID, hash, hashOAuthToken(refreshToken), now.Add(s.config.RefreshTokenTTL)); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, oauthError(OAuthErrInvalidGrant, "The refresh token is invalid")
+		}
+		return nil, errors.Wrap(err, "Failed to rotate refresh token")
+	}
+
+	response, err := s.issue(grant, scopes)
+	if err != nil {
+		return nil, err
+	}
+	response.RefreshToken = refreshToken
+	return response, nil
+}
+
+// issue signs an access token under a grant
+func (s *OAuthServerService) issue(grant *models.OAuthToken, scopes []string) (*TokenResponse, error) {
+	accessToken, expiresAt, err := s.jwt.GenerateAppToken(grant.UserID, grant.ID, grant.ClientID, scopes, s.config.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(expiresAt).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// idToken signs the OpenID Connect ID token for a code exchange
+func (s *OAuthServerService) idToken(user *models.User, app *models.OAuthApp, code *models.OAuthAuthorizationCode, now time.Time) (string, error) {
+	claims := &IDTokenClaims{
+		Nonce:    code.Nonce,
+		AuthTime: code.CreatedAt.Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    strings.TrimSuffix(s.config.IssuerURL, "/"),
+			Subject:   user.ID.Hex(),
+			Audience:  jwt.ClaimStrings{app.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL)),
+		},
+	}
+	if containsString(code.Scopes, constants.ScopeProfile) {
+		claims.Name = user.DisplayName
+		claims.PreferredUsername = user.Username
+		claims.Picture = user.ProfilePicture
+	}
+	if containsString(code.Scopes, constants.ScopeEmail) {
+		verified := user.EmailVerified
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+	return s.jwt.GenerateIDToken(claims)
+}
+
+// validateAppInput checks the details of an app being registered or updated
+func validateAppInput(input *AppInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxAppNameLength {
+		return errors.New(errors.CodeInvalidArgument, "App name is required and must be at most 64 characters")
+	}
+	if input.Website != "" {
+		if parsed, err := url.Parse(input.Website); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return errors.New(errors.CodeInvalidArgument, "Website must be an http or https URL")
+		}
+	}
+
+	if len(input.RedirectURIs) == 0 || len(input.RedirectURIs) > maxRedirectURIs {
+		return errors.New(errors.CodeInvalidArgument, "Between 1 and 10 redirect URIs are required")
+	}
+	for _, redirectURI := range input.RedirectURIs {
+		if err := validateRedirectURI(redirectURI, input.Public); err != nil {
+			return err
+		}
+	}
+
+	if len(input.Scopes) == 0 {
+		return errors.New(errors.CodeInvalidArgument, "At least one scope is required")
+	}
+	for _, scope := range input.Scopes {
+		if _, ok := ScopeDescriptions[scope]; !ok {
+			return errors.New(errors.CodeInvalidArgument, "Unknown scope "+scope)
+		}
+	}
+	return nil
+}
+
+// validateRedirectURI accepts https URIs, http only on a loopback address,
+// and for public apps the private-use schemes of native apps (RFC 8252)
+func validateRedirectURI(redirectURI string, public bool) error {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Scheme == "" || parsed.Fragment != "" {
+		return errors.New(errors.CodeInvalidArgument, "Redirect URIs must be absolute and have no fragment: "+redirectURI)
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		if parsed.Host == "" {
+			return errors.New(errors.CodeInvalidArgument, "Invalid redirect URI: "+redirectURI)
+		}
+	case "http":
+		host := parsed.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return errors.New(errors.CodeInvalidArgument, "http redirect URIs are only allowed on loopback addresses: "+redirectURI)
+		}
+	default:
+		// Private-use schemes are reverse domain names, e.g. com.example.app
+		if !public || !strings.Contains(parsed.Scheme, ".") {
+			return errors.New(errors.CodeInvalidArgument, "Redirect URIs must use https: "+redirectURI)
+		}
+	}
+	return nil
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against its S256 challenge
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func grantActive(grant *models.OAuthToken, now time.Time) bool {
+	return grant.RevokedAt == nil && now.Before(grant.ExpiresAt)
+}
+
+// normalizeScopes removes duplicate scopes and sorts the rest
+func normalizeScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectWith adds params to a redirect URI, keeping its own query
+func redirectWith(redirectURI string, params url.Values) string {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// randomToken returns n random bytes, base64url encoded without padding
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashOAuthToken hashes codes, refresh tokens and client secrets for
+// storage. They are random, so a plain SHA-256 cannot be reversed.
+func hashOAuthToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,249 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	stderrors "errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+const (
+	testRedirectURI  = "https://app.example.com/callback"
+	testCodeVerifier = "correct-horse-battery-staple-correct-horse-battery"
+)
+
+// testApp is a confidential app registered with the authorization server
+type testApp struct {
+	app    *models.OAuthApp
+	secret string
+}
+
+// registerApp registers a confidential app for a new developer, allowed the
+// sign-in scopes and reading posts
+func registerApp(t *testing.T, env *testEnv, developer string) *testApp {
+	t.Helper()
+	owner := registerUser(t, env, developer)
+	app, secret, err := env.auth.OAuthServer().RegisterApp(context.Background(), owner.ID, &auth.AppInput{
+		Name:         "Example",
+		RedirectURIs: []string{testRedirectURI, "https://app.example.com/other"},
+		Scopes: []string{
+			constants.ScopeOpenID,
+			constants.ScopeEmail,
+			constants.ScopeOfflineAccess,
+			constants.ScopePostsRead,
+		},
+	})
+	if err != nil {
+		t.Fatalf("register app: %v", err)
+	}
+	return &testApp{app: app, secret: secret}
+}
+
+// codeChallenge is the S256 PKCE challenge of verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorize approves an authorization request with the test code verifier
+// and returns the code the app is sent back with
+func authorize(t *testing.T, env *testEnv, app *testApp, user *models.User, scope string) string {
+	t.Helper()
+	redirect, err := env.auth.OAuthServer().DecideAuthorization(context.Background(), user.ID, &auth.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            app.app.ClientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               scope,
+		State:               "xyz",
+		CodeChallenge:       codeChallenge(testCodeVerifier),
+		CodeChallengeMethod: "S256",
+	}, true)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+
+	parsed, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatalf("parse redirect %q: %v", redirect, err)
+	}
+	query := parsed.Query()
+	if query.Get("state") != "xyz" || query.Get("code") == "" {
+		t.Fatalf("redirect = %q, want a code and the state", redirect)
+	}
+	return query.Get("code")
+}
+
+// exchangeCode redeems a code as app would
+func exchangeCode(env *testEnv, app *testApp, code string) (*auth.TokenResponse, error) {
+	return env.auth.OAuthServer().ExchangeToken(context.Background(), &auth.TokenRequest{
+		GrantType:    auth.GrantTypeAuthorizationCode,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     app.app.ClientID,
+		ClientSecret: app.secret,
+	})
+}
+
+// assertOAuthError fails the test unless err is an *OAuthError with code
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *auth.OAuthError
+	if !stderrors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("error = %v, want %s", err, code)
+	}
+}
+
+func TestOAuthCodeExchange(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	app := registerApp(t, env, "developer")
+	user := registerUser(t, env, "resourceowner")
+
+	code := authorize(t, env, app, user, "openid offline_access posts:read")
+	tokens, err := exchangeCode(env, app, code)
+	if err != nil {
+		t.Fatalf("exchange code: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.IDToken == "" || tokens.Scope != "offline_access openid posts:read" {
+		t.Fatalf("tokens = %+v, want access, refresh and ID tokens for the granted scopes", tokens)
+	}
+
+	accessToken, err := env.auth.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("authenticate with the app's token: %v", err)
+	}
+	if accessToken.UserID != user.ID || accessToken.ClientID != app.app.ClientID || !accessToken.HasScope(constants.ScopePostsRead) {
+		t.Fatalf("access token = %+v, want the user's token for the app", accessToken)
+	}
+
+	// A code is redeemed once
+	_, err = exchangeCode(env, app, code)
+	assertOAuthError(t, err, auth.OAuthErrInvalidGrant)
+}
+
+func TestOAuthCodeExchangeRejects(t *testing.T) {
+	tests := map[string]struct {
+		change func(req *auth.TokenRequest, other *testApp)
+		code   string
+	}{
+		"a code verifier for another challenge": {
+			change: func(req *auth.TokenRequest, _ *testApp) { req.CodeVerifier = strings.Repeat("x", 43) },
+			code:   auth.OAuthErrInvalidGrant,
+		},
+		"no code verifier": {
+			change: func(req *auth.TokenRequest, _ *testApp) { req.CodeVerifier = "" },
+			code:   auth.OAuthErrInvalidRequest,
+		},
+		"the challenge in place of the verifier": {
+			change: func(req *auth.TokenRequest, _ *testApp) { req.CodeVerifier = codeChallenge(testCodeVerifier) },
+			code:   auth.OAuthErrInvalidGrant,
+		},
+		"another registered redirect URI": {
+			change: func(req *auth.TokenRequest, _ *testApp) { req.RedirectURI = "https://app.example.com/other" },
+			code:   auth.OAuthErrInvalidGrant,
+		},
+		"another app": {
+			change: func(req *auth.TokenRequest, other *testApp) {
+				req.ClientID, req.ClientSecret = other.app.ClientID, other.secret
+			},
+			code: auth.OAuthErrInvalidGrant,
+		},
+		"a wrong client secret": {
+			change: func(req *auth.TokenRequest, _ *testApp) { req.ClientSecret = "not-the-secret" },
+			code:   auth.OAuthErrInvalidClient,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			app := registerApp(t, env, "developer")
+			other := registerApp(t, env, "otherdeveloper")
+			user := registerUser(t, env, "resourceowner")
+
+			req := &auth.TokenRequest{
+				GrantType:    auth.GrantTypeAuthorizationCode,
+				Code:         authorize(t, env, app, user, "posts:read"),
+				RedirectURI:  testRedirectURI,
+				CodeVerifier: testCodeVerifier,
+				ClientID:     app.app.ClientID,
+				ClientSecret: app.secret,
+			}
+			tt.change(req, other)
+			_, err := env.auth.OAuthServer().ExchangeToken(context.Background(), req)
+			assertOAuthError(t, err, tt.code)
+		})
+	}
+}
+
+func TestOAuthAuthorizationRequiresPKCE(t *testing.T) {
+	tests := map[string]struct {
+		challenge string
+		method    string
+	}{
+		"no challenge":    {"", ""},
+		"plain method":    {testCodeVerifier[:43], "plain"},
+		"short challenge": {"too-short", "S256"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			app := registerApp(t, env, "developer")
+			user := registerUser(t, env, "resourceowner")
+
+			_, err := env.auth.OAuthServer().DecideAuthorization(context.Background(), user.ID, &auth.AuthorizationRequest{
+				ResponseType:        "code",
+				ClientID:            app.app.ClientID,
+				RedirectURI:         testRedirectURI,
+				Scope:               constants.ScopePostsRead,
+				CodeChallenge:       tt.challenge,
+				CodeChallengeMethod: tt.method,
+			}, true)
+			assertOAuthError(t, err, auth.OAuthErrInvalidRequest)
+		})
+	}
+}
+
+func TestOAuthRefreshTokenReuseRevokesGrant(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	app := registerApp(t, env, "developer")
+	user := registerUser(t, env, "resourceowner")
+
+	tokens, err := exchangeCode(env, app, authorize(t, env, app, user, "offline_access posts:read"))
+	if err != nil {
+		t.Fatalf("exchange code: %v", err)
+	}
+	refresh := func(refreshToken string) (*auth.TokenResponse, error) {
+		return env.auth.OAuthServer().ExchangeToken(ctx, &auth.TokenRequest{
+			GrantType:    auth.GrantTypeRefreshToken,
+			RefreshToken: refreshToken,
+			ClientID:     app.app.ClientID,
+			ClientSecret: app.secret,
+		})
+	}
+
+	rotated, err := refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if rotated.RefreshToken == tokens.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Presenting the retired token again revokes the grant for both copies
+	_, err = refresh(tokens.RefreshToken)
+	assertOAuthError(t, err, auth.OAuthErrInvalidGrant)
+	_, err = refresh(rotated.RefreshToken)
+	assertOAuthError(t, err, auth.OAuthErrInvalidGrant)
+	if _, err := env.auth.Authenticate(ctx, rotated.AccessToken); err == nil {
+		t.Fatal("access token of a revoked grant was accepted")
+	}
+}
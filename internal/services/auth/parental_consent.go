@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// StatusPendingParentalConsent is the status of an account waiting for a
+// parent or guardian to approve it. Such accounts cannot log in.
+const StatusPendingParentalConsent = "pending_parental_consent"
+
+// Verification type of parental consent links
+const verificationParentalConsent = "parental_consent"
+
+// ParentalConsentService asks a young user's parent or guardian to approve
+// their account by email, and activates the account once they do. Only a
+// hash of the emailed token is stored.
+type ParentalConsentService struct {
+	userRepo         UserRepository
+	verificationRepo VerificationRepository
+	emailService     EmailService
+	config           *config.AuthConfig
+	logger           *logger.Logger
+	now              func() time.Time
+}
+
+// NewParentalConsentService creates a new parental consent service
+func NewParentalConsentService(
+	userRepo UserRepository,
+	verificationRepo VerificationRepository,
+	emailService EmailService,
+	config *config.AuthConfig,
+	logger *logger.Logger,
+) *ParentalConsentService {
+	return &ParentalConsentService{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		emailService:     emailService,
+		config:           config,
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// Required reports whether a user of age needs a guardian's consent
+func (s *ParentalConsentService) Required(age int) bool {
+	return age < s.config.ParentalConsentAge
+}
+
+// Prepare checks the guardian a registering user named and marks the
+// account as waiting for their consent
+func (s *ParentalConsentService) Prepare(user *models.User) error {
+	if user.ParentalConsent == nil || strings.TrimSpace(user.ParentalConsent.GuardianEmail) == "" {
+		return errors.New(errors.CodeInvalidArgument, "A parent or guardian's email is required")
+	}
+	guardianEmail := strings.TrimSpace(user.ParentalConsent.GuardianEmail)
+	if strings.EqualFold(guardianEmail, user.Email) {
+		return errors.New(errors.CodeInvalidArgument, "A parent or guardian's email must differ from your own")
+	}
+
+	user.Status = StatusPendingParentalConsent
+	user.ParentalConsent = &models.ParentalConsent{
+		GuardianEmail: guardianEmail,
+		RequestedAt:   s.now(),
+	}
+	return nil
+}
+
+// Request emails the user's guardian a link that approves the account,
+// replacing any sent before
+func (s *ParentalConsentService) Request(ctx context.Context, user *models.User) error {
+	if user.Status != StatusPendingParentalConsent || user.ParentalConsent == nil {
+		return errors.New(errors.CodeInvalidOperation, "Account does not need parental consent")
+	}
+
+	if previous, err := s.verificationRepo.FindByUserID(ctx, user.ID, verificationParentalConsent); err == nil && previous.Status == verificationPending {
+		previous.Status = verificationSuperseded
+		if err := s.verificationRepo.Update(ctx, previous); err != nil {
+			return errors.Wrap(err, "Failed to update verification record")
+		}
+	}
+
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return errors.Wrap(err, "Failed to generate consent link")
+	}
+
+	now := s.now()
+	verification := &models.Verification{
+		UserID:           user.ID,
+		Type:             verificationParentalConsent,
+		VerificationCode: hashLoginSecret(token),
+		Status:           verificationPending,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(s.config.ParentalConsentTTL),
+	}
+	if _, err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return errors.Wrap(err, "Failed to create verification record")
+	}
+
+	emailData := map[string]interface{}{
+		"Username":    user.Username,
+		"DisplayName": user.DisplayName,
+		"ConsentLink": s.config.AppURL + "/parental-consent?token=" + url.QueryEscape(token),
+		"ExpiresIn":   int(s.config.ParentalConsentTTL.Hours()),
+	}
+	if err := s.emailService.SendTemplatedEmail(user.ParentalConsent.GuardianEmail, "Approve Your Child's Account", "parental_consent", emailData); err != nil {
+		return errors.Wrap(err, "Failed to send parental consent email")
+	}
+
+	s.logger.Info("Parental consent requested", "userId", user.ID.Hex())
+	return nil
+}
+
+// Grant approves the account an emailed link was sent for and activates it
+func (s *ParentalConsentService) Grant(ctx context.Context, token string) error {
+	verification, err := s.verificationRepo.FindByToken(ctx, hashLoginSecret(token), verificationParentalConsent)
+	if err != nil || verification.Status != verificationPending {
+		return errors.New(errors.CodeInvalidToken, "Invalid consent link")
+	}
+	now := s.now()
+	if now.After(verification.ExpiresAt) {
+		return errors.New(errors.CodeInvalidToken, "Consent link has expired, ask for a new one")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, verification.UserID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find user")
+	}
+	if user.Status != StatusPendingParentalConsent || user.ParentalConsent == nil {
+		return errors.New(errors.CodeInvalidToken, "Invalid consent link")
+	}
+
+	user.Status = "active"
+	user.ParentalConsent.GrantedAt = timePtr(now)
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return errors.Wrap(err, "Failed to activate account")
+	}
+
+	verification.Status = verificationUsed
+	verification.VerifiedAt = timePtr(now)
+	if err := s.verificationRepo.Update(ctx, verification); err != nil {
+		s.logger.Warn("Failed to update verification record", "error", err, "userId", user.ID.Hex())
+	}
+
+	s.logger.Info("Parental consent granted", "userId", user.ID.Hex())
+	return nil
+}
+
+// ageOn returns how many whole years old someone born on dateOfBirth is on
+// the day of now
+func ageOn(dateOfBirth, now time.Time) int {
+	now = now.In(dateOfBirth.Location())
+	age := now.Year() - dateOfBirth.Year()
+	if now.Month() < dateOfBirth.Month() || (now.Month() == dateOfBirth.Month() && now.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,137 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+const testGuardianEmail = "guardian@example.com"
+
+// registerChild signs up a user of age naming the test guardian
+func registerChild(env *testEnv, username string, age int) (*models.User, error) {
+	return env.auth.Register(context.Background(), &models.User{
+		Username:        username,
+		Email:           username + "@example.com",
+		DateOfBirth:     time.Now().AddDate(-age, 0, -1),
+		ParentalConsent: &models.ParentalConsent{GuardianEmail: testGuardianEmail},
+	}, testPassword)
+}
+
+func TestRegisterAgeGate(t *testing.T) {
+	tests := map[string]struct {
+		dateOfBirth time.Time
+		allowed     bool
+		teen        bool
+	}{
+		"no date of birth":      {},
+		"under the minimum age": {dateOfBirth: time.Now().AddDate(-12, 0, 0)},
+		"born tomorrow":         {dateOfBirth: time.Now().AddDate(0, 0, 1)},
+		"teen":                  {dateOfBirth: time.Now().AddDate(-15, 0, 0), allowed: true, teen: true},
+		"adult":                 {dateOfBirth: time.Now().AddDate(-18, 0, -1), allowed: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user, err := env.auth.Register(context.Background(), &models.User{
+				Username:    "young",
+				Email:       "young@example.com",
+				DateOfBirth: tt.dateOfBirth,
+			}, testPassword)
+			if !tt.allowed {
+				if err == nil || errors.Code(err) != errors.CodeInvalidArgument {
+					t.Fatalf("register = %v, want it refused", err)
+				}
+				if got := env.count(t, constants.CollectionUsers, bson.M{}); got != 0 {
+					t.Fatalf("%d users, want none", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			privacy := user.Settings.PrivacySettings
+			teenDefaults := user.IsPrivate && privacy.WhoCanSendMeMessages == "followers" && privacy.HideFromSuggestions
+			if teenDefaults != tt.teen {
+				t.Fatalf("private = %v, messages from %q, hidden from suggestions = %v, want teen defaults %v",
+					user.IsPrivate, privacy.WhoCanSendMeMessages, privacy.HideFromSuggestions, tt.teen)
+			}
+			if user.Status != "active" || user.ParentalConsent != nil {
+				t.Fatalf("status = %s, consent = %+v, want an active account without consent", user.Status, user.ParentalConsent)
+			}
+		})
+	}
+}
+
+func TestParentalConsentActivatesAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Auth.ParentalConsentAge = 16
+	ctx := context.Background()
+
+	if _, err := env.auth.Register(ctx, &models.User{
+		Username:    "unnamed",
+		Email:       "unnamed@example.com",
+		DateOfBirth: time.Now().AddDate(-14, 0, 0),
+	}, testPassword); err == nil {
+		t.Fatal("child registered without naming a guardian")
+	}
+
+	child, err := registerChild(env, "child", 14)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if child.Status != auth.StatusPendingParentalConsent || !child.IsPrivate {
+		t.Fatalf("child = %+v, want a private account awaiting consent", child)
+	}
+
+	// A wrong password counts as a failure and does not reveal the status
+	_, err = env.auth.Login(ctx, child.Email, "wrong-password", testUserAgent, testIP)
+	if errors.Code(err) != errors.CodeInvalidCredentials {
+		t.Fatalf("login with a wrong password = %v, want invalid credentials", err)
+	}
+	if got := env.count(t, constants.CollectionLoginFailures, bson.M{"email": child.Email}); got != 1 {
+		t.Fatalf("%d login failures, want 1", got)
+	}
+	_, err = env.auth.Login(ctx, child.Email, testPassword, testUserAgent, testIP)
+	if errors.Code(err) != errors.CodeAccountDisabled {
+		t.Fatalf("login before consent = %v, want the account disabled", err)
+	}
+
+	link, err := url.Parse(env.mail.Last(t, testGuardianEmail).Data["ConsentLink"].(string))
+	if err != nil {
+		t.Fatalf("parse consent link: %v", err)
+	}
+	token := link.Query().Get("token")
+	if err := env.auth.GrantParentalConsent(ctx, token); err != nil {
+		t.Fatalf("grant consent: %v", err)
+	}
+	login(t, env, child)
+
+	// The link approves the account once
+	if err := env.auth.GrantParentalConsent(ctx, token); err == nil {
+		t.Fatal("consent link was used twice")
+	}
+}
+
+func TestParentalConsentOnlyBelowConsentAge(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Auth.ParentalConsentAge = 16
+
+	teen, err := registerChild(env, "teen", 16)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if teen.Status != "active" || teen.ParentalConsent != nil || !teen.IsPrivate {
+		t.Fatalf("teen = %+v, want an active private account without consent", teen)
+	}
+	login(t, env, teen)
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Ceremonies a passkey challenge can be answered for
+const (
+	passkeyPurposeRegister     = "register"
+	passkeyPurposeLogin        = "login"
+	passkeyPurposeSecondFactor = "second_factor"
+)
+
+const (
+	// maxPasskeysPerUser bounds how many passkeys one account can register
+	maxPasskeysPerUser = 20
+
+	// maxPasskeyNameLength bounds the name a user gives a passkey
+	maxPasskeyNameLength = 64
+)
+
+// PasskeyService handles WebAuthn passkeys. A passkey can replace the password
+// entirely, or stand in for the TOTP code as the second factor of a password
+// login.
+type PasskeyService struct {
+	passkeyRepo PasskeyRepository
+	userRepo    UserRepository
+	rp          *webauthn.RelyingParty
+	config      *config.WebAuthnConfig
+	logger      *logger.Logger
+}
+
+// PasskeyRepository handles passkey and ceremony challenge storage
+type PasskeyRepository interface {
+	Create(ctx context.Context, passkey *models.Passkey) (*models.Passkey, error)
+	FindByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error)
+	RecordUse(ctx context.Context, id primitive.ObjectID, previousCount, signCount uint32, backedUp bool, usedAt time.Time) error
+	Rename(ctx context.Context, id, userID primitive.ObjectID, name string) error
+	Delete(ctx context.Context, id, userID primitive.ObjectID) error
+	CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error
+	ConsumeChallenge(ctx context.Context, id string) (*models.PasskeyChallenge, error)
+}
+
+// NewPasskeyService creates a new passkey service
+func NewPasskeyService(
+	passkeyRepo PasskeyRepository,
+	userRepo UserRepository,
+	rp *webauthn.RelyingParty,
+	config *config.WebAuthnConfig,
+	logger *logger.Logger,
+) *PasskeyService {
+	return &PasskeyService{
+		passkeyRepo: passkeyRepo,
+		userRepo:    userRepo,
+		rp:          rp,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+// BeginRegistration starts registering a new passkey for a user
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID primitive.ObjectID) (*webauthn.CreationOptions, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find user")
+	}
+
+	passkeys, err := s.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find passkeys")
+	}
+	if len(passkeys) >= maxPasskeysPerUser {
+		return nil, errors.New(errors.CodeInvalidOperation, fmt.Sprintf("An account can have at most %d passkeys", maxPasskeysPerUser))
+	}
+
+	challenge, err := s.createChallenge(ctx, passkeyPurposeRegister, &userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user handle is the account ID, so a passkey login knows whose it is
+	return s.rp.RegistrationOptions(challenge, webauthn.User{
+		Handle:      userID[:],
+		Name:        user.Username,
+		DisplayName: user.DisplayName,
+	}, descriptors(passkeys)), nil
+}
+
+// FinishRegistration verifies the authenticator's response and stores the new passkey
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID primitive.ObjectID, name, device string, response *webauthn.CredentialCreationResponse) (*models.Passkey, error) {
+	challenge, err := s.consumeChallenge(ctx, response.Response.ClientDataJSON, passkeyPurposeRegister)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID == nil || *challenge.UserID != userID {
+		return nil, errors.New(errors.CodeInvalidArgument, "Passkey registration was started by another account")
+	}
+
+	credential, err := s.rp.VerifyRegistration(challenge.ID, webauthn.UserVerificationPreferred, response)
+	if err != nil {
+		s.logger.Warn("Passkey registration failed", "userId", userID.Hex(), "error", err)
+		return nil, errors.New(errors.CodeInvalidArgument, "Passkey could not be verified")
+	}
+
+	name, err = passkeyName(name, device)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := s.passkeyRepo.Create(ctx, &models.Passkey{
+		UserID:         userID,
+		Name:           name,
+		CredentialID:   credential.ID,
+		PublicKey:      credential.PublicKey,
+		Algorithm:      credential.Algorithm,
+		SignCount:      credential.SignCount,
+		AAGUID:         credential.AAGUID,
+		Transports:     credential.Transports,
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+		Device:         device,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New(errors.CodeDuplicateEntity, "Passkey is already registered")
+		}
+		return nil, errors.Wrap(err, "Failed to save passkey")
+	}
+
+	s.logger.Info("Passkey registered", "userId", userID.Hex(), "passkeyId", passkey.ID.Hex())
+	return passkey, nil
+}
+
+// BeginLogin starts a passwordless login. No account is named up front; the
+// authenticator offers the passkeys it holds for this site.
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*webauthn.RequestOptions, error) {
+	challenge, err := s.createChallenge(ctx, passkeyPurposeLogin, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.rp.LoginOptions(challenge, nil, webauthn.UserVerificationRequired), nil
+}
+
+// FinishLogin verifies a passwordless login and returns the passkey used. The
+// authenticator must have verified the user with a PIN or biometric, which
+// makes the passkey a second factor of its own.
+func (s *PasskeyService) FinishLogin(ctx context.Context, response *webauthn.CredentialAssertionResponse) (*models.Passkey, error) {
+	challenge, err := s.consumeChallenge(ctx, response.Response.ClientDataJSON, passkeyPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := s.verifyAssertion(ctx, challenge, webauthn.UserVerificationRequired, response)
+	if err != nil {
+		return nil, err
+	}
+
+	// A discoverable credential returns the user handle it was registered with
+	if handle, err := response.UserHandle(); err != nil || (len(handle) > 0 && string(handle) != string(passkey.UserID[:])) {
+		s.logger.Warn("Passkey user handle does not match its account", "passkeyId", passkey.ID.Hex())
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+	}
+
+	return passkey, nil
+}
+
+// BeginSecondFactor starts a passkey check for a login that is waiting for
+// its second factor. Only the user's own passkeys are offered.
+func (s *PasskeyService) BeginSecondFactor(ctx context.Context, sessionID, userID primitive.ObjectID) (*webauthn.RequestOptions, error) {
+	passkeys, err := s.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find passkeys")
+	}
+	if len(passkeys) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "No passkeys registered")
+	}
+
+	challenge, err := s.createChallenge(ctx, passkeyPurposeSecondFactor, &userID, &sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The password has already been checked, so user presence is enough
+	return s.rp.LoginOptions(challenge, descriptors(passkeys), webauthn.UserVerificationDiscouraged), nil
+}
+
+// FinishSecondFactor verifies the passkey check of a pending login
+func (s *PasskeyService) FinishSecondFactor(ctx context.Context, sessionID primitive.ObjectID, response *webauthn.CredentialAssertionResponse) (*models.Passkey, error) {
+	challenge, err := s.consumeChallenge(ctx, response.Response.ClientDataJSON, passkeyPurposeSecondFactor)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.SessionID == nil || *challenge.SessionID != sessionID || challenge.UserID == nil {
+		return nil, errors.New(errors.CodeInvalidArgument, "Passkey check was started for another login")
+	}
+
+	passkey, err := s.verifyAssertion(ctx, challenge, webauthn.UserVerificationDiscouraged, response)
+	if err != nil {
+		return nil, err
+	}
+	if passkey.UserID != *challenge.UserID {
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+	}
+
+	return passkey, nil
+}
+
+// List returns a user's passkeys, oldest first
+func (s *PasskeyService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error) {
+	passkeys, err := s.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find passkeys")
+	}
+	return passkeys, nil
+}
+
+// Rename changes the name of one of a user's passkeys
+func (s *PasskeyService) Rename(ctx context.Context, userID, passkeyID primitive.ObjectID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxPasskeyNameLength {
+		return errors.New(errors.CodeInvalidArgument, fmt.Sprintf("Passkey name must be 1 to %d characters", maxPasskeyNameLength))
+	}
+
+	if err := s.passkeyRepo.Rename(ctx, passkeyID, userID, name); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New(errors.CodeNotFound, "Passkey not found")
+		}
+		return errors.Wrap(err, "Failed to rename passkey")
+	}
+	return nil
+}
+
+// Delete removes one of a user's passkeys
+func (s *PasskeyService) Delete(ctx context.Context, userID, passkeyID primitive.ObjectID) error {
+	if err := s.passkeyRepo.Delete(ctx, passkeyID, userID); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New(errors.CodeNotFound, "Passkey not found")
+		}
+		return errors.Wrap(err, "Failed to delete passkey")
+	}
+
+	s.logger.Info("Passkey deleted", "userId", userID.Hex(), "passkeyId", passkeyID.Hex())
+	return nil
+}
+
+// Helper methods
+
+func (s *PasskeyService) createChallenge(ctx context.Context, purpose string, userID, sessionID *primitive.ObjectID) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to generate challenge")
+	}
+
+	now := time.Now()
+	err = s.passkeyRepo.CreateChallenge(ctx, &models.PasskeyChallenge{
+		ID:        challenge,
+		Purpose:   purpose,
+		UserID:    userID,
+		SessionID: sessionID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.Timeout),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to save challenge")
+	}
+
+	return challenge, nil
+}
+
+// consumeChallenge finds the ceremony a response answers and uses it up, so a
+// response cannot be replayed
+func (s *PasskeyService) consumeChallenge(ctx context.Context, clientDataJSON, purpose string) (*models.PasskeyChallenge, error) {
+	id, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidArgument, "Invalid passkey response")
+	}
+
+	challenge, err := s.passkeyRepo.ConsumeChallenge(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New(errors.CodeInvalidToken, "Passkey challenge has expired or was already used")
+		}
+		return nil, errors.Wrap(err, "Failed to find challenge")
+	}
+	if challenge.Purpose != purpose {
+		return nil, errors.New(errors.CodeInvalidArgument, "Passkey response is for another ceremony")
+	}
+
+	return challenge, nil
+}
+
+func (s *PasskeyService) verifyAssertion(ctx context.Context, challenge *models.PasskeyChallenge, userVerification string, response *webauthn.CredentialAssertionResponse) (*models.Passkey, error) {
+	credentialID, err := response.CredentialID()
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidArgument, "Invalid passkey response")
+	}
+
+	passkey, err := s.passkeyRepo.FindByCredentialID(ctx, credentialID)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+		}
+		return nil, errors.Wrap(err, "Failed to find passkey")
+	}
+
+	assertion, err := s.rp.VerifyAssertion(challenge.ID, userVerification, passkey.PublicKey, passkey.SignCount, response)
+	if err != nil {
+		if stderrors.Is(err, webauthn.ErrCounterRegressed) {
+			s.logger.Warn("Passkey sign count went backwards, it may have been cloned",
+				"userId", passkey.UserID.Hex(), "passkeyId", passkey.ID.Hex(), "storedCount", passkey.SignCount)
+		} else {
+			s.logger.Warn("Passkey assertion failed", "passkeyId", passkey.ID.Hex(), "error", err)
+		}
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+	}
+
+	// Losing this race means another login used the same count concurrently
+	now := time.Now()
+	err = s.passkeyRepo.RecordUse(ctx, passkey.ID, passkey.SignCount, assertion.SignCount, assertion.BackedUp, now)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+		}
+		return nil, errors.Wrap(err, "Failed to update passkey")
+	}
+
+	passkey.SignCount = assertion.SignCount
+	passkey.BackedUp = assertion.BackedUp
+	passkey.LastUsedAt = &now
+	return passkey, nil
+}
+
+// descriptors lists passkeys for an allow or exclude list
+func descriptors(passkeys []*models.Passkey) []webauthn.CredentialDescriptor {
+	list := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		list = append(list, webauthn.NewCredentialDescriptor(passkey.CredentialID, passkey.Transports))
+	}
+	return list
+}
+
+// passkeyName validates the name given to a new passkey, defaulting to the
+// device it was registered from
+func passkeyName(name, device string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+		if device != "" && device != "unknown" {
+			name = "Passkey on " + device
+		}
+		if len(name) > maxPasskeyNameLength {
+			name = name[:maxPasskeyNameLength]
+		}
+	}
+	if len(name) > maxPasskeyNameLength {
+		return "", errors.New(errors.CodeInvalidArgument, fmt.Sprintf("Passkey name must be at most %d characters", maxPasskeyNameLength))
+	}
+	return name, nil
+}
@@ -0,0 +1,209 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/tests/helpers/fakes"
+)
+
+const (
+	testPassword  = "Plum-Orchard-Lantern-42"
+	testUserAgent = "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"
+	testIP        = "192.0.2.10"
+)
+
+// registerUser signs up an adult with the test password
+func registerUser(t *testing.T, env *testEnv, username string) *models.User {
+	t.Helper()
+	user, err := env.auth.Register(context.Background(), &models.User{
+		Username:    username,
+		Email:       username + "@example.com",
+		DateOfBirth: time.Now().AddDate(-30, 0, 0),
+	}, testPassword)
+	if err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	return user
+}
+
+// registerPasskeyUser signs up a user and registers a passkey for them on a
+// fresh software authenticator
+func registerPasskeyUser(t *testing.T, env *testEnv, username string) (*models.User, *fakes.Authenticator) {
+	t.Helper()
+	ctx := context.Background()
+	user := registerUser(t, env, username)
+
+	authenticator := fakes.NewAuthenticator(env.cfg.WebAuthn.Origins[0])
+	options, err := env.auth.BeginPasskeyRegistration(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+	response, err := authenticator.Register(options)
+	if err != nil {
+		t.Fatalf("authenticator register: %v", err)
+	}
+
+	passkey, err := env.auth.FinishPasskeyRegistration(ctx, user.ID, "Laptop", testUserAgent, response)
+	if err != nil {
+		t.Fatalf("finish registration: %v", err)
+	}
+	if passkey.UserID != user.ID {
+		t.Fatalf("passkey belongs to %s, want %s", passkey.UserID.Hex(), user.ID.Hex())
+	}
+	return user, authenticator
+}
+
+// enableTwoFactor turns on the second factor for a user
+func enableTwoFactor(t *testing.T, env *testEnv, user *models.User) {
+	t.Helper()
+	stored, err := env.repos.Users.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	stored.TwoFactorEnabled = true
+	if err := env.repos.Users.Update(context.Background(), stored); err != nil {
+		t.Fatalf("enable two-factor: %v", err)
+	}
+}
+
+// passkeyLogin runs a passwordless login with the authenticator
+func passkeyLogin(t *testing.T, env *testEnv, authenticator *fakes.Authenticator) (*models.Session, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	options, err := env.auth.BeginPasskeyLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	if len(options.AllowCredentials) != 0 {
+		t.Fatalf("passwordless login allows %d credentials, want a discoverable login", len(options.AllowCredentials))
+	}
+	response, err := authenticator.Login(options)
+	if err != nil {
+		t.Fatalf("authenticator login: %v", err)
+	}
+
+	return env.auth.FinishPasskeyLogin(ctx, response, testUserAgent, testIP)
+}
+
+func TestPasskeyPasswordlessLogin(t *testing.T) {
+	env := newTestEnv(t)
+	user, authenticator := registerPasskeyUser(t, env, "passwordless")
+
+	session, err := passkeyLogin(t, env, authenticator)
+	if err != nil {
+		t.Fatalf("passkey login: %v", err)
+	}
+	if session.UserID != user.ID || session.PendingFactor != "" || !session.IsActive || session.Token == "" {
+		t.Fatalf("session = %+v, want an active session for %s with a token", session, user.ID.Hex())
+	}
+
+	// The same challenge cannot be answered twice
+	ctx := context.Background()
+	options, err := env.auth.BeginPasskeyLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	response, err := authenticator.Login(options)
+	if err != nil {
+		t.Fatalf("authenticator login: %v", err)
+	}
+	if _, err := env.auth.FinishPasskeyLogin(ctx, response, testUserAgent, testIP); err != nil {
+		t.Fatalf("passkey login: %v", err)
+	}
+	if _, err := env.auth.FinishPasskeyLogin(ctx, response, testUserAgent, testIP); err == nil {
+		t.Fatal("replayed assertion was accepted")
+	}
+}
+
+func TestPasskeySecondFactor(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user, authenticator := registerPasskeyUser(t, env, "secondfactor")
+	enableTwoFactor(t, env, user)
+
+	pending, err := env.auth.Login(ctx, user.Email, testPassword, testUserAgent, testIP)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if pending.PendingFactor != models.PendingFactorTwoFactor {
+		t.Fatalf("pending factor = %q, want %q", pending.PendingFactor, models.PendingFactorTwoFactor)
+	}
+
+	// The login waits for the second factor, not for an emailed code
+	if _, err := env.auth.VerifyLoginCode(ctx, pending.Token, "000000"); err == nil {
+		t.Fatal("second factor was completed with an email code")
+	}
+
+	options, err := env.auth.BeginPasskeyTwoFactor(ctx, pending.Token)
+	if err != nil {
+		t.Fatalf("begin second factor: %v", err)
+	}
+	if len(options.AllowCredentials) != 1 {
+		t.Fatalf("second factor allows %d credentials, want the user's passkey", len(options.AllowCredentials))
+	}
+
+	// A passkey the user never registered is refused
+	stranger := fakes.NewAuthenticator(env.cfg.WebAuthn.Origins[0])
+	if _, err := stranger.Login(options); err == nil {
+		t.Fatal("authenticator without the user's passkey answered the second factor")
+	}
+
+	response, err := authenticator.Login(options)
+	if err != nil {
+		t.Fatalf("authenticator login: %v", err)
+	}
+	session, err := env.auth.FinishPasskeyTwoFactor(ctx, pending.Token, response)
+	if err != nil {
+		t.Fatalf("finish second factor: %v", err)
+	}
+	if session.UserID != user.ID || !session.IsActive || session.Token == pending.Token || strings.HasPrefix(session.Token, "pending_") {
+		t.Fatalf("session = %+v, want a full session after the second factor", session)
+	}
+
+	// The promoted session no longer waits for anything and the pending token
+	// cannot complete the login again
+	promoted, err := env.repos.Sessions.FindByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("find session: %v", err)
+	}
+	if promoted.PendingFactor != "" || promoted.StepUpCodeHash != "" {
+		t.Fatalf("session = %+v, want the pending factor cleared", promoted)
+	}
+	if _, err := env.auth.BeginPasskeyTwoFactor(ctx, pending.Token); err == nil {
+		t.Fatal("pending token was used after the second factor")
+	}
+}
+
+func TestPasskeyRejectsRegressedSignCount(t *testing.T) {
+	env := newTestEnv(t)
+	user, authenticator := registerPasskeyUser(t, env, "cloned")
+
+	for i := 0; i < 2; i++ {
+		if _, err := passkeyLogin(t, env, authenticator); err != nil {
+			t.Fatalf("passkey login: %v", err)
+		}
+	}
+
+	// A cloned authenticator reports a counter behind the one last seen
+	passkeys, err := env.auth.ListPasskeys(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("list passkeys: %v", err)
+	}
+	if passkeys[0].SignCount != 2 {
+		t.Fatalf("sign count = %d, want 2", passkeys[0].SignCount)
+	}
+	credentialID := base64.RawURLEncoding.EncodeToString(passkeys[0].CredentialID)
+	if err := authenticator.SetSignCount(credentialID, 0); err != nil {
+		t.Fatalf("set sign count: %v", err)
+	}
+
+	if _, err := passkeyLogin(t, env, authenticator); err == nil {
+		t.Fatal("login with a regressed sign count was accepted")
+	}
+}
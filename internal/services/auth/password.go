@@ -9,9 +9,10 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // PasswordService handles password operations
@@ -19,21 +20,34 @@ type PasswordService struct {
 	userRepo         UserRepository
 	verificationRepo VerificationRepository
 	emailService     EmailService
+	breached         BreachedPasswordChecker
 	config           *config.PasswordConfig
+	logger           *logger.Logger
 }
 
-// NewPasswordService creates a new password service
+// BreachedPasswordChecker looks passwords up in a breached-password corpus.
+// *pwned.Checker implements it.
+type BreachedPasswordChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// NewPasswordService creates a new password service. breached is nil when
+// no breached-password corpus is configured.
 func NewPasswordService(
 	userRepo UserRepository,
 	verificationRepo VerificationRepository,
 	emailService EmailService,
+	breached BreachedPasswordChecker,
 	config *config.PasswordConfig,
+	logger *logger.Logger,
 ) *PasswordService {
 	return &PasswordService{
 		userRepo:         userRepo,
 		verificationRepo: verificationRepo,
 		emailService:     emailService,
+		breached:         breached,
 		config:           config,
+		logger:           logger,
 	}
 }
 
@@ -79,6 +93,11 @@ func (s *PasswordService) RequestReset(ctx context.Context, email string) error
 		return nil
 	}
 
+	// Accounts without a password turn it back on once signed in
+	if user.PasswordDisabled {
+		return nil
+	}
+
 	// Generate reset token
 	token, err := generateSecureToken(32)
 	if err != nil {
@@ -143,9 +162,9 @@ func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword
 		return errors.New(errors.CodeInvalidToken, "Reset token already used")
 	}
 
-	// Check password strength
-	if strong, msg := s.CheckStrength(newPassword); !strong {
-		return errors.New(errors.CodeInvalidArgument, msg)
+	// Check password strength and the breached-password corpus
+	if err := s.Validate(ctx, newPassword); err != nil {
+		return err
 	}
 
 	// Hash new password
@@ -159,8 +178,12 @@ func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword
 	if err != nil {
 		return errors.Wrap(err, "Failed to find user")
 	}
+	if user.PasswordDisabled {
+		return errors.New(errors.CodeInvalidOperation, "Password login is off for this account")
+	}
 
 	user.PasswordHash = passwordHash
+	user.PasswordBreached = false
 	user.UpdatedAt = time.Now()
 
 	err = s.userRepo.Update(ctx, user)
@@ -183,6 +206,40 @@ func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword
 	return nil
 }
 
+// Validate checks a new password: it must meet the strength requirements and
+// must not appear in the breached-password corpus
+func (s *PasswordService) Validate(ctx context.Context, password string) error {
+	if strong, msg := s.CheckStrength(password); !strong {
+		return errors.New(errors.CodeInvalidArgument, msg)
+	}
+	if s.IsBreached(ctx, password) {
+		return errors.New(errors.CodeInvalidArgument, "Password has appeared in a data breach, choose a different one")
+	}
+	return nil
+}
+
+// IsBreached reports whether a password appears in the breached-password
+// corpus. A corpus that cannot be read is logged and treated as not listing
+// the password, so it never stops users registering or logging in.
+func (s *PasswordService) IsBreached(ctx context.Context, password string) bool {
+	if s.breached == nil {
+		return false
+	}
+	breached, err := s.breached.Breached(ctx, password)
+	if err != nil {
+		s.logger.Error("Failed to check breached-password corpus", "error", err)
+		return false
+	}
+	return breached
+}
+
+// MustReset reports whether a password that was just used to log in must be
+// reset first, which it must when login checks are enabled and the password
+// is in the breached-password corpus
+func (s *PasswordService) MustReset(ctx context.Context, password string) bool {
+	return s.config.BreachedOnLogin && s.IsBreached(ctx, password)
+}
+
 // CheckStrength checks if a password meets security requirements
 func (s *PasswordService) CheckStrength(password string) (bool, string) {
 	if len(password) < s.config.MinLength {
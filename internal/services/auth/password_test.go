@@ -0,0 +1,135 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/pwned"
+	"github.com/Caqil/vyrall/pkg/errors"
+	"github.com/Caqil/vyrall/tests/helpers/fakes"
+)
+
+// breachedPassword is strong enough to pass the strength checks but listed
+// in the breached-password fixture
+const breachedPassword = "Summer2024!"
+
+// screenPasswords makes the environment's service check passwords against
+// the breached-password fixture
+func screenPasswords(t *testing.T, env *testEnv) {
+	t.Helper()
+	env.breached = pwned.New(fakes.PwnedPasswords(), 1)
+	env.auth = env.newService(t, env.repositories(), nil)
+}
+
+// resetToken asks for a password reset and returns the emailed token
+func resetToken(t *testing.T, env *testEnv, user *models.User) string {
+	t.Helper()
+	if err := env.auth.RequestPasswordReset(context.Background(), user.Email); err != nil {
+		t.Fatalf("request password reset: %v", err)
+	}
+	return resetTokenFromEmail(t, env, user)
+}
+
+// resetTokenFromEmail returns the token of the last reset link emailed to user
+func resetTokenFromEmail(t *testing.T, env *testEnv, user *models.User) string {
+	t.Helper()
+	link, err := url.Parse(env.mail.Last(t, user.Email).Data["ResetLink"].(string))
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestBreachedPasswordsAreRefused(t *testing.T) {
+	tests := map[string]func(env *testEnv, user *models.User, password string) error{
+		"registration": func(env *testEnv, _ *models.User, password string) error {
+			_, err := env.auth.Register(context.Background(), &models.User{
+				Username:    "newcomer",
+				Email:       "newcomer@example.com",
+				DateOfBirth: time.Now().AddDate(-30, 0, 0),
+			}, password)
+			return err
+		},
+		"password change": func(env *testEnv, user *models.User, password string) error {
+			return env.auth.ChangePassword(context.Background(), user.ID, testPassword, password)
+		},
+		"password reset": func(env *testEnv, user *models.User, password string) error {
+			return env.auth.ResetPassword(context.Background(), resetToken(t, env, user), password)
+		},
+	}
+	for name, setPassword := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			screenPasswords(t, env)
+			user := registerUser(t, env, "careful")
+
+			if err := setPassword(env, user, breachedPassword); errors.Code(err) != errors.CodeInvalidArgument {
+				t.Fatalf("breached password = %v, want it refused", err)
+			}
+			if err := setPassword(env, user, "Unlisted-Orchard-77"); err != nil {
+				t.Fatalf("password outside the corpus: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoginWithBreachedPasswordForcesReset(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.cfg.Password.BreachedOnLogin = true
+
+	// The account predates screening
+	user, err := env.auth.Register(ctx, &models.User{
+		Username:    "pwned",
+		Email:       "pwned@example.com",
+		DateOfBirth: time.Now().AddDate(-30, 0, 0),
+	}, breachedPassword)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	screenPasswords(t, env)
+
+	for i := 0; i < 2; i++ {
+		_, err := env.auth.Login(ctx, user.Email, breachedPassword, testUserAgent, testIP)
+		if errors.Code(err) != errors.CodeForbidden {
+			t.Fatalf("login %d with a breached password = %v, want it refused", i+1, err)
+		}
+	}
+
+	// One reset email, whose link sets a new password
+	resets := 0
+	for _, email := range env.mail.Sent() {
+		if email.To == user.Email && email.Template == "password_reset" {
+			resets++
+		}
+	}
+	if resets != 1 {
+		t.Fatalf("%d reset emails, want 1", resets)
+	}
+	if err := env.auth.ResetPassword(ctx, resetTokenFromEmail(t, env, user), testPassword); err != nil {
+		t.Fatalf("reset password: %v", err)
+	}
+	login(t, env, user)
+}
+
+func TestLoginWithBreachedPasswordAllowedWhenNotChecked(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	user, err := env.auth.Register(ctx, &models.User{
+		Username:    "tolerated",
+		Email:       "tolerated@example.com",
+		DateOfBirth: time.Now().AddDate(-30, 0, 0),
+	}, breachedPassword)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	screenPasswords(t, env)
+
+	if _, err := env.auth.Login(ctx, user.Email, breachedPassword, testUserAgent, testIP); err != nil {
+		t.Fatalf("login without the login-time check: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Verification types of passwordless logins. Each request stores one record
+// of each, created together; using either retires both.
+const (
+	verificationLoginLink = "login_link"
+	verificationLoginCode = "login_code"
+)
+
+// Verification statuses of passwordless logins
+const (
+	verificationPending    = "pending"
+	verificationUsed       = "used"
+	verificationSuperseded = "superseded"
+)
+
+// PasswordlessService signs users in with a single-use link or 6-digit code
+// emailed to them instead of a password. Only hashes of the link token and
+// code are stored.
+type PasswordlessService struct {
+	verificationRepo VerificationRepository
+	emailService     EmailService
+	config           *config.PasswordlessConfig
+	logger           *logger.Logger
+	now              func() time.Time
+}
+
+// NewPasswordlessService creates a new passwordless login service
+func NewPasswordlessService(
+	verificationRepo VerificationRepository,
+	emailService EmailService,
+	config *config.PasswordlessConfig,
+	logger *logger.Logger,
+) *PasswordlessService {
+	return &PasswordlessService{
+		verificationRepo: verificationRepo,
+		emailService:     emailService,
+		config:           config,
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// Send emails a login link and code to user, replacing any sent before.
+// Requests over the account's limit are dropped without an error, so the
+// answer never reveals whether an email has an account.
+func (s *PasswordlessService) Send(ctx context.Context, user *models.User, ipAddress string) error {
+	now := s.now()
+
+	allowed, err := s.allowRequest(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		s.logger.Warn("Passwordless login request dropped: too many requests", "userId", user.ID.Hex(), "ip", ipAddress)
+		return nil
+	}
+
+	for _, type_ := range []string{verificationLoginLink, verificationLoginCode} {
+		if err := s.retire(ctx, user.ID, type_, verificationSuperseded); err != nil {
+			return err
+		}
+	}
+
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return errors.Wrap(err, "Failed to generate login link")
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return errors.Wrap(err, "Failed to generate login code")
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	for type_, secret := range map[string]string{verificationLoginLink: token, verificationLoginCode: code} {
+		verification := &models.Verification{
+			UserID:           user.ID,
+			Type:             type_,
+			VerificationCode: hashLoginSecret(secret),
+			Status:           verificationPending,
+			CreatedAt:        now,
+			ExpiresAt:        now.Add(s.config.CodeTTL),
+		}
+		if _, err := s.verificationRepo.Create(ctx, verification); err != nil {
+			return errors.Wrap(err, "Failed to create verification record")
+		}
+	}
+
+	emailData := map[string]interface{}{
+		"Username":  user.Username,
+		"LoginLink": s.link(token),
+		"Code":      code,
+		"IPAddress": ipAddress,
+		"ExpiresIn": int(s.config.CodeTTL.Minutes()),
+	}
+	if err := s.emailService.SendTemplatedEmail(user.Email, "Your Sign-in Link", "login_link", emailData); err != nil {
+		return errors.Wrap(err, "Failed to send login email")
+	}
+
+	s.logger.Info("Passwordless login sent", "userId", user.ID.Hex(), "ip", ipAddress)
+	return nil
+}
+
+// ConsumeLink uses up an emailed link and returns the user it signs in
+func (s *PasswordlessService) ConsumeLink(ctx context.Context, token string) (primitive.ObjectID, error) {
+	verification, err := s.verificationRepo.FindByToken(ctx, hashLoginSecret(token), verificationLoginLink)
+	if err != nil || verification.Status != verificationPending {
+		return primitive.NilObjectID, errors.New(errors.CodeInvalidToken, "Invalid sign-in link")
+	}
+	if s.now().After(verification.ExpiresAt) {
+		return primitive.NilObjectID, errors.New(errors.CodeInvalidToken, "Sign-in link has expired, request a new one")
+	}
+
+	used, err := s.use(ctx, verification, verificationLoginCode)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if !used {
+		return primitive.NilObjectID, errors.New(errors.CodeInvalidToken, "Invalid sign-in link")
+	}
+	return verification.UserID, nil
+}
+
+// ConsumeCode uses up the code emailed to a user. A wrong code returns
+// CodeInvalidCredentials, for the caller to count as a failed login.
+func (s *PasswordlessService) ConsumeCode(ctx context.Context, userID primitive.ObjectID, code string) error {
+	verification, err := s.verificationRepo.FindByUserID(ctx, userID, verificationLoginCode)
+	if err != nil && !stderrors.Is(err, mongo.ErrNoDocuments) {
+		return errors.Wrap(err, "Failed to find login code")
+	}
+	if err != nil || verification.Status != verificationPending {
+		return errors.New(errors.CodeInvalidCredentials, "Invalid email or code")
+	}
+	if s.now().After(verification.ExpiresAt) {
+		return errors.New(errors.CodeInvalidToken, "Code has expired, request a new one")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashLoginSecret(strings.TrimSpace(code))), []byte(verification.VerificationCode)) != 1 {
+		return errors.New(errors.CodeInvalidCredentials, "Invalid email or code")
+	}
+
+	used, err := s.use(ctx, verification, verificationLoginLink)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.New(errors.CodeInvalidCredentials, "Invalid email or code")
+	}
+	return nil
+}
+
+// allowRequest reports whether the account may be sent another login email
+func (s *PasswordlessService) allowRequest(ctx context.Context, userID primitive.ObjectID, now time.Time) (bool, error) {
+	requests, err := s.verificationRepo.CountSince(ctx, userID, verificationLoginCode, now.Add(-s.config.RequestWindow))
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to count login requests")
+	}
+	if requests >= s.config.MaxRequests {
+		return false, nil
+	}
+
+	last, err := s.verificationRepo.FindByUserID(ctx, userID, verificationLoginCode)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return true, nil
+		}
+		return false, errors.Wrap(err, "Failed to find login code")
+	}
+	return !now.Before(last.CreatedAt.Add(s.config.ResendDelay)), nil
+}
+
+// use marks a link or code used, along with the other half of its request.
+// It reports false when another request used the record first, or it
+// expired or was superseded since it was read.
+func (s *PasswordlessService) use(ctx context.Context, verification *models.Verification, otherType string) (bool, error) {
+	if _, err := s.verificationRepo.Redeem(ctx, verification.ID, s.now()); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "Failed to update verification record")
+	}
+	return true, s.retire(ctx, verification.UserID, otherType, verificationUsed)
+}
+
+// retire moves the user's latest pending record of a type to status
+func (s *PasswordlessService) retire(ctx context.Context, userID primitive.ObjectID, type_, status string) error {
+	verification, err := s.verificationRepo.FindByUserID(ctx, userID, type_)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find verification record")
+	}
+	if verification.Status != verificationPending {
+		return nil
+	}
+
+	verification.Status = status
+	if err := s.verificationRepo.Update(ctx, verification); err != nil {
+		return errors.Wrap(err, "Failed to update verification record")
+	}
+	return nil
+}
+
+// link returns the page of the web client that completes a login with token
+func (s *PasswordlessService) link(token string) string {
+	link, err := url.Parse(s.config.LinkURL)
+	if err != nil {
+		return s.config.LinkURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+func hashLoginSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// requestPasswordless asks for a passwordless login and returns the link
+// token and code emailed for it
+func requestPasswordless(t *testing.T, env *testEnv, user *models.User) (string, string) {
+	t.Helper()
+	if err := env.auth.RequestPasswordlessLogin(context.Background(), user.Email, testIP); err != nil {
+		t.Fatalf("request passwordless login: %v", err)
+	}
+
+	email := env.mail.Last(t, user.Email)
+	link, err := url.Parse(email.Data["LoginLink"].(string))
+	if err != nil {
+		t.Fatalf("parse login link: %v", err)
+	}
+	return link.Query().Get("token"), email.Data["Code"].(string)
+}
+
+// loginWithLink signs in with the emailed link
+func loginWithLink(env *testEnv, _ *models.User, token, _ string) error {
+	_, err := env.auth.LoginWithLink(context.Background(), token, testUserAgent, testIP)
+	return err
+}
+
+// loginWithCode signs in with the emailed code
+func loginWithCode(env *testEnv, user *models.User, _, code string) error {
+	_, err := env.auth.LoginWithCode(context.Background(), user.Email, code, testUserAgent, testIP)
+	return err
+}
+
+func TestPasswordlessLinkAndCodeAreSingleUse(t *testing.T) {
+	tests := map[string]struct {
+		first  func(env *testEnv, user *models.User, token, code string) error
+		second func(env *testEnv, user *models.User, token, code string) error
+	}{
+		"link twice":     {first: loginWithLink, second: loginWithLink},
+		"link then code": {first: loginWithLink, second: loginWithCode},
+		"code twice":     {first: loginWithCode, second: loginWithCode},
+		"code then link": {first: loginWithCode, second: loginWithLink},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := registerUser(t, env, "emailed")
+			token, code := requestPasswordless(t, env, user)
+
+			if err := tt.first(env, user, token, code); err != nil {
+				t.Fatalf("first login: %v", err)
+			}
+			if err := tt.second(env, user, token, code); err == nil {
+				t.Fatal("second login with the same email was accepted")
+			}
+		})
+	}
+}
+
+func TestPasswordlessLinkExpires(t *testing.T) {
+	env := newTestEnv(t)
+	user := registerUser(t, env, "late")
+	token, _ := requestPasswordless(t, env, user)
+
+	_, err := env.db.Collection(constants.CollectionVerifications).UpdateMany(
+		bson.M{"user_id": user.ID},
+		bson.M{"$set": bson.M{"expires_at": time.Now().Add(-time.Minute)}},
+	)
+	if err != nil {
+		t.Fatalf("expire login email: %v", err)
+	}
+
+	if err := loginWithLink(env, user, token, ""); err == nil {
+		t.Fatal("expired link was accepted")
+	}
+}
+
+func TestPasswordlessLinkSupersededByNewerRequest(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Passwordless.ResendDelay = 0
+	user := registerUser(t, env, "impatient")
+
+	token, _ := requestPasswordless(t, env, user)
+	newer, _ := requestPasswordless(t, env, user)
+
+	if err := loginWithLink(env, user, token, ""); err == nil {
+		t.Fatal("superseded link was accepted")
+	}
+	if err := loginWithLink(env, user, newer, ""); err != nil {
+		t.Fatalf("login with the newer link: %v", err)
+	}
+}
+
+// racingVerifications lets another request use a link between the service
+// reading it and marking it used
+type racingVerifications struct {
+	auth.VerificationRepository
+	race func()
+}
+
+func (r *racingVerifications) FindByToken(ctx context.Context, token, type_ string) (*models.Verification, error) {
+	verification, err := r.VerificationRepository.FindByToken(ctx, token, type_)
+	if err == nil && r.race != nil {
+		r.race()
+		r.race = nil
+	}
+	return verification, err
+}
+
+func TestPasswordlessLinkUsedConcurrentlySignsInOnce(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := registerUser(t, env, "doubleclick")
+	token, _ := requestPasswordless(t, env, user)
+
+	repos := env.repositories()
+	verifications := &racingVerifications{VerificationRepository: repos.Verifications}
+	verifications.race = func() {
+		if _, err := env.auth.LoginWithLink(ctx, token, testUserAgent, testIP); err != nil {
+			t.Fatalf("concurrent login: %v", err)
+		}
+	}
+	repos.Verifications = verifications
+	service := env.newService(t, repos, nil)
+
+	if _, err := service.LoginWithLink(ctx, token, testUserAgent, testIP); err == nil {
+		t.Fatal("link signed in twice")
+	}
+	if got := env.count(t, constants.CollectionSessions, bson.M{"user_id": user.ID}); got != 1 {
+		t.Fatalf("%d sessions, want 1", got)
+	}
+}
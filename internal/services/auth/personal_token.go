@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// PersonalTokenPrefix starts every personal access token, so the API can
+// tell them from session tokens and secret scanners can find leaked ones
+const PersonalTokenPrefix = "vyp_"
+
+const (
+	// maxPersonalTokenNameLength bounds the name a token is listed under
+	maxPersonalTokenNameLength = 64
+
+	// maxAllowedIPs bounds the allowlist of a token
+	maxAllowedIPs = 20
+
+	// personalTokenUsageDays is how far back a token's usage is listed
+	personalTokenUsageDays = 30
+)
+
+// PersonalTokenScopes are the scopes a personal access token can be granted
+var PersonalTokenScopes = map[string]string{
+	constants.ScopePostsRead:          "See posts",
+	constants.ScopePostsWrite:         "Create, edit and delete posts",
+	constants.ScopeCommentsRead:       "See comments",
+	constants.ScopeCommentsWrite:      "Comment and reply",
+	constants.ScopeMessagesRead:       "Read direct messages",
+	constants.ScopeMessagesWrite:      "Send direct messages",
+	constants.ScopeUsersRead:          "See profiles, followers and who you follow",
+	constants.ScopeUsersWrite:         "Update your profile and follow or unfollow people",
+	constants.ScopeNotificationsRead:  "See notifications",
+	constants.ScopeNotificationsWrite: "Mark notifications as read",
+	constants.ScopeMediaRead:          "See uploaded photos and videos",
+	constants.ScopeMediaWrite:         "Upload photos and videos",
+	constants.ScopeBusinessRead:       "See business profiles, ads, campaigns and analytics",
+	constants.ScopeBusinessWrite:      "Manage business profiles, ads and campaigns",
+}
+
+// PersonalTokenInput is what a user supplies when creating a personal access token
+type PersonalTokenInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	AllowedIPs    []string `json:"allowed_ips"`     // Addresses or CIDR ranges; any address when empty
+	ExpiresInDays int      `json:"expires_in_days"` // The configured default when zero
+}
+
+// PersonalTokenService manages the long-lived personal access tokens users
+// create to call the API from scripts. Tokens are stored hashed, carry
+// scopes and an expiry, and can be limited to a set of addresses. Every
+// request made with a token is counted per day and address for auditing.
+type PersonalTokenService struct {
+	repo   PersonalTokenRepository
+	config *config.PersonalTokenConfig
+	logger *logger.Logger
+}
+
+// PersonalTokenRepository handles personal access token storage
+type PersonalTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.PersonalAccessToken, error)
+	FindByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error)
+	CountActiveByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (int, error)
+	RecordUse(ctx context.Context, id primitive.ObjectID, usedAt time.Time, ipAddress string) error
+	Revoke(ctx context.Context, id, userID primitive.ObjectID, revokedAt time.Time) error
+	RecordUsage(ctx context.Context, usage *models.PersonalAccessTokenUsage) error
+	FindUsage(ctx context.Context, tokenID primitive.ObjectID, since time.Time) ([]*models.PersonalAccessTokenUsage, error)
+}
+
+// NewPersonalTokenService creates a new personal access token service
+func NewPersonalTokenService(repo PersonalTokenRepository, config *config.PersonalTokenConfig, logger *logger.Logger) *PersonalTokenService {
+	return &PersonalTokenService{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Create issues a personal access token. The token is returned once and
+// only its hash is kept.
+func (s *PersonalTokenService) Create(ctx context.Context, userID primitive.ObjectID, input *PersonalTokenInput) (*models.PersonalAccessToken, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxPersonalTokenNameLength {
+		return nil, "", errors.New(errors.CodeInvalidArgument, "Token name is required and must be at most 64 characters")
+	}
+
+	scopes := normalizeScopes(input.Scopes)
+	if len(scopes) == 0 {
+		return nil, "", errors.New(errors.CodeInvalidArgument, "At least one scope is required")
+	}
+	for _, scope := range scopes {
+		if _, ok := PersonalTokenScopes[scope]; !ok {
+			return nil, "", errors.New(errors.CodeInvalidArgument, "Unknown scope "+scope)
+		}
+	}
+
+	if len(input.AllowedIPs) > maxAllowedIPs {
+		return nil, "", errors.New(errors.CodeInvalidArgument, "At most 20 allowed addresses can be set")
+	}
+	allowedIPs := make([]string, 0, len(input.AllowedIPs))
+	for _, entry := range input.AllowedIPs {
+		normalized, err := normalizeIPEntry(entry)
+		if err != nil {
+			return nil, "", err
+		}
+		allowedIPs = append(allowedIPs, normalized)
+	}
+
+	lifetime := s.config.DefaultLifetime
+	if input.ExpiresInDays != 0 {
+		lifetime = time.Duration(input.ExpiresInDays) * 24 * time.Hour
+		if input.ExpiresInDays < 0 || lifetime > s.config.MaxLifetime {
+			return nil, "", errors.New(errors.CodeInvalidArgument, "Tokens can be valid for at most "+formatDays(s.config.MaxLifetime))
+		}
+	}
+
+	now := time.Now()
+	count, err := s.repo.CountActiveByUserID(ctx, userID, now)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to count tokens")
+	}
+	if count >= s.config.MaxPerUser {
+		return nil, "", errors.New(errors.CodeInvalidOperation, "You have reached the maximum number of active tokens")
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to generate token")
+	}
+	token := PersonalTokenPrefix + secret
+
+	created, err := s.repo.Create(ctx, &models.PersonalAccessToken{
+		UserID:     userID,
+		Name:       name,
+		TokenHash:  hashOAuthToken(token),
+		Prefix:     token[:len(PersonalTokenPrefix)+6],
+		Scopes:     scopes,
+		AllowedIPs: allowedIPs,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(lifetime),
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Failed to create token")
+	}
+
+	s.logger.Info("Personal access token created", "userId", userID.Hex(), "tokenId", created.ID.Hex(), "scope", strings.Join(scopes, " "))
+	return created, token, nil
+}
+
+// List returns a user's personal access tokens, newest first, including
+// revoked and expired ones
+func (s *PersonalTokenService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error) {
+	tokens, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve tokens")
+	}
+	return tokens, nil
+}
+
+// Usage returns the requests one of a user's tokens made in the last 30 days,
+// per day and address
+func (s *PersonalTokenService) Usage(ctx context.Context, userID, tokenID primitive.ObjectID) ([]*models.PersonalAccessTokenUsage, error) {
+	token, err := s.repo.FindByID(ctx, tokenID)
+	if err != nil || token.UserID != userID {
+		return nil, errors.New(errors.CodeNotFound, "Token not found")
+	}
+
+	since := usageDay(time.Now()).AddDate(0, 0, -personalTokenUsageDays)
+	usage, err := s.repo.FindUsage(ctx, tokenID, since)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve token usage")
+	}
+	return usage, nil
+}
+
+// Revoke revokes one of a user's tokens. It stays listed so its usage can
+// still be audited.
+func (s *PersonalTokenService) Revoke(ctx context.Context, userID, tokenID primitive.ObjectID) error {
+	if err := s.repo.Revoke(ctx, tokenID, userID, time.Now()); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New(errors.CodeNotFound, "Token not found or already revoked")
+		}
+		return errors.Wrap(err, "Failed to revoke token")
+	}
+
+	s.logger.Info("Personal access token revoked", "userId", userID.Hex(), "tokenId", tokenID.Hex())
+	return nil
+}
+
+// Authenticate checks a personal access token presented from ipAddress and
+// records the request. The owner's account status is not checked here.
+func (s *PersonalTokenService) Authenticate(ctx context.Context, token, ipAddress, userAgent string) (*AccessToken, error) {
+	if !strings.HasPrefix(token, PersonalTokenPrefix) {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid token")
+	}
+
+	record, err := s.repo.FindByHash(ctx, hashOAuthToken(token))
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid token")
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil {
+		return nil, errors.New(errors.CodeInvalidToken, "Token has been revoked")
+	}
+	if !now.Before(record.ExpiresAt) {
+		return nil, errors.New(errors.CodeInvalidToken, "Token has expired")
+	}
+	if !ipAllowed(record.AllowedIPs, ipAddress) {
+		s.logger.Warn("Personal access token used from a disallowed address", "tokenId", record.ID.Hex(), "ip", ipAddress)
+		return nil, errors.New(errors.CodeInvalidToken, "Token cannot be used from this address")
+	}
+
+	s.recordUsage(ctx, record, now, ipAddress, userAgent)
+
+	return &AccessToken{
+		UserID:          record.UserID,
+		ExpiresAt:       record.ExpiresAt,
+		Scopes:          record.Scopes,
+		PersonalTokenID: record.ID.Hex(),
+	}, nil
+}
+
+// recordUsage counts the request for auditing and, at most once per
+// configured interval, updates the token's last-used time. Failures are
+// logged rather than failing the request.
+func (s *PersonalTokenService) recordUsage(ctx context.Context, record *models.PersonalAccessToken, now time.Time, ipAddress, userAgent string) {
+	day := usageDay(now)
+	err := s.repo.RecordUsage(ctx, &models.PersonalAccessTokenUsage{
+		TokenID:    record.ID,
+		UserID:     record.UserID,
+		Day:        day,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		LastUsedAt: now,
+		ExpiresAt:  day.Add(s.config.UsageRetention),
+	})
+	if err != nil {
+		s.logger.Warn("Failed to record personal access token usage", "tokenId", record.ID.Hex(), "error", err)
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= s.config.LastUsedInterval || record.LastUsedIP != ipAddress {
+		if err := s.repo.RecordUse(ctx, record.ID, now, ipAddress); err != nil {
+			s.logger.Warn("Failed to record personal access token use", "tokenId", record.ID.Hex(), "error", err)
+		}
+	}
+}
+
+// normalizeIPEntry validates an allowlist entry, returning addresses in
+// canonical form and ranges as their network
+func normalizeIPEntry(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return "", errors.New(errors.CodeInvalidArgument, "Invalid address range: "+entry)
+		}
+		return network.String(), nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return "", errors.New(errors.CodeInvalidArgument, "Invalid address: "+entry)
+	}
+	return ip.String(), nil
+}
+
+// ipAllowed reports whether ipAddress matches an allowlist. An empty
+// allowlist allows every address.
+func ipAllowed(allowed []string, ipAddress string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// usageDay truncates a time to midnight UTC
+func usageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func formatDays(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	if days == 1 {
+		return "1 day"
+	}
+	return strconv.Itoa(days) + " days"
+}
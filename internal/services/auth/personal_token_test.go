@@ -0,0 +1,114 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// createPersonalToken creates a token for a new user that may only be used
+// from allowedIPs
+func createPersonalToken(t *testing.T, env *testEnv, allowedIPs ...string) (*models.PersonalAccessToken, string) {
+	t.Helper()
+	user := registerUser(t, env, "scripter")
+	record, token, err := env.auth.CreatePersonalToken(context.Background(), user.ID, &auth.PersonalTokenInput{
+		Name:       "deploy script",
+		Scopes:     []string{constants.ScopePostsRead},
+		AllowedIPs: allowedIPs,
+	})
+	if err != nil {
+		t.Fatalf("create personal token: %v", err)
+	}
+	return record, token
+}
+
+func TestPersonalTokenIPAllowlist(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := createPersonalToken(t, env, "192.0.2.10", "198.51.100.0/24", "2001:db8::/32")
+
+	for ipAddress, allowed := range map[string]bool{
+		"192.0.2.10":        true,
+		"::ffff:192.0.2.10": true,
+		"198.51.100.77":     true,
+		"2001:db8::1":       true,
+		"192.0.2.11":        false,
+		"203.0.113.9":       false,
+		"2001:db9::1":       false,
+		"":                  false,
+		"not-an-address":    false,
+	} {
+		_, err := env.auth.AuthenticatePersonalToken(context.Background(), token, ipAddress, testUserAgent)
+		if allowed && err != nil {
+			t.Errorf("token used from %q: %v, want it allowed", ipAddress, err)
+		}
+		if !allowed && err == nil {
+			t.Errorf("token used from %q was allowed", ipAddress)
+		}
+	}
+}
+
+func TestPersonalTokenWithoutAllowlistWorksAnywhere(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := createPersonalToken(t, env)
+
+	for _, ipAddress := range []string{"192.0.2.10", "2001:db8::1"} {
+		if _, err := env.auth.AuthenticatePersonalToken(context.Background(), token, ipAddress, testUserAgent); err != nil {
+			t.Errorf("token used from %s: %v", ipAddress, err)
+		}
+	}
+}
+
+func TestPersonalTokenRejectsInvalidAllowlist(t *testing.T) {
+	env := newTestEnv(t)
+	user := registerUser(t, env, "scripter")
+
+	for _, entry := range []string{"192.0.2.256", "192.0.2.0/33", "example.com", ""} {
+		_, _, err := env.auth.CreatePersonalToken(context.Background(), user.ID, &auth.PersonalTokenInput{
+			Name:       "deploy script",
+			Scopes:     []string{constants.ScopePostsRead},
+			AllowedIPs: []string{entry},
+		})
+		if err == nil {
+			t.Errorf("allowlist entry %q was accepted", entry)
+		}
+	}
+}
+
+func TestPersonalTokenRecordsUsageByAddress(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	record, token := createPersonalToken(t, env)
+
+	for _, ipAddress := range []string{"192.0.2.10", "192.0.2.10", "198.51.100.7"} {
+		if _, err := env.auth.AuthenticatePersonalToken(ctx, token, ipAddress, testUserAgent); err != nil {
+			t.Fatalf("token used from %s: %v", ipAddress, err)
+		}
+	}
+
+	usage, err := env.auth.PersonalTokenUsage(ctx, record.UserID, record.ID)
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	requests := map[string]int64{}
+	for _, u := range usage {
+		requests[u.IPAddress] += u.Requests
+	}
+	if len(requests) != 2 || requests["192.0.2.10"] != 2 || requests["198.51.100.7"] != 1 {
+		t.Fatalf("requests by address = %v, want 2 from 192.0.2.10 and 1 from 198.51.100.7", requests)
+	}
+
+	// Only the owner sees the usage, and a revoked token stops working
+	other := registerUser(t, env, "auditor")
+	if _, err := env.auth.PersonalTokenUsage(ctx, other.ID, record.ID); err == nil {
+		t.Fatal("another user read the token's usage")
+	}
+	if err := env.auth.RevokePersonalToken(ctx, record.UserID, record.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := env.auth.AuthenticatePersonalToken(ctx, token, "192.0.2.10", testUserAgent); err == nil {
+		t.Fatal("revoked token was accepted")
+	}
+}
@@ -2,13 +2,18 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"time"
 
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
+// refreshTokenBytes is the amount of randomness in a refresh token
+const refreshTokenBytes = 32
+
 // RefreshTokenService handles refresh token operations
 type RefreshTokenService struct {
 	config *config.JWTConfig
@@ -24,7 +29,7 @@ func NewRefreshTokenService(config *config.JWTConfig) *RefreshTokenService {
 // GenerateRefreshToken generates a new refresh token
 func (s *RefreshTokenService) GenerateRefreshToken() (string, error) {
 	// Generate random bytes
-	b := make([]byte, 32)
+	b := make([]byte, refreshTokenBytes)
 	_, err := rand.Read(b)
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to generate random bytes")
@@ -36,25 +41,31 @@ func (s *RefreshTokenService) GenerateRefreshToken() (string, error) {
 	return token, nil
 }
 
-// ValidateRefreshToken validates a refresh token
+// ValidateRefreshToken checks that a token has the shape GenerateRefreshToken
+// produces, so malformed input is rejected before it reaches the database.
+// Whether the token is current is decided by the auth service.
 func (s *RefreshTokenService) ValidateRefreshToken(token string) (bool, error) {
-	// Refresh tokens are validated against the database in the auth service
-	// This method is more of a placeholder for any additional validation logic
-
 	// Check if token is empty
 	if token == "" {
 		return false, errors.New(errors.CodeInvalidToken, "Refresh token is empty")
 	}
 
-	// Decode base64 to check format
-	_, err := base64.URLEncoding.DecodeString(token)
-	if err != nil {
+	// Decode base64 to check format and length
+	b, err := base64.URLEncoding.DecodeString(token)
+	if err != nil || len(b) != refreshTokenBytes {
 		return false, errors.New(errors.CodeInvalidToken, "Invalid refresh token format")
 	}
 
 	return true, nil
 }
 
+// HashRefreshToken returns the SHA-256 hex digest sessions store instead of
+// the token. Refresh tokens are random, so an unsalted fast hash is enough.
+func (s *RefreshTokenService) HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetExpirationTime returns the expiration time for refresh tokens
 func (s *RefreshTokenService) GetExpirationTime() time.Time {
 	return time.Now().Add(time.Duration(s.config.RefreshExpirationDays) * 24 * time.Hour)
@@ -0,0 +1,150 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// login signs a registered user in with the test password
+func login(t *testing.T, env *testEnv, user *models.User) *models.Session {
+	t.Helper()
+	session, err := env.auth.Login(context.Background(), user.Email, testPassword, testUserAgent, testIP)
+	if err != nil {
+		t.Fatalf("login %s: %v", user.Username, err)
+	}
+	return session
+}
+
+// assertRevoked fails the test unless the session was revoked for reason
+func assertRevoked(t *testing.T, env *testEnv, id primitive.ObjectID, reason string) {
+	t.Helper()
+	stored, err := env.repos.Sessions.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("find session: %v", err)
+	}
+	if stored.IsActive || stored.RevokedAt == nil || stored.RevokedReason != reason {
+		t.Fatalf("session = %+v, want it revoked for %s", stored, reason)
+	}
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	session := login(t, env, registerUser(t, env, "rotating"))
+
+	refreshed, err := env.auth.RefreshToken(ctx, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if refreshed.ID != session.ID || refreshed.RefreshToken == session.RefreshToken || refreshed.Token == "" {
+		t.Fatalf("refreshed = %+v, want the same session with a new refresh token", refreshed)
+	}
+
+	// The new refresh token can be exchanged in turn
+	if _, err := env.auth.RefreshToken(ctx, refreshed.RefreshToken); err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	session := login(t, env, registerUser(t, env, "replayed"))
+
+	refreshed, err := env.auth.RefreshToken(ctx, session.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	// Presenting the retired token again means it was copied
+	if _, err := env.auth.RefreshToken(ctx, session.RefreshToken); err == nil {
+		t.Fatal("retired refresh token was exchanged")
+	}
+	assertRevoked(t, env, session.ID, auth.RevokedReasonTokenReuse)
+	if got := env.count(t, constants.CollectionOutbox, bson.M{"type": constants.EventSessionTokenReused}); got != 1 {
+		t.Fatalf("%d token reuse events, want 1", got)
+	}
+
+	// The thief's copy dies with the session, and replaying again is harmless
+	if _, err := env.auth.RefreshToken(ctx, refreshed.RefreshToken); err == nil {
+		t.Fatal("refresh token of a revoked session was exchanged")
+	}
+	if _, err := env.auth.RefreshToken(ctx, session.RefreshToken); err == nil {
+		t.Fatal("retired refresh token was exchanged")
+	}
+	if got := env.count(t, constants.CollectionOutbox, bson.M{"type": constants.EventSessionTokenReused}); got != 1 {
+		t.Fatalf("%d token reuse events, want 1", got)
+	}
+}
+
+// racingSessions lets another request exchange a refresh token between the
+// service finding the session and rotating its token
+type racingSessions struct {
+	auth.SessionRepository
+	race func(session *models.Session)
+}
+
+func (r *racingSessions) FindByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	session, err := r.SessionRepository.FindByRefreshTokenHash(ctx, hash)
+	if err == nil && r.race != nil {
+		race := *session
+		r.race(&race)
+		r.race = nil
+	}
+	return session, err
+}
+
+func TestRefreshTokenConcurrentExchangeRevokesSession(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	session := login(t, env, registerUser(t, env, "raced"))
+
+	repos := env.repositories()
+	sessions := &racingSessions{SessionRepository: repos.Sessions}
+	sessions.race = func(other *models.Session) {
+		previous := other.RefreshTokenHash
+		other.RefreshTokenHash = "exchanged-by-another-request"
+		if err := env.repos.Sessions.RotateRefreshToken(ctx, other, previous); err != nil {
+			t.Fatalf("concurrent rotation: %v", err)
+		}
+	}
+	repos.Sessions = sessions
+	service := env.newService(t, repos, nil)
+
+	if _, err := service.RefreshToken(ctx, session.RefreshToken); err == nil {
+		t.Fatal("refresh token exchanged twice")
+	}
+	assertRevoked(t, env, session.ID, auth.RevokedReasonTokenReuse)
+}
+
+func TestRefreshTokenRejectsUnknownTokens(t *testing.T) {
+	env := newTestEnv(t)
+	session := login(t, env, registerUser(t, env, "unknown"))
+
+	for name, token := range map[string]string{
+		"empty":     "",
+		"malformed": "not-a-refresh-token",
+		"access":    session.Token,
+		"truncated": session.RefreshToken[:len(session.RefreshToken)-1],
+	} {
+		if _, err := env.auth.RefreshToken(context.Background(), token); err == nil {
+			t.Errorf("%s token was exchanged", name)
+		}
+	}
+
+	// Guessing is not mistaken for reuse
+	stored, err := env.repos.Sessions.FindByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("find session: %v", err)
+	}
+	if !stored.IsActive || stored.RevokedAt != nil {
+		t.Fatalf("session = %+v, want it still active", stored)
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Repositories holds the repositories the auth services are written against.
+// All but the user repository are the shared ones as they are.
+type Repositories struct {
+	Users          UserRepository
+	Sessions       SessionRepository
+	Verifications  VerificationRepository
+	TwoFactor      TwoFactorRepository
+	Passkeys       PasskeyRepository
+	LoginAttempts  LoginAttemptRepository
+	LoginHistory   LoginHistoryRepository
+	OAuthApps      OAuthAppRepository
+	OAuthGrants    OAuthGrantRepository
+	PersonalTokens PersonalTokenRepository
+	AdminAudit     AdminAuditRepository
+}
+
+// NewUserRepository adapts the shared user repository to the one the auth
+// services are written against
+func NewUserRepository(users interfaces.UserRepository) UserRepository {
+	return &userRepository{users: users}
+}
+
+// userRepository implements UserRepository
+type userRepository struct {
+	users interfaces.UserRepository
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := r.users.GetByID(ctx, id)
+	return user, notFound(err, "User not found")
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := r.users.GetByEmail(ctx, email)
+	return user, notFound(err, "User not found")
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	user, err := r.users.GetByUsername(ctx, username)
+	return user, notFound(err, "User not found")
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	if _, err := r.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return notFound(r.users.Update(ctx, user), "User not found")
+}
+
+// notFound turns a missing document into the not found error the services check for
+func notFound(err error, message string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.New(errors.CodeNotFound, message)
+	}
+	return err
+}
@@ -2,18 +2,23 @@ package auth
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	stderrors "errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
-
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // Service represents the authentication service interface
@@ -21,10 +26,14 @@ type Service interface {
 	// Authentication
 	Login(ctx context.Context, email, password, userAgent, ipAddress string) (*models.Session, error)
 	Register(ctx context.Context, user *models.User, password string) (*models.User, error)
+	GrantParentalConsent(ctx context.Context, token string) error
+	ResendParentalConsent(ctx context.Context, email, password, ipAddress string) error
 	Logout(ctx context.Context, sessionID string) error
 
 	// Token management
 	ValidateToken(ctx context.Context, token string) (*primitive.ObjectID, error)
+	Authenticate(ctx context.Context, token string) (*AccessToken, error)
+	AuthenticatePersonalToken(ctx context.Context, token, ipAddress, userAgent string) (*AccessToken, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*models.Session, error)
 
 	// OAuth functions
@@ -44,14 +53,52 @@ type Service interface {
 	DisableTwoFactor(ctx context.Context, userID primitive.ObjectID, code string) error
 	TwoFactorRecoveryCodes(ctx context.Context, userID primitive.ObjectID) ([]string, error)
 
+	// Passkeys
+	BeginPasskeyRegistration(ctx context.Context, userID primitive.ObjectID) (*webauthn.CreationOptions, error)
+	FinishPasskeyRegistration(ctx context.Context, userID primitive.ObjectID, name, userAgent string, response *webauthn.CredentialCreationResponse) (*models.Passkey, error)
+	ListPasskeys(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error)
+	RenamePasskey(ctx context.Context, userID, passkeyID primitive.ObjectID, name string) error
+	DeletePasskey(ctx context.Context, userID, passkeyID primitive.ObjectID) error
+	BeginPasskeyLogin(ctx context.Context) (*webauthn.RequestOptions, error)
+	FinishPasskeyLogin(ctx context.Context, response *webauthn.CredentialAssertionResponse, userAgent, ipAddress string) (*models.Session, error)
+	BeginPasskeyTwoFactor(ctx context.Context, pendingToken string) (*webauthn.RequestOptions, error)
+	FinishPasskeyTwoFactor(ctx context.Context, pendingToken string, response *webauthn.CredentialAssertionResponse) (*models.Session, error)
+
+	// Passwordless login
+	RequestPasswordlessLogin(ctx context.Context, email, ipAddress string) error
+	LoginWithLink(ctx context.Context, token, userAgent, ipAddress string) (*models.Session, error)
+	LoginWithCode(ctx context.Context, email, code, userAgent, ipAddress string) (*models.Session, error)
+	DisablePassword(ctx context.Context, userID primitive.ObjectID, password string) error
+	EnablePassword(ctx context.Context, userID primitive.ObjectID, newPassword string) error
+
+	// Login protection
+	VerifyLoginCode(ctx context.Context, pendingToken, code string) (*models.Session, error)
+	GetLoginLockout(ctx context.Context, userID primitive.ObjectID) (*models.LoginLockout, error)
+	UnlockAccount(ctx context.Context, userID, adminID primitive.ObjectID) error
+	RevokeFlaggedSession(ctx context.Context, token string) error
+
+	// Personal access tokens
+	CreatePersonalToken(ctx context.Context, userID primitive.ObjectID, input *PersonalTokenInput) (*models.PersonalAccessToken, string, error)
+	ListPersonalTokens(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error)
+	PersonalTokenUsage(ctx context.Context, userID, tokenID primitive.ObjectID) ([]*models.PersonalAccessTokenUsage, error)
+	RevokePersonalToken(ctx context.Context, userID, tokenID primitive.ObjectID) error
+
+	// Impersonation
+	StartImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error)
+	ElevateImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error)
+	EndImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, userAgent, ipAddress string) error
+	RecordImpersonatedRequest(ctx context.Context, token *AccessToken, method, path string, statusCode int, userAgent, ipAddress string)
+
 	// Session management
 	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
 	GetUserSessions(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error)
 	RevokeSession(ctx context.Context, sessionID string) error
 	RevokeAllUserSessions(ctx context.Context, userID primitive.ObjectID) error
 	ExtendSession(ctx context.Context, sessionID string) error
+	CleanupExpiredSessions(ctx context.Context) error
 
 	// Account management
+	RequestAccountDeletion(ctx context.Context, userID primitive.ObjectID, password string) (time.Time, error)
 	VerifyEmail(ctx context.Context, token string) error
 	RequestEmailVerification(ctx context.Context, userID primitive.ObjectID) error
 	UpdateUserStatus(ctx context.Context, userID primitive.ObjectID, status string) error
@@ -66,11 +113,82 @@ type AuthService struct {
 	refresh          *RefreshTokenService
 	session          *SessionService
 	twoFactor        *TwoFactorService
+	passkey          *PasskeyService
+	guard            *LoginGuardService
+	apps             *OAuthServerService
+	tokens           *PersonalTokenService
+	risk             *LoginRiskService
+	passwordless     *PasswordlessService
+	impersonation    *ImpersonationService
+	consent          *ParentalConsentService
+	alerts           *AlertsService
+	emailService     EmailService
 	userRepo         UserRepository
 	sessionRepo      SessionRepository
 	verificationRepo VerificationRepository
+	tx               interfaces.Transactor
+	events           eventbus.Publisher
 	config           *config.AuthConfig
-	logger           logging.Logger
+	logger           *logger.Logger
+}
+
+// NewService builds the authentication service and every service behind it.
+// geoIP and breached are nil when no GeoIP database or breached-password
+// corpus is configured. Call Subscribe to have security alerts follow domain
+// events.
+func NewService(
+	repos *Repositories,
+	keys *keyring.Keyring,
+	rp *webauthn.RelyingParty,
+	geoIP GeoIPService,
+	breached BreachedPasswordChecker,
+	emailService EmailService,
+	notifier *notification.Service,
+	tx interfaces.Transactor,
+	publisher eventbus.Publisher,
+	cfg *config.Config,
+	logger *logger.Logger,
+) *AuthService {
+	jwt := NewJWTService(&cfg.JWT, keys)
+	refresh := NewRefreshTokenService(&cfg.JWT)
+	session := NewSessionService(repos.Sessions, *jwt, *refresh, geoIP, &cfg.Session)
+	risk := NewLoginRiskService(repos.LoginHistory, geoIP, tx, publisher, cfg.JWT.Secret, &cfg.LoginRisk, logger)
+
+	return &AuthService{
+		jwt:              jwt,
+		oauth:            NewOAuthService(&cfg.OAuth),
+		password:         NewPasswordService(repos.Users, repos.Verifications, emailService, breached, &cfg.Password, logger),
+		refresh:          refresh,
+		session:          session,
+		twoFactor:        NewTwoFactorService(repos.Users, repos.TwoFactor, emailService, &cfg.TwoFactor),
+		passkey:          NewPasskeyService(repos.Passkeys, repos.Users, rp, &cfg.WebAuthn, logger),
+		guard:            NewLoginGuardService(repos.LoginAttempts, emailService, tx, publisher, &cfg.LoginGuard, logger),
+		apps:             NewOAuthServerService(repos.OAuthApps, repos.OAuthGrants, repos.Users, jwt, tx, &cfg.OAuthServer, logger),
+		tokens:           NewPersonalTokenService(repos.PersonalTokens, &cfg.PersonalToken, logger),
+		risk:             risk,
+		passwordless:     NewPasswordlessService(repos.Verifications, emailService, &cfg.Passwordless, logger),
+		impersonation:    NewImpersonationService(repos.Sessions, repos.AdminAudit, jwt, session, &cfg.Session, logger),
+		consent:          NewParentalConsentService(repos.Users, repos.Verifications, emailService, &cfg.Auth, logger),
+		alerts:           NewAlertsService(notifier, emailService, repos.Users, risk, logger),
+		emailService:     emailService,
+		userRepo:         repos.Users,
+		sessionRepo:      repos.Sessions,
+		verificationRepo: repos.Verifications,
+		tx:               tx,
+		events:           publisher,
+		config:           &cfg.Auth,
+		logger:           logger,
+	}
+}
+
+// Subscribe registers the security alerts with the event bus
+func (s *AuthService) Subscribe(bus *eventbus.Bus) {
+	s.alerts.Subscribe(bus)
+}
+
+// OAuthServer returns the authorization server third-party apps use
+func (s *AuthService) OAuthServer() *OAuthServerService {
+	return s.apps
 }
 
 // NewAuthService creates a new instance of AuthService
@@ -81,11 +199,22 @@ func NewAuthService(
 	refresh *RefreshTokenService,
 	session *SessionService,
 	twoFactor *TwoFactorService,
+	passkey *PasskeyService,
+	guard *LoginGuardService,
+	apps *OAuthServerService,
+	tokens *PersonalTokenService,
+	risk *LoginRiskService,
+	passwordless *PasswordlessService,
+	impersonation *ImpersonationService,
+	consent *ParentalConsentService,
+	emailService EmailService,
 	userRepo UserRepository,
 	sessionRepo SessionRepository,
 	verificationRepo VerificationRepository,
+	tx interfaces.Transactor,
+	events eventbus.Publisher,
 	config *config.AuthConfig,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) Service {
 	return &AuthService{
 		jwt:              jwt,
@@ -94,58 +223,483 @@ func NewAuthService(
 		refresh:          refresh,
 		session:          session,
 		twoFactor:        twoFactor,
+		passkey:          passkey,
+		guard:            guard,
+		apps:             apps,
+		tokens:           tokens,
+		risk:             risk,
+		passwordless:     passwordless,
+		impersonation:    impersonation,
+		consent:          consent,
+		emailService:     emailService,
 		userRepo:         userRepo,
 		sessionRepo:      sessionRepo,
 		verificationRepo: verificationRepo,
+		tx:               tx,
+		events:           events,
 		config:           config,
 		logger:           logger,
 	}
 }
 
-// Login authenticates a user and creates a new session
+// Login authenticates a user and creates a new session. Failed logins slow
+// the account down and eventually lock it, and a login that looks risky must
+// be confirmed with a code sent to the account's email.
 func (s *AuthService) Login(ctx context.Context, email, password, userAgent, ipAddress string) (*models.Session, error) {
 	s.logger.Info("Login attempt", "email", email, "ip", ipAddress)
 
+	// Refuse locked accounts and attempts inside the backoff delay before
+	// spending any time on the password
+	risk, err := s.guard.Check(ctx, email, ipAddress)
+	if err != nil {
+		s.logger.Warn("Login throttled", "email", email, "ip", ipAddress, "error", err)
+		return nil, err
+	}
+
 	// Find user by email
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		s.logger.Warn("Login failed: user not found", "email", email)
+		s.recordLoginFailure(ctx, email, nil, ipAddress)
 		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid email or password")
 	}
 
-	// Check if account is active
-	if user.Status != "active" {
-		s.logger.Warn("Login attempt for inactive account", "email", email, "status", user.Status)
-		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
+	// Accounts that turned passwords off only sign in by emailed link or code
+	if user.PasswordDisabled {
+		s.logger.Warn("Login failed: password login is off", "email", email)
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid email or password")
 	}
 
 	// Verify password
 	if valid, err := s.password.VerifyPassword(password, user.PasswordHash); err != nil || !valid {
 		s.logger.Warn("Login failed: invalid password", "email", email)
+		s.recordLoginFailure(ctx, email, &user.ID, ipAddress)
 		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid email or password")
 	}
 
-	// Check if 2FA is enabled
+	// Check if account is active. Only the password's owner learns why not.
+	if user.Status == StatusPendingParentalConsent {
+		s.logger.Warn("Login attempt for account awaiting parental consent", "email", email)
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is waiting for a parent or guardian to approve it")
+	}
+	if user.Status != "active" {
+		s.logger.Warn("Login attempt for inactive account", "email", email, "status", user.Status)
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
+	}
+
+	// Refuse a password known to be breached until it is reset
+	if user.PasswordBreached || s.password.MustReset(ctx, password) {
+		s.logger.Warn("Login refused: password must be reset", "userId", user.ID.Hex())
+		s.requirePasswordReset(ctx, user)
+		return nil, errors.New(errors.CodeForbidden, "Your password has appeared in a data breach, reset it using the link sent to your email")
+	}
+
+	// Check if 2FA is enabled. Failures keep counting until the second
+	// factor is verified too.
 	if user.TwoFactorEnabled {
 		s.logger.Info("Creating 2FA pending session", "email", email)
-		return s.session.CreatePendingSession(ctx, user.ID, userAgent, ipAddress)
+		return s.session.CreatePendingSession(ctx, user.ID, models.PendingFactorTwoFactor, "", userAgent, ipAddress)
+	}
+
+	// Step up a risky login with an emailed code. Failures stay counted until
+	// the code is entered.
+	if risk.High {
+		codeHash, err := s.guard.SendStepUpCode(user, ipAddress)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Info("Creating email code pending session", "email", email, "reason", risk.Reason)
+		return s.session.CreatePendingSession(ctx, user.ID, models.PendingFactorEmailCode, codeHash, userAgent, ipAddress)
 	}
 
 	// Create regular session
+	s.recordLoginSuccess(ctx, email)
 	s.logger.Info("User logged in successfully", "userId", user.ID.Hex())
-	return s.session.CreateSession(ctx, user.ID, userAgent, ipAddress)
+	session, err := s.session.CreateSession(ctx, user.ID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.completeLogin(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// VerifyLoginCode completes a risky login with the code emailed for it. A
+// wrong code counts as a failed login, so guessing locks the account.
+func (s *AuthService) VerifyLoginCode(ctx context.Context, pendingToken, code string) (*models.Session, error) {
+	session, err := s.findPendingSession(ctx, pendingToken, models.PendingFactorEmailCode)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find user")
+	}
+
+	if err := s.guard.CheckAccount(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.VerifyStepUpCode(session, code); err != nil {
+		if errors.Code(err) == errors.CodeInvalidCredentials {
+			s.logger.Warn("Login code rejected", "userId", user.ID.Hex())
+			s.recordLoginFailure(ctx, user.Email, &user.ID, session.IPAddress)
+		}
+		return nil, err
+	}
+
+	s.recordLoginSuccess(ctx, user.Email)
+	s.logger.Info("Login confirmed with email code", "userId", user.ID.Hex())
+	session, err = s.session.CompleteTwoFactorAuth(ctx, session.ID, models.PendingFactorEmailCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.completeLogin(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetLoginLockout returns the lockout in force on a user's account
+func (s *AuthService) GetLoginLockout(ctx context.Context, userID primitive.ObjectID) (*models.LoginLockout, error) {
+	return s.guard.FindLockout(ctx, userID)
+}
+
+// UnlockAccount lets an admin lift the lockout on a user's account
+func (s *AuthService) UnlockAccount(ctx context.Context, userID, adminID primitive.ObjectID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find user")
+	}
+	return s.guard.Unlock(ctx, user, adminID)
+}
+
+// RevokeFlaggedSession signs out a session from the link in a login alert.
+// The link is all the user has, since the session may be the only one
+// signed in, so the signed token stands in for authentication. Revoking a
+// session that is already gone succeeds.
+func (s *AuthService) RevokeFlaggedSession(ctx context.Context, token string) error {
+	sessionID, err := s.risk.VerifyRevokeToken(token)
+	if err != nil {
+		return err
+	}
+
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find session")
+	}
+	if session.RevokedAt != nil || !session.IsActive {
+		return nil
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, sessionID, RevokedReasonReported); err != nil {
+		s.logger.Error("Failed to revoke reported session", "error", err, "sessionId", sessionID.Hex())
+		return errors.Wrap(err, "Failed to revoke session")
+	}
+
+	s.logger.Warn("Session reported from login alert and revoked", "userId", session.UserID.Hex(), "sessionId", sessionID.Hex(), "ip", session.IPAddress)
+	return nil
+}
+
+// RequestPasswordlessLogin emails a single-use link and code that sign the
+// account in without its password. Unknown emails and inactive accounts are
+// sent nothing, with no error, so the answer reveals nothing about them.
+func (s *AuthService) RequestPasswordlessLogin(ctx context.Context, email, ipAddress string) error {
+	if err := s.guard.CheckAccount(ctx, email); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		s.logger.Info("Passwordless login requested for unknown email", "email", email, "ip", ipAddress)
+		return nil
+	}
+	if user.Status != "active" {
+		s.logger.Warn("Passwordless login requested for inactive account", "userId", user.ID.Hex(), "status", user.Status)
+		return nil
+	}
+
+	return s.passwordless.Send(ctx, user, ipAddress)
+}
+
+// LoginWithLink signs a user in with the token from an emailed link. The
+// token cannot be guessed, so like a passkey it is not held up by lockouts.
+func (s *AuthService) LoginWithLink(ctx context.Context, token, userAgent, ipAddress string) (*models.Session, error) {
+	userID, err := s.passwordless.ConsumeLink(ctx, token)
+	if err != nil {
+		s.logger.Warn("Login link rejected", "ip", ipAddress, "error", err)
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid sign-in link")
+	}
+
+	return s.completePasswordlessLogin(ctx, user, userAgent, ipAddress)
+}
+
+// LoginWithCode signs a user in with an emailed code. A wrong code counts
+// as a failed login, so guessing locks the account.
+func (s *AuthService) LoginWithCode(ctx context.Context, email, code, userAgent, ipAddress string) (*models.Session, error) {
+	if err := s.guard.CheckAccount(ctx, email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		s.recordLoginFailure(ctx, email, nil, ipAddress)
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid email or code")
+	}
+
+	if err := s.passwordless.ConsumeCode(ctx, user.ID, code); err != nil {
+		if errors.Code(err) == errors.CodeInvalidCredentials {
+			s.logger.Warn("Login code rejected", "userId", user.ID.Hex(), "ip", ipAddress)
+			s.recordLoginFailure(ctx, email, &user.ID, ipAddress)
+		}
+		return nil, err
+	}
+
+	return s.completePasswordlessLogin(ctx, user, userAgent, ipAddress)
+}
+
+// completePasswordlessLogin signs in a user who proved they own the
+// account's email. It stands in for the password only, so accounts with 2FA
+// still get a pending session waiting for their second factor.
+func (s *AuthService) completePasswordlessLogin(ctx context.Context, user *models.User, userAgent, ipAddress string) (*models.Session, error) {
+	if user.Status != "active" {
+		s.logger.Warn("Passwordless login attempt for inactive account", "userId", user.ID.Hex(), "status", user.Status)
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
+	}
+
+	if user.TwoFactorEnabled {
+		s.logger.Info("Creating 2FA pending session", "userId", user.ID.Hex())
+		return s.session.CreatePendingSession(ctx, user.ID, models.PendingFactorTwoFactor, "", userAgent, ipAddress)
+	}
+
+	s.recordLoginSuccess(ctx, user.Email)
+
+	s.logger.Info("User logged in with emailed link or code", "userId", user.ID.Hex())
+	session, err := s.session.CreateSession(ctx, user.ID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.completeLogin(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// DisablePassword turns password login off for an account, which then signs
+// in by emailed link or code, or with a passkey. The password is removed, so
+// a leaked hash is no use. The email must be verified, since it becomes the
+// way into the account.
+func (s *AuthService) DisablePassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find user")
+	}
+	if user.PasswordDisabled {
+		return errors.New(errors.CodeInvalidOperation, "Password login is already off")
+	}
+	if !user.EmailVerified {
+		return errors.New(errors.CodeInvalidOperation, "Verify your email before turning password login off")
+	}
+
+	// Accounts created through an OAuth provider have no password to confirm
+	if user.PasswordHash != "" {
+		if valid, err := s.password.VerifyPassword(password, user.PasswordHash); err != nil || !valid {
+			s.logger.Warn("Disable password failed: incorrect password", "userId", userID.Hex())
+			return errors.New(errors.CodeInvalidCredentials, "Current password is incorrect")
+		}
+	}
+
+	user.PasswordHash = ""
+	user.PasswordDisabled = true
+	user.PasswordBreached = false
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to disable password", "error", err, "userId", userID.Hex())
+		return errors.Wrap(err, "Failed to update user")
+	}
+
+	s.logger.Info("Password login turned off", "userId", userID.Hex())
+	return nil
+}
+
+// EnablePassword turns password login back on with a new password
+func (s *AuthService) EnablePassword(ctx context.Context, userID primitive.ObjectID, newPassword string) error {
+	if err := s.password.Validate(ctx, newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find user")
+	}
+	if !user.PasswordDisabled {
+		return errors.New(errors.CodeInvalidOperation, "Password login is already on")
+	}
+
+	passwordHash, err := s.password.HashPassword(newPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash password", "error", err, "userId", userID.Hex())
+		return errors.Wrap(err, "Failed to hash password")
+	}
+
+	user.PasswordHash = passwordHash
+	user.PasswordDisabled = false
+	user.PasswordBreached = false
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to enable password", "error", err, "userId", userID.Hex())
+		return errors.Wrap(err, "Failed to update user")
+	}
+
+	s.logger.Info("Password login turned on", "userId", userID.Hex())
+	return nil
+}
+
+// RequestAccountDeletion schedules the account to be erased once the
+// deletion grace period has passed and logs it out everywhere. Logging in
+// again before then cancels the deletion. It returns when the account will
+// be erased.
+func (s *AuthService) RequestAccountDeletion(ctx context.Context, userID primitive.ObjectID, password string) (time.Time, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Failed to find user")
+	}
+	if user.DeletionScheduledAt != nil {
+		return time.Time{}, errors.New(errors.CodeInvalidOperation, "Account deletion is already scheduled")
+	}
+
+	// Accounts without a password have already proven themselves by logging in
+	if user.PasswordHash != "" {
+		if valid, err := s.password.VerifyPassword(password, user.PasswordHash); err != nil || !valid {
+			s.logger.Warn("Account deletion refused: incorrect password", "userId", userID.Hex())
+			return time.Time{}, errors.New(errors.CodeInvalidCredentials, "Current password is incorrect")
+		}
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(s.config.DeletionGracePeriod)
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduledAt
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to schedule account deletion", "error", err, "userId", userID.Hex())
+		return time.Time{}, errors.Wrap(err, "Failed to update user")
+	}
+
+	// Any later login is then a deliberate one, which cancels the deletion
+	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		s.logger.Warn("Failed to revoke sessions after deletion request", "error", err, "userId", userID.Hex())
+	}
+
+	s.logger.Info("Account deletion scheduled", "userId", userID.Hex(), "scheduledAt", scheduledAt)
+	return scheduledAt, nil
 }
 
-// Register creates a new user account
+// completeLogin cancels any pending deletion of the account and checks the
+// login against the account's history, which alerts the user if it looks
+// unfamiliar. A deletion that cannot be cancelled fails the login and
+// removes its session, since the account would still be erased; risk check
+// errors are only logged.
+func (s *AuthService) completeLogin(ctx context.Context, session *models.Session) error {
+	if err := s.cancelAccountDeletion(ctx, session.UserID); err != nil {
+		if err := s.sessionRepo.Delete(ctx, session.ID); err != nil {
+			s.logger.Error("Failed to remove session of failed login", "error", err, "sessionId", session.ID.Hex())
+		}
+		return err
+	}
+	if _, err := s.risk.Evaluate(ctx, session); err != nil {
+		s.logger.Error("Failed to evaluate login risk", "error", err, "userId", session.UserID.Hex(), "sessionId", session.ID.Hex())
+	}
+	return nil
+}
+
+// cancelAccountDeletion clears a pending deletion of the account
+func (s *AuthService) cancelAccountDeletion(ctx context.Context, userID primitive.ObjectID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to find user to cancel account deletion", "error", err, "userId", userID.Hex())
+		return errors.Wrap(err, "Failed to find user")
+	}
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to cancel account deletion", "error", err, "userId", userID.Hex())
+		return errors.Wrap(err, "Failed to cancel account deletion")
+	}
+	s.logger.Info("Account deletion cancelled by login", "userId", userID.Hex())
+	return nil
+}
+
+// requirePasswordReset marks an account's password as breached and emails
+// a reset link the first time. Later logins are refused without resending,
+// since the user can always ask for another link. Errors are logged rather
+// than returned, so they never change the answer the client gets.
+func (s *AuthService) requirePasswordReset(ctx context.Context, user *models.User) {
+	if user.PasswordBreached {
+		return
+	}
+
+	user.PasswordBreached = true
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to mark password as breached", "error", err, "userId", user.ID.Hex())
+		return
+	}
+	if err := s.password.RequestReset(ctx, user.Email); err != nil {
+		s.logger.Error("Failed to send password reset for breached password", "error", err, "userId", user.ID.Hex())
+	}
+}
+
+// recordLoginFailure counts a failed login. Bookkeeping errors are logged
+// rather than returned, so they never change the answer the client gets.
+func (s *AuthService) recordLoginFailure(ctx context.Context, email string, userID *primitive.ObjectID, ipAddress string) {
+	if err := s.guard.RecordFailure(ctx, email, userID, ipAddress); err != nil {
+		s.logger.Error("Failed to record login failure", "email", email, "error", err)
+	}
+}
+
+// recordLoginSuccess clears the account's failures once every factor of a
+// login has been verified
+func (s *AuthService) recordLoginSuccess(ctx context.Context, email string) {
+	if err := s.guard.RecordSuccess(ctx, email); err != nil {
+		s.logger.Error("Failed to clear login failures", "email", email, "error", err)
+	}
+}
+
+// Register creates a new user account. Users under the minimum age are
+// refused, and teen accounts start with stricter privacy settings. An
+// account that needs parental consent stays inactive until the guardian
+// named in user.ParentalConsent approves it from an emailed link.
 func (s *AuthService) Register(ctx context.Context, user *models.User, password string) (*models.User, error) {
 	// Validate inputs
 	if user.Email == "" || user.Username == "" || password == "" {
 		return nil, errors.New(errors.CodeInvalidArgument, "Email, username and password are required")
 	}
 
-	// Check password strength
-	if strong, msg := s.CheckPasswordStrength(password); !strong {
-		return nil, errors.New(errors.CodeInvalidArgument, msg)
+	// Check the user is old enough to register
+	age, err := s.checkAge(user.DateOfBirth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check password strength and the breached-password corpus
+	if err := s.password.Validate(ctx, password); err != nil {
+		return nil, err
 	}
 
 	// Check if email already exists
@@ -178,6 +732,12 @@ func (s *AuthService) Register(ctx context.Context, user *models.User, password
 	user.FollowingCount = 0
 	user.PostCount = 0
 
+	// Young users wait for a guardian's consent, and teens start private
+	needsConsent, err := s.applyAgeRules(user, age)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set display name if not provided
 	if user.DisplayName == "" {
 		user.DisplayName = user.Username
@@ -190,6 +750,13 @@ func (s *AuthService) Register(ctx context.Context, user *models.User, password
 		return nil, errors.Wrap(err, "Failed to create user")
 	}
 
+	// Ask the guardian for consent. The user can ask for the email again.
+	if needsConsent {
+		if err := s.consent.Request(ctx, createdUser); err != nil {
+			s.logger.Error("Failed to request parental consent", "error", err, "userId", createdUser.ID.Hex())
+		}
+	}
+
 	// Send verification email
 	s.RequestEmailVerification(ctx, createdUser.ID)
 
@@ -197,6 +764,76 @@ func (s *AuthService) Register(ctx context.Context, user *models.User, password
 	return createdUser, nil
 }
 
+// GrantParentalConsent activates the account a guardian's consent link was
+// sent for
+func (s *AuthService) GrantParentalConsent(ctx context.Context, token string) error {
+	return s.consent.Grant(ctx, token)
+}
+
+// ResendParentalConsent emails the guardian of an account awaiting consent
+// a new link. The user proves the account is theirs with its password, and
+// wrong passwords count as failed logins. A right one does not clear them,
+// as nobody logs in.
+func (s *AuthService) ResendParentalConsent(ctx context.Context, email, password, ipAddress string) error {
+	if _, err := s.guard.Check(ctx, email, ipAddress); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		s.recordLoginFailure(ctx, email, nil, ipAddress)
+		return errors.New(errors.CodeInvalidCredentials, "Invalid email or password")
+	}
+	if valid, err := s.password.VerifyPassword(password, user.PasswordHash); err != nil || !valid {
+		s.recordLoginFailure(ctx, email, &user.ID, ipAddress)
+		return errors.New(errors.CodeInvalidCredentials, "Invalid email or password")
+	}
+
+	return s.consent.Request(ctx, user)
+}
+
+// checkAge returns the age of a user born on dateOfBirth, refusing users
+// younger than the minimum age
+func (s *AuthService) checkAge(dateOfBirth time.Time) (int, error) {
+	if dateOfBirth.IsZero() {
+		return 0, errors.New(errors.CodeInvalidArgument, "Date of birth is required")
+	}
+	age := ageOn(dateOfBirth, time.Now())
+	if age < s.config.MinimumAge {
+		return 0, errors.New(errors.CodeInvalidArgument, fmt.Sprintf("You must be at least %d years old to register", s.config.MinimumAge))
+	}
+	return age, nil
+}
+
+// applyAgeRules holds a new account of a user too young to consent for a
+// guardian and applies the teen defaults. It reports whether the guardian
+// must be asked once the account is created.
+func (s *AuthService) applyAgeRules(user *models.User, age int) (bool, error) {
+	needsConsent := s.consent.Required(age)
+	if needsConsent {
+		if err := s.consent.Prepare(user); err != nil {
+			return false, err
+		}
+	} else {
+		user.ParentalConsent = nil
+	}
+
+	if age < models.AdultAge {
+		applyTeenDefaults(user)
+	}
+	return needsConsent, nil
+}
+
+// applyTeenDefaults makes a teen account private, open to messages from
+// followers only and absent from suggestions. Ads reach teens only through
+// targeting without personalization, which the ads API enforces.
+func applyTeenDefaults(user *models.User) {
+	user.IsPrivate = true
+	privacy := &user.Settings.PrivacySettings
+	privacy.WhoCanSendMeMessages = "followers"
+	privacy.HideFromSuggestions = true
+}
+
 // Logout invalidates a user session
 func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
 	id, err := primitive.ObjectIDFromHex(sessionID)
@@ -215,19 +852,32 @@ func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
 
 // ValidateToken validates a JWT token and returns the user ID
 func (s *AuthService) ValidateToken(ctx context.Context, token string) (*primitive.ObjectID, error) {
+	accessToken, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &accessToken.UserID, nil
+}
+
+// Authenticate validates a JWT token and returns the user and session it was
+// issued for. The user must still exist and be active, a token issued to a
+// third-party app must belong to a grant the user has not revoked, and an
+// impersonation must still be open.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*AccessToken, error) {
 	// Validate token structure
 	if token == "" {
 		return nil, errors.New(errors.CodeInvalidToken, "Token is empty")
 	}
 
-	userID, err := s.jwt.ValidateToken(token)
+	accessToken, err := s.jwt.ValidateToken(ctx, token)
 	if err != nil {
 		s.logger.Warn("Token validation failed", "error", err)
 		return nil, err
 	}
+	userID := accessToken.UserID
 
 	// Check if user exists and is active
-	user, err := s.userRepo.FindByID(ctx, *userID)
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		s.logger.Warn("Token validation failed: user not found", "userId", userID.Hex())
 		return nil, errors.New(errors.CodeInvalidToken, "Invalid token")
@@ -238,16 +888,64 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (*primiti
 		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
 	}
 
-	return userID, nil
+	if accessToken.IsThirdParty() {
+		if err := s.apps.CheckGrant(ctx, accessToken); err != nil {
+			s.logger.Warn("Token validation failed: app grant revoked", "userId", userID.Hex(), "clientId", accessToken.ClientID)
+			return nil, err
+		}
+	}
+
+	// Sessions staff opened as the user end early when the staff member
+	// closes them or loses their account
+	if accessToken.IsImpersonation() {
+		if err := s.impersonation.Check(ctx, accessToken); err != nil {
+			s.logger.Warn("Token validation failed: impersonation ended", "userId", userID.Hex(), "impersonatorId", accessToken.ImpersonatorID.Hex())
+			return nil, err
+		}
+		impersonator, err := s.userRepo.FindByID(ctx, accessToken.ImpersonatorID)
+		if err != nil || impersonator.Status != "active" {
+			s.logger.Warn("Token validation failed: impersonator inactive", "userId", userID.Hex(), "impersonatorId", accessToken.ImpersonatorID.Hex())
+			return nil, errors.New(errors.CodeInvalidToken, "Invalid token")
+		}
+	}
+
+	return accessToken, nil
+}
+
+// AuthenticatePersonalToken validates a personal access token presented from
+// ipAddress and records its use. The user must still exist and be active.
+func (s *AuthService) AuthenticatePersonalToken(ctx context.Context, token, ipAddress, userAgent string) (*AccessToken, error) {
+	accessToken, err := s.tokens.Authenticate(ctx, token, ipAddress, userAgent)
+	if err != nil {
+		s.logger.Warn("Personal access token validation failed", "ip", ipAddress, "error", err)
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, accessToken.UserID)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid token")
+	}
+	if user.Status != "active" {
+		s.logger.Warn("Personal access token validation failed: user inactive", "userId", user.ID.Hex(), "status", user.Status)
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
+	}
+
+	return accessToken, nil
 }
 
-// RefreshToken generates a new access token using a refresh token
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token. Each refresh token can be exchanged only once; presenting one
+// again means it has been copied, so its session is revoked.
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
+	if _, err := s.refresh.ValidateRefreshToken(refreshToken); err != nil {
+		return nil, err
+	}
+	hash := s.refresh.HashRefreshToken(refreshToken)
+
 	// Find session by refresh token
-	session, err := s.sessionRepo.FindByRefreshToken(ctx, refreshToken)
+	session, err := s.sessionRepo.FindByRefreshTokenHash(ctx, hash)
 	if err != nil {
-		s.logger.Warn("Invalid refresh token", "error", err)
-		return nil, errors.New(errors.CodeInvalidToken, "Invalid refresh token")
+		return nil, s.handleRefreshTokenReuse(ctx, hash)
 	}
 
 	// Check if session is active and not expired
@@ -259,7 +957,7 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 	}
 
 	// Generate new tokens
-	token, err := s.jwt.GenerateToken(session.UserID)
+	token, err := s.jwt.GenerateToken(session.UserID, session.ID)
 	if err != nil {
 		s.logger.Error("Failed to generate token", "error", err, "userId", session.UserID.Hex())
 		return nil, errors.Wrap(err, "Failed to generate token")
@@ -271,14 +969,19 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, errors.Wrap(err, "Failed to generate refresh token")
 	}
 
-	// Update session
+	// Rotate the refresh token, retiring the one just presented
 	now := time.Now()
 	session.Token = token
 	session.RefreshToken = newRefreshToken
+	session.RefreshTokenHash = s.refresh.HashRefreshToken(newRefreshToken)
 	session.LastUsedAt = now
 	session.ExpiresAt = s.refresh.GetExpirationTime()
 
-	if err := s.sessionRepo.Update(ctx, session); err != nil {
+	if err := s.sessionRepo.RotateRefreshToken(ctx, session, hash); err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			// Another request exchanged the same token first
+			return nil, s.handleRefreshTokenReuse(ctx, hash)
+		}
 		s.logger.Error("Failed to update session", "error", err, "sessionId", session.ID.Hex())
 		return nil, errors.Wrap(err, "Failed to update session")
 	}
@@ -287,6 +990,45 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 	return session, nil
 }
 
+// handleRefreshTokenReuse is called when a refresh token is not current. If its
+// session has already exchanged it, the token was copied and is being replayed,
+// by an attacker or by the user it was stolen from. There is no telling which,
+// so the whole session is revoked and the user is alerted. It always returns
+// the error to give the caller.
+func (s *AuthService) handleRefreshTokenReuse(ctx context.Context, hash string) error {
+	session, err := s.sessionRepo.FindByRotatedTokenHash(ctx, hash)
+	if err != nil {
+		s.logger.Warn("Invalid refresh token", "error", err)
+		return errors.New(errors.CodeInvalidToken, "Invalid refresh token")
+	}
+
+	if session.RevokedAt != nil {
+		s.logger.Warn("Refresh token presented for revoked session", "userId", session.UserID.Hex(), "sessionId", session.ID.Hex())
+		return errors.New(errors.CodeInvalidToken, "Invalid refresh token")
+	}
+
+	s.logger.Warn("Refresh token reuse detected, revoking session", "userId", session.UserID.Hex(), "sessionId", session.ID.Hex())
+
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.Revoke(ctx, session.ID, RevokedReasonTokenReuse); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, eventbus.SessionTokenReused{
+			SessionID: session.ID,
+			UserID:    session.UserID,
+			Device:    session.Device,
+			Location:  session.Location,
+		})
+	})
+	// Not found means a concurrent request revoked the session first
+	if err != nil && !stderrors.Is(err, mongo.ErrNoDocuments) {
+		s.logger.Error("Failed to revoke session", "error", err, "sessionId", session.ID.Hex())
+		return errors.Wrap(err, "Failed to revoke session")
+	}
+
+	return errors.New(errors.CodeInvalidToken, "Refresh token has already been used")
+}
+
 // GetOAuthURL returns the URL for OAuth authentication
 func (s *AuthService) GetOAuthURL(ctx context.Context, provider, redirectURL string) (string, error) {
 	// Validate provider
@@ -330,6 +1072,16 @@ func (s *AuthService) HandleOAuthCallback(ctx context.Context, provider, code, u
 	// Find or create user
 	user, err := s.userRepo.FindByEmail(ctx, userInfo.Email)
 	if err != nil {
+		// New users pass the same age checks as on registration. Providers
+		// that do not share a date of birth cannot sign up new users.
+		if userInfo.DateOfBirth.IsZero() {
+			return nil, errors.New(errors.CodeInvalidArgument, fmt.Sprintf("Register with your date of birth before signing in with %s", provider))
+		}
+		age, err := s.checkAge(userInfo.DateOfBirth)
+		if err != nil {
+			return nil, err
+		}
+
 		// Create new user
 		now := time.Now()
 
@@ -343,6 +1095,7 @@ func (s *AuthService) HandleOAuthCallback(ctx context.Context, provider, code, u
 			Email:          userInfo.Email,
 			Username:       username,
 			DisplayName:    userInfo.Name,
+			DateOfBirth:    userInfo.DateOfBirth,
 			EmailVerified:  true, // OAuth emails are typically verified
 			CreatedAt:      now,
 			UpdatedAt:      now,
@@ -353,6 +1106,11 @@ func (s *AuthService) HandleOAuthCallback(ctx context.Context, provider, code, u
 			PostCount:      0,
 		}
 
+		needsConsent, err := s.applyAgeRules(newUser, age)
+		if err != nil {
+			return nil, err
+		}
+
 		user, err = s.userRepo.Create(ctx, newUser)
 		if err != nil {
 			s.logger.Error("Failed to create user from OAuth", "error", err, "email", userInfo.Email)
@@ -360,12 +1118,36 @@ func (s *AuthService) HandleOAuthCallback(ctx context.Context, provider, code, u
 		}
 
 		s.logger.Info("Created new user from OAuth", "userId", user.ID.Hex(), "provider", provider)
+
+		// The account stays inactive until the guardian approves it
+		if needsConsent {
+			if err := s.consent.Request(ctx, user); err != nil {
+				s.logger.Error("Failed to request parental consent", "error", err, "userId", user.ID.Hex())
+			}
+		}
 	} else {
 		s.logger.Info("Found existing user from OAuth", "userId", user.ID.Hex(), "provider", provider)
 	}
 
+	// Check if account is active
+	if user.Status == StatusPendingParentalConsent {
+		s.logger.Warn("OAuth login for account awaiting parental consent", "userId", user.ID.Hex())
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is waiting for a parent or guardian to approve it")
+	}
+	if user.Status != "active" {
+		s.logger.Warn("OAuth login for inactive account", "userId", user.ID.Hex(), "status", user.Status)
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
+	}
+
 	// Create session
-	return s.session.CreateSession(ctx, user.ID, userAgent, ipAddress)
+	session, err := s.session.CreateSession(ctx, user.ID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.completeLogin(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
 // generateUsername creates a unique username from email or name
@@ -401,9 +1183,9 @@ func (s *AuthService) ListOAuthProviders() []string {
 
 // ChangePassword changes a user's password
 func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.ObjectID, oldPassword, newPassword string) error {
-	// Check password strength
-	if strong, msg := s.CheckPasswordStrength(newPassword); !strong {
-		return errors.New(errors.CodeInvalidArgument, msg)
+	// Check password strength and the breached-password corpus
+	if err := s.password.Validate(ctx, newPassword); err != nil {
+		return err
 	}
 
 	// Get user
@@ -412,6 +1194,9 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.Objec
 		s.logger.Warn("Change password failed: user not found", "userId", userID.Hex())
 		return errors.Wrap(err, "Failed to find user")
 	}
+	if user.PasswordDisabled {
+		return errors.New(errors.CodeInvalidOperation, "Password login is off, turn it on with a new password")
+	}
 
 	// Verify old password
 	if valid, err := s.password.VerifyPassword(oldPassword, user.PasswordHash); err != nil || !valid {
@@ -428,6 +1213,7 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.Objec
 
 	// Update user
 	user.PasswordHash = passwordHash
+	user.PasswordBreached = false
 	user.UpdatedAt = time.Now()
 	err = s.userRepo.Update(ctx, user)
 	if err != nil {
@@ -493,6 +1279,194 @@ func (s *AuthService) TwoFactorRecoveryCodes(ctx context.Context, userID primiti
 	return s.twoFactor.GetRecoveryCodes(ctx, userID)
 }
 
+// BeginPasskeyRegistration starts registering a passkey for a signed-in user
+func (s *AuthService) BeginPasskeyRegistration(ctx context.Context, userID primitive.ObjectID) (*webauthn.CreationOptions, error) {
+	return s.passkey.BeginRegistration(ctx, userID)
+}
+
+// FinishPasskeyRegistration stores the passkey the user's authenticator created
+func (s *AuthService) FinishPasskeyRegistration(ctx context.Context, userID primitive.ObjectID, name, userAgent string, response *webauthn.CredentialCreationResponse) (*models.Passkey, error) {
+	return s.passkey.FinishRegistration(ctx, userID, name, s.session.DetectDevice(userAgent), response)
+}
+
+// ListPasskeys returns a user's passkeys
+func (s *AuthService) ListPasskeys(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error) {
+	return s.passkey.List(ctx, userID)
+}
+
+// RenamePasskey renames one of a user's passkeys
+func (s *AuthService) RenamePasskey(ctx context.Context, userID, passkeyID primitive.ObjectID, name string) error {
+	return s.passkey.Rename(ctx, userID, passkeyID, name)
+}
+
+// DeletePasskey removes one of a user's passkeys
+func (s *AuthService) DeletePasskey(ctx context.Context, userID, passkeyID primitive.ObjectID) error {
+	return s.passkey.Delete(ctx, userID, passkeyID)
+}
+
+// BeginPasskeyLogin starts a passwordless login
+func (s *AuthService) BeginPasskeyLogin(ctx context.Context) (*webauthn.RequestOptions, error) {
+	return s.passkey.BeginLogin(ctx)
+}
+
+// FinishPasskeyLogin signs a user in with a passkey instead of a password.
+// The passkey has verified the user itself, so no second factor is asked for.
+func (s *AuthService) FinishPasskeyLogin(ctx context.Context, response *webauthn.CredentialAssertionResponse, userAgent, ipAddress string) (*models.Session, error) {
+	passkey, err := s.passkey.FinishLogin(ctx, response)
+	if err != nil {
+		s.logger.Warn("Passkey login failed", "ip", ipAddress, "error", err)
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, passkey.UserID)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+	}
+	if user.Status != "active" {
+		s.logger.Warn("Passkey login attempt for inactive account", "userId", user.ID.Hex(), "status", user.Status)
+		return nil, errors.New(errors.CodeAccountDisabled, "Account is not active")
+	}
+
+	s.logger.Info("User logged in with passkey", "userId", user.ID.Hex(), "passkeyId", passkey.ID.Hex())
+	session, err := s.session.CreateSession(ctx, user.ID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.completeLogin(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// BeginPasskeyTwoFactor starts a passkey check in place of a TOTP code for a
+// login waiting on its second factor. pendingToken is the token Login returned.
+func (s *AuthService) BeginPasskeyTwoFactor(ctx context.Context, pendingToken string) (*webauthn.RequestOptions, error) {
+	session, err := s.findPendingSession(ctx, pendingToken, models.PendingFactorTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+	return s.passkey.BeginSecondFactor(ctx, session.ID, session.UserID)
+}
+
+// FinishPasskeyTwoFactor verifies the passkey check and completes the login
+func (s *AuthService) FinishPasskeyTwoFactor(ctx context.Context, pendingToken string, response *webauthn.CredentialAssertionResponse) (*models.Session, error) {
+	session, err := s.findPendingSession(ctx, pendingToken, models.PendingFactorTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := s.passkey.FinishSecondFactor(ctx, session.ID, response)
+	if err != nil {
+		s.logger.Warn("Passkey second factor failed", "userId", session.UserID.Hex(), "error", err)
+		return nil, err
+	}
+	if passkey.UserID != session.UserID {
+		return nil, errors.New(errors.CodeInvalidCredentials, "Invalid passkey")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find user")
+	}
+
+	s.recordLoginSuccess(ctx, user.Email)
+	s.logger.Info("Second factor verified with passkey", "userId", session.UserID.Hex(), "passkeyId", passkey.ID.Hex())
+	session, err = s.session.CompleteTwoFactorAuth(ctx, session.ID, models.PendingFactorTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.completeLogin(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// findPendingSession finds an unexpired login that is waiting for factor
+func (s *AuthService) findPendingSession(ctx context.Context, pendingToken, factor string) (*models.Session, error) {
+	if !strings.HasPrefix(pendingToken, "pending_") {
+		return nil, errors.New(errors.CodeInvalidToken, "Invalid pending login token")
+	}
+
+	session, err := s.sessionRepo.FindByToken(ctx, pendingToken)
+	if err != nil || !session.IsActive || time.Now().After(session.ExpiresAt) {
+		return nil, errors.New(errors.CodeInvalidToken, "Login has expired, sign in again")
+	}
+	if session.PendingFactor != factor {
+		return nil, errors.New(errors.CodeInvalidOperation, "Login is not waiting for this step")
+	}
+
+	return session, nil
+}
+
+// CreatePersonalToken issues a personal access token for a user
+func (s *AuthService) CreatePersonalToken(ctx context.Context, userID primitive.ObjectID, input *PersonalTokenInput) (*models.PersonalAccessToken, string, error) {
+	return s.tokens.Create(ctx, userID, input)
+}
+
+// ListPersonalTokens returns a user's personal access tokens
+func (s *AuthService) ListPersonalTokens(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error) {
+	return s.tokens.List(ctx, userID)
+}
+
+// PersonalTokenUsage returns the recent usage of one of a user's personal access tokens
+func (s *AuthService) PersonalTokenUsage(ctx context.Context, userID, tokenID primitive.ObjectID) ([]*models.PersonalAccessTokenUsage, error) {
+	return s.tokens.Usage(ctx, userID, tokenID)
+}
+
+// RevokePersonalToken revokes one of a user's personal access tokens
+func (s *AuthService) RevokePersonalToken(ctx context.Context, userID, tokenID primitive.ObjectID) error {
+	return s.tokens.Revoke(ctx, userID, tokenID)
+}
+
+// StartImpersonation opens a short-lived, read-only session in which
+// impersonatorID sees the app as userID. Callers must have checked that the
+// member of staff may impersonate the user.
+func (s *AuthService) StartImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeNotFound, "User not found")
+	}
+	if user.Status != "active" {
+		return nil, errors.New(errors.CodeInvalidOperation, "Account is not active")
+	}
+
+	session, err := s.impersonation.Start(ctx, impersonatorID, userID, reason, userAgent, ipAddress)
+	if err != nil {
+		s.logger.Error("Failed to start impersonation", "error", err, "userId", userID.Hex(), "impersonatorId", impersonatorID.Hex())
+		return nil, err
+	}
+
+	s.logger.Info("Impersonation started", "userId", userID.Hex(), "impersonatorId", impersonatorID.Hex(), "sessionId", session.ID.Hex())
+	return session, nil
+}
+
+// ElevateImpersonation lets an open impersonation make changes as the user
+func (s *AuthService) ElevateImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, reason, userAgent, ipAddress string) (*models.Session, error) {
+	session, err := s.impersonation.Elevate(ctx, impersonatorID, userID, reason, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Warn("Impersonation elevated to write", "userId", userID.Hex(), "impersonatorId", impersonatorID.Hex(), "sessionId", session.ID.Hex())
+	return session, nil
+}
+
+// EndImpersonation closes an open impersonation
+func (s *AuthService) EndImpersonation(ctx context.Context, impersonatorID, userID primitive.ObjectID, userAgent, ipAddress string) error {
+	if err := s.impersonation.End(ctx, impersonatorID, userID, userAgent, ipAddress); err != nil {
+		return err
+	}
+
+	s.logger.Info("Impersonation ended", "userId", userID.Hex(), "impersonatorId", impersonatorID.Hex())
+	return nil
+}
+
+// RecordImpersonatedRequest writes a request made under impersonation to
+// the admin audit log
+func (s *AuthService) RecordImpersonatedRequest(ctx context.Context, token *AccessToken, method, path string, statusCode int, userAgent, ipAddress string) {
+	s.impersonation.RecordRequest(ctx, token, method, path, statusCode, userAgent, ipAddress)
+}
+
 // GetSession retrieves a session by ID
 func (s *AuthService) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
 	id, err := primitive.ObjectIDFromHex(sessionID)
@@ -588,6 +1562,11 @@ func (s *AuthService) ExtendSession(ctx context.Context, sessionID string) error
 	return nil
 }
 
+// CleanupExpiredSessions removes sessions that have expired
+func (s *AuthService) CleanupExpiredSessions(ctx context.Context) error {
+	return s.session.CleanupExpiredSessions(ctx)
+}
+
 // VerifyEmail verifies a user's email using a token
 func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
 	// Find verification record
@@ -671,8 +1650,9 @@ func (s *AuthService) RequestEmailVerification(ctx context.Context, userID primi
 		"VerificationLink": verificationLink,
 	}
 
-	// This would call an email service
-	// s.emailService.SendTemplatedEmail(user.Email, "Verify Your Email", "email_verification", emailData)
+	if err := s.emailService.SendTemplatedEmail(user.Email, "Verify Your Email", "email_verification", emailData); err != nil {
+		return errors.Wrap(err, "Failed to send verification email")
+	}
 
 	s.logger.Info("Email verification requested", "userId", user.ID.Hex())
 	return nil
@@ -735,27 +1715,14 @@ func (s *AuthService) CheckUserExists(ctx context.Context, email string) (bool,
 	return user != nil, nil
 }
 
-// Helper functions
-
-func timePtr(t time.Time) *time.Time {
-	return &t
-}
-
-func generateSecureToken(length int) (string, error) {
-	b := make([]byte, length)
-	_, err := rand.Read(b)
-	if err != nil {
-		return "", err
-	}
-	return base64.URLEncoding.EncodeToString(b), nil
-}
-
 // VerificationRepository handles verification data access
 type VerificationRepository interface {
 	Create(ctx context.Context, verification *models.Verification) (*models.Verification, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Verification, error)
 	FindByToken(ctx context.Context, token, type_ string) (*models.Verification, error)
 	FindByUserID(ctx context.Context, userID primitive.ObjectID, type_ string) (*models.Verification, error)
+	CountSince(ctx context.Context, userID primitive.ObjectID, type_ string, since time.Time) (int, error)
+	Redeem(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Verification, error)
 	Update(ctx context.Context, verification *models.Verification) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 }
@@ -774,9 +1741,13 @@ type SessionRepository interface {
 	Create(ctx context.Context, session *models.Session) (*models.Session, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
 	FindByToken(ctx context.Context, token string) (*models.Session, error)
-	FindByRefreshToken(ctx context.Context, refreshToken string) (*models.Session, error)
+	FindByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error)
+	FindByRotatedTokenHash(ctx context.Context, hash string) (*models.Session, error)
 	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error)
 	Update(ctx context.Context, session *models.Session) error
+	RotateRefreshToken(ctx context.Context, session *models.Session, previousHash string) error
+	Revoke(ctx context.Context, id primitive.ObjectID, reason string) error
+	ElevateImpersonation(ctx context.Context, id primitive.ObjectID, elevatedAt time.Time) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error
 	DeleteExpired(ctx context.Context) error
@@ -0,0 +1,138 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/tests/helpers/fakes"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// testEnv is an authentication service on top of in-memory repositories,
+// sending email to a mailbox. Services built after breached is set screen
+// passwords against it.
+type testEnv struct {
+	cfg      *config.Config
+	log      *logger.Logger
+	db       *memory.Database
+	repos    *memory.Repositories
+	mail     *fakes.Mailbox
+	bus      *eventbus.Bus
+	breached auth.BreachedPasswordChecker
+	auth     *auth.AuthService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Environment = config.EnvTest
+	cfg.JWT.Secret = "test-secret-that-is-long-enough-for-tests"
+
+	env := &testEnv{
+		cfg:  cfg,
+		log:  logger.NewNop(),
+		db:   memory.NewDatabase(),
+		mail: fakes.NewMailbox(),
+		bus:  eventbus.NewBus(),
+	}
+	env.repos = memory.NewRepositories(env.db)
+	env.auth = env.newService(t, env.repositories(), nil)
+	return env
+}
+
+// repositories returns the environment's repositories in the form the
+// authentication service takes them, for tests to swap one out
+func (e *testEnv) repositories() *auth.Repositories {
+	return &auth.Repositories{
+		Users:          auth.NewUserRepository(e.repos.Users),
+		Sessions:       e.repos.Sessions,
+		Verifications:  e.repos.Verifications,
+		TwoFactor:      e.repos.TwoFactor,
+		Passkeys:       e.repos.Passkeys,
+		LoginAttempts:  e.repos.LoginAttempts,
+		LoginHistory:   e.repos.LoginHistory,
+		OAuthApps:      e.repos.OAuthApps,
+		OAuthGrants:    e.repos.OAuthGrants,
+		PersonalTokens: e.repos.PersonalTokens,
+		AdminAudit:     e.repos.AdminAudit,
+	}
+}
+
+// newService builds another authentication service on repos, locating
+// logins with geoIP
+func (e *testEnv) newService(t *testing.T, repos *auth.Repositories, geoIP auth.GeoIPService) *auth.AuthService {
+	t.Helper()
+
+	keys, err := keyring.New(e.repos.SigningKeys, keyring.Options{
+		Algorithm:        e.cfg.JWT.Algorithm,
+		Secret:           e.cfg.JWT.Secret,
+		RotationInterval: e.cfg.JWT.KeyRotationInterval,
+		GracePeriod:      e.cfg.JWT.KeyGracePeriod,
+	}, e.log)
+	if err != nil {
+		t.Fatalf("create keyring: %v", err)
+	}
+	if err := keys.Rotate(context.Background()); err != nil {
+		t.Fatalf("create signing key: %v", err)
+	}
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    e.cfg.WebAuthn.RPID,
+		RPName:  e.cfg.WebAuthn.RPName,
+		Origins: e.cfg.WebAuthn.Origins,
+		Timeout: e.cfg.WebAuthn.Timeout,
+	})
+	if err != nil {
+		t.Fatalf("create webauthn relying party: %v", err)
+	}
+
+	service := auth.NewService(repos, keys, rp, geoIP, e.breached, e.mail, notification.NewService(e.repos.Notifications), e.repos.Transactor, eventbus.NewOutbox(e.repos.Outbox), e.cfg, e.log)
+	service.Subscribe(e.bus)
+	return service
+}
+
+// count returns how many documents in collection match filter
+func (e *testEnv) count(t *testing.T, collection string, filter bson.M) int {
+	t.Helper()
+	n, err := e.db.Collection(collection).Count(filter)
+	if err != nil {
+		t.Fatalf("count %s: %v", collection, err)
+	}
+	return n
+}
+
+// deliverEvents hands every pending outbox event to its subscribers and
+// marks it published, standing in for the relay
+func (e *testEnv) deliverEvents(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+
+	for {
+		messages, err := e.repos.Outbox.GetPending(ctx, time.Now(), 100)
+		if err != nil {
+			t.Fatalf("read outbox: %v", err)
+		}
+		if len(messages) == 0 {
+			return
+		}
+		for _, message := range messages {
+			envelope := eventbus.NewEnvelope(message)
+			if err := e.bus.Dispatch(ctx, &envelope); err != nil {
+				t.Fatalf("deliver %s event %s: %v", message.Type, message.ID.Hex(), err)
+			}
+			if err := e.repos.Outbox.MarkPublished(ctx, message.ID); err != nil {
+				t.Fatalf("mark %s published: %v", message.ID.Hex(), err)
+			}
+		}
+	}
+}
@@ -2,16 +2,27 @@ package auth
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"strings"
 	"time"
 
-	"github.com/mssola/user_agent"
+	"github.com/mssola/useragent"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/geoip"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Reasons recorded when a session is revoked
+const (
+	RevokedReasonTokenReuse = "refresh_token_reuse"
+	RevokedReasonReported   = "reported_by_user" // Signed out from a login alert
+
+	RevokedReasonImpersonationEnded = "impersonation_ended"
 )
 
 // SessionService handles session management
@@ -42,8 +53,9 @@ func NewSessionService(
 
 // CreateSession creates a new session
 func (s *SessionService) CreateSession(ctx context.Context, userID primitive.ObjectID, userAgent, ipAddress string) (*models.Session, error) {
-	// Generate JWT token
-	token, err := s.jwtService.GenerateToken(userID)
+	// Generate JWT token, bound to the session it is created for
+	sessionID := primitive.NewObjectID()
+	token, err := s.jwtService.GenerateToken(userID, sessionID)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to generate token")
 	}
@@ -57,17 +69,19 @@ func (s *SessionService) CreateSession(ctx context.Context, userID primitive.Obj
 	// Create session
 	now := time.Now()
 	session := &models.Session{
-		UserID:       userID,
-		Token:        token,
-		RefreshToken: refreshToken,
-		UserAgent:    userAgent,
-		IPAddress:    ipAddress,
-		Device:       s.DetectDevice(userAgent),
-		Location:     s.GetLocationFromIP(ipAddress),
-		CreatedAt:    now,
-		LastUsedAt:   now,
-		ExpiresAt:    s.refreshService.GetExpirationTime(),
-		IsActive:     true,
+		ID:               sessionID,
+		UserID:           userID,
+		Token:            token,
+		RefreshToken:     refreshToken,
+		RefreshTokenHash: s.refreshService.HashRefreshToken(refreshToken),
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		Device:           s.DetectDevice(userAgent),
+		Location:         s.GetLocationFromIP(ipAddress),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        s.refreshService.GetExpirationTime(),
+		IsActive:         true,
 	}
 
 	// Save session
@@ -79,8 +93,10 @@ func (s *SessionService) CreateSession(ctx context.Context, userID primitive.Obj
 	return createdSession, nil
 }
 
-// CreatePendingSession creates a session that requires 2FA verification
-func (s *SessionService) CreatePendingSession(ctx context.Context, userID primitive.ObjectID, userAgent, ipAddress string) (*models.Session, error) {
+// CreatePendingSession creates a session that waits for a second step: 2FA
+// verification, or an emailed code for a risky login. codeHash is the hash of
+// the emailed code and is empty for 2FA.
+func (s *SessionService) CreatePendingSession(ctx context.Context, userID primitive.ObjectID, factor, codeHash, userAgent, ipAddress string) (*models.Session, error) {
 	// Create a temporary session without a full JWT token
 	now := time.Now()
 
@@ -91,17 +107,19 @@ func (s *SessionService) CreatePendingSession(ctx context.Context, userID primit
 	}
 
 	session := &models.Session{
-		UserID:       userID,
-		Token:        "pending_" + tempToken, // Mark as pending
-		RefreshToken: "",                     // No refresh token for pending sessions
-		UserAgent:    userAgent,
-		IPAddress:    ipAddress,
-		Device:       s.DetectDevice(userAgent),
-		Location:     s.GetLocationFromIP(ipAddress),
-		CreatedAt:    now,
-		LastUsedAt:   now,
-		ExpiresAt:    now.Add(15 * time.Minute), // Short expiry for pending sessions
-		IsActive:     true,
+		UserID:         userID,
+		Token:          "pending_" + tempToken, // Mark as pending
+		RefreshToken:   "",                     // No refresh token for pending sessions
+		UserAgent:      userAgent,
+		IPAddress:      ipAddress,
+		Device:         s.DetectDevice(userAgent),
+		Location:       s.GetLocationFromIP(ipAddress),
+		CreatedAt:      now,
+		LastUsedAt:     now,
+		ExpiresAt:      now.Add(15 * time.Minute), // Short expiry for pending sessions
+		IsActive:       true,
+		PendingFactor:  factor,
+		StepUpCodeHash: codeHash,
 	}
 
 	// Save session
@@ -113,8 +131,9 @@ func (s *SessionService) CreatePendingSession(ctx context.Context, userID primit
 	return createdSession, nil
 }
 
-// CompleteTwoFactorAuth completes a pending session after 2FA verification
-func (s *SessionService) CompleteTwoFactorAuth(ctx context.Context, sessionID primitive.ObjectID) (*models.Session, error) {
+// CompleteTwoFactorAuth promotes a pending session to a full one once factor,
+// the second step it was waiting for, has been verified
+func (s *SessionService) CompleteTwoFactorAuth(ctx context.Context, sessionID primitive.ObjectID, factor string) (*models.Session, error) {
 	// Get the pending session
 	session, err := s.sessionRepo.FindByID(ctx, sessionID)
 	if err != nil {
@@ -122,12 +141,12 @@ func (s *SessionService) CompleteTwoFactorAuth(ctx context.Context, sessionID pr
 	}
 
 	// Check if session is pending
-	if !strings.HasPrefix(session.Token, "pending_") {
+	if !strings.HasPrefix(session.Token, "pending_") || session.PendingFactor != factor {
 		return nil, errors.New(errors.CodeInvalidOperation, "Session is not pending 2FA verification")
 	}
 
 	// Generate full JWT token
-	token, err := s.jwtService.GenerateToken(session.UserID)
+	token, err := s.jwtService.GenerateToken(session.UserID, session.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to generate token")
 	}
@@ -142,12 +161,18 @@ func (s *SessionService) CompleteTwoFactorAuth(ctx context.Context, sessionID pr
 	now := time.Now()
 	session.Token = token
 	session.RefreshToken = refreshToken
+	session.RefreshTokenHash = s.refreshService.HashRefreshToken(refreshToken)
 	session.LastUsedAt = now
 	session.ExpiresAt = s.refreshService.GetExpirationTime()
+	session.PendingFactor = ""
+	session.StepUpCodeHash = ""
 
-	// Save updated session
-	err = s.sessionRepo.Update(ctx, session)
+	// Issue the first refresh token of the session. Only one completion wins.
+	err = s.sessionRepo.RotateRefreshToken(ctx, session, "")
 	if err != nil {
+		if stderrors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New(errors.CodeInvalidToken, "Login has expired, sign in again")
+		}
 		return nil, errors.Wrap(err, "Failed to update session")
 	}
 
@@ -184,7 +209,7 @@ func (s *SessionService) DetectDevice(userAgentString string) string {
 		return "unknown"
 	}
 
-	ua := user_agent.New(userAgentString)
+	ua := useragent.New(userAgentString)
 
 	// Get browser
 	browserName, browserVersion := ua.Browser()
@@ -192,15 +217,30 @@ func (s *SessionService) DetectDevice(userAgentString string) string {
 	// Get OS
 	os := ua.OS()
 
-	// Get device type
-	deviceType := "desktop"
-	if ua.Mobile() {
-		deviceType = "mobile"
-	} else if strings.Contains(strings.ToLower(userAgentString), "tablet") {
-		deviceType = "tablet"
+	return fmt.Sprintf("%s / %s %s / %s", deviceType(ua, userAgentString), browserName, browserVersion, os)
+}
+
+// deviceKey identifies the kind of device a user agent runs on: its type,
+// browser and OS without versions, so updates do not make a device look new
+func deviceKey(userAgentString string) string {
+	if userAgentString == "" {
+		return "unknown"
 	}
 
-	return fmt.Sprintf("%s / %s %s / %s", deviceType, browserName, browserVersion, os)
+	ua := useragent.New(userAgentString)
+	browserName, _ := ua.Browser()
+	return strings.ToLower(fmt.Sprintf("%s/%s/%s", deviceType(ua, userAgentString), browserName, ua.OSInfo().Name))
+}
+
+// deviceType returns desktop, mobile or tablet
+func deviceType(ua *useragent.UserAgent, userAgentString string) string {
+	if ua.Mobile() {
+		return "mobile"
+	}
+	if strings.Contains(strings.ToLower(userAgentString), "tablet") {
+		return "tablet"
+	}
+	return "desktop"
 }
 
 // GetLocationFromIP gets location information from IP address
@@ -208,9 +248,12 @@ func (s *SessionService) GetLocationFromIP(ipAddress string) string {
 	if ipAddress == "" || ipAddress == "127.0.0.1" || ipAddress == "::1" {
 		return "local"
 	}
+	if s.geoIPService == nil {
+		return "unknown"
+	}
 
 	// Use GeoIP service to get location
-	location, err := s.geoIPService.GetLocation(ipAddress)
+	location, err := s.geoIPService.Lookup(ipAddress)
 	if err != nil {
 		return "unknown"
 	}
@@ -224,15 +267,8 @@ func (s *SessionService) GetLocationFromIP(ipAddress string) string {
 	return "unknown"
 }
 
-// GeoIPService provides location information from IP addresses
+// GeoIPService provides location information from IP addresses. It is
+// implemented by *geoip.DB, which reads a local database file.
 type GeoIPService interface {
-	GetLocation(ipAddress string) (*GeoLocation, error)
-}
-
-// GeoLocation contains geographical information about an IP address
-type GeoLocation struct {
-	Country  string
-	City     string
-	Region   string
-	Timezone string
+	Lookup(ipAddress string) (*geoip.Location, error)
 }
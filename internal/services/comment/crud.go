@@ -6,9 +6,11 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // CRUDService handles basic CRUD operations for comments
@@ -16,7 +18,9 @@ type CRUDService struct {
 	commentRepo CommentRepository
 	postRepo    PostRepository
 	userRepo    UserRepository
-	logger      logging.Logger
+	tx          interfaces.Transactor
+	events      eventbus.Publisher
+	logger      *logger.Logger
 }
 
 // NewCRUDService creates a new CRUD service for comments
@@ -24,12 +28,16 @@ func NewCRUDService(
 	commentRepo CommentRepository,
 	postRepo PostRepository,
 	userRepo UserRepository,
-	logger logging.Logger,
+	tx interfaces.Transactor,
+	events eventbus.Publisher,
+	logger *logger.Logger,
 ) *CRUDService {
 	return &CRUDService{
 		commentRepo: commentRepo,
 		postRepo:    postRepo,
 		userRepo:    userRepo,
+		tx:          tx,
+		events:      events,
 		logger:      logger,
 	}
 }
@@ -45,16 +53,34 @@ func (s *CRUDService) CreateComment(ctx context.Context, comment *models.Comment
 	comment.IsEdited = false
 	comment.IsHidden = false
 
-	// Create the comment
-	createdComment, err := s.commentRepo.Create(ctx, comment)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create comment")
-	}
+	// Create the comment, update the post's comment count and record the
+	// event together; notifications are sent by the event's subscribers
+	var createdComment *models.Comment
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		created, err := s.commentRepo.Create(ctx, comment)
+		if err != nil {
+			return errors.Wrap(err, "Failed to create comment")
+		}
+
+		if err := s.postRepo.IncrementCommentCount(ctx, comment.PostID); err != nil {
+			return errors.Wrap(err, "Failed to increment comment count")
+		}
 
-	// Update comment count on post
-	err = s.postRepo.IncrementCommentCount(ctx, comment.PostID)
+		err = s.events.Publish(ctx, eventbus.CommentAdded{
+			CommentID: created.ID,
+			PostID:    created.PostID,
+			UserID:    created.UserID,
+			ParentID:  created.ParentID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to record comment event")
+		}
+
+		createdComment = created
+		return nil
+	})
 	if err != nil {
-		s.logger.Warn("Failed to increment comment count", "postId", comment.PostID.Hex(), "error", err)
+		return nil, err
 	}
 
 	return createdComment, nil
@@ -137,48 +163,63 @@ func (s *CRUDService) UpdateComment(ctx context.Context, id primitive.ObjectID,
 	return comment, nil
 }
 
-// DeleteComment soft deletes a comment
+// DeleteComment soft deletes a comment. Deleted comments no longer count
+// towards the post's comment count.
 func (s *CRUDService) DeleteComment(ctx context.Context, id primitive.ObjectID) error {
-	// Get comment
-	comment, err := s.commentRepo.FindByID(ctx, id)
-	if err != nil {
-		return errors.Wrap(err, "Failed to find comment")
-	}
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// Get comment
+		comment, err := s.commentRepo.FindByID(ctx, id)
+		if err != nil {
+			return errors.Wrap(err, "Failed to find comment")
+		}
+		if comment.DeletedAt != nil {
+			return nil
+		}
 
-	// Set deletion time
-	now := time.Now()
-	comment.DeletedAt = &now
+		// Set deletion time
+		now := time.Now()
+		comment.DeletedAt = &now
 
-	// Update comment
-	err = s.commentRepo.Update(ctx, comment)
-	if err != nil {
-		return errors.Wrap(err, "Failed to delete comment")
-	}
+		// Update comment
+		if err := s.commentRepo.Update(ctx, comment); err != nil {
+			return errors.Wrap(err, "Failed to delete comment")
+		}
 
-	return nil
+		// Update comment count on post
+		if err := s.postRepo.DecrementCommentCount(ctx, comment.PostID); err != nil {
+			return errors.Wrap(err, "Failed to decrement comment count")
+		}
+
+		return nil
+	})
 }
 
 // HardDeleteComment permanently deletes a comment
 func (s *CRUDService) HardDeleteComment(ctx context.Context, id primitive.ObjectID) error {
-	// Get comment for post ID
-	comment, err := s.commentRepo.FindByID(ctx, id)
-	if err != nil {
-		return errors.Wrap(err, "Failed to find comment")
-	}
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// Get comment for post ID
+		comment, err := s.commentRepo.FindByID(ctx, id)
+		if err != nil {
+			return errors.Wrap(err, "Failed to find comment")
+		}
 
-	// Delete comment
-	err = s.commentRepo.Delete(ctx, id)
-	if err != nil {
-		return errors.Wrap(err, "Failed to delete comment")
-	}
+		// Delete comment
+		if err := s.commentRepo.Delete(ctx, id); err != nil {
+			return errors.Wrap(err, "Failed to delete comment")
+		}
 
-	// Update comment count on post
-	err = s.postRepo.DecrementCommentCount(ctx, comment.PostID)
-	if err != nil {
-		s.logger.Warn("Failed to decrement comment count", "postId", comment.PostID.Hex(), "error", err)
-	}
+		// A soft-deleted comment was already taken off the post's count
+		if comment.DeletedAt != nil {
+			return nil
+		}
+
+		// Update comment count on post
+		if err := s.postRepo.DecrementCommentCount(ctx, comment.PostID); err != nil {
+			return errors.Wrap(err, "Failed to decrement comment count")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // GetCommentsByStatus retrieves comments with a specific status
@@ -200,3 +241,22 @@ func (s *CRUDService) GetTotalCommentCount(ctx context.Context, postID primitive
 
 	return s.commentRepo.Count(ctx, filter)
 }
+
+// CommentListOptions represents pagination, sorting and filtering for comment listings
+type CommentListOptions struct {
+	Page          int                    `json:"page"`
+	Limit         int                    `json:"limit"`
+	SortBy        string                 `json:"sort_by"`
+	SortOrder     string                 `json:"sort_order"`
+	IncludeHidden bool                   `json:"include_hidden"`
+	Since         *time.Time             `json:"since,omitempty"`
+	Until         *time.Time             `json:"until,omitempty"`
+	Filters       map[string]interface{} `json:"filters,omitempty"`
+}
+
+// CommentUpdates represents fields that can be updated on a comment
+type CommentUpdates struct {
+	Content        string               `json:"content"`
+	MediaFiles     []models.Media       `json:"media_files,omitempty"`
+	MentionedUsers []primitive.ObjectID `json:"mentioned_users,omitempty"`
+}
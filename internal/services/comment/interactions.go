@@ -6,9 +6,9 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // InteractionsService handles interactions with comments (likes, reactions)
@@ -16,7 +16,7 @@ type InteractionsService struct {
 	commentRepo CommentRepository
 	likeRepo    LikeRepository
 	userRepo    UserRepository
-	logger      logging.Logger
+	logger      *logger.Logger
 }
 
 // NewInteractionsService creates a new interactions service
@@ -24,7 +24,7 @@ func NewInteractionsService(
 	commentRepo CommentRepository,
 	likeRepo LikeRepository,
 	userRepo UserRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *InteractionsService {
 	return &InteractionsService{
 		commentRepo: commentRepo,
@@ -275,3 +275,19 @@ func (s *InteractionsService) updateReactionCounts(ctx context.Context, comment
 		s.logger.Warn("Failed to update reaction counts", "commentId", comment.ID.Hex(), "error", err)
 	}
 }
+
+// LikeListOptions represents pagination for like listings
+type LikeListOptions struct {
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+	SortBy    string `json:"sort_by"`
+	SortOrder string `json:"sort_order"`
+}
+
+// UserReaction represents a user who reacted to a comment
+type UserReaction struct {
+	UserID         primitive.ObjectID `json:"user_id"`
+	Username       string             `json:"username"`
+	ProfilePicture string             `json:"profile_picture"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
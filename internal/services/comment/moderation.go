@@ -6,28 +6,31 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // ModerationService handles comment moderation
 type ModerationService struct {
 	commentRepo CommentRepository
+	postRepo    PostRepository
 	reportRepo  ReportRepository
 	userRepo    UserRepository
-	logger      logging.Logger
+	logger      *logger.Logger
 }
 
 // NewModerationService creates a new moderation service
 func NewModerationService(
 	commentRepo CommentRepository,
+	postRepo PostRepository,
 	reportRepo ReportRepository,
 	userRepo UserRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *ModerationService {
 	return &ModerationService{
 		commentRepo: commentRepo,
+		postRepo:    postRepo,
 		reportRepo:  reportRepo,
 		userRepo:    userRepo,
 		logger:      logger,
@@ -295,6 +298,9 @@ func (s *ModerationService) PinComment(ctx context.Context, commentID primitive.
 	if err != nil {
 		return errors.Wrap(err, "Failed to find user")
 	}
+	if err := s.checkCanPin(ctx, comment, user); err != nil {
+		return err
+	}
 
 	// Pin comment
 	comment.IsPinned = true
@@ -327,6 +333,9 @@ func (s *ModerationService) UnpinComment(ctx context.Context, commentID primitiv
 	if err != nil {
 		return errors.Wrap(err, "Failed to find user")
 	}
+	if err := s.checkCanPin(ctx, comment, user); err != nil {
+		return err
+	}
 
 	// Unpin comment
 	comment.IsPinned = false
@@ -340,3 +349,27 @@ func (s *ModerationService) UnpinComment(ctx context.Context, commentID primitiv
 
 	return nil
 }
+
+// checkCanPin allows moderators and the owner of the post to pin and unpin its comments
+func (s *ModerationService) checkCanPin(ctx context.Context, comment *models.Comment, user *models.User) error {
+	if user.Role == "admin" || user.Role == "moderator" {
+		return nil
+	}
+
+	post, err := s.postRepo.FindByID(ctx, comment.PostID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find post")
+	}
+	if post.UserID != user.ID {
+		return errors.New(errors.CodeForbidden, "Only the post owner or a moderator can pin comments")
+	}
+	return nil
+}
+
+// ReportListOptions represents pagination for report listings
+type ReportListOptions struct {
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+	SortBy    string `json:"sort_by"`
+	SortOrder string `json:"sort_order"`
+}
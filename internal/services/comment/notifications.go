@@ -5,27 +5,30 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/internal/notification"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // NotificationsService handles notifications for comment actions
 type NotificationsService struct {
-	notifier    notification.Service
+	notifier    *notification.Service
 	userRepo    UserRepository
 	postRepo    PostRepository
 	commentRepo CommentRepository
-	logger      logging.Logger
+	logger      *logger.Logger
 }
 
 // NewNotificationsService creates a new notifications service
 func NewNotificationsService(
-	notifier notification.Service,
+	notifier *notification.Service,
 	userRepo UserRepository,
 	postRepo PostRepository,
 	commentRepo CommentRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *NotificationsService {
 	return &NotificationsService{
 		notifier:    notifier,
@@ -36,6 +39,46 @@ func NewNotificationsService(
 	}
 }
 
+// Subscribe registers the comment notifications with the event bus, so they
+// are sent once the comment has committed and retried on their own
+func (s *NotificationsService) Subscribe(bus *eventbus.Bus) {
+	eventbus.On(bus, constants.SubscriberNotifications, s.handleCommentAdded)
+}
+
+// handleCommentAdded notifies about a new comment or reply
+func (s *NotificationsService) handleCommentAdded(ctx context.Context, event eventbus.CommentAdded) error {
+	comment, err := s.commentRepo.FindByID(ctx, event.CommentID)
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			// Deleted before the notification went out
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find comment")
+	}
+
+	if event.ParentID != nil {
+		parentComment, err := s.commentRepo.FindByID(ctx, *event.ParentID)
+		if err != nil {
+			if errors.Code(err) == errors.CodeNotFound {
+				return nil
+			}
+			return errors.Wrap(err, "Failed to find parent comment")
+		}
+		s.NotifyNewReply(ctx, comment, parentComment)
+		return nil
+	}
+
+	post, err := s.postRepo.FindByID(ctx, event.PostID)
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find post")
+	}
+	s.NotifyNewComment(ctx, comment, post)
+	return nil
+}
+
 // NotifyNewComment sends notifications for a new comment
 func (s *NotificationsService) NotifyNewComment(ctx context.Context, comment *models.Comment, post *models.Post) {
 	// Notify post owner if it's not their own comment
@@ -152,8 +195,8 @@ func (s *NotificationsService) NotifyCommentReaction(ctx context.Context, commen
 
 // NotifyCommentHidden sends notification when a comment is hidden by a moderator
 func (s *NotificationsService) NotifyCommentHidden(ctx context.Context, comment *models.Comment, moderatorID primitive.ObjectID, reason string) {
-	// Get moderator info
-	moderator, err := s.userRepo.FindByID(ctx, moderatorID)
+	// Check the moderator exists; they stay anonymous in the notification
+	_, err := s.userRepo.FindByID(ctx, moderatorID)
 	if err != nil {
 		s.logger.Warn("Failed to find moderator for notification", "moderatorId", moderatorID.Hex(), "error", err)
 		return
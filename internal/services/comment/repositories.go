@@ -0,0 +1,328 @@
+package comment
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Repositories adapts the shared repositories to the interfaces the comment
+// services are written against
+type Repositories struct {
+	Comments CommentRepository
+	Likes    LikeRepository
+	Posts    PostRepository
+	Users    UserRepository
+	Reports  ReportRepository
+}
+
+// NewRepositories builds the comment service repositories on top of the shared ones
+func NewRepositories(comments interfaces.CommentRepository, likes interfaces.LikeRepository, posts interfaces.PostRepository, users interfaces.UserRepository, reports interfaces.ReportRepository) *Repositories {
+	return &Repositories{
+		Comments: &commentRepository{comments: comments},
+		Likes:    &likeRepository{likes: likes},
+		Posts:    &postRepository{posts: posts},
+		Users:    &userRepository{users: users},
+		Reports:  &reportRepository{reports: reports},
+	}
+}
+
+// commentRepository implements CommentRepository
+type commentRepository struct {
+	comments interfaces.CommentRepository
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	comment, err := r.comments.GetByID(ctx, id)
+	return comment, notFound(err, "Comment not found")
+}
+
+func (r *commentRepository) FindWithFilter(ctx context.Context, filter map[string]interface{}, page, limit int, sortBy, sortOrder string) ([]models.Comment, int, error) {
+	comments, total, err := r.comments.List(ctx, filter, sortSpec(sortBy, sortOrder), limit, pageOffset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values(comments), total, nil
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	if _, err := r.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *commentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	return notFound(r.comments.Update(ctx, comment), "Comment not found")
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.comments.Delete(ctx, id), "Comment not found")
+}
+
+func (r *commentRepository) Count(ctx context.Context, filter map[string]interface{}) (int, error) {
+	_, total, err := r.comments.List(ctx, filter, nil, 1, 0)
+	return total, err
+}
+
+func (r *commentRepository) IncrementLikeCount(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.comments.IncrementLikeCount(ctx, id, 1), "Comment not found")
+}
+
+func (r *commentRepository) DecrementLikeCount(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.comments.IncrementLikeCount(ctx, id, -1), "Comment not found")
+}
+
+func (r *commentRepository) IncrementReplyCount(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.comments.IncrementReplyCount(ctx, id, 1), "Comment not found")
+}
+
+func (r *commentRepository) UpdateReactionCounts(ctx context.Context, id primitive.ObjectID, reactionCounts map[string]int) error {
+	return notFound(r.comments.UpdateReactionCounts(ctx, id, reactionCounts), "Comment not found")
+}
+
+// GetTrending returns the most liked visible comments written since a time
+func (r *commentRepository) GetTrending(ctx context.Context, since time.Time, limit int) ([]models.Comment, error) {
+	filter := map[string]interface{}{"is_hidden": false}
+	if !since.IsZero() {
+		filter["created_at"] = map[string]interface{}{"$gte": since}
+	}
+	comments, _, err := r.comments.List(ctx, filter, map[string]int{"like_count": -1}, limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	return values(comments), nil
+}
+
+// GetMostActiveCommenters returns the users who wrote the most comments since a time
+func (r *commentRepository) GetMostActiveCommenters(ctx context.Context, since time.Time, limit int) ([]UserCommentCount, error) {
+	userIDs, err := r.comments.GetTopCommenters(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]UserCommentCount, 0, len(userIDs))
+	for _, userID := range userIDs {
+		count, err := r.Count(ctx, sinceFilter("user_id", userID, since))
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, UserCommentCount{UserID: userID, CommentCount: count})
+	}
+	return counts, nil
+}
+
+// GetMostCommentedPosts returns the posts that received the most comments since a time
+func (r *commentRepository) GetMostCommentedPosts(ctx context.Context, since time.Time, limit int) ([]PostCommentCount, error) {
+	postIDs, err := r.comments.GetMostCommentedPosts(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]PostCommentCount, 0, len(postIDs))
+	for _, postID := range postIDs {
+		count, err := r.Count(ctx, sinceFilter("post_id", postID, since))
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, PostCommentCount{PostID: postID, CommentCount: count})
+	}
+	return counts, nil
+}
+
+// sinceFilter matches the comments with field set to id written since a time
+func sinceFilter(field string, id primitive.ObjectID, since time.Time) map[string]interface{} {
+	filter := map[string]interface{}{field: id}
+	if !since.IsZero() {
+		filter["created_at"] = map[string]interface{}{"$gte": since}
+	}
+	return filter
+}
+
+// likeRepository implements LikeRepository
+type likeRepository struct {
+	likes interfaces.LikeRepository
+}
+
+func (r *likeRepository) Create(ctx context.Context, like *models.Like) (*models.Like, error) {
+	if _, err := r.likes.Create(ctx, like); err != nil {
+		return nil, err
+	}
+	return like, nil
+}
+
+// Update saves a changed reaction; the reaction type is the only mutable field
+func (r *likeRepository) Update(ctx context.Context, like *models.Like) error {
+	return notFound(r.likes.UpdateReactionType(ctx, like.ID, like.ReactionType), "Reaction not found")
+}
+
+func (r *likeRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.likes.Delete(ctx, id), "Reaction not found")
+}
+
+func (r *likeRepository) FindByUserAndContent(ctx context.Context, userID, contentID primitive.ObjectID, contentType string) (*models.Like, error) {
+	like, err := r.likes.GetByUserAndContent(ctx, userID, contentID, contentType)
+	return like, notFound(err, "Reaction not found")
+}
+
+func (r *likeRepository) FindByContent(ctx context.Context, contentID primitive.ObjectID, contentType, reactionType string) ([]models.Like, error) {
+	return r.all(ctx, contentFilter(contentID, contentType, reactionType))
+}
+
+func (r *likeRepository) FindAllByContent(ctx context.Context, contentID primitive.ObjectID, contentType string) ([]models.Like, error) {
+	return r.all(ctx, contentFilter(contentID, contentType, ""))
+}
+
+func (r *likeRepository) FindByContentAndType(ctx context.Context, contentID primitive.ObjectID, contentType, reactionType string) ([]models.Like, error) {
+	return r.all(ctx, contentFilter(contentID, contentType, reactionType))
+}
+
+func (r *likeRepository) FindByContentWithPagination(ctx context.Context, contentID primitive.ObjectID, contentType, reactionType string, page, limit int, sortBy, sortOrder string) ([]models.Like, int, error) {
+	likes, total, err := r.likes.List(ctx, contentFilter(contentID, contentType, reactionType), sortSpec(sortBy, sortOrder), limit, pageOffset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values(likes), total, nil
+}
+
+func (r *likeRepository) all(ctx context.Context, filter map[string]interface{}) ([]models.Like, error) {
+	return listAll(func(limit, offset int) ([]*models.Like, int, error) {
+		return r.likes.List(ctx, filter, nil, limit, offset)
+	})
+}
+
+// contentFilter matches the reactions on a piece of content, optionally of one type
+func contentFilter(contentID primitive.ObjectID, contentType, reactionType string) map[string]interface{} {
+	filter := map[string]interface{}{"content_id": contentID, "content_type": contentType}
+	if reactionType != "" {
+		filter["reaction_type"] = reactionType
+	}
+	return filter
+}
+
+// postRepository implements PostRepository
+type postRepository struct {
+	posts interfaces.PostRepository
+}
+
+func (r *postRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	post, err := r.posts.GetByID(ctx, id)
+	return post, notFound(err, "Post not found")
+}
+
+func (r *postRepository) IncrementCommentCount(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.posts.IncrementCommentCount(ctx, id, 1), "Post not found")
+}
+
+func (r *postRepository) DecrementCommentCount(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.posts.IncrementCommentCount(ctx, id, -1), "Post not found")
+}
+
+// userRepository implements UserRepository
+type userRepository struct {
+	users interfaces.UserRepository
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := r.users.GetByID(ctx, id)
+	return user, notFound(err, "User not found")
+}
+
+// reportRepository implements ReportRepository
+type reportRepository struct {
+	reports interfaces.ReportRepository
+}
+
+func (r *reportRepository) Create(ctx context.Context, report *models.Report) (*models.Report, error) {
+	if _, err := r.reports.Create(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *reportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Report, error) {
+	report, err := r.reports.GetByID(ctx, id)
+	return report, notFound(err, "Report not found")
+}
+
+func (r *reportRepository) FindByReporterAndContent(ctx context.Context, reporterID, contentID primitive.ObjectID, contentType string) (*models.Report, error) {
+	filter := map[string]interface{}{"reporter_id": reporterID, "content_id": contentID, "content_type": contentType}
+	reports, _, err := r.reports.List(ctx, filter, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "Report not found")
+	}
+	return reports[0], nil
+}
+
+func (r *reportRepository) FindWithFilter(ctx context.Context, filter map[string]interface{}, page, limit int, sortBy, sortOrder string) ([]models.Report, int, error) {
+	reports, total, err := r.reports.List(ctx, filter, sortSpec(sortBy, sortOrder), limit, pageOffset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values(reports), total, nil
+}
+
+func (r *reportRepository) Update(ctx context.Context, report *models.Report) error {
+	return notFound(r.reports.Update(ctx, report), "Report not found")
+}
+
+// notFound turns a missing document into the not found error the services check for
+func notFound(err error, message string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.New(errors.CodeNotFound, message)
+	}
+	return err
+}
+
+// pageOffset converts a 1-based page into an offset
+func pageOffset(page, limit int) int {
+	if page < 1 {
+		page = 1
+	}
+	return (page - 1) * limit
+}
+
+// sortSpec converts a sort field and "asc"/"desc" order into a repository sort
+func sortSpec(sortBy, sortOrder string) map[string]int {
+	if sortBy == "" {
+		return nil
+	}
+	if sortOrder == "desc" {
+		return map[string]int{sortBy: -1}
+	}
+	return map[string]int{sortBy: 1}
+}
+
+// values dereferences a repository result list
+func values[T any](items []*T) []T {
+	result := make([]T, len(items))
+	for i, item := range items {
+		result[i] = *item
+	}
+	return result
+}
+
+// listAll reads every page of a repository listing
+func listAll[T any](list func(limit, offset int) ([]*T, int, error)) ([]T, error) {
+	var all []T
+	for offset := 0; ; offset += mongoutil.MaxLimit {
+		items, total, err := list(mongoutil.MaxLimit, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, values(items)...)
+		if len(items) == 0 || len(all) >= total {
+			return all, nil
+		}
+	}
+}
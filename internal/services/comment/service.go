@@ -6,11 +6,14 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
-	"github.com/Caqil/vyrall/internal/pkg/metrics"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+	"github.com/Caqil/vyrall/pkg/metrics"
 )
 
 // Service defines the interface for comment-related operations
@@ -72,8 +75,47 @@ type CommentService struct {
 	userRepo      UserRepository
 	reportRepo    ReportRepository
 	config        *config.CommentConfig
-	metrics       metrics.Collector
-	logger        logging.Logger
+	metrics       *metrics.Collector
+	logger        *logger.Logger
+}
+
+// NewService builds the comment service and the services behind it on top of
+// the shared repositories. Call Subscribe to have its notifications follow
+// domain events.
+func NewService(
+	comments interfaces.CommentRepository,
+	likes interfaces.LikeRepository,
+	posts interfaces.PostRepository,
+	users interfaces.UserRepository,
+	reports interfaces.ReportRepository,
+	notifier *notification.Service,
+	tx interfaces.Transactor,
+	publisher eventbus.Publisher,
+	config *config.CommentConfig,
+	metrics *metrics.Collector,
+	logger *logger.Logger,
+) *CommentService {
+	repos := NewRepositories(comments, likes, posts, users, reports)
+	return &CommentService{
+		crud:          NewCRUDService(repos.Comments, repos.Posts, repos.Users, tx, publisher, logger),
+		threading:     NewThreadingService(repos.Comments, repos.Posts, logger),
+		interactions:  NewInteractionsService(repos.Comments, repos.Likes, repos.Users, logger),
+		moderation:    NewModerationService(repos.Comments, repos.Posts, repos.Reports, repos.Users, logger),
+		notifications: NewNotificationsService(notifier, repos.Users, repos.Posts, repos.Comments, logger),
+		commentRepo:   repos.Comments,
+		likeRepo:      repos.Likes,
+		postRepo:      repos.Posts,
+		userRepo:      repos.Users,
+		reportRepo:    repos.Reports,
+		config:        config,
+		metrics:       metrics,
+		logger:        logger,
+	}
+}
+
+// Subscribe registers the comment notifications with the event bus
+func (s *CommentService) Subscribe(bus *eventbus.Bus) {
+	s.notifications.Subscribe(bus)
 }
 
 // NewCommentService creates a new comment service
@@ -89,8 +131,8 @@ func NewCommentService(
 	userRepo UserRepository,
 	reportRepo ReportRepository,
 	config *config.CommentConfig,
-	metrics metrics.Collector,
-	logger logging.Logger,
+	metrics *metrics.Collector,
+	logger *logger.Logger,
 ) Service {
 	return &CommentService{
 		crud:          crud,
@@ -121,15 +163,6 @@ func (s *CommentService) Create(ctx context.Context, comment *models.Comment) (*
 		return nil, err
 	}
 
-	// Get post for notification
-	post, err := s.postRepo.FindByID(ctx, comment.PostID)
-	if err != nil {
-		s.logger.Warn("Failed to get post for notifications", "postId", comment.PostID.Hex(), "error", err)
-	} else {
-		// Send notifications
-		s.notifications.NotifyNewComment(ctx, createdComment, post)
-	}
-
 	s.metrics.IncrementCounter("comment.created")
 	return createdComment, nil
 }
@@ -374,15 +407,6 @@ func (s *CommentService) CreateReply(ctx context.Context, parentID primitive.Obj
 		s.logger.Warn("Failed to increment reply count", "parentId", parentID.Hex(), "error", err)
 	}
 
-	// Get post for notification
-	post, err := s.postRepo.FindByID(ctx, parentComment.PostID)
-	if err != nil {
-		s.logger.Warn("Failed to get post for reply notification", "postId", parentComment.PostID.Hex(), "error", err)
-	} else {
-		// Send notifications
-		s.notifications.NotifyNewReply(ctx, reply, parentComment)
-	}
-
 	s.metrics.IncrementCounter("comment.replied")
 	return reply, nil
 }
@@ -810,7 +834,7 @@ func (s *CommentService) GetUserCommentActivity(ctx context.Context, userID prim
 			if _, ok := postTitles[comment.PostID]; !ok {
 				post, err := s.postRepo.FindByID(ctx, comment.PostID)
 				if err == nil {
-					postTitles[comment.PostID] = post.Title
+					postTitles[comment.PostID] = truncateText(post.Content, 50)
 				} else {
 					postTitles[comment.PostID] = "Unknown Post"
 				}
@@ -969,6 +993,104 @@ func (s *CommentService) GetMostCommentedPosts(ctx context.Context, timeframe st
 	return s.commentRepo.GetMostCommentedPosts(ctx, since, limit)
 }
 
+// CommentStats represents statistics about the comments on a post
+type CommentStats struct {
+	TotalCount       int            `json:"total_count"`
+	TopLevelCount    int            `json:"top_level_count"`
+	ReplyCount       int            `json:"reply_count"`
+	LikeCount        int            `json:"like_count"`
+	AverageLength    int            `json:"average_length"`
+	CommenterCount   int            `json:"commenter_count"`
+	MediaAttachments int            `json:"media_attachments"`
+	ReactionCounts   map[string]int `json:"reaction_counts"`
+	LatestComment    time.Time      `json:"latest_comment"`
+	MostLikedComment string         `json:"most_liked_comment"`
+}
+
+// UserCommentActivity represents a user's commenting activity
+type UserCommentActivity struct {
+	TotalComments     int               `json:"total_comments"`
+	CommentedPosts    int               `json:"commented_posts"`
+	TopPosts          []PostCommentInfo `json:"top_posts"`
+	LikesReceived     int               `json:"likes_received"`
+	RepliesReceived   int               `json:"replies_received"`
+	ReactionCounts    map[string]int    `json:"reaction_counts"`
+	CommentsPerMonth  map[string]int    `json:"comments_per_month"`
+	MostActive        time.Time         `json:"most_active"`
+	AvgCommentsPerDay float64           `json:"avg_comments_per_day"`
+}
+
+// PostCommentInfo represents how often a user commented on a post
+type PostCommentInfo struct {
+	PostID       primitive.ObjectID `json:"post_id"`
+	Title        string             `json:"title"`
+	CommentCount int                `json:"comment_count"`
+}
+
+// UserCommentCount represents how many comments a user wrote
+type UserCommentCount struct {
+	UserID       primitive.ObjectID `json:"user_id"`
+	CommentCount int                `json:"comment_count"`
+}
+
+// PostCommentCount represents how many comments a post received
+type PostCommentCount struct {
+	PostID       primitive.ObjectID `json:"post_id"`
+	CommentCount int                `json:"comment_count"`
+}
+
+// Repository interfaces
+
+// CommentRepository defines operations for comment data access
+type CommentRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error)
+	FindWithFilter(ctx context.Context, filter map[string]interface{}, page, limit int, sortBy, sortOrder string) ([]models.Comment, int, error)
+	Create(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	Count(ctx context.Context, filter map[string]interface{}) (int, error)
+	IncrementLikeCount(ctx context.Context, id primitive.ObjectID) error
+	DecrementLikeCount(ctx context.Context, id primitive.ObjectID) error
+	IncrementReplyCount(ctx context.Context, id primitive.ObjectID) error
+	UpdateReactionCounts(ctx context.Context, id primitive.ObjectID, reactionCounts map[string]int) error
+	GetTrending(ctx context.Context, since time.Time, limit int) ([]models.Comment, error)
+	GetMostActiveCommenters(ctx context.Context, since time.Time, limit int) ([]UserCommentCount, error)
+	GetMostCommentedPosts(ctx context.Context, since time.Time, limit int) ([]PostCommentCount, error)
+}
+
+// LikeRepository defines operations for comment like and reaction data access
+type LikeRepository interface {
+	Create(ctx context.Context, like *models.Like) (*models.Like, error)
+	Update(ctx context.Context, like *models.Like) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	FindByUserAndContent(ctx context.Context, userID, contentID primitive.ObjectID, contentType string) (*models.Like, error)
+	FindByContent(ctx context.Context, contentID primitive.ObjectID, contentType, reactionType string) ([]models.Like, error)
+	FindAllByContent(ctx context.Context, contentID primitive.ObjectID, contentType string) ([]models.Like, error)
+	FindByContentAndType(ctx context.Context, contentID primitive.ObjectID, contentType, reactionType string) ([]models.Like, error)
+	FindByContentWithPagination(ctx context.Context, contentID primitive.ObjectID, contentType, reactionType string, page, limit int, sortBy, sortOrder string) ([]models.Like, int, error)
+}
+
+// PostRepository defines the post operations comments need
+type PostRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error)
+	IncrementCommentCount(ctx context.Context, id primitive.ObjectID) error
+	DecrementCommentCount(ctx context.Context, id primitive.ObjectID) error
+}
+
+// UserRepository defines the user lookups comments need
+type UserRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+}
+
+// ReportRepository defines operations for comment report data access
+type ReportRepository interface {
+	Create(ctx context.Context, report *models.Report) (*models.Report, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Report, error)
+	FindByReporterAndContent(ctx context.Context, reporterID, contentID primitive.ObjectID, contentType string) (*models.Report, error)
+	FindWithFilter(ctx context.Context, filter map[string]interface{}, page, limit int, sortBy, sortOrder string) ([]models.Report, int, error)
+	Update(ctx context.Context, report *models.Report) error
+}
+
 // Helper methods
 
 // isValidReactionType checks if a reaction type is valid
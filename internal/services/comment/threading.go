@@ -6,23 +6,23 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // ThreadingService handles comment threading functionality
 type ThreadingService struct {
 	commentRepo CommentRepository
 	postRepo    PostRepository
-	logger      logging.Logger
+	logger      *logger.Logger
 }
 
 // NewThreadingService creates a new threading service
 func NewThreadingService(
 	commentRepo CommentRepository,
 	postRepo PostRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *ThreadingService {
 	return &ThreadingService{
 		commentRepo: commentRepo,
@@ -222,7 +222,7 @@ func (s *ThreadingService) GetThreadedComments(ctx context.Context, postID primi
 
 	// Get regular top-level comments
 	topFilter["is_pinned"] = false
-	topComments, total, err := s.commentRepo.FindWithFilter(ctx, topFilter, 1, options.TopLimit, sortBy, sortOrder)
+	topComments, _, err := s.commentRepo.FindWithFilter(ctx, topFilter, 1, options.TopLimit, sortBy, sortOrder)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to get top-level comments")
 	}
@@ -371,3 +371,23 @@ func (s *ThreadingService) GetRepliesFlatMap(ctx context.Context, commentID prim
 
 	return repliesMap, nil
 }
+
+// ThreadedCommentsOptions represents how much of a comment tree to load
+type ThreadedCommentsOptions struct {
+	MaxDepth      int        `json:"max_depth"`
+	TopLimit      int        `json:"top_limit"`
+	ReplyLimit    int        `json:"reply_limit"`
+	SortBy        string     `json:"sort_by"`
+	SortOrder     string     `json:"sort_order"`
+	IncludeHidden bool       `json:"include_hidden"`
+	Since         *time.Time `json:"since,omitempty"`
+	Until         *time.Time `json:"until,omitempty"`
+}
+
+// ThreadedComment represents a comment with the replies loaded beneath it
+type ThreadedComment struct {
+	Comment      models.Comment    `json:"comment"`
+	Replies      []ThreadedComment `json:"replies"`
+	HasMore      bool              `json:"has_more"`
+	TotalReplies int               `json:"total_replies"`
+}
@@ -6,9 +6,9 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // AnalyticsService handles analytics for events
@@ -17,7 +17,7 @@ type AnalyticsService struct {
 	attendeeRepo  AttendeeRepository
 	analyticsRepo EventAnalyticsRepository
 	userRepo      UserRepository
-	logger        logging.Logger
+	logger        *logger.Logger
 }
 
 // NewAnalyticsService creates a new analytics service for events
@@ -26,7 +26,7 @@ func NewAnalyticsService(
 	attendeeRepo AttendeeRepository,
 	analyticsRepo EventAnalyticsRepository,
 	userRepo UserRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *AnalyticsService {
 	return &AnalyticsService{
 		eventRepo:     eventRepo,
@@ -39,14 +39,13 @@ func NewAnalyticsService(
 
 // RecordEventView records a view of an event
 func (s *AnalyticsService) RecordEventView(ctx context.Context, eventID primitive.ObjectID, userID *primitive.ObjectID, sessionID string) error {
-	// Get event
-	event, err := s.eventRepo.FindByID(ctx, eventID)
-	if err != nil {
+	// Check the event exists
+	if _, err := s.eventRepo.FindByID(ctx, eventID); err != nil {
 		return errors.Wrap(err, "Failed to find event")
 	}
 
 	// Update view count
-	err = s.eventRepo.IncrementViewCount(ctx, eventID)
+	err := s.eventRepo.IncrementViewCount(ctx, eventID)
 	if err != nil {
 		s.logger.Warn("Failed to increment view count", "eventId", eventID.Hex(), "error", err)
 	}
@@ -6,9 +6,9 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // ManagementService handles event management operations
@@ -17,7 +17,7 @@ type ManagementService struct {
 	attendeeRepo AttendeeRepository
 	userRepo     UserRepository
 	groupRepo    GroupRepository
-	logger       logging.Logger
+	logger       *logger.Logger
 }
 
 // NewManagementService creates a new event management service
@@ -26,7 +26,7 @@ func NewManagementService(
 	attendeeRepo AttendeeRepository,
 	userRepo UserRepository,
 	groupRepo GroupRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *ManagementService {
 	return &ManagementService{
 		eventRepo:    eventRepo,
@@ -7,30 +7,32 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/internal/notification"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // NotificationsService handles notifications for events
 type NotificationsService struct {
-	notifier     notification.Service
+	notifier     *notification.Service
 	eventRepo    EventRepository
 	attendeeRepo AttendeeRepository
 	userRepo     UserRepository
 	groupRepo    GroupRepository
-	logger       logging.Logger
+	logger       *logger.Logger
 }
 
 // NewNotificationsService creates a new notifications service for events
 func NewNotificationsService(
-	notifier notification.Service,
+	notifier *notification.Service,
 	eventRepo EventRepository,
 	attendeeRepo AttendeeRepository,
 	userRepo UserRepository,
 	groupRepo GroupRepository,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *NotificationsService {
 	return &NotificationsService{
 		notifier:     notifier,
@@ -42,6 +44,43 @@ func NewNotificationsService(
 	}
 }
 
+// Subscribe registers the event notifications with the event bus, so they
+// are sent once the change has committed and retried on their own
+func (s *NotificationsService) Subscribe(bus *eventbus.Bus) {
+	eventbus.On(bus, constants.SubscriberNotifications, s.handleRSVPChanged)
+}
+
+// handleRSVPChanged tells the hosts about a new RSVP and the attendee about
+// leaving the waitlist
+func (s *NotificationsService) handleRSVPChanged(ctx context.Context, change eventbus.EventRSVPChanged) error {
+	event, err := s.eventRepo.FindByID(ctx, change.EventID)
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find event")
+	}
+
+	attendee, err := s.attendeeRepo.FindByEventAndUser(ctx, change.EventID, change.UserID)
+	if err != nil {
+		if errors.Code(err) == errors.CodeNotFound {
+			// The RSVP was removed before the notification went out
+			return nil
+		}
+		return errors.Wrap(err, "Failed to find attendee")
+	}
+
+	if change.Status != change.PreviousStatus {
+		if err := s.NotifyNewRSVP(ctx, event, attendee); err != nil {
+			return err
+		}
+	}
+	if change.PromotedFromWaitlist {
+		return s.NotifyEventWaitlistStatusChanged(ctx, event, attendee, true)
+	}
+	return nil
+}
+
 // NotifyEventCreated sends notifications when an event is created
 func (s *NotificationsService) NotifyEventCreated(ctx context.Context, event *models.Event) error {
 	// Get host
@@ -6,9 +6,9 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // RemindersService handles event reminders
@@ -18,7 +18,7 @@ type RemindersService struct {
 	attendeeRepo    AttendeeRepository
 	userRepo        UserRepository
 	notificationSvc *NotificationsService
-	logger          logging.Logger
+	logger          *logger.Logger
 }
 
 // NewRemindersService creates a new reminders service
@@ -28,7 +28,7 @@ func NewRemindersService(
 	attendeeRepo AttendeeRepository,
 	userRepo UserRepository,
 	notificationSvc *NotificationsService,
-	logger logging.Logger,
+	logger *logger.Logger,
 ) *RemindersService {
 	return &RemindersService{
 		reminderRepo:    reminderRepo,
@@ -281,7 +281,7 @@ func (s *RemindersService) ProcessDueReminders(ctx context.Context) (int, error)
 // CreateDefaultReminders creates default reminders for a user's upcoming events
 func (s *RemindersService) CreateDefaultReminders(ctx context.Context, userID primitive.ObjectID) (int, error) {
 	// Get user's upcoming events with RSVP "going"
-	attendees, err := s.attendeeRepo.FindByUserIDAndRSVP(ctx, userID, "going", 1, 100)
+	attendees, _, err := s.attendeeRepo.FindByUserIDAndRSVP(ctx, userID, "going", 1, 100)
 	if err != nil {
 		return 0, errors.Wrap(err, "Failed to find user's events")
 	}
@@ -418,8 +418,3 @@ func isValidReminderType(reminderType string) bool {
 
 	return validTypes[reminderType]
 }
-
-// timePtr returns a pointer to a time.Time
-func timePtr(t time.Time) *time.Time {
-	return &t
-}
@@ -0,0 +1,447 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Repositories adapts the shared repositories to the interfaces the event
+// services are written against
+type Repositories struct {
+	Events    EventRepository
+	Attendees AttendeeRepository
+	Reminders ReminderRepository
+	Analytics EventAnalyticsRepository
+	Users     UserRepository
+	Groups    GroupRepository
+}
+
+// NewRepositories builds the event service repositories on top of the shared ones
+func NewRepositories(events interfaces.EventRepository, users interfaces.UserRepository, follows interfaces.FollowRepository, groups interfaces.GroupRepository) *Repositories {
+	return &Repositories{
+		Events:    &eventRepository{events: events},
+		Attendees: &attendeeRepository{events: events},
+		Reminders: &reminderRepository{events: events},
+		Analytics: &analyticsRepository{events: events},
+		Users:     &userRepository{users: users, follows: follows},
+		Groups:    &groupRepository{groups: groups},
+	}
+}
+
+// eventRepository implements EventRepository
+type eventRepository struct {
+	events interfaces.EventRepository
+}
+
+func (r *eventRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+	event, err := r.events.GetByID(ctx, id)
+	return event, notFound(err, "Event not found")
+}
+
+func (r *eventRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Event, error) {
+	events, err := r.events.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return values(events), nil
+}
+
+func (r *eventRepository) FindWithFilter(ctx context.Context, filter map[string]interface{}, page, limit int, sortBy, sortOrder string) ([]models.Event, int, error) {
+	events, total, err := r.events.List(ctx, filter, sortSpec(sortBy, sortOrder), limit, pageOffset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values(events), total, nil
+}
+
+// FindTrending returns the most attended events created since a time
+func (r *eventRepository) FindTrending(ctx context.Context, since time.Time, limit int) ([]models.Event, error) {
+	filter := map[string]interface{}{"created_at": map[string]interface{}{"$gte": since}}
+	sort := map[string]int{"rsvp_count.going": -1}
+	events, _, err := r.events.List(ctx, filter, sort, limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	return values(events), nil
+}
+
+func (r *eventRepository) Create(ctx context.Context, event *models.Event) (*models.Event, error) {
+	if _, err := r.events.Create(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *eventRepository) Update(ctx context.Context, event *models.Event) error {
+	return notFound(r.events.Update(ctx, event), "Event not found")
+}
+
+func (r *eventRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.events.Delete(ctx, id), "Event not found")
+}
+
+// IncrementViewCount does nothing: events keep no view counter, views are
+// counted in the event analytics
+func (r *eventRepository) IncrementViewCount(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
+
+// IncrementShareCount does nothing: events keep no share counter, shares are
+// counted in the event analytics
+func (r *eventRepository) IncrementShareCount(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
+
+func (r *eventRepository) Count(ctx context.Context, filter map[string]interface{}) (int, error) {
+	_, total, err := r.events.List(ctx, filter, nil, 1, 0)
+	return total, err
+}
+
+// attendeeRepository implements AttendeeRepository
+type attendeeRepository struct {
+	events interfaces.EventRepository
+}
+
+func (r *attendeeRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.EventAttendee, error) {
+	attendees, _, err := r.events.ListAttendees(ctx, map[string]interface{}{"_id": id}, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(attendees) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "Attendee not found")
+	}
+	return attendees[0], nil
+}
+
+func (r *attendeeRepository) FindByEventID(ctx context.Context, eventID primitive.ObjectID) ([]models.EventAttendee, error) {
+	return r.all(ctx, map[string]interface{}{"event_id": eventID})
+}
+
+func (r *attendeeRepository) FindByEventIDWithPagination(ctx context.Context, eventID primitive.ObjectID, page, limit int) ([]models.EventAttendee, int, error) {
+	return r.page(ctx, map[string]interface{}{"event_id": eventID}, page, limit)
+}
+
+func (r *attendeeRepository) FindByEventIDAndRSVP(ctx context.Context, eventID primitive.ObjectID, rsvp string) ([]models.EventAttendee, error) {
+	return r.all(ctx, map[string]interface{}{"event_id": eventID, "rsvp": rsvp})
+}
+
+func (r *attendeeRepository) FindByEventIDAndRSVPWithPagination(ctx context.Context, eventID primitive.ObjectID, rsvp string, page, limit int) ([]models.EventAttendee, int, error) {
+	return r.page(ctx, map[string]interface{}{"event_id": eventID, "rsvp": rsvp}, page, limit)
+}
+
+func (r *attendeeRepository) FindByEventAndUser(ctx context.Context, eventID, userID primitive.ObjectID) (*models.EventAttendee, error) {
+	attendee, err := r.events.GetAttendeeByID(ctx, eventID, userID)
+	return attendee, notFound(err, "Attendee not found")
+}
+
+func (r *attendeeRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.EventAttendee, error) {
+	return r.all(ctx, map[string]interface{}{"user_id": userID})
+}
+
+func (r *attendeeRepository) FindByUserIDAndRSVP(ctx context.Context, userID primitive.ObjectID, rsvp string, page, limit int) ([]models.EventAttendee, int, error) {
+	return r.page(ctx, map[string]interface{}{"user_id": userID, "rsvp": rsvp}, page, limit)
+}
+
+// FindWaitlistedWithPagination returns the waitlist of an event in position order
+func (r *attendeeRepository) FindWaitlistedWithPagination(ctx context.Context, eventID primitive.ObjectID, page, limit int) ([]models.EventAttendee, int, error) {
+	filter := map[string]interface{}{"event_id": eventID, "is_waitlisted": true}
+	attendees, total, err := r.events.ListAttendees(ctx, filter, map[string]int{"waitlist_position": 1}, limit, pageOffset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values(attendees), total, nil
+}
+
+// Create adds the attendee to the event, then stores the caller's record,
+// including any waitlist placement, over the one AddAttendee created
+func (r *attendeeRepository) Create(ctx context.Context, attendee *models.EventAttendee) (*models.EventAttendee, error) {
+	id, err := r.events.AddAttendee(ctx, attendee.EventID, attendee.UserID, attendee.RSVP)
+	if err != nil {
+		return nil, notFound(err, "Event not found")
+	}
+	attendee.ID = id
+	if err := r.events.UpdateAttendee(ctx, attendee); err != nil {
+		return nil, err
+	}
+	return attendee, nil
+}
+
+func (r *attendeeRepository) Update(ctx context.Context, attendee *models.EventAttendee) error {
+	return notFound(r.events.UpdateAttendee(ctx, attendee), "Attendee not found")
+}
+
+func (r *attendeeRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	attendee, err := r.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return notFound(r.events.RemoveAttendee(ctx, attendee.EventID, attendee.UserID), "Attendee not found")
+}
+
+func (r *attendeeRepository) CountByEventIDAndRSVP(ctx context.Context, eventID primitive.ObjectID, rsvp string) (int, error) {
+	return r.CountWithFilter(ctx, map[string]interface{}{"event_id": eventID, "rsvp": rsvp})
+}
+
+func (r *attendeeRepository) CountWaitlisted(ctx context.Context, eventID primitive.ObjectID) (int, error) {
+	return r.CountWithFilter(ctx, map[string]interface{}{"event_id": eventID, "is_waitlisted": true})
+}
+
+func (r *attendeeRepository) CountCheckedIn(ctx context.Context, eventID primitive.ObjectID) (int, error) {
+	return r.CountWithFilter(ctx, map[string]interface{}{"event_id": eventID, "checked_in": true})
+}
+
+func (r *attendeeRepository) CountWithFilter(ctx context.Context, filter map[string]interface{}) (int, error) {
+	_, total, err := r.events.ListAttendees(ctx, filter, nil, 1, 0)
+	return total, err
+}
+
+func (r *attendeeRepository) page(ctx context.Context, filter map[string]interface{}, page, limit int) ([]models.EventAttendee, int, error) {
+	attendees, total, err := r.events.ListAttendees(ctx, filter, nil, limit, pageOffset(page, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values(attendees), total, nil
+}
+
+func (r *attendeeRepository) all(ctx context.Context, filter map[string]interface{}) ([]models.EventAttendee, error) {
+	return listAll(func(limit, offset int) ([]*models.EventAttendee, int, error) {
+		return r.events.ListAttendees(ctx, filter, nil, limit, offset)
+	})
+}
+
+// reminderRepository implements ReminderRepository
+type reminderRepository struct {
+	events interfaces.EventRepository
+}
+
+func (r *reminderRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.EventReminder, error) {
+	reminders, _, err := r.events.ListReminders(ctx, map[string]interface{}{"_id": id}, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(reminders) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "Reminder not found")
+	}
+	return reminders[0], nil
+}
+
+func (r *reminderRepository) FindByEventID(ctx context.Context, eventID primitive.ObjectID) ([]models.EventReminder, error) {
+	return r.all(ctx, map[string]interface{}{"event_id": eventID})
+}
+
+func (r *reminderRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.EventReminder, error) {
+	return r.all(ctx, map[string]interface{}{"user_id": userID})
+}
+
+func (r *reminderRepository) FindByEventAndUser(ctx context.Context, eventID, userID primitive.ObjectID) (*models.EventReminder, error) {
+	filter := map[string]interface{}{"event_id": eventID, "user_id": userID}
+	reminders, _, err := r.events.ListReminders(ctx, filter, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(reminders) == 0 {
+		return nil, errors.New(errors.CodeNotFound, "Reminder not found")
+	}
+	return reminders[0], nil
+}
+
+func (r *reminderRepository) FindDueReminders(ctx context.Context, before time.Time) ([]models.EventReminder, error) {
+	reminders, err := r.events.GetRemindersToSend(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	return values(reminders), nil
+}
+
+func (r *reminderRepository) Create(ctx context.Context, reminder *models.EventReminder) (*models.EventReminder, error) {
+	if _, err := r.events.CreateReminder(ctx, reminder); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func (r *reminderRepository) Update(ctx context.Context, reminder *models.EventReminder) error {
+	return notFound(r.events.UpdateReminder(ctx, reminder), "Reminder not found")
+}
+
+func (r *reminderRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return notFound(r.events.DeleteReminder(ctx, id), "Reminder not found")
+}
+
+func (r *reminderRepository) Count(ctx context.Context, filter map[string]interface{}) (int, error) {
+	_, total, err := r.events.ListReminders(ctx, filter, nil, 1, 0)
+	return total, err
+}
+
+func (r *reminderRepository) all(ctx context.Context, filter map[string]interface{}) ([]models.EventReminder, error) {
+	return listAll(func(limit, offset int) ([]*models.EventReminder, int, error) {
+		return r.events.ListReminders(ctx, filter, nil, limit, offset)
+	})
+}
+
+// analyticsRepository implements EventAnalyticsRepository. Viewers are
+// recorded as "user:<id>" and anonymous sessions as "session:<id>".
+type analyticsRepository struct {
+	events interfaces.EventRepository
+}
+
+// FindByEventID returns the stored analytics of an event, or a not found
+// error when none have been stored yet
+func (r *analyticsRepository) FindByEventID(ctx context.Context, eventID primitive.ObjectID) (*models.EventAnalytics, error) {
+	stats, err := r.events.GetEventStats(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if stats.ID.IsZero() {
+		return nil, errors.New(errors.CodeNotFound, "Event analytics not found")
+	}
+	return &stats, nil
+}
+
+func (r *analyticsRepository) Create(ctx context.Context, analytics *models.EventAnalytics) (*models.EventAnalytics, error) {
+	if err := r.events.SaveEventStats(ctx, analytics); err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+func (r *analyticsRepository) Update(ctx context.Context, analytics *models.EventAnalytics) error {
+	return r.events.SaveEventStats(ctx, analytics)
+}
+
+func (r *analyticsRepository) IsNewViewer(ctx context.Context, eventID, userID primitive.ObjectID) (bool, error) {
+	seen, err := r.events.HasViewer(ctx, eventID, userViewer(userID))
+	return !seen, err
+}
+
+func (r *analyticsRepository) IsNewSession(ctx context.Context, eventID primitive.ObjectID, sessionID string) (bool, error) {
+	seen, err := r.events.HasViewer(ctx, eventID, sessionViewer(sessionID))
+	return !seen, err
+}
+
+func (r *analyticsRepository) AddViewer(ctx context.Context, eventID, userID primitive.ObjectID) error {
+	return r.events.AddViewer(ctx, eventID, userViewer(userID))
+}
+
+func (r *analyticsRepository) AddSession(ctx context.Context, eventID primitive.ObjectID, sessionID string) error {
+	return r.events.AddViewer(ctx, eventID, sessionViewer(sessionID))
+}
+
+func userViewer(userID primitive.ObjectID) string {
+	return fmt.Sprintf("user:%s", userID.Hex())
+}
+
+func sessionViewer(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+// userRepository implements UserRepository
+type userRepository struct {
+	users   interfaces.UserRepository
+	follows interfaces.FollowRepository
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := r.users.GetByID(ctx, id)
+	return user, notFound(err, "User not found")
+}
+
+func (r *userRepository) FindFollowers(ctx context.Context, userID primitive.ObjectID) ([]*models.User, error) {
+	ids, err := r.follows.GetFollowerIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return r.users.FindByIDs(ctx, ids)
+}
+
+// groupRepository implements GroupRepository
+type groupRepository struct {
+	groups interfaces.GroupRepository
+}
+
+func (r *groupRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+	group, err := r.groups.GetByID(ctx, id)
+	return group, notFound(err, "Group not found")
+}
+
+func (r *groupRepository) FindMembers(ctx context.Context, groupID primitive.ObjectID) ([]*models.GroupMember, error) {
+	var members []*models.GroupMember
+	for offset := 0; ; offset += mongoutil.MaxLimit {
+		page, total, err := r.groups.GetMembers(ctx, groupID, "", mongoutil.MaxLimit, offset)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if len(page) == 0 || len(members) >= total {
+			return members, nil
+		}
+	}
+}
+
+func (r *groupRepository) IncrementEventCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.groups.IncrementEventCount(ctx, groupID)
+}
+
+func (r *groupRepository) DecrementEventCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.groups.DecrementEventCount(ctx, groupID)
+}
+
+// notFound turns a missing document into the not found error the services check for
+func notFound(err error, message string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.New(errors.CodeNotFound, message)
+	}
+	return err
+}
+
+// pageOffset converts a 1-based page into an offset
+func pageOffset(page, limit int) int {
+	if page < 1 {
+		page = 1
+	}
+	return (page - 1) * limit
+}
+
+// sortSpec converts a sort field and "asc"/"desc" order into a repository sort
+func sortSpec(sortBy, sortOrder string) map[string]int {
+	if sortBy == "" {
+		return nil
+	}
+	if sortOrder == "desc" {
+		return map[string]int{sortBy: -1}
+	}
+	return map[string]int{sortBy: 1}
+}
+
+// values dereferences a repository result list
+func values[T any](items []*T) []T {
+	result := make([]T, len(items))
+	for i, item := range items {
+		result[i] = *item
+	}
+	return result
+}
+
+// listAll reads every page of a repository listing
+func listAll[T any](list func(limit, offset int) ([]*T, int, error)) ([]T, error) {
+	var all []T
+	for offset := 0; ; offset += mongoutil.MaxLimit {
+		items, total, err := list(mongoutil.MaxLimit, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, values(items)...)
+		if len(items) == 0 || len(all) >= total {
+			return all, nil
+		}
+	}
+}
@@ -6,9 +6,11 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/errors"
 )
 
 // RSVPService handles event RSVPs
@@ -17,7 +19,9 @@ type RSVPService struct {
 	attendeeRepo    AttendeeRepository
 	userRepo        UserRepository
 	notificationSvc *NotificationsService
-	logger          logging.Logger
+	tx              interfaces.Transactor
+	events          eventbus.Publisher
+	logger          *logger.Logger
 }
 
 // NewRSVPService creates a new RSVP service
@@ -26,58 +30,100 @@ func NewRSVPService(
 	attendeeRepo AttendeeRepository,
 	userRepo UserRepository,
 	notificationSvc *NotificationsService,
-	logger logging.Logger,
+	tx interfaces.Transactor,
+	events eventbus.Publisher,
+	logger *logger.Logger,
 ) *RSVPService {
 	return &RSVPService{
 		eventRepo:       eventRepo,
 		attendeeRepo:    attendeeRepo,
 		userRepo:        userRepo,
 		notificationSvc: notificationSvc,
+		tx:              tx,
+		events:          events,
 		logger:          logger,
 	}
 }
 
-// RSVP creates or updates an RSVP for an event
+// RSVP creates or updates an RSVP for an event. The attendee record, the
+// event's RSVP counts and the EventRSVPChanged event are written in one
+// transaction; notifications are sent by the event's subscribers.
 func (s *RSVPService) RSVP(ctx context.Context, eventID, userID primitive.ObjectID, rsvpStatus string, guestCount int) (*models.EventAttendee, error) {
+	var attendee *models.EventAttendee
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		updated, oldRSVP, promoted, err := s.rsvp(ctx, eventID, userID, rsvpStatus, guestCount)
+		if err != nil {
+			return err
+		}
+		attendee = updated
+
+		if oldRSVP == rsvpStatus && !promoted {
+			return nil
+		}
+
+		err = s.events.Publish(ctx, eventbus.EventRSVPChanged{
+			EventID:              eventID,
+			UserID:               userID,
+			Status:               rsvpStatus,
+			PreviousStatus:       oldRSVP,
+			Waitlisted:           updated.IsWaitlisted,
+			PromotedFromWaitlist: promoted,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to record RSVP event")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attendee, nil
+}
+
+// rsvp creates or updates the attendee record and the event's RSVP counts. It
+// returns the previous RSVP status and whether the attendee left the waitlist.
+func (s *RSVPService) rsvp(ctx context.Context, eventID, userID primitive.ObjectID, rsvpStatus string, guestCount int) (*models.EventAttendee, string, bool, error) {
 	// Validate event exists
 	event, err := s.eventRepo.FindByID(ctx, eventID)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to find event")
+		return nil, "", false, errors.Wrap(err, "Failed to find event")
 	}
 
 	// Validate user exists
 	_, err = s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to find user")
+		return nil, "", false, errors.Wrap(err, "Failed to find user")
 	}
 
 	// Validate RSVP status
 	if !isValidRSVP(rsvpStatus) {
-		return nil, errors.New(errors.CodeInvalidArgument, "Invalid RSVP status")
+		return nil, "", false, errors.New(errors.CodeInvalidArgument, "Invalid RSVP status")
 	}
 
 	// Validate guest count
 	if guestCount < 0 {
-		return nil, errors.New(errors.CodeInvalidArgument, "Guest count cannot be negative")
+		return nil, "", false, errors.New(errors.CodeInvalidArgument, "Guest count cannot be negative")
 	}
 
 	// Check if event is cancelled
 	if event.Status == "cancelled" {
-		return nil, errors.New(errors.CodeInvalidOperation, "Cannot RSVP to a cancelled event")
+		return nil, "", false, errors.New(errors.CodeInvalidOperation, "Cannot RSVP to a cancelled event")
 	}
 
 	// Check if event is already over
 	if event.EndTime.Before(time.Now()) {
-		return nil, errors.New(errors.CodeInvalidOperation, "Cannot RSVP to a past event")
+		return nil, "", false, errors.New(errors.CodeInvalidOperation, "Cannot RSVP to a past event")
 	}
 
 	// Check if attendee record already exists
 	attendee, err := s.attendeeRepo.FindByEventAndUser(ctx, eventID, userID)
 	var oldRSVP string
+	var promoted bool
 
 	if err != nil {
 		if errors.Code(err) != errors.CodeNotFound {
-			return nil, errors.Wrap(err, "Failed to check existing RSVP")
+			return nil, "", false, errors.Wrap(err, "Failed to check existing RSVP")
 		}
 
 		// Create new attendee record
@@ -117,7 +163,7 @@ func (s *RSVPService) RSVP(ctx context.Context, eventID, userID primitive.Object
 		// Create attendee record
 		createdAttendee, err := s.attendeeRepo.Create(ctx, attendee)
 		if err != nil {
-			return nil, errors.Wrap(err, "Failed to create attendee record")
+			return nil, "", false, errors.Wrap(err, "Failed to create attendee record")
 		}
 
 		attendee = createdAttendee
@@ -166,9 +212,7 @@ func (s *RSVPService) RSVP(ctx context.Context, eventID, userID primitive.Object
 					// Remove from waitlist
 					attendee.IsWaitlisted = false
 					attendee.WaitlistPosition = 0
-
-					// Notify about waitlist change
-					s.notificationSvc.NotifyEventWaitlistStatusChanged(ctx, event, attendee, true)
+					promoted = true
 				}
 			}
 		} else if attendee.IsWaitlisted {
@@ -180,19 +224,14 @@ func (s *RSVPService) RSVP(ctx context.Context, eventID, userID primitive.Object
 		// Update attendee record
 		err = s.attendeeRepo.Update(ctx, attendee)
 		if err != nil {
-			return nil, errors.Wrap(err, "Failed to update attendee record")
+			return nil, "", false, errors.Wrap(err, "Failed to update attendee record")
 		}
 	}
 
 	// Update RSVP counts on event
 	s.updateEventRSVPCounts(ctx, event, oldRSVP, rsvpStatus)
 
-	// Send notification
-	if oldRSVP != rsvpStatus {
-		s.notificationSvc.NotifyNewRSVP(ctx, event, attendee)
-	}
-
-	return attendee, nil
+	return attendee, oldRSVP, promoted, nil
 }
 
 // GetAttendee retrieves an attendee record
@@ -455,7 +494,7 @@ func (s *RSVPService) updateEventRSVPCounts(ctx context.Context, event *models.E
 // promoteFromWaitlist promotes the next person from the waitlist
 func (s *RSVPService) promoteFromWaitlist(ctx context.Context, event *models.Event) {
 	// Get first person on waitlist
-	waitlistAttendees, err := s.attendeeRepo.FindWaitlistedWithPagination(ctx, event.ID, 1, 1)
+	waitlistAttendees, _, err := s.attendeeRepo.FindWaitlistedWithPagination(ctx, event.ID, 1, 1)
 	if err != nil {
 		s.logger.Warn("Failed to get waitlist", "eventId", event.ID.Hex(), "error", err)
 		return
@@ -490,7 +529,7 @@ func (s *RSVPService) promoteFromWaitlist(ctx context.Context, event *models.Eve
 // reorderWaitlist reorders waitlist positions after a promotion
 func (s *RSVPService) reorderWaitlist(ctx context.Context, eventID primitive.ObjectID) {
 	// Get all waitlisted attendees
-	waitlistAttendees, err := s.attendeeRepo.FindWaitlistedWithPagination(ctx, eventID, 1, 1000)
+	waitlistAttendees, _, err := s.attendeeRepo.FindWaitlistedWithPagination(ctx, eventID, 1, 1000)
 	if err != nil {
 		s.logger.Warn("Failed to get waitlist for reordering", "eventId", eventID.Hex(), "error", err)
 		return
@@ -6,11 +6,14 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Caqil/vyrall/internal/internal/models"
-	"github.com/Caqil/vyrall/internal/pkg/config"
-	"github.com/Caqil/vyrall/internal/pkg/errors"
-	"github.com/Caqil/vyrall/internal/pkg/logging"
-	"github.com/Caqil/vyrall/internal/pkg/metrics"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/errors"
+	"github.com/Caqil/vyrall/pkg/metrics"
 )
 
 // Service defines the interface for event-related operations
@@ -83,8 +86,46 @@ type EventService struct {
 	reminderRepo    ReminderRepository
 	analyticsRepo   EventAnalyticsRepository
 	config          *config.EventConfig
-	metrics         metrics.Collector
-	logger          logging.Logger
+	metrics         *metrics.Collector
+	logger          *logger.Logger
+}
+
+// NewService builds the event service and the services behind it on top of
+// the shared repositories. Call Subscribe to have its notifications follow
+// domain events.
+func NewService(
+	events interfaces.EventRepository,
+	users interfaces.UserRepository,
+	follows interfaces.FollowRepository,
+	groups interfaces.GroupRepository,
+	notifier *notification.Service,
+	tx interfaces.Transactor,
+	publisher eventbus.Publisher,
+	config *config.EventConfig,
+	metrics *metrics.Collector,
+	logger *logger.Logger,
+) *EventService {
+	repos := NewRepositories(events, users, follows, groups)
+	notifications := NewNotificationsService(notifier, repos.Events, repos.Attendees, repos.Users, repos.Groups, logger)
+	return &EventService{
+		managementSvc:   NewManagementService(repos.Events, repos.Attendees, repos.Users, repos.Groups, logger),
+		rsvpSvc:         NewRSVPService(repos.Events, repos.Attendees, repos.Users, notifications, tx, publisher, logger),
+		reminderSvc:     NewRemindersService(repos.Reminders, repos.Events, repos.Attendees, repos.Users, notifications, logger),
+		analyticsSvc:    NewAnalyticsService(repos.Events, repos.Attendees, repos.Analytics, repos.Users, logger),
+		notificationSvc: notifications,
+		eventRepo:       repos.Events,
+		attendeeRepo:    repos.Attendees,
+		reminderRepo:    repos.Reminders,
+		analyticsRepo:   repos.Analytics,
+		config:          config,
+		metrics:         metrics,
+		logger:          logger,
+	}
+}
+
+// Subscribe registers the event notifications with the event bus
+func (s *EventService) Subscribe(bus *eventbus.Bus) {
+	s.notificationSvc.Subscribe(bus)
 }
 
 // NewEventService creates a new event service
@@ -99,8 +140,8 @@ func NewEventService(
 	reminderRepo ReminderRepository,
 	analyticsRepo EventAnalyticsRepository,
 	config *config.EventConfig,
-	metrics metrics.Collector,
-	logger logging.Logger,
+	metrics *metrics.Collector,
+	logger *logger.Logger,
 ) Service {
 	return &EventService{
 		managementSvc:   managementSvc,
@@ -647,6 +688,20 @@ type EventAnalyticsRepository interface {
 	AddSession(ctx context.Context, eventID primitive.ObjectID, sessionID string) error
 }
 
+// UserRepository defines the user lookups events need
+type UserRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindFollowers(ctx context.Context, userID primitive.ObjectID) ([]*models.User, error)
+}
+
+// GroupRepository defines the group operations events need
+type GroupRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error)
+	FindMembers(ctx context.Context, groupID primitive.ObjectID) ([]*models.GroupMember, error)
+	IncrementEventCount(ctx context.Context, groupID primitive.ObjectID) error
+	DecrementEventCount(ctx context.Context, groupID primitive.ObjectID) error
+}
+
 // Helper functions
 
 // detectChanges identifies significant changes between event versions
@@ -0,0 +1,147 @@
+package media
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Caqil/vyrall/pkg/config"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Store removes uploaded files from an S3 bucket. Requests are signed with
+// AWS Signature Version 4.
+type S3Store struct {
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	endpoint  string
+	cdnBase   string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3Store creates a store for the configured bucket
+func NewS3Store(cfg *config.AWSConfig) (*S3Store, error) {
+	if cfg.S3Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("media: s3 bucket and region are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("media: aws credentials are required")
+	}
+
+	return &S3Store{
+		bucket:    cfg.S3Bucket,
+		region:    cfg.Region,
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey,
+		endpoint:  "https://" + cfg.S3Bucket + ".s3." + cfg.Region + ".amazonaws.com",
+		cdnBase:   strings.TrimSuffix(cfg.CDNBaseURL, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}, nil
+}
+
+// Delete removes the object a file URL points at. S3 answers a delete of a
+// missing object with success, so deleting a file twice is not an error.
+func (s *S3Store) Delete(ctx context.Context, fileURL string) error {
+	key, err := s.key(fileURL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.endpoint+"/"+escapePath(key), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("media: delete %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// key returns the object key of a file URL served from the CDN or the bucket
+func (s *S3Store) key(fileURL string) (string, error) {
+	for _, base := range []string{s.cdnBase, s.endpoint} {
+		if base != "" && strings.HasPrefix(fileURL, base+"/") {
+			return url.PathUnescape(strings.TrimPrefix(fileURL, base+"/"))
+		}
+	}
+	return "", fmt.Errorf("media: %s is not stored in bucket %s", fileURL, s.bucket)
+}
+
+// sign adds the Signature Version 4 headers for a request without a body
+func (s *S3Store) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + emptyPayloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// escapePath escapes each segment of an object key the way S3 expects
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,111 @@
+package moderation
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/pkg/errors"
+)
+
+// Moderation log actions
+const (
+	ActionAccountLocked   = "account_locked"
+	ActionAccountUnlocked = "account_unlocked"
+)
+
+// Moderation log target types
+const (
+	TargetUser = "user"
+)
+
+// LogService keeps the admin moderation log. Entries are written from domain
+// events, so an action is logged once the change behind it has committed.
+type LogService struct {
+	repo   ModerationLogRepository
+	logger *logger.Logger
+}
+
+// ModerationLogRepository handles moderation log storage
+type ModerationLogRepository interface {
+	Create(ctx context.Context, entry *models.ModerationLogEntry) error
+	List(ctx context.Context, limit, offset int) ([]*models.ModerationLogEntry, int, error)
+}
+
+// NewLogService creates a new moderation log service
+func NewLogService(repo ModerationLogRepository, logger *logger.Logger) *LogService {
+	return &LogService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Subscribe registers the moderation log with the event bus
+func (s *LogService) Subscribe(bus *eventbus.Bus) {
+	eventbus.OnEnvelope(bus, constants.SubscriberModerationLog, s.handleAccountLockedOut)
+	eventbus.OnEnvelope(bus, constants.SubscriberModerationLog, s.handleAccountUnlocked)
+}
+
+// List returns the moderation log, newest first
+func (s *LogService) List(ctx context.Context, limit, offset int) ([]*models.ModerationLogEntry, int, error) {
+	entries, total, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "Failed to retrieve moderation log")
+	}
+	return entries, total, nil
+}
+
+// handleAccountLockedOut logs an account locked by failed logins
+func (s *LogService) handleAccountLockedOut(ctx context.Context, envelope *eventbus.Envelope, event eventbus.AccountLockedOut) error {
+	userID := event.UserID
+	return s.record(ctx, envelope, &models.ModerationLogEntry{
+		Action:     ActionAccountLocked,
+		TargetType: TargetUser,
+		TargetID:   &userID,
+		Reason:     "Too many failed login attempts",
+		Details: map[string]string{
+			"email":        event.Email,
+			"failures":     strconv.Itoa(event.Failures),
+			"ip_address":   event.IPAddress,
+			"locked_until": event.LockedUntil.UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+// handleAccountUnlocked logs an admin lifting a lockout
+func (s *LogService) handleAccountUnlocked(ctx context.Context, envelope *eventbus.Envelope, event eventbus.AccountUnlocked) error {
+	userID, adminID := event.UserID, event.AdminID
+	return s.record(ctx, envelope, &models.ModerationLogEntry{
+		Action:     ActionAccountUnlocked,
+		TargetType: TargetUser,
+		TargetID:   &userID,
+		ActorID:    &adminID,
+		Reason:     "Login lockout lifted by an admin",
+		Details:    map[string]string{"email": event.Email},
+	})
+}
+
+// record writes an entry keyed by the event's outbox ID, so a redelivered
+// event is logged once
+func (s *LogService) record(ctx context.Context, envelope *eventbus.Envelope, entry *models.ModerationLogEntry) error {
+	if id, err := primitive.ObjectIDFromHex(envelope.ID); err == nil {
+		entry.ID = id
+	}
+	entry.CreatedAt = envelope.OccurredAt
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		s.logger.Warn("Failed to write moderation log entry", "action", entry.Action, "error", err)
+		return errors.Wrap(err, "Failed to write moderation log entry")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+)
+
+// Service delivers notifications to users. A notification is stored for the
+// recipient's in-app list; push and email delivery build on the stored record.
+type Service struct {
+	notifications interfaces.NotificationRepository
+}
+
+// NewService creates a notification service
+func NewService(notifications interfaces.NotificationRepository) *Service {
+	return &Service{notifications: notifications}
+}
+
+// Send stores a notification for its recipient
+func (s *Service) Send(ctx context.Context, notification *models.Notification) error {
+	_, err := s.notifications.Create(ctx, notification)
+	return err
+}
@@ -0,0 +1,221 @@
+package permission
+
+import "github.com/Caqil/vyrall/pkg/constants"
+
+// role declares the permissions a user role grants. A role also holds every
+// permission of the roles it inherits.
+type role struct {
+	inherits    []string
+	permissions []string
+}
+
+// roles is the platform-wide policy: what a user may do anywhere, by role
+var roles = map[string]role{
+	constants.RoleUser: {
+		permissions: []string{
+			constants.PermissionPostCreate,
+			constants.PermissionCommentCreate,
+			constants.PermissionGroupCreate,
+			constants.PermissionEventCreate,
+			constants.PermissionLiveStreamJoin,
+			constants.PermissionLiveStreamComment,
+			constants.PermissionLiveStreamReact,
+			constants.PermissionReportCreate,
+		},
+	},
+	constants.RoleCreator: {
+		inherits: []string{constants.RoleUser},
+		permissions: []string{
+			constants.PermissionLiveStreamCreate,
+			constants.PermissionAnalyticsRead,
+		},
+	},
+	constants.RoleBusiness: {
+		inherits: []string{constants.RoleUser},
+		permissions: []string{
+			constants.PermissionAdCreate,
+			constants.PermissionAdManage,
+			constants.PermissionAnalyticsRead,
+		},
+	},
+	constants.RoleSupport: {
+		inherits: []string{constants.RoleUser},
+		permissions: []string{
+			constants.PermissionAdminAccess,
+			constants.PermissionUserRead,
+			constants.PermissionUserUpdate,
+			constants.PermissionUserImpersonate,
+			constants.PermissionReportRead,
+		},
+	},
+	constants.RoleModerator: {
+		inherits: []string{constants.RoleUser},
+		permissions: []string{
+			constants.PermissionAdminAccess,
+			constants.PermissionReportRead,
+			constants.PermissionReportModerate,
+			constants.PermissionUserRead,
+			constants.PermissionUserModerate,
+			constants.PermissionPostDelete,
+			constants.PermissionPostModerate,
+			constants.PermissionCommentDelete,
+			constants.PermissionCommentModerate,
+			constants.PermissionGroupModerate,
+			constants.PermissionLiveStreamModerate,
+			constants.PermissionEventModerate,
+		},
+	},
+	constants.RoleAdmin: {
+		inherits: []string{constants.RoleModerator, constants.RoleSupport},
+		permissions: []string{
+			constants.PermissionUserDelete,
+			constants.PermissionUserImpersonateWrite,
+			constants.PermissionGroupDelete,
+			constants.PermissionLiveStreamDelete,
+			constants.PermissionEventDelete,
+			constants.PermissionSystemRead,
+			constants.PermissionSystemManage,
+		},
+	},
+	constants.RoleSuperAdmin: {
+		inherits:    []string{constants.RoleAdmin},
+		permissions: []string{constants.PermissionAll},
+	},
+}
+
+// Relations a user can hold to a single resource
+const (
+	relationOwner           = "owner" // Author, group creator, stream or event host, or the user themselves
+	relationGroupAdmin      = "group_admin"
+	relationGroupModerator  = "group_moderator"
+	relationGroupMember     = "group_member"
+	relationStreamModerator = "stream_moderator"
+	relationStreamBanned    = "stream_banned"
+	relationEventCoHost     = "event_cohost"
+	relationStaffAccount    = "staff_account" // The user is staff
+)
+
+// relationGrants is the resource-scoped policy: what a relation to a resource
+// permits on that resource, on top of the user's role. Permissions name their
+// resource type, so a relation only grants those of the resource it holds.
+// Relations to a group carry over to the posts and events in it.
+var relationGrants = map[string][]string{
+	relationOwner: {
+		constants.PermissionUserRead,
+		constants.PermissionUserUpdate,
+		constants.PermissionUserDelete,
+		constants.PermissionPostUpdate,
+		constants.PermissionPostDelete,
+		constants.PermissionCommentUpdate,
+		constants.PermissionCommentDelete,
+		constants.PermissionGroupDelete,
+		constants.PermissionLiveStreamUpdate,
+		constants.PermissionLiveStreamDelete,
+		constants.PermissionLiveStreamModerate,
+		constants.PermissionEventUpdate,
+		constants.PermissionEventDelete,
+		constants.PermissionEventManage,
+		constants.PermissionEventModerate,
+	},
+	relationGroupAdmin: {
+		constants.PermissionGroupUpdate,
+		constants.PermissionGroupManage,
+		constants.PermissionGroupModerate,
+		constants.PermissionGroupPost,
+		constants.PermissionPostDelete,
+		constants.PermissionPostModerate,
+		constants.PermissionEventUpdate,
+		constants.PermissionEventModerate,
+	},
+	relationGroupModerator: {
+		constants.PermissionGroupModerate,
+		constants.PermissionGroupPost,
+		constants.PermissionPostDelete,
+		constants.PermissionPostModerate,
+		constants.PermissionEventModerate,
+	},
+	relationGroupMember: {
+		constants.PermissionGroupPost,
+	},
+	relationStreamModerator: {
+		constants.PermissionLiveStreamModerate,
+	},
+	relationEventCoHost: {
+		constants.PermissionEventUpdate,
+		constants.PermissionEventModerate,
+	},
+}
+
+// relationDenials lists what a relation forbids, whatever else is granted.
+// Nobody may impersonate themselves or a member of staff, and staff accounts
+// are not edited, banned, verified or unlocked from the admin panel; they
+// change with the role assigned to them.
+var relationDenials = map[string][]string{
+	relationOwner: {
+		constants.PermissionUserImpersonate,
+		constants.PermissionUserImpersonateWrite,
+	},
+	relationStaffAccount: {
+		constants.PermissionUserImpersonate,
+		constants.PermissionUserImpersonateWrite,
+		constants.PermissionUserUpdate,
+		constants.PermissionUserModerate,
+	},
+	relationStreamBanned: {
+		constants.PermissionLiveStreamJoin,
+		constants.PermissionLiveStreamComment,
+		constants.PermissionLiveStreamReact,
+	},
+}
+
+// roleGrants holds the permissions of each role, inherited ones included
+var roleGrants = func() map[string]map[string]bool {
+	grants := make(map[string]map[string]bool, len(roles))
+	for name := range roles {
+		grants[name] = make(map[string]bool)
+		collect(name, grants[name])
+	}
+	return grants
+}()
+
+// collect adds the permissions of a role and the roles it inherits to set
+func collect(name string, set map[string]bool) {
+	for _, permission := range roles[name].permissions {
+		set[permission] = true
+	}
+	for _, parent := range roles[name].inherits {
+		collect(parent, set)
+	}
+}
+
+// inherits reports whether the role name is want or inherits it
+func inherits(name, want string) bool {
+	if name == want {
+		return true
+	}
+	for _, parent := range roles[name].inherits {
+		if inherits(parent, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// roleName returns the role of a user. Accounts created before roles were
+// recorded have none and are plain users.
+func roleName(name string) string {
+	if name == "" {
+		return constants.RoleUser
+	}
+	return name
+}
+
+// grants reports whether a list of permissions includes permission
+func grants(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+// Package permission decides what users may do. Roles grant permissions
+// platform-wide; a user's relation to a single resource, such as being a
+// group's admin, a stream's moderator or an event's co-host, grants more on
+// that resource. HTTP middleware and websocket handlers both ask Can.
+package permission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// ErrResourceNotFound is returned when checking a permission on a resource that does not exist
+var ErrResourceNotFound = errors.New("resource not found")
+
+// Resource is what a permission is checked against. A zero ID checks the
+// resource type as a whole, for actions such as creating one.
+type Resource struct {
+	Type string
+	ID   primitive.ObjectID
+}
+
+// Service checks permissions against the role policy and the resources
+// themselves, so relations such as group admin always reflect the latest
+// write.
+type Service struct {
+	users       interfaces.UserRepository
+	groups      interfaces.GroupRepository
+	posts       interfaces.PostRepository
+	comments    interfaces.CommentRepository
+	liveStreams interfaces.LiveStreamRepository
+	events      interfaces.EventRepository
+}
+
+// NewService creates a permission service
+func NewService(
+	users interfaces.UserRepository,
+	groups interfaces.GroupRepository,
+	posts interfaces.PostRepository,
+	comments interfaces.CommentRepository,
+	liveStreams interfaces.LiveStreamRepository,
+	events interfaces.EventRepository,
+) *Service {
+	return &Service{
+		users:       users,
+		groups:      groups,
+		posts:       posts,
+		comments:    comments,
+		liveStreams: liveStreams,
+		events:      events,
+	}
+}
+
+// Can reports whether subject may perform action on resource. Suspended,
+// deleted and unknown users may do nothing. A relation that denies the
+// action, such as a ban from a stream, wins over any grant.
+func (s *Service) Can(ctx context.Context, subject primitive.ObjectID, action string, resource Resource) (bool, error) {
+	user, err := s.activeUser(ctx, subject)
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	permission := resource.Type + "." + action
+
+	var relations []string
+	if !resource.ID.IsZero() {
+		relations, err = s.relationsTo(ctx, subject, resource)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, relation := range relations {
+		if grants(relationDenials[relation], permission) {
+			return false, nil
+		}
+	}
+
+	granted := roleGrants[roleName(user.Role)]
+	if granted[permission] || granted[constants.PermissionAll] {
+		return true, nil
+	}
+	for _, relation := range relations {
+		if grants(relationGrants[relation], permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasPermission reports whether a user holds a permission platform-wide
+func (s *Service) HasPermission(ctx context.Context, userID primitive.ObjectID, permission string) (bool, error) {
+	resourceType, action, ok := strings.Cut(permission, ".")
+	if !ok {
+		return false, fmt.Errorf("invalid permission %q", permission)
+	}
+	return s.Can(ctx, userID, action, Resource{Type: resourceType})
+}
+
+// IsResourceOwner reports whether a user owns a resource: wrote the post or
+// comment, created the group, hosts the stream or event, or is the user
+func (s *Service) IsResourceOwner(ctx context.Context, userID primitive.ObjectID, resourceType string, resourceID primitive.ObjectID) (bool, error) {
+	relations, err := s.relationsTo(ctx, userID, Resource{Type: resourceType, ID: resourceID})
+	if err != nil {
+		return false, err
+	}
+	return grants(relations, relationOwner), nil
+}
+
+// HasAnyRole reports whether a user has one of roles, or a role that
+// inherits one of them
+func (s *Service) HasAnyRole(ctx context.Context, userID primitive.ObjectID, roles []string) (bool, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	for _, want := range roles {
+		if inherits(roleName(user.Role), want) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// activeUser returns a user who may act, or nil for one who may not
+func (s *Service) activeUser(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil || user.Status == "suspended" || user.Status == "deleted" {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// relationsTo returns the relations userID holds to resource
+func (s *Service) relationsTo(ctx context.Context, userID primitive.ObjectID, resource Resource) ([]string, error) {
+	var relations []string
+
+	switch resource.Type {
+	case constants.ResourceTypeUser:
+		user, err := s.users.GetByID(ctx, resource.ID)
+		if err != nil {
+			return nil, lookupError(err)
+		}
+		if user.ID == userID {
+			relations = append(relations, relationOwner)
+		}
+		if granted := roleGrants[roleName(user.Role)]; granted[constants.PermissionAdminAccess] || granted[constants.PermissionAll] {
+			relations = append(relations, relationStaffAccount)
+		}
+
+	case constants.ResourceTypePost:
+		post, err := s.posts.GetByID(ctx, resource.ID)
+		if err != nil {
+			return nil, lookupError(err)
+		}
+		if post.UserID == userID {
+			relations = append(relations, relationOwner)
+		}
+		if post.GroupID != nil {
+			groupRelations, err := s.groupRelations(ctx, userID, *post.GroupID)
+			if err != nil && !errors.Is(err, ErrResourceNotFound) {
+				return nil, err
+			}
+			relations = append(relations, groupRelations...)
+		}
+
+	case constants.ResourceTypeComment:
+		comment, err := s.comments.GetByID(ctx, resource.ID)
+		if err != nil {
+			return nil, lookupError(err)
+		}
+		if comment.UserID == userID {
+			relations = append(relations, relationOwner)
+		}
+
+	case constants.ResourceTypeGroup:
+		group, err := s.groups.GetByID(ctx, resource.ID)
+		if err != nil {
+			return nil, lookupError(err)
+		}
+		if group.CreatorID == userID {
+			relations = append(relations, relationOwner)
+		}
+		groupRelations, err := s.groupRelations(ctx, userID, resource.ID)
+		if err != nil {
+			return nil, err
+		}
+		relations = append(relations, groupRelations...)
+
+	case constants.ResourceTypeLiveStream:
+		stream, err := s.liveStreams.GetByID(ctx, resource.ID)
+		if err != nil {
+			return nil, lookupError(err)
+		}
+		if stream.UserID == userID {
+			relations = append(relations, relationOwner)
+		}
+		if containsID(stream.ChatSettings.ModeratorIDs, userID) {
+			relations = append(relations, relationStreamModerator)
+		}
+		if containsID(stream.ChatSettings.BannedUsers, userID) {
+			relations = append(relations, relationStreamBanned)
+		}
+
+	case constants.ResourceTypeEvent:
+		event, err := s.events.GetByID(ctx, resource.ID)
+		if err != nil {
+			return nil, lookupError(err)
+		}
+		if event.HostID == userID {
+			relations = append(relations, relationOwner)
+		}
+		if containsID(event.CoHosts, userID) {
+			relations = append(relations, relationEventCoHost)
+		}
+		if event.GroupID != nil {
+			groupRelations, err := s.groupRelations(ctx, userID, *event.GroupID)
+			if err != nil && !errors.Is(err, ErrResourceNotFound) {
+				return nil, err
+			}
+			relations = append(relations, groupRelations...)
+		}
+	}
+
+	return relations, nil
+}
+
+// groupRelations returns the relations userID holds to a group and, through
+// it, to the content posted in it. The creator is always an admin.
+func (s *Service) groupRelations(ctx context.Context, userID, groupID primitive.ObjectID) ([]string, error) {
+	group, err := s.groups.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	var relations []string
+	if group.CreatorID == userID || containsID(group.Admins, userID) {
+		relations = append(relations, relationGroupAdmin)
+	}
+	if containsID(group.Moderators, userID) {
+		relations = append(relations, relationGroupModerator)
+	}
+
+	member, err := s.groups.GetMember(ctx, groupID, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return relations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch member.Role {
+	case "admin":
+		relations = append(relations, relationGroupAdmin)
+	case "moderator":
+		relations = append(relations, relationGroupModerator)
+	}
+	return append(relations, relationGroupMember), nil
+}
+
+// lookupError maps a missing document to ErrResourceNotFound
+func lookupError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrResourceNotFound
+	}
+	return err
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
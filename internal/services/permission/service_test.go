@@ -0,0 +1,254 @@
+package permission_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// testEnv is a permission service on top of in-memory repositories
+type testEnv struct {
+	repos       *memory.Repositories
+	permissions *permission.Service
+}
+
+func newTestEnv() *testEnv {
+	repos := memory.NewRepositories(memory.NewDatabase())
+	return &testEnv{
+		repos:       repos,
+		permissions: permission.NewService(repos.Users, repos.Groups, repos.Posts, repos.Comments, repos.LiveStreams, repos.Events),
+	}
+}
+
+// user creates a user with role and status
+func (e *testEnv) user(t *testing.T, username, role, status string) primitive.ObjectID {
+	t.Helper()
+	id, err := e.repos.Users.Create(context.Background(), &models.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Role:     role,
+		Status:   status,
+	})
+	if err != nil {
+		t.Fatalf("create %s: %v", username, err)
+	}
+	return id
+}
+
+// can asks whether subject may perform action on resource
+func (e *testEnv) can(t *testing.T, subject primitive.ObjectID, action string, resource permission.Resource) bool {
+	t.Helper()
+	allowed, err := e.permissions.Can(context.Background(), subject, action, resource)
+	if err != nil {
+		t.Fatalf("can %s %s: %v", resource.Type, action, err)
+	}
+	return allowed
+}
+
+func TestRolePermissions(t *testing.T) {
+	tests := map[string]struct {
+		role       string
+		status     string
+		permission string
+		allowed    bool
+	}{
+		"user posts":                        {role: constants.RoleUser, permission: constants.PermissionPostCreate, allowed: true},
+		"user without a role posts":         {permission: constants.PermissionPostCreate, allowed: true},
+		"user enters the admin panel":       {role: constants.RoleUser, permission: constants.PermissionAdminAccess},
+		"user starts a live stream":         {role: constants.RoleUser, permission: constants.PermissionLiveStreamCreate},
+		"creator starts a live stream":      {role: constants.RoleCreator, permission: constants.PermissionLiveStreamCreate, allowed: true},
+		"creator manages ads":               {role: constants.RoleCreator, permission: constants.PermissionAdManage},
+		"business manages ads":              {role: constants.RoleBusiness, permission: constants.PermissionAdManage, allowed: true},
+		"support impersonates":              {role: constants.RoleSupport, permission: constants.PermissionUserImpersonate, allowed: true},
+		"support writes as a user":          {role: constants.RoleSupport, permission: constants.PermissionUserImpersonateWrite},
+		"support moderates reports":         {role: constants.RoleSupport, permission: constants.PermissionReportModerate},
+		"moderator moderates reports":       {role: constants.RoleModerator, permission: constants.PermissionReportModerate, allowed: true},
+		"moderator deletes users":           {role: constants.RoleModerator, permission: constants.PermissionUserDelete},
+		"moderator manages the system":      {role: constants.RoleModerator, permission: constants.PermissionSystemManage},
+		"admin inherits moderation":         {role: constants.RoleAdmin, permission: constants.PermissionPostModerate, allowed: true},
+		"admin inherits impersonation":      {role: constants.RoleAdmin, permission: constants.PermissionUserImpersonate, allowed: true},
+		"admin manages the system":          {role: constants.RoleAdmin, permission: constants.PermissionSystemManage, allowed: true},
+		"admin assigns roles":               {role: constants.RoleAdmin, permission: constants.PermissionRoleAssign},
+		"superadmin assigns roles":          {role: constants.RoleSuperAdmin, permission: constants.PermissionRoleAssign, allowed: true},
+		"unknown role":                      {role: "owner", permission: constants.PermissionPostCreate},
+		"suspended admin":                   {role: constants.RoleAdmin, status: "suspended", permission: constants.PermissionAdminAccess},
+		"deleted superadmin":                {role: constants.RoleSuperAdmin, status: "deleted", permission: constants.PermissionPostCreate},
+		"suspended user posts":              {role: constants.RoleUser, status: "suspended", permission: constants.PermissionPostCreate},
+		"permission of another type":        {role: constants.RoleBusiness, permission: "post.manage"},
+		"superadmin on an unknown resource": {role: constants.RoleSuperAdmin, permission: "spaceship.launch", allowed: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv()
+			subject := env.user(t, "subject", tt.role, tt.status)
+
+			allowed, err := env.permissions.HasPermission(context.Background(), subject, tt.permission)
+			if err != nil {
+				t.Fatalf("has permission: %v", err)
+			}
+			if allowed != tt.allowed {
+				t.Fatalf("%s allowed = %v, want %v", tt.permission, allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestUnknownAndSoftDeletedUsersMayDoNothing(t *testing.T) {
+	env := newTestEnv()
+	ctx := context.Background()
+
+	if allowed, err := env.permissions.HasPermission(ctx, primitive.NewObjectID(), constants.PermissionPostCreate); err != nil || allowed {
+		t.Fatalf("unknown user = %v, %v, want denied", allowed, err)
+	}
+
+	deleted := env.user(t, "leaving", constants.RoleAdmin, "")
+	user, err := env.repos.Users.GetByID(ctx, deleted)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	now := time.Now()
+	user.DeletedAt = &now
+	if err := env.repos.Users.Update(ctx, user); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	if allowed, err := env.permissions.HasPermission(ctx, deleted, constants.PermissionAdminAccess); err != nil || allowed {
+		t.Fatalf("user pending deletion = %v, %v, want denied", allowed, err)
+	}
+}
+
+func TestStaffAccountsAreOutOfReach(t *testing.T) {
+	actions := []string{"update", "moderate", "impersonate", "impersonate_write"}
+
+	env := newTestEnv()
+	admin := env.user(t, "admin", constants.RoleAdmin, "")
+	superadmin := env.user(t, "superadmin", constants.RoleSuperAdmin, "")
+	member := env.user(t, "member", constants.RoleUser, "")
+
+	// Admins act on regular accounts
+	for _, action := range actions {
+		if !env.can(t, superadmin, action, permission.Resource{Type: constants.ResourceTypeUser, ID: member}) {
+			t.Errorf("superadmin may not %s a user", action)
+		}
+	}
+
+	// but not on staff, whatever their own role
+	for _, role := range []string{constants.RoleSupport, constants.RoleModerator, constants.RoleAdmin, constants.RoleSuperAdmin} {
+		staff := env.user(t, "staff-"+role, role, "")
+		target := permission.Resource{Type: constants.ResourceTypeUser, ID: staff}
+		for subjectRole, subject := range map[string]primitive.ObjectID{"admin": admin, "superadmin": superadmin} {
+			for _, action := range actions {
+				if env.can(t, subject, action, target) {
+					t.Errorf("%s may %s a %s account", subjectRole, action, role)
+				}
+			}
+		}
+		if !env.can(t, superadmin, "read", target) {
+			t.Errorf("superadmin may not read a %s account", role)
+		}
+	}
+
+	// Nobody impersonates themselves
+	for _, action := range []string{"impersonate", "impersonate_write"} {
+		if env.can(t, member, action, permission.Resource{Type: constants.ResourceTypeUser, ID: member}) {
+			t.Errorf("user may %s themselves", action)
+		}
+	}
+	if !env.can(t, member, "update", permission.Resource{Type: constants.ResourceTypeUser, ID: member}) {
+		t.Error("user may not update themselves")
+	}
+}
+
+func TestResourceRelations(t *testing.T) {
+	env := newTestEnv()
+	ctx := context.Background()
+	owner := env.user(t, "owner", constants.RoleUser, "")
+	groupAdmin := env.user(t, "groupadmin", constants.RoleUser, "")
+	moderator := env.user(t, "moderator", constants.RoleUser, "")
+	member := env.user(t, "member", constants.RoleUser, "")
+	stranger := env.user(t, "stranger", constants.RoleUser, "")
+
+	groupID, err := env.repos.Groups.Create(ctx, &models.Group{Name: "Gardeners", CreatorID: owner, Admins: []primitive.ObjectID{groupAdmin}})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if err := env.repos.Groups.AddMember(ctx, groupID, moderator, "moderator"); err != nil {
+		t.Fatalf("add moderator: %v", err)
+	}
+	if err := env.repos.Groups.AddMember(ctx, groupID, member, "member"); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	postID, err := env.repos.Posts.Create(ctx, &models.Post{UserID: member, GroupID: &groupID, Content: "First tomatoes"})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	streamID, err := env.repos.LiveStreams.Create(ctx, &models.LiveStream{
+		UserID: owner,
+		Title:  "Pruning",
+		ChatSettings: models.LiveStreamChatSettings{
+			ModeratorIDs: []primitive.ObjectID{moderator},
+			BannedUsers:  []primitive.ObjectID{stranger},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create live stream: %v", err)
+	}
+	eventID, err := env.repos.Events.Create(ctx, &models.Event{Title: "Seed swap", HostID: owner, CoHosts: []primitive.ObjectID{member}})
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+
+	group := permission.Resource{Type: constants.ResourceTypeGroup, ID: groupID}
+	post := permission.Resource{Type: constants.ResourceTypePost, ID: postID}
+	stream := permission.Resource{Type: constants.ResourceTypeLiveStream, ID: streamID}
+	event := permission.Resource{Type: constants.ResourceTypeEvent, ID: eventID}
+
+	tests := map[string]struct {
+		subject  primitive.ObjectID
+		action   string
+		resource permission.Resource
+		allowed  bool
+	}{
+		"creator manages the group":           {owner, "manage", group, true},
+		"listed admin manages the group":      {groupAdmin, "manage", group, true},
+		"moderator manages the group":         {moderator, "manage", group, false},
+		"moderator moderates the group":       {moderator, "moderate", group, true},
+		"member posts in the group":           {member, "post", group, true},
+		"member moderates the group":          {member, "moderate", group, false},
+		"stranger posts in the group":         {stranger, "post", group, false},
+		"author updates the post":             {member, "update", post, true},
+		"group moderator deletes the post":    {moderator, "delete", post, true},
+		"group moderator edits the post":      {moderator, "update", post, false},
+		"stranger deletes the post":           {stranger, "delete", post, false},
+		"stream moderator moderates":          {moderator, "moderate", stream, true},
+		"member joins the stream":             {member, "join", stream, true},
+		"member moderates the stream":         {member, "moderate", stream, false},
+		"banned user joins the stream":        {stranger, "join", stream, false},
+		"banned user comments on the stream":  {stranger, "comment", stream, false},
+		"co-host updates the event":           {member, "update", event, true},
+		"co-host deletes the event":           {member, "delete", event, false},
+		"host deletes the event":              {owner, "delete", event, true},
+		"stranger updates the event":          {stranger, "update", event, false},
+		"relation to one resource is not all": {groupAdmin, "update", event, false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if allowed := env.can(t, tt.subject, tt.action, tt.resource); allowed != tt.allowed {
+				t.Fatalf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+		})
+	}
+
+	// A missing resource is reported, not silently denied
+	_, err = env.permissions.Can(ctx, owner, "update", permission.Resource{Type: constants.ResourceTypePost, ID: primitive.NewObjectID()})
+	if !stderrors.Is(err, permission.ErrResourceNotFound) {
+		t.Fatalf("can on a missing post = %v, want resource not found", err)
+	}
+}
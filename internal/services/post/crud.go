@@ -0,0 +1,73 @@
+package post
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+)
+
+// CreatePost creates a post with the given media attached, in the order
+// given. The post, the author's post count, the media associations and the
+// PostCreated event are written in one transaction.
+func (s *Service) CreatePost(ctx context.Context, post *models.Post, mediaIDs []primitive.ObjectID) (*models.Post, error) {
+	if len(mediaIDs) > 0 {
+		files, err := s.ownedMedia(ctx, post.UserID, mediaIDs)
+		if err != nil {
+			return nil, err
+		}
+		post.MediaFiles = files
+	}
+
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.posts.Create(ctx, post); err != nil {
+			return err
+		}
+		if err := s.users.IncrementPostCount(ctx, post.UserID, 1); err != nil {
+			return err
+		}
+		for _, mediaID := range mediaIDs {
+			if err := s.media.AssociateWithContent(ctx, mediaID, contentTypePost, post.ID); err != nil {
+				return err
+			}
+		}
+
+		return s.events.Publish(ctx, eventbus.PostCreated{
+			PostID:      post.ID,
+			UserID:      post.UserID,
+			GroupID:     post.GroupID,
+			Privacy:     post.Privacy,
+			PublishedAt: post.PublishedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// ownedMedia loads media items in the order given, failing if any is missing
+// or was uploaded by someone other than userID
+func (s *Service) ownedMedia(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) ([]models.Media, error) {
+	found, err := s.media.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Media, len(found))
+	for _, media := range found {
+		byID[media.ID] = media
+	}
+
+	files := make([]models.Media, 0, len(ids))
+	for _, id := range ids {
+		media, ok := byID[id]
+		if !ok || media.UserID != userID {
+			return nil, ErrMediaNotFound
+		}
+		files = append(files, *media)
+	}
+	return files, nil
+}
@@ -0,0 +1,185 @@
+package post
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+)
+
+// HomeFeed returns up to limit posts for userID's home feed after the cursor
+// after, newest first, and the cursor to pass as after for the next page,
+// which is zero once there are no more. A zero cursor, or one in the future,
+// starts from now. The cached timeline is rebuilt first if it has expired,
+// and the posts of followed celebrities are merged in from the posts
+// themselves.
+func (s *TimelineService) HomeFeed(ctx context.Context, userID primitive.ObjectID, after models.TimelineCursor, limit int) ([]*models.Post, models.TimelineCursor, error) {
+	limit, _ = mongoutil.NormalizePagination(limit, 0)
+	if now := s.now(); after.PublishedAt.IsZero() || after.PublishedAt.After(now) {
+		// Start past the current millisecond, so posts published in it are
+		// included whatever their IDs
+		after = models.TimelineCursor{PublishedAt: now.Add(time.Millisecond)}
+	}
+
+	exists, err := s.timelines.Exists(ctx, userID)
+	if err != nil {
+		return nil, models.TimelineCursor{}, err
+	}
+	if !exists {
+		if err := s.Rebuild(ctx, userID); err != nil {
+			return nil, models.TimelineCursor{}, err
+		}
+	}
+
+	cached, err := s.timelines.Range(ctx, userID, after, limit, s.config.IdleTTL)
+	if err != nil {
+		return nil, models.TimelineCursor{}, err
+	}
+	fannedIn, err := s.celebrityEntries(ctx, userID, after, limit)
+	if err != nil {
+		return nil, models.TimelineCursor{}, err
+	}
+
+	entries := mergeEntries(cached, fannedIn, limit)
+	var next models.TimelineCursor
+	if len(entries) == limit {
+		last := entries[len(entries)-1]
+		next = models.TimelineCursor{PublishedAt: last.PublishedAt, PostID: last.PostID}
+	}
+
+	posts, err := s.hydrate(ctx, userID, entries)
+	if err != nil {
+		return nil, models.TimelineCursor{}, err
+	}
+	return posts, next, nil
+}
+
+// EncodeCursor returns cursor as the string handed to clients, or "" for the
+// zero cursor
+func EncodeCursor(cursor models.TimelineCursor) string {
+	if cursor.PublishedAt.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(cursor.PublishedAt.UnixMilli(), 10) + "_" + cursor.PostID.Hex()
+}
+
+// ParseCursor reads a cursor written by EncodeCursor. An empty string is the
+// zero cursor.
+func ParseCursor(value string) (models.TimelineCursor, error) {
+	if value == "" {
+		return models.TimelineCursor{}, nil
+	}
+	millis, post, found := strings.Cut(value, "_")
+	if !found {
+		return models.TimelineCursor{}, ErrInvalidCursor
+	}
+	publishedAt, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return models.TimelineCursor{}, ErrInvalidCursor
+	}
+	postID, err := primitive.ObjectIDFromHex(post)
+	if err != nil {
+		return models.TimelineCursor{}, ErrInvalidCursor
+	}
+	return models.TimelineCursor{PublishedAt: time.UnixMilli(publishedAt), PostID: postID}, nil
+}
+
+// celebrityEntries returns entries for up to limit of the newest posts after
+// the cursor after by the celebrities userID follows
+func (s *TimelineService) celebrityEntries(ctx context.Context, userID primitive.ObjectID, after models.TimelineCursor, limit int) ([]models.TimelineEntry, error) {
+	following, err := s.follows.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	celebrities, err := s.celebrities(ctx, following)
+	if err != nil {
+		return nil, err
+	}
+	if len(celebrities) == 0 {
+		return []models.TimelineEntry{}, nil
+	}
+
+	authors := make([]primitive.ObjectID, 0, len(celebrities))
+	for id := range celebrities {
+		authors = append(authors, id)
+	}
+	// Publish times are compared by the millisecond, as cursors keep them
+	bound := time.UnixMilli(after.PublishedAt.UnixMilli())
+	return s.recentEntries(ctx, map[string]interface{}{
+		"user_id": bson.M{"$in": authors},
+		"privacy": privacyPublic,
+		"$or": bson.A{
+			bson.M{"published_at": bson.M{"$lt": bound}},
+			bson.M{
+				"published_at": bson.M{"$gte": bound, "$lt": bound.Add(time.Millisecond)},
+				"_id":          bson.M{"$lt": after.PostID},
+			},
+		},
+	}, limit)
+}
+
+// hydrate loads the posts for entries in order, dropping any that have since
+// been deleted, hidden or archived, or are no longer public unless they are
+// userID's own
+func (s *TimelineService) hydrate(ctx context.Context, userID primitive.ObjectID, entries []models.TimelineEntry) ([]*models.Post, error) {
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.PostID
+	}
+	found, err := s.posts.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Post, len(found))
+	for _, post := range found {
+		byID[post.ID] = post
+	}
+
+	posts := make([]*models.Post, 0, len(entries))
+	for _, entry := range entries {
+		post, ok := byID[entry.PostID]
+		if !ok || post.DeletedAt != nil || post.IsHidden || post.IsArchived {
+			continue
+		}
+		if post.UserID != userID && post.Privacy != privacyPublic {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+// mergeEntries merges two timelines into one of at most limit entries,
+// newest first and then by post ID, keeping one entry per post
+func mergeEntries(a, b []models.TimelineEntry, limit int) []models.TimelineEntry {
+	seen := make(map[primitive.ObjectID]bool, len(a)+len(b))
+	merged := make([]models.TimelineEntry, 0, len(a)+len(b))
+	for _, entries := range [][]models.TimelineEntry{a, b} {
+		for _, entry := range entries {
+			if !seen[entry.PostID] {
+				seen[entry.PostID] = true
+				merged = append(merged, entry)
+			}
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i].PublishedAt.UnixMilli(), merged[j].PublishedAt.UnixMilli()
+		if a != b {
+			return a > b
+		}
+		return merged[i].PostID.Hex() > merged[j].PostID.Hex()
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
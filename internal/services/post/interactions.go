@@ -0,0 +1,141 @@
+package post
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+)
+
+// incrementFunc adjusts the like counter of a post or comment
+type incrementFunc func(ctx context.Context, id primitive.ObjectID, amount int) error
+
+// LikePost records a like on a post and bumps its like count in one
+// transaction. Liking a post twice has no further effect.
+func (s *Service) LikePost(ctx context.Context, postID, userID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.posts.GetByID(ctx, postID); err != nil {
+			return err
+		}
+		return s.like(ctx, userID, postID, contentTypePost, s.posts.IncrementLikeCount)
+	})
+}
+
+// UnlikePost removes a like from a post and lowers its like count in one
+// transaction. Unliking a post that was not liked has no effect.
+func (s *Service) UnlikePost(ctx context.Context, postID, userID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.unlike(ctx, userID, postID, contentTypePost, s.posts.IncrementLikeCount)
+	})
+}
+
+// LikeComment records a like on a comment and bumps its like count in one transaction
+func (s *Service) LikeComment(ctx context.Context, commentID, userID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.comments.GetByID(ctx, commentID); err != nil {
+			return err
+		}
+		return s.like(ctx, userID, commentID, contentTypeComment, s.comments.IncrementLikeCount)
+	})
+}
+
+// UnlikeComment removes a like from a comment and lowers its like count in one transaction
+func (s *Service) UnlikeComment(ctx context.Context, commentID, userID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.unlike(ctx, userID, commentID, contentTypeComment, s.comments.IncrementLikeCount)
+	})
+}
+
+// BookmarkPost saves a post for a user, optionally into one of their named
+// collections, and bumps the collection's item count in one transaction.
+// Bookmarking a post twice has no further effect.
+func (s *Service) BookmarkPost(ctx context.Context, postID, userID primitive.ObjectID, collection string) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.posts.GetByID(ctx, postID); err != nil {
+			return err
+		}
+
+		_, err := s.bookmarks.GetByUserAndPost(ctx, userID, postID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		bookmark := &models.Bookmark{UserID: userID, PostID: postID}
+		if collection != "" {
+			folder, err := s.bookmarks.GetCollectionByName(ctx, userID, collection)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrBookmarkCollectionNotFound
+			}
+			if err != nil {
+				return err
+			}
+			bookmark.CollectionID = &folder.ID
+		}
+
+		if _, err := s.bookmarks.Create(ctx, bookmark); err != nil {
+			return err
+		}
+		if bookmark.CollectionID != nil {
+			return s.bookmarks.IncrementCollectionItemCount(ctx, *bookmark.CollectionID, 1)
+		}
+		return nil
+	})
+}
+
+// UnbookmarkPost removes a user's bookmark of a post and lowers its
+// collection's item count in one transaction. A post is bookmarked at most
+// once per user, so the collection name is not needed to find it.
+func (s *Service) UnbookmarkPost(ctx context.Context, postID, userID primitive.ObjectID, collection string) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		bookmark, err := s.bookmarks.GetByUserAndPost(ctx, userID, postID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.bookmarks.Delete(ctx, bookmark.ID); err != nil {
+			return err
+		}
+		if bookmark.CollectionID != nil {
+			return s.bookmarks.IncrementCollectionItemCount(ctx, *bookmark.CollectionID, -1)
+		}
+		return nil
+	})
+}
+
+// like records a like unless the user already liked the content
+func (s *Service) like(ctx context.Context, userID, contentID primitive.ObjectID, contentType string, increment incrementFunc) error {
+	liked, _, err := s.likes.CheckIfUserLiked(ctx, userID, contentID, contentType)
+	if err != nil {
+		return err
+	}
+	if liked {
+		return nil
+	}
+
+	like := &models.Like{UserID: userID, ContentID: contentID, ContentType: contentType, ReactionType: "like"}
+	if _, err := s.likes.Create(ctx, like); err != nil {
+		return err
+	}
+	return increment(ctx, contentID, 1)
+}
+
+// unlike removes a user's like, if any
+func (s *Service) unlike(ctx context.Context, userID, contentID primitive.ObjectID, contentType string, increment incrementFunc) error {
+	err := s.likes.DeleteByUserAndContent(ctx, userID, contentID, contentType)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return increment(ctx, contentID, -1)
+}
@@ -0,0 +1,60 @@
+package post
+
+import (
+	"errors"
+
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+)
+
+// Content types recorded on likes
+const (
+	contentTypePost    = "post"
+	contentTypeComment = "comment"
+)
+
+var (
+	// ErrBookmarkCollectionNotFound is returned when bookmarking into a collection the user does not have
+	ErrBookmarkCollectionNotFound = errors.New("bookmark collection not found")
+	// ErrMediaNotFound is returned when a post refers to media that does not exist or belongs to someone else
+	ErrMediaNotFound = errors.New("media not found")
+	// ErrInvalidCursor is returned for a home feed cursor that was not handed out by the feed
+	ErrInvalidCursor = errors.New("invalid feed cursor")
+)
+
+// Service implements post features on top of the repositories. Writes that
+// touch a denormalised counter run in a transaction with the counter update,
+// and domain events are published to the outbox in the same transaction.
+type Service struct {
+	posts     interfaces.PostRepository
+	comments  interfaces.CommentRepository
+	likes     interfaces.LikeRepository
+	bookmarks interfaces.BookmarkRepository
+	media     interfaces.MediaRepository
+	users     interfaces.UserRepository
+	tx        interfaces.Transactor
+	events    eventbus.Publisher
+}
+
+// NewService creates a post service
+func NewService(
+	posts interfaces.PostRepository,
+	comments interfaces.CommentRepository,
+	likes interfaces.LikeRepository,
+	bookmarks interfaces.BookmarkRepository,
+	media interfaces.MediaRepository,
+	users interfaces.UserRepository,
+	tx interfaces.Transactor,
+	events eventbus.Publisher,
+) *Service {
+	return &Service{
+		posts:     posts,
+		comments:  comments,
+		likes:     likes,
+		bookmarks: bookmarks,
+		media:     media,
+		users:     users,
+		tx:        tx,
+		events:    events,
+	}
+}
@@ -0,0 +1,233 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// privacyPublic is the privacy of posts shown to followers. Friends-only and
+// private posts only reach their author's own timeline.
+const privacyPublic = "public"
+
+// followStatusAccepted is the status of a follow that has been approved
+const followStatusAccepted = "accepted"
+
+// timelinePageSize bounds how many documents one query loads while building
+// a timeline
+const timelinePageSize = 100
+
+// TimelineService keeps the cached home timelines up to date. A post by a
+// regular author is written into the timeline of each follower when it is
+// created; posts by accounts with at least CelebrityFollowers followers are
+// left out and merged in when a timeline is read. Timelines are only kept
+// for users who read them: one that has expired is rebuilt from the posts
+// on the next read, and writes to it are skipped until then.
+//
+// An author is judged regular or not when each post is written, so posts
+// from before an author crossed the threshold may be merged from both sides;
+// reads drop the duplicates.
+type TimelineService struct {
+	posts     interfaces.PostRepository
+	users     interfaces.UserRepository
+	follows   interfaces.FollowRepository
+	timelines interfaces.TimelineRepository
+	config    *config.TimelineConfig
+	now       func() time.Time
+}
+
+// NewTimelineService creates a timeline service
+func NewTimelineService(
+	posts interfaces.PostRepository,
+	users interfaces.UserRepository,
+	follows interfaces.FollowRepository,
+	timelines interfaces.TimelineRepository,
+	config *config.TimelineConfig,
+) *TimelineService {
+	return &TimelineService{
+		posts:     posts,
+		users:     users,
+		follows:   follows,
+		timelines: timelines,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Subscribe registers the timeline updates with the event bus, so they run
+// once the change has committed and are retried on their own
+func (s *TimelineService) Subscribe(bus *eventbus.Bus) {
+	eventbus.On(bus, constants.SubscriberTimelines, s.handlePostCreated)
+	eventbus.On(bus, constants.SubscriberTimelines, s.handleUserFollowed)
+	eventbus.On(bus, constants.SubscriberTimelines, s.handleUserUnfollowed)
+}
+
+// handlePostCreated fans a new post out to its author's timeline and, for a
+// public post by a regular author, to their followers' timelines. Group
+// posts stay in the group.
+func (s *TimelineService) handlePostCreated(ctx context.Context, event eventbus.PostCreated) error {
+	if event.GroupID != nil {
+		return nil
+	}
+
+	recipients := []primitive.ObjectID{event.UserID}
+	if event.Privacy == privacyPublic {
+		author, err := s.users.GetByID(ctx, event.UserID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !s.isCelebrity(author) {
+			followers, err := s.follows.GetFollowerIDs(ctx, event.UserID)
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, followers...)
+		}
+	}
+
+	entry := models.TimelineEntry{PostID: event.PostID, AuthorID: event.UserID, PublishedAt: event.PublishedAt}
+	return s.timelines.Add(ctx, recipients, []models.TimelineEntry{entry}, s.config.MaxSize)
+}
+
+// handleUserFollowed backfills the new follower's timeline with the recent
+// posts of a regular author once the follow is accepted
+func (s *TimelineService) handleUserFollowed(ctx context.Context, event eventbus.UserFollowed) error {
+	if event.Status != followStatusAccepted {
+		return nil
+	}
+
+	author, err := s.users.GetByID(ctx, event.FollowingID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.isCelebrity(author) {
+		return nil
+	}
+
+	entries, err := s.recentEntries(ctx, map[string]interface{}{
+		"user_id": event.FollowingID,
+		"privacy": privacyPublic,
+	}, s.config.MaxSize)
+	if err != nil {
+		return err
+	}
+	return s.timelines.Add(ctx, []primitive.ObjectID{event.FollowerID}, entries, s.config.MaxSize)
+}
+
+// handleUserUnfollowed takes the author's posts out of the former follower's
+// timeline
+func (s *TimelineService) handleUserUnfollowed(ctx context.Context, event eventbus.UserUnfollowed) error {
+	if event.Status != followStatusAccepted {
+		return nil
+	}
+	return s.timelines.RemoveAuthor(ctx, event.FollowerID, event.FollowingID)
+}
+
+// Rebuild replaces userID's timeline with the newest posts of the user and
+// the regular authors they follow. Scheduled posts are included and only
+// show once they are published.
+func (s *TimelineService) Rebuild(ctx context.Context, userID primitive.ObjectID) error {
+	following, err := s.follows.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	celebrities, err := s.celebrities(ctx, following)
+	if err != nil {
+		return err
+	}
+
+	authors := make([]primitive.ObjectID, 0, len(following))
+	for _, id := range following {
+		if !celebrities[id] {
+			authors = append(authors, id)
+		}
+	}
+
+	entries, err := s.recentEntries(ctx, map[string]interface{}{
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{"user_id": bson.M{"$in": authors}, "privacy": privacyPublic},
+		},
+	}, s.config.MaxSize)
+	if err != nil {
+		return err
+	}
+	return s.timelines.Rebuild(ctx, userID, entries, s.config.MaxSize, s.config.IdleTTL)
+}
+
+// isCelebrity reports whether user's posts are merged in at read time
+// rather than fanned out
+func (s *TimelineService) isCelebrity(user *models.User) bool {
+	return user.FollowerCount >= s.config.CelebrityFollowers
+}
+
+// celebrities returns which of ids are accounts whose posts are merged in at
+// read time
+func (s *TimelineService) celebrities(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	result := map[primitive.ObjectID]bool{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	filter := map[string]interface{}{
+		"_id":            bson.M{"$in": ids},
+		"follower_count": bson.M{"$gte": s.config.CelebrityFollowers},
+	}
+	for offset := 0; ; offset += timelinePageSize {
+		users, _, err := s.users.List(ctx, filter, map[string]int{"_id": 1}, timelinePageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			result[user.ID] = true
+		}
+		if len(users) < timelinePageSize {
+			return result, nil
+		}
+	}
+}
+
+// recentEntries returns timeline entries for up to limit of the newest
+// non-group posts matching filter that are neither hidden nor archived
+func (s *TimelineService) recentEntries(ctx context.Context, filter map[string]interface{}, limit int) ([]models.TimelineEntry, error) {
+	query := map[string]interface{}{
+		"group_id":    nil,
+		"is_hidden":   false,
+		"is_archived": false,
+	}
+	for key, value := range filter {
+		query[key] = value
+	}
+
+	entries := make([]models.TimelineEntry, 0, limit)
+	for len(entries) < limit {
+		size := min(limit-len(entries), timelinePageSize)
+		posts, _, err := s.posts.List(ctx, query, map[string]int{"published_at": -1}, size, len(entries))
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			entries = append(entries, models.TimelineEntry{PostID: post.ID, AuthorID: post.UserID, PublishedAt: post.PublishedAt})
+		}
+		if len(posts) < size {
+			break
+		}
+	}
+	return entries, nil
+}
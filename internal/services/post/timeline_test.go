@@ -0,0 +1,211 @@
+package post_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/post"
+	"github.com/Caqil/vyrall/internal/services/user"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// testEnv is the post, user and timeline services on top of in-memory
+// repositories, with the timelines subscribed to the domain events
+type testEnv struct {
+	cfg       *config.Config
+	repos     *memory.Repositories
+	bus       *eventbus.Bus
+	posts     *post.Service
+	users     *user.Service
+	timelines *post.TimelineService
+}
+
+func newTestEnv() *testEnv {
+	cfg := config.Default()
+	repos := memory.NewRepositories(memory.NewDatabase())
+	events := eventbus.NewOutbox(repos.Outbox)
+
+	env := &testEnv{
+		cfg:       cfg,
+		repos:     repos,
+		bus:       eventbus.NewBus(),
+		posts:     post.NewService(repos.Posts, repos.Comments, repos.Likes, repos.Bookmarks, repos.Media, repos.Users, repos.Transactor, events),
+		users:     user.NewService(repos.Users, repos.Follows, repos.Transactor, events),
+		timelines: post.NewTimelineService(repos.Posts, repos.Users, repos.Follows, repos.Timelines, &cfg.Timeline),
+	}
+	env.timelines.Subscribe(env.bus)
+	return env
+}
+
+// deliverEvents hands every pending outbox event to its subscribers and
+// marks it published, standing in for the relay. It reports how many events
+// were delivered.
+func (e *testEnv) deliverEvents(t *testing.T) int {
+	t.Helper()
+	ctx := context.Background()
+
+	delivered := 0
+	for {
+		messages, err := e.repos.Outbox.GetPending(ctx, time.Now(), 100)
+		if err != nil {
+			t.Fatalf("read outbox: %v", err)
+		}
+		if len(messages) == 0 {
+			return delivered
+		}
+		for _, message := range messages {
+			envelope := eventbus.NewEnvelope(message)
+			if err := e.bus.Dispatch(ctx, &envelope); err != nil {
+				t.Fatalf("deliver %s event %s: %v", message.Type, message.ID.Hex(), err)
+			}
+			if err := e.repos.Outbox.MarkPublished(ctx, message.ID); err != nil {
+				t.Fatalf("mark %s published: %v", message.ID.Hex(), err)
+			}
+			delivered++
+		}
+	}
+}
+
+// createUsers stores users directly, bypassing sign-up
+func (e *testEnv) createUsers(t *testing.T, users ...*models.User) {
+	t.Helper()
+	for _, u := range users {
+		if _, err := e.repos.Users.Create(context.Background(), u); err != nil {
+			t.Fatalf("create %s: %v", u.Username, err)
+		}
+	}
+}
+
+func TestTimelineDeliversPostsToFollowers(t *testing.T) {
+	env := newTestEnv()
+	ctx := context.Background()
+
+	author := &models.User{Username: "author", Email: "author@example.com"}
+	follower := &models.User{Username: "follower", Email: "follower@example.com"}
+	env.createUsers(t, author, follower)
+
+	status, err := env.users.FollowUser(ctx, follower.ID, author.ID, false)
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	if status != "accepted" {
+		t.Fatalf("follow status = %q, want accepted", status)
+	}
+
+	created, err := env.posts.CreatePost(ctx, &models.Post{
+		UserID:  author.ID,
+		Content: "hello followers",
+		Privacy: "public",
+	}, nil)
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	if delivered := env.deliverEvents(t); delivered == 0 {
+		t.Fatal("no events delivered")
+	}
+
+	feed, _, err := env.timelines.HomeFeed(ctx, follower.ID, models.TimelineCursor{}, 10)
+	if err != nil {
+		t.Fatalf("home feed: %v", err)
+	}
+	if len(feed) != 1 || feed[0].ID != created.ID {
+		t.Fatalf("home feed = %v, want the author's post", feed)
+	}
+}
+
+func TestHomeFeedPagesPostsPublishedTogether(t *testing.T) {
+	env := newTestEnv()
+	ctx := context.Background()
+
+	follower := &models.User{Username: "reader", Email: "reader@example.com"}
+	regular := &models.User{Username: "regular", Email: "regular@example.com"}
+	celebrity := &models.User{
+		Username:      "celebrity",
+		Email:         "celebrity@example.com",
+		FollowerCount: env.cfg.Timeline.CelebrityFollowers,
+	}
+	env.createUsers(t, follower, regular, celebrity)
+	for _, author := range []*models.User{regular, celebrity} {
+		if _, err := env.users.FollowUser(ctx, follower.ID, author.ID, false); err != nil {
+			t.Fatalf("follow %s: %v", author.Username, err)
+		}
+	}
+
+	// Both the cached timeline and the celebrity's posts hold several posts
+	// published in the same millisecond, more than fit on one page
+	publishedAt := time.UnixMilli(time.Now().Add(-time.Hour).UnixMilli())
+	want := map[primitive.ObjectID]bool{}
+	for i := 0; i < 3; i++ {
+		for _, author := range []*models.User{regular, celebrity} {
+			id, err := env.repos.Posts.Create(ctx, &models.Post{
+				UserID:      author.ID,
+				Content:     "published together",
+				Privacy:     "public",
+				PublishedAt: publishedAt,
+			})
+			if err != nil {
+				t.Fatalf("create post: %v", err)
+			}
+			want[id] = true
+		}
+	}
+	env.repos.Timelines.Expire(follower.ID)
+
+	seen := map[primitive.ObjectID]bool{}
+	var last primitive.ObjectID
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > len(want) {
+			t.Fatal("home feed does not end")
+		}
+		before, err := post.ParseCursor(cursor)
+		if err != nil {
+			t.Fatalf("parse cursor %q: %v", cursor, err)
+		}
+		posts, next, err := env.timelines.HomeFeed(ctx, follower.ID, before, 2)
+		if err != nil {
+			t.Fatalf("home feed: %v", err)
+		}
+
+		for _, p := range posts {
+			if seen[p.ID] {
+				t.Fatalf("post %s is on two pages", p.ID.Hex())
+			}
+			if !last.IsZero() && p.ID.Hex() >= last.Hex() {
+				t.Fatalf("post %s comes after %s, want posts published together ordered by ID", p.ID.Hex(), last.Hex())
+			}
+			seen[p.ID] = true
+			last = p.ID
+		}
+
+		cursor = post.EncodeCursor(next)
+		if cursor == "" {
+			break
+		}
+	}
+
+	if len(seen) != len(want) {
+		t.Fatalf("home feed holds %d posts, want %d", len(seen), len(want))
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Fatalf("post %s is missing from the home feed", id.Hex())
+		}
+	}
+}
+
+func TestParseCursorRejectsForeignCursors(t *testing.T) {
+	for _, value := range []string{"1700000000000", "soon_65a000000000000000000000", "1700000000000_not-a-post"} {
+		if _, err := post.ParseCursor(value); !errors.Is(err, post.ErrInvalidCursor) {
+			t.Errorf("ParseCursor(%q) = %v, want %v", value, err, post.ErrInvalidCursor)
+		}
+	}
+}
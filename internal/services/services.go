@@ -0,0 +1,64 @@
+package services
+
+import (
+	"github.com/Caqil/vyrall/internal/services/analytics"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/services/comment"
+	"github.com/Caqil/vyrall/internal/services/event"
+	"github.com/Caqil/vyrall/internal/services/livestream"
+	"github.com/Caqil/vyrall/internal/services/message"
+	"github.com/Caqil/vyrall/internal/services/metrics"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/internal/services/post"
+	"github.com/Caqil/vyrall/internal/services/user"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+// Services holds every application service, built once at startup and shared by
+// the HTTP handlers, websocket handlers and background workers
+type Services struct {
+	AnalyticsService    *analytics.Service
+	AuthService         auth.Service
+	CommentService      comment.Service
+	EventService        event.Service
+	LiveStreamService   *livestream.Service
+	MessageService      *message.Service
+	MetricsService      *metrics.Service
+	NotificationService *notification.Service
+	PermissionService   *permission.Service
+	PostService         *post.Service
+	UserService         *user.Service
+
+	// JobQueue runs work in the background; services enqueue and the admin
+	// system endpoints read its stats and control it
+	JobQueue *queue.RedisQueue
+
+	// EventBus routes domain events recorded in the outbox to the services
+	// subscribed to them
+	EventBus *eventbus.Bus
+
+	// OAuthServer is the authorization server third-party apps use to act for users
+	OAuthServer *auth.OAuthServerService
+
+	// Keyring signs access tokens and publishes the keys that verify them
+	Keyring *keyring.Keyring
+
+	// AccountPurgeService erases accounts once their deletion grace period
+	// has passed and keeps the record of what was erased
+	AccountPurgeService *user.PurgeService
+
+	// TimelineService keeps the cached home timelines up to date and reads
+	// the home feed from them
+	TimelineService *post.TimelineService
+}
+
+// Close stops background processing owned by the services and flushes buffered state.
+// It must run before the database connections are closed.
+func (s *Services) Close() {
+	if s.AnalyticsService != nil {
+		s.AnalyticsService.Close()
+	}
+}
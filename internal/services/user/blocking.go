@@ -0,0 +1,69 @@
+package user
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BlockUser blocks targetID for userID and removes any follow between them in
+// either direction, pending or accepted, in one transaction. Blocking someone
+// twice has no further effect.
+func (s *Service) BlockUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	if userID == targetID {
+		return ErrCannotBlockSelf
+	}
+
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.users.GetByID(ctx, targetID); err != nil {
+			return err
+		}
+		if err := s.removeFollow(ctx, userID, targetID); err != nil {
+			return err
+		}
+		if err := s.removeFollow(ctx, targetID, userID); err != nil {
+			return err
+		}
+
+		return s.updateBlockedUsers(ctx, userID, func(blocked []string) []string {
+			if indexOf(blocked, targetID.Hex()) >= 0 {
+				return blocked
+			}
+			return append(blocked, targetID.Hex())
+		})
+	})
+}
+
+// UnblockUser lifts userID's block on targetID. Follows removed by the block
+// are not restored. Unblocking someone not blocked has no effect.
+func (s *Service) UnblockUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.updateBlockedUsers(ctx, userID, func(blocked []string) []string {
+			if i := indexOf(blocked, targetID.Hex()); i >= 0 {
+				return append(blocked[:i:i], blocked[i+1:]...)
+			}
+			return blocked
+		})
+	})
+}
+
+// updateBlockedUsers rewrites userID's blocked list with update
+func (s *Service) updateBlockedUsers(ctx context.Context, userID primitive.ObjectID, update func([]string) []string) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	settings := user.Settings.PrivacySettings
+	settings.BlockedUsers = update(settings.BlockedUsers)
+	return s.users.UpdatePrivacySettings(ctx, userID, settings)
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,214 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// purgeBatchSize bounds how many accounts a sweep returns and how many media
+// items are loaded at a time
+const purgeBatchSize = 100
+
+// FileStore removes uploaded files. Deleting a file that is already gone is
+// not an error.
+type FileStore interface {
+	Delete(ctx context.Context, url string) error
+}
+
+// PurgeService erases accounts whose deletion grace period has ended.
+// Deletion is requested and cancelled through the auth service, which
+// stamps the user with when the purge becomes due.
+type PurgeService struct {
+	users  interfaces.UserRepository
+	media  interfaces.MediaRepository
+	purges interfaces.AccountPurgeRepository
+	files  FileStore
+	now    func() time.Time
+}
+
+// NewPurgeService creates an account purge service
+func NewPurgeService(users interfaces.UserRepository, media interfaces.MediaRepository, purges interfaces.AccountPurgeRepository, files FileStore) *PurgeService {
+	return &PurgeService{
+		users:  users,
+		media:  media,
+		purges: purges,
+		files:  files,
+		now:    time.Now,
+	}
+}
+
+// DueDeletions returns up to one batch of accounts whose grace period ended by now
+func (s *PurgeService) DueDeletions(ctx context.Context, now time.Time) ([]primitive.ObjectID, error) {
+	users, _, err := s.users.List(ctx,
+		map[string]interface{}{"deletion_scheduled_at": bson.M{"$lte": now}},
+		map[string]int{"deletion_scheduled_at": 1},
+		purgeBatchSize, 0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids, nil
+}
+
+// PurgeAccount erases a user whose grace period has ended and returns the
+// report of what was erased. The report is saved before anything is touched
+// and the account is then marked deleted, so it can no longer log in, and a
+// purge that fails part way is finished by the next attempt. Purging an
+// account that was already purged returns the existing report; an account
+// whose deletion was cancelled or is not yet due is left alone and no
+// report is returned.
+func (s *PurgeService) PurgeAccount(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error) {
+	now := s.now()
+
+	report, err := s.purges.GetReport(ctx, userID)
+	switch {
+	case err == nil:
+		if report.CompletedAt != nil {
+			return report, nil
+		}
+	case errors.Is(err, mongo.ErrNoDocuments):
+		if report, err = s.startPurge(ctx, userID, now); err != nil || report == nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	// Files go before their records, so a retry still finds what is left
+	files, err := s.deleteMediaFiles(ctx, userID)
+	report.MediaFiles += files
+	addPurged(report, models.PurgedCollection{Collection: constants.CollectionMedia, Deleted: int64(files)})
+	if err != nil {
+		return nil, s.saveProgress(ctx, report, err)
+	}
+
+	purged, err := s.purges.Purge(ctx, userID, now)
+	for _, collection := range purged {
+		addPurged(report, collection)
+	}
+	if err != nil {
+		return nil, s.saveProgress(ctx, report, err)
+	}
+
+	completedAt := s.now()
+	report.CompletedAt = &completedAt
+	if err := s.purges.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports returns purge reports, most recently started first
+func (s *PurgeService) ListReports(ctx context.Context, limit, offset int) ([]*models.AccountPurgeReport, int, error) {
+	return s.purges.ListReports(ctx, limit, offset)
+}
+
+// GetReport returns the purge report for an account
+func (s *PurgeService) GetReport(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error) {
+	return s.purges.GetReport(ctx, userID)
+}
+
+// startPurge records the start of a purge and marks the account deleted.
+// It returns no report when the account is not due to be purged.
+func (s *PurgeService) startPurge(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.AccountPurgeReport, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt == nil || user.DeletionScheduledAt.After(now) {
+		return nil, nil
+	}
+
+	report := &models.AccountPurgeReport{
+		UserID:      userID,
+		ScheduledAt: *user.DeletionScheduledAt,
+		StartedAt:   now,
+		Collections: []models.PurgedCollection{},
+	}
+	if user.DeletionRequestedAt != nil {
+		report.RequestedAt = *user.DeletionRequestedAt
+	}
+	if err := s.purges.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.UpdateStatus(ctx, userID, "deleted"); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// deleteMediaFiles removes the user's uploaded files and their media records,
+// returning how many were removed. Each page is read from the start, as the
+// records before it are gone; a page of records already handled means they
+// are not being removed, and fails the purge rather than looping on it.
+func (s *PurgeService) deleteMediaFiles(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	deleted := 0
+	handled := make(map[primitive.ObjectID]bool)
+	for {
+		items, _, err := s.media.GetByUserID(ctx, userID, "", purgeBatchSize, 0)
+		if err != nil || len(items) == 0 {
+			return deleted, err
+		}
+
+		progress := false
+		for _, item := range items {
+			if handled[item.ID] {
+				continue
+			}
+			handled[item.ID] = true
+			progress = true
+
+			for _, url := range []string{item.URL, item.ThumbnailURL} {
+				if url == "" {
+					continue
+				}
+				if err := s.files.Delete(ctx, url); err != nil {
+					return deleted, err
+				}
+			}
+			if err := s.media.Delete(ctx, item.ID); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return deleted, err
+			}
+			deleted++
+		}
+		if !progress {
+			return deleted, fmt.Errorf("media records of user %s are not being removed", userID.Hex())
+		}
+	}
+}
+
+// saveProgress records what a failed purge erased before returning its error
+func (s *PurgeService) saveProgress(ctx context.Context, report *models.AccountPurgeReport, err error) error {
+	if saveErr := s.purges.SaveReport(ctx, report); saveErr != nil {
+		return errors.Join(err, saveErr)
+	}
+	return err
+}
+
+// addPurged adds counts to the report's entry for a collection
+func addPurged(report *models.AccountPurgeReport, counts models.PurgedCollection) {
+	for i := range report.Collections {
+		if report.Collections[i].Collection == counts.Collection {
+			report.Collections[i].Deleted += counts.Deleted
+			report.Collections[i].Updated += counts.Updated
+			return
+		}
+	}
+	report.Collections = append(report.Collections, counts)
+}
@@ -0,0 +1,271 @@
+package user_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/services/user"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// flakyFiles is a file store whose failAt'th delete fails, if failAt is set
+type flakyFiles struct {
+	*memory.FileStore
+	deletes int
+	failAt  int
+}
+
+func (f *flakyFiles) Delete(ctx context.Context, url string) error {
+	f.deletes++
+	if f.deletes == f.failAt {
+		return stderrors.New("storage unavailable")
+	}
+	return f.FileStore.Delete(ctx, url)
+}
+
+// purgeEnv is a purge service on top of in-memory repositories
+type purgeEnv struct {
+	db     *memory.Database
+	repos  *memory.Repositories
+	files  *flakyFiles
+	purges *user.PurgeService
+}
+
+func newPurgeEnv() *purgeEnv {
+	db := memory.NewDatabase()
+	repos := memory.NewRepositories(db)
+	files := &flakyFiles{FileStore: memory.NewFileStore()}
+	return &purgeEnv{
+		db:     db,
+		repos:  repos,
+		files:  files,
+		purges: user.NewPurgeService(repos.Users, repos.Media, repos.AccountPurges, files),
+	}
+}
+
+// user creates a user whose deletion falls due at scheduledAt, or who has
+// not asked to be deleted if it is nil, with a post, two uploads and a
+// follower
+func (e *purgeEnv) user(t *testing.T, username string, scheduledAt *time.Time) primitive.ObjectID {
+	t.Helper()
+	ctx := context.Background()
+
+	var requestedAt *time.Time
+	if scheduledAt != nil {
+		requested := scheduledAt.Add(-30 * 24 * time.Hour)
+		requestedAt = &requested
+	}
+	id, err := e.repos.Users.Create(ctx, &models.User{
+		Username:            username,
+		Email:               username + "@example.com",
+		Status:              "active",
+		DeletionRequestedAt: requestedAt,
+		DeletionScheduledAt: scheduledAt,
+	})
+	if err != nil {
+		t.Fatalf("create %s: %v", username, err)
+	}
+	if _, err := e.repos.Posts.Create(ctx, &models.Post{UserID: id, Content: "Hello from " + username}); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	for _, name := range []string{"avatar", "cover"} {
+		upload := &models.Media{UserID: id, URL: mediaURL(username, name), ThumbnailURL: mediaURL(username, name+"_thumb")}
+		if _, err := e.repos.Media.Create(ctx, upload); err != nil {
+			t.Fatalf("create media: %v", err)
+		}
+		e.files.Put(upload.URL)
+		e.files.Put(upload.ThumbnailURL)
+	}
+	if _, err := e.repos.Follows.Create(ctx, &models.Follow{FollowerID: primitive.NewObjectID(), FollowingID: id}); err != nil {
+		t.Fatalf("create follow: %v", err)
+	}
+	return id
+}
+
+// mediaURL returns where a test user's upload is stored
+func mediaURL(username, name string) string {
+	return "https://cdn.example.com/" + username + "/" + name + ".jpg"
+}
+
+// count returns how many documents in collection match filter
+func (e *purgeEnv) count(t *testing.T, collection string, filter bson.M) int {
+	t.Helper()
+	n, err := e.db.Collection(collection).Count(filter)
+	if err != nil {
+		t.Fatalf("count %s: %v", collection, err)
+	}
+	return n
+}
+
+// assertPurged fails the test unless report is complete and counts what
+// the test user had, and the user's data is gone
+func (e *purgeEnv) assertPurged(t *testing.T, report *models.AccountPurgeReport, id primitive.ObjectID) {
+	t.Helper()
+	if report == nil || report.CompletedAt == nil {
+		t.Fatalf("report = %+v, want a completed purge", report)
+	}
+	if report.MediaFiles != 2 {
+		t.Errorf("report counts %d media files, want 2", report.MediaFiles)
+	}
+
+	deleted := make(map[string]int64)
+	for _, collection := range report.Collections {
+		if _, ok := deleted[collection.Collection]; ok {
+			t.Errorf("report lists %s twice", collection.Collection)
+		}
+		deleted[collection.Collection] = collection.Deleted
+	}
+	for collection, want := range map[string]int64{
+		constants.CollectionUsers:   1,
+		constants.CollectionPosts:   1,
+		constants.CollectionMedia:   2,
+		constants.CollectionFollows: 1,
+	} {
+		if deleted[collection] != want {
+			t.Errorf("report counts %d deleted from %s, want %d", deleted[collection], collection, want)
+		}
+		if n := e.count(t, collection, bson.M{"$or": bson.A{bson.M{"_id": id}, bson.M{"user_id": id}, bson.M{"following_id": id}}}); n != 0 {
+			t.Errorf("%d documents left in %s", n, collection)
+		}
+	}
+}
+
+func TestPurgeAccount(t *testing.T) {
+	env := newPurgeEnv()
+	ctx := context.Background()
+	due := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	id := env.user(t, "leaving", &due)
+	env.user(t, "staying", nil)
+
+	report, err := env.purges.PurgeAccount(ctx, id)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	env.assertPurged(t, report, id)
+	for _, name := range []string{"avatar", "avatar_thumb", "cover", "cover_thumb"} {
+		if env.files.Exists(mediaURL("leaving", name)) {
+			t.Errorf("uploaded file %s was kept", name)
+		}
+	}
+	if !report.ScheduledAt.Equal(due) || report.RequestedAt.IsZero() {
+		t.Errorf("report requested %v and scheduled %v, want the account's deletion request", report.RequestedAt, report.ScheduledAt)
+	}
+
+	// Other accounts are untouched
+	if n := env.count(t, constants.CollectionPosts, bson.M{}); n != 1 {
+		t.Errorf("%d posts left, want the other user's", n)
+	}
+	if !env.files.Exists(mediaURL("staying", "avatar")) {
+		t.Error("other user's upload was removed")
+	}
+
+	// Purging again returns the stored report and erases nothing more
+	again, err := env.purges.PurgeAccount(ctx, id)
+	if err != nil {
+		t.Fatalf("second purge: %v", err)
+	}
+	if again.ID != report.ID || !again.CompletedAt.Equal(report.CompletedAt.Truncate(time.Millisecond)) {
+		t.Fatalf("second purge reported %+v, want the first report", again)
+	}
+	env.assertPurged(t, again, id)
+	if _, total, err := env.purges.ListReports(ctx, 10, 0); err != nil || total != 1 {
+		t.Fatalf("list reports = %d, %v, want 1", total, err)
+	}
+}
+
+func TestPurgeAccountResumesAfterFailure(t *testing.T) {
+	env := newPurgeEnv()
+	ctx := context.Background()
+	due := time.Now().Add(-time.Minute)
+	id := env.user(t, "leaving", &due)
+
+	// Storage fails once the first upload is gone
+	env.files.failAt = 3
+	if _, err := env.purges.PurgeAccount(ctx, id); err == nil {
+		t.Fatal("purge succeeded while storage was down")
+	}
+
+	// The account is closed and the attempt recorded before anything is erased
+	stored, err := env.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if stored.Status != "deleted" {
+		t.Fatalf("status after a failed purge = %q, want deleted", stored.Status)
+	}
+	partial, err := env.purges.GetReport(ctx, id)
+	if err != nil {
+		t.Fatalf("get report: %v", err)
+	}
+	if partial.CompletedAt != nil {
+		t.Fatal("failed purge reported complete")
+	}
+
+	report, err := env.purges.PurgeAccount(ctx, id)
+	if err != nil {
+		t.Fatalf("retry purge: %v", err)
+	}
+	if report.ID != partial.ID || !report.StartedAt.Equal(partial.StartedAt) {
+		t.Fatalf("retry started report %s at %v, want it to finish %s", report.ID.Hex(), report.StartedAt, partial.ID.Hex())
+	}
+	env.assertPurged(t, report, id)
+}
+
+func TestPurgeAccountLeavesAccountsNotDue(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	tests := map[string]*time.Time{
+		"deletion not requested": nil,
+		"deletion not yet due":   &later,
+	}
+	for name, scheduledAt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newPurgeEnv()
+			ctx := context.Background()
+			id := env.user(t, "member", scheduledAt)
+
+			report, err := env.purges.PurgeAccount(ctx, id)
+			if err != nil || report != nil {
+				t.Fatalf("purge = %+v, %v, want nothing done", report, err)
+			}
+			stored, err := env.repos.Users.GetByID(ctx, id)
+			if err != nil {
+				t.Fatalf("get user: %v", err)
+			}
+			if stored.Status != "active" {
+				t.Fatalf("status = %q, want active", stored.Status)
+			}
+			if n := env.count(t, constants.CollectionPosts, bson.M{"user_id": id}); n != 1 {
+				t.Fatalf("%d posts left, want 1", n)
+			}
+			if n := env.count(t, constants.CollectionAccountPurgeReports, bson.M{}); n != 0 {
+				t.Fatalf("%d purge reports, want none", n)
+			}
+		})
+	}
+}
+
+func TestDueDeletions(t *testing.T) {
+	env := newPurgeEnv()
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	due := env.user(t, "due", &past)
+	env.user(t, "pending", &future)
+	env.user(t, "member", nil)
+
+	ids, err := env.purges.DueDeletions(context.Background(), now)
+	if err != nil {
+		t.Fatalf("due deletions: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != due {
+		t.Fatalf("due deletions = %v, want only %s", ids, due.Hex())
+	}
+}
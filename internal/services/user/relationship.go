@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+)
+
+// FollowUser follows targetID and returns the resulting follow status.
+// Following a private account creates a pending request and leaves the
+// counters alone until it is approved. Following someone twice returns the
+// existing status.
+func (s *Service) FollowUser(ctx context.Context, userID, targetID primitive.ObjectID, notifyPosts bool) (string, error) {
+	if userID == targetID {
+		return "", ErrCannotFollowSelf
+	}
+
+	var status string
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		target, err := s.users.GetByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := s.follows.GetByFollowerAndFollowing(ctx, userID, targetID)
+		if err == nil {
+			status = existing.Status
+			return nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		status = FollowStatusAccepted
+		if target.IsPrivate {
+			status = FollowStatusPending
+		}
+
+		now := time.Now()
+		follow := &models.Follow{
+			FollowerID:  userID,
+			FollowingID: targetID,
+			Status:      status,
+			NotifyPosts: notifyPosts,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if _, err := s.follows.Create(ctx, follow); err != nil {
+			return err
+		}
+
+		if status == FollowStatusAccepted {
+			if err := s.adjustFollowCounts(ctx, userID, targetID, 1); err != nil {
+				return err
+			}
+		}
+		return s.events.Publish(ctx, eventbus.UserFollowed{FollowerID: userID, FollowingID: targetID, Status: status})
+	})
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// UnfollowUser stops userID following targetID, withdrawing a pending request
+// if there is one. Unfollowing someone not followed has no effect.
+func (s *Service) UnfollowUser(ctx context.Context, userID, targetID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.removeFollow(ctx, userID, targetID)
+	})
+}
+
+// RemoveFollower stops followerID following userID
+func (s *Service) RemoveFollower(ctx context.Context, userID, followerID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.removeFollow(ctx, followerID, userID)
+	})
+}
+
+// ApproveFollowRequest accepts a pending request from followerID and updates
+// both users' counters in one transaction
+func (s *Service) ApproveFollowRequest(ctx context.Context, userID, followerID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.pendingRequest(ctx, userID, followerID); err != nil {
+			return err
+		}
+		if err := s.follows.UpdateStatus(ctx, followerID, userID, FollowStatusAccepted); err != nil {
+			return err
+		}
+		if err := s.adjustFollowCounts(ctx, followerID, userID, 1); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, eventbus.UserFollowed{FollowerID: followerID, FollowingID: userID, Status: FollowStatusAccepted})
+	})
+}
+
+// RejectFollowRequest deletes a pending request from followerID
+func (s *Service) RejectFollowRequest(ctx context.Context, userID, followerID primitive.ObjectID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		follow, err := s.pendingRequest(ctx, userID, followerID)
+		if err != nil {
+			return err
+		}
+		return s.follows.Delete(ctx, follow.ID)
+	})
+}
+
+// pendingRequest returns the pending follow from followerID to userID
+func (s *Service) pendingRequest(ctx context.Context, userID, followerID primitive.ObjectID) (*models.Follow, error) {
+	follow, err := s.follows.GetByFollowerAndFollowing(ctx, followerID, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNoFollowRequest
+	}
+	if err != nil {
+		return nil, err
+	}
+	if follow.Status != FollowStatusPending {
+		return nil, ErrNoFollowRequest
+	}
+	return follow, nil
+}
+
+// removeFollow deletes the follow from followerID to followingID, lowering the
+// counters if it had been accepted, and publishes UserUnfollowed
+func (s *Service) removeFollow(ctx context.Context, followerID, followingID primitive.ObjectID) error {
+	follow, err := s.follows.GetByFollowerAndFollowing(ctx, followerID, followingID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.follows.Delete(ctx, follow.ID); err != nil {
+		return err
+	}
+	if follow.Status == FollowStatusAccepted {
+		if err := s.adjustFollowCounts(ctx, followerID, followingID, -1); err != nil {
+			return err
+		}
+	}
+	return s.events.Publish(ctx, eventbus.UserUnfollowed{FollowerID: followerID, FollowingID: followingID, Status: follow.Status})
+}
+
+// adjustFollowCounts moves the following count of followerID and the follower
+// count of followingID by amount
+func (s *Service) adjustFollowCounts(ctx context.Context, followerID, followingID primitive.ObjectID, amount int) error {
+	if err := s.users.IncrementFollowingCount(ctx, followerID, amount); err != nil {
+		return err
+	}
+	return s.users.IncrementFollowerCount(ctx, followingID, amount)
+}
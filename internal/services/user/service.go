@@ -0,0 +1,43 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+)
+
+// Follow statuses. Follows of private accounts wait as pending until approved.
+const (
+	FollowStatusPending  = "pending"
+	FollowStatusAccepted = "accepted"
+)
+
+var (
+	// ErrCannotFollowSelf is returned when a user tries to follow themselves
+	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+	// ErrCannotBlockSelf is returned when a user tries to block themselves
+	ErrCannotBlockSelf = errors.New("cannot block yourself")
+	// ErrNoFollowRequest is returned when approving or rejecting a request that is not pending
+	ErrNoFollowRequest = errors.New("no pending follow request")
+)
+
+// Service implements user features on top of the repositories. Writes that
+// touch a denormalised counter run in a transaction with the counter update,
+// and domain events are published to the outbox in the same transaction.
+type Service struct {
+	users   interfaces.UserRepository
+	follows interfaces.FollowRepository
+	tx      interfaces.Transactor
+	events  eventbus.Publisher
+}
+
+// NewService creates a user service
+func NewService(users interfaces.UserRepository, follows interfaces.FollowRepository, tx interfaces.Transactor, events eventbus.Publisher) *Service {
+	return &Service{
+		users:   users,
+		follows: follows,
+		tx:      tx,
+		events:  events,
+	}
+}
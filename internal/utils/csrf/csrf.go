@@ -0,0 +1,197 @@
+// Package csrf protects cookie-authenticated clients from cross-site request
+// forgery with signed double-submit tokens. A token is bound to the session it
+// was minted for and signed with a key derived from the server secret; it is
+// sent both in a cookie and in a request header, so a cross-site page, which
+// can neither read the cookie nor set the header, cannot produce a valid pair.
+// The Origin or Referer of the request is checked as a second layer.
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Where clients send the token
+const (
+	CookieName = "vyrall_csrf"
+	HeaderName = "X-CSRF-Token"
+	FormField  = "_csrf"
+)
+
+const (
+	nonceBytes = 16
+	tokenBytes = nonceBytes + 8 + sha256.Size
+	keyBytes   = sha256.Size
+
+	// keyLabel separates the CSRF key from other keys derived from the same secret
+	keyLabel = "vyrall csrf token key v1"
+)
+
+var (
+	// ErrInvalidToken is returned for a missing, malformed, forged, expired or
+	// mismatched token, or one minted for another session
+	ErrInvalidToken = errors.New("csrf: invalid token")
+
+	// ErrOriginNotAllowed is returned when the request comes from an untrusted origin
+	ErrOriginNotAllowed = errors.New("csrf: origin not allowed")
+)
+
+// Config configures a Protector
+type Config struct {
+	TrustedOrigins []string      // Origins allowed to send state-changing requests
+	TTL            time.Duration // How long a token is accepted
+	SecureCookie   bool          // Send the cookie over HTTPS only
+}
+
+// Protector mints and verifies tokens
+type Protector struct {
+	key     []byte
+	origins map[string]bool
+	ttl     time.Duration
+	secure  bool
+	now     func() time.Time
+}
+
+// New creates a Protector that signs tokens with a key derived from secret
+// by HKDF under the CSRF label, so the key cannot sign or decrypt anything
+// else derived from the same secret
+func New(secret string, config Config) *Protector {
+	key := make([]byte, keyBytes)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(keyLabel)), key); err != nil {
+		// HKDF-SHA256 yields up to 255 blocks, far more than one key
+		panic("csrf: derive key: " + err.Error())
+	}
+
+	origins := make(map[string]bool, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		origins[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return &Protector{
+		key:     key,
+		origins: origins,
+		ttl:     config.TTL,
+		secure:  config.SecureCookie,
+		now:     time.Now,
+	}
+}
+
+// Token mints a token for a session
+func (p *Protector) Token(sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", errors.New("csrf: session ID is required")
+	}
+
+	raw := make([]byte, nonceBytes, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	raw = binary.BigEndian.AppendUint64(raw, uint64(p.now().Add(p.ttl).Unix()))
+	raw = append(raw, p.sign(sessionID, raw)...)
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Verify checks that token was minted by this server for sessionID and has not expired
+func (p *Protector) Verify(sessionID, token string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != tokenBytes || sessionID == "" {
+		return ErrInvalidToken
+	}
+
+	payload, signature := raw[:nonceBytes+8], raw[nonceBytes+8:]
+	if !hmac.Equal(signature, p.sign(sessionID, payload)) {
+		return ErrInvalidToken
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[nonceBytes:])), 0)
+	if !p.now().Before(expiresAt) {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// VerifyRequest checks the double submit: the token in the header (or form
+// field) must equal the token in the cookie and be valid for sessionID
+func (p *Protector) VerifyRequest(r *http.Request, sessionID string) error {
+	submitted := r.Header.Get(HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(FormField)
+	}
+
+	cookie, err := r.Cookie(CookieName)
+	if err != nil || submitted == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(cookie.Value)) != 1 {
+		return ErrInvalidToken
+	}
+
+	return p.Verify(sessionID, submitted)
+}
+
+// CheckOrigin rejects requests whose Origin, or Referer when there is no
+// Origin, is not a trusted origin. Requests carrying neither are left to the
+// token check, since some clients and privacy tools strip both.
+func (p *Protector) CheckOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return nil
+		}
+		parsed, err := url.Parse(referer)
+		if err != nil || parsed.Host == "" {
+			return ErrOriginNotAllowed
+		}
+		origin = parsed.Scheme + "://" + parsed.Host
+	}
+
+	// Opaque origins ("null") come from sandboxed frames and data: URLs
+	if !p.origins[origin] {
+		return ErrOriginNotAllowed
+	}
+	return nil
+}
+
+// Cookie returns the cookie that carries token. It is HttpOnly because the
+// client reads the token from the response that minted it, and SameSite=Strict
+// so it is never sent by cross-site requests in the first place.
+func (p *Protector) Cookie(token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(p.ttl.Seconds()),
+		Secure:   p.secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// Safe reports whether method is one that must not change state, and so needs no token
+func Safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func (p *Protector) sign(sessionID string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(sessionID))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,155 @@
+package csrf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret-that-is-long-enough-for-tests"
+
+func newTestProtector() *Protector {
+	return New(testSecret, Config{
+		TrustedOrigins: []string{"https://app.example.com/"},
+		TTL:            time.Hour,
+	})
+}
+
+// newRequest builds a POST that submits token in the header and cookie token in the cookie
+func newRequest(token, cookie string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "https://api.example.com/api/posts", nil)
+	if token != "" {
+		r.Header.Set(HeaderName, token)
+	}
+	if cookie != "" {
+		r.AddCookie(&http.Cookie{Name: CookieName, Value: cookie})
+	}
+	return r
+}
+
+func TestVerifyRequest(t *testing.T) {
+	p := newTestProtector()
+	token, err := p.Token("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := p.Token("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		request   *http.Request
+		sessionID string
+		wantErr   error
+	}{
+		{"matching token", newRequest(token, token), "session-a", nil},
+		{"missing cookie", newRequest(token, ""), "session-a", ErrInvalidToken},
+		{"missing header", newRequest("", token), "session-a", ErrInvalidToken},
+		{"header and cookie differ", newRequest(token, other), "session-a", ErrInvalidToken},
+		{"token for another session", newRequest(token, token), "session-b", ErrInvalidToken},
+		{"malformed token", newRequest("not-a-token", "not-a-token"), "session-a", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.VerifyRequest(tt.request, tt.sessionID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyRequest() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestReadsFormField(t *testing.T) {
+	p := newTestProtector()
+	token, err := p.Token("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+	r.PostForm = map[string][]string{FormField: {token}}
+	r.AddCookie(&http.Cookie{Name: CookieName, Value: token})
+	if err := p.VerifyRequest(r, "session-a"); err != nil {
+		t.Fatalf("VerifyRequest() = %v, want nil", err)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	p := newTestProtector()
+	issued := time.Now()
+	p.now = func() time.Time { return issued }
+	token, err := p.Token("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.now = func() time.Time { return issued.Add(59 * time.Minute) }
+	if err := p.Verify("session-a", token); err != nil {
+		t.Fatalf("Verify() before expiry = %v, want nil", err)
+	}
+
+	p.now = func() time.Time { return issued.Add(time.Hour) }
+	if err := p.Verify("session-a", token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() after expiry = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsTokenSignedWithAnotherSecret(t *testing.T) {
+	token, err := New("another-secret", Config{TTL: time.Hour}).Token("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestProtector().Verify("session-a", token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsTokenSignedWithTheSecretItself(t *testing.T) {
+	// Whoever holds a key used elsewhere under the same secret cannot mint tokens
+	forger := newTestProtector()
+	forger.key = []byte(testSecret)
+	token, err := forger.Token("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestProtector().Verify("session-a", token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	p := newTestProtector()
+
+	tests := []struct {
+		name    string
+		origin  string
+		referer string
+		wantErr error
+	}{
+		{"trusted origin", "https://app.example.com", "", nil},
+		{"foreign origin", "https://evil.example", "", ErrOriginNotAllowed},
+		{"opaque origin", "null", "", ErrOriginNotAllowed},
+		{"trusted referer", "", "https://app.example.com/settings?tab=security", nil},
+		{"foreign referer", "", "https://evil.example/app.example.com", ErrOriginNotAllowed},
+		{"relative referer", "", "/settings", ErrOriginNotAllowed},
+		{"origin wins over referer", "https://evil.example", "https://app.example.com/", ErrOriginNotAllowed},
+		{"neither header", "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				r.Header.Set("Referer", tt.referer)
+			}
+			if err := p.CheckOrigin(r); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckOrigin() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
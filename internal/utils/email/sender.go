@@ -0,0 +1,180 @@
+// Package email renders and sends transactional email
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+)
+
+// Providers understood by NewSender
+const (
+	ProviderSMTP = "smtp"
+	ProviderSES  = "ses"
+	ProviderLog  = "log"
+)
+
+// Message is a rendered email ready to send
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// transport delivers a raw message to one recipient
+type transport func(from, to string, raw []byte) error
+
+// Sender renders templated email and hands it to the configured provider
+type Sender struct {
+	from      mail.Address
+	templates *Templates
+	send      transport
+}
+
+// NewSender creates a sender for the configured provider. SES is reached
+// through its SMTP interface in region, using the SMTP credentials from cfg.
+// The log provider writes messages to log instead of sending them.
+func NewSender(cfg *config.EmailConfig, region string, log *logger.Logger) (*Sender, error) {
+	if cfg.FromAddress == "" {
+		return nil, fmt.Errorf("email: from address is required")
+	}
+
+	sender := &Sender{
+		from:      mail.Address{Name: cfg.FromName, Address: cfg.FromAddress},
+		templates: DefaultTemplates(),
+	}
+
+	switch cfg.Provider {
+	case ProviderSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("email: smtp host is required")
+		}
+		sender.send = smtpTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.UseTLS)
+	case ProviderSES:
+		if region == "" || cfg.SMTPUsername == "" || cfg.SMTPPassword == "" {
+			return nil, fmt.Errorf("email: ses needs a region and smtp credentials")
+		}
+		host := "email-smtp." + region + ".amazonaws.com"
+		sender.send = smtpTransport(host, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, true)
+	case ProviderLog:
+		sender.send = func(from, to string, raw []byte) error {
+			log.Info("Email not sent, log provider", "from", from, "to", to, "message", string(raw))
+			return nil
+		}
+	default:
+		return nil, fmt.Errorf("email: unknown provider %q", cfg.Provider)
+	}
+	return sender, nil
+}
+
+// SendTemplatedEmail renders template with data and sends it to one recipient
+func (s *Sender) SendTemplatedEmail(to, subject, template string, data map[string]interface{}) error {
+	text, html, err := s.templates.Render(template, data)
+	if err != nil {
+		return err
+	}
+	return s.Send(&Message{To: to, Subject: subject, Text: text, HTML: html})
+}
+
+// Send sends a rendered message
+func (s *Sender) Send(msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("email: invalid recipient: %w", err)
+	}
+	return s.send(s.from.Address, to.Address, s.build(to, msg))
+}
+
+// build encodes a message as multipart/alternative when it has an HTML body
+func (s *Sender) build(to *mail.Address, msg *Message) []byte {
+	var b bytes.Buffer
+	header := func(key, value string) {
+		b.WriteString(key + ": " + value + "\r\n")
+	}
+
+	header("From", s.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		b.WriteString("\r\n" + msg.Text)
+		return b.Bytes()
+	}
+
+	boundary := "vyrall-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	header("Content-Type", "multipart/alternative; boundary="+boundary)
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		b.WriteString("--" + boundary + "\r\n")
+		b.WriteString("Content-Type: " + part.contentType + "; charset=utf-8\r\n\r\n")
+		b.WriteString(part.body + "\r\n")
+	}
+	b.WriteString("--" + boundary + "--\r\n")
+	return b.Bytes()
+}
+
+// smtpTransport sends through an SMTP server. With useTLS the connection is
+// implicitly encrypted on port 465 and upgraded with STARTTLS otherwise.
+func smtpTransport(host string, port int, username, password string, useTLS bool) transport {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return func(from, to string, raw []byte) error {
+		if !useTLS || port != 465 {
+			return smtp.SendMail(addr, auth, from, []string{to}, raw)
+		}
+
+		conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return err
+		}
+		client, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		defer client.Close()
+
+		if auth != nil {
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+		if err := client.Mail(from); err != nil {
+			return err
+		}
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+		w, err := client.Data()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		return client.Quit()
+	}
+}
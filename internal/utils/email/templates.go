@@ -0,0 +1,136 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// Templates holds the named email templates. Every template has a plain-text
+// body and may have an HTML body as well.
+type Templates struct {
+	text map[string]*template.Template
+	html map[string]*htmltemplate.Template
+}
+
+// NewTemplates creates an empty template set
+func NewTemplates() *Templates {
+	return &Templates{
+		text: map[string]*template.Template{},
+		html: map[string]*htmltemplate.Template{},
+	}
+}
+
+// Add parses and registers a template. html may be empty.
+func (t *Templates) Add(name, text, html string) error {
+	textTmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return fmt.Errorf("email: parse %s: %w", name, err)
+	}
+	t.text[name] = textTmpl
+
+	if html != "" {
+		htmlTmpl, err := htmltemplate.New(name).Option("missingkey=zero").Parse(html)
+		if err != nil {
+			return fmt.Errorf("email: parse %s html: %w", name, err)
+		}
+		t.html[name] = htmlTmpl
+	}
+	return nil
+}
+
+// Render executes a template, returning its text and HTML bodies
+func (t *Templates) Render(name string, data map[string]interface{}) (text, html string, err error) {
+	textTmpl, ok := t.text[name]
+	if !ok {
+		return "", "", fmt.Errorf("email: unknown template %q", name)
+	}
+
+	var b bytes.Buffer
+	if err := textTmpl.Execute(&b, data); err != nil {
+		return "", "", fmt.Errorf("email: render %s: %w", name, err)
+	}
+	text = b.String()
+
+	if htmlTmpl, ok := t.html[name]; ok {
+		b.Reset()
+		if err := htmlTmpl.Execute(&b, data); err != nil {
+			return "", "", fmt.Errorf("email: render %s html: %w", name, err)
+		}
+		html = b.String()
+	}
+	return text, html, nil
+}
+
+// DefaultTemplates returns the templates for the emails the services send
+func DefaultTemplates() *Templates {
+	t := NewTemplates()
+	for name, text := range defaultTemplates {
+		if err := t.Add(name, text, ""); err != nil {
+			panic(err)
+		}
+	}
+	return t
+}
+
+var defaultTemplates = map[string]string{
+	"email_verification": `Hi {{.Username}},
+
+Confirm your email address by opening this link:
+
+{{.VerificationLink}}
+`,
+	"password_reset": `Hi {{.Username}},
+
+Someone asked to reset the password for your account. Open this link within {{.ExpiresIn}} hours to choose a new one:
+
+{{.ResetLink}}
+
+If this wasn't you, ignore this email and your password stays the same.
+`,
+	"login_code": `Hi {{.Username}},
+
+Your login code is {{.Code}}. It expires in {{.ExpiresIn}} minutes.
+
+The sign-in was attempted from {{.IPAddress}}. If this wasn't you, change your password.
+`,
+	"login_link": `Hi {{.Username}},
+
+Sign in by opening this link, or enter the code {{.Code}}. Both expire in {{.ExpiresIn}} minutes.
+
+{{.LoginLink}}
+
+The request came from {{.IPAddress}}. If this wasn't you, ignore this email.
+`,
+	"login_alert": `Hi {{.Username}},
+
+Your account was signed in to from a device or place we don't recognise.
+
+Device: {{.Device}}
+Location: {{.Location}}
+IP address: {{.IPAddress}}
+Time: {{.Time}}
+{{range .Reasons}}- {{.}}
+{{end}}
+If this wasn't you, sign that session out and change your password:
+
+{{.RevokeURL}}
+`,
+	"2fa_disabled": `Hi {{.Username}},
+
+Two-factor authentication was turned off for your account at {{.Time}}.
+
+If this wasn't you, change your password and turn it back on.
+`,
+	"parental_consent": `Hello,
+
+{{if .DisplayName}}{{.DisplayName}}{{else}}{{.Username}}{{end}} has signed up for Vyrall and named you as their parent or guardian.
+
+Approve the account within {{.ExpiresIn}} hours by opening this link:
+
+{{.ConsentLink}}
+
+If you don't recognise this request, ignore this email and the account stays locked.
+`,
+}
@@ -0,0 +1,120 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+// Handler processes one delivery of an event. Delivery is at least once, so a
+// handler may see the same event again after a retry and must be idempotent.
+// Returning an error retries the delivery; wrap it with queue.Permanent to
+// dead-letter it instead.
+type Handler func(ctx context.Context, envelope *Envelope) error
+
+// Bus routes events to the subscribers registered for their type. Every
+// instance must register the same subscribers, since the relay and the
+// delivery workers may run on different instances.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string]map[string]Handler // event type -> subscriber -> handler
+}
+
+// NewBus creates a bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string]map[string]Handler)}
+}
+
+// Subscribe registers handler as subscriber's handler for eventType. A
+// subscriber has one handler per event type; subscribing again replaces it.
+func (b *Bus) Subscribe(subscriber, eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handlers[eventType] == nil {
+		b.handlers[eventType] = make(map[string]Handler)
+	}
+	b.handlers[eventType][subscriber] = handler
+}
+
+// On subscribes fn to events of type T, decoding each payload before calling
+// it. T must be one of the event structs, not a pointer to one.
+func On[T Event](b *Bus, subscriber string, fn func(ctx context.Context, event T) error) {
+	OnEnvelope(b, subscriber, func(ctx context.Context, _ *Envelope, event T) error {
+		return fn(ctx, event)
+	})
+}
+
+// OnEnvelope is On for subscribers that also need the envelope, usually to
+// deduplicate redeliveries by its ID
+func OnEnvelope[T Event](b *Bus, subscriber string, fn func(ctx context.Context, envelope *Envelope, event T) error) {
+	var zero T
+	b.Subscribe(subscriber, zero.EventType(), func(ctx context.Context, envelope *Envelope) error {
+		var event T
+		if err := json.Unmarshal(envelope.Payload, &event); err != nil {
+			return queue.Permanent(fmt.Errorf("decode %s event: %w", envelope.Type, err))
+		}
+		return fn(ctx, envelope, event)
+	})
+}
+
+// subscribers returns the subscribers of an event type in a stable order
+func (b *Bus) subscribers(eventType string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names := make([]string, 0, len(b.handlers[eventType]))
+	for name := range b.handlers[eventType] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *Bus) handler(eventType, subscriber string) Handler {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.handlers[eventType][subscriber]
+}
+
+// Dispatch runs every subscriber of the envelope's event type in turn and
+// returns the first error. It bypasses the queue, so nothing is retried; it
+// lets tests deliver outbox events without Redis.
+func (b *Bus) Dispatch(ctx context.Context, envelope *Envelope) error {
+	for _, subscriber := range b.subscribers(envelope.Type) {
+		if err := b.handler(envelope.Type, subscriber)(ctx, envelope); err != nil {
+			return fmt.Errorf("%s: %w", subscriber, err)
+		}
+	}
+	return nil
+}
+
+// DeliveryPayload hands one event to one subscriber
+type DeliveryPayload struct {
+	Subscriber string   `json:"subscriber"`
+	Event      Envelope `json:"event"`
+}
+
+// DeliveryProcessor runs the subscriber handler named by each delivery job.
+// The queue retries failed deliveries with backoff and dead-letters them once
+// they run out of attempts.
+func (b *Bus) DeliveryProcessor(log *logger.Logger) queue.Handler {
+	return queue.Typed(func(ctx context.Context, payload DeliveryPayload) error {
+		handler := b.handler(payload.Event.Type, payload.Subscriber)
+		if handler == nil {
+			// The subscriber was removed after the event was relayed
+			log.Warn("Dropping event for unknown subscriber",
+				"subscriber", payload.Subscriber,
+				"event_type", payload.Event.Type,
+				"event_id", payload.Event.ID,
+			)
+			return nil
+		}
+		return handler(ctx, &payload.Event)
+	})
+}
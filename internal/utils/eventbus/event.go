@@ -0,0 +1,141 @@
+// Package eventbus delivers domain events from the services that record them
+// to independent subscribers. Services write events to a transactional outbox
+// alongside the change they describe; a relay moves them onto the job queue as
+// one delivery job per subscriber, so each subscriber is retried on its own.
+package eventbus
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Event is a domain event. Its type names the outbox record and selects the subscribers.
+type Event interface {
+	EventType() string
+}
+
+// PostCreated is recorded when a post is created. Scheduled posts are created
+// with a PublishedAt in the future.
+type PostCreated struct {
+	PostID      primitive.ObjectID  `json:"post_id"`
+	UserID      primitive.ObjectID  `json:"user_id"`
+	GroupID     *primitive.ObjectID `json:"group_id,omitempty"`
+	Privacy     string              `json:"privacy"`
+	PublishedAt time.Time           `json:"published_at"`
+}
+
+// EventType implements Event
+func (PostCreated) EventType() string { return constants.EventPostCreated }
+
+// CommentAdded is recorded when a comment or reply is created
+type CommentAdded struct {
+	CommentID primitive.ObjectID  `json:"comment_id"`
+	PostID    primitive.ObjectID  `json:"post_id"`
+	UserID    primitive.ObjectID  `json:"user_id"`
+	ParentID  *primitive.ObjectID `json:"parent_id,omitempty"`
+}
+
+// EventType implements Event
+func (CommentAdded) EventType() string { return constants.EventCommentAdded }
+
+// UserFollowed is recorded when a follow is created, with status pending for
+// private accounts, and again with status accepted when a request is approved
+type UserFollowed struct {
+	FollowerID  primitive.ObjectID `json:"follower_id"`
+	FollowingID primitive.ObjectID `json:"following_id"`
+	Status      string             `json:"status"`
+}
+
+// EventType implements Event
+func (UserFollowed) EventType() string { return constants.EventUserFollowed }
+
+// UserUnfollowed is recorded when a follow is removed, whether by the
+// follower, by the followed user or by a block. Status is the status the
+// follow had, so pending is a withdrawn or removed request.
+type UserUnfollowed struct {
+	FollowerID  primitive.ObjectID `json:"follower_id"`
+	FollowingID primitive.ObjectID `json:"following_id"`
+	Status      string             `json:"status"`
+}
+
+// EventType implements Event
+func (UserUnfollowed) EventType() string { return constants.EventUserUnfollowed }
+
+// EventRSVPChanged is recorded when a user's RSVP to an event changes.
+// PreviousStatus is empty for a first RSVP.
+type EventRSVPChanged struct {
+	EventID              primitive.ObjectID `json:"event_id"`
+	UserID               primitive.ObjectID `json:"user_id"`
+	Status               string             `json:"status"`
+	PreviousStatus       string             `json:"previous_status,omitempty"`
+	Waitlisted           bool               `json:"waitlisted"`
+	PromotedFromWaitlist bool               `json:"promoted_from_waitlist"`
+}
+
+// EventType implements Event
+func (EventRSVPChanged) EventType() string { return constants.EventEventRSVPChanged }
+
+// SessionTokenReused is recorded when a refresh token that was already
+// exchanged is presented again and the session it belongs to is revoked.
+// Device and Location describe the session the token was issued to.
+type SessionTokenReused struct {
+	SessionID primitive.ObjectID `json:"session_id"`
+	UserID    primitive.ObjectID `json:"user_id"`
+	Device    string             `json:"device,omitempty"`
+	Location  string             `json:"location,omitempty"`
+}
+
+// EventType implements Event
+func (SessionTokenReused) EventType() string { return constants.EventSessionTokenReused }
+
+// SessionFlagged is recorded when a login does not match the account's
+// history. Risks lists why, such as new_device; Location is empty when the
+// address could not be located.
+type SessionFlagged struct {
+	SessionID  primitive.ObjectID `json:"session_id"`
+	UserID     primitive.ObjectID `json:"user_id"`
+	Device     string             `json:"device,omitempty"`
+	Location   string             `json:"location,omitempty"`
+	IPAddress  string             `json:"ip_address"`
+	Risks      []string           `json:"risks"`
+	LoggedInAt time.Time          `json:"logged_in_at"`
+}
+
+// EventType implements Event
+func (SessionFlagged) EventType() string { return constants.EventSessionFlagged }
+
+// AccountLockedOut is recorded when repeated failed logins lock an account
+type AccountLockedOut struct {
+	UserID      primitive.ObjectID `json:"user_id"`
+	Email       string             `json:"email"`
+	Failures    int                `json:"failures"`
+	IPAddress   string             `json:"ip_address"`
+	LockedUntil time.Time          `json:"locked_until"`
+}
+
+// EventType implements Event
+func (AccountLockedOut) EventType() string { return constants.EventAccountLockedOut }
+
+// AccountUnlocked is recorded when an admin lifts a login lockout
+type AccountUnlocked struct {
+	UserID  primitive.ObjectID `json:"user_id"`
+	AdminID primitive.ObjectID `json:"admin_id"`
+	Email   string             `json:"email"`
+}
+
+// EventType implements Event
+func (AccountUnlocked) EventType() string { return constants.EventAccountUnlocked }
+
+// Envelope carries an encoded event from the outbox to a subscriber
+type Envelope struct {
+	// ID is the outbox message ID. It is the same on every delivery of the
+	// event, so subscribers can use it to deduplicate.
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
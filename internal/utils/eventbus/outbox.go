@@ -0,0 +1,135 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+const (
+	// relayBatch caps how many outbox messages are read at once
+	relayBatch = 100
+
+	// deliveryUniqueFor deduplicates delivery jobs, so a message relayed again
+	// after failing to be marked published is not delivered twice
+	deliveryUniqueFor = 24 * time.Hour
+)
+
+// Publisher records domain events. Services publish with the context of the
+// transaction that makes the change, so events are stored only if it commits.
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// Outbox is the Publisher that writes events to the outbox collection
+type Outbox struct {
+	repo interfaces.OutboxRepository
+}
+
+var _ Publisher = (*Outbox)(nil)
+
+// NewOutbox creates a publisher that writes to repo
+func NewOutbox(repo interfaces.OutboxRepository) *Outbox {
+	return &Outbox{repo: repo}
+}
+
+// Publish encodes events and adds them to the outbox
+func (o *Outbox) Publish(ctx context.Context, events ...Event) error {
+	now := time.Now()
+	messages := make([]*models.OutboxMessage, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode %s event: %w", event.EventType(), err)
+		}
+		messages = append(messages, &models.OutboxMessage{
+			Type:       event.EventType(),
+			Payload:    string(payload),
+			OccurredAt: now,
+		})
+	}
+	return o.repo.Add(ctx, messages)
+}
+
+// NewEnvelope wraps a stored outbox message for delivery
+func NewEnvelope(message *models.OutboxMessage) Envelope {
+	return Envelope{
+		ID:         message.ID.Hex(),
+		Type:       message.Type,
+		Payload:    json.RawMessage(message.Payload),
+		OccurredAt: message.OccurredAt,
+	}
+}
+
+// Relay moves pending outbox messages onto the job queue as one delivery job
+// per subscriber. It must run on one instance at a time to keep events in order.
+type Relay struct {
+	repo    interfaces.OutboxRepository
+	queue   *queue.RedisQueue
+	bus     *Bus
+	log     *logger.Logger
+	backoff queue.Backoff
+}
+
+// NewRelay creates a relay from the outbox to the queue
+func NewRelay(repo interfaces.OutboxRepository, q *queue.RedisQueue, bus *Bus, log *logger.Logger) *Relay {
+	return &Relay{
+		repo:    repo,
+		queue:   q,
+		bus:     bus,
+		log:     log,
+		backoff: queue.ExponentialBackoff(time.Second, 5*time.Minute),
+	}
+}
+
+// Run relays pending messages in batches until none are left. A message that
+// cannot be relayed is retried later with backoff while the rest carry on.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		messages, err := r.repo.GetPending(ctx, time.Now(), relayBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err := r.relay(ctx, message); err != nil {
+				r.log.Warn("Failed to relay event", "event_id", message.ID.Hex(), "event_type", message.Type, "error", err)
+				retryAt := time.Now().Add(r.backoff(message.Attempts + 1))
+				if err := r.repo.MarkRetry(ctx, message.ID, err.Error(), retryAt); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := r.repo.MarkPublished(ctx, message.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < relayBatch || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// relay enqueues a delivery of message for each of its subscribers
+func (r *Relay) relay(ctx context.Context, message *models.OutboxMessage) error {
+	envelope := NewEnvelope(message)
+
+	for _, subscriber := range r.bus.subscribers(message.Type) {
+		_, err := r.queue.EnqueueJob(ctx, queue.JobDeliverEvent,
+			DeliveryPayload{Subscriber: subscriber, Event: envelope},
+			queue.WithUniqueKey("event:"+envelope.ID+":"+subscriber, deliveryUniqueFor),
+		)
+		if err != nil && !errors.Is(err, queue.ErrDuplicateJob) {
+			return err
+		}
+	}
+	return nil
+}
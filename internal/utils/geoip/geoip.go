@@ -0,0 +1,183 @@
+// Package geoip resolves IP addresses to locations from a local database
+// file, so lookups need no network access and tests run offline. The file is
+// CSV with one address range per line:
+//
+//	start_ip,end_ip,country_code,country,region,city,latitude,longitude,timezone
+//
+// Ranges are inclusive and may be IPv4 or IPv6. Lines starting with # are
+// comments, and a header line whose first field is "start_ip" is skipped.
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned for addresses no range covers, and for private,
+// loopback and malformed addresses
+var ErrNotFound = errors.New("geoip: address not found")
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// Location is where an address is registered. Latitude and Longitude are
+// only meaningful when HasCoordinates is set.
+type Location struct {
+	CountryCode    string // ISO 3166-1 alpha-2
+	Country        string
+	Region         string
+	City           string
+	Timezone       string
+	Latitude       float64
+	Longitude      float64
+	HasCoordinates bool
+}
+
+// DistanceKm returns the great-circle distance between two locations, or
+// false when either has no coordinates
+func (l *Location) DistanceKm(other *Location) (float64, bool) {
+	if l == nil || other == nil || !l.HasCoordinates || !other.HasCoordinates {
+		return 0, false
+	}
+
+	lat1, lat2 := radians(l.Latitude), radians(other.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(other.Longitude - l.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a))), true
+}
+
+type ipRange struct {
+	start, end net.IP // 16-byte form
+	location   *Location
+}
+
+// DB is a loaded database. It is read-only and safe for concurrent use.
+type DB struct {
+	ranges []ipRange // Sorted by start
+}
+
+// Open loads the database file at path
+func Open(path string) (*DB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: %w", err)
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse loads a database from r
+func Parse(r io.Reader) (*DB, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 9
+	reader.TrimLeadingSpace = true
+
+	db := &DB{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("geoip: %w", err)
+		}
+		if record[0] == "start_ip" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		entry, err := parseRange(record)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: line %d: %w", line, err)
+		}
+		db.ranges = append(db.ranges, entry)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+	for i := 1; i < len(db.ranges); i++ {
+		if bytes.Compare(db.ranges[i].start, db.ranges[i-1].end) <= 0 {
+			return nil, fmt.Errorf("geoip: range starting at %s overlaps the one before it", db.ranges[i].start)
+		}
+	}
+
+	return db, nil
+}
+
+// Len returns the number of ranges in the database
+func (db *DB) Len() int {
+	return len(db.ranges)
+}
+
+// Lookup returns the location of ipAddress
+func (db *DB) Lookup(ipAddress string) (*Location, error) {
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+		return nil, ErrNotFound
+	}
+	ip = ip.To16()
+
+	// The last range starting at or before ip is the only one that can hold it
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, db.ranges[i].end) > 0 {
+		return nil, ErrNotFound
+	}
+
+	location := *db.ranges[i].location
+	return &location, nil
+}
+
+func parseRange(record []string) (ipRange, error) {
+	start, end := net.ParseIP(record[0]), net.ParseIP(record[1])
+	if start == nil || end == nil {
+		return ipRange{}, errors.New("invalid address")
+	}
+	if (start.To4() == nil) != (end.To4() == nil) {
+		return ipRange{}, errors.New("range mixes IPv4 and IPv6")
+	}
+	start, end = start.To16(), end.To16()
+	if bytes.Compare(start, end) > 0 {
+		return ipRange{}, errors.New("range ends before it starts")
+	}
+
+	location := &Location{
+		CountryCode: strings.ToUpper(record[2]),
+		Country:     record[3],
+		Region:      record[4],
+		City:        record[5],
+		Timezone:    record[8],
+	}
+	if record[6] != "" || record[7] != "" {
+		latitude, err := strconv.ParseFloat(record[6], 64)
+		if err != nil || latitude < -90 || latitude > 90 {
+			return ipRange{}, errors.New("invalid latitude")
+		}
+		longitude, err := strconv.ParseFloat(record[7], 64)
+		if err != nil || longitude < -180 || longitude > 180 {
+			return ipRange{}, errors.New("invalid longitude")
+		}
+		location.Latitude, location.Longitude, location.HasCoordinates = latitude, longitude, true
+	}
+
+	return ipRange{start: start, end: end, location: location}, nil
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,201 @@
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JWKSMaxAge is how long clients may cache the published key set
+const JWKSMaxAge = time.Hour
+
+// JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519 (OKP)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK converts a key's public half to JWK form
+func NewJWK(key *Key) (JWK, error) {
+	jwk := JWK{Use: "sig", Algorithm: key.Algorithm, KeyID: key.ID}
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JWK{}, fmt.Errorf("keyring: unsupported public key type %T", key.Public)
+	}
+	return jwk, nil
+}
+
+// Key converts a JWK back into a verification key
+func (j JWK) Key() (*Key, error) {
+	var public crypto.PublicKey
+	switch j.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %s: bad modulus: %w", j.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %s: bad exponent: %w", j.KeyID, err)
+		}
+		public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("keyring: key %s: unsupported curve %q", j.KeyID, j.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("keyring: key %s: bad Ed25519 key", j.KeyID)
+		}
+		public = ed25519.PublicKey(x)
+	default:
+		return nil, fmt.Errorf("keyring: key %s: unsupported key type %q", j.KeyID, j.KeyType)
+	}
+
+	if _, err := signingMethod(j.Algorithm); err != nil {
+		return nil, err
+	}
+	return &Key{ID: j.KeyID, Algorithm: j.Algorithm, Public: public}, nil
+}
+
+// JWKS returns the published public keys
+func (k *Keyring) JWKS() JWKS {
+	keys := k.Keys()
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := NewJWK(key)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// Handler serves the published key set, for /.well-known/jwks.json
+func (k *Keyring) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(JWKSMaxAge.Seconds())))
+		_ = json.NewEncoder(w).Encode(k.JWKS())
+	}
+}
+
+// RemoteJWKS verifies tokens against a key set fetched over HTTP, for services
+// that verify tokens but do not hold the signing keys. The set is cached for
+// JWKSMaxAge and refetched early when a token names a key it does not know.
+type RemoteJWKS struct {
+	url    string
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*Key
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+var _ KeySource = (*RemoteJWKS)(nil)
+
+// NewRemoteJWKS creates a key source for the key set at url. A nil client uses
+// one with a short timeout.
+func NewRemoteJWKS(url string, client *http.Client) *RemoteJWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &RemoteJWKS{url: url, client: client, keys: map[string]*Key{}}
+}
+
+// VerificationKey returns the key with the given ID, fetching the set when the
+// cache is stale or the key is unknown
+func (r *RemoteJWKS) VerificationKey(ctx context.Context, kid string) (*Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[kid]
+	if ok && time.Since(r.fetchedAt) < JWKSMaxAge {
+		return key, nil
+	}
+
+	if time.Since(r.attemptedAt) >= missReloadGap {
+		r.attemptedAt = time.Now()
+		if err := r.fetch(ctx); err != nil {
+			// Keep verifying with the cached set while the issuer is unreachable
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+		key, ok = r.keys[kid]
+	}
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (r *RemoteJWKS) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch key set: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch key set: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode key set: %w", err)
+	}
+
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.Key()
+		if err != nil {
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	r.keys = keys
+	r.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,39 @@
+package keyring_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+func TestHandlerServesSigningKeys(t *testing.T) {
+	keys := newKeyring(t, memory.NewDatabase(), testSecret)
+	rotate(t, keys)
+	server := httptest.NewServer(keys.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("fetch key set: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var jwks keyring.JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		t.Fatalf("decode key set: %v", err)
+	}
+	if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != keys.Keys()[0].ID {
+		t.Fatalf("key set = %+v, want the signing key", jwks)
+	}
+
+	// A verifier without the private keys finds the key by its ID
+	remote := keyring.NewRemoteJWKS(server.URL, server.Client())
+	if _, err := remote.VerificationKey(context.Background(), jwks.Keys[0].KeyID); err != nil {
+		t.Fatalf("remote key set: %v", err)
+	}
+}
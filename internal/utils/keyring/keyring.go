@@ -0,0 +1,338 @@
+// Package keyring signs access tokens with asymmetric keys that rotate on a
+// schedule, and verifies them by the key ID in their header. The private keys
+// live in the database, encrypted with the JWT secret, so only the issuer can
+// sign; anything that verifies tokens needs only the public keys, which are
+// published as a JSON Web Key Set.
+//
+// A key is created PublishAhead before it starts signing so verifiers that
+// cache the key set learn it in time, signs for the rotation interval and
+// stays published for a grace period after it retires so tokens it signed can
+// still be verified.
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+)
+
+const (
+	// PublishAhead is how long before it starts signing a new key is published.
+	// It must exceed the JWKS cache lifetime plus the reload interval.
+	PublishAhead = 6 * time.Hour
+
+	// ReloadInterval is how often each instance reloads keys created elsewhere
+	ReloadInterval = time.Minute
+
+	// missReloadGap rate-limits reloads triggered by an unknown key ID, so
+	// tokens with made-up key IDs cannot hammer the database
+	missReloadGap = 10 * time.Second
+)
+
+var (
+	// ErrNoSigningKey is returned when no usable key is active, which happens
+	// before the first rotation or when the JWT secret has changed
+	ErrNoSigningKey = errors.New("keyring: no active signing key")
+
+	// ErrUnknownKey is returned when a token names a key that is not published
+	ErrUnknownKey = errors.New("keyring: unknown key ID")
+)
+
+// Key is a loaded signing key. Keys from a remote key set have no private half.
+type Key struct {
+	ID          string
+	Algorithm   string
+	Public      crypto.PublicKey
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	ExpiresAt   time.Time
+
+	private crypto.PrivateKey
+}
+
+// Options configures a Keyring
+type Options struct {
+	Algorithm        string // RS256 or EdDSA, used for new keys
+	Secret           string // Encrypts private keys at rest
+	RotationInterval time.Duration
+	GracePeriod      time.Duration
+}
+
+// Keyring holds the published keys and signs with the active one
+type Keyring struct {
+	repo interfaces.SigningKeyRepository
+	opts Options
+	aead cipher.AEAD
+	log  *logger.Logger
+
+	mu         sync.RWMutex
+	keys       []*Key // Ordered by activation
+	lastReload time.Time
+}
+
+var _ KeySource = (*Keyring)(nil)
+
+// New creates a keyring backed by repo. Call Rotate or Load before signing.
+func New(repo interfaces.SigningKeyRepository, opts Options, log *logger.Logger) (*Keyring, error) {
+	if _, err := signingMethod(opts.Algorithm); err != nil {
+		return nil, err
+	}
+	if opts.RotationInterval <= PublishAhead {
+		return nil, fmt.Errorf("keyring: rotation interval must exceed %s", PublishAhead)
+	}
+
+	aead, err := newAEAD(opts.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keyring{repo: repo, opts: opts, aead: aead, log: log}, nil
+}
+
+// Load reads the published keys from the database. Keys already loaded are
+// kept as they are, so private keys are only decrypted once.
+func (k *Keyring) Load(ctx context.Context) error {
+	now := time.Now()
+	stored, err := k.repo.GetUnexpired(ctx, now)
+	if err != nil {
+		return fmt.Errorf("load signing keys: %w", err)
+	}
+
+	k.mu.RLock()
+	loaded := make(map[string]*Key, len(k.keys))
+	for _, key := range k.keys {
+		loaded[key.ID] = key
+	}
+	k.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(stored))
+	for _, record := range stored {
+		if key, ok := loaded[record.ID]; ok {
+			keys = append(keys, key)
+			continue
+		}
+
+		key, err := k.parse(record)
+		if err != nil {
+			k.log.Error("Skipping unreadable signing key", "kid", record.ID, "error", err)
+			continue
+		}
+		if key.private == nil {
+			k.log.Warn("Signing key cannot be decrypted with the current JWT secret; it will only verify", "kid", record.ID)
+		}
+		keys = append(keys, key)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.lastReload = now
+	k.mu.Unlock()
+	return nil
+}
+
+// Run reloads the keys every ReloadInterval until ctx is cancelled
+func (k *Keyring) Run(ctx context.Context) {
+	ticker := time.NewTicker(ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Load(ctx); err != nil && ctx.Err() == nil {
+				k.log.Warn("Failed to reload signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// Rotate makes sure a key is signing now and that its successor is published
+// PublishAhead before it retires. It is safe to call often; it only creates a
+// key when one is due. Run it on one instance at a time.
+func (k *Keyring) Rotate(ctx context.Context) error {
+	if err := k.Load(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	current := k.active(now)
+
+	var activatesAt time.Time
+	switch {
+	case current == nil:
+		activatesAt = now
+	case current.RetiresAt.Sub(now) <= PublishAhead && !k.hasSuccessor(current):
+		activatesAt = current.RetiresAt
+	default:
+		return nil
+	}
+
+	record, err := k.generate(activatesAt)
+	if err != nil {
+		return err
+	}
+	if err := k.repo.Create(ctx, record); err != nil {
+		return fmt.Errorf("store signing key: %w", err)
+	}
+	k.log.Info("Signing key created", "kid", record.ID, "algorithm", record.Algorithm, "activates_at", record.ActivatesAt)
+
+	return k.Load(ctx)
+}
+
+// Sign signs claims with the active key and names it in the kid header
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key := k.active(time.Now())
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// VerificationKey returns the published key with the given ID. An unknown ID
+// triggers a reload, since another instance may have just created the key.
+func (k *Keyring) VerificationKey(ctx context.Context, kid string) (*Key, error) {
+	if key := k.published(kid); key != nil {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	stale := time.Since(k.lastReload) >= missReloadGap
+	k.mu.RUnlock()
+	if stale {
+		if err := k.Load(ctx); err != nil {
+			return nil, err
+		}
+		if key := k.published(kid); key != nil {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// Keys returns the keys that are published now, including the next key
+func (k *Keyring) Keys() []*Key {
+	now := time.Now()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		if now.Before(key.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// active returns the most recently activated key that can sign at now
+func (k *Keyring) active(now time.Time) *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var active *Key
+	for _, key := range k.keys {
+		if key.private != nil && !now.Before(key.ActivatesAt) && now.Before(key.RetiresAt) {
+			active = key
+		}
+	}
+	return active
+}
+
+// hasSuccessor reports whether a usable key activates after current
+func (k *Keyring) hasSuccessor(current *Key) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.private != nil && key.ActivatesAt.After(current.ActivatesAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Keyring) published(kid string) *Key {
+	now := time.Now()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == kid && now.Before(key.ExpiresAt) {
+			return key
+		}
+	}
+	return nil
+}
+
+// generate creates a key record that signs from activatesAt for one rotation interval
+func (k *Keyring) generate(activatesAt time.Time) (*models.SigningKey, error) {
+	kid, err := newKeyID()
+	if err != nil {
+		return nil, err
+	}
+
+	private, public, err := generateKeyPair(k.opts.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(k.aead, private)
+	if err != nil {
+		return nil, err
+	}
+
+	retiresAt := activatesAt.Add(k.opts.RotationInterval)
+	return &models.SigningKey{
+		ID:          kid,
+		Algorithm:   k.opts.Algorithm,
+		PrivateKey:  sealed,
+		PublicKey:   public,
+		CreatedAt:   time.Now(),
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(k.opts.GracePeriod),
+	}, nil
+}
+
+// parse loads a stored key. A private key that cannot be decrypted leaves the
+// key usable for verification only.
+func (k *Keyring) parse(record *models.SigningKey) (*Key, error) {
+	public, err := parsePublicKey(record.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		ID:          record.ID,
+		Algorithm:   record.Algorithm,
+		Public:      public,
+		ActivatesAt: record.ActivatesAt,
+		RetiresAt:   record.RetiresAt,
+		ExpiresAt:   record.ExpiresAt,
+	}
+	if private, err := open(k.aead, record.PrivateKey); err == nil {
+		key.private = private
+	}
+	return key, nil
+}
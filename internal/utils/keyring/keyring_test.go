@@ -0,0 +1,217 @@
+package keyring_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/constants"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+const (
+	testSecret   = "test-secret-that-is-long-enough-for-tests"
+	testIssuer   = "vyrall"
+	testAudience = "vyrall-api"
+)
+
+// newKeyring creates a keyring with the default JWT settings on an in-memory
+// signing key repository, as one instance of the API would
+func newKeyring(t *testing.T, db *memory.Database, secret string) *keyring.Keyring {
+	t.Helper()
+
+	cfg := config.Default().JWT
+	keys, err := keyring.New(memory.NewSigningKeyRepository(db), keyring.Options{
+		Algorithm:        cfg.Algorithm,
+		Secret:           secret,
+		RotationInterval: cfg.KeyRotationInterval,
+		GracePeriod:      cfg.KeyGracePeriod,
+	}, logger.NewNop())
+	if err != nil {
+		t.Fatalf("create keyring: %v", err)
+	}
+	return keys
+}
+
+func rotate(t *testing.T, keys *keyring.Keyring) {
+	t.Helper()
+	if err := keys.Rotate(context.Background()); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+}
+
+// setKeyTimes moves a stored key's schedule, standing in for time passing.
+// Keyrings that already loaded the key keep the old schedule.
+func setKeyTimes(t *testing.T, db *memory.Database, kid string, times bson.M) {
+	t.Helper()
+	if _, err := db.Collection(constants.CollectionSigningKeys).UpdateOne(bson.M{"_id": kid}, bson.M{"$set": times}); err != nil {
+		t.Fatalf("update key %s: %v", kid, err)
+	}
+}
+
+func sign(t *testing.T, keys *keyring.Keyring) string {
+	t.Helper()
+	token, err := keys.Sign(jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Audience:  jwt.ClaimStrings{testAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return token
+}
+
+func keyID(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func verify(keys keyring.KeySource, token string) error {
+	return keyring.NewVerifier(keys, testIssuer, testAudience).Verify(context.Background(), token, &jwt.RegisteredClaims{})
+}
+
+func TestNewRejectsRotationShorterThanPublishAhead(t *testing.T) {
+	_, err := keyring.New(memory.NewSigningKeyRepository(memory.NewDatabase()), keyring.Options{
+		Algorithm:        keyring.AlgorithmEdDSA,
+		Secret:           testSecret,
+		RotationInterval: keyring.PublishAhead,
+	}, logger.NewNop())
+	if err == nil {
+		t.Fatal("keyring created with a rotation interval no longer than PublishAhead")
+	}
+}
+
+func TestRotateCreatesKeysOnlyWhenDue(t *testing.T) {
+	db := memory.NewDatabase()
+	keys := newKeyring(t, db, testSecret)
+
+	if _, err := keys.Sign(jwt.RegisteredClaims{}); !errors.Is(err, keyring.ErrNoSigningKey) {
+		t.Fatalf("sign before the first rotation = %v, want %v", err, keyring.ErrNoSigningKey)
+	}
+
+	rotate(t, keys)
+	rotate(t, keys)
+	if got := len(keys.Keys()); got != 1 {
+		t.Fatalf("%d keys after rotating twice, want 1", got)
+	}
+	current := keys.Keys()[0]
+
+	// Within PublishAhead of retiring, the successor is published but the
+	// current key keeps signing until it retires
+	retiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	setKeyTimes(t, db, current.ID, bson.M{"retires_at": retiresAt, "expires_at": retiresAt.Add(48 * time.Hour)})
+	other := newKeyring(t, db, testSecret)
+	rotate(t, other)
+	rotate(t, other)
+
+	published := other.Keys()
+	if len(published) != 2 {
+		t.Fatalf("%d keys published, want the current key and its successor", len(published))
+	}
+	if !published[1].ActivatesAt.Equal(retiresAt) {
+		t.Fatalf("successor activates at %s, want %s when the current key retires", published[1].ActivatesAt, retiresAt)
+	}
+	if kid := keyID(t, sign(t, other)); kid != current.ID {
+		t.Fatalf("signed with %s, want the current key %s", kid, current.ID)
+	}
+}
+
+func TestVerifyAcceptsRetiredKeysDuringGracePeriod(t *testing.T) {
+	db := memory.NewDatabase()
+	keys := newKeyring(t, db, testSecret)
+	rotate(t, keys)
+	old := keys.Keys()[0]
+	token := sign(t, keys)
+
+	// The key retires and a new one takes over
+	setKeyTimes(t, db, old.ID, bson.M{"retires_at": time.Now().Add(-time.Minute), "expires_at": time.Now().Add(time.Hour)})
+	next := newKeyring(t, db, testSecret)
+	rotate(t, next)
+
+	if kid := keyID(t, sign(t, next)); kid == old.ID {
+		t.Fatal("retired key still signs")
+	}
+	if err := verify(next, token); err != nil {
+		t.Fatalf("token signed by a retired key in its grace period: %v", err)
+	}
+
+	// Once the grace period ends the key is no longer published
+	setKeyTimes(t, db, old.ID, bson.M{"expires_at": time.Now().Add(-time.Minute)})
+	if err := verify(newKeyring(t, db, testSecret), token); err == nil {
+		t.Fatal("token signed by an expired key was accepted")
+	}
+}
+
+func TestKeyringWithAnotherSecretOnlyVerifies(t *testing.T) {
+	db := memory.NewDatabase()
+	keys := newKeyring(t, db, testSecret)
+	rotate(t, keys)
+	token := sign(t, keys)
+
+	other := newKeyring(t, db, "another-secret-that-is-long-enough")
+	if err := other.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := other.Sign(jwt.RegisteredClaims{}); !errors.Is(err, keyring.ErrNoSigningKey) {
+		t.Fatalf("sign with another secret = %v, want %v", err, keyring.ErrNoSigningKey)
+	}
+	if err := verify(other, token); err != nil {
+		t.Fatalf("verify with another secret: %v", err)
+	}
+}
+
+func TestVerifyRejectsForeignTokens(t *testing.T) {
+	db := memory.NewDatabase()
+	keys := newKeyring(t, db, testSecret)
+	rotate(t, keys)
+
+	stranger := newKeyring(t, memory.NewDatabase(), testSecret)
+	rotate(t, stranger)
+
+	claims := func(issuer, audience string) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}
+	}
+	signWith := func(k *keyring.Keyring, c jwt.RegisteredClaims) string {
+		token, err := k.Sign(c)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(testIssuer, testAudience)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("sign with none: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		token string
+		want  error
+	}{
+		"another keyring":  {token: signWith(stranger, claims(testIssuer, testAudience)), want: keyring.ErrUnknownKey},
+		"another issuer":   {token: signWith(keys, claims("elsewhere", testAudience)), want: keyring.ErrInvalidIssuer},
+		"another audience": {token: signWith(keys, claims(testIssuer, "elsewhere")), want: keyring.ErrInvalidAudience},
+		"unsigned":         {token: unsigned},
+	} {
+		err := verify(keys, tc.token)
+		if err == nil || (tc.want != nil && !errors.Is(err, tc.want)) {
+			t.Errorf("%s: verify = %v, want %v", name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	// ErrInvalidIssuer is returned for a token from another issuer
+	ErrInvalidIssuer = errors.New("keyring: invalid issuer")
+
+	// ErrInvalidAudience is returned for a token meant for another audience
+	ErrInvalidAudience = errors.New("keyring: invalid audience")
+)
+
+// KeySource looks up verification keys by key ID. A Keyring serves its own
+// keys; a RemoteJWKS serves the keys another service publishes.
+type KeySource interface {
+	VerificationKey(ctx context.Context, kid string) (*Key, error)
+}
+
+// Claims are token claims whose issuer and audience can be checked.
+// Embedding jwt.RegisteredClaims satisfies it.
+type Claims interface {
+	jwt.Claims
+	VerifyIssuer(cmp string, req bool) bool
+	VerifyAudience(cmp string, req bool) bool
+}
+
+// Verifier checks token signatures against a key source and enforces the
+// expected issuer and audience
+type Verifier struct {
+	keys     KeySource
+	issuer   string
+	audience string
+}
+
+// NewVerifier creates a verifier that accepts tokens from issuer meant for audience
+func NewVerifier(keys KeySource, issuer, audience string) *Verifier {
+	return &Verifier{keys: keys, issuer: issuer, audience: audience}
+}
+
+// Verify parses token into claims. The key is chosen by the kid header and the
+// token's alg must match the key's, so a token cannot pick a weaker algorithm.
+func (v *Verifier) Verify(ctx context.Context, token string, claims Claims) error {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
+
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("keyring: token has no kid header")
+		}
+		key, err := v.keys.VerificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("keyring: token alg %s does not match key %s", t.Method.Alg(), key.ID)
+		}
+		return key.Public, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return ErrInvalidIssuer
+	}
+	if !claims.VerifyAudience(v.audience, true) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a structured logger. Context is passed as alternating keys and
+// values, and zap.Field values may be mixed in freely.
+type Logger struct {
+	sugar *zap.SugaredLogger
+}
+
+// New creates a logger for the given level (debug, info, warn, error) and format (json, console)
+func New(level, format string) (*Logger, error) {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	cfg := zap.NewProductionConfig()
+	if format == "console" {
+		cfg = zap.NewDevelopmentConfig()
+	}
+	cfg.Level = zap.NewAtomicLevelAt(zapLevel)
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	base, err := cfg.Build(zap.AddCallerSkip(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{sugar: base.Sugar()}, nil
+}
+
+// NewNop creates a logger that discards everything
+func NewNop() *Logger {
+	return &Logger{sugar: zap.NewNop().Sugar()}
+}
+
+// With returns a child logger that adds the given context to every entry
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	return &Logger{sugar: l.sugar.With(keysAndValues...)}
+}
+
+// Debug logs a debug message
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	l.sugar.Debugw(msg, keysAndValues...)
+}
+
+// Info logs an informational message
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.sugar.Infow(msg, keysAndValues...)
+}
+
+// Warn logs a warning
+func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	l.sugar.Warnw(msg, keysAndValues...)
+}
+
+// Error logs an error
+func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
+	l.sugar.Errorw(msg, keysAndValues...)
+}
+
+// Fatal logs a message and exits the process
+func (l *Logger) Fatal(msg string, keysAndValues ...interface{}) {
+	l.sugar.Fatalw(msg, keysAndValues...)
+}
+
+// Sync flushes any buffered log entries
+func (l *Logger) Sync() error {
+	return l.sugar.Sync()
+}
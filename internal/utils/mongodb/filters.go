@@ -0,0 +1,31 @@
+package mongodb
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NotDeleted matches documents that have not been soft deleted.
+// A null query value matches both missing and null fields.
+func NotDeleted() bson.M {
+	return bson.M{"deleted_at": nil}
+}
+
+// ToFilter converts a generic filter map into a BSON filter
+func ToFilter(filter map[string]interface{}) bson.M {
+	result := bson.M{}
+	for key, value := range filter {
+		result[key] = value
+	}
+	return result
+}
+
+// Merge combines several filters into one. Later filters win on key conflicts.
+func Merge(filters ...bson.M) bson.M {
+	result := bson.M{}
+	for _, filter := range filters {
+		for key, value := range filter {
+			result[key] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultLimit is used when a caller passes a non-positive limit
+	DefaultLimit = 20
+
+	// MaxLimit caps the number of documents returned by a single page
+	MaxLimit = 100
+)
+
+// NormalizePagination clamps limit and offset to sane values
+func NormalizePagination(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	} else if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// FindPage builds find options for a limit/offset page with the given sort
+func FindPage(limit, offset int, sort bson.D) *options.FindOptions {
+	limit, offset = NormalizePagination(limit, offset)
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	if len(sort) > 0 {
+		opts.SetSort(sort)
+	}
+
+	return opts
+}
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NewestFirst sorts documents by creation time, newest first
+var NewestFirst = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// ToSort converts a field/direction map into an ordered sort document.
+// Keys are ordered alphabetically so the resulting sort is deterministic,
+// and _id is appended as a tie-breaker to keep pagination stable.
+func ToSort(fields map[string]int, fallback bson.D) bson.D {
+	if len(fields) == 0 {
+		return fallback
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(bson.D, 0, len(keys)+1)
+	hasID := false
+	for _, key := range keys {
+		direction := 1
+		if fields[key] < 0 {
+			direction = -1
+		}
+		if key == "_id" {
+			hasID = true
+		}
+		result = append(result, bson.E{Key: key, Value: direction})
+	}
+
+	if !hasID {
+		result = append(result, bson.E{Key: "_id", Value: -1})
+	}
+
+	return result
+}
@@ -0,0 +1,156 @@
+// Package pwned screens passwords against a breached-password corpus stored
+// on local disk in the format of the Pwned Passwords range files: one file
+// per 5-character SHA-1 prefix, named <PREFIX>.txt, with one line per hash
+//
+//	SUFFIX:COUNT
+//
+// where SUFFIX is the remaining 35 hex characters of the hash and COUNT the
+// number of times it was seen. Lookups only ever read the range of a hash's
+// prefix (k-anonymity), so a Source may equally be served remotely without
+// revealing the password or its full hash.
+package pwned
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PrefixLength is the number of hex characters of the hash that select a range
+const PrefixLength = 5
+
+// Source returns the range file for a hash prefix. A prefix with no range
+// returns an empty reader rather than an error.
+type Source interface {
+	Range(ctx context.Context, prefix string) (io.ReadCloser, error)
+}
+
+// Dir is a directory of range files, as written by the Pwned Passwords
+// downloader
+type Dir string
+
+// OpenDir returns the corpus in the directory at path. Ranges with no file
+// are empty, so it checks up front that the directory exists.
+func OpenDir(path string) (Dir, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("pwned: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("pwned: %s is not a directory", path)
+	}
+	return Dir(path), nil
+}
+
+// Range opens the range file for prefix
+func (d Dir) Range(ctx context.Context, prefix string) (io.ReadCloser, error) {
+	if !validHex(prefix, PrefixLength) {
+		return nil, fmt.Errorf("pwned: invalid prefix %q", prefix)
+	}
+
+	file, err := os.Open(filepath.Join(string(d), strings.ToUpper(prefix)+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pwned: %w", err)
+	}
+	return file, nil
+}
+
+// Checker looks passwords up in a corpus. Passwords seen fewer than
+// MinCount times are not reported as breached.
+type Checker struct {
+	source   Source
+	minCount int
+}
+
+// New creates a checker that reads ranges from source. minCount below 1 is
+// treated as 1.
+func New(source Source, minCount int) *Checker {
+	if minCount < 1 {
+		minCount = 1
+	}
+	return &Checker{source: source, minCount: minCount}
+}
+
+// Breached reports whether password appears in the corpus at least the
+// checker's minimum number of times
+func (c *Checker) Breached(ctx context.Context, password string) (bool, error) {
+	count, err := c.Count(ctx, password)
+	if err != nil {
+		return false, err
+	}
+	return count >= c.minCount, nil
+}
+
+// Count returns how many times password appears in the corpus
+func (c *Checker) Count(ctx context.Context, password string) (int, error) {
+	prefix, suffix := Split(password)
+
+	r, err := c.source.Range(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	return Find(r, suffix)
+}
+
+// Split returns the range prefix and suffix of the SHA-1 hash of password,
+// in upper-case hex
+func Split(password string) (prefix, suffix string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:PrefixLength], hash[PrefixLength:]
+}
+
+// Find returns the count of suffix in a range file, or 0 when it is not
+// listed. Padding entries, listed with a count of 0, are never matched.
+func Find(r io.Reader, suffix string) (int, error) {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" {
+			continue
+		}
+
+		hash, count, ok := strings.Cut(entry, ":")
+		if !ok || !validHex(hash, sha1.Size*2-PrefixLength) {
+			return 0, fmt.Errorf("pwned: line %d: malformed entry", line)
+		}
+		if !strings.EqualFold(hash, suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("pwned: line %d: invalid count", line)
+		}
+		return n, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("pwned: %w", err)
+	}
+	return 0, nil
+}
+
+func validHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
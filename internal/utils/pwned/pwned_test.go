@@ -0,0 +1,97 @@
+package pwned_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Caqil/vyrall/internal/utils/pwned"
+	"github.com/Caqil/vyrall/tests/helpers/fakes"
+)
+
+func TestCount(t *testing.T) {
+	checker := pwned.New(fakes.PwnedPasswords(), 1)
+
+	for password, want := range map[string]int{
+		"password":                     10434004,
+		"Password1":                    2,
+		"Summer2024!":                  1,
+		"correct horse battery staple": 0,
+		"Unlisted-Orchard-77":          0,
+	} {
+		got, err := checker.Count(context.Background(), password)
+		if err != nil {
+			t.Fatalf("count %q: %v", password, err)
+		}
+		if got != want {
+			t.Errorf("count %q = %d, want %d", password, got, want)
+		}
+	}
+}
+
+func TestBreachedHonoursMinCount(t *testing.T) {
+	checker := pwned.New(fakes.PwnedPasswords(), 2)
+
+	for password, want := range map[string]bool{
+		"password":    true,
+		"Password1":   true,
+		"Summer2024!": false,
+	} {
+		got, err := checker.Breached(context.Background(), password)
+		if err != nil {
+			t.Fatalf("breached %q: %v", password, err)
+		}
+		if got != want {
+			t.Errorf("breached %q = %v, want %v", password, got, want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	const suffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+	tests := map[string]struct {
+		rangeFile string
+		count     int
+		wantErr   bool
+	}{
+		"listed":          {rangeFile: "00000000000000000000000000000000000:4\r\n" + suffix + ":7\r\n", count: 7},
+		"lower case":      {rangeFile: strings.ToLower(suffix) + ":7\n", count: 7},
+		"not listed":      {rangeFile: "00000000000000000000000000000000000:4\n"},
+		"padding":         {rangeFile: suffix + ":0\n"},
+		"empty":           {},
+		"malformed hash":  {rangeFile: "not-a-hash:4\n", wantErr: true},
+		"malformed count": {rangeFile: suffix + ":many\n", wantErr: true},
+		"missing count":   {rangeFile: suffix + "\n", wantErr: true},
+		"negative count":  {rangeFile: suffix + ":-1\n", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			count, err := pwned.Find(strings.NewReader(tt.rangeFile), suffix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("find = %v, want error %v", err, tt.wantErr)
+			}
+			if count != tt.count {
+				t.Fatalf("count = %d, want %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestSplitUsesTheSHA1Range(t *testing.T) {
+	prefix, suffix := pwned.Split("password")
+	if prefix != "5BAA6" || suffix != "1E4C9B93F3F0682250B6CF8331B7EE68FD8" {
+		t.Fatalf("split = %s %s, want the SHA-1 of password in upper case", prefix, suffix)
+	}
+}
+
+func TestDirRejectsInvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "5BAA", "5BAA61", "../..", "ZZZZZ"} {
+		if _, err := fakes.PwnedPasswords().Range(context.Background(), prefix); err == nil {
+			t.Errorf("range %q was opened", prefix)
+		}
+	}
+	if _, err := pwned.OpenDir("does-not-exist"); err == nil {
+		t.Error("missing corpus directory was opened")
+	}
+}
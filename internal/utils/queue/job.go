@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JobType identifies the kind of work a job carries and selects its handler
+type JobType string
+
+// Job types processed by the background workers
+const (
+	JobEventReminders        JobType = "event.reminders"
+	JobPublishScheduledPosts JobType = "post.publish_scheduled"
+	JobPublishScheduledPost  JobType = "post.publish"
+	JobMediaProcessing       JobType = "media.process"
+	JobRunScheduledReports   JobType = "analytics.run_scheduled_reports"
+	JobRunScheduledReport    JobType = "analytics.run_scheduled_report"
+	JobDeleteExpiredMessages JobType = "message.delete_expired"
+	JobDeliverEvent          JobType = "events.deliver"
+	JobPurgeDueAccounts      JobType = "user.purge_due_accounts"
+	JobPurgeAccount          JobType = "user.purge_account"
+)
+
+// DefaultMaxAttempts is how many times a job runs before it is dead-lettered
+const DefaultMaxAttempts = 5
+
+var (
+	// ErrDuplicateJob is returned by Enqueue when a job with the same ID or unique key is already queued
+	ErrDuplicateJob = errors.New("job already queued")
+
+	// ErrJobNotFound is returned when a job ID does not exist in the queue
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job is a unit of background work. The payload is stored as JSON so it survives
+// restarts; handlers decode it back into a typed struct.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        JobType         `json:"type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	CreatedAt   time.Time       `json:"created_at"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	FailedAt    *time.Time      `json:"failed_at,omitempty"`
+
+	// uniqueKey deduplicates enqueues for uniqueFor after the first one
+	uniqueKey string
+	uniqueFor time.Duration
+
+	// token identifies the current reservation so stale workers cannot ack
+	token string
+}
+
+// Option customises a job created with NewJob
+type Option func(*Job)
+
+// WithID sets the job ID. Enqueueing a second job with the same ID while the
+// first is still queued returns ErrDuplicateJob.
+func WithID(id string) Option {
+	return func(j *Job) {
+		j.ID = id
+	}
+}
+
+// WithDelay runs the job no earlier than d from now
+func WithDelay(d time.Duration) Option {
+	return func(j *Job) {
+		j.RunAt = time.Now().Add(d)
+	}
+}
+
+// WithRunAt runs the job no earlier than t
+func WithRunAt(t time.Time) Option {
+	return func(j *Job) {
+		j.RunAt = t
+	}
+}
+
+// WithMaxAttempts sets how many times the job runs before it is dead-lettered
+func WithMaxAttempts(n int) Option {
+	return func(j *Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
+
+// WithUniqueKey drops any other enqueue with the same key for ttl, even after
+// this job has completed. It is used to make periodic and fan-out enqueues
+// idempotent across server instances.
+func WithUniqueKey(key string, ttl time.Duration) Option {
+	return func(j *Job) {
+		j.uniqueKey = key
+		j.uniqueFor = ttl
+	}
+}
+
+// NewJob creates a job of the given type with payload encoded as JSON
+func NewJob(jobType JobType, payload interface{}, opts ...Option) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:          newID(),
+		Type:        jobType,
+		MaxAttempts: DefaultMaxAttempts,
+		CreatedAt:   now,
+		RunAt:       now,
+	}
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s payload: %w", jobType, err)
+		}
+		job.Payload = data
+	}
+
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	return job, nil
+}
+
+// Decode unmarshals the job payload into v
+func (j *Job) Decode(v interface{}) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("decode %s payload: %w", j.Type, err))
+	}
+	return nil
+}
+
+// Handler processes a job. Returning an error schedules a retry with backoff
+// until the job runs out of attempts; wrap the error with Permanent to
+// dead-letter the job immediately.
+type Handler func(ctx context.Context, job *Job) error
+
+// Typed adapts a function taking a decoded payload into a Handler
+func Typed[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered without further retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to the clock
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,209 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+)
+
+// fanOutUniqueFor is how long a per-item job enqueued by a sweep is deduplicated,
+// so overlapping sweeps do not queue the same post or report twice
+const fanOutUniqueFor = 10 * time.Minute
+
+// PostPayload identifies the post a job acts on
+type PostPayload struct {
+	PostID primitive.ObjectID `json:"post_id"`
+}
+
+// MediaPayload identifies the media item to process and the steps to run,
+// such as "thumbnail", "transcode" or "compress"
+type MediaPayload struct {
+	MediaID    primitive.ObjectID `json:"media_id"`
+	Operations []string           `json:"operations,omitempty"`
+}
+
+// ReportPayload identifies the scheduled report to run
+type ReportPayload struct {
+	ReportID primitive.ObjectID `json:"report_id"`
+}
+
+// AccountPayload identifies the account a job acts on
+type AccountPayload struct {
+	UserID primitive.ObjectID `json:"user_id"`
+}
+
+// ReminderSender sends event reminders that have come due
+type ReminderSender interface {
+	ProcessDueReminders(ctx context.Context) (int, error)
+}
+
+// ScheduledPostStore finds and publishes scheduled posts
+type ScheduledPostStore interface {
+	GetScheduledPosts(ctx context.Context, userID primitive.ObjectID, startTime, endTime time.Time) ([]*models.Post, error)
+	PublishScheduledPost(ctx context.Context, id primitive.ObjectID) error
+}
+
+// MediaProcessor runs post-upload processing on a media item
+type MediaProcessor interface {
+	ProcessMedia(ctx context.Context, mediaID primitive.ObjectID, operations []string) error
+}
+
+// ReportRunner finds and generates scheduled analytics reports
+type ReportRunner interface {
+	DueScheduledReports(ctx context.Context, now time.Time) ([]primitive.ObjectID, error)
+	RunScheduledReport(ctx context.Context, reportID primitive.ObjectID) error
+}
+
+// ExpiredMessageSweeper deletes disappearing messages whose expiry has passed
+type ExpiredMessageSweeper interface {
+	DeleteExpiredMessages(ctx context.Context) (int, error)
+}
+
+// AccountPurger finds and erases accounts whose deletion grace period has ended
+type AccountPurger interface {
+	DueDeletions(ctx context.Context, now time.Time) ([]primitive.ObjectID, error)
+	PurgeAccount(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error)
+}
+
+// EventRemindersProcessor sends every event reminder that is due
+func EventRemindersProcessor(reminders ReminderSender, log *logger.Logger) Handler {
+	return func(ctx context.Context, job *Job) error {
+		sent, err := reminders.ProcessDueReminders(ctx)
+		if sent > 0 {
+			log.Info("Sent event reminders", "count", sent)
+		}
+		return err
+	}
+}
+
+// ScheduledPostsSweepProcessor enqueues a publish job for every scheduled post
+// that is due, so each post is published and retried independently
+func ScheduledPostsSweepProcessor(q *RedisQueue, posts ScheduledPostStore) Handler {
+	return func(ctx context.Context, job *Job) error {
+		due, err := posts.GetScheduledPosts(ctx, primitive.NilObjectID, time.Time{}, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, post := range due {
+			_, err := q.EnqueueJob(ctx, JobPublishScheduledPost, PostPayload{PostID: post.ID},
+				WithUniqueKey("publish:"+post.ID.Hex(), fanOutUniqueFor),
+			)
+			if err != nil && !errors.Is(err, ErrDuplicateJob) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// PublishScheduledPostProcessor publishes one scheduled post
+func PublishScheduledPostProcessor(posts ScheduledPostStore) Handler {
+	return Typed(func(ctx context.Context, payload PostPayload) error {
+		err := posts.PublishScheduledPost(ctx, payload.PostID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Deleted or already published
+			return nil
+		}
+		return err
+	})
+}
+
+// MediaProcessingProcessor runs the requested processing steps on an upload
+func MediaProcessingProcessor(media MediaProcessor) Handler {
+	return Typed(func(ctx context.Context, payload MediaPayload) error {
+		err := media.ProcessMedia(ctx, payload.MediaID, payload.Operations)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Permanent(err)
+		}
+		return err
+	})
+}
+
+// ScheduledReportsSweepProcessor enqueues a job for every scheduled report that is due
+func ScheduledReportsSweepProcessor(q *RedisQueue, reports ReportRunner) Handler {
+	return func(ctx context.Context, job *Job) error {
+		due, err := reports.DueScheduledReports(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, reportID := range due {
+			_, err := q.EnqueueJob(ctx, JobRunScheduledReport, ReportPayload{ReportID: reportID},
+				WithUniqueKey("report:"+reportID.Hex(), fanOutUniqueFor),
+			)
+			if err != nil && !errors.Is(err, ErrDuplicateJob) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// RunScheduledReportProcessor generates one scheduled report
+func RunScheduledReportProcessor(reports ReportRunner) Handler {
+	return Typed(func(ctx context.Context, payload ReportPayload) error {
+		err := reports.RunScheduledReport(ctx, payload.ReportID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The schedule was deleted after the job was queued
+			return nil
+		}
+		return err
+	})
+}
+
+// ExpiredMessagesProcessor deletes expired disappearing messages
+func ExpiredMessagesProcessor(messages ExpiredMessageSweeper, log *logger.Logger) Handler {
+	return func(ctx context.Context, job *Job) error {
+		deleted, err := messages.DeleteExpiredMessages(ctx)
+		if deleted > 0 {
+			log.Debug("Deleted expired messages", "count", deleted)
+		}
+		return err
+	}
+}
+
+// DueAccountsSweepProcessor enqueues a purge job for every account whose
+// deletion grace period has ended
+func DueAccountsSweepProcessor(q *RedisQueue, accounts AccountPurger) Handler {
+	return func(ctx context.Context, job *Job) error {
+		due, err := accounts.DueDeletions(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, userID := range due {
+			_, err := q.EnqueueJob(ctx, JobPurgeAccount, AccountPayload{UserID: userID},
+				WithUniqueKey("purge:"+userID.Hex(), fanOutUniqueFor),
+			)
+			if err != nil && !errors.Is(err, ErrDuplicateJob) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// PurgeAccountProcessor erases one account and logs the report of what went
+func PurgeAccountProcessor(accounts AccountPurger, log *logger.Logger) Handler {
+	return Typed(func(ctx context.Context, payload AccountPayload) error {
+		report, err := accounts.PurgeAccount(ctx, payload.UserID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Already purged
+			return nil
+		}
+		if err != nil || report == nil {
+			// A nil report means the user logged in and cancelled the deletion
+			return err
+		}
+		log.Info("Purged deleted account",
+			"user_id", payload.UserID.Hex(),
+			"collections", report.Collections,
+			"media_files", report.MediaFiles,
+		)
+		return nil
+	})
+}
@@ -0,0 +1,712 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix namespaces every key the queue writes. It is a hash tag, so
+// on a Redis Cluster every key of the queue lives in one slot and the scripts
+// can touch several of them atomically.
+const DefaultPrefix = "{queue}:"
+
+// allTypes is the member of the paused set that pauses every job type
+const allTypes = "*"
+
+// Per-type counters kept in the stats hash
+const (
+	statEnqueued  = "enqueued"
+	statProcessed = "processed"
+	statFailed    = "failed"
+	statRetried   = "retried"
+	statDead      = "dead"
+)
+
+// Options configures a RedisQueue
+type Options struct {
+	// Prefix is prepended to every key; defaults to DefaultPrefix. A prefix
+	// without a hash tag is wrapped in one.
+	Prefix string
+}
+
+// RedisQueue is a durable job queue stored in Redis. Jobs are delivered at
+// least once: a reserved job stays in the in-flight set until it is acked, and
+// is handed to another worker if its visibility timeout expires first.
+//
+// Scripts only touch keys passed in KEYS. Where a script finds job IDs as it
+// runs, it is passed the job or ready key prefix as a key instead, which hashes
+// to the same slot as every key built from it.
+//
+// Keys (all under the prefix):
+//
+//	job:<id>        hash with the job fields and the current reservation token
+//	ready:<type>    list of job IDs that can run now
+//	scheduled       sorted set of delayed and retrying job IDs by run time
+//	inflight        sorted set of reserved job IDs by visibility deadline
+//	dead            sorted set of dead-lettered job IDs by failure time
+//	types           set of job types that have been enqueued
+//	paused          set of paused job types, or "*" for all
+//	stats:<type>    hash of counters
+//	unique:<key>    deduplication marker set by WithUniqueKey
+type RedisQueue struct {
+	client *goredis.Client
+	prefix string
+}
+
+// NewRedisQueue creates a queue on the given Redis client
+func NewRedisQueue(client *goredis.Client, opts Options) *RedisQueue {
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	if !strings.Contains(prefix, "{") {
+		prefix = "{" + strings.TrimSuffix(prefix, ":") + "}:"
+	}
+	return &RedisQueue{client: client, prefix: prefix}
+}
+
+// Client returns the underlying Redis client
+func (q *RedisQueue) Client() *goredis.Client {
+	return q.client
+}
+
+func (q *RedisQueue) key(parts ...string) string {
+	key := q.prefix
+	for i, part := range parts {
+		if i > 0 {
+			key += ":"
+		}
+		key += part
+	}
+	return key
+}
+
+func (q *RedisQueue) jobKey(id string) string         { return q.key("job", id) }
+func (q *RedisQueue) readyKey(jobType JobType) string { return q.key("ready", string(jobType)) }
+func (q *RedisQueue) statsKey(jobType JobType) string { return q.key("stats", string(jobType)) }
+func (q *RedisQueue) uniqueKey(key string) string     { return q.key("unique", key) }
+
+// jobKeyPrefix and readyKeyPrefix are the job and ready keys without the
+// job ID or type, for scripts that build those keys themselves
+func (q *RedisQueue) jobKeyPrefix() string   { return q.key("job", "") }
+func (q *RedisQueue) readyKeyPrefix() string { return q.key("ready", "") }
+
+func millis(t time.Time) int64 { return t.UnixMilli() }
+
+var enqueueScript = goredis.NewScript(`
+if ARGV[8] ~= '0' then
+	if not redis.call('SET', KEYS[5], ARGV[1], 'NX', 'PX', ARGV[8]) then
+		return 0
+	end
+end
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'id', ARGV[1], 'type', ARGV[2], 'payload', ARGV[3],
+	'attempts', 0, 'max_attempts', ARGV[4], 'created_at', ARGV[5], 'run_at', ARGV[6])
+redis.call('SADD', KEYS[4], ARGV[2])
+if tonumber(ARGV[6]) <= tonumber(ARGV[7]) then
+	redis.call('RPUSH', KEYS[2], ARGV[1])
+else
+	redis.call('ZADD', KEYS[3], ARGV[6], ARGV[1])
+end
+return 1
+`)
+
+// Enqueue adds a job to the queue. Jobs whose RunAt is in the future wait in
+// the scheduled set until the Scheduler promotes them.
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	if job.Type == "" {
+		return errors.New("job type is required")
+	}
+	if job.ID == "" {
+		job.ID = newID()
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+
+	uniqueKey := q.uniqueKey(job.ID)
+	uniqueFor := int64(0)
+	if job.uniqueKey != "" && job.uniqueFor > 0 {
+		uniqueKey = q.uniqueKey(job.uniqueKey)
+		uniqueFor = job.uniqueFor.Milliseconds()
+	}
+
+	added, err := enqueueScript.Run(ctx, q.client,
+		[]string{q.jobKey(job.ID), q.readyKey(job.Type), q.key("scheduled"), q.key("types"), uniqueKey},
+		job.ID, string(job.Type), string(job.Payload), job.MaxAttempts,
+		millis(job.CreatedAt), millis(job.RunAt), millis(time.Now()), uniqueFor,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("enqueue %s job: %w", job.Type, err)
+	}
+	if added == 0 {
+		return ErrDuplicateJob
+	}
+
+	q.client.HIncrBy(ctx, q.statsKey(job.Type), statEnqueued, 1)
+	return nil
+}
+
+// EnqueueJob creates a job with NewJob and enqueues it
+func (q *RedisQueue) EnqueueJob(ctx context.Context, jobType JobType, payload interface{}, opts ...Option) (*Job, error) {
+	job, err := NewJob(jobType, payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+var reserveScript = goredis.NewScript(`
+for i = 1, 10 do
+	local id = redis.call('LPOP', KEYS[1])
+	if not id then
+		return false
+	end
+	local key = KEYS[3] .. id
+	if redis.call('EXISTS', key) == 1 then
+		redis.call('ZADD', KEYS[2], ARGV[1], id)
+		redis.call('HINCRBY', key, 'attempts', 1)
+		redis.call('HSET', key, 'token', ARGV[2])
+		return redis.call('HGETALL', key)
+	end
+end
+return false
+`)
+
+// Reserve takes the next ready job of the given type and hides it from other
+// workers for visibility. It returns nil when no job is ready.
+func (q *RedisQueue) Reserve(ctx context.Context, jobType JobType, visibility time.Duration) (*Job, error) {
+	token := newID()
+	deadline := millis(time.Now().Add(visibility))
+
+	fields, err := reserveScript.Run(ctx, q.client,
+		[]string{q.readyKey(jobType), q.key("inflight"), q.jobKeyPrefix()},
+		deadline, token,
+	).StringSlice()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reserve %s job: %w", jobType, err)
+	}
+
+	job := jobFromFields(pairsToMap(fields))
+	job.token = token
+	return job, nil
+}
+
+var ackScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[2], 'token') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('DEL', KEYS[2])
+return 1
+`)
+
+// Ack removes a successfully processed job. It is a no-op when the reservation
+// has expired and the job was handed to another worker or cancelled.
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	acked, err := ackScript.Run(ctx, q.client, []string{q.key("inflight"), q.jobKey(job.ID)}, job.ID, job.token).Int()
+	if err != nil {
+		return fmt.Errorf("ack job %s: %w", job.ID, err)
+	}
+	if acked == 1 {
+		q.client.HIncrBy(ctx, q.statsKey(job.Type), statProcessed, 1)
+	}
+	return nil
+}
+
+var retryScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[3], 'token') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[3], 'token', '', 'run_at', ARGV[3], 'last_error', ARGV[4])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+// Retry releases a failed job and schedules it to run again at runAt
+func (q *RedisQueue) Retry(ctx context.Context, job *Job, runAt time.Time, cause error) error {
+	retried, err := retryScript.Run(ctx, q.client,
+		[]string{q.key("inflight"), q.key("scheduled"), q.jobKey(job.ID)},
+		job.ID, job.token, millis(runAt), errorText(cause),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("retry job %s: %w", job.ID, err)
+	}
+	if retried == 1 {
+		q.client.HIncrBy(ctx, q.statsKey(job.Type), statFailed, 1)
+		q.client.HIncrBy(ctx, q.statsKey(job.Type), statRetried, 1)
+	}
+	return nil
+}
+
+var buryScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[3], 'token') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[3], 'token', '', 'failed_at', ARGV[3], 'last_error', ARGV[4])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+// Bury moves a failed job to the dead-letter queue, where it stays until an
+// admin retries or cancels it
+func (q *RedisQueue) Bury(ctx context.Context, job *Job, cause error) error {
+	buried, err := buryScript.Run(ctx, q.client,
+		[]string{q.key("inflight"), q.key("dead"), q.jobKey(job.ID)},
+		job.ID, job.token, millis(time.Now()), errorText(cause),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("bury job %s: %w", job.ID, err)
+	}
+	if buried == 1 {
+		q.client.HIncrBy(ctx, q.statsKey(job.Type), statFailed, 1)
+		q.client.HIncrBy(ctx, q.statsKey(job.Type), statDead, 1)
+	}
+	return nil
+}
+
+var extendScript = goredis.NewScript(`
+if redis.call('HGET', KEYS[2], 'token') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[3], ARGV[1])
+return 1
+`)
+
+// Extend pushes back the visibility deadline of a reserved job. It reports
+// false when the reservation has been lost.
+func (q *RedisQueue) Extend(ctx context.Context, job *Job, visibility time.Duration) (bool, error) {
+	extended, err := extendScript.Run(ctx, q.client,
+		[]string{q.key("inflight"), q.jobKey(job.ID)},
+		job.ID, job.token, millis(time.Now().Add(visibility)),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("extend job %s: %w", job.ID, err)
+	}
+	return extended == 1, nil
+}
+
+var promoteScript = goredis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	local jobType = redis.call('HGET', KEYS[2] .. id, 'type')
+	if jobType then
+		redis.call('RPUSH', KEYS[3] .. jobType, id)
+	end
+end
+return #ids
+`)
+
+// PromoteDue moves up to limit scheduled jobs whose run time has passed onto
+// their ready lists
+func (q *RedisQueue) PromoteDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	n, err := promoteScript.Run(ctx, q.client,
+		[]string{q.key("scheduled"), q.jobKeyPrefix(), q.readyKeyPrefix()},
+		millis(now), limit,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("promote due jobs: %w", err)
+	}
+	return n, nil
+}
+
+var reclaimScript = goredis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local dead = {}
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	local key = KEYS[4] .. id
+	local job = redis.call('HMGET', key, 'type', 'attempts', 'max_attempts')
+	if job[1] then
+		if tonumber(job[2]) >= tonumber(job[3]) then
+			redis.call('HSET', key, 'token', '', 'failed_at', ARGV[1], 'last_error', 'visibility timeout expired')
+			redis.call('ZADD', KEYS[3], ARGV[1], id)
+			table.insert(dead, job[1])
+		else
+			redis.call('HSET', key, 'token', '', 'last_error', 'visibility timeout expired')
+			redis.call('ZADD', KEYS[2], ARGV[1], id)
+		end
+	end
+end
+return {#ids, dead}
+`)
+
+// ReclaimExpired returns up to limit jobs whose visibility timeout has passed
+// to the queue, dead-lettering those that have used all their attempts. This
+// is what makes delivery at-least-once when a worker crashes mid-job.
+func (q *RedisQueue) ReclaimExpired(ctx context.Context, now time.Time, limit int) (int, error) {
+	result, err := reclaimScript.Run(ctx, q.client,
+		[]string{q.key("inflight"), q.key("scheduled"), q.key("dead"), q.jobKeyPrefix()},
+		millis(now), limit,
+	).Slice()
+	if err != nil {
+		return 0, fmt.Errorf("reclaim expired jobs: %w", err)
+	}
+
+	reclaimed, _ := result[0].(int64)
+	if deadTypes, ok := result[1].([]interface{}); ok {
+		for _, jobType := range deadTypes {
+			if s, ok := jobType.(string); ok {
+				q.client.HIncrBy(ctx, q.statsKey(JobType(s)), statDead, 1)
+			}
+		}
+	}
+	return int(reclaimed), nil
+}
+
+var cancelScript = goredis.NewScript(`
+local jobType = redis.call('HGET', KEYS[4], 'type')
+if not jobType then
+	return 0
+end
+redis.call('LREM', KEYS[5] .. jobType, 0, ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('DEL', KEYS[4])
+return 1
+`)
+
+// Cancel removes a job wherever it is. A worker already running it finishes,
+// but its result is discarded.
+func (q *RedisQueue) Cancel(ctx context.Context, id string) error {
+	cancelled, err := cancelScript.Run(ctx, q.client,
+		[]string{q.key("scheduled"), q.key("inflight"), q.key("dead"), q.jobKey(id), q.readyKeyPrefix()},
+		id,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("cancel job %s: %w", id, err)
+	}
+	if cancelled == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+var requeueDeadScript = goredis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+local jobType = redis.call('HGET', KEYS[2], 'type')
+if not jobType then
+	return 0
+end
+redis.call('HSET', KEYS[2], 'attempts', 0, 'run_at', ARGV[2])
+redis.call('HDEL', KEYS[2], 'failed_at')
+redis.call('RPUSH', KEYS[3] .. jobType, ARGV[1])
+return 1
+`)
+
+// RetryDead moves a dead-lettered job back onto its ready list with a fresh
+// set of attempts
+func (q *RedisQueue) RetryDead(ctx context.Context, id string) error {
+	requeued, err := requeueDeadScript.Run(ctx, q.client,
+		[]string{q.key("dead"), q.jobKey(id), q.readyKeyPrefix()},
+		id, millis(time.Now()),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("retry dead job %s: %w", id, err)
+	}
+	if requeued == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+var clearScript = goredis.NewScript(`
+local removed = 0
+local function drop(id)
+	redis.call('DEL', KEYS[4] .. id)
+	removed = removed + 1
+end
+for _, jobType in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+	local ready = KEYS[5] .. jobType
+	for _, id in ipairs(redis.call('LRANGE', ready, 0, -1)) do
+		drop(id)
+	end
+	redis.call('DEL', ready)
+end
+for i = 2, 3 do
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[i], 0, -1)) do
+		drop(id)
+	end
+	redis.call('DEL', KEYS[i])
+end
+return removed
+`)
+
+// Clear drops every ready, scheduled and dead job. Jobs that are currently
+// running are left alone.
+func (q *RedisQueue) Clear(ctx context.Context) (int, error) {
+	removed, err := clearScript.Run(ctx, q.client,
+		[]string{q.key("types"), q.key("scheduled"), q.key("dead"), q.jobKeyPrefix(), q.readyKeyPrefix()},
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("clear queue: %w", err)
+	}
+	return removed, nil
+}
+
+// Pause stops workers from taking jobs of the given type. An empty type pauses
+// every type.
+func (q *RedisQueue) Pause(ctx context.Context, jobType JobType) error {
+	if jobType == "" {
+		jobType = allTypes
+	}
+	return q.client.SAdd(ctx, q.key("paused"), string(jobType)).Err()
+}
+
+// Resume undoes Pause. An empty type resumes every type, including those
+// paused individually.
+func (q *RedisQueue) Resume(ctx context.Context, jobType JobType) error {
+	if jobType == "" {
+		return q.client.Del(ctx, q.key("paused")).Err()
+	}
+	return q.client.SRem(ctx, q.key("paused"), string(jobType)).Err()
+}
+
+// paused returns the set of paused job types
+func (q *RedisQueue) paused(ctx context.Context) (map[JobType]bool, error) {
+	members, err := q.client.SMembers(ctx, q.key("paused")).Result()
+	if err != nil {
+		return nil, err
+	}
+	paused := make(map[JobType]bool, len(members))
+	for _, member := range members {
+		paused[JobType(member)] = true
+	}
+	return paused, nil
+}
+
+// Get returns a job by ID
+func (q *RedisQueue) Get(ctx context.Context, id string) (*Job, error) {
+	fields, err := q.client.HGetAll(ctx, q.jobKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrJobNotFound
+	}
+	return jobFromFields(fields), nil
+}
+
+// DeadJobs returns the most recently dead-lettered jobs
+func (q *RedisQueue) DeadJobs(ctx context.Context, limit int) ([]*Job, error) {
+	ids, err := q.client.ZRevRange(ctx, q.key("dead"), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := q.Get(ctx, id)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// TypeStats holds the queue depth and counters for one job type
+type TypeStats struct {
+	Ready     int64 `json:"ready"`
+	Paused    bool  `json:"paused"`
+	Enqueued  int64 `json:"enqueued"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	Retried   int64 `json:"retried"`
+	Dead      int64 `json:"dead"`
+}
+
+// Stats is a snapshot of the whole queue
+type Stats struct {
+	Ready     int64                 `json:"ready"`
+	Scheduled int64                 `json:"scheduled"`
+	InFlight  int64                 `json:"in_flight"`
+	Dead      int64                 `json:"dead"`
+	Paused    bool                  `json:"paused"`
+	Types     map[JobType]TypeStats `json:"types"`
+}
+
+// Stats returns the current depth of every part of the queue and the per-type counters
+func (q *RedisQueue) Stats(ctx context.Context) (*Stats, error) {
+	types, err := q.client.SMembers(ctx, q.key("types")).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(types)
+
+	pipe := q.client.Pipeline()
+	scheduled := pipe.ZCard(ctx, q.key("scheduled"))
+	inflight := pipe.ZCard(ctx, q.key("inflight"))
+	dead := pipe.ZCard(ctx, q.key("dead"))
+	paused := pipe.SMembers(ctx, q.key("paused"))
+	ready := make([]*goredis.IntCmd, len(types))
+	counters := make([]*goredis.MapStringStringCmd, len(types))
+	for i, jobType := range types {
+		ready[i] = pipe.LLen(ctx, q.readyKey(JobType(jobType)))
+		counters[i] = pipe.HGetAll(ctx, q.statsKey(JobType(jobType)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	pausedTypes := make(map[string]bool)
+	for _, member := range paused.Val() {
+		pausedTypes[member] = true
+	}
+
+	stats := &Stats{
+		Scheduled: scheduled.Val(),
+		InFlight:  inflight.Val(),
+		Dead:      dead.Val(),
+		Paused:    pausedTypes[allTypes],
+		Types:     make(map[JobType]TypeStats, len(types)),
+	}
+	for i, jobType := range types {
+		c := counters[i].Val()
+		stats.Types[JobType(jobType)] = TypeStats{
+			Ready:     ready[i].Val(),
+			Paused:    stats.Paused || pausedTypes[jobType],
+			Enqueued:  parseCount(c[statEnqueued]),
+			Processed: parseCount(c[statProcessed]),
+			Failed:    parseCount(c[statFailed]),
+			Retried:   parseCount(c[statRetried]),
+			Dead:      parseCount(c[statDead]),
+		}
+		stats.Ready += ready[i].Val()
+	}
+
+	return stats, nil
+}
+
+// adminTimeout bounds the admin operations, which have no request context
+const adminTimeout = 10 * time.Second
+
+// GetJobQueueStats returns the queue stats for the admin system endpoint
+func (q *RedisQueue) GetJobQueueStats() (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), adminTimeout)
+	defer cancel()
+
+	stats, err := q.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadJobs, err := q.DeadJobs(ctx, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"ready":     stats.Ready,
+		"scheduled": stats.Scheduled,
+		"in_flight": stats.InFlight,
+		"dead":      stats.Dead,
+		"paused":    stats.Paused,
+		"types":     stats.Types,
+		"dead_jobs": deadJobs,
+	}, nil
+}
+
+// ManageJobQueue applies an admin action. For pause and resume, jobID may name
+// a job type to pause or resume just that type.
+func (q *RedisQueue) ManageJobQueue(action string, jobID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), adminTimeout)
+	defer cancel()
+
+	switch action {
+	case "pause":
+		return q.Pause(ctx, JobType(jobID))
+	case "resume":
+		return q.Resume(ctx, JobType(jobID))
+	case "pause_all":
+		return q.Pause(ctx, "")
+	case "resume_all":
+		return q.Resume(ctx, "")
+	case "cancel":
+		return q.Cancel(ctx, jobID)
+	case "retry":
+		return q.RetryDead(ctx, jobID)
+	case "clear_all":
+		_, err := q.Clear(ctx)
+		return err
+	default:
+		return fmt.Errorf("unknown job queue action %q", action)
+	}
+}
+
+func jobFromFields(fields map[string]string) *Job {
+	job := &Job{
+		ID:          fields["id"],
+		Type:        JobType(fields["type"]),
+		Attempts:    int(parseCount(fields["attempts"])),
+		MaxAttempts: int(parseCount(fields["max_attempts"])),
+		CreatedAt:   parseMillis(fields["created_at"]),
+		RunAt:       parseMillis(fields["run_at"]),
+		LastError:   fields["last_error"],
+	}
+	if payload := fields["payload"]; payload != "" {
+		job.Payload = []byte(payload)
+	}
+	if failedAt := fields["failed_at"]; failedAt != "" {
+		t := parseMillis(failedAt)
+		job.FailedAt = &t
+	}
+	return job
+}
+
+func pairsToMap(pairs []string) map[string]string {
+	m := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m
+}
+
+func parseCount(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+func parseMillis(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	return time.UnixMilli(parseCount(s))
+}
+
+// maxErrorLength caps the error text stored on a job
+const maxErrorLength = 1024
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	text := err.Error()
+	if len(text) > maxErrorLength {
+		text = text[:maxErrorLength]
+	}
+	return text
+}
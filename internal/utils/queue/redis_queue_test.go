@@ -0,0 +1,203 @@
+package queue_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+const testJob queue.JobType = "test.job"
+
+// newQueue returns a queue on a fresh in-memory Redis
+func newQueue(t *testing.T) *queue.RedisQueue {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return queue.NewRedisQueue(client, queue.Options{})
+}
+
+// enqueue adds a job of the test type, failing the test on error
+func enqueue(t *testing.T, q *queue.RedisQueue, opts ...queue.Option) *queue.Job {
+	t.Helper()
+	job, err := q.EnqueueJob(context.Background(), testJob, map[string]string{"hello": "world"}, opts...)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return job
+}
+
+// reserve takes the next test job, failing the test on error
+func reserve(t *testing.T, q *queue.RedisQueue) *queue.Job {
+	t.Helper()
+	job, err := q.Reserve(context.Background(), testJob, time.Minute)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	return job
+}
+
+// stats returns the queue stats, failing the test on error
+func stats(t *testing.T, q *queue.RedisQueue) *queue.Stats {
+	t.Helper()
+	stats, err := q.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	return stats
+}
+
+func TestReserveHidesJobUntilAcked(t *testing.T) {
+	q := newQueue(t)
+	ctx := context.Background()
+	enqueued := enqueue(t, q)
+
+	job := reserve(t, q)
+	if job == nil || job.ID != enqueued.ID || job.Attempts != 1 || string(job.Payload) != `{"hello":"world"}` {
+		t.Fatalf("reserved %+v, want the enqueued job on its first attempt", job)
+	}
+	if other := reserve(t, q); other != nil {
+		t.Fatalf("reserved %+v while the job was in flight", other)
+	}
+	if got := stats(t, q); got.InFlight != 1 || got.Ready != 0 {
+		t.Fatalf("stats = %+v, want one job in flight", got)
+	}
+
+	if err := q.Ack(ctx, job); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	got := stats(t, q)
+	if got.InFlight != 0 || got.Types[testJob].Enqueued != 1 || got.Types[testJob].Processed != 1 {
+		t.Fatalf("stats = %+v, want the job processed", got)
+	}
+	if _, err := q.Get(ctx, job.ID); !stderrors.Is(err, queue.ErrJobNotFound) {
+		t.Fatalf("get acked job = %v, want not found", err)
+	}
+}
+
+func TestDelayedJobWaitsForItsRunTime(t *testing.T) {
+	q := newQueue(t)
+	ctx := context.Background()
+	enqueue(t, q, queue.WithDelay(time.Hour))
+
+	if job := reserve(t, q); job != nil {
+		t.Fatalf("reserved delayed job %+v", job)
+	}
+	if n, err := q.PromoteDue(ctx, time.Now(), 10); err != nil || n != 0 {
+		t.Fatalf("promote before the run time = %d, %v, want nothing promoted", n, err)
+	}
+	if n, err := q.PromoteDue(ctx, time.Now().Add(2*time.Hour), 10); err != nil || n != 1 {
+		t.Fatalf("promote after the run time = %d, %v, want the job promoted", n, err)
+	}
+	if job := reserve(t, q); job == nil {
+		t.Fatal("promoted job was not ready")
+	}
+}
+
+func TestEnqueueDeduplicates(t *testing.T) {
+	tests := map[string]queue.Option{
+		"same ID":         queue.WithID("report-42"),
+		"same unique key": queue.WithUniqueKey("nightly", time.Hour),
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := newQueue(t)
+			enqueue(t, q, opt)
+
+			_, err := q.EnqueueJob(context.Background(), testJob, nil, opt)
+			if !stderrors.Is(err, queue.ErrDuplicateJob) {
+				t.Fatalf("second enqueue = %v, want a duplicate", err)
+			}
+			if got := stats(t, q).Ready; got != 1 {
+				t.Fatalf("%d ready jobs, want 1", got)
+			}
+		})
+	}
+}
+
+func TestExpiredReservationIsRedeliveredThenDeadLettered(t *testing.T) {
+	q := newQueue(t)
+	ctx := context.Background()
+	enqueue(t, q, queue.WithMaxAttempts(2))
+	later := func() time.Time { return time.Now().Add(time.Hour) }
+
+	// The worker holding the first reservation dies
+	first := reserve(t, q)
+	if n, err := q.ReclaimExpired(ctx, later(), 10); err != nil || n != 1 {
+		t.Fatalf("reclaim = %d, %v, want the job reclaimed", n, err)
+	}
+	if _, err := q.PromoteDue(ctx, later(), 10); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	second := reserve(t, q)
+	if second == nil || second.ID != first.ID || second.Attempts != 2 {
+		t.Fatalf("redelivered %+v, want the job on its second attempt", second)
+	}
+
+	// A stale worker finishing late cannot ack the new reservation
+	if err := q.Ack(ctx, first); err != nil {
+		t.Fatalf("stale ack: %v", err)
+	}
+	if got := stats(t, q).InFlight; got != 1 {
+		t.Fatalf("%d jobs in flight after a stale ack, want 1", got)
+	}
+
+	// Out of attempts, the next expiry dead-letters the job
+	if _, err := q.ReclaimExpired(ctx, later(), 10); err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	got := stats(t, q)
+	if got.Dead != 1 || got.InFlight != 0 || got.Scheduled != 0 || got.Types[testJob].Dead != 1 {
+		t.Fatalf("stats = %+v, want the job dead-lettered", got)
+	}
+
+	// An admin can send it back with a fresh set of attempts
+	if err := q.ManageJobQueue("retry", first.ID); err != nil {
+		t.Fatalf("retry dead job: %v", err)
+	}
+	if job := reserve(t, q); job == nil || job.Attempts != 1 {
+		t.Fatalf("retried %+v, want the job on a first attempt", job)
+	}
+}
+
+func TestManageJobQueue(t *testing.T) {
+	q := newQueue(t)
+	ctx := context.Background()
+	job := enqueue(t, q)
+	enqueue(t, q, queue.WithDelay(time.Hour))
+
+	if err := q.ManageJobQueue("pause", string(testJob)); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if got := stats(t, q).Types[testJob]; !got.Paused {
+		t.Fatalf("type stats = %+v, want it paused", got)
+	}
+	if err := q.ManageJobQueue("resume", string(testJob)); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	if err := q.ManageJobQueue("cancel", job.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := q.ManageJobQueue("cancel", job.ID); !stderrors.Is(err, queue.ErrJobNotFound) {
+		t.Fatalf("cancel twice = %v, want not found", err)
+	}
+	if err := q.ManageJobQueue("clear_all", ""); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if got := stats(t, q); got.Ready != 0 || got.Scheduled != 0 {
+		t.Fatalf("stats = %+v, want an empty queue", got)
+	}
+	if err := q.ManageJobQueue("explode", ""); err == nil {
+		t.Fatal("unknown action was accepted")
+	}
+	if _, err := q.Get(ctx, job.ID); !stderrors.Is(err, queue.ErrJobNotFound) {
+		t.Fatalf("get cancelled job = %v, want not found", err)
+	}
+}
@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/utils/logger"
+)
+
+const (
+	// schedulerTick is how often delayed jobs are promoted and lost jobs reclaimed
+	schedulerTick = time.Second
+
+	// schedulerBatch caps how many jobs are moved per tick
+	schedulerBatch = 500
+)
+
+// recurringJob is a job enqueued once per interval
+type recurringJob struct {
+	jobType  JobType
+	interval time.Duration
+	payload  interface{}
+}
+
+// Scheduler moves delayed and retrying jobs onto the ready lists when they are
+// due, returns jobs whose visibility timeout expired, and enqueues recurring
+// jobs. Every server instance can run one: recurring jobs are deduplicated per
+// interval so each runs once across the cluster.
+type Scheduler struct {
+	queue     *RedisQueue
+	log       *logger.Logger
+	mu        sync.Mutex
+	recurring []recurringJob
+}
+
+// NewScheduler creates a scheduler for the queue
+func NewScheduler(queue *RedisQueue, log *logger.Logger) *Scheduler {
+	return &Scheduler{queue: queue, log: log}
+}
+
+// Every enqueues a job of the given type with payload once per interval
+func (s *Scheduler) Every(jobType JobType, interval time.Duration, payload interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recurring = append(s.recurring, recurringJob{jobType: jobType, interval: interval, payload: payload})
+}
+
+// Run performs the scheduler duties every second until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	if _, err := s.queue.PromoteDue(ctx, now, schedulerBatch); err != nil && ctx.Err() == nil {
+		s.log.Error("Failed to promote due jobs", "error", err)
+	}
+
+	reclaimed, err := s.queue.ReclaimExpired(ctx, now, schedulerBatch)
+	if err != nil && ctx.Err() == nil {
+		s.log.Error("Failed to reclaim expired jobs", "error", err)
+	}
+	if reclaimed > 0 {
+		s.log.Warn("Reclaimed jobs whose visibility timeout expired", "count", reclaimed)
+	}
+
+	s.mu.Lock()
+	recurring := append([]recurringJob(nil), s.recurring...)
+	s.mu.Unlock()
+
+	for _, r := range recurring {
+		if err := s.enqueueRecurring(ctx, r, now); err != nil && ctx.Err() == nil {
+			s.log.Error("Failed to enqueue recurring job", "job_type", string(r.jobType), "error", err)
+		}
+	}
+}
+
+// enqueueRecurring enqueues the job for the interval slot containing now. The
+// unique key is the slot, so other instances (and later ticks) skip it.
+func (s *Scheduler) enqueueRecurring(ctx context.Context, r recurringJob, now time.Time) error {
+	slot := now.Truncate(r.interval)
+	_, err := s.queue.EnqueueJob(ctx, r.jobType, r.payload,
+		WithUniqueKey(fmt.Sprintf("recurring:%s:%d", r.jobType, slot.Unix()), r.interval),
+		WithMaxAttempts(1),
+	)
+	if errors.Is(err, ErrDuplicateJob) {
+		return nil
+	}
+	return err
+}
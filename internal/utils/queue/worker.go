@@ -0,0 +1,275 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/utils/logger"
+)
+
+// WorkerOptions configures a Worker. Zero values select the defaults.
+type WorkerOptions struct {
+	// Concurrency is the number of jobs processed at once (default 4)
+	Concurrency int
+
+	// VisibilityTimeout is how long a reserved job is hidden from other workers
+	// before it is considered lost. Running jobs extend it with a heartbeat.
+	// Default 30s.
+	VisibilityTimeout time.Duration
+
+	// JobTimeout bounds a single handler run (default 5m)
+	JobTimeout time.Duration
+
+	// PollInterval is how long an idle worker waits before looking again (default 1s)
+	PollInterval time.Duration
+
+	// Backoff computes the delay before a failed job is retried
+	Backoff Backoff
+}
+
+// Backoff returns the delay before retry number attempt (starting at 1)
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff doubles the delay from base on every attempt up to max,
+// with up to 20% jitter so failed jobs do not retry in lockstep
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		if attempt < 1 {
+			attempt = 1
+		}
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+		return delay + jitter
+	}
+}
+
+func (o WorkerOptions) withDefaults() WorkerOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.VisibilityTimeout <= 0 {
+		o.VisibilityTimeout = 30 * time.Second
+	}
+	if o.JobTimeout <= 0 {
+		o.JobTimeout = 5 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Backoff == nil {
+		o.Backoff = ExponentialBackoff(10*time.Second, time.Hour)
+	}
+	return o
+}
+
+// Worker reserves jobs from a RedisQueue and runs the handler registered for
+// each job type
+type Worker struct {
+	queue    *RedisQueue
+	log      *logger.Logger
+	opts     WorkerOptions
+	mu       sync.RWMutex
+	handlers map[JobType]Handler
+}
+
+// NewWorker creates a worker for the queue
+func NewWorker(queue *RedisQueue, log *logger.Logger, opts WorkerOptions) *Worker {
+	return &Worker{
+		queue:    queue,
+		log:      log,
+		opts:     opts.withDefaults(),
+		handlers: make(map[JobType]Handler),
+	}
+}
+
+// Handle registers the handler for a job type. Jobs of types without a handler
+// stay queued until a worker that handles them runs.
+func (w *Worker) Handle(jobType JobType, handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// types returns the registered job types in a stable order
+func (w *Worker) types() []JobType {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	types := make([]JobType, 0, len(w.handlers))
+	for jobType := range w.handlers {
+		types = append(types, jobType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+func (w *Worker) handler(jobType JobType) Handler {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.handlers[jobType]
+}
+
+// Run processes jobs until ctx is cancelled, then waits for running jobs to finish
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func(slot int) {
+			defer wg.Done()
+			w.loop(ctx, slot)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context, slot int) {
+	for ctx.Err() == nil {
+		job, err := w.next(ctx, slot)
+		if err != nil && ctx.Err() == nil {
+			w.log.Error("Failed to reserve job", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.opts.PollInterval):
+			}
+			continue
+		}
+
+		w.process(ctx, job)
+	}
+}
+
+// next reserves a job from the first unpaused type that has one. Each slot
+// starts from a different type so one busy type cannot starve the others.
+func (w *Worker) next(ctx context.Context, slot int) (*Job, error) {
+	types := w.types()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	paused, err := w.queue.paused(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if paused[allTypes] {
+		return nil, nil
+	}
+
+	for i := range types {
+		jobType := types[(slot+i)%len(types)]
+		if paused[jobType] {
+			continue
+		}
+		job, err := w.queue.Reserve(ctx, jobType, w.opts.VisibilityTimeout)
+		if err != nil || job != nil {
+			return job, err
+		}
+	}
+	return nil, nil
+}
+
+// process runs one job and records the outcome. The handler context is not
+// cancelled on shutdown so a running job can finish; it is bounded by JobTimeout.
+func (w *Worker) process(ctx context.Context, job *Job) {
+	log := w.log.With("job_id", job.ID, "job_type", string(job.Type), "attempt", job.Attempts)
+
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.opts.JobTimeout)
+	defer cancel()
+
+	stopHeartbeat := w.heartbeat(jobCtx, job, cancel, log)
+	err := w.run(jobCtx, job)
+	stopHeartbeat()
+
+	// Record the outcome even when shutting down
+	outcomeCtx, outcomeCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer outcomeCancel()
+
+	if err == nil {
+		if ackErr := w.queue.Ack(outcomeCtx, job); ackErr != nil {
+			log.Error("Failed to ack job", "error", ackErr)
+		}
+		return
+	}
+
+	if IsPermanent(err) || job.Attempts >= job.MaxAttempts {
+		log.Error("Job failed permanently, moving to dead-letter queue", "error", err)
+		if buryErr := w.queue.Bury(outcomeCtx, job, err); buryErr != nil {
+			log.Error("Failed to dead-letter job", "error", buryErr)
+		}
+		return
+	}
+
+	delay := w.opts.Backoff(job.Attempts)
+	log.Warn("Job failed, retrying", "error", err, "retry_in", delay.String())
+	if retryErr := w.queue.Retry(outcomeCtx, job, time.Now().Add(delay), err); retryErr != nil {
+		log.Error("Failed to schedule job retry", "error", retryErr)
+	}
+}
+
+// run calls the handler, turning a missing handler or a panic into an error
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	handler := w.handler(job.Type)
+	if handler == nil {
+		return Permanent(fmt.Errorf("no handler registered for job type %s", job.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// heartbeat keeps extending the job's visibility while it runs. If the
+// reservation is lost the job context is cancelled, since another worker may
+// already be running the job.
+func (w *Worker) heartbeat(ctx context.Context, job *Job, cancelJob context.CancelFunc, log *logger.Logger) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(w.opts.VisibilityTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				held, err := w.queue.Extend(ctx, job, w.opts.VisibilityTimeout)
+				if err != nil {
+					log.Warn("Failed to extend job visibility", "error", err)
+					continue
+				}
+				if !held {
+					log.Warn("Lost job reservation, cancelling handler")
+					cancelJob()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
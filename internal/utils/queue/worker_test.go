@@ -0,0 +1,115 @@
+package queue_test
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/queue"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := queue.ExponentialBackoff(time.Second, time.Minute)
+
+	for attempt, want := range map[int]time.Duration{
+		0:  time.Second,
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		7:  time.Minute,
+		40: time.Minute,
+	} {
+		// Jitter adds up to a fifth of the delay
+		if got := backoff(attempt); got < want || got > want+want/5 {
+			t.Errorf("attempt %d waits %v, want %v plus up to 20%%", attempt, got, want)
+		}
+	}
+}
+
+// runWorker processes test jobs with handler until the test ends, retrying
+// failures immediately
+func runWorker(t *testing.T, q *queue.RedisQueue, handler queue.Handler) {
+	t.Helper()
+	worker := queue.NewWorker(q, logger.NewNop(), queue.WorkerOptions{
+		Concurrency:  1,
+		PollInterval: 5 * time.Millisecond,
+		Backoff:      func(int) time.Duration { return 0 },
+	})
+	worker.Handle(testJob, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitFor polls the queue stats until done reports true
+func waitFor(t *testing.T, q *queue.RedisQueue, done func(stats *queue.Stats) bool) *queue.Stats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// Stand in for the scheduler, promoting retries as they fall due
+		if _, err := q.PromoteDue(context.Background(), time.Now(), 10); err != nil {
+			t.Fatalf("promote: %v", err)
+		}
+		got := stats(t, q)
+		if done(got) {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v, timed out waiting", got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWorkerOutcomes(t *testing.T) {
+	tests := map[string]struct {
+		failures int32
+		err      error
+		runs     int32
+		dead     bool
+	}{
+		"success":           {runs: 1},
+		"transient failure": {failures: 2, err: stderrors.New("flaky"), runs: 3},
+		"out of attempts":   {failures: 10, err: stderrors.New("down"), runs: 3, dead: true},
+		"permanent failure": {failures: 10, err: queue.Permanent(stderrors.New("bad payload")), runs: 1, dead: true},
+		"panic":             {failures: 10, runs: 3, dead: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := newQueue(t)
+			enqueue(t, q, queue.WithMaxAttempts(3))
+
+			var runs atomic.Int32
+			runWorker(t, q, func(ctx context.Context, job *queue.Job) error {
+				if runs.Add(1) > tt.failures {
+					return nil
+				}
+				if tt.err == nil {
+					panic("boom")
+				}
+				return tt.err
+			})
+
+			got := waitFor(t, q, func(s *queue.Stats) bool {
+				return s.Types[testJob].Processed+s.Dead > 0
+			})
+			if dead := got.Dead == 1; dead != tt.dead || got.Types[testJob].Processed+got.Dead != 1 {
+				t.Fatalf("stats = %+v, want dead-lettered %v", got, tt.dead)
+			}
+			if n := runs.Load(); n != tt.runs {
+				t.Fatalf("handler ran %d times, want %d", n, tt.runs)
+			}
+		})
+	}
+}
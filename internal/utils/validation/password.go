@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"context"
 	"crypto/subtle"
 	"fmt"
 	"strings"
@@ -18,6 +19,13 @@ type PasswordOptions struct {
 	RequireNumbers    bool
 	RequireSymbols    bool
 	DisallowedStrings []string
+	Breached          BreachChecker // Optional breached-password corpus
+}
+
+// BreachChecker reports whether a password appears in a breached-password
+// corpus. *pwned.Checker implements it.
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
 }
 
 // DefaultPasswordOptions returns default password validation options
@@ -35,6 +43,13 @@ func DefaultPasswordOptions() PasswordOptions {
 
 // ValidatePassword validates a password against the specified options
 func ValidatePassword(password string, options PasswordOptions) (bool, string) {
+	return ValidatePasswordContext(context.Background(), password, options)
+}
+
+// ValidatePasswordContext validates a password against the specified
+// options, looking it up in options.Breached with ctx. A corpus that cannot
+// be read does not fail validation.
+func ValidatePasswordContext(ctx context.Context, password string, options PasswordOptions) (bool, string) {
 	// Check length
 	if len(password) < options.MinLength {
 		return false, fmt.Sprintf("Password must be at least %d characters long", options.MinLength)
@@ -108,6 +123,13 @@ func ValidatePassword(password string, options PasswordOptions) (bool, string) {
 		}
 	}
 
+	// Check the breached-password corpus last, since it reads from disk
+	if options.Breached != nil {
+		if breached, err := options.Breached.Breached(ctx, password); err == nil && breached {
+			return false, "Password has appeared in a data breach, choose a different one"
+		}
+	}
+
 	return true, ""
 }
 
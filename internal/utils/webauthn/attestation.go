@@ -0,0 +1,114 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedAttestation is returned for an attestation statement format
+// other than none or packed. Registration asks for none, which browsers honour
+// by replacing whatever the authenticator produced.
+var ErrUnsupportedAttestation = errors.New("webauthn: unsupported attestation format")
+
+// attestationObject is the decoded attestation object (WebAuthn §6.5)
+type attestationObject struct {
+	format   string
+	stmt     map[interface{}]interface{}
+	authData *authenticatorData
+}
+
+func parseAttestationObject(raw []byte) (*attestationObject, error) {
+	item, rest, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := item.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrInvalidResponse)
+	}
+
+	format, _ := fields["fmt"].(string)
+	stmt, _ := fields["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := fields["authData"].([]byte)
+	if format == "" || stmt == nil || rawAuthData == nil {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrInvalidResponse)
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	return &attestationObject{format: format, stmt: stmt, authData: authData}, nil
+}
+
+// verify checks the attestation signature. It establishes that the
+// authenticator holds the credential key, not which authenticator it is, so a
+// certificate chain is not checked against any roots.
+func (a *attestationObject) verify(credentialKey *PublicKey, clientDataHash []byte) error {
+	switch a.format {
+	case "none":
+		if len(a.stmt) != 0 {
+			return fmt.Errorf("%w: none attestation with a statement", ErrInvalidResponse)
+		}
+		return nil
+	case "packed":
+		return a.verifyPacked(credentialKey, clientDataHash)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAttestation, a.format)
+	}
+}
+
+// verifyPacked verifies a packed attestation (WebAuthn §8.2), either self
+// attestation by the credential key or a signature by the attestation
+// certificate in x5c
+func (a *attestationObject) verifyPacked(credentialKey *PublicKey, clientDataHash []byte) error {
+	alg, _ := a.stmt["alg"].(int64)
+	sig, _ := a.stmt["sig"].([]byte)
+	if sig == nil {
+		return fmt.Errorf("%w: packed attestation without a signature", ErrInvalidResponse)
+	}
+	signed := concat(a.authData.raw, clientDataHash)
+
+	chain, hasChain := a.stmt["x5c"].([]interface{})
+	if !hasChain {
+		if alg != credentialKey.Algorithm {
+			return fmt.Errorf("%w: self attestation algorithm does not match the credential", ErrInvalidResponse)
+		}
+		return credentialKey.Verify(signed, sig)
+	}
+
+	if len(chain) == 0 {
+		return fmt.Errorf("%w: empty attestation certificate chain", ErrInvalidResponse)
+	}
+	der, _ := chain[0].([]byte)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("%w: attestation certificate: %v", ErrInvalidResponse, err)
+	}
+
+	key := &PublicKey{Algorithm: alg}
+	switch public := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if alg != AlgES256 {
+			return fmt.Errorf("%w: attestation algorithm %d does not match its certificate", ErrInvalidResponse, alg)
+		}
+		key.key = public
+	case *rsa.PublicKey:
+		if alg != AlgRS256 {
+			return fmt.Errorf("%w: attestation algorithm %d does not match its certificate", ErrInvalidResponse, alg)
+		}
+		key.key = public
+	case ed25519.PublicKey:
+		if alg != AlgEdDSA {
+			return fmt.Errorf("%w: attestation algorithm %d does not match its certificate", ErrInvalidResponse, alg)
+		}
+		key.key = public
+	default:
+		return fmt.Errorf("%w: unsupported attestation certificate key %T", ErrInvalidResponse, cert.PublicKey)
+	}
+	return key.Verify(signed, sig)
+}
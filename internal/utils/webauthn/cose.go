@@ -0,0 +1,133 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers accepted for credentials, in order of preference
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms are offered to authenticators when registering
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE_Key labels and values (RFC 9053)
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurve   = -1 // EC2, OKP
+	coseX       = -2 // EC2, OKP
+	coseY       = -3 // EC2
+	coseModulus = -1 // RSA
+	coseExpo    = -2 // RSA
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// minRSAKeyBits is the smallest RSA credential key accepted
+const minRSAKeyBits = 2048
+
+// ErrUnsupportedAlgorithm is returned for a credential key of an algorithm
+// that is not in SupportedAlgorithms
+var ErrUnsupportedAlgorithm = errors.New("webauthn: unsupported credential algorithm")
+
+// PublicKey is a parsed credential public key
+type PublicKey struct {
+	Algorithm int64
+	key       crypto.PublicKey
+}
+
+// ParsePublicKey parses a COSE_Key as stored for a credential
+func ParsePublicKey(cose []byte) (*PublicKey, error) {
+	item, rest, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("webauthn: trailing bytes after COSE key")
+	}
+	fields, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("webauthn: COSE key is not a map")
+	}
+	return publicKeyFromCOSE(fields)
+}
+
+func publicKeyFromCOSE(fields map[interface{}]interface{}) (*PublicKey, error) {
+	kty, _ := fields[int64(coseKeyType)].(int64)
+	alg, _ := fields[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case alg == AlgES256 && kty == coseKeyTypeEC2:
+		crv, _ := fields[int64(coseCurve)].(int64)
+		x, _ := fields[int64(coseX)].([]byte)
+		y, _ := fields[int64(coseY)].([]byte)
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("webauthn: invalid P-256 COSE key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("webauthn: P-256 point is not on the curve")
+		}
+		return &PublicKey{Algorithm: alg, key: key}, nil
+
+	case alg == AlgEdDSA && kty == coseKeyTypeOKP:
+		crv, _ := fields[int64(coseCurve)].(int64)
+		x, _ := fields[int64(coseX)].([]byte)
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("webauthn: invalid Ed25519 COSE key")
+		}
+		return &PublicKey{Algorithm: alg, key: ed25519.PublicKey(x)}, nil
+
+	case alg == AlgRS256 && kty == coseKeyTypeRSA:
+		n, _ := fields[int64(coseModulus)].([]byte)
+		e, _ := fields[int64(coseExpo)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("webauthn: invalid RSA COSE key")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSAKeyBits || key.E < 3 {
+			return nil, fmt.Errorf("webauthn: RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		return &PublicKey{Algorithm: alg, key: key}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: alg %d, kty %d", ErrUnsupportedAlgorithm, alg, kty)
+	}
+}
+
+// Verify checks sig over data
+func (k *PublicKey) Verify(data, sig []byte) error {
+	var valid bool
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, sig)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	}
+	if !valid {
+		return ErrBadSignature
+	}
+	return nil
+}
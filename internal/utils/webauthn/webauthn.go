@@ -0,0 +1,448 @@
+// Package webauthn implements the relying party side of WebAuthn: it builds
+// the options passed to navigator.credentials.create() and .get() and verifies
+// the credentials the browser returns. Attestation is not used to decide which
+// authenticators are trusted, so registration asks for none; storing the
+// credential and the challenge between the two steps of a ceremony is up to
+// the caller.
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// challengeBytes is the amount of randomness in a challenge
+const challengeBytes = 32
+
+// User verification requirements
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+var (
+	// ErrInvalidResponse is returned for a response that is malformed or not
+	// for this relying party
+	ErrInvalidResponse = errors.New("webauthn: invalid response")
+
+	// ErrChallengeMismatch is returned when a response answers another challenge
+	ErrChallengeMismatch = errors.New("webauthn: challenge mismatch")
+
+	// ErrUserNotVerified is returned when user verification was required but
+	// the authenticator did not perform it
+	ErrUserNotVerified = errors.New("webauthn: user not verified")
+
+	// ErrBadSignature is returned when an assertion or attestation signature
+	// does not verify
+	ErrBadSignature = errors.New("webauthn: bad signature")
+
+	// ErrCounterRegressed is returned when an authenticator's signature counter
+	// did not advance, which means the credential may have been cloned
+	ErrCounterRegressed = errors.New("webauthn: signature counter did not increase")
+)
+
+// Config identifies the relying party
+type Config struct {
+	RPID    string   // Domain credentials are scoped to, e.g. vyrall.com
+	RPName  string   // Shown by the authenticator
+	Origins []string // Exact origins allowed to run ceremonies, e.g. https://vyrall.com
+	Timeout time.Duration
+}
+
+// RelyingParty builds ceremony options and verifies their responses
+type RelyingParty struct {
+	config   Config
+	rpIDHash [32]byte
+	origins  map[string]bool
+}
+
+// New creates a relying party. Every origin must be the RP ID or a subdomain of it.
+func New(config Config) (*RelyingParty, error) {
+	if config.RPID == "" || len(config.Origins) == 0 {
+		return nil, errors.New("webauthn: an RP ID and at least one origin are required")
+	}
+
+	origins := make(map[string]bool, len(config.Origins))
+	for _, origin := range config.Origins {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("webauthn: invalid origin %q", origin)
+		}
+		host := parsed.Hostname()
+		if host != config.RPID && !strings.HasSuffix(host, "."+config.RPID) {
+			return nil, fmt.Errorf("webauthn: origin %q is not within RP ID %q", origin, config.RPID)
+		}
+		origins[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return &RelyingParty{
+		config:   config,
+		rpIDHash: sha256.Sum256([]byte(config.RPID)),
+		origins:  origins,
+	}, nil
+}
+
+// NewChallenge returns a random challenge, base64url encoded
+func NewChallenge() (string, error) {
+	b := make([]byte, challengeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// User is the account a credential is registered for
+type User struct {
+	Handle      []byte // Opaque and stable; returned as the user handle on login
+	Name        string
+	DisplayName string
+}
+
+// CredentialDescriptor names an existing credential
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// NewCredentialDescriptor describes a stored credential for allow and exclude lists
+func NewCredentialDescriptor(credentialID []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{
+		Type:       "public-key",
+		ID:         base64.RawURLEncoding.EncodeToString(credentialID),
+		Transports: transports,
+	}
+}
+
+// CreationOptions are the options for navigator.credentials.create(), in the
+// JSON form PublicKeyCredential.parseCreationOptionsFromJSON() accepts
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams []CredentialParameter `json:"pubKeyCredParams"`
+	Timeout          int64                 `json:"timeout"`
+	// Credentials the user already has, so the same authenticator is not registered twice
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey        string `json:"residentKey"`
+		RequireResidentKey bool   `json:"requireResidentKey"`
+		UserVerification   string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// CredentialParameter offers a credential algorithm
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// RequestOptions are the options for navigator.credentials.get(), in the JSON
+// form PublicKeyCredential.parseRequestOptionsFromJSON() accepts
+type RequestOptions struct {
+	Challenge string `json:"challenge"`
+	RPID      string `json:"rpId"`
+	Timeout   int64  `json:"timeout"`
+	// Empty for a passwordless login, so the authenticator offers its discoverable credentials
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationOptions builds registration options. Passkeys are discoverable
+// credentials, so the authenticator is asked to store one.
+func (rp *RelyingParty) RegistrationOptions(challenge string, user User, exclude []CredentialDescriptor) *CreationOptions {
+	options := &CreationOptions{
+		Challenge:          challenge,
+		Timeout:            rp.config.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		Attestation:        "none",
+	}
+	if options.ExcludeCredentials == nil {
+		options.ExcludeCredentials = []CredentialDescriptor{}
+	}
+	options.RP.ID = rp.config.RPID
+	options.RP.Name = rp.config.RPName
+	options.User.ID = base64.RawURLEncoding.EncodeToString(user.Handle)
+	options.User.Name = user.Name
+	options.User.DisplayName = user.DisplayName
+	for _, alg := range SupportedAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	options.AuthenticatorSelection.ResidentKey = "required"
+	options.AuthenticatorSelection.RequireResidentKey = true
+	options.AuthenticatorSelection.UserVerification = UserVerificationPreferred
+	return options
+}
+
+// LoginOptions builds login options. An empty allow list lets the user
+// pick any passkey they hold for this site.
+func (rp *RelyingParty) LoginOptions(challenge string, allow []CredentialDescriptor, userVerification string) *RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.config.RPID,
+		Timeout:          rp.config.Timeout.Milliseconds(),
+		AllowCredentials: allow,
+		UserVerification: userVerification,
+	}
+}
+
+// CredentialCreationResponse is the credential returned by
+// navigator.credentials.create(), as serialized by PublicKeyCredential.toJSON()
+type CredentialCreationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// CredentialAssertionResponse is the credential returned by
+// navigator.credentials.get(), as serialized by PublicKeyCredential.toJSON()
+type CredentialAssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CredentialID decodes the ID of the credential that signed the assertion
+func (r *CredentialAssertionResponse) CredentialID() ([]byte, error) {
+	return decodeBase64URL(r.ID)
+}
+
+// UserHandle decodes the user handle a discoverable credential returns
+func (r *CredentialAssertionResponse) UserHandle() ([]byte, error) {
+	return decodeBase64URL(r.Response.UserHandle)
+}
+
+// Challenge returns the challenge a response answers, so the caller can find
+// the ceremony it belongs to before verifying it
+func Challenge(clientDataJSON string) (string, error) {
+	clientData, _, err := parseClientData(clientDataJSON)
+	if err != nil {
+		return "", err
+	}
+	return clientData.Challenge, nil
+}
+
+// Credential is a newly registered credential
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte // Authenticator model, all zero for most passkey providers
+	Transports     []string
+	UserVerified   bool
+	BackupEligible bool // A synced passkey rather than one bound to a device
+	BackedUp       bool
+}
+
+// VerifyRegistration checks a registration response against the challenge
+// it was issued (WebAuthn §7.1) and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(challenge string, userVerification string, response *CredentialCreationResponse) (*Credential, error) {
+	if response.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type %q", ErrInvalidResponse, response.Type)
+	}
+
+	clientData, clientDataHash, err := parseClientData(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkClientData(clientData, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := decodeBase64URL(response.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestation object: %v", ErrInvalidResponse, err)
+	}
+	attestation, err := parseAttestationObject(rawAttestation)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := attestation.authData
+	if err := rp.checkAuthenticatorData(authData, userVerification); err != nil {
+		return nil, err
+	}
+	if !authData.has(FlagAttestedData) {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrInvalidResponse)
+	}
+
+	publicKey, err := ParsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := attestation.verify(publicKey, clientDataHash); err != nil {
+		return nil, err
+	}
+
+	// The ID the browser reports must be the one the authenticator attested
+	if id, err := decodeBase64URL(response.ID); err != nil || subtle.ConstantTimeCompare(id, authData.credentialID) != 1 {
+		return nil, fmt.Errorf("%w: credential ID does not match authenticator data", ErrInvalidResponse)
+	}
+
+	return &Credential{
+		ID:             append([]byte(nil), authData.credentialID...),
+		PublicKey:      append([]byte(nil), authData.publicKey...),
+		Algorithm:      publicKey.Algorithm,
+		SignCount:      authData.signCount,
+		AAGUID:         append([]byte(nil), authData.aaguid...),
+		Transports:     response.Response.Transports,
+		UserVerified:   authData.has(FlagUserVerified),
+		BackupEligible: authData.has(FlagBackupEligible),
+		BackedUp:       authData.has(FlagBackedUp),
+	}, nil
+}
+
+// Assertion is the result of a verified login
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackedUp     bool
+}
+
+// VerifyAssertion checks a login response against the challenge it was issued
+// and the stored credential it claims to come from (WebAuthn §7.2). The
+// caller must have found the credential by the response's ID and checked it
+// belongs to the user signing in.
+func (rp *RelyingParty) VerifyAssertion(challenge, userVerification string, publicKey []byte, signCount uint32, response *CredentialAssertionResponse) (*Assertion, error) {
+	if response.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type %q", ErrInvalidResponse, response.Type)
+	}
+
+	clientData, clientDataHash, err := parseClientData(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkClientData(clientData, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := decodeBase64URL(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: authenticator data: %v", ErrInvalidResponse, err)
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkAuthenticatorData(authData, userVerification); err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decodeBase64URL(response.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidResponse, err)
+	}
+	if err := key.Verify(concat(rawAuthData, clientDataHash), signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators without a counter always report zero; any other must count up
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return nil, ErrCounterRegressed
+	}
+
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.has(FlagUserVerified),
+		BackedUp:     authData.has(FlagBackedUp),
+	}, nil
+}
+
+// clientData is the collected client data (WebAuthn §5.8.1)
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func parseClientData(encoded string) (*clientData, []byte, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, nil, fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	hash := sha256.Sum256(raw)
+	return &data, hash[:], nil
+}
+
+func (rp *RelyingParty) checkClientData(data *clientData, ceremony, challenge string) error {
+	if data.Type != ceremony {
+		return fmt.Errorf("%w: client data type %q", ErrInvalidResponse, data.Type)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+	if !rp.origins[data.Origin] {
+		return fmt.Errorf("%w: origin %q is not allowed", ErrInvalidResponse, data.Origin)
+	}
+	if data.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremonies are not allowed", ErrInvalidResponse)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) checkAuthenticatorData(data *authenticatorData, userVerification string) error {
+	if subtle.ConstantTimeCompare(data.rpIDHash, rp.rpIDHash[:]) != 1 {
+		return fmt.Errorf("%w: credential is for another RP ID", ErrInvalidResponse)
+	}
+	if !data.has(FlagUserPresent) {
+		return fmt.Errorf("%w: user not present", ErrInvalidResponse)
+	}
+	if userVerification == UserVerificationRequired && !data.has(FlagUserVerified) {
+		return ErrUserNotVerified
+	}
+	if data.has(FlagBackedUp) && !data.has(FlagBackupEligible) {
+		return fmt.Errorf("%w: backed up credential is not backup eligible", ErrInvalidResponse)
+	}
+	return nil
+}
+
+// decodeBase64URL accepts base64url with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func concat(a, b []byte) []byte {
+	out := make([]byte, 0, len(a)+len(b))
+	return append(append(out, a...), b...)
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// AuthConfig configures the authentication service. An account whose
+// deletion is requested is erased once DeletionGracePeriod has passed,
+// unless the user logs in again before then. Users younger than MinimumAge
+// cannot register, and those younger than ParentalConsentAge need a parent
+// or guardian to approve their account by email within ParentalConsentTTL.
+type AuthConfig struct {
+	AppURL              string        `yaml:"app_url"`
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period"`
+	MinimumAge          int           `yaml:"minimum_age"`
+	ParentalConsentAge  int           `yaml:"parental_consent_age"` // No parental consent when 0
+	ParentalConsentTTL  time.Duration `yaml:"parental_consent_ttl"`
+}
+
+func defaultAuth() AuthConfig {
+	return AuthConfig{
+		AppURL:              "http://localhost:3000",
+		DeletionGracePeriod: 30 * 24 * time.Hour,
+		MinimumAge:          13,
+		ParentalConsentTTL:  7 * 24 * time.Hour,
+	}
+}
@@ -0,0 +1,14 @@
+package config
+
+// AWSConfig configures AWS access for media storage and email
+type AWSConfig struct {
+	Region          string `yaml:"region"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key" secret:"true"`
+	S3Bucket        string `yaml:"s3_bucket"`
+	CDNBaseURL      string `yaml:"cdn_base_url"`
+}
+
+func defaultAWS() AWSConfig {
+	return AWSConfig{Region: "us-east-1"}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of every environment variable that overrides a setting
+const EnvPrefix = "VYRALL_"
+
+// baseFiles are loaded in order from the config directory before the environment file
+var baseFiles = []string{"app.yaml", "logging.yaml", "mongodb.yaml", "redis.yaml"}
+
+// Config is the application configuration
+type Config struct {
+	Environment string `yaml:"environment"`
+	AppName     string `yaml:"app_name"`
+
+	Server        ServerConfig        `yaml:"server"`
+	MongoDB       MongoDBConfig       `yaml:"mongodb"`
+	Redis         RedisConfig         `yaml:"redis"`
+	Logging       LoggingConfig       `yaml:"logging"`
+	JWT           JWTConfig           `yaml:"jwt"`
+	OAuth         OAuthConfig         `yaml:"oauth"`
+	Auth          AuthConfig          `yaml:"auth"`
+	Password      PasswordConfig      `yaml:"password"`
+	Passwordless  PasswordlessConfig  `yaml:"passwordless"`
+	Session       SessionConfig       `yaml:"session"`
+	TwoFactor     TwoFactorConfig     `yaml:"two_factor"`
+	WebAuthn      WebAuthnConfig      `yaml:"webauthn"`
+	CSRF          CSRFConfig          `yaml:"csrf"`
+	LoginGuard    LoginGuardConfig    `yaml:"login_guard"`
+	LoginRisk     LoginRiskConfig     `yaml:"login_risk"`
+	OAuthServer   OAuthServerConfig   `yaml:"oauth_server"`
+	PersonalToken PersonalTokenConfig `yaml:"personal_token"`
+	Email         EmailConfig         `yaml:"email"`
+	AWS           AWSConfig           `yaml:"aws"`
+	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch"`
+	WebSocket     WebSocketConfig     `yaml:"websocket"`
+	Comment       CommentConfig       `yaml:"comment"`
+	Event         EventConfig         `yaml:"event"`
+	Timeline      TimelineConfig      `yaml:"timeline"`
+	Analytics     AnalyticsConfig     `yaml:"analytics"`
+
+	EnableCompression bool `yaml:"enable_compression"`
+	EnableMetrics     bool `yaml:"enable_metrics"`
+	ServeStaticFiles  bool `yaml:"serve_static_files"`
+	EnableSwagger     bool `yaml:"enable_swagger"`
+}
+
+// ServerConfig configures the HTTP server. The client address of a request
+// is read from X-Forwarded-For only when the request comes from one of
+// TrustedProxies; otherwise it is the address of the connection, so clients
+// cannot choose the address that login throttling and allowlists see.
+type ServerConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	TrustedProxies  []string      `yaml:"trusted_proxies"` // IP addresses or CIDR ranges of reverse proxies
+}
+
+// LoggingConfig configures the application logger
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// AnalyticsConfig configures analytics processing
+type AnalyticsConfig struct {
+	RealTimeProcessing bool `yaml:"real_time_processing"`
+}
+
+// CommentConfig configures comment listing and limits
+type CommentConfig struct {
+	DefaultPageSize  int `yaml:"default_page_size"`
+	MaxPageSize      int `yaml:"max_page_size"`
+	MaxCommentLength int `yaml:"max_comment_length"`
+}
+
+// EventConfig configures event listing
+type EventConfig struct {
+	DefaultPageSize int `yaml:"default_page_size"`
+	MaxPageSize     int `yaml:"max_page_size"`
+}
+
+// TimelineConfig configures home timelines. Posts by authors with fewer
+// than CelebrityFollowers followers are written into each follower's cached
+// timeline when published; posts by bigger accounts are read and merged in
+// when a timeline is read. A cached timeline keeps the newest MaxSize posts
+// and is dropped after IdleTTL without a read, to be rebuilt on the next.
+type TimelineConfig struct {
+	CelebrityFollowers int           `yaml:"celebrity_followers"`
+	MaxSize            int           `yaml:"max_size"`
+	IdleTTL            time.Duration `yaml:"idle_ttl"`
+}
+
+// Default returns the built-in configuration that files and environment variables override
+func Default() *Config {
+	return &Config{
+		Environment: EnvDevelopment,
+		AppName:     "vyrall",
+		Server: ServerConfig{
+			Host:            "0.0.0.0",
+			Port:            8080,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		MongoDB:       defaultMongoDB(),
+		Redis:         defaultRedis(),
+		Logging:       LoggingConfig{Level: "info", Format: "json"},
+		JWT:           defaultJWT(),
+		Auth:          defaultAuth(),
+		Password:      defaultPassword(),
+		Passwordless:  defaultPasswordless(),
+		Session:       defaultSession(),
+		TwoFactor:     TwoFactorConfig{Issuer: "Vyrall"},
+		WebAuthn:      defaultWebAuthn(),
+		CSRF:          defaultCSRF(),
+		LoginGuard:    defaultLoginGuard(),
+		LoginRisk:     defaultLoginRisk(),
+		OAuthServer:   defaultOAuthServer(),
+		PersonalToken: defaultPersonalToken(),
+		Email:         defaultEmail(),
+		AWS:           defaultAWS(),
+		Elasticsearch: defaultElasticsearch(),
+		WebSocket:     defaultWebSocket(),
+		Comment:       CommentConfig{DefaultPageSize: 20, MaxPageSize: 100, MaxCommentLength: 2000},
+		Event:         EventConfig{DefaultPageSize: 20, MaxPageSize: 100},
+		Timeline:      TimelineConfig{CelebrityFollowers: 10000, MaxSize: 500, IdleTTL: 7 * 24 * time.Hour},
+		Analytics:     AnalyticsConfig{RealTimeProcessing: true},
+
+		EnableCompression: true,
+	}
+}
+
+// Load reads the configuration from the directory named by VYRALL_CONFIG_DIR
+// (default "configs") for the environment named by VYRALL_ENVIRONMENT
+func Load() (*Config, error) {
+	dir := os.Getenv(EnvPrefix + "CONFIG_DIR")
+	if dir == "" {
+		dir = "configs"
+	}
+	return LoadFrom(dir, os.Getenv(EnvPrefix+"ENVIRONMENT"))
+}
+
+// LoadFrom builds the configuration in layers: built-in defaults, the base files
+// in dir, dir/<environment>.yaml, and finally VYRALL_* environment variables.
+// The result is validated before it is returned.
+func LoadFrom(dir, environment string) (*Config, error) {
+	cfg := Default()
+
+	for _, name := range baseFiles {
+		if err := mergeFile(cfg, filepath.Join(dir, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The environment may come from the base files when it is not set explicitly
+	if environment == "" {
+		environment = cfg.Environment
+	}
+	if err := mergeFile(cfg, filepath.Join(dir, environment+".yaml")); err != nil {
+		return nil, err
+	}
+	cfg.Environment = environment
+
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// mergeFile decodes a YAML file over cfg. Missing files are skipped; unknown keys are errors.
+func mergeFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	return nil
+}
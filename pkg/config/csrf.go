@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// CSRFConfig configures CSRF protection for clients authenticated by the
+// session cookie. Tokens are signed with a key derived from Secret, or from
+// the JWT secret when Secret is empty.
+type CSRFConfig struct {
+	Secret         string        `yaml:"secret" secret:"true"`
+	TrustedOrigins []string      `yaml:"trusted_origins"` // Origins allowed to send state-changing requests
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+	SecureCookie   bool          `yaml:"secure_cookie"`
+}
+
+func defaultCSRF() CSRFConfig {
+	return CSRFConfig{
+		TrustedOrigins: []string{"http://localhost:3000"},
+		TokenTTL:       12 * time.Hour,
+	}
+}
+
+// SigningSecret is the secret CSRF token keys are derived from
+func (c CSRFConfig) SigningSecret(jwtSecret string) string {
+	if c.Secret != "" {
+		return c.Secret
+	}
+	return jwtSecret
+}
@@ -0,0 +1,17 @@
+package config
+
+// ElasticsearchConfig configures the search cluster
+type ElasticsearchConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Addresses   []string `yaml:"addresses"`
+	Username    string   `yaml:"username"`
+	Password    string   `yaml:"password" secret:"true"`
+	IndexPrefix string   `yaml:"index_prefix"`
+}
+
+func defaultElasticsearch() ElasticsearchConfig {
+	return ElasticsearchConfig{
+		Addresses:   []string{"http://localhost:9200"},
+		IndexPrefix: "vyrall",
+	}
+}
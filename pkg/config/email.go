@@ -0,0 +1,23 @@
+package config
+
+// EmailConfig configures outgoing email
+type EmailConfig struct {
+	Provider     string `yaml:"provider"` // smtp, ses, log
+	FromAddress  string `yaml:"from_address"`
+	FromName     string `yaml:"from_name"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" secret:"true"`
+	UseTLS       bool   `yaml:"use_tls"`
+}
+
+func defaultEmail() EmailConfig {
+	return EmailConfig{
+		Provider:    "log",
+		FromAddress: "no-reply@vyrall.local",
+		FromName:    "Vyrall",
+		SMTPPort:    587,
+		UseTLS:      true,
+	}
+}
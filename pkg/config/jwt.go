@@ -0,0 +1,36 @@
+package config
+
+import "time"
+
+// JWTConfig configures access and refresh token issuance. Access tokens are
+// signed with asymmetric keys that rotate every KeyRotationInterval and stay
+// published for KeyGracePeriod after they stop signing. Secret encrypts the
+// private keys stored in the database, so only the issuer needs it.
+type JWTConfig struct {
+	Secret                string        `yaml:"secret" secret:"true"`
+	Issuer                string        `yaml:"issuer"`
+	Audience              string        `yaml:"audience"`
+	Algorithm             string        `yaml:"algorithm"` // RS256 or EdDSA
+	ExpirationHours       int           `yaml:"expiration_hours"`
+	RefreshExpirationDays int           `yaml:"refresh_expiration_days"`
+	KeyRotationInterval   time.Duration `yaml:"key_rotation_interval"`
+	KeyGracePeriod        time.Duration `yaml:"key_grace_period"`
+}
+
+// Signing algorithms for access tokens
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+func defaultJWT() JWTConfig {
+	return JWTConfig{
+		Issuer:                "vyrall",
+		Audience:              "vyrall-api",
+		Algorithm:             JWTAlgorithmEdDSA,
+		ExpirationHours:       1,
+		RefreshExpirationDays: 30,
+		KeyRotationInterval:   30 * 24 * time.Hour,
+		KeyGracePeriod:        48 * time.Hour,
+	}
+}
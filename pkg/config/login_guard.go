@@ -0,0 +1,36 @@
+package config
+
+import "time"
+
+// LoginGuardConfig configures protection of password logins against guessing
+// and credential stuffing. Failures are counted over FailureWindow: an account
+// is slowed down after DelayAfter failures and locked after LockoutThreshold,
+// and logins from an IP address or subnet with many failures, or an IP address
+// failing against many accounts, must be confirmed with an emailed code.
+type LoginGuardConfig struct {
+	FailureWindow          time.Duration `yaml:"failure_window"`
+	DelayAfter             int           `yaml:"delay_after"`
+	BaseDelay              time.Duration `yaml:"base_delay"` // Doubles with each further failure
+	MaxDelay               time.Duration `yaml:"max_delay"`
+	LockoutThreshold       int           `yaml:"lockout_threshold"`
+	LockoutDuration        time.Duration `yaml:"lockout_duration"`
+	IPFailureThreshold     int           `yaml:"ip_failure_threshold"`
+	SubnetFailureThreshold int           `yaml:"subnet_failure_threshold"`
+	IPAccountThreshold     int           `yaml:"ip_account_threshold"` // Distinct accounts one IP address may fail against
+	StepUpCodeTTL          time.Duration `yaml:"step_up_code_ttl"`
+}
+
+func defaultLoginGuard() LoginGuardConfig {
+	return LoginGuardConfig{
+		FailureWindow:          time.Hour,
+		DelayAfter:             3,
+		BaseDelay:              time.Second,
+		MaxDelay:               time.Minute,
+		LockoutThreshold:       10,
+		LockoutDuration:        15 * time.Minute,
+		IPFailureThreshold:     20,
+		SubnetFailureThreshold: 50,
+		IPAccountThreshold:     5,
+		StepUpCodeTTL:          10 * time.Minute,
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+// LoginRiskConfig configures the alerts sent when a login does not match the
+// account's history: a device not seen before, a new country, or a login too
+// far from the previous one to have travelled in between at MaxTravelSpeed.
+// Logins closer than MinTravelDistance never count as impossible travel,
+// since address locations are approximate. The alert links to RevokeURL, the
+// web client's page that signs the session out, with a token valid for
+// RevokeLinkTTL.
+type LoginRiskConfig struct {
+	GeoIPDatabase     string        `yaml:"geoip_database"`      // Local range file, see package geoip; no location checks when empty
+	MaxTravelSpeed    float64       `yaml:"max_travel_speed"`    // km/h
+	MinTravelDistance float64       `yaml:"min_travel_distance"` // km
+	HistoryRetention  time.Duration `yaml:"history_retention"`
+	RevokeURL         string        `yaml:"revoke_url"`
+	RevokeLinkTTL     time.Duration `yaml:"revoke_link_ttl"`
+}
+
+func defaultLoginRisk() LoginRiskConfig {
+	return LoginRiskConfig{
+		MaxTravelSpeed:    1000,
+		MinTravelDistance: 500,
+		HistoryRetention:  180 * 24 * time.Hour,
+		RevokeURL:         "http://localhost:3000/security/revoke-session",
+		RevokeLinkTTL:     7 * 24 * time.Hour,
+	}
+}
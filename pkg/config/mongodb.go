@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// MongoDBConfig configures the MongoDB connection
+type MongoDBConfig struct {
+	URI            string        `yaml:"uri" secret:"uri"`
+	Database       string        `yaml:"database"`
+	MaxPoolSize    int           `yaml:"max_pool_size"`
+	MinPoolSize    int           `yaml:"min_pool_size"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	AutoMigrate    bool          `yaml:"auto_migrate"`
+}
+
+func defaultMongoDB() MongoDBConfig {
+	return MongoDBConfig{
+		URI:            "mongodb://localhost:27017",
+		Database:       "vyrall",
+		MaxPoolSize:    100,
+		MinPoolSize:    0,
+		ConnectTimeout: 10 * time.Second,
+	}
+}
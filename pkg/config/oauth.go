@@ -0,0 +1,21 @@
+package config
+
+// OAuthConfig configures third-party login providers
+type OAuthConfig struct {
+	Google   OAuthProviderConfig `yaml:"google"`
+	GitHub   OAuthProviderConfig `yaml:"github"`
+	Facebook OAuthProviderConfig `yaml:"facebook"`
+}
+
+// OAuthProviderConfig holds the client credentials for one OAuth provider.
+// A provider is enabled when both the client ID and secret are set.
+type OAuthProviderConfig struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret" secret:"true"`
+	RedirectURL  string `yaml:"redirect_url"`
+}
+
+// Enabled reports whether the provider has credentials
+func (p OAuthProviderConfig) Enabled() bool {
+	return p.ClientID != "" && p.ClientSecret != ""
+}
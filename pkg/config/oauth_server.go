@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// OAuthServerConfig configures the authorization server third-party apps use
+// to act for users. IssuerURL is the public base URL of the API; it is the
+// issuer of ID tokens and the base of the endpoints in the discovery document.
+// The authorization endpoint sends users to ConsentURL, the web client's
+// consent screen, with the request's query string.
+type OAuthServerConfig struct {
+	IssuerURL       string        `yaml:"issuer_url"`
+	ConsentURL      string        `yaml:"consent_url"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"` // Extended each time the refresh token is used
+	CodeTTL         time.Duration `yaml:"code_ttl"`
+	MaxAppsPerUser  int           `yaml:"max_apps_per_user"`
+}
+
+func defaultOAuthServer() OAuthServerConfig {
+	return OAuthServerConfig{
+		IssuerURL:       "http://localhost:8080",
+		ConsentURL:      "http://localhost:3000/oauth/consent",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+		CodeTTL:         5 * time.Minute,
+		MaxAppsPerUser:  10,
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+// PasswordConfig configures password rules and resets. New passwords seen
+// at least BreachedMinCount times in the breached-password corpus are
+// refused, and with BreachedOnLogin a login with such a password is refused
+// until the password is reset.
+type PasswordConfig struct {
+	MinLength             int    `yaml:"min_length"`
+	RequireUppercase      bool   `yaml:"require_uppercase"`
+	RequireLowercase      bool   `yaml:"require_lowercase"`
+	RequireNumbers        bool   `yaml:"require_numbers"`
+	RequireSpecial        bool   `yaml:"require_special"`
+	ResetBaseURL          string `yaml:"reset_base_url"`
+	ResetTokenExpiryHours int    `yaml:"reset_token_expiry_hours"`
+	BreachedCorpus        string `yaml:"breached_corpus"` // Directory of Pwned Passwords range files; no screening when empty
+	BreachedMinCount      int    `yaml:"breached_min_count"`
+	BreachedOnLogin       bool   `yaml:"breached_on_login"`
+}
+
+func defaultPassword() PasswordConfig {
+	return PasswordConfig{
+		MinLength:             8,
+		RequireUppercase:      true,
+		RequireLowercase:      true,
+		RequireNumbers:        true,
+		ResetBaseURL:          "http://localhost:3000/reset-password",
+		ResetTokenExpiryHours: 1,
+		BreachedMinCount:      1,
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// PasswordlessConfig configures login with an emailed link or code instead of
+// a password. The link opens LinkURL, the web client's page that completes
+// the login, and the link and code expire after CodeTTL. An account may ask
+// for MaxRequests logins per RequestWindow, at least ResendDelay apart.
+type PasswordlessConfig struct {
+	LinkURL       string        `yaml:"link_url"`
+	CodeTTL       time.Duration `yaml:"code_ttl"`
+	MaxRequests   int           `yaml:"max_requests"`
+	RequestWindow time.Duration `yaml:"request_window"`
+	ResendDelay   time.Duration `yaml:"resend_delay"`
+}
+
+func defaultPasswordless() PasswordlessConfig {
+	return PasswordlessConfig{
+		LinkURL:       "http://localhost:3000/login/email",
+		CodeTTL:       15 * time.Minute,
+		MaxRequests:   5,
+		RequestWindow: time.Hour,
+		ResendDelay:   time.Minute,
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// PersonalTokenConfig configures personal access tokens. Tokens expire after
+// at most MaxLifetime, DefaultLifetime when none is asked for. A token's
+// last-used time is written at most once per LastUsedInterval, while its
+// per-day usage is counted on every request and kept for UsageRetention.
+type PersonalTokenConfig struct {
+	MaxPerUser       int           `yaml:"max_per_user"` // Active tokens
+	DefaultLifetime  time.Duration `yaml:"default_lifetime"`
+	MaxLifetime      time.Duration `yaml:"max_lifetime"`
+	LastUsedInterval time.Duration `yaml:"last_used_interval"`
+	UsageRetention   time.Duration `yaml:"usage_retention"`
+}
+
+func defaultPersonalToken() PersonalTokenConfig {
+	return PersonalTokenConfig{
+		MaxPerUser:       20,
+		DefaultLifetime:  90 * 24 * time.Hour,
+		MaxLifetime:      365 * 24 * time.Hour,
+		LastUsedInterval: time.Minute,
+		UsageRetention:   90 * 24 * time.Hour,
+	}
+}
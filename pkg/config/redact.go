@@ -0,0 +1,91 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RedactedValue is the placeholder shown in place of secret values
+const RedactedValue = "[REDACTED]"
+
+// Redacted returns the configuration as nested maps keyed by YAML name with
+// secrets replaced. Fields tagged secret:"true" are hidden entirely and fields
+// tagged secret:"uri" keep the URL but lose the password.
+func (c *Config) Redacted() map[string]interface{} {
+	return redactStruct(reflect.ValueOf(c).Elem())
+}
+
+// Section returns one top-level section of the redacted configuration
+func (c *Config) Section(name string) (map[string]interface{}, bool) {
+	section, ok := c.Redacted()[name].(map[string]interface{})
+	return section, ok
+}
+
+// Value returns a redacted setting by its dotted YAML path, such as "server.port"
+func (c *Config) Value(key string) (interface{}, bool) {
+	var current interface{} = c.Redacted()
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func redactStruct(value reflect.Value) map[string]interface{} {
+	t := value.Type()
+	out := make(map[string]interface{}, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		if name == "" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			out[name] = redactStruct(fieldValue)
+		case field.Tag.Get("secret") == "true":
+			if fieldValue.IsZero() {
+				out[name] = ""
+			} else {
+				out[name] = RedactedValue
+			}
+		case field.Tag.Get("secret") == "uri":
+			out[name] = redactURI(fieldValue.String())
+		case field.Type == durationType:
+			out[name] = time.Duration(fieldValue.Int()).String()
+		default:
+			out[name] = fieldValue.Interface()
+		}
+	}
+
+	return out
+}
+
+// redactURI hides the password in a connection string. Unparseable values are
+// hidden completely since they may still contain credentials.
+func redactURI(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return RedactedValue
+	}
+	if parsed.User == nil {
+		return raw
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), "xxxxx")
+	}
+	return strings.Replace(parsed.String(), "xxxxx", RedactedValue, 1)
+}
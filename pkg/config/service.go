@@ -0,0 +1,60 @@
+package config
+
+import "fmt"
+
+// ErrReadOnly is returned when something tries to change the configuration at runtime
+var ErrReadOnly = fmt.Errorf("configuration is read-only; change the config files or %s* environment variables and restart", EnvPrefix)
+
+// Service exposes the loaded configuration to the admin API. It only ever
+// returns redacted values and the configuration cannot be changed at runtime.
+type Service struct {
+	cfg *Config
+}
+
+// NewService creates a read-only configuration service
+func NewService(cfg *Config) *Service {
+	return &Service{cfg: cfg}
+}
+
+// GetAllConfigurations returns the full redacted configuration
+func (s *Service) GetAllConfigurations() (map[string]interface{}, error) {
+	return s.cfg.Redacted(), nil
+}
+
+// GetConfigurationsByCategory returns one redacted top-level section
+func (s *Service) GetConfigurationsByCategory(category string) (map[string]interface{}, error) {
+	section, ok := s.cfg.Section(category)
+	if !ok {
+		return nil, fmt.Errorf("unknown configuration category %q", category)
+	}
+	return section, nil
+}
+
+// GetConfigurationValue returns one redacted setting by dotted key
+func (s *Service) GetConfigurationValue(key string) (interface{}, error) {
+	value, ok := s.cfg.Value(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown configuration key %q", key)
+	}
+	return value, nil
+}
+
+// UpdateConfiguration always fails because configuration is loaded at startup
+func (s *Service) UpdateConfiguration(key string, value interface{}) error {
+	return ErrReadOnly
+}
+
+// ResetConfigurationToDefault always fails because configuration is loaded at startup
+func (s *Service) ResetConfigurationToDefault(key string) error {
+	return ErrReadOnly
+}
+
+// ValidateConfiguration always fails because configuration is loaded at startup
+func (s *Service) ValidateConfiguration(key string, value interface{}) (bool, error) {
+	return false, ErrReadOnly
+}
+
+// GetConfigurationHistory returns no history since configuration never changes at runtime
+func (s *Service) GetConfigurationHistory(key string, limit int) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{}, nil
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// SessionConfig configures login sessions. Sessions staff open to see the
+// app as a user have no refresh token and end after ImpersonationTTL.
+type SessionConfig struct {
+	MaxActiveSessions int           `yaml:"max_active_sessions"`
+	ImpersonationTTL  time.Duration `yaml:"impersonation_ttl"`
+}
+
+func defaultSession() SessionConfig {
+	return SessionConfig{
+		MaxActiveSessions: 10,
+		ImpersonationTTL:  30 * time.Minute,
+	}
+}
@@ -0,0 +1,6 @@
+package config
+
+// TwoFactorConfig configures TOTP two-factor authentication
+type TwoFactorConfig struct {
+	Issuer string `yaml:"issuer"`
+}
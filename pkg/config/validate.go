@@ -0,0 +1,348 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// minProductionSecretLength is the shortest JWT secret accepted in production
+	minProductionSecretLength = 32
+
+	// minKeyRotationInterval is the shortest signing key lifetime, which leaves
+	// room to publish the next key before it starts signing
+	minKeyRotationInterval = 24 * time.Hour
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validator collects problems keyed by the YAML path of the offending setting
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, option := range allowed {
+		if value == option {
+			return
+		}
+	}
+	v.add(path, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) between(path string, value, min, max int) {
+	if value < min || value > max {
+		v.add(path, "must be between %d and %d, got %d", min, max, value)
+	}
+}
+
+func (v *validator) positive(path string, value time.Duration) {
+	if value <= 0 {
+		v.add(path, "must be a positive duration, got %s", value)
+	}
+}
+
+func (v *validator) url(path, value string, schemes ...string) {
+	if value == "" {
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		v.add(path, "must be an absolute URL, got %q", value)
+		return
+	}
+	if len(schemes) > 0 {
+		v.oneOf(path+" scheme", parsed.Scheme, schemes...)
+	}
+}
+
+func (v *validator) hostPort(path, value string) {
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		v.add(path, "must be host:port, got %q", value)
+	}
+}
+
+func (v *validator) ipOrCIDR(path, value string) {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return
+	}
+	if net.ParseIP(value) == nil {
+		v.add(path, "must be an IP address or CIDR range, got %q", value)
+	}
+}
+
+// Validate checks required settings and ranges and reports every problem at once
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("environment", c.Environment, EnvDevelopment, EnvStaging, EnvProduction, EnvTest)
+
+	// Server
+	v.between("server.port", c.Server.Port, 1, 65535)
+	v.positive("server.read_timeout", c.Server.ReadTimeout)
+	v.positive("server.write_timeout", c.Server.WriteTimeout)
+	v.positive("server.idle_timeout", c.Server.IdleTimeout)
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	for i, proxy := range c.Server.TrustedProxies {
+		v.ipOrCIDR(fmt.Sprintf("server.trusted_proxies[%d]", i), proxy)
+	}
+
+	// Databases
+	v.required("mongodb.uri", c.MongoDB.URI)
+	if c.MongoDB.URI != "" && !strings.HasPrefix(c.MongoDB.URI, "mongodb://") && !strings.HasPrefix(c.MongoDB.URI, "mongodb+srv://") {
+		v.add("mongodb.uri", "must start with mongodb:// or mongodb+srv://")
+	}
+	v.required("mongodb.database", c.MongoDB.Database)
+	v.between("mongodb.max_pool_size", c.MongoDB.MaxPoolSize, 1, 10000)
+	if c.MongoDB.MinPoolSize < 0 || c.MongoDB.MinPoolSize > c.MongoDB.MaxPoolSize {
+		v.add("mongodb.min_pool_size", "must be between 0 and mongodb.max_pool_size (%d), got %d", c.MongoDB.MaxPoolSize, c.MongoDB.MinPoolSize)
+	}
+	v.positive("mongodb.connect_timeout", c.MongoDB.ConnectTimeout)
+
+	v.hostPort("redis.addr", c.Redis.Addr)
+	v.between("redis.db", c.Redis.DB, 0, 15)
+	v.between("redis.pool_size", c.Redis.PoolSize, 1, 10000)
+
+	// Logging
+	v.oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+	v.oneOf("logging.format", c.Logging.Format, "json", "console")
+
+	// Authentication
+	v.required("jwt.secret", c.JWT.Secret)
+	if c.IsProduction() && c.JWT.Secret != "" && len(c.JWT.Secret) < minProductionSecretLength {
+		v.add("jwt.secret", "must be at least %d characters in production", minProductionSecretLength)
+	}
+	v.required("jwt.issuer", c.JWT.Issuer)
+	v.required("jwt.audience", c.JWT.Audience)
+	v.oneOf("jwt.algorithm", c.JWT.Algorithm, JWTAlgorithmRS256, JWTAlgorithmEdDSA)
+	v.between("jwt.expiration_hours", c.JWT.ExpirationHours, 1, 24*7)
+	v.between("jwt.refresh_expiration_days", c.JWT.RefreshExpirationDays, 1, 365)
+	if c.JWT.KeyRotationInterval < minKeyRotationInterval {
+		v.add("jwt.key_rotation_interval", "must be at least %s, got %s", minKeyRotationInterval, c.JWT.KeyRotationInterval)
+	}
+	// A retired key must stay published until every token it signed has expired
+	if tokenLifetime := time.Duration(c.JWT.ExpirationHours) * time.Hour; c.JWT.KeyGracePeriod < tokenLifetime {
+		v.add("jwt.key_grace_period", "must be at least the token lifetime (%s), got %s", tokenLifetime, c.JWT.KeyGracePeriod)
+	}
+
+	for name, provider := range map[string]OAuthProviderConfig{
+		"google":   c.OAuth.Google,
+		"github":   c.OAuth.GitHub,
+		"facebook": c.OAuth.Facebook,
+	} {
+		path := "oauth." + name
+		if provider.ClientID == "" && provider.ClientSecret == "" {
+			continue
+		}
+		v.required(path+".client_id", provider.ClientID)
+		v.required(path+".client_secret", provider.ClientSecret)
+		v.required(path+".redirect_url", provider.RedirectURL)
+		v.url(path+".redirect_url", provider.RedirectURL, "http", "https")
+	}
+
+	v.required("auth.app_url", c.Auth.AppURL)
+	v.url("auth.app_url", c.Auth.AppURL, "http", "https")
+	if c.Auth.DeletionGracePeriod < 24*time.Hour || c.Auth.DeletionGracePeriod > 90*24*time.Hour {
+		v.add("auth.deletion_grace_period", "must be between 24h and 2160h, got %s", c.Auth.DeletionGracePeriod)
+	}
+	v.between("auth.minimum_age", c.Auth.MinimumAge, 13, 21)
+	if c.Auth.ParentalConsentAge != 0 {
+		v.between("auth.parental_consent_age", c.Auth.ParentalConsentAge, c.Auth.MinimumAge+1, 21)
+		if c.Auth.ParentalConsentTTL < time.Hour || c.Auth.ParentalConsentTTL > 30*24*time.Hour {
+			v.add("auth.parental_consent_ttl", "must be between 1h and 720h, got %s", c.Auth.ParentalConsentTTL)
+		}
+	}
+	v.between("password.min_length", c.Password.MinLength, 8, 128)
+	v.url("password.reset_base_url", c.Password.ResetBaseURL, "http", "https")
+	v.between("password.reset_token_expiry_hours", c.Password.ResetTokenExpiryHours, 1, 72)
+	v.between("password.breached_min_count", c.Password.BreachedMinCount, 1, 1000000)
+	if c.Password.BreachedOnLogin && c.Password.BreachedCorpus == "" {
+		v.add("password.breached_on_login", "needs password.breached_corpus")
+	}
+	v.required("passwordless.link_url", c.Passwordless.LinkURL)
+	v.url("passwordless.link_url", c.Passwordless.LinkURL, "http", "https")
+	if c.Passwordless.CodeTTL < time.Minute || c.Passwordless.CodeTTL > time.Hour {
+		v.add("passwordless.code_ttl", "must be between 1m and 1h, got %s", c.Passwordless.CodeTTL)
+	}
+	v.between("passwordless.max_requests", c.Passwordless.MaxRequests, 1, 100)
+	v.positive("passwordless.request_window", c.Passwordless.RequestWindow)
+	if c.Passwordless.ResendDelay < 0 || c.Passwordless.ResendDelay > c.Passwordless.RequestWindow {
+		v.add("passwordless.resend_delay", "must be between 0 and passwordless.request_window (%s), got %s", c.Passwordless.RequestWindow, c.Passwordless.ResendDelay)
+	}
+	v.between("session.max_active_sessions", c.Session.MaxActiveSessions, 1, 1000)
+	if c.Session.ImpersonationTTL < time.Minute || c.Session.ImpersonationTTL > 4*time.Hour {
+		v.add("session.impersonation_ttl", "must be between 1m and 4h, got %s", c.Session.ImpersonationTTL)
+	}
+	v.required("two_factor.issuer", c.TwoFactor.Issuer)
+	v.required("webauthn.rp_id", c.WebAuthn.RPID)
+	v.required("webauthn.rp_name", c.WebAuthn.RPName)
+	if len(c.WebAuthn.Origins) == 0 {
+		v.add("webauthn.origins", "must list at least one origin")
+	}
+	for i, origin := range c.WebAuthn.Origins {
+		path := fmt.Sprintf("webauthn.origins[%d]", i)
+		v.url(path, origin, "http", "https")
+		// Browsers only run ceremonies for origins within the RP ID
+		if parsed, err := url.Parse(origin); err == nil && parsed.Host != "" {
+			host := parsed.Hostname()
+			if host != c.WebAuthn.RPID && !strings.HasSuffix(host, "."+c.WebAuthn.RPID) {
+				v.add(path, "must be webauthn.rp_id (%s) or a subdomain of it, got %q", c.WebAuthn.RPID, origin)
+			}
+			if c.IsProduction() && parsed.Scheme != "https" {
+				v.add(path, "must use https in production")
+			}
+		}
+	}
+	if c.WebAuthn.Timeout < 30*time.Second || c.WebAuthn.Timeout > 10*time.Minute {
+		v.add("webauthn.timeout", "must be between 30s and 10m, got %s", c.WebAuthn.Timeout)
+	}
+	if len(c.CSRF.TrustedOrigins) == 0 {
+		v.add("csrf.trusted_origins", "must list at least one origin")
+	}
+	for i, origin := range c.CSRF.TrustedOrigins {
+		path := fmt.Sprintf("csrf.trusted_origins[%d]", i)
+		v.url(path, origin, "http", "https")
+		if parsed, err := url.Parse(origin); err == nil && parsed.Host != "" {
+			// Browsers send the bare origin, so a path would never match
+			if strings.TrimSuffix(parsed.Path, "/") != "" {
+				v.add(path, "must be an origin without a path, got %q", origin)
+			}
+			if c.IsProduction() && parsed.Scheme != "https" {
+				v.add(path, "must use https in production")
+			}
+		}
+	}
+	if c.CSRF.TokenTTL < time.Hour || c.CSRF.TokenTTL > 30*24*time.Hour {
+		v.add("csrf.token_ttl", "must be between 1h and 720h, got %s", c.CSRF.TokenTTL)
+	}
+	if c.IsProduction() && c.CSRF.Secret != "" && len(c.CSRF.Secret) < minProductionSecretLength {
+		v.add("csrf.secret", "must be at least %d characters in production", minProductionSecretLength)
+	}
+	if c.IsProduction() && !c.CSRF.SecureCookie {
+		v.add("csrf.secure_cookie", "must be true in production")
+	}
+	v.positive("login_guard.failure_window", c.LoginGuard.FailureWindow)
+	v.between("login_guard.lockout_threshold", c.LoginGuard.LockoutThreshold, 3, 100)
+	v.between("login_guard.delay_after", c.LoginGuard.DelayAfter, 1, c.LoginGuard.LockoutThreshold)
+	v.positive("login_guard.base_delay", c.LoginGuard.BaseDelay)
+	if c.LoginGuard.MaxDelay < c.LoginGuard.BaseDelay {
+		v.add("login_guard.max_delay", "must be at least login_guard.base_delay (%s), got %s", c.LoginGuard.BaseDelay, c.LoginGuard.MaxDelay)
+	}
+	if c.LoginGuard.LockoutDuration < time.Minute || c.LoginGuard.LockoutDuration > 24*time.Hour {
+		v.add("login_guard.lockout_duration", "must be between 1m and 24h, got %s", c.LoginGuard.LockoutDuration)
+	}
+	v.between("login_guard.ip_failure_threshold", c.LoginGuard.IPFailureThreshold, 1, 100000)
+	v.between("login_guard.subnet_failure_threshold", c.LoginGuard.SubnetFailureThreshold, c.LoginGuard.IPFailureThreshold, 100000)
+	v.between("login_guard.ip_account_threshold", c.LoginGuard.IPAccountThreshold, 2, 1000)
+	if c.LoginGuard.StepUpCodeTTL < time.Minute || c.LoginGuard.StepUpCodeTTL > time.Hour {
+		v.add("login_guard.step_up_code_ttl", "must be between 1m and 1h, got %s", c.LoginGuard.StepUpCodeTTL)
+	}
+	if c.LoginRisk.MaxTravelSpeed <= 0 {
+		v.add("login_risk.max_travel_speed", "must be positive, got %g", c.LoginRisk.MaxTravelSpeed)
+	}
+	if c.LoginRisk.MinTravelDistance < 0 {
+		v.add("login_risk.min_travel_distance", "must not be negative, got %g", c.LoginRisk.MinTravelDistance)
+	}
+	if c.LoginRisk.HistoryRetention < 24*time.Hour {
+		v.add("login_risk.history_retention", "must be at least 24h, got %s", c.LoginRisk.HistoryRetention)
+	}
+	v.required("login_risk.revoke_url", c.LoginRisk.RevokeURL)
+	v.url("login_risk.revoke_url", c.LoginRisk.RevokeURL, "http", "https")
+	v.positive("login_risk.revoke_link_ttl", c.LoginRisk.RevokeLinkTTL)
+	v.required("oauth_server.issuer_url", c.OAuthServer.IssuerURL)
+	v.url("oauth_server.issuer_url", c.OAuthServer.IssuerURL, "http", "https")
+	v.required("oauth_server.consent_url", c.OAuthServer.ConsentURL)
+	v.url("oauth_server.consent_url", c.OAuthServer.ConsentURL, "http", "https")
+	if c.IsProduction() && !strings.HasPrefix(c.OAuthServer.IssuerURL, "https://") {
+		v.add("oauth_server.issuer_url", "must use https in production")
+	}
+	if c.OAuthServer.AccessTokenTTL < time.Minute || c.OAuthServer.AccessTokenTTL > 24*time.Hour {
+		v.add("oauth_server.access_token_ttl", "must be between 1m and 24h, got %s", c.OAuthServer.AccessTokenTTL)
+	}
+	if c.OAuthServer.RefreshTokenTTL < c.OAuthServer.AccessTokenTTL {
+		v.add("oauth_server.refresh_token_ttl", "must be at least oauth_server.access_token_ttl (%s), got %s", c.OAuthServer.AccessTokenTTL, c.OAuthServer.RefreshTokenTTL)
+	}
+	// RFC 6749 recommends codes live no longer than 10 minutes
+	if c.OAuthServer.CodeTTL < 30*time.Second || c.OAuthServer.CodeTTL > 10*time.Minute {
+		v.add("oauth_server.code_ttl", "must be between 30s and 10m, got %s", c.OAuthServer.CodeTTL)
+	}
+	v.between("oauth_server.max_apps_per_user", c.OAuthServer.MaxAppsPerUser, 1, 1000)
+	v.between("personal_token.max_per_user", c.PersonalToken.MaxPerUser, 1, 1000)
+	v.positive("personal_token.max_lifetime", c.PersonalToken.MaxLifetime)
+	if c.PersonalToken.DefaultLifetime <= 0 || c.PersonalToken.DefaultLifetime > c.PersonalToken.MaxLifetime {
+		v.add("personal_token.default_lifetime", "must be between 0 and personal_token.max_lifetime (%s), got %s", c.PersonalToken.MaxLifetime, c.PersonalToken.DefaultLifetime)
+	}
+	v.positive("personal_token.last_used_interval", c.PersonalToken.LastUsedInterval)
+	if c.PersonalToken.UsageRetention < 24*time.Hour {
+		v.add("personal_token.usage_retention", "must be at least 24h, got %s", c.PersonalToken.UsageRetention)
+	}
+
+	// Email
+	v.oneOf("email.provider", c.Email.Provider, "smtp", "ses", "log")
+	v.required("email.from_address", c.Email.FromAddress)
+	if c.Email.Provider == "smtp" {
+		v.required("email.smtp_host", c.Email.SMTPHost)
+		v.between("email.smtp_port", c.Email.SMTPPort, 1, 65535)
+	}
+	if c.Email.Provider == "ses" {
+		v.required("aws.region", c.AWS.Region)
+	}
+
+	if c.Elasticsearch.Enabled && len(c.Elasticsearch.Addresses) == 0 {
+		v.add("elasticsearch.addresses", "must list at least one address when elasticsearch is enabled")
+	}
+	for i, address := range c.Elasticsearch.Addresses {
+		v.url(fmt.Sprintf("elasticsearch.addresses[%d]", i), address, "http", "https")
+	}
+
+	// Websocket
+	if c.IsProduction() {
+		for _, origin := range c.WebSocket.AllowedOrigins {
+			if origin == "*" {
+				v.add("websocket.allowed_origins", "must not allow every origin in production")
+			}
+		}
+	}
+	v.between("websocket.read_buffer_size", c.WebSocket.ReadBufferSize, 256, 1<<20)
+	v.between("websocket.write_buffer_size", c.WebSocket.WriteBufferSize, 256, 1<<20)
+	if c.WebSocket.MaxMessageSize <= 0 {
+		v.add("websocket.max_message_size", "must be positive, got %d", c.WebSocket.MaxMessageSize)
+	}
+
+	// Content
+	v.between("comment.max_page_size", c.Comment.MaxPageSize, 1, 1000)
+	v.between("comment.default_page_size", c.Comment.DefaultPageSize, 1, c.Comment.MaxPageSize)
+	v.between("comment.max_comment_length", c.Comment.MaxCommentLength, 1, 100000)
+	v.between("event.max_page_size", c.Event.MaxPageSize, 1, 1000)
+	v.between("event.default_page_size", c.Event.DefaultPageSize, 1, c.Event.MaxPageSize)
+	v.between("timeline.celebrity_followers", c.Timeline.CelebrityFollowers, 100, 10000000)
+	v.between("timeline.max_size", c.Timeline.MaxSize, 50, 5000)
+	if c.Timeline.IdleTTL < time.Hour || c.Timeline.IdleTTL > 30*24*time.Hour {
+		v.add("timeline.idle_ttl", "must be between 1h and 720h, got %s", c.Timeline.IdleTTL)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
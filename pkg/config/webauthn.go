@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// WebAuthnConfig configures passkeys. Passkeys are bound to RPID, so changing
+// it strands every registered passkey.
+type WebAuthnConfig struct {
+	RPID    string        `yaml:"rp_id"`   // Registrable domain, e.g. vyrall.com
+	RPName  string        `yaml:"rp_name"` // Shown by the authenticator
+	Origins []string      `yaml:"origins"` // Web origins allowed to use passkeys
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func defaultWebAuthn() WebAuthnConfig {
+	return WebAuthnConfig{
+		RPID:    "localhost",
+		RPName:  "Vyrall",
+		Origins: []string{"http://localhost:3000"},
+		Timeout: 5 * time.Minute,
+	}
+}
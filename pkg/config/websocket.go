@@ -0,0 +1,18 @@
+package config
+
+// WebSocketConfig configures the realtime websocket endpoint
+type WebSocketConfig struct {
+	AllowedOrigins  []string `yaml:"allowed_origins"`
+	ReadBufferSize  int      `yaml:"read_buffer_size"`
+	WriteBufferSize int      `yaml:"write_buffer_size"`
+	MaxMessageSize  int64    `yaml:"max_message_size"`
+}
+
+func defaultWebSocket() WebSocketConfig {
+	return WebSocketConfig{
+		AllowedOrigins:  []string{"*"},
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		MaxMessageSize:  10000,
+	}
+}
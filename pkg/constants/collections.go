@@ -0,0 +1,68 @@
+package constants
+
+// MongoDB collection names
+const (
+	CollectionUsers            = "users"
+	CollectionSessions         = "sessions"
+	CollectionUserSessions     = "user_sessions"
+	CollectionVerifications    = "verifications"
+	CollectionPosts            = "posts"
+	CollectionComments         = "comments"
+	CollectionLikes            = "likes"
+	CollectionShares           = "shares"
+	CollectionBookmarks        = "bookmarks"
+	CollectionBookmarkFolders  = "bookmark_collections"
+	CollectionFollows          = "follows"
+	CollectionFriendships      = "friendships"
+	CollectionHashtags         = "hashtags"
+	CollectionHashtagFollows   = "hashtag_follows"
+	CollectionMedia            = "media"
+	CollectionStories          = "stories"
+	CollectionConversations    = "conversations"
+	CollectionMessages         = "messages"
+	CollectionNotifications    = "notifications"
+	CollectionGroups           = "groups"
+	CollectionGroupMembers     = "group_members"
+	CollectionEvents           = "events"
+	CollectionEventAttendees   = "event_attendees"
+	CollectionEventReminders   = "event_reminders"
+	CollectionLiveStreams      = "live_streams"
+	CollectionReports          = "reports"
+	CollectionAnalyticsEvents  = "analytics_events"
+	CollectionScheduledReports = "scheduled_reports"
+	CollectionOutbox           = "outbox"
+	CollectionSigningKeys      = "signing_keys"
+
+	// WebAuthn credentials and the ceremonies that register and use them
+	CollectionPasskeys          = "passkeys"
+	CollectionPasskeyChallenges = "passkey_challenges"
+
+	// Failed password logins and the lockouts they trigger
+	CollectionLoginFailures = "login_failures"
+	CollectionLoginLockouts = "login_lockouts"
+
+	// Completed logins, compared with new ones to spot unfamiliar devices and places
+	CollectionLoginHistory = "login_history"
+
+	// Security and moderation actions shown to admins
+	CollectionModerationLog = "moderation_log"
+
+	// Impersonations of users by staff and every request made under them
+	CollectionAdminAudit = "admin_audit_log"
+
+	// What was erased from each collection when a deleted account was purged
+	CollectionAccountPurgeReports = "account_purge_reports"
+
+	// Third-party apps and what users have authorized them to do
+	CollectionOAuthApps     = "oauth_apps"
+	CollectionOAuthCodes    = "oauth_codes"
+	CollectionOAuthConsents = "oauth_consents"
+	CollectionOAuthTokens   = "oauth_tokens"
+
+	// Personal access tokens and their daily usage, kept for auditing
+	CollectionPersonalTokens     = "personal_access_tokens"
+	CollectionPersonalTokenUsage = "personal_access_token_usage"
+
+	// Authenticator app secrets and recovery codes
+	CollectionTwoFactorSecrets = "two_factor_secrets"
+)
@@ -0,0 +1,23 @@
+package constants
+
+// Domain event types. Events are named <aggregate>.<past-tense verb> and are
+// stored in the outbox under these names, so never rename one that has shipped.
+const (
+	EventPostCreated        = "post.created"
+	EventCommentAdded       = "comment.added"
+	EventUserFollowed       = "user.followed"
+	EventUserUnfollowed     = "user.unfollowed"
+	EventEventRSVPChanged   = "event.rsvp_changed"
+	EventSessionTokenReused = "session.token_reused"
+	EventSessionFlagged     = "session.flagged"
+	EventAccountLockedOut   = "account.locked_out"
+	EventAccountUnlocked    = "account.unlocked"
+)
+
+// Subscriber names. Each subscriber gets its own delivery job per event, so
+// one failing subscriber is retried without re-running the others.
+const (
+	SubscriberNotifications = "notifications"
+	SubscriberModerationLog = "moderation_log"
+	SubscriberTimelines     = "timelines"
+)
@@ -0,0 +1,107 @@
+package constants
+
+// User roles. Each role is granted a set of permissions and inherits those
+// of the roles beneath it; see the permission service for the policy.
+const (
+	RoleUser       = "user"
+	RoleCreator    = "creator"
+	RoleBusiness   = "business"
+	RoleModerator  = "moderator"
+	RoleSupport    = "support"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// Types of resource that permissions are checked against
+const (
+	ResourceTypeUser       = "user"
+	ResourceTypePost       = "post"
+	ResourceTypeComment    = "comment"
+	ResourceTypeGroup      = "group"
+	ResourceTypeLiveStream = "live_stream"
+	ResourceTypeEvent      = "event"
+	ResourceTypeReport     = "report"
+	ResourceTypeAd         = "ad"
+	ResourceTypeAnalytics  = "analytics"
+	ResourceTypeAdmin      = "admin"  // The admin panel itself
+	ResourceTypeSystem     = "system" // Platform settings, logs and analytics
+	ResourceTypeRole       = "role"
+)
+
+// Actions on a resource
+const (
+	ActionRead     = "read"
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionModerate = "moderate" // Remove or feature others' content, ban users
+	ActionManage   = "manage"   // Change settings, members and roles
+	ActionPost     = "post"     // Post into a group
+	ActionJoin     = "join"
+	ActionComment  = "comment"
+	ActionReact    = "react"
+	ActionAccess   = "access"
+	ActionAssign   = "assign"
+
+	// Sign in as a user to see the app as they do, read-only unless elevated
+	ActionImpersonate      = "impersonate"
+	ActionImpersonateWrite = "impersonate_write"
+)
+
+// Permissions are named <resource type>.<action>. PermissionAll grants every
+// permission.
+const (
+	PermissionAll = "*"
+
+	PermissionUserRead     = "user.read"
+	PermissionUserUpdate   = "user.update"
+	PermissionUserDelete   = "user.delete"
+	PermissionUserModerate = "user.moderate"
+
+	PermissionUserImpersonate      = "user.impersonate"
+	PermissionUserImpersonateWrite = "user.impersonate_write"
+
+	PermissionPostCreate   = "post.create"
+	PermissionPostUpdate   = "post.update"
+	PermissionPostDelete   = "post.delete"
+	PermissionPostModerate = "post.moderate"
+
+	PermissionCommentCreate   = "comment.create"
+	PermissionCommentUpdate   = "comment.update"
+	PermissionCommentDelete   = "comment.delete"
+	PermissionCommentModerate = "comment.moderate"
+
+	PermissionGroupCreate   = "group.create"
+	PermissionGroupUpdate   = "group.update"
+	PermissionGroupDelete   = "group.delete"
+	PermissionGroupModerate = "group.moderate"
+	PermissionGroupManage   = "group.manage"
+	PermissionGroupPost     = "group.post"
+
+	PermissionLiveStreamCreate   = "live_stream.create"
+	PermissionLiveStreamUpdate   = "live_stream.update"
+	PermissionLiveStreamDelete   = "live_stream.delete"
+	PermissionLiveStreamModerate = "live_stream.moderate"
+	PermissionLiveStreamJoin     = "live_stream.join"
+	PermissionLiveStreamComment  = "live_stream.comment"
+	PermissionLiveStreamReact    = "live_stream.react"
+
+	PermissionEventCreate   = "event.create"
+	PermissionEventUpdate   = "event.update"
+	PermissionEventDelete   = "event.delete"
+	PermissionEventModerate = "event.moderate"
+	PermissionEventManage   = "event.manage"
+
+	PermissionReportCreate   = "report.create"
+	PermissionReportRead     = "report.read"
+	PermissionReportModerate = "report.moderate"
+
+	PermissionAdCreate      = "ad.create"
+	PermissionAdManage      = "ad.manage"
+	PermissionAnalyticsRead = "analytics.read"
+
+	PermissionAdminAccess  = "admin.access"
+	PermissionSystemRead   = "system.read"
+	PermissionSystemManage = "system.manage"
+	PermissionRoleAssign   = "role.assign"
+)
@@ -0,0 +1,43 @@
+package constants
+
+// Scopes of tokens issued to third-party apps and of personal access tokens.
+// API scopes are named <resource>:read or <resource>:write; a write scope does
+// not imply read. Tokens store the scopes they were granted, so never rename
+// one that has shipped.
+const (
+	// OpenID Connect
+	ScopeOpenID        = "openid"
+	ScopeProfile       = "profile"
+	ScopeEmail         = "email"
+	ScopeOfflineAccess = "offline_access" // Issue a refresh token
+
+	ScopePostsRead          = "posts:read"
+	ScopePostsWrite         = "posts:write"
+	ScopeCommentsRead       = "comments:read"
+	ScopeCommentsWrite      = "comments:write"
+	ScopeMessagesRead       = "messages:read"
+	ScopeMessagesWrite      = "messages:write"
+	ScopeUsersRead          = "users:read"
+	ScopeUsersWrite         = "users:write"
+	ScopeNotificationsRead  = "notifications:read"
+	ScopeNotificationsWrite = "notifications:write"
+	ScopeMediaRead          = "media:read"
+	ScopeMediaWrite         = "media:write"
+
+	// Business profiles, ads, campaigns and their analytics. Only personal
+	// access tokens can be granted these.
+	ScopeBusinessRead  = "business:read"
+	ScopeBusinessWrite = "business:write"
+)
+
+// API resources that route groups expose to scoped tokens, each guarded by
+// its read and write scope
+const (
+	ResourcePosts         = "posts"
+	ResourceComments      = "comments"
+	ResourceMessages      = "messages"
+	ResourceUsers         = "users"
+	ResourceNotifications = "notifications"
+	ResourceMedia         = "media"
+	ResourceBusiness      = "business"
+)
@@ -0,0 +1,15 @@
+package errors
+
+// Codes classify an error so callers can react to it without matching on
+// its message
+const (
+	CodeInternal           = "internal"
+	CodeNotFound           = "not_found"
+	CodeInvalidArgument    = "invalid_argument"
+	CodeInvalidOperation   = "invalid_operation"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeInvalidToken       = "invalid_token"
+	CodeAccountDisabled    = "account_disabled"
+	CodeForbidden          = "forbidden"
+	CodeDuplicateEntity    = "duplicate_entity"
+)
@@ -0,0 +1,58 @@
+// Package errors provides errors that carry a code alongside their message
+package errors
+
+import (
+	stderrors "errors"
+)
+
+// Error is an error with a code. Message is safe to show to clients; the
+// wrapped error, if any, is not.
+type Error struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+// Error returns the message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates an error with a code and message
+func New(code, message string) error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap gives err a new message, keeping its code. Errors without a code are
+// internal. Wrap returns nil when err is nil.
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: Code(err), Message: message, Err: err}
+}
+
+// Code returns the code of the first coded error in err's chain, or
+// CodeInternal if there is none
+func Code(err error) string {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// Is reports whether any error in err's chain matches target
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
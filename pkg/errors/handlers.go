@@ -0,0 +1,31 @@
+package errors
+
+import "net/http"
+
+// HTTPStatus returns the HTTP status that matches err's code
+func HTTPStatus(err error) int {
+	switch Code(err) {
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeInvalidArgument:
+		return http.StatusBadRequest
+	case CodeInvalidOperation, CodeDuplicateEntity:
+		return http.StatusConflict
+	case CodeInvalidCredentials, CodeInvalidToken:
+		return http.StatusUnauthorized
+	case CodeAccountDisabled, CodeForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Message returns the message that is safe to show a client for err. Errors
+// without a code are reported generically.
+func Message(err error) string {
+	var e *Error
+	if As(err, &e) && e.Code != CodeInternal {
+		return e.Message
+	}
+	return http.StatusText(http.StatusInternalServerError)
+}
@@ -0,0 +1,21 @@
+package errors
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FromMongo translates a MongoDB error: a missing document becomes a not
+// found error with message, a unique index violation a duplicate entity, and
+// anything else is wrapped as internal
+func FromMongo(err error, message string) error {
+	switch {
+	case err == nil:
+		return nil
+	case Is(err, mongo.ErrNoDocuments):
+		return &Error{Code: CodeNotFound, Message: message, Err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Code: CodeDuplicateEntity, Message: message, Err: err}
+	default:
+		return Wrap(err, message)
+	}
+}
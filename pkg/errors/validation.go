@@ -0,0 +1,37 @@
+package errors
+
+import "strings"
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects the invalid fields of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add records an invalid field
+func (v *ValidationError) Add(field, message string) {
+	v.Fields = append(v.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns nil if no field was invalid, and otherwise an invalid argument
+// error listing the fields
+func (v *ValidationError) Err() error {
+	if len(v.Fields) == 0 {
+		return nil
+	}
+	return &Error{Code: CodeInvalidArgument, Message: v.Error(), Err: v}
+}
+
+// Error lists the invalid fields
+func (v *ValidationError) Error() string {
+	parts := make([]string, len(v.Fields))
+	for i, f := range v.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "invalid fields: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,76 @@
+// Package metrics collects application counters and latencies in memory
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Latency summarises the observations of one latency metric
+type Latency struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean returns the average observed latency
+func (l Latency) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Total / time.Duration(l.Count)
+}
+
+// Collector records named counters and latencies. It is safe for concurrent use.
+type Collector struct {
+	mu        sync.Mutex
+	counters  map[string]int
+	latencies map[string]Latency
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{
+		counters:  map[string]int{},
+		latencies: map[string]Latency{},
+	}
+}
+
+// IncrementCounter adds one to a counter
+func (c *Collector) IncrementCounter(name string) {
+	c.AddCounter(name, 1)
+}
+
+// AddCounter adds delta to a counter
+func (c *Collector) AddCounter(name string, delta int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[name] += delta
+}
+
+// ObserveLatency records one observation of a latency
+func (c *Collector) ObserveLatency(name string, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := c.latencies[name]
+	l.Count++
+	l.Total += latency
+	if latency > l.Max {
+		l.Max = latency
+	}
+	c.latencies[name] = l
+}
+
+// Counter returns the current value of a counter
+func (c *Collector) Counter(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counters[name]
+}
+
+// Latency returns the summary of a latency
+func (c *Collector) Latency(name string) Latency {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latencies[name]
+}
@@ -0,0 +1,27 @@
+#!/usr/bin/env bash
+#
+# Run schema migrations against MongoDB.
+#
+# usage: scripts/mongodb/migrate.sh <status|up|down> [argument]
+#
+# The connection is taken from VYRALL_MONGODB_URI and VYRALL_MONGODB_DATABASE.
+# Set SERVER_BIN to use a prebuilt server binary instead of go run.
+
+set -euo pipefail
+
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)"
+
+if [[ $# -lt 1 ]]; then
+  echo "usage: $0 <status|up|down> [argument]" >&2
+  exit 2
+fi
+
+export VYRALL_MONGODB_URI="${VYRALL_MONGODB_URI:-mongodb://localhost:27017}"
+export VYRALL_MONGODB_DATABASE="${VYRALL_MONGODB_DATABASE:-vyrall}"
+
+if [[ -n "${SERVER_BIN:-}" ]]; then
+  exec "$SERVER_BIN" migrate "$@"
+fi
+
+cd "$ROOT_DIR"
+exec go run ./cmd/server migrate "$@"
@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// AssertNoError fails the test immediately if err is not nil
+func AssertNoError(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// AssertErrorIs fails the test if err does not wrap target
+func AssertErrorIs(tb testing.TB, err, target error) {
+	tb.Helper()
+	if !errors.Is(err, target) {
+		tb.Fatalf("expected error %v, got %v", target, err)
+	}
+}
+
+// AssertNotFound fails the test unless err is the not-found error the repositories return
+func AssertNotFound(tb testing.TB, err error) {
+	tb.Helper()
+	AssertErrorIs(tb, err, mongo.ErrNoDocuments)
+}
+
+// AssertDuplicateKey fails the test unless err is a unique index violation
+func AssertDuplicateKey(tb testing.TB, err error) {
+	tb.Helper()
+	if !mongo.IsDuplicateKeyError(err) {
+		tb.Fatalf("expected duplicate key error, got %v", err)
+	}
+}
+
+// AssertEqual fails the test if got and want are not deeply equal
+func AssertEqual(tb testing.TB, got, want interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(got, want) {
+		tb.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+// AssertPage fails the test unless a paginated result holds wantLen items out of wantTotal
+func AssertPage[T any](tb testing.TB, items []T, total, wantLen, wantTotal int) {
+	tb.Helper()
+	if len(items) != wantLen || total != wantTotal {
+		tb.Fatalf("got page of %d with total %d, want page of %d with total %d", len(items), total, wantLen, wantTotal)
+	}
+}
+
+// AssertCount fails the test unless the collection holds want documents matching filter
+func AssertCount(tb testing.TB, db *memory.Database, collection string, filter bson.M, want int) {
+	tb.Helper()
+	got, err := db.Collection(collection).Count(filter)
+	AssertNoError(tb, err)
+	if got != want {
+		tb.Fatalf("%s: got %d documents matching %v, want %d", collection, got, filter, want)
+	}
+}
+
+// AssertStatus fails the test unless the response has the expected status code
+func AssertStatus(tb testing.TB, rec *httptest.ResponseRecorder, want int) {
+	tb.Helper()
+	if rec.Code != want {
+		tb.Fatalf("got status %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+// DecodeJSON decodes a JSON response body into v
+func DecodeJSON(tb testing.TB, rec *httptest.ResponseRecorder, v interface{}) {
+	tb.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		tb.Fatalf("decode response body: %v; body: %s", err, rec.Body.String())
+	}
+}
@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// Reset empties every collection so one environment can be reused across subtests
+func (e *Env) Reset() {
+	e.DB.Drop()
+}
+
+// Close stops background work owned by the services. NewEnv registers it with
+// the test, so it only needs calling for environments built by hand.
+func (e *Env) Close() {
+	if e.Services != nil {
+		e.Services.Close()
+	}
+}
+
+// AssertClean fails the test if any collection still holds documents, which
+// catches tests that rely on state left behind by an earlier one
+func AssertClean(tb testing.TB, db *memory.Database) {
+	tb.Helper()
+	if names := db.CollectionNames(); len(names) > 0 {
+		tb.Fatalf("collections not empty: %v", names)
+	}
+}
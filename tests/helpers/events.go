@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+)
+
+// DeliverEvents hands every pending outbox event to its subscribers on the
+// environment's event bus and marks it published, standing in for the relay
+// and the delivery workers. It fails the test if a subscriber returns an
+// error and reports how many events were delivered.
+func (e *Env) DeliverEvents(tb testing.TB) int {
+	tb.Helper()
+	ctx := context.Background()
+
+	delivered := 0
+	for {
+		messages, err := e.Repos.Outbox.GetPending(ctx, time.Now(), 100)
+		AssertNoError(tb, err)
+		if len(messages) == 0 {
+			return delivered
+		}
+
+		for _, message := range messages {
+			envelope := eventbus.NewEnvelope(message)
+			if err := e.Services.EventBus.Dispatch(ctx, &envelope); err != nil {
+				tb.Fatalf("deliver %s event %s: %v", message.Type, message.ID.Hex(), err)
+			}
+			AssertNoError(tb, e.Repos.Outbox.MarkPublished(ctx, message.ID))
+			delivered++
+		}
+	}
+}
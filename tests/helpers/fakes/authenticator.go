@@ -0,0 +1,265 @@
+// Package fakes holds stand-ins for the outside world the services talk to in
+// tests: a mailbox, a WebAuthn authenticator and the GeoIP and breached-password
+// fixtures
+package fakes
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+)
+
+// Authenticator is a software WebAuthn authenticator for exercising passkey
+// ceremonies in tests. It keeps ES256 credentials in memory and answers
+// registration and login options with the JSON a browser would send.
+type Authenticator struct {
+	// Origin is reported in the client data, as the browser would
+	Origin string
+
+	// UserVerified reports that a PIN or biometric check passed. Defaults to true.
+	UserVerified bool
+
+	// BackupEligible makes new credentials synced passkeys
+	BackupEligible bool
+
+	// StaticCounter keeps the sign count at zero, as synced passkeys do
+	StaticCounter bool
+
+	credentials []*softCredential
+}
+
+type softCredential struct {
+	id         []byte
+	key        *ecdsa.PrivateKey
+	rpID       string
+	userHandle []byte
+	signCount  uint32
+}
+
+// NewAuthenticator creates an authenticator that runs ceremonies for origin
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerified: true}
+}
+
+// Register creates a credential for the options a registration began with
+func (a *Authenticator) Register(options *webauthn.CreationOptions) (*webauthn.CredentialCreationResponse, error) {
+	for _, excluded := range options.ExcludeCredentials {
+		if a.find(options.RP.ID, excluded.ID) != nil {
+			return nil, errors.New("authenticator: already registered (InvalidStateError)")
+		}
+	}
+
+	supported := false
+	for _, param := range options.PubKeyCredParams {
+		supported = supported || param.Alg == webauthn.AlgES256
+	}
+	if !supported {
+		return nil, errors.New("authenticator: ES256 not offered (NotSupportedError)")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	userHandle, err := decodeBase64URL(options.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &softCredential{id: id, key: key, rpID: options.RP.ID, userHandle: userHandle}
+	a.credentials = append(a.credentials, credential)
+
+	attested := make([]byte, 16, 16+2+len(id)) // Zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(id)))
+	attested = append(attested, id...)
+	attested = append(attested, coseKey(&key.PublicKey)...)
+	authData := a.authenticatorData(credential, webauthn.FlagAttestedData, attested)
+
+	clientData, err := a.clientData("webauthn.create", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	attestation := cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(authData),
+	)
+
+	response := &webauthn.CredentialCreationResponse{
+		ID:    encodeBase64URL(id),
+		RawID: encodeBase64URL(id),
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = encodeBase64URL(clientData)
+	response.Response.AttestationObject = encodeBase64URL(attestation)
+	response.Response.Transports = []string{"internal"}
+	return response, nil
+}
+
+// Login signs the challenge of a login with a credential it holds: the first
+// one allowed, or for a passwordless login the first one for the RP
+func (a *Authenticator) Login(options *webauthn.RequestOptions) (*webauthn.CredentialAssertionResponse, error) {
+	var credential *softCredential
+	if len(options.AllowCredentials) == 0 {
+		for _, c := range a.credentials {
+			if c.rpID == options.RPID {
+				credential = c
+				break
+			}
+		}
+	}
+	for _, allowed := range options.AllowCredentials {
+		if credential = a.find(options.RPID, allowed.ID); credential != nil {
+			break
+		}
+	}
+	if credential == nil {
+		return nil, errors.New("authenticator: no matching credential (NotAllowedError)")
+	}
+
+	if !a.StaticCounter {
+		credential.signCount++
+	}
+	authData := a.authenticatorData(credential, 0, nil)
+	clientData, err := a.clientData("webauthn.get", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, credential.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	response := &webauthn.CredentialAssertionResponse{
+		ID:    encodeBase64URL(credential.id),
+		RawID: encodeBase64URL(credential.id),
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = encodeBase64URL(clientData)
+	response.Response.AuthenticatorData = encodeBase64URL(authData)
+	response.Response.Signature = encodeBase64URL(signature)
+	response.Response.UserHandle = encodeBase64URL(credential.userHandle)
+	return response, nil
+}
+
+// SetSignCount overwrites a credential's counter, e.g. to simulate a cloned authenticator
+func (a *Authenticator) SetSignCount(credentialID string, count uint32) error {
+	for _, c := range a.credentials {
+		if encodeBase64URL(c.id) == credentialID {
+			c.signCount = count
+			return nil
+		}
+	}
+	return fmt.Errorf("authenticator: unknown credential %s", credentialID)
+}
+
+func (a *Authenticator) find(rpID, encodedID string) *softCredential {
+	for _, c := range a.credentials {
+		if c.rpID == rpID && encodeBase64URL(c.id) == encodedID {
+			return c
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) authenticatorData(c *softCredential, flags byte, attested []byte) []byte {
+	flags |= webauthn.FlagUserPresent
+	if a.UserVerified {
+		flags |= webauthn.FlagUserVerified
+	}
+	if a.BackupEligible {
+		flags |= webauthn.FlagBackupEligible | webauthn.FlagBackedUp
+	}
+
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, c.signCount)
+	return append(data, attested...)
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+}
+
+// coseKey encodes a P-256 public key as an ES256 COSE_Key
+func coseKey(key *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return cborMap(
+		cborInt(1), cborInt(2), // kty: EC2
+		cborInt(3), cborInt(webauthn.AlgES256),
+		cborInt(-1), cborInt(1), // crv: P-256
+		cborInt(-2), cborBytes(x),
+		cborInt(-3), cborBytes(y),
+	)
+}
+
+// Minimal CBOR encoding, enough for attestation objects and COSE keys
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+	}
+}
+
+func cborInt(n int64) []byte {
+	if n < 0 {
+		return cborHead(1, uint64(-1-n))
+	}
+	return cborHead(0, uint64(n))
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, uint64(len(b))), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, uint64(len(s))), s...)
+}
+
+// cborMap encodes alternating, already encoded keys and values
+func cborMap(items ...[]byte) []byte {
+	out := cborHead(5, uint64(len(items)/2))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
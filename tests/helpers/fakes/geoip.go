@@ -0,0 +1,24 @@
+package fakes
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Caqil/vyrall/internal/utils/geoip"
+)
+
+// GeoIP opens the GeoIP database fixture. It locates the documentation
+// address blocks: 192.0.2.0/25 in London, 192.0.2.128/25 in Manchester,
+// 198.51.100.0/24 in New York, 203.0.113.0/24 in Sydney and 2001:db8::/32
+// in Berlin.
+func GeoIP(tb testing.TB) *geoip.DB {
+	tb.Helper()
+
+	_, file, _, _ := runtime.Caller(0)
+	db, err := geoip.Open(filepath.Join(filepath.Dir(file), "..", "..", "fixtures", "geoip.csv"))
+	if err != nil {
+		tb.Fatalf("open geoip fixture: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,54 @@
+package fakes
+
+import (
+	"sync"
+	"testing"
+)
+
+// Email is a message the services sent
+type Email struct {
+	To       string
+	Subject  string
+	Template string
+	Data     map[string]interface{}
+}
+
+// Mailbox records the email the services send instead of delivering it
+type Mailbox struct {
+	mu   sync.Mutex
+	sent []Email
+}
+
+// NewMailbox creates an empty mailbox
+func NewMailbox() *Mailbox {
+	return &Mailbox{}
+}
+
+// SendTemplatedEmail records a message
+func (m *Mailbox) SendTemplatedEmail(to, subject, template string, data map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, Email{To: to, Subject: subject, Template: template, Data: data})
+	return nil
+}
+
+// Sent returns every message sent so far, oldest first
+func (m *Mailbox) Sent() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Email(nil), m.sent...)
+}
+
+// Last returns the most recent message sent to an address and fails the test
+// if there is none
+func (m *Mailbox) Last(tb testing.TB, to string) Email {
+	tb.Helper()
+	sent := m.Sent()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].To == to {
+			return sent[i]
+		}
+	}
+	tb.Fatalf("no email sent to %s", to)
+	return Email{}
+}
@@ -0,0 +1,17 @@
+package fakes
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/Caqil/vyrall/internal/utils/pwned"
+)
+
+// PwnedPasswords returns the breached-password corpus fixture, in range file
+// format. It lists "password" (seen 10434004 times), "Password1" (2) and
+// "Summer2024!" (1), and has a range, without the password itself, for
+// "correct horse battery staple".
+func PwnedPasswords() pwned.Dir {
+	_, file, _, _ := runtime.Caller(0)
+	return pwned.Dir(filepath.Join(filepath.Dir(file), "..", "..", "fixtures", "pwned"))
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// AccountPurgeRepository implements interfaces.AccountPurgeRepository in memory
+type AccountPurgeRepository struct {
+	store
+}
+
+var _ interfaces.AccountPurgeRepository = (*AccountPurgeRepository)(nil)
+
+// NewAccountPurgeRepository creates an in-memory account purge repository
+func NewAccountPurgeRepository(db *Database) *AccountPurgeRepository {
+	return &AccountPurgeRepository{store: newStore(db, constants.CollectionAccountPurgeReports)}
+}
+
+// Purge runs every account purge step and counts, per collection, the
+// documents deleted and the documents of other users that were changed
+func (r *AccountPurgeRepository) Purge(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.PurgedCollection, error) {
+	var purged []models.PurgedCollection
+	for _, step := range dbmongo.AccountPurgeSteps(userID, now) {
+		if len(purged) == 0 || purged[len(purged)-1].Collection != step.Collection {
+			purged = append(purged, models.PurgedCollection{Collection: step.Collection})
+		}
+		counts := &purged[len(purged)-1]
+
+		collection := r.db.Collection(step.Collection)
+		if step.Update == nil {
+			deleted, err := collection.DeleteMany(step.Filter)
+			if err != nil {
+				return purged, fmt.Errorf("purge %s: %w", step.Collection, err)
+			}
+			counts.Deleted += int64(deleted)
+			continue
+		}
+
+		updated, err := collection.UpdateMany(step.Filter, step.Update)
+		if err != nil {
+			return purged, fmt.Errorf("purge %s: %w", step.Collection, err)
+		}
+		counts.Updated += int64(updated)
+	}
+	return purged, nil
+}
+
+// SaveReport inserts or replaces the report for the report's user
+func (r *AccountPurgeRepository) SaveReport(ctx context.Context, report *models.AccountPurgeReport) error {
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+	replaced, err := r.collection.ReplaceOne(bson.M{"user_id": report.UserID}, report)
+	if err != nil || replaced > 0 {
+		return err
+	}
+	_, err = r.insert(report)
+	return err
+}
+
+// GetReport retrieves the report for a purged or purging account
+func (r *AccountPurgeRepository) GetReport(ctx context.Context, userID primitive.ObjectID) (*models.AccountPurgeReport, error) {
+	return findOne[models.AccountPurgeReport](r.collection, bson.M{"user_id": userID}, nil)
+}
+
+// ListReports retrieves reports, most recently started first
+func (r *AccountPurgeRepository) ListReports(ctx context.Context, limit, offset int) ([]*models.AccountPurgeReport, int, error) {
+	return findPage[models.AccountPurgeReport](r.collection, bson.M{}, bson.D{{Key: "started_at", Value: -1}}, limit, offset)
+}
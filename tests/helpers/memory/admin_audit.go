@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// AdminAuditRepository implements interfaces.AdminAuditRepository in memory
+type AdminAuditRepository struct {
+	store
+}
+
+var _ interfaces.AdminAuditRepository = (*AdminAuditRepository)(nil)
+
+// NewAdminAuditRepository creates an in-memory admin audit log repository
+func NewAdminAuditRepository(db *Database) *AdminAuditRepository {
+	return &AdminAuditRepository{store: newStore(db, constants.CollectionAdminAudit)}
+}
+
+// Create inserts an audit entry
+func (r *AdminAuditRepository) Create(ctx context.Context, entry *models.AdminAuditEntry) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.insert(entry)
+	return err
+}
+
+// List retrieves audit entries newest first, optionally about one user
+func (r *AdminAuditRepository) List(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.AdminAuditEntry, int, error) {
+	filter := bson.M{}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+	return findPage[models.AdminAuditEntry](r.collection, filter, bson.D{{Key: "created_at", Value: -1}}, limit, offset)
+}
@@ -0,0 +1,539 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Collections holding the precomputed analytics documents
+const (
+	collectionUserAnalytics  = "user_analytics"
+	collectionPostAnalytics  = "post_analytics"
+	collectionGroupAnalytics = "group_analytics"
+	collectionEventAnalytics = "event_analytics"
+)
+
+// allTimePeriod is the period of the running totals the increment methods maintain
+const allTimePeriod = "all_time"
+
+// activeWindow is how recently a user must have been seen to count as active
+const activeWindow = 5 * time.Minute
+
+// AnalyticsRepository implements interfaces.AnalyticsRepository in memory
+type AnalyticsRepository struct {
+	db       *Database
+	events   store
+	sessions store
+	users    store
+	posts    store
+	groups   store
+	eventsAn store
+}
+
+var _ interfaces.AnalyticsRepository = (*AnalyticsRepository)(nil)
+
+// NewAnalyticsRepository creates an in-memory analytics repository
+func NewAnalyticsRepository(db *Database) *AnalyticsRepository {
+	return &AnalyticsRepository{
+		db:       db,
+		events:   newStore(db, constants.CollectionAnalyticsEvents),
+		sessions: newStore(db, constants.CollectionUserSessions),
+		users:    newStore(db, collectionUserAnalytics),
+		posts:    newStore(db, collectionPostAnalytics),
+		groups:   newStore(db, collectionGroupAnalytics),
+		eventsAn: newStore(db, collectionEventAnalytics),
+	}
+}
+
+// CreateEvent records an analytics event
+func (r *AnalyticsRepository) CreateEvent(ctx context.Context, event *models.AnalyticsEvent) (primitive.ObjectID, error) {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	return r.events.insert(event)
+}
+
+// GetEventsByType retrieves events of a type within a date range, newest first
+func (r *AnalyticsRepository) GetEventsByType(ctx context.Context, eventType string, startDate, endDate time.Time, limit, offset int) ([]*models.AnalyticsEvent, int, error) {
+	filter := inRange(bson.M{"event_type": eventType}, "created_at", startDate, endDate)
+	return findPage[models.AnalyticsEvent](r.events.collection, filter, newestFirst, limit, offset)
+}
+
+// GetEventsByUserID retrieves a user's events within a date range. An empty
+// eventTypes matches every type.
+func (r *AnalyticsRepository) GetEventsByUserID(ctx context.Context, userID primitive.ObjectID, eventTypes []string, startDate, endDate time.Time, limit, offset int) ([]*models.AnalyticsEvent, int, error) {
+	filter := inRange(bson.M{"user_id": userID}, "created_at", startDate, endDate)
+	if len(eventTypes) > 0 {
+		filter["event_type"] = bson.M{"$in": eventTypes}
+	}
+	return findPage[models.AnalyticsEvent](r.events.collection, filter, newestFirst, limit, offset)
+}
+
+// GetEventsByEntityID retrieves the events recorded against an entity within a date range
+func (r *AnalyticsRepository) GetEventsByEntityID(ctx context.Context, entityType string, entityID primitive.ObjectID, startDate, endDate time.Time, limit, offset int) ([]*models.AnalyticsEvent, int, error) {
+	filter := inRange(bson.M{"entity_type": entityType, "entity_id": entityID}, "created_at", startDate, endDate)
+	return findPage[models.AnalyticsEvent](r.events.collection, filter, newestFirst, limit, offset)
+}
+
+// CreateSession records the start of a user session
+func (r *AnalyticsRepository) CreateSession(ctx context.Context, session *models.UserSession) (primitive.ObjectID, error) {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	if session.StartTime.IsZero() {
+		session.StartTime = time.Now()
+	}
+	return r.sessions.insert(session)
+}
+
+// UpdateSession records the end of a user session
+func (r *AnalyticsRepository) UpdateSession(ctx context.Context, sessionID string, endTime time.Time, duration int, pageViews int, exitPage string) error {
+	return matchedOne(r.sessions.collection.UpdateOne(
+		bson.M{"session_id": sessionID},
+		bson.M{"$set": bson.M{
+			"end_time":   endTime,
+			"duration":   duration,
+			"page_views": pageViews,
+			"exit_page":  exitPage,
+		}},
+	))
+}
+
+// GetSessionsByUserID retrieves a user's sessions started within a date range, newest first
+func (r *AnalyticsRepository) GetSessionsByUserID(ctx context.Context, userID primitive.ObjectID, startDate, endDate time.Time, limit, offset int) ([]*models.UserSession, int, error) {
+	filter := inRange(bson.M{"user_id": userID}, "start_time", startDate, endDate)
+	sort := bson.D{{Key: "start_time", Value: -1}, {Key: "_id", Value: -1}}
+	return findPage[models.UserSession](r.sessions.collection, filter, sort, limit, offset)
+}
+
+// CreateOrUpdateUserAnalytics upserts the user analytics for a period
+func (r *AnalyticsRepository) CreateOrUpdateUserAnalytics(ctx context.Context, analytics *models.UserAnalytics) (primitive.ObjectID, error) {
+	analytics.UpdatedAt = time.Now()
+	filter := bson.M{"user_id": analytics.UserID, "period": analytics.Period, "start_date": analytics.StartDate}
+	return upsertModel(r.users, filter, &analytics.ID, analytics)
+}
+
+// GetUserAnalytics retrieves the latest user analytics for a period within a date range
+func (r *AnalyticsRepository) GetUserAnalytics(ctx context.Context, userID primitive.ObjectID, period string, startDate, endDate time.Time) (*models.UserAnalytics, error) {
+	return findOne[models.UserAnalytics](r.users.collection, periodFilter("user_id", userID, period, startDate, endDate), byStartDate)
+}
+
+// IncrementUserProfileViews bumps the all-time profile view counter
+func (r *AnalyticsRepository) IncrementUserProfileViews(ctx context.Context, userID primitive.ObjectID) error {
+	return r.users.collection.Upsert(
+		bson.M{"user_id": userID, "period": allTimePeriod},
+		bson.M{
+			"$inc": bson.M{"profile_views": 1},
+			"$set": bson.M{"last_profile_viewed_at": time.Now(), "updated_at": time.Now()},
+		},
+	)
+}
+
+// IncrementUserPostImpressions bumps the all-time post impression counter
+func (r *AnalyticsRepository) IncrementUserPostImpressions(ctx context.Context, userID primitive.ObjectID, amount int) error {
+	return r.users.collection.Upsert(
+		bson.M{"user_id": userID, "period": allTimePeriod},
+		bson.M{"$inc": bson.M{"post_impressions": amount}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+}
+
+// UpdateUserEngagementRate sets the all-time engagement rate
+func (r *AnalyticsRepository) UpdateUserEngagementRate(ctx context.Context, userID primitive.ObjectID, rate float64) error {
+	return r.users.collection.Upsert(
+		bson.M{"user_id": userID, "period": allTimePeriod},
+		bson.M{"$set": bson.M{"engagement_rate": rate, "updated_at": time.Now()}},
+	)
+}
+
+// CreateOrUpdatePostAnalytics upserts the post analytics for a period
+func (r *AnalyticsRepository) CreateOrUpdatePostAnalytics(ctx context.Context, analytics *models.PostAnalytics) (primitive.ObjectID, error) {
+	analytics.UpdatedAt = time.Now()
+	filter := bson.M{"post_id": analytics.PostID, "period": analytics.Period, "start_date": analytics.StartDate}
+	return upsertModel(r.posts, filter, &analytics.ID, analytics)
+}
+
+// GetPostAnalytics retrieves the latest post analytics for a period within a date range
+func (r *AnalyticsRepository) GetPostAnalytics(ctx context.Context, postID primitive.ObjectID, period string, startDate, endDate time.Time) (*models.PostAnalytics, error) {
+	return findOne[models.PostAnalytics](r.posts.collection, periodFilter("post_id", postID, period, startDate, endDate), byStartDate)
+}
+
+// IncrementPostImpressions bumps the all-time impression counter of a post
+func (r *AnalyticsRepository) IncrementPostImpressions(ctx context.Context, postID primitive.ObjectID, amount int) error {
+	return r.posts.collection.Upsert(
+		bson.M{"post_id": postID, "period": allTimePeriod},
+		bson.M{"$inc": bson.M{"impressions": amount}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+}
+
+// IncrementPostReach bumps the all-time reach counter of a post
+func (r *AnalyticsRepository) IncrementPostReach(ctx context.Context, postID primitive.ObjectID, amount int) error {
+	return r.posts.collection.Upsert(
+		bson.M{"post_id": postID, "period": allTimePeriod},
+		bson.M{"$inc": bson.M{"reach": amount}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+}
+
+// UpdatePostEngagementRate sets the all-time engagement rate of a post
+func (r *AnalyticsRepository) UpdatePostEngagementRate(ctx context.Context, postID primitive.ObjectID, rate float64) error {
+	return r.posts.collection.Upsert(
+		bson.M{"post_id": postID, "period": allTimePeriod},
+		bson.M{"$set": bson.M{"engagement_rate": rate, "updated_at": time.Now()}},
+	)
+}
+
+// CreateOrUpdateGroupAnalytics upserts the group analytics for a period
+func (r *AnalyticsRepository) CreateOrUpdateGroupAnalytics(ctx context.Context, analytics *models.GroupAnalytics) (primitive.ObjectID, error) {
+	analytics.UpdatedAt = time.Now()
+	filter := bson.M{"group_id": analytics.GroupID, "period": analytics.Period, "start_date": analytics.StartDate}
+	return upsertModel(r.groups, filter, &analytics.ID, analytics)
+}
+
+// GetGroupAnalytics retrieves the latest group analytics for a period within a date range
+func (r *AnalyticsRepository) GetGroupAnalytics(ctx context.Context, groupID primitive.ObjectID, period string, startDate, endDate time.Time) (*models.GroupAnalytics, error) {
+	return findOne[models.GroupAnalytics](r.groups.collection, periodFilter("group_id", groupID, period, startDate, endDate), byStartDate)
+}
+
+// CreateOrUpdateEventAnalytics upserts the analytics of an event
+func (r *AnalyticsRepository) CreateOrUpdateEventAnalytics(ctx context.Context, analytics *models.EventAnalytics) (primitive.ObjectID, error) {
+	analytics.UpdatedAt = time.Now()
+	return upsertModel(r.eventsAn, bson.M{"event_id": analytics.EventID}, &analytics.ID, analytics)
+}
+
+// GetEventAnalytics retrieves the analytics of an event
+func (r *AnalyticsRepository) GetEventAnalytics(ctx context.Context, eventID primitive.ObjectID) (*models.EventAnalytics, error) {
+	return findOne[models.EventAnalytics](r.eventsAn.collection, bson.M{"event_id": eventID}, nil)
+}
+
+// GetTopPerformingContent ranks entities of a content type by how many events
+// named metric were recorded against them within a date range
+func (r *AnalyticsRepository) GetTopPerformingContent(ctx context.Context, contentType string, metric string, startDate, endDate time.Time, limit int) ([]map[string]interface{}, error) {
+	filter := inRange(bson.M{"entity_type": contentType, "entity_id": bson.M{"$ne": nil}}, "created_at", startDate, endDate)
+	if metric != "" {
+		filter["event_type"] = metric
+	}
+	events, err := findAll[models.AnalyticsEvent](r.events.collection, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, event := range events {
+		counts[*event.EntityID]++
+	}
+
+	results := make([]map[string]interface{}, 0, len(counts))
+	for id, count := range counts {
+		results = append(results, map[string]interface{}{"content_id": id, "content_type": contentType, "value": count})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i]["value"].(int), results[j]["value"].(int)
+		if a != b {
+			return a > b
+		}
+		return results[i]["content_id"].(primitive.ObjectID).Hex() > results[j]["content_id"].(primitive.ObjectID).Hex()
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// GetPlatformGrowth counts the users and posts created within a date range
+func (r *AnalyticsRepository) GetPlatformGrowth(ctx context.Context, period string, startDate, endDate time.Time) (map[string]interface{}, error) {
+	users := r.db.Collection(constants.CollectionUsers)
+	newUsers, err := users.Count(inRange(bson.M{}, "created_at", startDate, endDate))
+	if err != nil {
+		return nil, err
+	}
+	totalUsers, err := users.Count(bson.M{"deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	newPosts, err := r.db.Collection(constants.CollectionPosts).Count(inRange(bson.M{}, "created_at", startDate, endDate))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"period":      period,
+		"new_users":   newUsers,
+		"total_users": totalUsers,
+		"new_posts":   newPosts,
+	}, nil
+}
+
+// GetEngagementMetrics counts the likes, comments and shares made within a date range
+func (r *AnalyticsRepository) GetEngagementMetrics(ctx context.Context, period string, startDate, endDate time.Time) (map[string]interface{}, error) {
+	metrics := map[string]interface{}{"period": period}
+	for key, collection := range map[string]string{
+		"likes":    constants.CollectionLikes,
+		"comments": constants.CollectionComments,
+		"shares":   constants.CollectionShares,
+	} {
+		count, err := r.db.Collection(collection).Count(inRange(bson.M{}, "created_at", startDate, endDate))
+		if err != nil {
+			return nil, err
+		}
+		metrics[key] = count
+	}
+
+	activeUsers, err := r.events.collection.Distinct("user_id", inRange(bson.M{}, "created_at", startDate, endDate))
+	if err != nil {
+		return nil, err
+	}
+	metrics["active_users"] = len(activeUsers)
+	return metrics, nil
+}
+
+// GetUserDemographics breaks the user base down by gender and location
+func (r *AnalyticsRepository) GetUserDemographics(ctx context.Context) (map[string]interface{}, error) {
+	users, err := findAll[models.User](r.db.Collection(constants.CollectionUsers), bson.M{"deleted_at": nil}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	genders := map[string]int{}
+	locations := map[string]int{}
+	for _, user := range users {
+		genders[orUnknown(user.Gender)]++
+		locations[orUnknown(user.Location)]++
+	}
+
+	return map[string]interface{}{
+		"total":    len(users),
+		"gender":   genders,
+		"location": locations,
+	}, nil
+}
+
+// GetRetentionMetrics reports, for users who joined on cohortDate, how many
+// were active again in each of the following weeks
+func (r *AnalyticsRepository) GetRetentionMetrics(ctx context.Context, cohortDate time.Time, periods int) (map[string]interface{}, error) {
+	start := cohortDate.Truncate(24 * time.Hour)
+	cohort, err := r.db.Collection(constants.CollectionUsers).Distinct("_id", inRange(bson.M{}, "created_at", start, start.Add(24*time.Hour)))
+	if err != nil {
+		return nil, err
+	}
+
+	retained := make([]int, periods)
+	for period := 0; period < periods; period++ {
+		from := start.Add(time.Duration(period+1) * 7 * 24 * time.Hour)
+		active, err := r.events.collection.Distinct("user_id", inRange(
+			bson.M{"user_id": bson.M{"$in": cohort}}, "created_at", from, from.Add(7*24*time.Hour),
+		))
+		if err != nil {
+			return nil, err
+		}
+		retained[period] = len(active)
+	}
+
+	return map[string]interface{}{
+		"cohort_date": start,
+		"cohort_size": len(cohort),
+		"retained":    retained,
+	}, nil
+}
+
+// GetCurrentActiveUsers counts users who recorded an event in the last few minutes
+func (r *AnalyticsRepository) GetCurrentActiveUsers(ctx context.Context) (int, error) {
+	users, err := r.events.collection.Distinct("user_id", inRange(bson.M{}, "created_at", time.Now().Add(-activeWindow), time.Time{}))
+	return len(users), err
+}
+
+// GetCurrentActiveSessions counts sessions that have not ended
+func (r *AnalyticsRepository) GetCurrentActiveSessions(ctx context.Context) (int, error) {
+	return r.sessions.collection.Count(bson.M{"end_time": nil})
+}
+
+// GetRealtimeEngagement counts the events of each type recorded in the last minutes
+func (r *AnalyticsRepository) GetRealtimeEngagement(ctx context.Context, minutes int) (map[string]interface{}, error) {
+	since := time.Now().Add(-time.Duration(minutes) * time.Minute)
+	events, err := findAll[models.AnalyticsEvent](r.events.collection, inRange(bson.M{}, "created_at", since, time.Time{}), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := map[string]int{}
+	for _, event := range events {
+		byType[event.EventType]++
+	}
+	return map[string]interface{}{
+		"minutes": minutes,
+		"total":   len(events),
+		"by_type": byType,
+	}, nil
+}
+
+// GetUsersByLocation counts users per location
+func (r *AnalyticsRepository) GetUsersByLocation(ctx context.Context) (map[string]int, error) {
+	users, err := findAll[models.User](r.db.Collection(constants.CollectionUsers), bson.M{"deleted_at": nil}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, user := range users {
+		counts[orUnknown(user.Location)]++
+	}
+	return counts, nil
+}
+
+// GetEventsByLocation counts events of a type per country within a date range
+func (r *AnalyticsRepository) GetEventsByLocation(ctx context.Context, eventType string, startDate, endDate time.Time) (map[string]int, error) {
+	events, err := r.eventsOfType(eventType, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, event := range events {
+		country := ""
+		if event.Location != nil {
+			country = event.Location.Country
+		}
+		counts[orUnknown(country)]++
+	}
+	return counts, nil
+}
+
+// GetUsersByDevice counts distinct users per device type within a date range
+func (r *AnalyticsRepository) GetUsersByDevice(ctx context.Context, startDate, endDate time.Time) (map[string]int, error) {
+	sessions, err := findAll[models.UserSession](r.sessions.collection, inRange(bson.M{}, "start_time", startDate, endDate), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string]map[primitive.ObjectID]bool{}
+	for _, session := range sessions {
+		if session.UserID == nil {
+			continue
+		}
+		device := orUnknown(session.Device.Type)
+		if users[device] == nil {
+			users[device] = map[primitive.ObjectID]bool{}
+		}
+		users[device][*session.UserID] = true
+	}
+
+	counts := map[string]int{}
+	for device, ids := range users {
+		counts[device] = len(ids)
+	}
+	return counts, nil
+}
+
+// GetSessionsByDevice counts sessions per device type within a date range
+func (r *AnalyticsRepository) GetSessionsByDevice(ctx context.Context, startDate, endDate time.Time) (map[string]int, error) {
+	sessions, err := findAll[models.UserSession](r.sessions.collection, inRange(bson.M{}, "start_time", startDate, endDate), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, session := range sessions {
+		counts[orUnknown(session.Device.Type)]++
+	}
+	return counts, nil
+}
+
+// GetActivityByTimeOfDay counts events of a type per UTC hour within a date range
+func (r *AnalyticsRepository) GetActivityByTimeOfDay(ctx context.Context, eventType string, startDate, endDate time.Time) (map[int]int, error) {
+	events, err := r.eventsOfType(eventType, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[int]int{}
+	for _, event := range events {
+		counts[event.CreatedAt.UTC().Hour()]++
+	}
+	return counts, nil
+}
+
+// GetActivityByDayOfWeek counts events of a type per weekday within a date
+// range, numbered 1 (Sunday) to 7 like MongoDB's $dayOfWeek
+func (r *AnalyticsRepository) GetActivityByDayOfWeek(ctx context.Context, eventType string, startDate, endDate time.Time) (map[int]int, error) {
+	events, err := r.eventsOfType(eventType, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[int]int{}
+	for _, event := range events {
+		counts[int(event.CreatedAt.UTC().Weekday())+1]++
+	}
+	return counts, nil
+}
+
+// DeleteOldEvents removes events recorded before a cutoff
+func (r *AnalyticsRepository) DeleteOldEvents(ctx context.Context, before time.Time) (int, error) {
+	return r.events.collection.DeleteMany(bson.M{"created_at": bson.M{"$lt": before}})
+}
+
+// DeleteOldSessions removes sessions started before a cutoff
+func (r *AnalyticsRepository) DeleteOldSessions(ctx context.Context, before time.Time) (int, error) {
+	return r.sessions.collection.DeleteMany(bson.M{"start_time": bson.M{"$lt": before}})
+}
+
+func (r *AnalyticsRepository) eventsOfType(eventType string, startDate, endDate time.Time) ([]*models.AnalyticsEvent, error) {
+	filter := inRange(bson.M{}, "created_at", startDate, endDate)
+	if eventType != "" {
+		filter["event_type"] = eventType
+	}
+	return findAll[models.AnalyticsEvent](r.events.collection, filter, nil)
+}
+
+// byStartDate sorts period documents latest period first
+var byStartDate = bson.D{{Key: "start_date", Value: -1}, {Key: "_id", Value: -1}}
+
+// newestFirst sorts documents by creation time, newest first
+var newestFirst = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// periodFilter matches the analytics documents of an entity for a period within a date range
+func periodFilter(field string, id primitive.ObjectID, period string, startDate, endDate time.Time) bson.M {
+	filter := bson.M{field: id, "period": period}
+	if !startDate.IsZero() {
+		filter["start_date"] = bson.M{"$gte": startDate}
+	}
+	if !endDate.IsZero() {
+		filter["end_date"] = bson.M{"$lte": endDate}
+	}
+	return filter
+}
+
+// upsertModel replaces the document matching filter with v, or inserts v when
+// there is none, and stores the resulting ID in id
+func upsertModel(s store, filter bson.M, id *primitive.ObjectID, v interface{}) (primitive.ObjectID, error) {
+	existing, err := s.collection.FindOne(filter, nil)
+	switch {
+	case err == nil:
+		*id = existing["_id"].(primitive.ObjectID)
+		return *id, s.replace(*id, v)
+	case err == mongo.ErrNoDocuments:
+		if id.IsZero() {
+			*id = primitive.NewObjectID()
+		}
+		return s.insert(v)
+	default:
+		return primitive.NilObjectID, err
+	}
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// BookmarkRepository implements interfaces.BookmarkRepository in memory
+type BookmarkRepository struct {
+	store
+	folders store
+}
+
+var _ interfaces.BookmarkRepository = (*BookmarkRepository)(nil)
+
+// NewBookmarkRepository creates an in-memory bookmark repository
+func NewBookmarkRepository(db *Database) *BookmarkRepository {
+	return &BookmarkRepository{
+		store:   newStore(db, constants.CollectionBookmarks),
+		folders: newStore(db, constants.CollectionBookmarkFolders),
+	}
+}
+
+// Create inserts a new bookmark. A user can bookmark a post only once.
+func (r *BookmarkRepository) Create(ctx context.Context, bookmark *models.Bookmark) (primitive.ObjectID, error) {
+	if bookmark.ID.IsZero() {
+		bookmark.ID = primitive.NewObjectID()
+	}
+	if bookmark.CreatedAt.IsZero() {
+		bookmark.CreatedAt = time.Now()
+	}
+	return r.insert(bookmark)
+}
+
+// Delete permanently removes a bookmark
+func (r *BookmarkRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// GetByUserAndPost retrieves a user's bookmark of a post
+func (r *BookmarkRepository) GetByUserAndPost(ctx context.Context, userID, postID primitive.ObjectID) (*models.Bookmark, error) {
+	return findOne[models.Bookmark](r.collection, bson.M{"user_id": userID, "post_id": postID}, nil)
+}
+
+// GetCollectionByName retrieves one of a user's bookmark collections by name
+func (r *BookmarkRepository) GetCollectionByName(ctx context.Context, userID primitive.ObjectID, name string) (*models.BookmarkCollection, error) {
+	return findOne[models.BookmarkCollection](r.folders.collection, bson.M{"user_id": userID, "name": name}, nil)
+}
+
+// IncrementCollectionItemCount atomically adjusts the item counter of a bookmark collection
+func (r *BookmarkRepository) IncrementCollectionItemCount(ctx context.Context, collectionID primitive.ObjectID, amount int) error {
+	return r.folders.increment(collectionID, "item_count", amount)
+}
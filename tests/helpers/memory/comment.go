@@ -0,0 +1,260 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// CommentRepository implements interfaces.CommentRepository in memory
+type CommentRepository struct {
+	store
+}
+
+var _ interfaces.CommentRepository = (*CommentRepository)(nil)
+
+// NewCommentRepository creates an in-memory comment repository
+func NewCommentRepository(db *Database) *CommentRepository {
+	return &CommentRepository{store: newStore(db, constants.CollectionComments)}
+}
+
+// Create inserts a new comment
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) (primitive.ObjectID, error) {
+	now := time.Now()
+	if comment.ID.IsZero() {
+		comment.ID = primitive.NewObjectID()
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = now
+	}
+	comment.UpdatedAt = now
+	return r.insert(comment)
+}
+
+// GetByID retrieves a comment by ID, ignoring soft-deleted comments
+func (r *CommentRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	return findOne[models.Comment](r.collection, notDeleted(id), nil)
+}
+
+// Update replaces a comment document
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	comment.UpdatedAt = time.Now()
+	return r.replace(comment.ID, comment)
+}
+
+// Delete permanently removes a comment
+func (r *CommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// SoftDelete marks a comment as deleted without removing it
+func (r *CommentRepository) SoftDelete(ctx context.Context, id primitive.ObjectID) error {
+	return r.softDelete(id)
+}
+
+// GetByPostID retrieves every visible comment on a post, newest first
+func (r *CommentRepository) GetByPostID(ctx context.Context, postID primitive.ObjectID, limit, offset int) ([]*models.Comment, int, error) {
+	filter := mongoutil.Merge(bson.M{"post_id": postID}, visibleComment)
+	return findPage[models.Comment](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByUserID retrieves the comments a user wrote, newest first
+func (r *CommentRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Comment, int, error) {
+	filter := mongoutil.Merge(bson.M{"user_id": userID}, mongoutil.NotDeleted())
+	return findPage[models.Comment](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetReplies retrieves the direct replies to a comment, oldest first
+func (r *CommentRepository) GetReplies(ctx context.Context, parentID primitive.ObjectID, limit, offset int) ([]*models.Comment, int, error) {
+	filter := mongoutil.Merge(bson.M{"parent_id": parentID}, visibleComment)
+	return findPage[models.Comment](r.collection, filter, oldestFirst, limit, offset)
+}
+
+// GetByIDs retrieves several comments by ID
+func (r *CommentRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Comment, error) {
+	if len(ids) == 0 {
+		return []*models.Comment{}, nil
+	}
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return findAll[models.Comment](r.collection, filter, nil)
+}
+
+// GetCommentThread retrieves a comment and all of its descendants, oldest first
+func (r *CommentRepository) GetCommentThread(ctx context.Context, rootID primitive.ObjectID, limit, offset int) ([]*models.Comment, int, error) {
+	ids := []primitive.ObjectID{rootID}
+	frontier := []primitive.ObjectID{rootID}
+	for len(frontier) > 0 {
+		children, err := r.collection.Distinct("_id", bson.M{"parent_id": bson.M{"$in": frontier}})
+		if err != nil {
+			return nil, 0, err
+		}
+		frontier = objectIDs(children)
+		ids = append(ids, frontier...)
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, visibleComment)
+	return findPage[models.Comment](r.collection, filter, oldestFirst, limit, offset)
+}
+
+// GetTopLevelComments retrieves the comments on a post that are not replies, pinned first
+func (r *CommentRepository) GetTopLevelComments(ctx context.Context, postID primitive.ObjectID, limit, offset int) ([]*models.Comment, int, error) {
+	filter := mongoutil.Merge(bson.M{"post_id": postID, "parent_id": nil}, visibleComment)
+	sort := bson.D{{Key: "is_pinned", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	return findPage[models.Comment](r.collection, filter, sort, limit, offset)
+}
+
+// IncrementLikeCount atomically adjusts the like counter
+func (r *CommentRepository) IncrementLikeCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "like_count", amount)
+}
+
+// IncrementReplyCount atomically adjusts the reply counter
+func (r *CommentRepository) IncrementReplyCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "reply_count", amount)
+}
+
+// UpdateReactionCounts replaces the per-reaction counters
+func (r *CommentRepository) UpdateReactionCounts(ctx context.Context, id primitive.ObjectID, reactionCounts map[string]int) error {
+	return r.updateFields(byID(id), bson.M{"reaction_counts": reactionCounts})
+}
+
+// PinComment pins or unpins a comment
+func (r *CommentRepository) PinComment(ctx context.Context, id primitive.ObjectID, isPinned bool) error {
+	return r.updateFields(byID(id), bson.M{"is_pinned": isPinned})
+}
+
+// HideComment hides or unhides a comment
+func (r *CommentRepository) HideComment(ctx context.Context, id primitive.ObjectID, isHidden bool) error {
+	return r.updateFields(byID(id), bson.M{"is_hidden": isHidden})
+}
+
+// GetReportedComments retrieves comments that have reports in the given status.
+// An empty status or "all" matches reports in any status.
+func (r *CommentRepository) GetReportedComments(ctx context.Context, status string, limit, offset int) ([]*models.Comment, int, error) {
+	ids, err := reportedContent(r.db, "comment", status)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(ids) == 0 {
+		return []*models.Comment{}, 0, nil
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return findPage[models.Comment](r.collection, filter, newestFirst, limit, offset)
+}
+
+// FlagAsInappropriate hides a comment and files a system report so it lands in the moderation queue
+func (r *CommentRepository) FlagAsInappropriate(ctx context.Context, id primitive.ObjectID, reason string) error {
+	if err := r.updateFields(byID(id), bson.M{"is_hidden": true}); err != nil {
+		return err
+	}
+	return fileSystemReport(r.db, id, "comment", reason)
+}
+
+// List retrieves comments with an arbitrary filter and sort.
+// Soft-deleted comments are excluded unless the filter mentions deleted_at.
+func (r *CommentRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Comment, int, error) {
+	return findPage[models.Comment](r.collection, listFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// GetMostLikedComments retrieves the most liked visible comments on a post
+func (r *CommentRepository) GetMostLikedComments(ctx context.Context, postID primitive.ObjectID, limit int) ([]*models.Comment, error) {
+	filter := mongoutil.Merge(bson.M{"post_id": postID}, visibleComment)
+	sort := bson.D{{Key: "like_count", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	return findLimit[models.Comment](r.collection, filter, sort, limit)
+}
+
+// GetUserCommentActivity counts the comments a user wrote between two dates
+// given as YYYY-MM-DD or RFC 3339. An empty bound is open.
+func (r *CommentRepository) GetUserCommentActivity(ctx context.Context, userID primitive.ObjectID, startDate, endDate string) (int, error) {
+	start, err := parseDate(startDate)
+	if err != nil {
+		return 0, err
+	}
+	end, err := parseDate(endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := inRange(mongoutil.Merge(bson.M{"user_id": userID}, mongoutil.NotDeleted()), "created_at", start, end)
+	return r.collection.Count(filter)
+}
+
+// GetTopCommenters returns the IDs of the users who wrote the most comments
+// since a time, most active first. A zero time counts every comment.
+func (r *CommentRepository) GetTopCommenters(ctx context.Context, since time.Time, limit int) ([]primitive.ObjectID, error) {
+	return r.rank(since, limit, func(comment *models.Comment) primitive.ObjectID { return comment.UserID })
+}
+
+// GetMostCommentedPosts returns the IDs of the posts that received the most
+// comments since a time, most commented first. A zero time counts every comment.
+func (r *CommentRepository) GetMostCommentedPosts(ctx context.Context, since time.Time, limit int) ([]primitive.ObjectID, error) {
+	return r.rank(since, limit, func(comment *models.Comment) primitive.ObjectID { return comment.PostID })
+}
+
+// rank counts the live comments written since a time per key and returns the keys with the most
+func (r *CommentRepository) rank(since time.Time, limit int, key func(*models.Comment) primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := inRange(mongoutil.NotDeleted(), "created_at", since, time.Time{})
+	comments, err := findAll[models.Comment](r.collection, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, comment := range comments {
+		counts[key(comment)]++
+	}
+	return rankIDs(counts, limit), nil
+}
+
+// visibleComment matches comments that can be shown in listings
+var visibleComment = bson.M{"deleted_at": nil, "is_hidden": false}
+
+// oldestFirst sorts documents by creation time, oldest first
+var oldestFirst = bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+
+// parseDate parses a date given as YYYY-MM-DD or RFC 3339
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// reportedContent returns the IDs of content of a type with reports in status
+func reportedContent(db *Database, contentType, status string) ([]primitive.ObjectID, error) {
+	filter := bson.M{"content_type": contentType}
+	if status != "" && status != "all" {
+		filter["status"] = status
+	}
+	values, err := db.Collection(constants.CollectionReports).Distinct("content_id", filter)
+	if err != nil {
+		return nil, err
+	}
+	return objectIDs(values), nil
+}
+
+// fileSystemReport files a pending report raised by the platform itself
+func fileSystemReport(db *Database, contentID primitive.ObjectID, contentType, reason string) error {
+	now := time.Now()
+	_, err := db.Collection(constants.CollectionReports).InsertOne(&models.Report{
+		ContentID:   contentID,
+		ContentType: contentType,
+		ReasonCode:  "inappropriate",
+		Description: reason,
+		Status:      "pending",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	return err
+}
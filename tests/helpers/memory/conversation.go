@@ -0,0 +1,308 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// ConversationRepository implements interfaces.ConversationRepository in memory
+type ConversationRepository struct {
+	store
+}
+
+var _ interfaces.ConversationRepository = (*ConversationRepository)(nil)
+
+// NewConversationRepository creates an in-memory conversation repository
+func NewConversationRepository(db *Database) *ConversationRepository {
+	return &ConversationRepository{store: newStore(db, constants.CollectionConversations)}
+}
+
+// Create inserts a new conversation
+func (r *ConversationRepository) Create(ctx context.Context, conversation *models.Conversation) (primitive.ObjectID, error) {
+	now := time.Now()
+	if conversation.ID.IsZero() {
+		conversation.ID = primitive.NewObjectID()
+	}
+	if conversation.CreatedAt.IsZero() {
+		conversation.CreatedAt = now
+	}
+	conversation.UpdatedAt = now
+	for i := range conversation.Participants {
+		if conversation.Participants[i].JoinedAt.IsZero() {
+			conversation.Participants[i].JoinedAt = now
+		}
+	}
+	return r.insert(conversation)
+}
+
+// GetByID retrieves a conversation by ID
+func (r *ConversationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Conversation, error) {
+	return findOne[models.Conversation](r.collection, byID(id), nil)
+}
+
+// Update replaces a conversation document
+func (r *ConversationRepository) Update(ctx context.Context, conversation *models.Conversation) error {
+	conversation.UpdatedAt = time.Now()
+	return r.replace(conversation.ID, conversation)
+}
+
+// Delete permanently removes a conversation
+func (r *ConversationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// SoftDelete hides a conversation for a single user. Other participants keep seeing it.
+func (r *ConversationRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	return r.update(byID(id), bson.M{"$addToSet": bson.M{"deleted_for_users": userID}})
+}
+
+// GetByUserID retrieves a user's conversations, most recently active first
+func (r *ConversationRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Conversation, int, error) {
+	return findPage[models.Conversation](r.collection, r.visibleTo(userID), byLastMessage, limit, offset)
+}
+
+// GetByParticipantIDs retrieves the conversation whose participants are exactly participantIDs
+func (r *ConversationRepository) GetByParticipantIDs(ctx context.Context, participantIDs []primitive.ObjectID) (*models.Conversation, error) {
+	return findOne[models.Conversation](r.collection, bson.M{
+		"participants.user_id": bson.M{"$all": participantIDs},
+		"participants":         bson.M{"$size": len(participantIDs)},
+	}, nil)
+}
+
+// GetDirectConversation retrieves the one-to-one conversation between two users
+func (r *ConversationRepository) GetDirectConversation(ctx context.Context, user1ID, user2ID primitive.ObjectID) (*models.Conversation, error) {
+	return findOne[models.Conversation](r.collection, bson.M{
+		"type":                 "direct",
+		"participants.user_id": bson.M{"$all": bson.A{user1ID, user2ID}},
+	}, nil)
+}
+
+// GetByIDs retrieves several conversations by ID
+func (r *ConversationRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Conversation, error) {
+	if len(ids) == 0 {
+		return []*models.Conversation{}, nil
+	}
+	return findAll[models.Conversation](r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// AddParticipant adds a user to a conversation, reactivating them if they left before
+func (r *ConversationRepository) AddParticipant(ctx context.Context, conversationID, userID primitive.ObjectID, role string) error {
+	return r.modify(byID(conversationID), func(conversation *models.Conversation) error {
+		if p := findParticipant(conversation, userID); p != nil {
+			p.IsActive = true
+			p.Role = role
+			return nil
+		}
+		conversation.Participants = append(conversation.Participants, models.Participant{
+			UserID:              userID,
+			JoinedAt:            time.Now(),
+			Role:                role,
+			IsActive:            true,
+			NotificationSetting: "all",
+		})
+		return nil
+	})
+}
+
+// RemoveParticipant removes a user from a conversation
+func (r *ConversationRepository) RemoveParticipant(ctx context.Context, conversationID, userID primitive.ObjectID) error {
+	return r.update(byID(conversationID), bson.M{"$pull": bson.M{"participants": bson.M{"user_id": userID}}})
+}
+
+// UpdateParticipantRole changes a participant's role
+func (r *ConversationRepository) UpdateParticipantRole(ctx context.Context, conversationID, userID primitive.ObjectID, role string) error {
+	return r.modifyParticipant(conversationID, userID, func(p *models.Participant) {
+		p.Role = role
+	})
+}
+
+// GetParticipants retrieves the participants of a conversation
+func (r *ConversationRepository) GetParticipants(ctx context.Context, conversationID primitive.ObjectID) ([]models.Participant, error) {
+	conversation, err := r.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return conversation.Participants, nil
+}
+
+// UpdateGroupInfo replaces the group settings of a conversation
+func (r *ConversationRepository) UpdateGroupInfo(ctx context.Context, conversationID primitive.ObjectID, info models.GroupChatInfo) error {
+	return r.updateFields(byID(conversationID), bson.M{"group_info": info})
+}
+
+// AddJoinRequest records a request to join a group conversation
+func (r *ConversationRepository) AddJoinRequest(ctx context.Context, conversationID primitive.ObjectID, request models.JoinRequest) error {
+	if request.RequestedAt.IsZero() {
+		request.RequestedAt = time.Now()
+	}
+	if request.Status == "" {
+		request.Status = "pending"
+	}
+	return r.update(byID(conversationID), bson.M{"$push": bson.M{"group_info.join_requests": request}})
+}
+
+// UpdateJoinRequest records the review of a user's pending join request
+func (r *ConversationRepository) UpdateJoinRequest(ctx context.Context, conversationID primitive.ObjectID, userID primitive.ObjectID, status string, reviewerID primitive.ObjectID) error {
+	return r.modify(byID(conversationID), func(conversation *models.Conversation) error {
+		if conversation.GroupInfo == nil {
+			return mongo.ErrNoDocuments
+		}
+		for i := range conversation.GroupInfo.JoinRequests {
+			request := &conversation.GroupInfo.JoinRequests[i]
+			if request.UserID == userID && request.Status == "pending" {
+				now := time.Now()
+				request.Status = status
+				request.ReviewedBy = &reviewerID
+				request.ReviewedAt = &now
+				return nil
+			}
+		}
+		return mongo.ErrNoDocuments
+	})
+}
+
+// GetPendingJoinRequests retrieves the join requests awaiting review
+func (r *ConversationRepository) GetPendingJoinRequests(ctx context.Context, conversationID primitive.ObjectID) ([]models.JoinRequest, error) {
+	conversation, err := r.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []models.JoinRequest{}
+	if conversation.GroupInfo != nil {
+		for _, request := range conversation.GroupInfo.JoinRequests {
+			if request.Status == "pending" {
+				pending = append(pending, request)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// UpdateLastMessage records the latest message of a conversation
+func (r *ConversationRepository) UpdateLastMessage(ctx context.Context, conversationID, messageID, senderID primitive.ObjectID, preview string) error {
+	return r.updateFields(byID(conversationID), bson.M{
+		"last_message_id":        messageID,
+		"last_message_sender_id": senderID,
+		"last_message_preview":   preview,
+		"last_message_at":        time.Now(),
+	})
+}
+
+// IncrementMessageCount atomically bumps the message counter
+func (r *ConversationRepository) IncrementMessageCount(ctx context.Context, conversationID primitive.ObjectID) error {
+	return r.increment(conversationID, "message_count", 1)
+}
+
+// UpdateParticipantLastRead moves a participant's read marker and clears their unread count
+func (r *ConversationRepository) UpdateParticipantLastRead(ctx context.Context, conversationID, userID, messageID primitive.ObjectID) error {
+	return r.modifyParticipant(conversationID, userID, func(p *models.Participant) {
+		now := time.Now()
+		p.LastReadMessageID = &messageID
+		p.LastReadAt = &now
+		p.UnreadCount = 0
+	})
+}
+
+// UpdateUnreadCount sets a participant's unread count
+func (r *ConversationRepository) UpdateUnreadCount(ctx context.Context, conversationID, userID primitive.ObjectID, count int) error {
+	return r.modifyParticipant(conversationID, userID, func(p *models.Participant) {
+		p.UnreadCount = count
+	})
+}
+
+// MarkTyping records that a participant is typing
+func (r *ConversationRepository) MarkTyping(ctx context.Context, conversationID, userID primitive.ObjectID) error {
+	return r.modifyParticipant(conversationID, userID, func(p *models.Participant) {
+		now := time.Now()
+		p.TypingAt = &now
+	})
+}
+
+// MuteConversation mutes a conversation for a user until the given time
+func (r *ConversationRepository) MuteConversation(ctx context.Context, conversationID, userID primitive.ObjectID, until primitive.DateTime) error {
+	return r.updateFields(byID(conversationID), bson.M{"muted_for_users." + userID.Hex(): until})
+}
+
+// UnmuteConversation unmutes a conversation for a user
+func (r *ConversationRepository) UnmuteConversation(ctx context.Context, conversationID, userID primitive.ObjectID) error {
+	return r.update(byID(conversationID), bson.M{"$unset": bson.M{"muted_for_users." + userID.Hex(): ""}})
+}
+
+// ArchiveConversation archives a conversation for a user
+func (r *ConversationRepository) ArchiveConversation(ctx context.Context, conversationID, userID primitive.ObjectID) error {
+	return r.update(byID(conversationID), bson.M{"$addToSet": bson.M{"archived_for_users": userID}})
+}
+
+// UnarchiveConversation unarchives a conversation for a user
+func (r *ConversationRepository) UnarchiveConversation(ctx context.Context, conversationID, userID primitive.ObjectID) error {
+	return r.update(byID(conversationID), bson.M{"$pull": bson.M{"archived_for_users": userID}})
+}
+
+// EnableEncryption turns on end-to-end encryption
+func (r *ConversationRepository) EnableEncryption(ctx context.Context, conversationID primitive.ObjectID) error {
+	return r.updateFields(byID(conversationID), bson.M{"is_encrypted": true, "encryption_enabled": time.Now()})
+}
+
+// DisableEncryption turns off end-to-end encryption
+func (r *ConversationRepository) DisableEncryption(ctx context.Context, conversationID primitive.ObjectID) error {
+	return r.updateFields(byID(conversationID), bson.M{"is_encrypted": false})
+}
+
+// List retrieves conversations with an arbitrary filter and sort
+func (r *ConversationRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Conversation, int, error) {
+	return findPage[models.Conversation](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, byLastMessage), limit, offset)
+}
+
+// SearchConversations searches a user's conversations by title and last message
+func (r *ConversationRepository) SearchConversations(ctx context.Context, userID primitive.ObjectID, query string, limit, offset int) ([]*models.Conversation, int, error) {
+	filter := and(r.visibleTo(userID), textFilter(query, "title", "last_message_preview"))
+	return findPage[models.Conversation](r.collection, filter, byLastMessage, limit, offset)
+}
+
+// byLastMessage sorts conversations most recently active first
+var byLastMessage = bson.D{{Key: "last_message_at", Value: -1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// visibleTo matches the conversations a user takes part in and has not deleted
+func (r *ConversationRepository) visibleTo(userID primitive.ObjectID) bson.M {
+	return bson.M{
+		"participants.user_id": userID,
+		"deleted_for_users":    bson.M{"$ne": userID},
+	}
+}
+
+func (r *ConversationRepository) modify(filter bson.M, fn func(conversation *models.Conversation) error) error {
+	return modify(r.collection, filter, func(conversation *models.Conversation) error {
+		if err := fn(conversation); err != nil {
+			return err
+		}
+		conversation.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *ConversationRepository) modifyParticipant(conversationID, userID primitive.ObjectID, fn func(p *models.Participant)) error {
+	filter := bson.M{"_id": conversationID, "participants.user_id": userID}
+	return r.modify(filter, func(conversation *models.Conversation) error {
+		fn(findParticipant(conversation, userID))
+		return nil
+	})
+}
+
+func findParticipant(conversation *models.Conversation, userID primitive.ObjectID) *models.Participant {
+	for i := range conversation.Participants {
+		if conversation.Participants[i].UserID == userID {
+			return &conversation.Participants[i]
+		}
+	}
+	return nil
+}
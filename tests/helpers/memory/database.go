@@ -0,0 +1,609 @@
+// Package memory provides thread-safe in-memory implementations of the
+// repository interfaces for tests. The repositories run the same filters,
+// sorts and updates as the MongoDB ones against an in-memory document store,
+// so pagination, soft delete and counter behaviour match production.
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	dbmongo "github.com/Caqil/vyrall/internal/database/mongodb"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+)
+
+// Database is an in-memory stand-in for a MongoDB database
+type Database struct {
+	mu          sync.Mutex
+	collections map[string]*Collection
+}
+
+// NewDatabase creates an empty database
+func NewDatabase() *Database {
+	return &Database{collections: make(map[string]*Collection)}
+}
+
+// Collection returns the named collection, creating it on first use. Unique
+// indexes declared for the collection in the production index set are enforced.
+func (d *Database) Collection(name string) *Collection {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if c, ok := d.collections[name]; ok {
+		return c
+	}
+
+	c := &Collection{name: name}
+	for _, model := range dbmongo.Indexes[name] {
+		if model.Options == nil || model.Options.Unique == nil || !*model.Options.Unique {
+			continue
+		}
+		keys, ok := model.Keys.(bson.D)
+		if !ok {
+			continue
+		}
+		index := uniqueIndex{sparse: model.Options.Sparse != nil && *model.Options.Sparse}
+		for _, key := range keys {
+			index.fields = append(index.fields, key.Key)
+		}
+		c.unique = append(c.unique, index)
+	}
+
+	d.collections[name] = c
+	return c
+}
+
+// CollectionNames returns the names of every collection holding documents
+func (d *Database) CollectionNames() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make([]string, 0, len(d.collections))
+	for name, c := range d.collections {
+		if c.Len() > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Drop removes every document from every collection
+func (d *Database) Drop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, c := range d.collections {
+		c.Drop()
+	}
+}
+
+// snapshot copies the documents of every collection
+func (d *Database) snapshot() map[string][]bson.M {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snap := make(map[string][]bson.M, len(d.collections))
+	for name, c := range d.collections {
+		c.mu.RLock()
+		docs := make([]bson.M, len(c.docs))
+		for i, doc := range c.docs {
+			docs[i] = copyDocument(doc)
+		}
+		c.mu.RUnlock()
+		snap[name] = docs
+	}
+	return snap
+}
+
+// restore puts every collection back to the state captured by snapshot.
+// Collections created since are emptied.
+func (d *Database) restore(snap map[string][]bson.M) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, c := range d.collections {
+		c.mu.Lock()
+		c.docs = snap[name]
+		c.mu.Unlock()
+	}
+}
+
+// uniqueIndex is a unique constraint over one or more fields
+type uniqueIndex struct {
+	fields []string
+	sparse bool
+}
+
+// Collection is an in-memory collection of BSON documents in insertion order
+type Collection struct {
+	name   string
+	mu     sync.RWMutex
+	docs   []bson.M
+	unique []uniqueIndex
+}
+
+// Name returns the collection name
+func (c *Collection) Name() string {
+	return c.name
+}
+
+// Len returns the number of documents in the collection
+func (c *Collection) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.docs)
+}
+
+// Drop removes every document from the collection
+func (c *Collection) Drop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.docs = nil
+}
+
+// InsertOne stores a copy of v. A missing _id is generated. Violating a unique
+// index returns a write exception that mongo.IsDuplicateKeyError recognises.
+func (c *Collection) InsertOne(v interface{}) (primitive.ObjectID, error) {
+	doc, err := toDocument(v)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	// Keep caller-chosen IDs of other types, such as string key IDs
+	id, ok := doc["_id"].(primitive.ObjectID)
+	if (!ok && doc["_id"] == nil) || (ok && id.IsZero()) {
+		id = primitive.NewObjectID()
+		doc["_id"] = id
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkUnique(doc, -1); err != nil {
+		return primitive.NilObjectID, err
+	}
+	c.docs = append(c.docs, doc)
+	return id, nil
+}
+
+// FindOne returns a copy of the first document matching filter in sort order
+func (c *Collection) FindOne(filter bson.M, sort bson.D) (bson.M, error) {
+	docs, err := c.Find(filter, sort, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return docs[0], nil
+}
+
+// Find returns copies of the documents matching filter in sort order. A
+// non-positive limit returns every match after skip.
+func (c *Collection) Find(filter bson.M, sort bson.D, limit, skip int) ([]bson.M, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	matched, err := c.match(filter)
+	if err != nil {
+		return nil, err
+	}
+	sortDocuments(matched, sort)
+
+	if skip > len(matched) {
+		skip = len(matched)
+	}
+	matched = matched[skip:]
+	if limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	result := make([]bson.M, len(matched))
+	for i, doc := range matched {
+		result[i] = copyDocument(doc)
+	}
+	return result, nil
+}
+
+// Count returns the number of documents matching filter
+func (c *Collection) Count(filter bson.M) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	matched, err := c.match(filter)
+	if err != nil {
+		return 0, err
+	}
+	return len(matched), nil
+}
+
+// Distinct returns the distinct values of field across documents matching
+// filter. Array fields contribute each of their elements.
+func (c *Collection) Distinct(field string, filter bson.M) ([]interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	matched, err := c.match(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+	for _, doc := range matched {
+		candidates, _ := lookup(doc, field)
+		for _, candidate := range expand(candidates) {
+			if _, isArray := candidate.(primitive.A); isArray {
+				continue
+			}
+			seen := false
+			for _, value := range values {
+				if equal(value, candidate) {
+					seen = true
+					break
+				}
+			}
+			if !seen {
+				values = append(values, candidate)
+			}
+		}
+	}
+	return values, nil
+}
+
+// UpdateOne applies update to the first document matching filter and returns
+// the number of matched documents
+func (c *Collection) UpdateOne(filter, update bson.M) (int, error) {
+	return c.update(filter, update, false, false)
+}
+
+// UpdateMany applies update to every document matching filter
+func (c *Collection) UpdateMany(filter, update bson.M) (int, error) {
+	return c.update(filter, update, true, false)
+}
+
+// Upsert applies update to the first document matching filter, inserting a new
+// document built from the filter's equality fields when nothing matches
+func (c *Collection) Upsert(filter, update bson.M) error {
+	_, err := c.update(filter, update, false, true)
+	return err
+}
+
+// ReplaceOne replaces the first document matching filter with v, keeping its _id
+func (c *Collection) ReplaceOne(filter bson.M, v interface{}) (int, error) {
+	replacement, err := toDocument(v)
+	if err != nil {
+		return 0, err
+	}
+	filter = normalizeFilter(filter)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, doc := range c.docs {
+		ok, err := matches(doc, filter)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		replacement["_id"] = doc["_id"]
+		if err := c.checkUnique(replacement, i); err != nil {
+			return 0, err
+		}
+		c.docs[i] = replacement
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Modify atomically rewrites the first document matching filter with fn. It
+// stands in for updates the in-memory engine has no operator for, such as
+// positional array updates.
+func (c *Collection) Modify(filter bson.M, fn func(doc bson.M) (bson.M, error)) (int, error) {
+	filter = normalizeFilter(filter)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, doc := range c.docs {
+		ok, err := matches(doc, filter)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		updated, err := fn(copyDocument(doc))
+		if err != nil {
+			return 0, err
+		}
+		updated["_id"] = doc["_id"]
+		if err := c.checkUnique(updated, i); err != nil {
+			return 0, err
+		}
+		c.docs[i] = updated
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// DeleteOne removes the first document matching filter and returns how many were removed
+func (c *Collection) DeleteOne(filter bson.M) (int, error) {
+	return c.delete(filter, false)
+}
+
+// DeleteMany removes every document matching filter
+func (c *Collection) DeleteMany(filter bson.M) (int, error) {
+	return c.delete(filter, true)
+}
+
+func (c *Collection) match(filter bson.M) ([]bson.M, error) {
+	filter = normalizeFilter(filter)
+
+	matched := []bson.M{}
+	for _, doc := range c.docs {
+		ok, err := matches(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, doc)
+		}
+	}
+	return matched, nil
+}
+
+func (c *Collection) update(filter, update bson.M, many, upsert bool) (int, error) {
+	filter = normalizeFilter(filter)
+	update = normalizeFilter(update)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matched := 0
+	for i, doc := range c.docs {
+		ok, err := matches(doc, filter)
+		if err != nil {
+			return matched, err
+		}
+		if !ok {
+			continue
+		}
+
+		updated := copyDocument(doc)
+		if err := applyUpdate(updated, update, false); err != nil {
+			return matched, err
+		}
+		if err := c.checkUnique(updated, i); err != nil {
+			return matched, err
+		}
+		c.docs[i] = updated
+		matched++
+
+		if !many {
+			break
+		}
+	}
+
+	if matched == 0 && upsert {
+		doc := seedFromFilter(filter)
+		if err := applyUpdate(doc, update, true); err != nil {
+			return 0, err
+		}
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = primitive.NewObjectID()
+		}
+		if err := c.checkUnique(doc, -1); err != nil {
+			return 0, err
+		}
+		c.docs = append(c.docs, doc)
+	}
+
+	return matched, nil
+}
+
+func (c *Collection) delete(filter bson.M, many bool) (int, error) {
+	filter = normalizeFilter(filter)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.docs[:0:0]
+	deleted := 0
+	for _, doc := range c.docs {
+		if many || deleted == 0 {
+			ok, err := matches(doc, filter)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				deleted++
+				continue
+			}
+		}
+		kept = append(kept, doc)
+	}
+	c.docs = kept
+	return deleted, nil
+}
+
+// checkUnique reports a duplicate key error if doc collides with any document
+// other than the one at position self
+func (c *Collection) checkUnique(doc bson.M, self int) error {
+	if err := c.checkUniqueField(doc, self, []string{"_id"}, false); err != nil {
+		return err
+	}
+	for _, index := range c.unique {
+		if err := c.checkUniqueField(doc, self, index.fields, index.sparse); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Collection) checkUniqueField(doc bson.M, self int, fields []string, sparse bool) error {
+	key := make([]interface{}, len(fields))
+	present := false
+	for i, field := range fields {
+		key[i], _ = lookupValue(doc, field)
+		if key[i] != nil {
+			present = true
+		}
+	}
+	if sparse && !present {
+		return nil
+	}
+
+	for i, other := range c.docs {
+		if i == self {
+			continue
+		}
+		same := true
+		for j, field := range fields {
+			value, _ := lookupValue(other, field)
+			if !equal(value, key[j]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return duplicateKeyError(c.name, fields)
+		}
+	}
+	return nil
+}
+
+func duplicateKeyError(collection string, fields []string) error {
+	return mongo.WriteException{
+		WriteErrors: []mongo.WriteError{{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: " + collection + " index: " + joinFields(fields),
+		}},
+	}
+}
+
+func joinFields(fields []string) string {
+	result := ""
+	for i, field := range fields {
+		if i > 0 {
+			result += "_"
+		}
+		result += field + "_1"
+	}
+	return result
+}
+
+// decode converts a stored document back into a model
+func decode[T any](doc bson.M) (*T, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := bson.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// findOne returns the first model matching filter or mongo.ErrNoDocuments
+func findOne[T any](c *Collection, filter bson.M, sort bson.D) (*T, error) {
+	doc, err := c.FindOne(filter, sort)
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](doc)
+}
+
+// findAll returns every model matching filter in sort order
+func findAll[T any](c *Collection, filter bson.M, sort bson.D) ([]*T, error) {
+	docs, err := c.Find(filter, sort, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](docs)
+}
+
+// findLimit returns at most limit models matching filter in sort order
+func findLimit[T any](c *Collection, filter bson.M, sort bson.D, limit int) ([]*T, error) {
+	limit, _ = mongoutil.NormalizePagination(limit, 0)
+	docs, err := c.Find(filter, sort, limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](docs)
+}
+
+// findPage returns one page of models matching filter plus the total number of
+// matches, clamping limit and offset the way the MongoDB repositories do
+func findPage[T any](c *Collection, filter bson.M, sort bson.D, limit, offset int) ([]*T, int, error) {
+	limit, offset = mongoutil.NormalizePagination(limit, offset)
+
+	total, err := c.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	docs, err := c.Find(filter, sort, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := decodeAll[T](docs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func decodeAll[T any](docs []bson.M) ([]*T, error) {
+	items := make([]*T, 0, len(docs))
+	for _, doc := range docs {
+		item, err := decode[T](doc)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// modify atomically decodes the first model matching filter, lets fn change it
+// and stores the result. It returns mongo.ErrNoDocuments when nothing matches.
+func modify[T any](c *Collection, filter bson.M, fn func(v *T) error) error {
+	return matchedOne(c.Modify(filter, func(doc bson.M) (bson.M, error) {
+		v, err := decode[T](doc)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(v); err != nil {
+			return nil, err
+		}
+		return toDocument(v)
+	}))
+}
+
+// values converts a slice of models into a slice of values
+func values[T any](items []*T) []T {
+	result := make([]T, len(items))
+	for i, item := range items {
+		result[i] = *item
+	}
+	return result
+}
+
+// matchedOne turns an update count into the not-found error the MongoDB repositories return
+func matchedOne(matched int, err error) error {
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
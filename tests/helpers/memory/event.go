@@ -0,0 +1,549 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// collectionEventViewers records who has viewed each event, for unique view counts
+const collectionEventViewers = "event_viewers"
+
+// maxRecurringInstances bounds how many occurrences CreateRecurringInstances generates
+const maxRecurringInstances = 366
+
+// EventRepository implements interfaces.EventRepository in memory
+type EventRepository struct {
+	store
+	attendees store
+	reminders store
+	analytics store
+	viewers   store
+}
+
+var _ interfaces.EventRepository = (*EventRepository)(nil)
+
+// NewEventRepository creates an in-memory event repository
+func NewEventRepository(db *Database) *EventRepository {
+	return &EventRepository{
+		store:     newStore(db, constants.CollectionEvents),
+		attendees: newStore(db, constants.CollectionEventAttendees),
+		reminders: newStore(db, constants.CollectionEventReminders),
+		analytics: newStore(db, collectionEventAnalytics),
+		viewers:   newStore(db, collectionEventViewers),
+	}
+}
+
+// Create inserts a new event
+func (r *EventRepository) Create(ctx context.Context, event *models.Event) (primitive.ObjectID, error) {
+	now := time.Now()
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = now
+	}
+	event.UpdatedAt = now
+	if event.Status == "" {
+		event.Status = "scheduled"
+	}
+	return r.insert(event)
+}
+
+// GetByID retrieves an event by ID
+func (r *EventRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+	return findOne[models.Event](r.collection, byID(id), nil)
+}
+
+// Update replaces an event document
+func (r *EventRepository) Update(ctx context.Context, event *models.Event) error {
+	event.UpdatedAt = time.Now()
+	return r.replace(event.ID, event)
+}
+
+// Delete permanently removes an event
+func (r *EventRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// GetByHostID retrieves the events a user hosts or co-hosts, latest first
+func (r *EventRepository) GetByHostID(ctx context.Context, hostID primitive.ObjectID, limit, offset int) ([]*models.Event, int, error) {
+	filter := bson.M{"$or": bson.A{bson.M{"host_id": hostID}, bson.M{"co_hosts": hostID}}}
+	return findPage[models.Event](r.collection, filter, latestStart, limit, offset)
+}
+
+// GetByGroupID retrieves the events of a group, latest first
+func (r *EventRepository) GetByGroupID(ctx context.Context, groupID primitive.ObjectID, limit, offset int) ([]*models.Event, int, error) {
+	return findPage[models.Event](r.collection, bson.M{"group_id": groupID}, latestStart, limit, offset)
+}
+
+// GetByIDs retrieves several events by ID
+func (r *EventRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Event, error) {
+	if len(ids) == 0 {
+		return []*models.Event{}, nil
+	}
+	return findAll[models.Event](r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// GetByLocation retrieves public events within radiusKm of a point, nearest first
+func (r *EventRepository) GetByLocation(ctx context.Context, lat, lng float64, radiusKm float64, limit, offset int) ([]*models.Event, int, error) {
+	events, err := findAll[models.Event](r.collection, bson.M{
+		"privacy":              "public",
+		"status":               bson.M{"$ne": "cancelled"},
+		"location.coordinates": bson.M{"$exists": true},
+	}, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	distances := map[primitive.ObjectID]float64{}
+	nearby := []*models.Event{}
+	for _, event := range events {
+		if d, ok := distanceKm(event.Location.Coordinates, lat, lng); ok && d <= radiusKm {
+			distances[event.ID] = d
+			nearby = append(nearby, event)
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return distances[nearby[i].ID] < distances[nearby[j].ID]
+	})
+
+	page, total := paginate(nearby, limit, offset)
+	return page, total, nil
+}
+
+// GetRecurringEvents retrieves the occurrences of a recurring event in order
+func (r *EventRepository) GetRecurringEvents(ctx context.Context, parentID primitive.ObjectID) ([]*models.Event, error) {
+	return findAll[models.Event](r.collection, bson.M{"parent_event_id": parentID}, earliestStart)
+}
+
+// GetUpcomingEvents retrieves public events that have not started, soonest first
+func (r *EventRepository) GetUpcomingEvents(ctx context.Context, limit, offset int) ([]*models.Event, int, error) {
+	filter := mongoutil.Merge(activeEvent, bson.M{"privacy": "public", "start_time": bson.M{"$gt": time.Now()}})
+	return findPage[models.Event](r.collection, filter, earliestStart, limit, offset)
+}
+
+// GetUpcomingEventsForUser retrieves upcoming events a user is going to or interested in
+func (r *EventRepository) GetUpcomingEventsForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Event, int, error) {
+	values, err := r.attendees.collection.Distinct("event_id", bson.M{
+		"user_id": userID,
+		"rsvp":    bson.M{"$in": bson.A{"going", "interested"}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := mongoutil.Merge(activeEvent, bson.M{"_id": bson.M{"$in": values}, "start_time": bson.M{"$gt": time.Now()}})
+	return findPage[models.Event](r.collection, filter, earliestStart, limit, offset)
+}
+
+// GetEventsByDateRange retrieves events starting within a date range, soonest first
+func (r *EventRepository) GetEventsByDateRange(ctx context.Context, startDate, endDate time.Time, limit, offset int) ([]*models.Event, int, error) {
+	filter := inRange(mongoutil.Merge(activeEvent, bson.M{"privacy": "public"}), "start_time", startDate, endDate)
+	return findPage[models.Event](r.collection, filter, earliestStart, limit, offset)
+}
+
+// GetPastEvents retrieves public events that have ended, most recent first
+func (r *EventRepository) GetPastEvents(ctx context.Context, limit, offset int) ([]*models.Event, int, error) {
+	filter := bson.M{"privacy": "public", "end_time": bson.M{"$lt": time.Now()}}
+	return findPage[models.Event](r.collection, filter, latestStart, limit, offset)
+}
+
+// GetOngoingEvents retrieves public events happening now
+func (r *EventRepository) GetOngoingEvents(ctx context.Context, limit, offset int) ([]*models.Event, int, error) {
+	now := time.Now()
+	filter := mongoutil.Merge(activeEvent, bson.M{
+		"privacy":    "public",
+		"start_time": bson.M{"$lte": now},
+		"end_time":   bson.M{"$gte": now},
+	})
+	return findPage[models.Event](r.collection, filter, earliestStart, limit, offset)
+}
+
+// AddAttendee records a user's RSVP. Going RSVPs beyond the event's capacity
+// are waitlisted. A second RSVP by the same user is a duplicate key error.
+func (r *EventRepository) AddAttendee(ctx context.Context, eventID, userID primitive.ObjectID, rsvp string) (primitive.ObjectID, error) {
+	event, err := r.GetByID(ctx, eventID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	attendee := &models.EventAttendee{
+		ID:            primitive.NewObjectID(),
+		EventID:       eventID,
+		UserID:        userID,
+		RSVP:          rsvp,
+		RSVPTimestamp: time.Now(),
+	}
+	if rsvp == "going" && event.MaxAttendees > 0 {
+		going, err := r.attendees.collection.Count(bson.M{"event_id": eventID, "rsvp": "going", "is_waitlisted": false})
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		if going >= event.MaxAttendees {
+			waiting, err := r.attendees.collection.Count(bson.M{"event_id": eventID, "is_waitlisted": true})
+			if err != nil {
+				return primitive.NilObjectID, err
+			}
+			attendee.IsWaitlisted = true
+			attendee.WaitlistPosition = waiting + 1
+		}
+	}
+
+	id, err := r.attendees.insert(attendee)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return id, r.UpdateRSVPCounts(ctx, eventID)
+}
+
+// UpdateAttendeeStatus changes a user's RSVP
+func (r *EventRepository) UpdateAttendeeStatus(ctx context.Context, eventID, userID primitive.ObjectID, rsvp string) error {
+	err := matchedOne(r.attendees.collection.UpdateOne(
+		bson.M{"event_id": eventID, "user_id": userID},
+		bson.M{"$set": bson.M{"rsvp": rsvp, "rsvp_timestamp": time.Now()}},
+	))
+	if err != nil {
+		return err
+	}
+	return r.UpdateRSVPCounts(ctx, eventID)
+}
+
+// RemoveAttendee deletes a user's RSVP
+func (r *EventRepository) RemoveAttendee(ctx context.Context, eventID, userID primitive.ObjectID) error {
+	if err := matchedOne(r.attendees.collection.DeleteOne(bson.M{"event_id": eventID, "user_id": userID})); err != nil {
+		return err
+	}
+	return r.UpdateRSVPCounts(ctx, eventID)
+}
+
+// GetAttendees retrieves the attendees of an event with an RSVP, in RSVP order.
+// An empty rsvp matches every attendee.
+func (r *EventRepository) GetAttendees(ctx context.Context, eventID primitive.ObjectID, rsvp string, limit, offset int) ([]*models.EventAttendee, int, error) {
+	filter := bson.M{"event_id": eventID}
+	if rsvp != "" {
+		filter["rsvp"] = rsvp
+	}
+	return findPage[models.EventAttendee](r.attendees.collection, filter, byRSVPTime, limit, offset)
+}
+
+// GetAttendeeByID retrieves a user's RSVP to an event
+func (r *EventRepository) GetAttendeeByID(ctx context.Context, eventID, userID primitive.ObjectID) (*models.EventAttendee, error) {
+	return findOne[models.EventAttendee](r.attendees.collection, bson.M{"event_id": eventID, "user_id": userID}, nil)
+}
+
+// UpdateRSVPCounts recomputes the RSVP counters of an event from its attendees
+func (r *EventRepository) UpdateRSVPCounts(ctx context.Context, eventID primitive.ObjectID) error {
+	attendees, err := findAll[models.EventAttendee](r.attendees.collection, bson.M{"event_id": eventID}, nil)
+	if err != nil {
+		return err
+	}
+
+	counts := models.EventRSVPCounts{}
+	for _, attendee := range attendees {
+		switch {
+		case attendee.IsWaitlisted:
+			counts.Waitlist++
+		case attendee.RSVP == "going":
+			counts.Going++
+		case attendee.RSVP == "interested":
+			counts.Interested++
+		case attendee.RSVP == "not_going":
+			counts.NotGoing++
+		default:
+			counts.NoReply++
+		}
+	}
+
+	return r.updateFields(byID(eventID), bson.M{"rsvp_count": counts})
+}
+
+// UpdateAttendee replaces an attendee record and recomputes the event's RSVP counters
+func (r *EventRepository) UpdateAttendee(ctx context.Context, attendee *models.EventAttendee) error {
+	if err := r.attendees.replace(attendee.ID, attendee); err != nil {
+		return err
+	}
+	return r.UpdateRSVPCounts(ctx, attendee.EventID)
+}
+
+// ListAttendees retrieves attendee records with an arbitrary filter and sort
+func (r *EventRepository) ListAttendees(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.EventAttendee, int, error) {
+	return findPage[models.EventAttendee](r.attendees.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, byRSVPTime), limit, offset)
+}
+
+// UpdateStatus sets the status of an event
+func (r *EventRepository) UpdateStatus(ctx context.Context, eventID primitive.ObjectID, status string) error {
+	return r.updateFields(byID(eventID), bson.M{"status": status})
+}
+
+// CancelEvent marks an event as cancelled
+func (r *EventRepository) CancelEvent(ctx context.Context, eventID primitive.ObjectID) error {
+	return r.UpdateStatus(ctx, eventID, "cancelled")
+}
+
+// CheckInAttendee records that an attendee arrived
+func (r *EventRepository) CheckInAttendee(ctx context.Context, eventID, userID primitive.ObjectID) error {
+	return matchedOne(r.attendees.collection.UpdateOne(
+		bson.M{"event_id": eventID, "user_id": userID},
+		bson.M{"$set": bson.M{"checked_in": true, "check_in_time": time.Now()}},
+	))
+}
+
+// GetCheckedInAttendees retrieves the attendees who checked in, in arrival order
+func (r *EventRepository) GetCheckedInAttendees(ctx context.Context, eventID primitive.ObjectID, limit, offset int) ([]*models.EventAttendee, int, error) {
+	sort := bson.D{{Key: "check_in_time", Value: 1}, {Key: "_id", Value: 1}}
+	return findPage[models.EventAttendee](r.attendees.collection, bson.M{"event_id": eventID, "checked_in": true}, sort, limit, offset)
+}
+
+// UpdateTicketInfo replaces the ticketing settings of an event
+func (r *EventRepository) UpdateTicketInfo(ctx context.Context, eventID primitive.ObjectID, ticketInfo models.EventTicketInfo) error {
+	return r.updateFields(byID(eventID), bson.M{"ticket_info": ticketInfo})
+}
+
+// UpdateTicketSales records the sale of quantity tickets of a type. Like the
+// conditional update it mirrors, it matches nothing when too few tickets are left.
+func (r *EventRepository) UpdateTicketSales(ctx context.Context, eventID primitive.ObjectID, ticketTypeID string, quantity int) error {
+	return modify(r.collection, byID(eventID), func(event *models.Event) error {
+		if event.TicketInfo == nil {
+			return mongo.ErrNoDocuments
+		}
+		for i := range event.TicketInfo.TicketTypes {
+			ticket := &event.TicketInfo.TicketTypes[i]
+			if ticket.ID != ticketTypeID {
+				continue
+			}
+			if ticket.Available < quantity {
+				return mongo.ErrNoDocuments
+			}
+			ticket.Available -= quantity
+			ticket.Sold += quantity
+			event.UpdatedAt = time.Now()
+			return nil
+		}
+		return mongo.ErrNoDocuments
+	})
+}
+
+// CreateRecurringInstances creates the occurrences of a recurring event up to
+// endDate. rule is daily, weekly or monthly, or an RRULE with that FREQ.
+func (r *EventRepository) CreateRecurringInstances(ctx context.Context, parentEventID primitive.ObjectID, rule string, endDate time.Time) ([]primitive.ObjectID, error) {
+	parent, err := r.GetByID(ctx, parentEventID)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := recurrence(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{}
+	duration := parent.EndTime.Sub(parent.StartTime)
+	start := next(parent.StartTime)
+	for !start.After(endDate) && len(ids) < maxRecurringInstances {
+		instance := *parent
+		instance.ID = primitive.NilObjectID
+		instance.StartTime = start
+		instance.EndTime = start.Add(duration)
+		instance.ParentEventID = &parentEventID
+		instance.IsRecurring = false
+		instance.RecurrenceRule = ""
+		instance.RSVPCount = models.EventRSVPCounts{}
+		instance.Status = "scheduled"
+		instance.CreatedAt = time.Time{}
+
+		id, err := r.Create(ctx, &instance)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+		start = next(start)
+	}
+	return ids, nil
+}
+
+// CreateReminder schedules a reminder for an event
+func (r *EventRepository) CreateReminder(ctx context.Context, reminder *models.EventReminder) (primitive.ObjectID, error) {
+	if reminder.ID.IsZero() {
+		reminder.ID = primitive.NewObjectID()
+	}
+	if reminder.CreatedAt.IsZero() {
+		reminder.CreatedAt = time.Now()
+	}
+	if reminder.Status == "" {
+		reminder.Status = "pending"
+	}
+	return r.reminders.insert(reminder)
+}
+
+// GetRemindersToSend retrieves pending reminders due before a time, earliest first
+func (r *EventRepository) GetRemindersToSend(ctx context.Context, before time.Time) ([]*models.EventReminder, error) {
+	sort := bson.D{{Key: "reminder_time", Value: 1}, {Key: "_id", Value: 1}}
+	return findAll[models.EventReminder](r.reminders.collection, bson.M{
+		"status":        "pending",
+		"reminder_time": bson.M{"$lte": before},
+	}, sort)
+}
+
+// MarkReminderSent marks a reminder as sent
+func (r *EventRepository) MarkReminderSent(ctx context.Context, reminderID primitive.ObjectID) error {
+	return matchedOne(r.reminders.collection.UpdateOne(
+		byID(reminderID),
+		bson.M{"$set": bson.M{"status": "sent", "sent_at": time.Now()}},
+	))
+}
+
+// UpdateReminder replaces a reminder
+func (r *EventRepository) UpdateReminder(ctx context.Context, reminder *models.EventReminder) error {
+	return r.reminders.replace(reminder.ID, reminder)
+}
+
+// DeleteReminder permanently removes a reminder
+func (r *EventRepository) DeleteReminder(ctx context.Context, reminderID primitive.ObjectID) error {
+	return r.reminders.remove(reminderID)
+}
+
+// ListReminders retrieves reminders with an arbitrary filter and sort
+func (r *EventRepository) ListReminders(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.EventReminder, int, error) {
+	byTime := bson.D{{Key: "reminder_time", Value: 1}, {Key: "_id", Value: 1}}
+	return findPage[models.EventReminder](r.reminders.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, byTime), limit, offset)
+}
+
+// GetEventStats returns the stored analytics of an event with the check-in
+// rate recomputed from its attendees
+func (r *EventRepository) GetEventStats(ctx context.Context, eventID primitive.ObjectID) (models.EventAnalytics, error) {
+	stats := models.EventAnalytics{EventID: eventID}
+	stored, err := findOne[models.EventAnalytics](r.analytics.collection, bson.M{"event_id": eventID}, nil)
+	switch {
+	case err == nil:
+		stats = *stored
+	case err != mongo.ErrNoDocuments:
+		return stats, err
+	}
+
+	going, err := r.attendees.collection.Count(bson.M{"event_id": eventID, "rsvp": "going", "is_waitlisted": false})
+	if err != nil {
+		return stats, err
+	}
+	checkedIn, err := r.attendees.collection.Count(bson.M{"event_id": eventID, "checked_in": true})
+	if err != nil {
+		return stats, err
+	}
+	if going > 0 {
+		stats.CheckInRate = float64(checkedIn) / float64(going)
+	}
+	return stats, nil
+}
+
+// SaveEventStats stores the analytics of an event, replacing any stored before
+func (r *EventRepository) SaveEventStats(ctx context.Context, stats *models.EventAnalytics) error {
+	if stats.ID.IsZero() {
+		stats.ID = primitive.NewObjectID()
+	}
+	stats.UpdatedAt = time.Now()
+	replaced, err := r.analytics.collection.ReplaceOne(bson.M{"event_id": stats.EventID}, stats)
+	if err != nil || replaced > 0 {
+		return err
+	}
+	_, err = r.analytics.insert(stats)
+	return err
+}
+
+// HasViewer reports whether a viewer, a user or an anonymous session, has
+// already been counted as viewing an event
+func (r *EventRepository) HasViewer(ctx context.Context, eventID primitive.ObjectID, viewer string) (bool, error) {
+	count, err := r.viewers.collection.Count(bson.M{"event_id": eventID, "viewer": viewer})
+	return count > 0, err
+}
+
+// AddViewer counts a viewer as having viewed an event. Adding them twice has no further effect.
+func (r *EventRepository) AddViewer(ctx context.Context, eventID primitive.ObjectID, viewer string) error {
+	return r.viewers.collection.Upsert(
+		bson.M{"event_id": eventID, "viewer": viewer},
+		bson.M{"$setOnInsert": bson.M{"viewed_at": time.Now()}},
+	)
+}
+
+// GetRecommendedEvents retrieves popular upcoming public events the user is not
+// hosting or already attending
+func (r *EventRepository) GetRecommendedEvents(ctx context.Context, userID primitive.ObjectID, limit int) ([]*models.Event, error) {
+	attending, err := r.attendees.collection.Distinct("event_id", bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := mongoutil.Merge(activeEvent, bson.M{
+		"_id":        bson.M{"$nin": attending},
+		"host_id":    bson.M{"$ne": userID},
+		"privacy":    "public",
+		"start_time": bson.M{"$gt": time.Now()},
+	})
+	return findLimit[models.Event](r.collection, filter, byPopularity, limit)
+}
+
+// GetPopularEvents retrieves the upcoming public events with the most attendees
+func (r *EventRepository) GetPopularEvents(ctx context.Context, limit int) ([]*models.Event, error) {
+	filter := mongoutil.Merge(activeEvent, bson.M{"privacy": "public", "start_time": bson.M{"$gt": time.Now()}})
+	return findLimit[models.Event](r.collection, filter, byPopularity, limit)
+}
+
+// Search searches public events by title, description and tags
+func (r *EventRepository) Search(ctx context.Context, query string, filter map[string]interface{}, limit, offset int) ([]*models.Event, int, error) {
+	search := and(
+		mongoutil.Merge(mongoutil.ToFilter(filter), bson.M{"privacy": "public"}),
+		textFilter(query, "title", "description", "tags"),
+	)
+	return findPage[models.Event](r.collection, search, earliestStart, limit, offset)
+}
+
+// List retrieves events with an arbitrary filter and sort
+func (r *EventRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Event, int, error) {
+	return findPage[models.Event](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, earliestStart), limit, offset)
+}
+
+// activeEvent matches events that have not been cancelled
+var activeEvent = bson.M{"status": bson.M{"$ne": "cancelled"}}
+
+// earliestStart sorts events soonest first
+var earliestStart = bson.D{{Key: "start_time", Value: 1}, {Key: "_id", Value: 1}}
+
+// latestStart sorts events latest first
+var latestStart = bson.D{{Key: "start_time", Value: -1}, {Key: "_id", Value: -1}}
+
+// byPopularity sorts events by how many people are going
+var byPopularity = bson.D{{Key: "rsvp_count.going", Value: -1}, {Key: "start_time", Value: 1}, {Key: "_id", Value: 1}}
+
+// byRSVPTime sorts attendees in the order they responded
+var byRSVPTime = bson.D{{Key: "rsvp_timestamp", Value: 1}, {Key: "_id", Value: 1}}
+
+// recurrence returns a function stepping a start time forward by one occurrence of rule
+func recurrence(rule string) (func(time.Time) time.Time, error) {
+	frequency := strings.ToLower(rule)
+	for _, part := range strings.Split(rule, ";") {
+		if strings.HasPrefix(strings.ToUpper(part), "FREQ=") {
+			frequency = strings.ToLower(part[len("FREQ="):])
+		}
+	}
+
+	switch frequency {
+	case "daily":
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, nil
+	case "weekly":
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }, nil
+	case "monthly":
+		return func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }, nil
+	default:
+		return nil, fmt.Errorf("unsupported recurrence rule %q", rule)
+	}
+}
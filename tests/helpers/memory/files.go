@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// FileStore holds uploaded files by URL in memory
+type FileStore struct {
+	mu    sync.Mutex
+	files map[string]bool
+}
+
+// NewFileStore creates an empty in-memory file store
+func NewFileStore() *FileStore {
+	return &FileStore{files: map[string]bool{}}
+}
+
+// Put stores a file at url
+func (s *FileStore) Put(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[url] = true
+}
+
+// Exists reports whether a file is stored at url
+func (s *FileStore) Exists(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files[url]
+}
+
+// Delete removes the file at url. Deleting a missing file is not an error.
+func (s *FileStore) Delete(ctx context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, url)
+	return nil
+}
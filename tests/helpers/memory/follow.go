@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// FollowRepository implements interfaces.FollowRepository in memory
+type FollowRepository struct {
+	store
+}
+
+var _ interfaces.FollowRepository = (*FollowRepository)(nil)
+
+// NewFollowRepository creates an in-memory follow repository
+func NewFollowRepository(db *Database) *FollowRepository {
+	return &FollowRepository{store: newStore(db, constants.CollectionFollows)}
+}
+
+// Create inserts a new follow. Following the same user twice is a duplicate key error.
+func (r *FollowRepository) Create(ctx context.Context, follow *models.Follow) (primitive.ObjectID, error) {
+	now := time.Now()
+	if follow.ID.IsZero() {
+		follow.ID = primitive.NewObjectID()
+	}
+	if follow.CreatedAt.IsZero() {
+		follow.CreatedAt = now
+	}
+	follow.UpdatedAt = now
+	if follow.Status == "" {
+		follow.Status = "accepted"
+	}
+	return r.insert(follow)
+}
+
+// GetByID retrieves a follow by ID
+func (r *FollowRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Follow, error) {
+	return findOne[models.Follow](r.collection, byID(id), nil)
+}
+
+// Update replaces a follow document
+func (r *FollowRepository) Update(ctx context.Context, follow *models.Follow) error {
+	follow.UpdatedAt = time.Now()
+	return r.replace(follow.ID, follow)
+}
+
+// Delete permanently removes a follow
+func (r *FollowRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// GetByFollowerAndFollowing retrieves the follow between two users in one direction
+func (r *FollowRepository) GetByFollowerAndFollowing(ctx context.Context, followerID, followingID primitive.ObjectID) (*models.Follow, error) {
+	return findOne[models.Follow](r.collection, bson.M{"follower_id": followerID, "following_id": followingID}, nil)
+}
+
+// GetFollowers retrieves the accepted follows of a user, newest first
+func (r *FollowRepository) GetFollowers(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	filter := bson.M{"following_id": userID, "status": "accepted"}
+	return findPage[models.Follow](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetFollowing retrieves the accepted follows by a user, newest first
+func (r *FollowRepository) GetFollowing(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	filter := bson.M{"follower_id": userID, "status": "accepted"}
+	return findPage[models.Follow](r.collection, filter, newestFirst, limit, offset)
+}
+
+// UpdateStatus sets the status of the follow between two users
+func (r *FollowRepository) UpdateStatus(ctx context.Context, followerID, followingID primitive.ObjectID, status string) error {
+	return r.updateFields(bson.M{"follower_id": followerID, "following_id": followingID}, bson.M{"status": status})
+}
+
+// GetPendingFollowRequests retrieves the follow requests awaiting a user's approval
+func (r *FollowRepository) GetPendingFollowRequests(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	filter := bson.M{"following_id": userID, "status": "pending"}
+	return findPage[models.Follow](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetMutualFollows retrieves the follows by user1 of accounts user2 also follows
+func (r *FollowRepository) GetMutualFollows(ctx context.Context, user1ID, user2ID primitive.ObjectID, limit, offset int) ([]*models.Follow, int, error) {
+	shared, err := r.GetFollowingIDs(ctx, user2ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{"follower_id": user1ID, "following_id": bson.M{"$in": shared}, "status": "accepted"}
+	return findPage[models.Follow](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetFollowerIDs returns the IDs of a user's accepted followers
+func (r *FollowRepository) GetFollowerIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	values, err := r.collection.Distinct("follower_id", bson.M{"following_id": userID, "status": "accepted"})
+	if err != nil {
+		return nil, err
+	}
+	return objectIDs(values), nil
+}
+
+// GetFollowingIDs returns the IDs of the users a user follows
+func (r *FollowRepository) GetFollowingIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	values, err := r.collection.Distinct("following_id", bson.M{"follower_id": userID, "status": "accepted"})
+	if err != nil {
+		return nil, err
+	}
+	return objectIDs(values), nil
+}
+
+// GetFollowSuggestions suggests the accounts most followed by the people a user
+// follows, leaving out the user and accounts they already follow
+func (r *FollowRepository) GetFollowSuggestions(ctx context.Context, userID primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	following, err := r.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, err := findAll[models.Follow](r.collection, bson.M{
+		"follower_id":  bson.M{"$in": following},
+		"following_id": bson.M{"$nin": append(following, userID)},
+		"status":       "accepted",
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, follow := range follows {
+		counts[follow.FollowingID]++
+	}
+	return rankIDs(counts, limit), nil
+}
+
+// UpdateNotifyPosts turns new-post notifications for a followed account on or off
+func (r *FollowRepository) UpdateNotifyPosts(ctx context.Context, followerID, followingID primitive.ObjectID, notify bool) error {
+	return r.updateFields(bson.M{"follower_id": followerID, "following_id": followingID}, bson.M{"notify_posts": notify})
+}
+
+// BulkCreate inserts several follows, stopping at the first error
+func (r *FollowRepository) BulkCreate(ctx context.Context, follows []*models.Follow) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(follows))
+	for _, follow := range follows {
+		id, err := r.Create(ctx, follow)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkDelete removes several follows and returns how many were removed
+func (r *FollowRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.collection.DeleteMany(bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// CountFollowers counts a user's accepted followers
+func (r *FollowRepository) CountFollowers(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.collection.Count(bson.M{"following_id": userID, "status": "accepted"})
+}
+
+// CountFollowing counts the users a user follows
+func (r *FollowRepository) CountFollowing(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.collection.Count(bson.M{"follower_id": userID, "status": "accepted"})
+}
+
+// IsFollowing reports whether followerID follows followingID with an accepted follow
+func (r *FollowRepository) IsFollowing(ctx context.Context, followerID, followingID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.Count(bson.M{"follower_id": followerID, "following_id": followingID, "status": "accepted"})
+	return count > 0, err
+}
+
+// List retrieves follows with an arbitrary filter and sort
+func (r *FollowRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Follow, int, error) {
+	return findPage[models.Follow](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// rankIDs returns up to limit IDs ordered by descending count
+func rankIDs(counts map[primitive.ObjectID]int, limit int) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i].Hex() > ids[j].Hex()
+	})
+
+	limit, _ = mongoutil.NormalizePagination(limit, 0)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
@@ -0,0 +1,325 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// FriendshipRepository implements interfaces.FriendshipRepository in memory.
+// Each pair of users is stored once with the lower ID in user_id_1, so the
+// unique (user_id_1, user_id_2) index covers both directions.
+type FriendshipRepository struct {
+	store
+}
+
+var _ interfaces.FriendshipRepository = (*FriendshipRepository)(nil)
+
+// NewFriendshipRepository creates an in-memory friendship repository
+func NewFriendshipRepository(db *Database) *FriendshipRepository {
+	return &FriendshipRepository{store: newStore(db, constants.CollectionFriendships)}
+}
+
+// Create inserts a new friendship. RequestedBy defaults to UserID1 and status to pending.
+func (r *FriendshipRepository) Create(ctx context.Context, friendship *models.Friendship) (primitive.ObjectID, error) {
+	now := time.Now()
+	if friendship.ID.IsZero() {
+		friendship.ID = primitive.NewObjectID()
+	}
+	if friendship.RequestedBy.IsZero() {
+		friendship.RequestedBy = friendship.UserID1
+	}
+	if friendship.Status == "" {
+		friendship.Status = "pending"
+	}
+	if friendship.CreatedAt.IsZero() {
+		friendship.CreatedAt = now
+	}
+	friendship.UpdatedAt = now
+	friendship.UserID1, friendship.UserID2 = orderedPair(friendship.UserID1, friendship.UserID2)
+	return r.insert(friendship)
+}
+
+// GetByID retrieves a friendship by ID
+func (r *FriendshipRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Friendship, error) {
+	return findOne[models.Friendship](r.collection, byID(id), nil)
+}
+
+// Update replaces a friendship document
+func (r *FriendshipRepository) Update(ctx context.Context, friendship *models.Friendship) error {
+	friendship.UpdatedAt = time.Now()
+	friendship.UserID1, friendship.UserID2 = orderedPair(friendship.UserID1, friendship.UserID2)
+	return r.replace(friendship.ID, friendship)
+}
+
+// Delete permanently removes a friendship
+func (r *FriendshipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// GetBetweenUsers retrieves the friendship between two users in either direction
+func (r *FriendshipRepository) GetBetweenUsers(ctx context.Context, user1ID, user2ID primitive.ObjectID) (*models.Friendship, error) {
+	return findOne[models.Friendship](r.collection, pairFilter(user1ID, user2ID), nil)
+}
+
+// GetByUserID retrieves the friendships a user is part of, optionally by status
+func (r *FriendshipRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, status string, limit, offset int) ([]*models.Friendship, int, error) {
+	filter := involving(userID)
+	if status != "" {
+		filter["status"] = status
+	}
+	return findPage[models.Friendship](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByStatus retrieves the friendships of a user in the given status
+func (r *FriendshipRepository) GetByStatus(ctx context.Context, userID primitive.ObjectID, status string, limit, offset int) ([]*models.Friendship, int, error) {
+	filter := mongoutil.Merge(involving(userID), bson.M{"status": status})
+	return findPage[models.Friendship](r.collection, filter, newestFirst, limit, offset)
+}
+
+// UpdateStatus sets the status of a friendship
+func (r *FriendshipRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	return r.updateFields(byID(id), bson.M{"status": status})
+}
+
+// AcceptFriendRequest accepts a pending friend request
+func (r *FriendshipRepository) AcceptFriendRequest(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(bson.M{"_id": id, "status": "pending"}, bson.M{"status": "accepted"})
+}
+
+// RejectFriendRequest rejects a pending friend request
+func (r *FriendshipRepository) RejectFriendRequest(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(bson.M{"_id": id, "status": "pending"}, bson.M{"status": "rejected"})
+}
+
+// BlockFriend records that user1 blocked user2, replacing any existing relationship
+func (r *FriendshipRepository) BlockFriend(ctx context.Context, user1ID, user2ID primitive.ObjectID) error {
+	now := time.Now()
+	return r.collection.Upsert(pairFilter(user1ID, user2ID), bson.M{
+		"$set":         bson.M{"status": "blocked", "requested_by": user1ID, "updated_at": now},
+		"$setOnInsert": bson.M{"created_at": now},
+	})
+}
+
+// UnblockFriend removes a block placed by user1 on user2
+func (r *FriendshipRepository) UnblockFriend(ctx context.Context, user1ID, user2ID primitive.ObjectID) error {
+	filter := mongoutil.Merge(pairFilter(user1ID, user2ID), bson.M{"status": "blocked", "requested_by": user1ID})
+	return matchedOne(r.collection.DeleteOne(filter))
+}
+
+// GetPendingRequests retrieves the friend requests a user has received
+func (r *FriendshipRepository) GetPendingRequests(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Friendship, int, error) {
+	filter := mongoutil.Merge(involving(userID), bson.M{"status": "pending", "requested_by": bson.M{"$ne": userID}})
+	return findPage[models.Friendship](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetSentRequests retrieves the friend requests a user has sent
+func (r *FriendshipRepository) GetSentRequests(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Friendship, int, error) {
+	filter := bson.M{"status": "pending", "requested_by": userID}
+	return findPage[models.Friendship](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetFriendshipRequestCount counts the friend requests awaiting a user's answer
+func (r *FriendshipRepository) GetFriendshipRequestCount(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.collection.Count(mongoutil.Merge(involving(userID), bson.M{"status": "pending", "requested_by": bson.M{"$ne": userID}}))
+}
+
+// GetFriends returns the IDs of a user's friends, most recent friendships first
+func (r *FriendshipRepository) GetFriends(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]primitive.ObjectID, int, error) {
+	friendships, total, err := r.GetFriendsWithDetails(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return otherUsers(friendships, userID), total, nil
+}
+
+// GetFriendsWithDetails retrieves a user's accepted friendships, newest first
+func (r *FriendshipRepository) GetFriendsWithDetails(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Friendship, int, error) {
+	filter := mongoutil.Merge(involving(userID), bson.M{"status": "accepted"})
+	return findPage[models.Friendship](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetBlockedUsers returns the IDs of the users a user has blocked
+func (r *FriendshipRepository) GetBlockedUsers(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]primitive.ObjectID, int, error) {
+	filter := bson.M{"status": "blocked", "requested_by": userID}
+	friendships, total, err := findPage[models.Friendship](r.collection, filter, newestFirst, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return otherUsers(friendships, userID), total, nil
+}
+
+// IsFriend reports whether two users have an accepted friendship
+func (r *FriendshipRepository) IsFriend(ctx context.Context, user1ID, user2ID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.Count(mongoutil.Merge(pairFilter(user1ID, user2ID), bson.M{"status": "accepted"}))
+	return count > 0, err
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (r *FriendshipRepository) IsBlocked(ctx context.Context, user1ID, user2ID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.Count(mongoutil.Merge(pairFilter(user1ID, user2ID), bson.M{"status": "blocked"}))
+	return count > 0, err
+}
+
+// GetFriendSuggestions suggests friends of friends, ranked by the number of
+// mutual friends and leaving out anyone already related to the user
+func (r *FriendshipRepository) GetFriendSuggestions(ctx context.Context, userID primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	friends, err := r.friendIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	related, err := findAll[models.Friendship](r.collection, involving(userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[primitive.ObjectID]bool{userID: true}
+	for _, id := range otherUsers(related, userID) {
+		excluded[id] = true
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, friendID := range friends {
+		theirs, err := r.friendIDs(friendID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range theirs {
+			if !excluded[id] {
+				counts[id]++
+			}
+		}
+	}
+	return rankIDs(counts, limit), nil
+}
+
+// GetMutualFriends returns the IDs of the friends two users have in common
+func (r *FriendshipRepository) GetMutualFriends(ctx context.Context, user1ID, user2ID primitive.ObjectID, limit, offset int) ([]primitive.ObjectID, int, error) {
+	mutual, err := r.mutualFriends(user1ID, user2ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := paginate(mutual, limit, offset)
+	return page, total, nil
+}
+
+// GetMutualFriendsCount counts the friends two users have in common
+func (r *FriendshipRepository) GetMutualFriendsCount(ctx context.Context, user1ID, user2ID primitive.ObjectID) (int, error) {
+	mutual, err := r.mutualFriends(user1ID, user2ID)
+	return len(mutual), err
+}
+
+// BulkCreate inserts several friendships, stopping at the first error
+func (r *FriendshipRepository) BulkCreate(ctx context.Context, friendships []*models.Friendship) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(friendships))
+	for _, friendship := range friendships {
+		id, err := r.Create(ctx, friendship)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkUpdate sets the status of several friendships and returns how many matched
+func (r *FriendshipRepository) BulkUpdate(ctx context.Context, ids []primitive.ObjectID, status string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.collection.UpdateMany(bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$set": bson.M{"status": status, "updated_at": time.Now()},
+	})
+}
+
+// BulkDelete removes several friendships and returns how many were removed
+func (r *FriendshipRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.collection.DeleteMany(bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// CountFriends counts a user's accepted friendships
+func (r *FriendshipRepository) CountFriends(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.collection.Count(mongoutil.Merge(involving(userID), bson.M{"status": "accepted"}))
+}
+
+// List retrieves friendships with an arbitrary filter and sort
+func (r *FriendshipRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Friendship, int, error) {
+	return findPage[models.Friendship](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// friendIDs returns the IDs of all of a user's friends
+func (r *FriendshipRepository) friendIDs(userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	friendships, err := findAll[models.Friendship](r.collection, mongoutil.Merge(involving(userID), bson.M{"status": "accepted"}), nil)
+	if err != nil {
+		return nil, err
+	}
+	return otherUsers(friendships, userID), nil
+}
+
+// mutualFriends returns the friends two users share, ordered by ID
+func (r *FriendshipRepository) mutualFriends(user1ID, user2ID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	first, err := r.friendIDs(user1ID)
+	if err != nil {
+		return nil, err
+	}
+	second, err := r.friendIDs(user2ID)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := map[primitive.ObjectID]bool{}
+	for _, id := range second {
+		shared[id] = true
+	}
+	mutual := []primitive.ObjectID{}
+	for _, id := range first {
+		if shared[id] {
+			mutual = append(mutual, id)
+		}
+	}
+	sort.Slice(mutual, func(i, j int) bool { return mutual[i].Hex() < mutual[j].Hex() })
+	return mutual, nil
+}
+
+// orderedPair returns two user IDs with the lower one first
+func orderedPair(a, b primitive.ObjectID) (primitive.ObjectID, primitive.ObjectID) {
+	if b.Hex() < a.Hex() {
+		return b, a
+	}
+	return a, b
+}
+
+// pairFilter matches the friendship between two users
+func pairFilter(a, b primitive.ObjectID) bson.M {
+	first, second := orderedPair(a, b)
+	return bson.M{"user_id_1": first, "user_id_2": second}
+}
+
+// involving matches friendships a user is part of
+func involving(userID primitive.ObjectID) bson.M {
+	return bson.M{"$or": bson.A{bson.M{"user_id_1": userID}, bson.M{"user_id_2": userID}}}
+}
+
+// otherUsers returns the other side of each friendship
+func otherUsers(friendships []*models.Friendship, userID primitive.ObjectID) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(friendships))
+	for _, friendship := range friendships {
+		if friendship.UserID1 == userID {
+			ids = append(ids, friendship.UserID2)
+		} else {
+			ids = append(ids, friendship.UserID1)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,471 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// GroupRepository implements interfaces.GroupRepository in memory. Memberships
+// live in their own collection and keep member_count, admins and moderators on
+// the group in step.
+type GroupRepository struct {
+	store
+	members store
+	posts   store
+}
+
+var _ interfaces.GroupRepository = (*GroupRepository)(nil)
+
+// NewGroupRepository creates an in-memory group repository
+func NewGroupRepository(db *Database) *GroupRepository {
+	return &GroupRepository{
+		store:   newStore(db, constants.CollectionGroups),
+		members: newStore(db, constants.CollectionGroupMembers),
+		posts:   newStore(db, constants.CollectionPosts),
+	}
+}
+
+// Create inserts a new group. The creator is not added as a member; callers do
+// that with AddMember as they would against MongoDB.
+func (r *GroupRepository) Create(ctx context.Context, group *models.Group) (primitive.ObjectID, error) {
+	now := time.Now()
+	if group.ID.IsZero() {
+		group.ID = primitive.NewObjectID()
+	}
+	if group.CreatedAt.IsZero() {
+		group.CreatedAt = now
+	}
+	group.UpdatedAt = now
+	if group.LastActivityAt.IsZero() {
+		group.LastActivityAt = now
+	}
+	if group.Status == "" {
+		group.Status = "active"
+	}
+	if group.Admins == nil {
+		group.Admins = []primitive.ObjectID{}
+	}
+	return r.insert(group)
+}
+
+// GetByID retrieves a group by ID, ignoring soft-deleted groups
+func (r *GroupRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+	return findOne[models.Group](r.collection, notDeleted(id), nil)
+}
+
+// Update replaces a group document
+func (r *GroupRepository) Update(ctx context.Context, group *models.Group) error {
+	group.UpdatedAt = time.Now()
+	return r.replace(group.ID, group)
+}
+
+// Delete permanently removes a group and its memberships
+func (r *GroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := r.remove(id); err != nil {
+		return err
+	}
+	_, err := r.members.collection.DeleteMany(bson.M{"group_id": id})
+	return err
+}
+
+// SoftDelete marks a group as deleted without removing it
+func (r *GroupRepository) SoftDelete(ctx context.Context, id primitive.ObjectID) error {
+	return r.softDelete(id)
+}
+
+// GetByName retrieves a group by its exact name
+func (r *GroupRepository) GetByName(ctx context.Context, name string) (*models.Group, error) {
+	return findOne[models.Group](r.collection, mongoutil.Merge(bson.M{"name": name}, mongoutil.NotDeleted()), nil)
+}
+
+// GetByUserID retrieves the groups a user belongs to, optionally only those
+// where they hold role
+func (r *GroupRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, role string, limit, offset int) ([]*models.Group, int, error) {
+	membership := bson.M{"user_id": userID}
+	if role != "" {
+		membership["role"] = role
+	}
+	groupIDs, err := r.members.collection.Distinct("group_id", membership)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": groupIDs}}, mongoutil.NotDeleted())
+	return findPage[models.Group](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByCategoryIDs retrieves the groups tagged with any of the given categories
+func (r *GroupRepository) GetByCategoryIDs(ctx context.Context, categoryIDs []primitive.ObjectID, limit, offset int) ([]*models.Group, int, error) {
+	categories := make([]string, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		categories = append(categories, id.Hex())
+	}
+
+	filter := mongoutil.Merge(bson.M{"categories": bson.M{"$in": categories}}, mongoutil.NotDeleted())
+	return findPage[models.Group](r.collection, filter, newestFirst, limit, offset)
+}
+
+// GetByIDs retrieves several groups by ID
+func (r *GroupRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Group, error) {
+	if len(ids) == 0 {
+		return []*models.Group{}, nil
+	}
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return findAll[models.Group](r.collection, filter, nil)
+}
+
+// AddMember adds a user to a group with a role. Adding an existing member is a
+// duplicate key error.
+func (r *GroupRepository) AddMember(ctx context.Context, groupID, userID primitive.ObjectID, role string) error {
+	if _, err := r.GetByID(ctx, groupID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err := r.members.insert(&models.GroupMember{
+		ID:                   primitive.NewObjectID(),
+		GroupID:              groupID,
+		UserID:               userID,
+		Role:                 role,
+		JoinedAt:             now,
+		NotificationSettings: "all",
+		IsActive:             true,
+		LastActiveAt:         now,
+	})
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$inc": bson.M{"member_count": 1}}
+	if field := roleField(role); field != "" {
+		update["$addToSet"] = bson.M{field: userID}
+	}
+	return r.update(byID(groupID), update)
+}
+
+// RemoveMember removes a user from a group
+func (r *GroupRepository) RemoveMember(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	if err := matchedOne(r.members.collection.DeleteOne(bson.M{"group_id": groupID, "user_id": userID})); err != nil {
+		return err
+	}
+	return r.update(byID(groupID), bson.M{
+		"$inc":  bson.M{"member_count": -1},
+		"$pull": bson.M{"admins": userID, "moderators": userID},
+	})
+}
+
+// UpdateMemberRole changes a member's role and the group's admin and moderator lists
+func (r *GroupRepository) UpdateMemberRole(ctx context.Context, groupID, userID primitive.ObjectID, role string) error {
+	if err := matchedOne(r.members.collection.UpdateOne(
+		bson.M{"group_id": groupID, "user_id": userID},
+		bson.M{"$set": bson.M{"role": role}},
+	)); err != nil {
+		return err
+	}
+
+	update := bson.M{"$pull": bson.M{"admins": userID, "moderators": userID}}
+	if err := r.update(byID(groupID), update); err != nil {
+		return err
+	}
+	if field := roleField(role); field != "" {
+		return r.update(byID(groupID), bson.M{"$addToSet": bson.M{field: userID}})
+	}
+	return nil
+}
+
+// GetMembers retrieves the members of a group, optionally by role, in join order
+func (r *GroupRepository) GetMembers(ctx context.Context, groupID primitive.ObjectID, role string, limit, offset int) ([]*models.GroupMember, int, error) {
+	filter := bson.M{"group_id": groupID}
+	if role != "" {
+		filter["role"] = role
+	}
+	return findPage[models.GroupMember](r.members.collection, filter, byJoinTime, limit, offset)
+}
+
+// GetMember retrieves a user's membership of a group
+func (r *GroupRepository) GetMember(ctx context.Context, groupID, userID primitive.ObjectID) (*models.GroupMember, error) {
+	return findOne[models.GroupMember](r.members.collection, bson.M{"group_id": groupID, "user_id": userID}, nil)
+}
+
+// AddJoinRequest records a request to join a group
+func (r *GroupRepository) AddJoinRequest(ctx context.Context, groupID primitive.ObjectID, request models.GroupJoinRequest) error {
+	if request.RequestedAt.IsZero() {
+		request.RequestedAt = time.Now()
+	}
+	if request.Status == "" {
+		request.Status = "pending"
+	}
+	return r.update(notDeleted(groupID), bson.M{"$push": bson.M{"join_requests": request}})
+}
+
+// UpdateJoinRequest records the review of a user's pending join request
+func (r *GroupRepository) UpdateJoinRequest(ctx context.Context, groupID, userID primitive.ObjectID, status, reason string, reviewerID primitive.ObjectID) error {
+	return modify(r.collection, notDeleted(groupID), func(group *models.Group) error {
+		for i := range group.JoinRequests {
+			request := &group.JoinRequests[i]
+			if request.UserID == userID && request.Status == "pending" {
+				now := time.Now()
+				request.Status = status
+				request.Reason = reason
+				request.ReviewedBy = &reviewerID
+				request.ReviewedAt = &now
+				group.UpdatedAt = now
+				return nil
+			}
+		}
+		return mongo.ErrNoDocuments
+	})
+}
+
+// GetPendingJoinRequests retrieves the join requests awaiting review, oldest first
+func (r *GroupRepository) GetPendingJoinRequests(ctx context.Context, groupID primitive.ObjectID, limit, offset int) ([]models.GroupJoinRequest, int, error) {
+	group, err := r.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pending := []models.GroupJoinRequest{}
+	for _, request := range group.JoinRequests {
+		if request.Status == "pending" {
+			pending = append(pending, request)
+		}
+	}
+	page, total := paginate(pending, limit, offset)
+	return page, total, nil
+}
+
+// IncrementPostCount atomically increments the post counter
+func (r *GroupRepository) IncrementPostCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.increment(groupID, "post_count", 1)
+}
+
+// DecrementPostCount atomically decrements the post counter
+func (r *GroupRepository) DecrementPostCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.increment(groupID, "post_count", -1)
+}
+
+// IncrementMemberCount atomically increments the member counter
+func (r *GroupRepository) IncrementMemberCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.increment(groupID, "member_count", 1)
+}
+
+// DecrementMemberCount atomically decrements the member counter
+func (r *GroupRepository) DecrementMemberCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.increment(groupID, "member_count", -1)
+}
+
+// IncrementEventCount atomically increments the event counter
+func (r *GroupRepository) IncrementEventCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.increment(groupID, "event_count", 1)
+}
+
+// DecrementEventCount atomically decrements the event counter
+func (r *GroupRepository) DecrementEventCount(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.increment(groupID, "event_count", -1)
+}
+
+// UpdateRules replaces the rules of a group
+func (r *GroupRepository) UpdateRules(ctx context.Context, groupID primitive.ObjectID, rules []models.GroupRule) error {
+	return r.updateFields(notDeleted(groupID), bson.M{"rules": rules})
+}
+
+// UpdateFeatures replaces the feature switches of a group
+func (r *GroupRepository) UpdateFeatures(ctx context.Context, groupID primitive.ObjectID, features models.GroupFeatures) error {
+	return r.updateFields(notDeleted(groupID), bson.M{"features": features})
+}
+
+// UpdateVisibility sets whether a group is public and discoverable
+func (r *GroupRepository) UpdateVisibility(ctx context.Context, groupID primitive.ObjectID, isPublic, isVisible bool) error {
+	return r.updateFields(notDeleted(groupID), bson.M{"is_public": isPublic, "is_visible": isVisible})
+}
+
+// UpdateJoinSettings sets whether joining a group needs approval
+func (r *GroupRepository) UpdateJoinSettings(ctx context.Context, groupID primitive.ObjectID, requireApproval bool) error {
+	return r.updateFields(notDeleted(groupID), bson.M{"join_approval_required": requireApproval})
+}
+
+// AddAdmin promotes a member to admin
+func (r *GroupRepository) AddAdmin(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	return r.UpdateMemberRole(ctx, groupID, userID, "admin")
+}
+
+// RemoveAdmin demotes an admin to a regular member
+func (r *GroupRepository) RemoveAdmin(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	return r.demote(ctx, groupID, userID, "admin")
+}
+
+// AddModerator promotes a member to moderator
+func (r *GroupRepository) AddModerator(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	return r.UpdateMemberRole(ctx, groupID, userID, "moderator")
+}
+
+// RemoveModerator demotes a moderator to a regular member
+func (r *GroupRepository) RemoveModerator(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	return r.demote(ctx, groupID, userID, "moderator")
+}
+
+// GenerateInviteLink replaces the invite code of a group with a fresh random one
+func (r *GroupRepository) GenerateInviteLink(ctx context.Context, groupID primitive.ObjectID) (string, error) {
+	code := make([]byte, 16)
+	if _, err := rand.Read(code); err != nil {
+		return "", err
+	}
+	link := hex.EncodeToString(code)
+
+	if err := r.updateFields(notDeleted(groupID), bson.M{"invite_link": link}); err != nil {
+		return "", err
+	}
+	return link, nil
+}
+
+// ValidateInviteLink returns the group an invite code belongs to
+func (r *GroupRepository) ValidateInviteLink(ctx context.Context, link string) (primitive.ObjectID, error) {
+	if link == "" {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	filter := mongoutil.Merge(bson.M{"invite_link": link, "status": "active"}, mongoutil.NotDeleted())
+	group, err := findOne[models.Group](r.collection, filter, nil)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return group.ID, nil
+}
+
+// GetRecommendedGroups suggests popular public groups the user has not joined
+func (r *GroupRepository) GetRecommendedGroups(ctx context.Context, userID primitive.ObjectID, limit int) ([]*models.Group, error) {
+	joined, err := r.members.collection.Distinct("group_id", bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := mongoutil.Merge(discoverableGroup, bson.M{"_id": bson.M{"$nin": joined}})
+	return findLimit[models.Group](r.collection, filter, byMemberCount, limit)
+}
+
+// GetPopularGroups retrieves the discoverable groups with the most members
+func (r *GroupRepository) GetPopularGroups(ctx context.Context, limit int) ([]*models.Group, error) {
+	return findLimit[models.Group](r.collection, discoverableGroup, byMemberCount, limit)
+}
+
+// GetNewGroups retrieves the most recently created discoverable groups
+func (r *GroupRepository) GetNewGroups(ctx context.Context, limit int) ([]*models.Group, error) {
+	return findLimit[models.Group](r.collection, discoverableGroup, newestFirst, limit)
+}
+
+// UpdateLastActivity stamps the group's last activity time
+func (r *GroupRepository) UpdateLastActivity(ctx context.Context, groupID primitive.ObjectID) error {
+	return r.updateFields(byID(groupID), bson.M{"last_activity_at": time.Now()})
+}
+
+// UpdateMemberLastActivity stamps a member's last activity time
+func (r *GroupRepository) UpdateMemberLastActivity(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	return matchedOne(r.members.collection.UpdateOne(
+		bson.M{"group_id": groupID, "user_id": userID},
+		bson.M{"$set": bson.M{"last_active_at": time.Now()}},
+	))
+}
+
+// GetGroupStats computes activity and growth figures for a group from its
+// memberships and posts
+func (r *GroupRepository) GetGroupStats(ctx context.Context, groupID primitive.ObjectID) (models.GroupAnalytics, error) {
+	group, err := r.GetByID(ctx, groupID)
+	if err != nil {
+		return models.GroupAnalytics{}, err
+	}
+
+	now := time.Now()
+	members := bson.M{"group_id": groupID}
+	daily, err := r.members.collection.Count(mongoutil.Merge(members, bson.M{"last_active_at": bson.M{"$gte": now.AddDate(0, 0, -1)}}))
+	if err != nil {
+		return models.GroupAnalytics{}, err
+	}
+	monthly, err := r.members.collection.Count(mongoutil.Merge(members, bson.M{"last_active_at": bson.M{"$gte": now.AddDate(0, -1, 0)}}))
+	if err != nil {
+		return models.GroupAnalytics{}, err
+	}
+	joined, err := r.members.collection.Count(mongoutil.Merge(members, bson.M{"joined_at": bson.M{"$gte": now.AddDate(0, -1, 0)}}))
+	if err != nil {
+		return models.GroupAnalytics{}, err
+	}
+	posts, err := r.posts.collection.Count(mongoutil.Merge(
+		bson.M{"group_id": groupID, "created_at": bson.M{"$gte": now.AddDate(0, 0, -7)}},
+		mongoutil.NotDeleted(),
+	))
+	if err != nil {
+		return models.GroupAnalytics{}, err
+	}
+
+	stats := models.GroupAnalytics{
+		GroupID:            groupID,
+		DailyActiveUsers:   daily,
+		MonthlyActiveUsers: monthly,
+		PostsThisWeek:      posts,
+		Period:             "monthly",
+		StartDate:          now.AddDate(0, -1, 0),
+		EndDate:            now,
+		UpdatedAt:          now,
+	}
+	if previous := group.MemberCount - joined; previous > 0 {
+		stats.GrowthRate = float64(joined) / float64(previous) * 100
+	}
+	if group.MemberCount > 0 {
+		stats.EngagementRate = float64(monthly) / float64(group.MemberCount) * 100
+	}
+	return stats, nil
+}
+
+// Search finds discoverable groups whose name, description or tags contain the query
+func (r *GroupRepository) Search(ctx context.Context, query string, filter map[string]interface{}, limit, offset int) ([]*models.Group, int, error) {
+	search := and(
+		mongoutil.Merge(mongoutil.ToFilter(filter), discoverableGroup),
+		textFilter(query, "name", "description", "tags"),
+	)
+	return findPage[models.Group](r.collection, search, byMemberCount, limit, offset)
+}
+
+// List retrieves groups with an arbitrary filter and sort.
+// Soft-deleted groups are excluded unless the filter mentions deleted_at.
+func (r *GroupRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Group, int, error) {
+	return findPage[models.Group](r.collection, listFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// demote turns a member holding role back into a regular member
+func (r *GroupRepository) demote(ctx context.Context, groupID, userID primitive.ObjectID, role string) error {
+	member, err := findOne[models.GroupMember](r.members.collection, bson.M{"group_id": groupID, "user_id": userID, "role": role}, nil)
+	if err != nil {
+		return err
+	}
+	return r.UpdateMemberRole(ctx, groupID, member.UserID, "member")
+}
+
+// roleField returns the group field that lists members holding role
+func roleField(role string) string {
+	switch role {
+	case "admin":
+		return "admins"
+	case "moderator":
+		return "moderators"
+	default:
+		return ""
+	}
+}
+
+// discoverableGroup matches groups that show up in search and discovery
+var discoverableGroup = bson.M{"deleted_at": nil, "is_visible": true, "status": "active"}
+
+// byMemberCount sorts groups by member count, largest first
+var byMemberCount = bson.D{{Key: "member_count", Value: -1}, {Key: "_id", Value: -1}}
+
+// byJoinTime sorts memberships by join time, earliest first
+var byJoinTime = bson.D{{Key: "joined_at", Value: 1}, {Key: "_id", Value: 1}}
@@ -0,0 +1,412 @@
+package memory
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// HashtagRepository implements interfaces.HashtagRepository in memory
+type HashtagRepository struct {
+	store
+	follows store
+	posts   store
+}
+
+var _ interfaces.HashtagRepository = (*HashtagRepository)(nil)
+
+// NewHashtagRepository creates an in-memory hashtag repository
+func NewHashtagRepository(db *Database) *HashtagRepository {
+	return &HashtagRepository{
+		store:   newStore(db, constants.CollectionHashtags),
+		follows: newStore(db, constants.CollectionHashtagFollows),
+		posts:   newStore(db, constants.CollectionPosts),
+	}
+}
+
+// Create inserts a new hashtag. Names are unique.
+func (r *HashtagRepository) Create(ctx context.Context, hashtag *models.Hashtag) (primitive.ObjectID, error) {
+	now := time.Now()
+	if hashtag.ID.IsZero() {
+		hashtag.ID = primitive.NewObjectID()
+	}
+	if hashtag.CreatedAt.IsZero() {
+		hashtag.CreatedAt = now
+	}
+	hashtag.UpdatedAt = now
+	return r.insert(hashtag)
+}
+
+// GetByID retrieves a hashtag by ID
+func (r *HashtagRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Hashtag, error) {
+	return findOne[models.Hashtag](r.collection, byID(id), nil)
+}
+
+// Update replaces a hashtag document
+func (r *HashtagRepository) Update(ctx context.Context, hashtag *models.Hashtag) error {
+	hashtag.UpdatedAt = time.Now()
+	return r.replace(hashtag.ID, hashtag)
+}
+
+// Delete permanently removes a hashtag and its follows
+func (r *HashtagRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := r.remove(id); err != nil {
+		return err
+	}
+	_, err := r.follows.collection.DeleteMany(bson.M{"hashtag_id": id})
+	return err
+}
+
+// GetByName retrieves a hashtag by its exact name
+func (r *HashtagRepository) GetByName(ctx context.Context, name string) (*models.Hashtag, error) {
+	return findOne[models.Hashtag](r.collection, bson.M{"name": name}, nil)
+}
+
+// GetByNames retrieves the hashtags with any of the given names
+func (r *HashtagRepository) GetByNames(ctx context.Context, names []string) ([]*models.Hashtag, error) {
+	if len(names) == 0 {
+		return []*models.Hashtag{}, nil
+	}
+	return findAll[models.Hashtag](r.collection, bson.M{"name": bson.M{"$in": names}}, nil)
+}
+
+// GetByIDs retrieves several hashtags by ID
+func (r *HashtagRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Hashtag, error) {
+	if len(ids) == 0 {
+		return []*models.Hashtag{}, nil
+	}
+	return findAll[models.Hashtag](r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// GetTrending retrieves the trending hashtags in rank order
+func (r *HashtagRepository) GetTrending(ctx context.Context, limit int) ([]*models.Hashtag, error) {
+	return findLimit[models.Hashtag](r.collection, trendingHashtag, byTrendingRank, limit)
+}
+
+// GetTrendingByCategory retrieves the trending hashtags stored with a category
+// field. The Hashtag model has no category, so this only finds documents that
+// were seeded with one.
+func (r *HashtagRepository) GetTrendingByCategory(ctx context.Context, category string, limit int) ([]*models.Hashtag, error) {
+	filter := mongoutil.Merge(trendingHashtag, bson.M{"category": category})
+	return findLimit[models.Hashtag](r.collection, filter, byTrendingRank, limit)
+}
+
+// UpdateTrendingStatus sets whether a hashtag is trending and its rank
+func (r *HashtagRepository) UpdateTrendingStatus(ctx context.Context, id primitive.ObjectID, isTrending bool, rank int) error {
+	return r.updateFields(byID(id), bson.M{"is_trending": isTrending, "trending_rank": rank})
+}
+
+// IncrementPostCount atomically increments the post counter
+func (r *HashtagRepository) IncrementPostCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(id, "post_count", 1)
+}
+
+// DecrementPostCount atomically decrements the post counter
+func (r *HashtagRepository) DecrementPostCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(id, "post_count", -1)
+}
+
+// GetPostsByHashtag returns the IDs of visible public posts tagged with a hashtag, newest first
+func (r *HashtagRepository) GetPostsByHashtag(ctx context.Context, hashtagName string, limit, offset int) ([]primitive.ObjectID, int, error) {
+	filter := mongoutil.Merge(bson.M{"hashtags": hashtagName, "privacy": "public"}, visiblePost())
+	posts, total, err := findPage[models.Post](r.posts.collection, filter, byPublishedAt, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids, total, nil
+}
+
+// IncrementFollowerCount atomically increments the follower counter
+func (r *HashtagRepository) IncrementFollowerCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(id, "follower_count", 1)
+}
+
+// DecrementFollowerCount atomically decrements the follower counter
+func (r *HashtagRepository) DecrementFollowerCount(ctx context.Context, id primitive.ObjectID) error {
+	return r.increment(id, "follower_count", -1)
+}
+
+// CreateFollow records that a user follows a hashtag. Following twice is a
+// duplicate key error. The follower counter is left to the caller.
+func (r *HashtagRepository) CreateFollow(ctx context.Context, follow *models.HashtagFollow) (primitive.ObjectID, error) {
+	if follow.ID.IsZero() {
+		follow.ID = primitive.NewObjectID()
+	}
+	if follow.CreatedAt.IsZero() {
+		follow.CreatedAt = time.Now()
+	}
+	return r.follows.insert(follow)
+}
+
+// DeleteFollow removes a user's follow of a hashtag
+func (r *HashtagRepository) DeleteFollow(ctx context.Context, userID, hashtagID primitive.ObjectID) error {
+	return matchedOne(r.follows.collection.DeleteOne(bson.M{"user_id": userID, "hashtag_id": hashtagID}))
+}
+
+// GetFollowedHashtags retrieves the hashtags a user follows, most recently followed first
+func (r *HashtagRepository) GetFollowedHashtags(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Hashtag, int, error) {
+	follows, total, err := findPage[models.HashtagFollow](r.follows.collection, bson.M{"user_id": userID}, newestFirst, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hashtags := make([]*models.Hashtag, 0, len(follows))
+	for _, follow := range follows {
+		hashtag, err := r.GetByID(ctx, follow.HashtagID)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		hashtags = append(hashtags, hashtag)
+	}
+	return hashtags, total, nil
+}
+
+// IsFollowing reports whether a user follows a hashtag
+func (r *HashtagRepository) IsFollowing(ctx context.Context, userID, hashtagID primitive.ObjectID) (bool, error) {
+	count, err := r.follows.collection.Count(bson.M{"user_id": userID, "hashtag_id": hashtagID})
+	return count > 0, err
+}
+
+// GetHashtagFollowers returns the IDs of the users following a hashtag, most recent first
+func (r *HashtagRepository) GetHashtagFollowers(ctx context.Context, hashtagID primitive.ObjectID, limit, offset int) ([]primitive.ObjectID, int, error) {
+	follows, total, err := findPage[models.HashtagFollow](r.follows.collection, bson.M{"hashtag_id": hashtagID}, newestFirst, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(follows))
+	for _, follow := range follows {
+		ids = append(ids, follow.UserID)
+	}
+	return ids, total, nil
+}
+
+// SetRestricted restricts or unrestricts a hashtag
+func (r *HashtagRepository) SetRestricted(ctx context.Context, id primitive.ObjectID, isRestricted bool) error {
+	return r.updateFields(byID(id), bson.M{"is_restricted": isRestricted})
+}
+
+// GetRestrictedHashtags retrieves every restricted hashtag
+func (r *HashtagRepository) GetRestrictedHashtags(ctx context.Context) ([]*models.Hashtag, error) {
+	return findAll[models.Hashtag](r.collection, bson.M{"is_restricted": true}, bson.D{{Key: "name", Value: 1}})
+}
+
+// GetPopularHashtags retrieves the unrestricted hashtags used by the most posts
+// created within timeRange. A zero range ranks by the stored post counter.
+func (r *HashtagRepository) GetPopularHashtags(ctx context.Context, timeRange time.Duration, limit int) ([]*models.Hashtag, error) {
+	if timeRange <= 0 {
+		sort := bson.D{{Key: "post_count", Value: -1}, {Key: "_id", Value: -1}}
+		return findLimit[models.Hashtag](r.collection, bson.M{"is_restricted": false}, sort, limit)
+	}
+
+	filter := mongoutil.Merge(bson.M{"created_at": bson.M{"$gte": time.Now().Add(-timeRange)}}, mongoutil.NotDeleted())
+	posts, err := findAll[models.Post](r.posts.collection, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, post := range posts {
+		for _, name := range post.Hashtags {
+			hashtag, err := r.GetByName(ctx, name)
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !hashtag.IsRestricted {
+				counts[hashtag.ID]++
+			}
+		}
+	}
+	return r.inOrder(ctx, rankIDs(counts, limit))
+}
+
+// GetHashtagGrowth counts the posts and follows a hashtag gained within a date range
+func (r *HashtagRepository) GetHashtagGrowth(ctx context.Context, id primitive.ObjectID, period string, startDate, endDate time.Time) (map[string]interface{}, error) {
+	hashtag, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := r.posts.collection.Count(inRange(
+		mongoutil.Merge(bson.M{"hashtags": hashtag.Name}, mongoutil.NotDeleted()), "created_at", startDate, endDate,
+	))
+	if err != nil {
+		return nil, err
+	}
+	followers, err := r.follows.collection.Count(inRange(bson.M{"hashtag_id": id}, "created_at", startDate, endDate))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"hashtag_id":      id,
+		"period":          period,
+		"new_posts":       posts,
+		"new_followers":   followers,
+		"total_posts":     hashtag.PostCount,
+		"total_followers": hashtag.FollowerCount,
+	}, nil
+}
+
+// GetHashtagEngagement sums the likes, comments and shares on posts tagged with
+// a hashtag within a date range
+func (r *HashtagRepository) GetHashtagEngagement(ctx context.Context, id primitive.ObjectID, startDate, endDate time.Time) (map[string]interface{}, error) {
+	hashtag, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := findAll[models.Post](r.posts.collection, inRange(
+		mongoutil.Merge(bson.M{"hashtags": hashtag.Name}, mongoutil.NotDeleted()), "created_at", startDate, endDate,
+	), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	likes, comments, shares, views := 0, 0, 0, 0
+	for _, post := range posts {
+		likes += post.LikeCount
+		comments += post.CommentCount
+		shares += post.ShareCount
+		views += post.ViewCount
+	}
+
+	return map[string]interface{}{
+		"hashtag_id": id,
+		"posts":      len(posts),
+		"likes":      likes,
+		"comments":   comments,
+		"shares":     shares,
+		"views":      views,
+	}, nil
+}
+
+// Search finds unrestricted hashtags whose name starts with the query, most used first
+func (r *HashtagRepository) Search(ctx context.Context, query string, limit int) ([]*models.Hashtag, error) {
+	prefix := strings.TrimPrefix(strings.TrimSpace(query), "#")
+	if prefix == "" {
+		return []*models.Hashtag{}, nil
+	}
+
+	filter := bson.M{
+		"name":          primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix), Options: "i"},
+		"is_restricted": false,
+	}
+	sort := bson.D{{Key: "post_count", Value: -1}, {Key: "_id", Value: -1}}
+	return findLimit[models.Hashtag](r.collection, filter, sort, limit)
+}
+
+// GetRelatedHashtags retrieves the hashtags that appear most often alongside a hashtag
+func (r *HashtagRepository) GetRelatedHashtags(ctx context.Context, hashtagName string, limit int) ([]*models.Hashtag, error) {
+	filter := mongoutil.Merge(bson.M{"hashtags": hashtagName}, mongoutil.NotDeleted())
+	posts, err := findAll[models.Post](r.posts.collection, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, post := range posts {
+		for _, name := range post.Hashtags {
+			if name == hashtagName {
+				continue
+			}
+			hashtag, err := r.GetByName(ctx, name)
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !hashtag.IsRestricted {
+				counts[hashtag.ID]++
+			}
+		}
+	}
+	return r.inOrder(ctx, rankIDs(counts, limit))
+}
+
+// GetRecommendedHashtags suggests trending or popular hashtags the user does not follow yet
+func (r *HashtagRepository) GetRecommendedHashtags(ctx context.Context, userID primitive.ObjectID, limit int) ([]*models.Hashtag, error) {
+	followed, err := r.follows.collection.Distinct("hashtag_id", bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": bson.M{"$nin": followed}, "is_restricted": false}
+	sort := bson.D{{Key: "is_trending", Value: -1}, {Key: "post_count", Value: -1}, {Key: "_id", Value: -1}}
+	return findLimit[models.Hashtag](r.collection, filter, sort, limit)
+}
+
+// BulkCreate inserts several hashtags, stopping at the first error
+func (r *HashtagRepository) BulkCreate(ctx context.Context, hashtags []*models.Hashtag) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hashtags))
+	for _, hashtag := range hashtags {
+		id, err := r.Create(ctx, hashtag)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkUpdate replaces several hashtags and returns how many existed
+func (r *HashtagRepository) BulkUpdate(ctx context.Context, hashtags []*models.Hashtag) (int, error) {
+	updated := 0
+	for _, hashtag := range hashtags {
+		err := r.Update(ctx, hashtag)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// List retrieves hashtags with an arbitrary filter and sort
+func (r *HashtagRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Hashtag, int, error) {
+	return findPage[models.Hashtag](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, newestFirst), limit, offset)
+}
+
+// inOrder loads hashtags by ID, keeping the order of ids
+func (r *HashtagRepository) inOrder(ctx context.Context, ids []primitive.ObjectID) ([]*models.Hashtag, error) {
+	hashtags := make([]*models.Hashtag, 0, len(ids))
+	for _, id := range ids {
+		hashtag, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		hashtags = append(hashtags, hashtag)
+	}
+	return hashtags, nil
+}
+
+// trendingHashtag matches hashtags currently shown as trending
+var trendingHashtag = bson.M{"is_trending": true, "is_restricted": false}
+
+// byTrendingRank sorts hashtags by trending rank, top first
+var byTrendingRank = bson.D{{Key: "trending_rank", Value: 1}, {Key: "post_count", Value: -1}, {Key: "_id", Value: 1}}
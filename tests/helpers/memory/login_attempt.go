@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// LoginAttemptRepository implements interfaces.LoginAttemptRepository in memory
+type LoginAttemptRepository struct {
+	store
+	lockouts *Collection
+}
+
+var _ interfaces.LoginAttemptRepository = (*LoginAttemptRepository)(nil)
+
+// NewLoginAttemptRepository creates an in-memory login attempt repository
+func NewLoginAttemptRepository(db *Database) *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		store:    newStore(db, constants.CollectionLoginFailures),
+		lockouts: db.Collection(constants.CollectionLoginLockouts),
+	}
+}
+
+// RecordFailure inserts a failed login
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, failure *models.LoginFailure) error {
+	if failure.ID.IsZero() {
+		failure.ID = primitive.NewObjectID()
+	}
+	if failure.CreatedAt.IsZero() {
+		failure.CreatedAt = time.Now()
+	}
+	_, err := r.insert(failure)
+	return err
+}
+
+// CountByEmail counts the failures for an account since a time
+func (r *LoginAttemptRepository) CountByEmail(ctx context.Context, email string, since time.Time) (int, error) {
+	return r.collection.Count(bson.M{"email": email, "created_at": bson.M{"$gte": since}})
+}
+
+// CountByIP counts the failures from an IP address since a time
+func (r *LoginAttemptRepository) CountByIP(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	return r.collection.Count(bson.M{"ip_address": ipAddress, "created_at": bson.M{"$gte": since}})
+}
+
+// CountBySubnet counts the failures from a subnet since a time
+func (r *LoginAttemptRepository) CountBySubnet(ctx context.Context, subnet string, since time.Time) (int, error) {
+	return r.collection.Count(bson.M{"subnet": subnet, "created_at": bson.M{"$gte": since}})
+}
+
+// CountEmailsByIP counts the distinct accounts an IP address failed to log in to since a time
+func (r *LoginAttemptRepository) CountEmailsByIP(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	emails, err := r.collection.Distinct("email", bson.M{
+		"ip_address": ipAddress,
+		"email":      bson.M{"$ne": ""},
+		"created_at": bson.M{"$gte": since},
+	})
+	return len(emails), err
+}
+
+// LastFailure retrieves the most recent failure for an account
+func (r *LoginAttemptRepository) LastFailure(ctx context.Context, email string) (*models.LoginFailure, error) {
+	return findOne[models.LoginFailure](r.collection, bson.M{"email": email}, bson.D{{Key: "created_at", Value: -1}})
+}
+
+// ClearFailures detaches the failures from an account, as the MongoDB repository does
+func (r *LoginAttemptRepository) ClearFailures(ctx context.Context, email string) error {
+	_, err := r.collection.UpdateMany(bson.M{"email": email}, bson.M{"$set": bson.M{"email": ""}})
+	return err
+}
+
+// SaveLockout stores a lockout, replacing any earlier lockout of the account
+func (r *LoginAttemptRepository) SaveLockout(ctx context.Context, lockout *models.LoginLockout) error {
+	if lockout.ID.IsZero() {
+		lockout.ID = primitive.NewObjectID()
+	}
+	return r.lockouts.Upsert(bson.M{"email": lockout.Email}, bson.M{
+		"$set": bson.M{
+			"user_id":         lockout.UserID,
+			"failures":        lockout.Failures,
+			"last_ip_address": lockout.LastIPAddress,
+			"locked_at":       lockout.LockedAt,
+			"locked_until":    lockout.LockedUntil,
+		},
+		"$setOnInsert": bson.M{"_id": lockout.ID},
+	})
+}
+
+// FindLockout retrieves the lockout of an account if it is still in force
+func (r *LoginAttemptRepository) FindLockout(ctx context.Context, email string, now time.Time) (*models.LoginLockout, error) {
+	return findOne[models.LoginLockout](r.lockouts, bson.M{"email": email, "locked_until": bson.M{"$gt": now}}, nil)
+}
+
+// FindLockoutByUserID retrieves the lockout of a user if it is still in force
+func (r *LoginAttemptRepository) FindLockoutByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.LoginLockout, error) {
+	return findOne[models.LoginLockout](r.lockouts, bson.M{"user_id": userID, "locked_until": bson.M{"$gt": now}}, nil)
+}
+
+// DeleteLockout lifts the lockout of an account
+func (r *LoginAttemptRepository) DeleteLockout(ctx context.Context, email string) error {
+	return matchedOne(r.lockouts.DeleteOne(bson.M{"email": email}))
+}
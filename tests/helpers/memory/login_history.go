@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// LoginHistoryRepository implements interfaces.LoginHistoryRepository in memory
+type LoginHistoryRepository struct {
+	store
+}
+
+var _ interfaces.LoginHistoryRepository = (*LoginHistoryRepository)(nil)
+
+// NewLoginHistoryRepository creates an in-memory login history repository
+func NewLoginHistoryRepository(db *Database) *LoginHistoryRepository {
+	return &LoginHistoryRepository{store: newStore(db, constants.CollectionLoginHistory)}
+}
+
+// Create inserts a login record
+func (r *LoginHistoryRepository) Create(ctx context.Context, record *models.LoginRecord) error {
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	_, err := r.insert(record)
+	return err
+}
+
+// FindLatest retrieves a user's most recent login
+func (r *LoginHistoryRepository) FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.LoginRecord, error) {
+	return findOne[models.LoginRecord](r.collection, bson.M{"user_id": userID}, bson.D{{Key: "created_at", Value: -1}})
+}
+
+// HasDevice reports whether a user has logged in from a kind of device before
+func (r *LoginHistoryRepository) HasDevice(ctx context.Context, userID primitive.ObjectID, deviceKey string) (bool, error) {
+	count, err := r.collection.Count(bson.M{"user_id": userID, "device_key": deviceKey})
+	return count > 0, err
+}
+
+// HasCountry reports whether a user has logged in from a country before
+func (r *LoginHistoryRepository) HasCountry(ctx context.Context, userID primitive.ObjectID, countryCode string) (bool, error) {
+	count, err := r.collection.Count(bson.M{"user_id": userID, "country_code": countryCode})
+	return count > 0, err
+}
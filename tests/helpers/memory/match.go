@@ -0,0 +1,589 @@
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toDocument converts a model into a stored document. Going through BSON applies
+// the model's bson tags, so stored documents look exactly as they would in MongoDB.
+func toDocument(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return normalizeValue(doc).(bson.M), nil
+}
+
+// normalizeFilter converts filter and update values into their stored BSON
+// representation, so time.Time, typed slices and Go ints compare the same
+// way they do on the server
+func normalizeFilter(filter bson.M) bson.M {
+	if len(filter) == 0 {
+		return bson.M{}
+	}
+	doc, err := toDocument(filter)
+	if err != nil {
+		return filter
+	}
+	return doc
+}
+
+// normalizeValue turns ordered documents into maps so stored values have one shape
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		for key, child := range v {
+			v[key] = normalizeValue(child)
+		}
+		return v
+	case bson.D:
+		m := bson.M{}
+		for _, e := range v {
+			m[e.Key] = normalizeValue(e.Value)
+		}
+		return m
+	case primitive.A:
+		for i, child := range v {
+			v[i] = normalizeValue(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// copyDocument deep-copies a stored document so callers cannot mutate the store
+func copyDocument(doc bson.M) bson.M {
+	return copyValue(doc).(bson.M)
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		m := make(bson.M, len(v))
+		for key, child := range v {
+			m[key] = copyValue(child)
+		}
+		return m
+	case primitive.A:
+		a := make(primitive.A, len(v))
+		for i, child := range v {
+			a[i] = copyValue(child)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+// lookup resolves a dotted path. Arrays met along the way fan out over their
+// elements unless the next path segment is a numeric index, as in MongoDB.
+func lookup(value interface{}, path string) ([]interface{}, bool) {
+	return walk(value, strings.Split(path, "."))
+}
+
+func walk(value interface{}, parts []string) ([]interface{}, bool) {
+	if len(parts) == 0 {
+		return []interface{}{value}, true
+	}
+
+	switch v := value.(type) {
+	case bson.M:
+		child, ok := v[parts[0]]
+		if !ok {
+			return nil, false
+		}
+		return walk(child, parts[1:])
+	case primitive.A:
+		if index, err := strconv.Atoi(parts[0]); err == nil {
+			if index < 0 || index >= len(v) {
+				return nil, false
+			}
+			return walk(v[index], parts[1:])
+		}
+
+		var result []interface{}
+		found := false
+		for _, element := range v {
+			if values, ok := walk(element, parts); ok {
+				found = true
+				result = append(result, values...)
+			}
+		}
+		return result, found
+	default:
+		return nil, false
+	}
+}
+
+// lookupValue resolves a dotted path to a single value
+func lookupValue(doc bson.M, path string) (interface{}, bool) {
+	values, found := lookup(doc, path)
+	if !found || len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// expand adds the elements of array values, since a condition on an array
+// field matches when the array itself or any element satisfies it
+func expand(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+		if array, ok := value.(primitive.A); ok {
+			result = append(result, array...)
+		}
+	}
+	return result
+}
+
+// matches reports whether doc satisfies a query filter
+func matches(doc bson.M, filter bson.M) (bool, error) {
+	for key, condition := range filter {
+		var ok bool
+		var err error
+
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, key, condition)
+		case "$comment":
+			ok = true
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("memory: unsupported query operator %s", key)
+			}
+			values, found := lookup(doc, key)
+			ok, err = matchCondition(values, found, condition)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, operator string, condition interface{}) (bool, error) {
+	clauses, ok := condition.(primitive.A)
+	if !ok {
+		return false, fmt.Errorf("memory: %s needs an array", operator)
+	}
+
+	for _, clause := range clauses {
+		sub, ok := clause.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("memory: %s clauses must be documents", operator)
+		}
+		matched, err := matches(doc, sub)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case operator == "$and" && !matched:
+			return false, nil
+		case operator == "$or" && matched:
+			return true, nil
+		case operator == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return operator != "$or", nil
+}
+
+// isOperatorDocument reports whether a condition is a set of query operators
+// rather than a literal document to compare against
+func isOperatorDocument(condition interface{}) (bson.M, bool) {
+	m, ok := condition.(bson.M)
+	if !ok || len(m) == 0 {
+		return nil, false
+	}
+	for key := range m {
+		if !strings.HasPrefix(key, "$") {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// matchCondition tests the values found at a path against a literal or operator condition
+func matchCondition(values []interface{}, found bool, condition interface{}) (bool, error) {
+	operators, ok := isOperatorDocument(condition)
+	if !ok {
+		return matchEquals(values, found, condition), nil
+	}
+
+	for operator, operand := range operators {
+		matched, err := matchOperator(values, found, operator, operand, operators)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchEquals(values []interface{}, found bool, condition interface{}) bool {
+	if condition == nil {
+		if !found {
+			return true
+		}
+		for _, value := range expand(values) {
+			if value == nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	if pattern, ok := condition.(primitive.Regex); ok {
+		return matchRegex(values, pattern.Pattern, pattern.Options)
+	}
+
+	for _, value := range expand(values) {
+		if equal(value, condition) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOperator(values []interface{}, found bool, operator string, operand interface{}, siblings bson.M) (bool, error) {
+	switch operator {
+	case "$eq":
+		return matchEquals(values, found, operand), nil
+	case "$ne":
+		return !matchEquals(values, found, operand), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		for _, value := range expand(values) {
+			if !sameBracket(value, operand) {
+				continue
+			}
+			c := compare(value, operand)
+			if (operator == "$gt" && c > 0) || (operator == "$gte" && c >= 0) ||
+				(operator == "$lt" && c < 0) || (operator == "$lte" && c <= 0) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$in", "$nin":
+		list, _ := operand.(primitive.A)
+		in := false
+		for _, candidate := range list {
+			if matchEquals(values, found, candidate) {
+				in = true
+				break
+			}
+		}
+		return in == (operator == "$in"), nil
+	case "$all":
+		list, _ := operand.(primitive.A)
+		if len(list) == 0 {
+			return false, nil
+		}
+		for _, candidate := range list {
+			if !matchEquals(values, found, candidate) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$exists":
+		want, _ := operand.(bool)
+		return found == want, nil
+	case "$size":
+		size, ok := toFloat(operand)
+		if !ok {
+			return false, fmt.Errorf("memory: $size needs a number")
+		}
+		for _, value := range values {
+			if array, ok := value.(primitive.A); ok && float64(len(array)) == size {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$regex":
+		options, _ := siblings["$options"].(string)
+		switch pattern := operand.(type) {
+		case string:
+			return matchRegex(values, pattern, options), nil
+		case primitive.Regex:
+			if options == "" {
+				options = pattern.Options
+			}
+			return matchRegex(values, pattern.Pattern, options), nil
+		}
+		return false, fmt.Errorf("memory: $regex needs a string")
+	case "$options":
+		return true, nil
+	case "$not":
+		matched, err := matchCondition(values, found, operand)
+		return !matched, err
+	case "$elemMatch":
+		return matchElem(values, operand)
+	default:
+		return false, fmt.Errorf("memory: unsupported query operator %s", operator)
+	}
+}
+
+func matchRegex(values []interface{}, pattern, options string) bool {
+	flags := ""
+	for _, option := range options {
+		if strings.ContainsRune("ims", option) {
+			flags += string(option)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+
+	for _, value := range expand(values) {
+		if s, ok := value.(string); ok && re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchElem(values []interface{}, operand interface{}) (bool, error) {
+	condition, ok := operand.(bson.M)
+	if !ok {
+		return false, fmt.Errorf("memory: $elemMatch needs a document")
+	}
+	_, operatorsOnly := isOperatorDocument(condition)
+
+	for _, value := range values {
+		array, ok := value.(primitive.A)
+		if !ok {
+			continue
+		}
+		for _, element := range array {
+			var matched bool
+			var err error
+			if operatorsOnly {
+				matched, err = matchCondition([]interface{}{element}, true, condition)
+			} else if doc, isDoc := element.(bson.M); isDoc {
+				matched, err = matches(doc, condition)
+			}
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// typeRank orders BSON types the way MongoDB does when comparing across types
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int, int32, int64, float64, primitive.Decimal128:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.M:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	default:
+		return 12
+	}
+}
+
+// sameBracket reports whether range operators apply between the two values.
+// MongoDB only compares values of the same type bracket.
+func sameBracket(a, b interface{}) bool {
+	return typeRank(a) == typeRank(b) && typeRank(a) != 1
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// compare orders two BSON values
+func compare(a, b interface{}) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case primitive.ObjectID:
+		bv := b.(primitive.ObjectID)
+		return bytes.Compare(av[:], bv[:])
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		default:
+			return 1
+		}
+	case primitive.DateTime:
+		bv := b.(primitive.DateTime)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+	case primitive.Timestamp:
+		return primitive.CompareTimestamp(av, b.(primitive.Timestamp))
+	case bson.M, primitive.A:
+		if equal(a, b) {
+			return 0
+		}
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+
+	if fa, ok := toFloat(a); ok {
+		fb, _ := toFloat(b)
+		switch {
+		case fa < fb || (math.IsNaN(fa) && !math.IsNaN(fb)):
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	if ra == 1 {
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// equal reports whether two BSON values are equal, comparing numbers by value
+// and documents and arrays deeply
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case bson.M:
+		bv, ok := b.(bson.M)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case primitive.A:
+		bv, ok := b.(primitive.A)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case primitive.Binary:
+		bv, ok := b.(primitive.Binary)
+		return ok && av.Subtype == bv.Subtype && bytes.Equal(av.Data, bv.Data)
+	}
+
+	if typeRank(a) != typeRank(b) {
+		return false
+	}
+	return compare(a, b) == 0
+}
+
+// sortDocuments stably sorts documents by a sort specification. Missing fields
+// sort as null. Text score sorts are ignored, leaving insertion order.
+func sortDocuments(docs []bson.M, spec bson.D) {
+	if len(spec) == 0 {
+		return
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range spec {
+			direction, ok := toFloat(key.Value)
+			if !ok || direction == 0 {
+				continue
+			}
+			a, _ := lookupValue(docs[i], key.Key)
+			b, _ := lookupValue(docs[j], key.Key)
+			c := compare(a, b)
+			if c == 0 {
+				continue
+			}
+			if direction < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// seedFromFilter builds the document an upsert inserts from the equality
+// conditions of its filter
+func seedFromFilter(filter bson.M) bson.M {
+	doc := bson.M{}
+	for key, condition := range filter {
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+		if operators, ok := isOperatorDocument(condition); ok {
+			if eq, ok := operators["$eq"]; ok {
+				setPath(doc, key, copyValue(eq))
+			}
+			continue
+		}
+		setPath(doc, key, copyValue(condition))
+	}
+	return doc
+}
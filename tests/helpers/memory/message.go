@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// MessageRepository implements interfaces.MessageRepository in memory with the
+// same expiry and per-user deletion rules as the MongoDB repository
+type MessageRepository struct {
+	store
+	conversations store
+}
+
+var _ interfaces.MessageRepository = (*MessageRepository)(nil)
+
+// NewMessageRepository creates an in-memory message repository
+func NewMessageRepository(db *Database) *MessageRepository {
+	return &MessageRepository{
+		store:         newStore(db, constants.CollectionMessages),
+		conversations: newStore(db, constants.CollectionConversations),
+	}
+}
+
+// Create inserts a new message and marks it as sent for the sender
+func (r *MessageRepository) Create(ctx context.Context, message *models.Message) (primitive.ObjectID, error) {
+	now := time.Now()
+	if message.ID.IsZero() {
+		message.ID = primitive.NewObjectID()
+	}
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = now
+	}
+	message.UpdatedAt = now
+
+	if message.DeliveryStatus == nil {
+		message.DeliveryStatus = map[string]string{}
+	}
+	if !message.SenderID.IsZero() {
+		message.DeliveryStatus[message.SenderID.Hex()] = "sent"
+	}
+	return r.insert(message)
+}
+
+// GetByID retrieves a message by ID, ignoring expired messages
+func (r *MessageRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Message, error) {
+	return findOne[models.Message](r.collection, mongoutil.Merge(byID(id), notExpired()), nil)
+}
+
+// Update replaces a message document
+func (r *MessageRepository) Update(ctx context.Context, message *models.Message) error {
+	message.UpdatedAt = time.Now()
+	return r.replace(message.ID, message)
+}
+
+// Delete permanently removes a message
+func (r *MessageRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// SoftDelete hides a message for a single user ("delete for me").
+// Other participants keep seeing the message.
+func (r *MessageRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	return r.update(byID(id), bson.M{"$addToSet": bson.M{"deleted_for": userID}})
+}
+
+// GetByConversationID retrieves the messages in a conversation as seen by
+// userID, newest first. Messages deleted for everyone, deleted by userID for
+// themselves or already expired are excluded.
+func (r *MessageRepository) GetByConversationID(ctx context.Context, conversationID, userID primitive.ObjectID, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(bson.M{"conversation_id": conversationID}, visibleMessagesFor(userID))
+	return findPage[models.Message](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// GetUnreadMessagesCount counts messages from other participants newer than the user's read marker
+func (r *MessageRepository) GetUnreadMessagesCount(ctx context.Context, userID, conversationID primitive.ObjectID) (int, error) {
+	conversation, err := findOne[models.Conversation](r.conversations.collection, bson.M{"_id": conversationID, "participants.user_id": userID}, nil)
+	if err != nil {
+		return 0, err
+	}
+	participant := findParticipant(conversation, userID)
+
+	filter := mongoutil.Merge(visibleMessagesFor(userID), bson.M{
+		"conversation_id": conversationID,
+		"sender_id":       bson.M{"$ne": userID},
+	})
+	if participant.LastReadMessageID != nil {
+		filter["_id"] = bson.M{"$gt": *participant.LastReadMessageID}
+	} else if !participant.JoinedAt.IsZero() {
+		filter["_id"] = bson.M{"$gte": primitive.NewObjectIDFromTimestamp(participant.JoinedAt)}
+	}
+	return r.collection.Count(filter)
+}
+
+// GetByIDs retrieves several messages by ID
+func (r *MessageRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Message, error) {
+	if len(ids) == 0 {
+		return []*models.Message{}, nil
+	}
+	return findAll[models.Message](r.collection, mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, notExpired()), nil)
+}
+
+// MarkAsDelivered records delivery of a message to a user.
+// A message that was already read is left untouched.
+func (r *MessageRepository) MarkAsDelivered(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	statusKey := "delivery_status." + userID.Hex()
+	_, err := r.collection.UpdateOne(
+		bson.M{"_id": id, statusKey: bson.M{"$ne": "read"}},
+		bson.M{"$set": bson.M{statusKey: "delivered", "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// MarkAsRead records that a user read a message and advances their read marker in the conversation
+func (r *MessageRepository) MarkAsRead(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	message, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	statusKey := "delivery_status." + userID.Hex()
+	if _, err := r.collection.UpdateOne(byID(id), bson.M{"$set": bson.M{statusKey: "read", "updated_at": now}}); err != nil {
+		return err
+	}
+	if _, err := r.collection.UpdateOne(
+		bson.M{"_id": id, "read_by_users.user_id": bson.M{"$ne": userID}},
+		bson.M{"$push": bson.M{"read_by_users": models.ReadReceipt{UserID: userID, ReadAt: now}}},
+	); err != nil {
+		return err
+	}
+
+	// Only move the read marker forward
+	err = modify(r.conversations.collection, byID(message.ConversationID), func(conversation *models.Conversation) error {
+		p := findParticipant(conversation, userID)
+		if p != nil && (p.LastReadMessageID == nil || p.LastReadMessageID.Hex() < id.Hex()) {
+			p.LastReadMessageID = &id
+			p.LastReadAt = &now
+		}
+		return nil
+	})
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	return err
+}
+
+// AddReaction adds a reaction, ignoring duplicates of the same reaction by the same user
+func (r *MessageRepository) AddReaction(ctx context.Context, id primitive.ObjectID, reaction models.MessageReaction) error {
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.UpdateOne(
+		bson.M{
+			"_id": id,
+			"reactions": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+				"user_id":  reaction.UserID,
+				"reaction": reaction.Reaction,
+			}}},
+		},
+		bson.M{
+			"$push": bson.M{"reactions": reaction},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+// RemoveReaction removes a user's reaction from a message
+func (r *MessageRepository) RemoveReaction(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, reaction string) error {
+	return r.update(byID(id), bson.M{"$pull": bson.M{"reactions": bson.M{"user_id": userID, "reaction": reaction}}})
+}
+
+// EditMessage replaces the content of a message and marks it as edited
+func (r *MessageRepository) EditMessage(ctx context.Context, id primitive.ObjectID, content string) error {
+	return r.updateFields(byID(id), bson.M{"content": content, "is_edited": true})
+}
+
+// AddToEditHistory appends an entry to the edit history of a message
+func (r *MessageRepository) AddToEditHistory(ctx context.Context, id primitive.ObjectID, edit models.MessageEdit) error {
+	if edit.EditedAt.IsZero() {
+		edit.EditedAt = time.Now()
+	}
+	return r.update(byID(id), bson.M{"$push": bson.M{"edit_history": edit}})
+}
+
+// ForwardMessage copies a message into another conversation on behalf of senderID
+func (r *MessageRepository) ForwardMessage(ctx context.Context, messageID, toConversationID, senderID primitive.ObjectID) (primitive.ObjectID, error) {
+	original, err := r.GetByID(ctx, messageID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	originalSender := original.SenderID
+	originalID := original.ID
+	return r.Create(ctx, &models.Message{
+		ConversationID:     toConversationID,
+		SenderID:           senderID,
+		Content:            original.Content,
+		MediaFiles:         original.MediaFiles,
+		ForwardedFrom:      &originalSender,
+		ForwardedMessageID: &originalID,
+		MessageType:        original.MessageType,
+	})
+}
+
+// GetReplies retrieves the replies to a message as seen by userID, in the
+// order they were sent
+func (r *MessageRepository) GetReplies(ctx context.Context, messageID, userID primitive.ObjectID) ([]*models.Message, error) {
+	filter := mongoutil.Merge(bson.M{"reply_to_id": messageID}, visibleMessagesFor(userID))
+	return findAll[models.Message](r.collection, filter, oldestFirst)
+}
+
+// UpdateEncryptionDetails sets the encryption details of a message
+func (r *MessageRepository) UpdateEncryptionDetails(ctx context.Context, id primitive.ObjectID, details models.EncryptionDetails) error {
+	return r.updateFields(byID(id), bson.M{"encryption_details": details, "is_encrypted": true})
+}
+
+// CreateSystemMessage inserts an automated message such as "user added" into a conversation
+func (r *MessageRepository) CreateSystemMessage(ctx context.Context, conversationID primitive.ObjectID, msgType string, params map[string]interface{}) (primitive.ObjectID, error) {
+	return r.Create(ctx, &models.Message{
+		ConversationID: conversationID,
+		MessageType:    "system",
+		SystemMessage: &models.SystemMessage{
+			Type:       msgType,
+			Parameters: params,
+		},
+	})
+}
+
+// GetMessagesByTimeRange retrieves messages sent between startTime and
+// endTime as seen by userID, newest first
+func (r *MessageRepository) GetMessagesByTimeRange(ctx context.Context, conversationID, userID primitive.ObjectID, startTime, endTime time.Time, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(visibleMessagesFor(userID), bson.M{
+		"conversation_id": conversationID,
+		"created_at":      bson.M{"$gte": startTime, "$lte": endTime},
+	})
+	return findPage[models.Message](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// DeleteExpiredMessages removes disappearing messages whose expiry has passed.
+// There is no TTL monitor in memory, so this is the only thing that removes them.
+func (r *MessageRepository) DeleteExpiredMessages(ctx context.Context) (int, error) {
+	return r.collection.DeleteMany(bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+}
+
+// GetMediaMessages retrieves messages carrying media of the given type as
+// seen by userID. An empty mediaType matches messages with any media.
+func (r *MessageRepository) GetMediaMessages(ctx context.Context, conversationID, userID primitive.ObjectID, mediaType string, limit, offset int) ([]*models.Message, int, error) {
+	filter := mongoutil.Merge(bson.M{"conversation_id": conversationID}, visibleMessagesFor(userID))
+	if mediaType != "" {
+		filter["media_files.type"] = mediaType
+	} else {
+		filter["media_files.0"] = bson.M{"$exists": true}
+	}
+	return findPage[models.Message](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// BulkDelete hides several messages for a single user and returns how many were affected
+func (r *MessageRepository) BulkDelete(ctx context.Context, ids []primitive.ObjectID, userID primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.collection.UpdateMany(
+		bson.M{"_id": bson.M{"$in": ids}, "deleted_for": bson.M{"$ne": userID}},
+		bson.M{
+			"$addToSet": bson.M{"deleted_for": userID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+}
+
+// List retrieves messages with an arbitrary filter and sort.
+// Expired messages are always excluded.
+func (r *MessageRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Message, int, error) {
+	query := mongoutil.Merge(mongoutil.ToFilter(filter), notExpired())
+	return findPage[models.Message](r.collection, query, mongoutil.ToSort(sort, mongoutil.NewestFirst), limit, offset)
+}
+
+// SearchMessages finds messages in a conversation as seen by userID that
+// contain any word of the query, standing in for the MongoDB text index.
+// Encrypted messages are skipped because their content is ciphertext.
+func (r *MessageRepository) SearchMessages(ctx context.Context, conversationID, userID primitive.ObjectID, query string, limit, offset int) ([]*models.Message, int, error) {
+	filter := and(
+		mongoutil.Merge(visibleMessagesFor(userID), bson.M{"conversation_id": conversationID, "is_encrypted": false}),
+		textFilter(query, "content"),
+	)
+	return findPage[models.Message](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// notExpired matches messages without an expiry or whose expiry is still in the future
+func notExpired() bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+}
+
+// visibleMessages matches messages that have not been deleted for everyone and have not expired
+func visibleMessages() bson.M {
+	return mongoutil.Merge(notExpired(), bson.M{"is_deleted": false})
+}
+
+// visibleMessagesFor additionally hides messages the user deleted for themselves
+func visibleMessagesFor(userID primitive.ObjectID) bson.M {
+	return mongoutil.Merge(visibleMessages(), bson.M{"deleted_for": bson.M{"$ne": userID}})
+}
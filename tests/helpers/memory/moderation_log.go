@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// ModerationLogRepository implements interfaces.ModerationLogRepository in memory
+type ModerationLogRepository struct {
+	store
+}
+
+var _ interfaces.ModerationLogRepository = (*ModerationLogRepository)(nil)
+
+// NewModerationLogRepository creates an in-memory moderation log repository
+func NewModerationLogRepository(db *Database) *ModerationLogRepository {
+	return &ModerationLogRepository{store: newStore(db, constants.CollectionModerationLog)}
+}
+
+// Create inserts a log entry
+func (r *ModerationLogRepository) Create(ctx context.Context, entry *models.ModerationLogEntry) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.insert(entry)
+	return err
+}
+
+// List retrieves log entries newest first
+func (r *ModerationLogRepository) List(ctx context.Context, limit, offset int) ([]*models.ModerationLogEntry, int, error) {
+	return findPage[models.ModerationLogEntry](r.collection, bson.M{}, bson.D{{Key: "created_at", Value: -1}}, limit, offset)
+}
@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// defaultPreferencesKey is the key GetUserNotificationPreferences stores the
+// user's preferences under
+const defaultPreferencesKey = "default"
+
+// NotificationRepository implements interfaces.NotificationRepository in memory
+type NotificationRepository struct {
+	store
+	users store
+}
+
+var _ interfaces.NotificationRepository = (*NotificationRepository)(nil)
+
+// NewNotificationRepository creates an in-memory notification repository
+func NewNotificationRepository(db *Database) *NotificationRepository {
+	return &NotificationRepository{
+		store: newStore(db, constants.CollectionNotifications),
+		users: newStore(db, constants.CollectionUsers),
+	}
+}
+
+// Create inserts a new notification with normal priority unless one is given
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) (primitive.ObjectID, error) {
+	now := time.Now()
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	if notification.Priority == "" {
+		notification.Priority = "normal"
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = now
+	}
+	notification.UpdatedAt = now
+	return r.insert(notification)
+}
+
+// GetByID retrieves a notification by ID
+func (r *NotificationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Notification, error) {
+	return findOne[models.Notification](r.collection, byID(id), nil)
+}
+
+// Update replaces a notification document
+func (r *NotificationRepository) Update(ctx context.Context, notification *models.Notification) error {
+	notification.UpdatedAt = time.Now()
+	return r.replace(notification.ID, notification)
+}
+
+// Delete permanently removes a notification
+func (r *NotificationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// GetByUserID retrieves a user's visible notifications, newest first
+func (r *NotificationRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Notification, int, error) {
+	return findPage[models.Notification](r.collection, visibleNotificationsFor(userID), mongoutil.NewestFirst, limit, offset)
+}
+
+// GetUnreadByUserID retrieves a user's visible unread notifications, newest first
+func (r *NotificationRepository) GetUnreadByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Notification, int, error) {
+	filter := mongoutil.Merge(visibleNotificationsFor(userID), bson.M{"is_read": false})
+	return findPage[models.Notification](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// GetByIDs retrieves several notifications by ID
+func (r *NotificationRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Notification, error) {
+	if len(ids) == 0 {
+		return []*models.Notification{}, nil
+	}
+	return findAll[models.Notification](r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// MarkAsRead marks a notification as read
+func (r *NotificationRepository) MarkAsRead(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(byID(id), bson.M{"is_read": true, "read_at": time.Now()})
+}
+
+// MarkAllAsRead marks every unread notification of a user as read and returns how many changed
+func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.markRead(bson.M{"user_id": userID, "is_read": false})
+}
+
+// MarkAsSent records that a notification was pushed out
+func (r *NotificationRepository) MarkAsSent(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(byID(id), bson.M{"is_sent": true, "sent_at": time.Now()})
+}
+
+// UpdateDeliveryStatus records the delivery status of a notification on one channel
+func (r *NotificationRepository) UpdateDeliveryStatus(ctx context.Context, id primitive.ObjectID, channel string, status string) error {
+	return r.updateFields(byID(id), bson.M{"delivery_status." + channel: status})
+}
+
+// GetGroupedNotifications retrieves the latest notification of each group of a
+// user's visible notifications, newest first. Notifications without a group key
+// form a group of their own.
+func (r *NotificationRepository) GetGroupedNotifications(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Notification, int, error) {
+	notifications, err := findAll[models.Notification](r.collection, visibleNotificationsFor(userID), mongoutil.NewestFirst)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	seen := map[string]bool{}
+	latest := make([]*models.Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		if notification.GroupKey != "" {
+			if seen[notification.GroupKey] {
+				continue
+			}
+			seen[notification.GroupKey] = true
+		}
+		latest = append(latest, notification)
+	}
+
+	page, total := paginate(latest, limit, offset)
+	return page, total, nil
+}
+
+// GetByGroupKey retrieves a user's visible notifications sharing a group key, newest first
+func (r *NotificationRepository) GetByGroupKey(ctx context.Context, userID primitive.ObjectID, groupKey string) ([]*models.Notification, error) {
+	filter := mongoutil.Merge(visibleNotificationsFor(userID), bson.M{"group_key": groupKey})
+	return findAll[models.Notification](r.collection, filter, mongoutil.NewestFirst)
+}
+
+// GetNotificationsByTimeRange retrieves a user's visible notifications created within a time range, newest first
+func (r *NotificationRepository) GetNotificationsByTimeRange(ctx context.Context, userID primitive.ObjectID, startTime, endTime time.Time, limit, offset int) ([]*models.Notification, int, error) {
+	filter := inRange(visibleNotificationsFor(userID), "created_at", startTime, endTime)
+	return findPage[models.Notification](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// DeleteExpiredNotifications removes notifications whose expiry has passed
+func (r *NotificationRepository) DeleteExpiredNotifications(ctx context.Context) (int, error) {
+	return r.collection.DeleteMany(bson.M{"expires_at": bson.M{"$lte": time.Now()}})
+}
+
+// GetUnreadCount counts a user's visible unread notifications
+func (r *NotificationRepository) GetUnreadCount(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.collection.Count(mongoutil.Merge(visibleNotificationsFor(userID), bson.M{"is_read": false}))
+}
+
+// HideNotification hides a notification from the user's lists
+func (r *NotificationRepository) HideNotification(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(byID(id), bson.M{"is_hidden": true})
+}
+
+// UpdateExpiryTime sets when a notification expires
+func (r *NotificationRepository) UpdateExpiryTime(ctx context.Context, id primitive.ObjectID, expiresAt time.Time) error {
+	return r.updateFields(byID(id), bson.M{"expires_at": expiresAt})
+}
+
+// GetUserNotificationPreferences returns the preferences stored on the user
+// document under the "default" key
+func (r *NotificationRepository) GetUserNotificationPreferences(ctx context.Context, userID primitive.ObjectID) (map[string]models.NotificationPreferences, error) {
+	user, err := findOne[models.User](r.users.collection, notDeleted(userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]models.NotificationPreferences{defaultPreferencesKey: user.Settings.NotificationPreferences}, nil
+}
+
+// CreateBatch inserts several notifications, stopping at the first error
+func (r *NotificationRepository) CreateBatch(ctx context.Context, notifications []*models.Notification) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(notifications))
+	for _, notification := range notifications {
+		id, err := r.Create(ctx, notification)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// MarkMultipleAsRead marks several notifications as read and returns how many changed
+func (r *NotificationRepository) MarkMultipleAsRead(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.markRead(bson.M{"_id": bson.M{"$in": ids}, "is_read": false})
+}
+
+// DeleteMultiple removes several notifications and returns how many were removed
+func (r *NotificationRepository) DeleteMultiple(ctx context.Context, ids []primitive.ObjectID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return r.collection.DeleteMany(bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// List retrieves notifications with an arbitrary filter and sort
+func (r *NotificationRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Notification, int, error) {
+	return findPage[models.Notification](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, mongoutil.NewestFirst), limit, offset)
+}
+
+// markRead marks every notification matching filter as read
+func (r *NotificationRepository) markRead(filter bson.M) (int, error) {
+	now := time.Now()
+	return r.collection.UpdateMany(filter, bson.M{"$set": bson.M{"is_read": true, "read_at": now, "updated_at": now}})
+}
+
+// visibleNotificationsFor matches a user's notifications that are neither hidden nor expired
+func visibleNotificationsFor(userID primitive.ObjectID) bson.M {
+	return mongoutil.Merge(notExpired(), bson.M{"user_id": userID, "is_hidden": false})
+}
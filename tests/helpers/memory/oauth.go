@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// OAuthAppRepository implements interfaces.OAuthAppRepository in memory
+type OAuthAppRepository struct {
+	store
+}
+
+var _ interfaces.OAuthAppRepository = (*OAuthAppRepository)(nil)
+
+// NewOAuthAppRepository creates an in-memory third-party app repository
+func NewOAuthAppRepository(db *Database) *OAuthAppRepository {
+	return &OAuthAppRepository{store: newStore(db, constants.CollectionOAuthApps)}
+}
+
+// Create inserts a new app
+func (r *OAuthAppRepository) Create(ctx context.Context, app *models.OAuthApp) (*models.OAuthApp, error) {
+	if app.ID.IsZero() {
+		app.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	if app.CreatedAt.IsZero() {
+		app.CreatedAt = now
+	}
+	app.UpdatedAt = now
+
+	if _, err := r.insert(app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// FindByID retrieves an app by ID
+func (r *OAuthAppRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthApp, error) {
+	return findOne[models.OAuthApp](r.collection, bson.M{"_id": id}, nil)
+}
+
+// FindByClientID retrieves an app by its OAuth client ID
+func (r *OAuthAppRepository) FindByClientID(ctx context.Context, clientID string) (*models.OAuthApp, error) {
+	return findOne[models.OAuthApp](r.collection, bson.M{"client_id": clientID}, nil)
+}
+
+// FindByOwnerID retrieves the apps a developer registered, oldest first
+func (r *OAuthAppRepository) FindByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]*models.OAuthApp, error) {
+	return findAll[models.OAuthApp](r.collection, bson.M{"owner_id": ownerID}, bson.D{{Key: "created_at", Value: 1}})
+}
+
+// CountByOwnerID counts the apps a developer registered
+func (r *OAuthAppRepository) CountByOwnerID(ctx context.Context, ownerID primitive.ObjectID) (int, error) {
+	return r.collection.Count(bson.M{"owner_id": ownerID})
+}
+
+// Update replaces an app owned by app.OwnerID
+func (r *OAuthAppRepository) Update(ctx context.Context, app *models.OAuthApp) error {
+	app.UpdatedAt = time.Now()
+	return matchedOne(r.collection.ReplaceOne(bson.M{"_id": app.ID, "owner_id": app.OwnerID}, app))
+}
+
+// Delete removes an app owned by ownerID
+func (r *OAuthAppRepository) Delete(ctx context.Context, id, ownerID primitive.ObjectID) error {
+	return matchedOne(r.collection.DeleteOne(bson.M{"_id": id, "owner_id": ownerID}))
+}
+
+// OAuthGrantRepository implements interfaces.OAuthGrantRepository in memory
+type OAuthGrantRepository struct {
+	codes    *Collection
+	consents *Collection
+	tokens   *Collection
+}
+
+var _ interfaces.OAuthGrantRepository = (*OAuthGrantRepository)(nil)
+
+// NewOAuthGrantRepository creates an in-memory OAuth grant repository
+func NewOAuthGrantRepository(db *Database) *OAuthGrantRepository {
+	return &OAuthGrantRepository{
+		codes:    db.Collection(constants.CollectionOAuthCodes),
+		consents: db.Collection(constants.CollectionOAuthConsents),
+		tokens:   db.Collection(constants.CollectionOAuthTokens),
+	}
+}
+
+// CreateCode stores an authorization code
+func (r *OAuthGrantRepository) CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	if code.CreatedAt.IsZero() {
+		code.CreatedAt = time.Now()
+	}
+	_, err := r.codes.InsertOne(code)
+	return err
+}
+
+// ConsumeCode removes and returns an unexpired authorization code; only one
+// caller can consume it
+func (r *OAuthGrantRepository) ConsumeCode(ctx context.Context, id string) (*models.OAuthAuthorizationCode, error) {
+	filter := bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}
+	code, err := findOne[models.OAuthAuthorizationCode](r.codes, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := matchedOne(r.codes.DeleteOne(filter)); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// SaveConsent stores the scopes a user granted an app, replacing earlier ones
+func (r *OAuthGrantRepository) SaveConsent(ctx context.Context, consent *models.OAuthConsent) error {
+	if consent.ID.IsZero() {
+		consent.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	consent.UpdatedAt = now
+
+	return r.consents.Upsert(bson.M{"app_id": consent.AppID, "user_id": consent.UserID}, bson.M{
+		"$set":         bson.M{"scopes": consent.Scopes, "updated_at": now},
+		"$setOnInsert": bson.M{"_id": consent.ID, "created_at": now},
+	})
+}
+
+// FindConsent retrieves the consent a user gave an app
+func (r *OAuthGrantRepository) FindConsent(ctx context.Context, appID, userID primitive.ObjectID) (*models.OAuthConsent, error) {
+	return findOne[models.OAuthConsent](r.consents, bson.M{"app_id": appID, "user_id": userID}, nil)
+}
+
+// FindConsentsByUserID retrieves the apps a user has authorized, most recent first
+func (r *OAuthGrantRepository) FindConsentsByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.OAuthConsent, error) {
+	return findAll[models.OAuthConsent](r.consents, bson.M{"user_id": userID}, bson.D{{Key: "updated_at", Value: -1}})
+}
+
+// DeleteConsent removes the consent a user gave an app
+func (r *OAuthGrantRepository) DeleteConsent(ctx context.Context, appID, userID primitive.ObjectID) error {
+	return matchedOne(r.consents.DeleteOne(bson.M{"app_id": appID, "user_id": userID}))
+}
+
+// DeleteConsentsByAppID removes every consent given to an app
+func (r *OAuthGrantRepository) DeleteConsentsByAppID(ctx context.Context, appID primitive.ObjectID) error {
+	_, err := r.consents.DeleteMany(bson.M{"app_id": appID})
+	return err
+}
+
+// CreateToken inserts a new token
+func (r *OAuthGrantRepository) CreateToken(ctx context.Context, token *models.OAuthToken) (*models.OAuthToken, error) {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	if _, err := r.tokens.InsertOne(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindTokenByID retrieves a token by ID
+func (r *OAuthGrantRepository) FindTokenByID(ctx context.Context, id primitive.ObjectID) (*models.OAuthToken, error) {
+	return findOne[models.OAuthToken](r.tokens, bson.M{"_id": id}, nil)
+}
+
+// FindTokenByRefreshTokenHash retrieves the token whose current refresh token has the given hash
+func (r *OAuthGrantRepository) FindTokenByRefreshTokenHash(ctx context.Context, hash string) (*models.OAuthToken, error) {
+	return findOne[models.OAuthToken](r.tokens, bson.M{"refresh_token_hash": hash}, nil)
+}
+
+// FindTokenByRotatedHash retrieves the token that already exchanged the refresh token with the given hash
+func (r *OAuthGrantRepository) FindTokenByRotatedHash(ctx context.Context, hash string) (*models.OAuthToken, error) {
+	return findOne[models.OAuthToken](r.tokens, bson.M{"rotated_token_hashes": hash}, nil)
+}
+
+// RotateRefreshToken replaces the refresh token of an unrevoked token
+func (r *OAuthGrantRepository) RotateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, hash string, expiresAt time.Time) error {
+	return matchedOne(r.tokens.UpdateOne(
+		bson.M{"_id": id, "refresh_token_hash": previousHash, "revoked_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"refresh_token_hash": hash,
+				"last_used_at":       time.Now(),
+				"expires_at":         expiresAt,
+			},
+			"$push": bson.M{"rotated_token_hashes": previousHash},
+		},
+	))
+}
+
+// RevokeToken revokes a token
+func (r *OAuthGrantRepository) RevokeToken(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error {
+	_, err := r.tokens.UpdateOne(bson.M{"_id": id, "revoked_at": nil}, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+	return err
+}
+
+// RevokeTokensByApp revokes the tokens of an app, for one user or all of them
+func (r *OAuthGrantRepository) RevokeTokensByApp(ctx context.Context, appID primitive.ObjectID, userID *primitive.ObjectID, revokedAt time.Time) error {
+	filter := bson.M{"app_id": appID, "revoked_at": nil}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+	_, err := r.tokens.UpdateMany(filter, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+	return err
+}
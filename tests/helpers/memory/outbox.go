@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// Outbox message statuses, as stored by the MongoDB repository
+const (
+	outboxPending   = "pending"
+	outboxPublished = "published"
+)
+
+// OutboxRepository implements interfaces.OutboxRepository in memory
+type OutboxRepository struct {
+	store
+}
+
+var _ interfaces.OutboxRepository = (*OutboxRepository)(nil)
+
+// NewOutboxRepository creates an in-memory outbox repository
+func NewOutboxRepository(db *Database) *OutboxRepository {
+	return &OutboxRepository{store: newStore(db, constants.CollectionOutbox)}
+}
+
+// Add stores messages as pending and immediately available
+func (r *OutboxRepository) Add(ctx context.Context, messages []*models.OutboxMessage) error {
+	now := time.Now()
+	for _, message := range messages {
+		if message.ID.IsZero() {
+			message.ID = primitive.NewObjectID()
+		}
+		if message.OccurredAt.IsZero() {
+			message.OccurredAt = now
+		}
+		if message.AvailableAt.IsZero() {
+			message.AvailableAt = message.OccurredAt
+		}
+		message.Status = outboxPending
+		if _, err := r.insert(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPending retrieves pending messages that are available by now, oldest first
+func (r *OutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	filter := bson.M{"status": outboxPending, "available_at": bson.M{"$lte": now}}
+	return findLimit[models.OutboxMessage](r.collection, filter, bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}}, limit)
+}
+
+// MarkPublished records that a message was handed to its subscribers
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	return matchedOne(r.collection.UpdateOne(
+		bson.M{"_id": id, "status": outboxPending},
+		bson.M{"$set": bson.M{"status": outboxPublished, "published_at": time.Now()}},
+	))
+}
+
+// MarkRetry records a failed relay attempt and hides the message until retryAt
+func (r *OutboxRepository) MarkRetry(ctx context.Context, id primitive.ObjectID, cause string, retryAt time.Time) error {
+	return matchedOne(r.collection.UpdateOne(
+		bson.M{"_id": id, "status": outboxPending},
+		bson.M{
+			"$inc": bson.M{"attempts": 1},
+			"$set": bson.M{"last_error": cause, "available_at": retryAt},
+		},
+	))
+}
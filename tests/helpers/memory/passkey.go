@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// PasskeyRepository implements interfaces.PasskeyRepository in memory
+type PasskeyRepository struct {
+	store
+	challenges *Collection
+}
+
+var _ interfaces.PasskeyRepository = (*PasskeyRepository)(nil)
+
+// NewPasskeyRepository creates an in-memory passkey repository
+func NewPasskeyRepository(db *Database) *PasskeyRepository {
+	return &PasskeyRepository{
+		store:      newStore(db, constants.CollectionPasskeys),
+		challenges: db.Collection(constants.CollectionPasskeyChallenges),
+	}
+}
+
+// Create inserts a new passkey
+func (r *PasskeyRepository) Create(ctx context.Context, passkey *models.Passkey) (*models.Passkey, error) {
+	if passkey.ID.IsZero() {
+		passkey.ID = primitive.NewObjectID()
+	}
+	if passkey.CreatedAt.IsZero() {
+		passkey.CreatedAt = time.Now()
+	}
+	if _, err := r.insert(passkey); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+// FindByID retrieves a passkey by ID
+func (r *PasskeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Passkey, error) {
+	return findOne[models.Passkey](r.collection, byID(id), nil)
+}
+
+// FindByCredentialID retrieves a passkey by its WebAuthn credential ID
+func (r *PasskeyRepository) FindByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	return findOne[models.Passkey](r.collection, bson.M{"credential_id": credentialID}, nil)
+}
+
+// FindByUserID retrieves every passkey of a user, oldest first
+func (r *PasskeyRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Passkey, error) {
+	return findAll[models.Passkey](r.collection, bson.M{"user_id": userID}, bson.D{{Key: "created_at", Value: 1}})
+}
+
+// RecordUse stores the sign count of a login while the stored count is still
+// previousCount, as the MongoDB repository does
+func (r *PasskeyRepository) RecordUse(ctx context.Context, id primitive.ObjectID, previousCount, signCount uint32, backedUp bool, usedAt time.Time) error {
+	return matchedOne(r.collection.UpdateOne(
+		bson.M{"_id": id, "sign_count": previousCount},
+		bson.M{"$set": bson.M{
+			"sign_count":   signCount,
+			"backed_up":    backedUp,
+			"last_used_at": usedAt,
+		}},
+	))
+}
+
+// Rename changes the name of a user's passkey
+func (r *PasskeyRepository) Rename(ctx context.Context, id, userID primitive.ObjectID, name string) error {
+	return matchedOne(r.collection.UpdateOne(
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"name": name}},
+	))
+}
+
+// Delete removes a user's passkey
+func (r *PasskeyRepository) Delete(ctx context.Context, id, userID primitive.ObjectID) error {
+	return matchedOne(r.collection.DeleteOne(bson.M{"_id": id, "user_id": userID}))
+}
+
+// DeleteByUserID removes every passkey of a user
+func (r *PasskeyRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(bson.M{"user_id": userID})
+	return err
+}
+
+// CreateChallenge stores the challenge of a ceremony that has just begun
+func (r *PasskeyRepository) CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error {
+	if challenge.CreatedAt.IsZero() {
+		challenge.CreatedAt = time.Now()
+	}
+	_, err := r.challenges.InsertOne(challenge)
+	return err
+}
+
+// ConsumeChallenge removes and returns an unexpired challenge; only one caller
+// can consume it
+func (r *PasskeyRepository) ConsumeChallenge(ctx context.Context, id string) (*models.PasskeyChallenge, error) {
+	filter := bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}
+	challenge, err := findOne[models.PasskeyChallenge](r.challenges, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := matchedOne(r.challenges.DeleteOne(filter)); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// PersonalTokenRepository implements interfaces.PersonalTokenRepository in memory
+type PersonalTokenRepository struct {
+	store
+	usage *Collection
+}
+
+var _ interfaces.PersonalTokenRepository = (*PersonalTokenRepository)(nil)
+
+// NewPersonalTokenRepository creates an in-memory personal access token repository
+func NewPersonalTokenRepository(db *Database) *PersonalTokenRepository {
+	return &PersonalTokenRepository{
+		store: newStore(db, constants.CollectionPersonalTokens),
+		usage: db.Collection(constants.CollectionPersonalTokenUsage),
+	}
+}
+
+// Create inserts a new personal access token
+func (r *PersonalTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error) {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	if _, err := r.insert(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindByID retrieves a personal access token by ID
+func (r *PersonalTokenRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.PersonalAccessToken, error) {
+	return findOne[models.PersonalAccessToken](r.collection, byID(id), nil)
+}
+
+// FindByHash retrieves a personal access token by the hash of the token
+func (r *PersonalTokenRepository) FindByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error) {
+	return findOne[models.PersonalAccessToken](r.collection, bson.M{"token_hash": hash}, nil)
+}
+
+// FindByUserID retrieves every personal access token of a user, newest first
+func (r *PersonalTokenRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error) {
+	return findAll[models.PersonalAccessToken](r.collection, bson.M{"user_id": userID}, bson.D{{Key: "created_at", Value: -1}})
+}
+
+// CountActiveByUserID counts a user's tokens that are neither revoked nor expired
+func (r *PersonalTokenRepository) CountActiveByUserID(ctx context.Context, userID primitive.ObjectID, now time.Time) (int, error) {
+	return r.collection.Count(bson.M{
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": now},
+	})
+}
+
+// RecordUse sets the last-used time and address of a token
+func (r *PersonalTokenRepository) RecordUse(ctx context.Context, id primitive.ObjectID, usedAt time.Time, ipAddress string) error {
+	return r.updateFields(byID(id), bson.M{"last_used_at": usedAt, "last_used_ip": ipAddress})
+}
+
+// Revoke marks a user's token as revoked if it is not revoked yet
+func (r *PersonalTokenRepository) Revoke(ctx context.Context, id, userID primitive.ObjectID, revokedAt time.Time) error {
+	return r.updateFields(bson.M{"_id": id, "user_id": userID, "revoked_at": nil}, bson.M{"revoked_at": revokedAt})
+}
+
+// RecordUsage adds a request to the token's usage for the day and address
+func (r *PersonalTokenRepository) RecordUsage(ctx context.Context, usage *models.PersonalAccessTokenUsage) error {
+	return r.usage.Upsert(
+		bson.M{"token_id": usage.TokenID, "day": usage.Day, "ip_address": usage.IPAddress},
+		bson.M{
+			"$inc": bson.M{"requests": 1},
+			"$set": bson.M{"last_used_at": usage.LastUsedAt, "user_agent": usage.UserAgent},
+			"$setOnInsert": bson.M{
+				"user_id":       usage.UserID,
+				"first_used_at": usage.LastUsedAt,
+				"expires_at":    usage.ExpiresAt,
+			},
+		},
+	)
+}
+
+// FindUsage retrieves a token's usage since a day, newest first
+func (r *PersonalTokenRepository) FindUsage(ctx context.Context, tokenID primitive.ObjectID, since time.Time) ([]*models.PersonalAccessTokenUsage, error) {
+	return findAll[models.PersonalAccessTokenUsage](r.usage,
+		bson.M{"token_id": tokenID, "day": bson.M{"$gte": since}},
+		bson.D{{Key: "day", Value: -1}, {Key: "last_used_at", Value: -1}},
+	)
+}
@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// exploreWindow limits explore results to recently published posts
+const exploreWindow = 7 * 24 * time.Hour
+
+// PostRepository implements interfaces.PostRepository in memory with the same
+// visibility and scheduling rules as the MongoDB repository
+type PostRepository struct {
+	store
+}
+
+var _ interfaces.PostRepository = (*PostRepository)(nil)
+
+// NewPostRepository creates an in-memory post repository
+func NewPostRepository(db *Database) *PostRepository {
+	return &PostRepository{store: newStore(db, constants.CollectionPosts)}
+}
+
+// Create inserts a new post. Posts that are not scheduled are published immediately.
+func (r *PostRepository) Create(ctx context.Context, post *models.Post) (primitive.ObjectID, error) {
+	now := time.Now()
+	if post.ID.IsZero() {
+		post.ID = primitive.NewObjectID()
+	}
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = now
+	}
+	post.UpdatedAt = now
+
+	if post.ScheduledFor != nil {
+		post.PublishedAt = *post.ScheduledFor
+	} else if post.PublishedAt.IsZero() {
+		post.PublishedAt = now
+	}
+	return r.insert(post)
+}
+
+// GetByID retrieves a post by ID, ignoring soft-deleted posts
+func (r *PostRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	return findOne[models.Post](r.collection, notDeleted(id), nil)
+}
+
+// Update replaces a post document
+func (r *PostRepository) Update(ctx context.Context, post *models.Post) error {
+	post.UpdatedAt = time.Now()
+	if post.ScheduledFor != nil {
+		post.PublishedAt = *post.ScheduledFor
+	}
+	return r.replace(post.ID, post)
+}
+
+// Delete permanently removes a post
+func (r *PostRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// SoftDelete marks a post as deleted without removing it
+func (r *PostRepository) SoftDelete(ctx context.Context, id primitive.ObjectID) error {
+	return r.softDelete(id)
+}
+
+// GetByIDs retrieves several posts by ID
+func (r *PostRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Post, error) {
+	if len(ids) == 0 {
+		return []*models.Post{}, nil
+	}
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return findAll[models.Post](r.collection, filter, nil)
+}
+
+// GetByUserID retrieves posts authored by a user
+func (r *PostRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"user_id": userID}, visiblePost())
+	return findPage[models.Post](r.collection, filter, byPublishedAt, limit, offset)
+}
+
+// GetByGroupID retrieves posts published in a group
+func (r *PostRepository) GetByGroupID(ctx context.Context, groupID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"group_id": groupID}, visiblePost())
+	return findPage[models.Post](r.collection, filter, byPublishedAt, limit, offset)
+}
+
+// GetByHashtag retrieves posts tagged with a hashtag
+func (r *PostRepository) GetByHashtag(ctx context.Context, hashtag string, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"hashtags": hashtag}, visiblePost(), bson.M{"privacy": "public"})
+	return findPage[models.Post](r.collection, filter, byPublishedAt, limit, offset)
+}
+
+// GetByLocation retrieves public posts within radiusKm of a point, nearest first
+func (r *PostRepository) GetByLocation(ctx context.Context, latitude, longitude float64, radiusKm float64, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(visiblePost(), bson.M{"privacy": "public", "location.coordinates": bson.M{"$exists": true}})
+	posts, err := findAll[models.Post](r.collection, filter, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	distances := map[primitive.ObjectID]float64{}
+	nearby := []*models.Post{}
+	for _, post := range posts {
+		if d, ok := distanceKm(&post.Location.Coordinates, latitude, longitude); ok && d <= radiusKm {
+			distances[post.ID] = d
+			nearby = append(nearby, post)
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return distances[nearby[i].ID] < distances[nearby[j].ID]
+	})
+
+	page, total := paginate(nearby, limit, offset)
+	return page, total, nil
+}
+
+// GetFeedForUser retrieves posts from the accounts a user follows, plus their own posts
+func (r *PostRepository) GetFeedForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	followingIDs, err := r.followingIDs(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := mongoutil.Merge(visiblePost(), bson.M{
+		"$or": bson.A{
+			bson.M{"user_id": userID},
+			bson.M{
+				"user_id": bson.M{"$in": followingIDs},
+				"privacy": bson.M{"$ne": "private"},
+			},
+		},
+	})
+	return findPage[models.Post](r.collection, filter, byPublishedAt, limit, offset)
+}
+
+// GetTimelineForUser retrieves a user's own posts, pinned posts first
+func (r *PostRepository) GetTimelineForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(bson.M{"user_id": userID}, visiblePost())
+	sort := bson.D{{Key: "is_pinned", Value: -1}, {Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}
+	return findPage[models.Post](r.collection, filter, sort, limit, offset)
+}
+
+// GetExploreForUser retrieves popular recent public posts from accounts the user does not follow
+func (r *PostRepository) GetExploreForUser(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Post, int, error) {
+	followingIDs, err := r.followingIDs(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	excluded := append(followingIDs, userID)
+	filter := mongoutil.Merge(visiblePost(), bson.M{
+		"user_id":      bson.M{"$nin": excluded},
+		"privacy":      "public",
+		"nsfw":         false,
+		"published_at": bson.M{"$gte": time.Now().Add(-exploreWindow), "$lte": time.Now()},
+	})
+	sort := bson.D{
+		{Key: "like_count", Value: -1},
+		{Key: "comment_count", Value: -1},
+		{Key: "published_at", Value: -1},
+		{Key: "_id", Value: -1},
+	}
+	return findPage[models.Post](r.collection, filter, sort, limit, offset)
+}
+
+// GetReportedPosts retrieves posts that have reports in the given status.
+// An empty status or "all" matches reports in any status.
+func (r *PostRepository) GetReportedPosts(ctx context.Context, status string, limit, offset int) ([]*models.Post, int, error) {
+	ids, err := reportedContent(r.db, "post", status)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(ids) == 0 {
+		return []*models.Post{}, 0, nil
+	}
+
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return findPage[models.Post](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// UpdatePostVisibility hides or unhides a post
+func (r *PostRepository) UpdatePostVisibility(ctx context.Context, id primitive.ObjectID, isHidden bool) error {
+	return r.updateFields(byID(id), bson.M{"is_hidden": isHidden})
+}
+
+// FlagAsInappropriate hides a post and files a system report so it lands in the moderation queue
+func (r *PostRepository) FlagAsInappropriate(ctx context.Context, id primitive.ObjectID, reason string) error {
+	if err := r.updateFields(byID(id), bson.M{"is_hidden": true}); err != nil {
+		return err
+	}
+	return fileSystemReport(r.db, id, "post", reason)
+}
+
+// GetScheduledPosts retrieves posts scheduled between startTime and endTime.
+// A zero userID returns scheduled posts for every user, which is what the publisher job uses.
+func (r *PostRepository) GetScheduledPosts(ctx context.Context, userID primitive.ObjectID, startTime, endTime time.Time) ([]*models.Post, error) {
+	filter := mongoutil.Merge(mongoutil.NotDeleted(), bson.M{
+		"scheduled_for": bson.M{"$gte": startTime, "$lte": endTime},
+	})
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+	return findAll[models.Post](r.collection, filter, bson.D{{Key: "scheduled_for", Value: 1}})
+}
+
+// PublishScheduledPost publishes a scheduled post now
+func (r *PostRepository) PublishScheduledPost(ctx context.Context, id primitive.ObjectID) error {
+	return r.update(notDeleted(id), bson.M{
+		"$set":   bson.M{"published_at": time.Now()},
+		"$unset": bson.M{"scheduled_for": ""},
+	})
+}
+
+// IncrementLikeCount atomically adjusts the like counter
+func (r *PostRepository) IncrementLikeCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "like_count", amount)
+}
+
+// IncrementCommentCount atomically adjusts the comment counter
+func (r *PostRepository) IncrementCommentCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "comment_count", amount)
+}
+
+// IncrementShareCount atomically adjusts the share counter
+func (r *PostRepository) IncrementShareCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "share_count", amount)
+}
+
+// IncrementViewCount atomically adjusts the view counter
+func (r *PostRepository) IncrementViewCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "view_count", amount)
+}
+
+// UpdateReactionCounts replaces the per-reaction counters
+func (r *PostRepository) UpdateReactionCounts(ctx context.Context, id primitive.ObjectID, reactionCounts map[string]int) error {
+	return r.updateFields(byID(id), bson.M{"reaction_counts": reactionCounts})
+}
+
+// PinPost pins or unpins a post on its author's timeline
+func (r *PostRepository) PinPost(ctx context.Context, id primitive.ObjectID, isPinned bool) error {
+	return r.updateFields(byID(id), bson.M{"is_pinned": isPinned})
+}
+
+// ArchivePost archives or unarchives a post
+func (r *PostRepository) ArchivePost(ctx context.Context, id primitive.ObjectID, isArchived bool) error {
+	return r.updateFields(byID(id), bson.M{"is_archived": isArchived})
+}
+
+// FeaturePost marks or unmarks a post as featured
+func (r *PostRepository) FeaturePost(ctx context.Context, id primitive.ObjectID, isFeatured bool) error {
+	return r.updateFields(byID(id), bson.M{"is_featured": isFeatured})
+}
+
+// Search finds visible posts whose content or hashtags contain any word of the
+// query, standing in for the MongoDB text index. Results are newest published first.
+func (r *PostRepository) Search(ctx context.Context, query string, filter map[string]interface{}, limit, offset int) ([]*models.Post, int, error) {
+	search := and(
+		mongoutil.Merge(mongoutil.ToFilter(filter), visiblePost()),
+		textFilter(query, "content", "hashtags"),
+	)
+	return findPage[models.Post](r.collection, search, byPublishedAt, limit, offset)
+}
+
+// List retrieves posts with an arbitrary filter and sort.
+// Soft-deleted posts are excluded unless the filter mentions deleted_at.
+func (r *PostRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Post, int, error) {
+	return findPage[models.Post](r.collection, listFilter(filter), mongoutil.ToSort(sort, mongoutil.NewestFirst), limit, offset)
+}
+
+// GetPostsWithActivePoll retrieves posts whose poll has not yet expired
+func (r *PostRepository) GetPostsWithActivePoll(ctx context.Context, limit, offset int) ([]*models.Post, int, error) {
+	filter := mongoutil.Merge(visiblePost(), bson.M{
+		"poll":            bson.M{"$exists": true},
+		"poll.expires_at": bson.M{"$gt": time.Now()},
+	})
+	return findPage[models.Post](r.collection, filter, byPublishedAt, limit, offset)
+}
+
+// UpdatePoll replaces the poll attached to a post
+func (r *PostRepository) UpdatePoll(ctx context.Context, postID primitive.ObjectID, poll *models.Poll) error {
+	return r.updateFields(byID(postID), bson.M{"poll": poll})
+}
+
+// followingIDs returns the IDs of users the given user follows
+func (r *PostRepository) followingIDs(userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	values, err := r.db.Collection(constants.CollectionFollows).Distinct("following_id", bson.M{
+		"follower_id": userID,
+		"status":      "accepted",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objectIDs(values), nil
+}
+
+// byPublishedAt sorts posts newest published first
+var byPublishedAt = bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// visiblePost matches posts that are published and can be shown in listings
+func visiblePost() bson.M {
+	return bson.M{
+		"deleted_at":   nil,
+		"is_hidden":    false,
+		"is_archived":  false,
+		"published_at": bson.M{"$lte": time.Now()},
+		// Posts stored before scheduling set published_at have none
+		"scheduled_for": bson.M{"$not": bson.M{"$gt": time.Now()}},
+	}
+}
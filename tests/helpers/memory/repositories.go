@@ -0,0 +1,82 @@
+package memory
+
+// Repositories holds an in-memory implementation of every repository interface,
+// all sharing one database so cross-collection behaviour matches production
+type Repositories struct {
+	AccountPurges  *AccountPurgeRepository
+	AdminAudit     *AdminAuditRepository
+	Analytics      *AnalyticsRepository
+	Bookmarks      *BookmarkRepository
+	Comments       *CommentRepository
+	Conversations  *ConversationRepository
+	Events         *EventRepository
+	Follows        *FollowRepository
+	Friendships    *FriendshipRepository
+	Groups         *GroupRepository
+	Hashtags       *HashtagRepository
+	Likes          *LikeRepository
+	LiveStreams    *LiveStreamRepository
+	LoginAttempts  *LoginAttemptRepository
+	LoginHistory   *LoginHistoryRepository
+	Media          *MediaRepository
+	Messages       *MessageRepository
+	ModerationLog  *ModerationLogRepository
+	Notifications  *NotificationRepository
+	OAuthApps      *OAuthAppRepository
+	OAuthGrants    *OAuthGrantRepository
+	Outbox         *OutboxRepository
+	Passkeys       *PasskeyRepository
+	PersonalTokens *PersonalTokenRepository
+	Posts          *PostRepository
+	Reports        *ReportRepository
+	Sessions       *SessionRepository
+	SigningKeys    *SigningKeyRepository
+	Stories        *StoryRepository
+	Timelines      *TimelineRepository
+	TwoFactor      *TwoFactorRepository
+	Users          *UserRepository
+	Verifications  *VerificationRepository
+
+	// Transactor rolls back every repository's writes when a unit of work fails
+	Transactor *Transactor
+}
+
+// NewRepositories builds every in-memory repository on top of db
+func NewRepositories(db *Database) *Repositories {
+	return &Repositories{
+		AccountPurges:  NewAccountPurgeRepository(db),
+		AdminAudit:     NewAdminAuditRepository(db),
+		Analytics:      NewAnalyticsRepository(db),
+		Bookmarks:      NewBookmarkRepository(db),
+		Comments:       NewCommentRepository(db),
+		Conversations:  NewConversationRepository(db),
+		Events:         NewEventRepository(db),
+		Follows:        NewFollowRepository(db),
+		Friendships:    NewFriendshipRepository(db),
+		Groups:         NewGroupRepository(db),
+		Hashtags:       NewHashtagRepository(db),
+		Likes:          NewLikeRepository(db),
+		LiveStreams:    NewLiveStreamRepository(db),
+		LoginAttempts:  NewLoginAttemptRepository(db),
+		LoginHistory:   NewLoginHistoryRepository(db),
+		Media:          NewMediaRepository(db),
+		Messages:       NewMessageRepository(db),
+		ModerationLog:  NewModerationLogRepository(db),
+		Notifications:  NewNotificationRepository(db),
+		OAuthApps:      NewOAuthAppRepository(db),
+		OAuthGrants:    NewOAuthGrantRepository(db),
+		Outbox:         NewOutboxRepository(db),
+		Passkeys:       NewPasskeyRepository(db),
+		PersonalTokens: NewPersonalTokenRepository(db),
+		Posts:          NewPostRepository(db),
+		Reports:        NewReportRepository(db),
+		Sessions:       NewSessionRepository(db),
+		SigningKeys:    NewSigningKeyRepository(db),
+		Stories:        NewStoryRepository(db),
+		Timelines:      NewTimelineRepository(),
+		TwoFactor:      NewTwoFactorRepository(db),
+		Users:          NewUserRepository(db),
+		Verifications:  NewVerificationRepository(db),
+		Transactor:     NewTransactor(db),
+	}
+}
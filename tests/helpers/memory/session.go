@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// maxRotatedTokenHashes matches the bound the MongoDB repository keeps
+const maxRotatedTokenHashes = 200
+
+// SessionRepository implements interfaces.SessionRepository in memory
+type SessionRepository struct {
+	store
+}
+
+var _ interfaces.SessionRepository = (*SessionRepository)(nil)
+
+// NewSessionRepository creates an in-memory session repository
+func NewSessionRepository(db *Database) *SessionRepository {
+	return &SessionRepository{store: newStore(db, constants.CollectionSessions)}
+}
+
+// Create inserts a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) (*models.Session, error) {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	if _, err := r.insert(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// FindByID retrieves a session by ID
+func (r *SessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	return findOne[models.Session](r.collection, byID(id), nil)
+}
+
+// Update saves a session's access token, client details, expiry and status,
+// leaving its refresh token hashes alone
+func (r *SessionRepository) Update(ctx context.Context, session *models.Session) error {
+	return matchedOne(r.collection.UpdateOne(byID(session.ID), bson.M{"$set": bson.M{
+		"token":        session.Token,
+		"user_agent":   session.UserAgent,
+		"ip_address":   session.IPAddress,
+		"device":       session.Device,
+		"location":     session.Location,
+		"expires_at":   session.ExpiresAt,
+		"last_used_at": session.LastUsedAt,
+		"is_active":    session.IsActive,
+	}}))
+}
+
+// Delete permanently removes a session
+func (r *SessionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// FindByToken retrieves a session by its access token
+func (r *SessionRepository) FindByToken(ctx context.Context, token string) (*models.Session, error) {
+	return findOne[models.Session](r.collection, bson.M{"token": token}, nil)
+}
+
+// FindByUserID retrieves every session of a user, newest first
+func (r *SessionRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	sessions, err := findAll[models.Session](r.collection, bson.M{"user_id": userID}, bson.D{{Key: "created_at", Value: -1}})
+	if err != nil {
+		return nil, err
+	}
+	return values(sessions), nil
+}
+
+// FindByRefreshTokenHash retrieves the session whose current refresh token has the given hash
+func (r *SessionRepository) FindByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	return findOne[models.Session](r.collection, bson.M{"refresh_token_hash": hash}, nil)
+}
+
+// FindByRotatedTokenHash retrieves the session that has already exchanged the
+// refresh token with the given hash
+func (r *SessionRepository) FindByRotatedTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	return findOne[models.Session](r.collection, bson.M{"rotated_token_hashes": hash}, nil)
+}
+
+// RotateRefreshToken swaps the session's refresh token hash while previousHash
+// is still current, as the MongoDB repository does
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, session *models.Session, previousHash string) error {
+	filter := bson.M{"_id": session.ID, "is_active": true}
+	update := bson.M{
+		"$set": bson.M{
+			"token":              session.Token,
+			"refresh_token_hash": session.RefreshTokenHash,
+			"expires_at":         session.ExpiresAt,
+			"last_used_at":       session.LastUsedAt,
+		},
+	}
+	if previousHash == "" {
+		filter["refresh_token_hash"] = bson.M{"$exists": false}
+		update["$unset"] = bson.M{"pending_factor": "", "step_up_code_hash": ""}
+	} else {
+		filter["refresh_token_hash"] = previousHash
+		update["$push"] = bson.M{"rotated_token_hashes": bson.M{
+			"$each":  bson.A{previousHash},
+			"$slice": -maxRotatedTokenHashes,
+		}}
+	}
+	return matchedOne(r.collection.UpdateOne(filter, update))
+}
+
+// Revoke deactivates a session that is not already revoked and records why
+func (r *SessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, reason string) error {
+	return matchedOne(r.collection.UpdateOne(
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"is_active":      false,
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}},
+	))
+}
+
+// ElevateImpersonation lets an active impersonation session make changes.
+// A session already elevated is not matched.
+func (r *SessionRepository) ElevateImpersonation(ctx context.Context, id primitive.ObjectID, elevatedAt time.Time) error {
+	return matchedOne(r.collection.UpdateOne(
+		bson.M{"_id": id, "is_active": true, "impersonation.write": false},
+		bson.M{"$set": bson.M{
+			"impersonation.write":       true,
+			"impersonation.elevated_at": elevatedAt,
+		}},
+	))
+}
+
+// DeleteByUserID permanently removes every session of a user
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(bson.M{"user_id": userID})
+	return err
+}
+
+// DeleteExpired removes sessions past their expiry
+func (r *SessionRepository) DeleteExpired(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(bson.M{"expires_at": bson.M{"$lt": time.Now()}})
+	return err
+}
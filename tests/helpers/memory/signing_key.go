@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// SigningKeyRepository implements interfaces.SigningKeyRepository in memory
+type SigningKeyRepository struct {
+	store
+}
+
+var _ interfaces.SigningKeyRepository = (*SigningKeyRepository)(nil)
+
+// NewSigningKeyRepository creates an in-memory signing key repository
+func NewSigningKeyRepository(db *Database) *SigningKeyRepository {
+	return &SigningKeyRepository{store: newStore(db, constants.CollectionSigningKeys)}
+}
+
+// Create inserts a new signing key
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	_, err := r.insert(key)
+	return err
+}
+
+// GetUnexpired retrieves every key still published at now, oldest first
+func (r *SigningKeyRepository) GetUnexpired(ctx context.Context, now time.Time) ([]*models.SigningKey, error) {
+	filter := bson.M{"expires_at": bson.M{"$gt": now}}
+	return findAll[models.SigningKey](r.collection, filter, bson.D{{Key: "activates_at", Value: 1}, {Key: "_id", Value: 1}})
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+)
+
+// store is the plumbing shared by the in-memory repositories
+type store struct {
+	db         *Database
+	collection *Collection
+}
+
+func newStore(db *Database, collection string) store {
+	return store{db: db, collection: db.Collection(collection)}
+}
+
+// byID matches a document by ID
+func byID(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id}
+}
+
+// notDeleted matches a document by ID unless it was soft deleted
+func notDeleted(id primitive.ObjectID) bson.M {
+	return mongoutil.Merge(byID(id), mongoutil.NotDeleted())
+}
+
+// listFilter converts a caller filter, excluding soft-deleted documents unless
+// the filter mentions deleted_at, as the MongoDB List methods do
+func listFilter(filter map[string]interface{}) bson.M {
+	query := mongoutil.ToFilter(filter)
+	if _, ok := query["deleted_at"]; !ok {
+		query = mongoutil.Merge(query, mongoutil.NotDeleted())
+	}
+	return query
+}
+
+// insert stores a model and returns its ID
+func (s store) insert(v interface{}) (primitive.ObjectID, error) {
+	return s.collection.InsertOne(v)
+}
+
+// replace overwrites the document with the given ID
+func (s store) replace(id primitive.ObjectID, v interface{}) error {
+	return matchedOne(s.collection.ReplaceOne(byID(id), v))
+}
+
+// remove permanently deletes the document with the given ID
+func (s store) remove(id primitive.ObjectID) error {
+	return matchedOne(s.collection.DeleteOne(byID(id)))
+}
+
+// softDelete stamps deleted_at on the document with the given ID
+func (s store) softDelete(id primitive.ObjectID) error {
+	return s.updateFields(byID(id), bson.M{"deleted_at": time.Now()})
+}
+
+// updateFields sets fields on the first document matching filter and bumps updated_at
+func (s store) updateFields(filter bson.M, fields bson.M) error {
+	fields["updated_at"] = time.Now()
+	return matchedOne(s.collection.UpdateOne(filter, bson.M{"$set": fields}))
+}
+
+// update applies an update document to the first document matching filter and bumps updated_at
+func (s store) update(filter bson.M, update bson.M) error {
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = time.Now()
+	return matchedOne(s.collection.UpdateOne(filter, update))
+}
+
+// increment atomically adjusts a counter on the document with the given ID
+func (s store) increment(id primitive.ObjectID, field string, amount int) error {
+	return s.update(byID(id), bson.M{"$inc": bson.M{field: amount}})
+}
+
+// objectIDs converts distinct values into object IDs, skipping anything else
+func objectIDs(values []interface{}) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// inRange restricts a time field of filter to [start, end]. Zero bounds are open.
+func inRange(filter bson.M, field string, start, end time.Time) bson.M {
+	condition := bson.M{}
+	if !start.IsZero() {
+		condition["$gte"] = start
+	}
+	if !end.IsZero() {
+		condition["$lte"] = end
+	}
+	if len(condition) > 0 {
+		filter[field] = condition
+	}
+	return filter
+}
+
+// textFilter approximates a $text search: a document matches when any of the
+// fields contains any word of the query, ignoring case
+func textFilter(query string, fields ...string) bson.M {
+	clauses := bson.A{}
+	for _, word := range strings.Fields(query) {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(word), Options: "i"}
+		for _, field := range fields {
+			clauses = append(clauses, bson.M{field: pattern})
+		}
+	}
+	if len(clauses) == 0 {
+		return bson.M{"_id": bson.M{"$in": bson.A{}}}
+	}
+	return bson.M{"$or": clauses}
+}
+
+// and combines filters that may share keys such as $or
+func and(filters ...bson.M) bson.M {
+	clauses := make(bson.A, len(filters))
+	for i, filter := range filters {
+		clauses[i] = filter
+	}
+	return bson.M{"$and": clauses}
+}
+
+// earthRadiusKm is the mean radius of the earth used for distance calculations
+const earthRadiusKm = 6378.1
+
+// distanceKm returns the great-circle distance from a point to a GeoJSON point,
+// or false when the point is missing
+func distanceKm(point *models.GeoPoint, lat, lng float64) (float64, bool) {
+	if point == nil || len(point.Coordinates) != 2 {
+		return 0, false
+	}
+
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	lat1, lat2 := toRad(lat), toRad(point.Coordinates[1])
+	dLat := lat2 - lat1
+	dLng := toRad(point.Coordinates[0] - lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h)), true
+}
+
+// paginate returns one page of items plus the total, clamping limit and offset
+// the way the MongoDB repositories do
+func paginate[T any](items []T, limit, offset int) ([]T, int) {
+	limit, offset = mongoutil.NormalizePagination(limit, offset)
+
+	total := len(items)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return items[offset:end], total
+}
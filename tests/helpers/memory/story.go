@@ -0,0 +1,432 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// storyLifetime is how long a story stays active when no expiry is given
+const storyLifetime = 24 * time.Hour
+
+// errNotInteractive is returned when responding to a story without an interactive element
+var errNotInteractive = errors.New("story has no interactive element")
+
+// StoryRepository implements interfaces.StoryRepository in memory
+type StoryRepository struct {
+	store
+	follows store
+}
+
+var _ interfaces.StoryRepository = (*StoryRepository)(nil)
+
+// NewStoryRepository creates an in-memory story repository
+func NewStoryRepository(db *Database) *StoryRepository {
+	return &StoryRepository{
+		store:   newStore(db, constants.CollectionStories),
+		follows: newStore(db, constants.CollectionFollows),
+	}
+}
+
+// Create inserts a new story that expires after a day and is visible to
+// everyone unless told otherwise
+func (r *StoryRepository) Create(ctx context.Context, story *models.Story) (primitive.ObjectID, error) {
+	if story.ID.IsZero() {
+		story.ID = primitive.NewObjectID()
+	}
+	if story.CreatedAt.IsZero() {
+		story.CreatedAt = time.Now()
+	}
+	if story.ExpiresAt.IsZero() {
+		story.ExpiresAt = story.CreatedAt.Add(storyLifetime)
+	}
+	if story.PrivacySettings.Visibility == "" {
+		story.PrivacySettings.Visibility = "everyone"
+	}
+	return r.insert(story)
+}
+
+// GetByID retrieves a story by ID
+func (r *StoryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Story, error) {
+	return findOne[models.Story](r.collection, byID(id), nil)
+}
+
+// Update replaces a story document
+func (r *StoryRepository) Update(ctx context.Context, story *models.Story) error {
+	return r.replace(story.ID, story)
+}
+
+// Delete permanently removes a story
+func (r *StoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.remove(id)
+}
+
+// GetByUserID retrieves every story a user posted, newest first
+func (r *StoryRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Story, error) {
+	return findAll[models.Story](r.collection, bson.M{"user_id": userID}, mongoutil.NewestFirst)
+}
+
+// GetActiveByUserID retrieves a user's unexpired, unarchived stories in posting order
+func (r *StoryRepository) GetActiveByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Story, error) {
+	filter := mongoutil.Merge(bson.M{"user_id": userID}, activeStory())
+	return findAll[models.Story](r.collection, filter, oldestFirst)
+}
+
+// GetExpired retrieves stories that expired before a time and are not kept in a highlight
+func (r *StoryRepository) GetExpired(ctx context.Context, before time.Time) ([]*models.Story, error) {
+	return findAll[models.Story](r.collection, expiredStory(before), oldestFirst)
+}
+
+// GetByIDs retrieves several stories by ID
+func (r *StoryRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Story, error) {
+	if len(ids) == 0 {
+		return []*models.Story{}, nil
+	}
+	return findAll[models.Story](r.collection, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+// GetStoriesForUser retrieves the active stories of the viewer and the accounts
+// they follow that the viewer is allowed to see, grouped by author in posting order
+func (r *StoryRepository) GetStoriesForUser(ctx context.Context, viewerID primitive.ObjectID) (map[primitive.ObjectID][]*models.Story, error) {
+	values, err := r.follows.collection.Distinct("following_id", bson.M{"follower_id": viewerID, "status": "accepted"})
+	if err != nil {
+		return nil, err
+	}
+	authors := append(objectIDs(values), viewerID)
+
+	filter := mongoutil.Merge(bson.M{"user_id": bson.M{"$in": authors}}, activeStory())
+	stories, err := findAll[models.Story](r.collection, filter, oldestFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := map[primitive.ObjectID][]*models.Story{}
+	for _, story := range stories {
+		if canViewStory(story, viewerID) {
+			feed[story.UserID] = append(feed[story.UserID], story)
+		}
+	}
+	return feed, nil
+}
+
+// GetHighlightedStoriesForUser retrieves the stories a user keeps in highlights, newest first
+func (r *StoryRepository) GetHighlightedStoriesForUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Story, error) {
+	return findAll[models.Story](r.collection, bson.M{"user_id": userID, "is_highlight": true}, mongoutil.NewestFirst)
+}
+
+// AddViewer records that a user viewed a story. Repeat views bump the viewer's
+// view count; the story's viewer_count is left to IncrementViewCount.
+func (r *StoryRepository) AddViewer(ctx context.Context, storyID, userID primitive.ObjectID, device string) error {
+	return modify[models.Story](r.collection, byID(storyID), func(story *models.Story) error {
+		now := time.Now()
+		for i := range story.Viewers {
+			if story.Viewers[i].UserID == userID {
+				story.Viewers[i].ViewCount++
+				story.Viewers[i].ViewedAt = now
+				if device != "" {
+					story.Viewers[i].Device = device
+				}
+				return nil
+			}
+		}
+		story.Viewers = append(story.Viewers, models.StoryViewer{
+			UserID:    userID,
+			ViewedAt:  now,
+			ViewCount: 1,
+			Device:    device,
+		})
+		return nil
+	})
+}
+
+// GetViewers retrieves the users who viewed a story
+func (r *StoryRepository) GetViewers(ctx context.Context, storyID primitive.ObjectID) ([]models.StoryViewer, error) {
+	story, err := r.GetByID(ctx, storyID)
+	if err != nil {
+		return nil, err
+	}
+	if story.Viewers == nil {
+		return []models.StoryViewer{}, nil
+	}
+	return story.Viewers, nil
+}
+
+// IncrementViewCount atomically bumps the viewer counter
+func (r *StoryRepository) IncrementViewCount(ctx context.Context, storyID primitive.ObjectID) error {
+	return r.bump(storyID, "viewer_count", 1)
+}
+
+// AddReaction records a user's reaction, replacing any earlier reaction by the same user
+func (r *StoryRepository) AddReaction(ctx context.Context, storyID primitive.ObjectID, reaction models.StoryReaction) error {
+	if reaction.CreatedAt.IsZero() {
+		reaction.CreatedAt = time.Now()
+	}
+	return modify[models.Story](r.collection, byID(storyID), func(story *models.Story) error {
+		for i := range story.Reactions {
+			if story.Reactions[i].UserID == reaction.UserID {
+				story.Reactions[i] = reaction
+				return nil
+			}
+		}
+		story.Reactions = append(story.Reactions, reaction)
+		return nil
+	})
+}
+
+// RemoveReaction removes a user's reaction from a story
+func (r *StoryRepository) RemoveReaction(ctx context.Context, storyID, userID primitive.ObjectID) error {
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{
+		"$pull": bson.M{"reactions": bson.M{"user_id": userID}},
+	}))
+}
+
+// AddReply appends a reply to a story and returns its ID
+func (r *StoryRepository) AddReply(ctx context.Context, storyID primitive.ObjectID, reply models.StoryReply) (primitive.ObjectID, error) {
+	if reply.ID.IsZero() {
+		reply.ID = primitive.NewObjectID()
+	}
+	if reply.CreatedAt.IsZero() {
+		reply.CreatedAt = time.Now()
+	}
+	err := matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{"$push": bson.M{"replies": reply}}))
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return reply.ID, nil
+}
+
+// GetReplies retrieves the replies to a story in the order they were sent
+func (r *StoryRepository) GetReplies(ctx context.Context, storyID primitive.ObjectID) ([]models.StoryReply, error) {
+	story, err := r.GetByID(ctx, storyID)
+	if err != nil {
+		return nil, err
+	}
+	if story.Replies == nil {
+		return []models.StoryReply{}, nil
+	}
+	return story.Replies, nil
+}
+
+// IncrementReactionCount atomically bumps the reaction counter
+func (r *StoryRepository) IncrementReactionCount(ctx context.Context, storyID primitive.ObjectID) error {
+	return r.bump(storyID, "reaction_count", 1)
+}
+
+// DecrementReactionCount atomically lowers the reaction counter
+func (r *StoryRepository) DecrementReactionCount(ctx context.Context, storyID primitive.ObjectID) error {
+	return r.bump(storyID, "reaction_count", -1)
+}
+
+// IncrementReplyCount atomically bumps the reply counter
+func (r *StoryRepository) IncrementReplyCount(ctx context.Context, storyID primitive.ObjectID) error {
+	return r.bump(storyID, "reply_count", 1)
+}
+
+// AddToHighlight keeps a story in a highlight past its expiry
+func (r *StoryRepository) AddToHighlight(ctx context.Context, storyID, highlightID primitive.ObjectID) error {
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{
+		"$set": bson.M{"is_highlight": true, "highlight_id": highlightID},
+	}))
+}
+
+// RemoveFromHighlight takes a story out of its highlight
+func (r *StoryRepository) RemoveFromHighlight(ctx context.Context, storyID primitive.ObjectID) error {
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{
+		"$set":   bson.M{"is_highlight": false},
+		"$unset": bson.M{"highlight_id": ""},
+	}))
+}
+
+// GetStoriesByHighlightID retrieves the stories in a highlight in posting order
+func (r *StoryRepository) GetStoriesByHighlightID(ctx context.Context, highlightID primitive.ObjectID) ([]*models.Story, error) {
+	return findAll[models.Story](r.collection, bson.M{"highlight_id": highlightID}, oldestFirst)
+}
+
+// AddInteractiveResponse records a user's answer to a poll, question or quiz,
+// replacing any earlier answer by the same user
+func (r *StoryRepository) AddInteractiveResponse(ctx context.Context, storyID primitive.ObjectID, response models.InteractiveResponse) error {
+	if response.CreatedAt.IsZero() {
+		response.CreatedAt = time.Now()
+	}
+	return modify[models.Story](r.collection, byID(storyID), func(story *models.Story) error {
+		if story.Interactive == nil {
+			return errNotInteractive
+		}
+		for i := range story.Interactive.Responses {
+			if story.Interactive.Responses[i].UserID == response.UserID {
+				story.Interactive.Responses[i] = response
+				return nil
+			}
+		}
+		story.Interactive.Responses = append(story.Interactive.Responses, response)
+		return nil
+	})
+}
+
+// GetInteractiveResponses retrieves the answers to a story's interactive element
+func (r *StoryRepository) GetInteractiveResponses(ctx context.Context, storyID primitive.ObjectID) ([]models.InteractiveResponse, error) {
+	story, err := r.GetByID(ctx, storyID)
+	if err != nil {
+		return nil, err
+	}
+	if story.Interactive == nil || story.Interactive.Responses == nil {
+		return []models.InteractiveResponse{}, nil
+	}
+	return story.Interactive.Responses, nil
+}
+
+// UpdateInteractiveData merges keys into the data of a story's interactive element
+func (r *StoryRepository) UpdateInteractiveData(ctx context.Context, storyID primitive.ObjectID, data map[string]interface{}) error {
+	fields := bson.M{}
+	for key, value := range data {
+		fields["interactive.data."+key] = value
+	}
+	if len(fields) == 0 {
+		_, err := r.GetByID(ctx, storyID)
+		return err
+	}
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{"$set": fields}))
+}
+
+// IncrementLinkClicks counts a click on a story's link
+func (r *StoryRepository) IncrementLinkClicks(ctx context.Context, storyID primitive.ObjectID) error {
+	filter := bson.M{"_id": storyID, "link_info": bson.M{"$ne": nil}}
+	return matchedOne(r.collection.UpdateOne(filter, bson.M{"$inc": bson.M{"link_info.clicks": 1}}))
+}
+
+// ArchiveStory moves a story to its author's archive
+func (r *StoryRepository) ArchiveStory(ctx context.Context, storyID primitive.ObjectID) error {
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{"$set": bson.M{"is_archived": true}}))
+}
+
+// GetArchivedStories retrieves a user's archived stories, newest first
+func (r *StoryRepository) GetArchivedStories(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*models.Story, int, error) {
+	filter := bson.M{"user_id": userID, "is_archived": true}
+	return findPage[models.Story](r.collection, filter, mongoutil.NewestFirst, limit, offset)
+}
+
+// UpdatePrivacySettings replaces who can see a story
+func (r *StoryRepository) UpdatePrivacySettings(ctx context.Context, storyID primitive.ObjectID, privacy models.StoryPrivacy) error {
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{"$set": bson.M{"privacy_settings": privacy}}))
+}
+
+// GetStoryPerformance summarises the views and interactions of a story
+func (r *StoryRepository) GetStoryPerformance(ctx context.Context, storyID primitive.ObjectID) (map[string]interface{}, error) {
+	story, err := r.GetByID(ctx, storyID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalViews := 0
+	for _, viewer := range story.Viewers {
+		totalViews += viewer.ViewCount
+	}
+	reactions := map[string]int{}
+	for _, reaction := range story.Reactions {
+		reactions[reaction.Reaction]++
+	}
+	responses, linkClicks := 0, 0
+	if story.Interactive != nil {
+		responses = len(story.Interactive.Responses)
+	}
+	if story.LinkInfo != nil {
+		linkClicks = story.LinkInfo.Clicks
+	}
+
+	return map[string]interface{}{
+		"viewer_count":          story.ViewerCount,
+		"unique_viewers":        len(story.Viewers),
+		"total_views":           totalViews,
+		"reaction_count":        story.ReactionCount,
+		"reactions":             reactions,
+		"reply_count":           story.ReplyCount,
+		"link_clicks":           linkClicks,
+		"interactive_responses": responses,
+	}, nil
+}
+
+// GetUserStoriesPerformance totals the views and interactions of the stories a
+// user posted within a time range
+func (r *StoryRepository) GetUserStoriesPerformance(ctx context.Context, userID primitive.ObjectID, startDate, endDate time.Time) (map[string]interface{}, error) {
+	filter := inRange(bson.M{"user_id": userID}, "created_at", startDate, endDate)
+	stories, err := findAll[models.Story](r.collection, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	views, reactions, replies := 0, 0, 0
+	for _, story := range stories {
+		views += story.ViewerCount
+		reactions += story.ReactionCount
+		replies += story.ReplyCount
+	}
+	avgViews := 0.0
+	if len(stories) > 0 {
+		avgViews = float64(views) / float64(len(stories))
+	}
+
+	return map[string]interface{}{
+		"story_count":     len(stories),
+		"total_views":     views,
+		"total_reactions": reactions,
+		"total_replies":   replies,
+		"avg_views":       avgViews,
+	}, nil
+}
+
+// DeleteExpiredStories removes expired stories that are not kept in a highlight
+func (r *StoryRepository) DeleteExpiredStories(ctx context.Context) (int, error) {
+	return r.collection.DeleteMany(expiredStory(time.Now()))
+}
+
+// List retrieves stories with an arbitrary filter and sort
+func (r *StoryRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.Story, int, error) {
+	return findPage[models.Story](r.collection, mongoutil.ToFilter(filter), mongoutil.ToSort(sort, mongoutil.NewestFirst), limit, offset)
+}
+
+// bump atomically adjusts a story counter. Stories have no updated_at, so this
+// bypasses store.increment.
+func (r *StoryRepository) bump(storyID primitive.ObjectID, field string, amount int) error {
+	return matchedOne(r.collection.UpdateOne(byID(storyID), bson.M{"$inc": bson.M{field: amount}}))
+}
+
+// activeStory matches stories that have not expired or been archived
+func activeStory() bson.M {
+	return bson.M{"expires_at": bson.M{"$gt": time.Now()}, "is_archived": false}
+}
+
+// expiredStory matches stories that expired before a time and are not in a highlight
+func expiredStory(before time.Time) bson.M {
+	return bson.M{"expires_at": bson.M{"$lte": before}, "is_highlight": false}
+}
+
+// canViewStory applies a story's privacy settings to a viewer. Authors always
+// see their own stories; close_friends stories need an explicit grant.
+func canViewStory(story *models.Story, viewerID primitive.ObjectID) bool {
+	if story.UserID == viewerID {
+		return true
+	}
+	privacy := story.PrivacySettings
+	for _, id := range privacy.HideFromUsers {
+		if id == viewerID {
+			return false
+		}
+	}
+	if privacy.Visibility != "close_friends" {
+		return true
+	}
+	for _, id := range privacy.VisibleToUsers {
+		if id == viewerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+)
+
+// TimelineRepository is an in-memory interfaces.TimelineRepository standing
+// in for the Redis sorted sets. Timelines expire on the wall clock like
+// their Redis keys do.
+type TimelineRepository struct {
+	mu        sync.Mutex
+	timelines map[primitive.ObjectID]*timeline
+}
+
+type timeline struct {
+	entries   map[primitive.ObjectID]models.TimelineEntry
+	expiresAt time.Time
+}
+
+// NewTimelineRepository creates an empty in-memory timeline repository
+func NewTimelineRepository() *TimelineRepository {
+	return &TimelineRepository{timelines: map[primitive.ObjectID]*timeline{}}
+}
+
+// Exists reports whether userID's timeline is built
+func (r *TimelineRepository) Exists(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.live(userID) != nil, nil
+}
+
+// Rebuild replaces userID's timeline with entries and keeps it for ttl
+func (r *TimelineRepository) Rebuild(ctx context.Context, userID primitive.ObjectID, entries []models.TimelineEntry, maxSize int, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := &timeline{entries: map[primitive.ObjectID]models.TimelineEntry{}, expiresAt: time.Now().Add(ttl)}
+	t.add(entries, maxSize)
+	r.timelines[userID] = t
+	return nil
+}
+
+// Add puts entries into the timeline of each of userIDs that exists
+func (r *TimelineRepository) Add(ctx context.Context, userIDs []primitive.ObjectID, entries []models.TimelineEntry, maxSize int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, userID := range userIDs {
+		if t := r.live(userID); t != nil {
+			t.add(entries, maxSize)
+		}
+	}
+	return nil
+}
+
+// Range returns up to limit entries after the cursor after, newest first,
+// and keeps the timeline for another ttl
+func (r *TimelineRepository) Range(ctx context.Context, userID primitive.ObjectID, after models.TimelineCursor, limit int, ttl time.Duration) ([]models.TimelineEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.live(userID)
+	if t == nil {
+		return []models.TimelineEntry{}, nil
+	}
+	t.expiresAt = time.Now().Add(ttl)
+
+	entries := []models.TimelineEntry{}
+	for _, entry := range t.sorted() {
+		if len(entries) >= limit {
+			break
+		}
+		published, bound := entry.PublishedAt.UnixMilli(), after.PublishedAt.UnixMilli()
+		if published < bound || published == bound && entry.PostID.Hex() < after.PostID.Hex() {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// RemoveAuthor removes authorID's posts from userID's timeline
+func (r *TimelineRepository) RemoveAuthor(ctx context.Context, userID, authorID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t := r.live(userID); t != nil {
+		for postID, entry := range t.entries {
+			if entry.AuthorID == authorID {
+				delete(t.entries, postID)
+			}
+		}
+	}
+	return nil
+}
+
+// Expire drops userID's timeline, as if it had not been read for its TTL
+func (r *TimelineRepository) Expire(userID primitive.ObjectID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.timelines, userID)
+}
+
+// live returns userID's timeline unless it is missing or has expired
+func (r *TimelineRepository) live(userID primitive.ObjectID) *timeline {
+	t := r.timelines[userID]
+	if t == nil || !time.Now().Before(t.expiresAt) {
+		delete(r.timelines, userID)
+		return nil
+	}
+	return t
+}
+
+// add stores entries, truncated to the millisecond as Redis scores are,
+// and keeps the newest maxSize
+func (t *timeline) add(entries []models.TimelineEntry, maxSize int) {
+	for _, entry := range entries {
+		entry.PublishedAt = time.UnixMilli(entry.PublishedAt.UnixMilli())
+		t.entries[entry.PostID] = entry
+	}
+	sorted := t.sorted()
+	for i := maxSize; i < len(sorted); i++ {
+		delete(t.entries, sorted[i].PostID)
+	}
+}
+
+// sorted returns the entries newest first, then by post ID, highest first
+func (t *timeline) sorted() []models.TimelineEntry {
+	entries := make([]models.TimelineEntry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].PublishedAt.Equal(entries[j].PublishedAt) {
+			return entries[i].PublishedAt.After(entries[j].PublishedAt)
+		}
+		return entries[i].PostID.Hex() > entries[j].PostID.Hex()
+	})
+	return entries
+}
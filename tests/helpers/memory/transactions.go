@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+)
+
+// txKey marks a context that is already inside a transaction
+type txKey struct{}
+
+// Transactor implements interfaces.Transactor in memory. Transactions run one
+// at a time and a failed one restores the whole database to how it was when the
+// transaction started, so writes made concurrently outside a transaction can be
+// rolled back with it.
+type Transactor struct {
+	db *Database
+	mu sync.Mutex
+}
+
+var _ interfaces.Transactor = (*Transactor)(nil)
+
+// NewTransactor creates an in-memory transactor over db
+func NewTransactor(db *Database) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithTransaction runs fn, rolling back every write it made if it returns an
+// error. Calls made inside an existing transaction join it.
+func (t *Transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snap := t.db.snapshot()
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		t.db.restore(snap)
+		return err
+	}
+	return nil
+}
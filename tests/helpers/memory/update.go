@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// applyUpdate applies MongoDB update operators to doc in place. $setOnInsert
+// only applies when the update inserts a new document.
+func applyUpdate(doc bson.M, update bson.M, inserting bool) error {
+	for operator, operand := range update {
+		fields, ok := operand.(bson.M)
+		if !ok {
+			return fmt.Errorf("memory: update operator %s needs a document", operator)
+		}
+
+		for path, value := range fields {
+			var err error
+			switch operator {
+			case "$set":
+				setPath(doc, path, copyValue(value))
+			case "$setOnInsert":
+				if inserting {
+					setPath(doc, path, copyValue(value))
+				}
+			case "$unset":
+				unsetPath(doc, path)
+			case "$inc":
+				err = incPath(doc, path, value)
+			case "$max", "$min":
+				current, found := lookupValue(doc, path)
+				c := compare(value, current)
+				if !found || (operator == "$max" && c > 0) || (operator == "$min" && c < 0) {
+					setPath(doc, path, copyValue(value))
+				}
+			case "$push":
+				err = pushPath(doc, path, value, false)
+			case "$addToSet":
+				err = pushPath(doc, path, value, true)
+			case "$pull":
+				err = pullPath(doc, path, value)
+			default:
+				err = fmt.Errorf("memory: unsupported update operator %s", operator)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setPath sets a dotted path, creating intermediate documents as needed
+func setPath(doc bson.M, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	var current interface{} = doc
+
+	for i, part := range parts {
+		last := i == len(parts)-1
+
+		switch node := current.(type) {
+		case bson.M:
+			if last {
+				node[part] = value
+				return
+			}
+			child, ok := node[part]
+			if !ok || child == nil {
+				child = bson.M{}
+				node[part] = child
+			}
+			current = child
+		case primitive.A:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return
+			}
+			if last {
+				node[index] = value
+				return
+			}
+			if node[index] == nil {
+				node[index] = bson.M{}
+			}
+			current = node[index]
+		default:
+			return
+		}
+	}
+}
+
+// unsetPath removes a dotted path if it exists
+func unsetPath(doc bson.M, path string) {
+	parent, key, ok := parentOf(doc, path)
+	if ok {
+		delete(parent, key)
+	}
+}
+
+// parentOf returns the document holding the last segment of path
+func parentOf(doc bson.M, path string) (bson.M, string, bool) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(bson.M)
+		if !ok {
+			return nil, "", false
+		}
+		current = child
+	}
+	return current, parts[len(parts)-1], true
+}
+
+func incPath(doc bson.M, path string, delta interface{}) error {
+	current, found := lookupValue(doc, path)
+	if !found || current == nil {
+		setPath(doc, path, delta)
+		return nil
+	}
+
+	sum, err := add(current, delta)
+	if err != nil {
+		return fmt.Errorf("memory: $inc on %s: %w", path, err)
+	}
+	setPath(doc, path, sum)
+	return nil
+}
+
+// add sums two BSON numbers, widening the result the way the server does
+func add(a, b interface{}) (interface{}, error) {
+	_, aFloat := a.(float64)
+	_, bFloat := b.(float64)
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if !okA || !okB {
+		return nil, fmt.Errorf("cannot apply to a non-numeric value")
+	}
+	if aFloat || bFloat {
+		return fa + fb, nil
+	}
+
+	sum := toInt(a) + toInt(b)
+	_, a32 := a.(int32)
+	_, b32 := b.(int32)
+	if a32 && b32 && sum >= math.MinInt32 && sum <= math.MaxInt32 {
+		return int32(sum), nil
+	}
+	return sum, nil
+}
+
+func toInt(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	f, _ := toFloat(value)
+	return int64(f)
+}
+
+func pushPath(doc bson.M, path string, value interface{}, unique bool) error {
+	items := primitive.A{value}
+	slice, hasSlice := 0, false
+	if modifiers, ok := value.(bson.M); ok {
+		if each, ok := modifiers["$each"]; ok {
+			items, _ = each.(primitive.A)
+			if s, ok := modifiers["$slice"]; ok {
+				slice, hasSlice = int(toInt(s)), true
+			}
+		}
+	}
+
+	current, found := lookupValue(doc, path)
+	array, ok := current.(primitive.A)
+	if found && current != nil && !ok {
+		return fmt.Errorf("memory: cannot push to non-array field %s", path)
+	}
+	array = append(primitive.A{}, array...)
+
+	for _, item := range items {
+		if unique && containsValue(array, item) {
+			continue
+		}
+		array = append(array, copyValue(item))
+	}
+
+	if hasSlice {
+		switch {
+		case slice >= 0 && slice < len(array):
+			array = array[:slice]
+		case slice < 0 && -slice < len(array):
+			array = array[len(array)+slice:]
+		}
+	}
+
+	setPath(doc, path, array)
+	return nil
+}
+
+func pullPath(doc bson.M, path string, condition interface{}) error {
+	current, found := lookupValue(doc, path)
+	if !found || current == nil {
+		return nil
+	}
+	array, ok := current.(primitive.A)
+	if !ok {
+		return fmt.Errorf("memory: cannot pull from non-array field %s", path)
+	}
+
+	kept := primitive.A{}
+	for _, element := range array {
+		matched, err := pullMatches(element, condition)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			kept = append(kept, element)
+		}
+	}
+
+	setPath(doc, path, kept)
+	return nil
+}
+
+// pullMatches reports whether an array element satisfies a $pull condition,
+// which is a literal value, a set of operators or a query on element fields
+func pullMatches(element, condition interface{}) (bool, error) {
+	if _, ok := isOperatorDocument(condition); ok {
+		return matchCondition([]interface{}{element}, true, condition)
+	}
+	if query, ok := condition.(bson.M); ok {
+		if doc, isDoc := element.(bson.M); isDoc {
+			return matches(doc, query)
+		}
+		return false, nil
+	}
+	return equal(element, condition), nil
+}
+
+func containsValue(array primitive.A, value interface{}) bool {
+	for _, element := range array {
+		if equal(element, value) {
+			return true
+		}
+	}
+	return false
+}
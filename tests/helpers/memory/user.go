@@ -0,0 +1,271 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Caqil/vyrall/internal/models"
+	"github.com/Caqil/vyrall/internal/repository/interfaces"
+	mongoutil "github.com/Caqil/vyrall/internal/utils/mongodb"
+	"github.com/Caqil/vyrall/pkg/constants"
+)
+
+// profileFields are the fields UpdateProfile may change
+var profileFields = map[string]bool{
+	"first_name":      true,
+	"last_name":       true,
+	"display_name":    true,
+	"bio":             true,
+	"profile_picture": true,
+	"cover_photo":     true,
+	"phone_number":    true,
+	"website":         true,
+	"location":        true,
+	"date_of_birth":   true,
+	"gender":          true,
+	"is_private":      true,
+}
+
+// UserRepository implements interfaces.UserRepository in memory. Usernames and
+// emails are unique, as enforced by the production indexes.
+type UserRepository struct {
+	store
+	follows store
+}
+
+var _ interfaces.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository creates an in-memory user repository
+func NewUserRepository(db *Database) *UserRepository {
+	return &UserRepository{
+		store:   newStore(db, constants.CollectionUsers),
+		follows: newStore(db, constants.CollectionFollows),
+	}
+}
+
+// Create inserts a new active user
+func (r *UserRepository) Create(ctx context.Context, user *models.User) (primitive.ObjectID, error) {
+	now := time.Now()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	if user.Role == "" {
+		user.Role = "user"
+	}
+	if user.Status == "" {
+		user.Status = "active"
+	}
+	if user.JoinedAt.IsZero() {
+		user.JoinedAt = now
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+	return r.insert(user)
+}
+
+// GetByID retrieves a user by ID, ignoring deleted accounts
+func (r *UserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return findOne[models.User](r.collection, notDeleted(id), nil)
+}
+
+// GetByUsername retrieves a user by username, ignoring deleted accounts
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	filter := mongoutil.Merge(bson.M{"username": username}, mongoutil.NotDeleted())
+	return findOne[models.User](r.collection, filter, nil)
+}
+
+// GetByEmail retrieves a user by email, ignoring deleted accounts
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	filter := mongoutil.Merge(bson.M{"email": email}, mongoutil.NotDeleted())
+	return findOne[models.User](r.collection, filter, nil)
+}
+
+// Update replaces a user document
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now()
+	return r.replace(user.ID, user)
+}
+
+// Delete soft deletes a user, keeping the document so content can still be attributed
+func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(notDeleted(id), bson.M{"deleted_at": time.Now(), "status": "deleted"})
+}
+
+// FindByIDs retrieves several users by ID, ignoring deleted accounts
+func (r *UserRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.User, error) {
+	if len(ids) == 0 {
+		return []*models.User{}, nil
+	}
+	filter := mongoutil.Merge(bson.M{"_id": bson.M{"$in": ids}}, mongoutil.NotDeleted())
+	return findAll[models.User](r.collection, filter, nil)
+}
+
+// Search finds active users whose username, display name or bio contain any
+// word of the query, standing in for the users_text index. The most followed come first.
+func (r *UserRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.User, int, error) {
+	filter := and(activeUser(), textFilter(query, "username", "display_name", "bio"))
+	return findPage[models.User](r.collection, filter, byFollowers, limit, offset)
+}
+
+// GetSuggestions recommends active users followed by the accounts a user
+// follows, ranked by how many of them follow each candidate, then tops the
+// list up with popular users
+func (r *UserRepository) GetSuggestions(ctx context.Context, userID primitive.ObjectID, limit int) ([]*models.User, error) {
+	limit, _ = mongoutil.NormalizePagination(limit, 0)
+
+	values, err := r.follows.collection.Distinct("following_id", bson.M{"follower_id": userID, "status": "accepted"})
+	if err != nil {
+		return nil, err
+	}
+	following := objectIDs(values)
+
+	excluded := map[primitive.ObjectID]bool{userID: true}
+	for _, id := range following {
+		excluded[id] = true
+	}
+
+	follows, err := findAll[models.Follow](r.follows.collection, bson.M{
+		"follower_id": bson.M{"$in": following},
+		"status":      "accepted",
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[primitive.ObjectID]int{}
+	for _, follow := range follows {
+		if !excluded[follow.FollowingID] {
+			counts[follow.FollowingID]++
+		}
+	}
+
+	suggestions := make([]*models.User, 0, limit)
+	for _, id := range rankIDs(counts, limit) {
+		user, err := findOne[models.User](r.collection, mongoutil.Merge(byID(id), suggestable()), nil)
+		if err != nil {
+			continue
+		}
+		suggestions = append(suggestions, user)
+		excluded[id] = true
+	}
+	if len(suggestions) >= limit {
+		return suggestions, nil
+	}
+
+	popular, err := findAll[models.User](r.collection, suggestable(), byFollowers)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range popular {
+		if len(suggestions) >= limit {
+			break
+		}
+		if !excluded[user.ID] {
+			suggestions = append(suggestions, user)
+		}
+	}
+	return suggestions, nil
+}
+
+// GetPopular retrieves the most followed active users who may be suggested
+func (r *UserRepository) GetPopular(ctx context.Context, limit int) ([]*models.User, error) {
+	return findLimit[models.User](r.collection, suggestable(), byFollowers, limit)
+}
+
+// UpdateStatus sets the account status of a user
+func (r *UserRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	return r.updateFields(notDeleted(id), bson.M{"status": status})
+}
+
+// UpdateVerificationStatus sets whether a user carries the verified badge
+func (r *UserRepository) UpdateVerificationStatus(ctx context.Context, id primitive.ObjectID, isVerified bool) error {
+	return r.updateFields(notDeleted(id), bson.M{"is_verified": isVerified})
+}
+
+// UpdateSettings replaces a user's settings
+func (r *UserRepository) UpdateSettings(ctx context.Context, id primitive.ObjectID, settings models.UserSettings) error {
+	return r.updateFields(notDeleted(id), bson.M{"settings": settings})
+}
+
+// UpdateNotificationPreferences replaces a user's notification preferences
+func (r *UserRepository) UpdateNotificationPreferences(ctx context.Context, id primitive.ObjectID, prefs models.NotificationPreferences) error {
+	return r.updateFields(notDeleted(id), bson.M{"settings.notification_preferences": prefs})
+}
+
+// UpdatePrivacySettings replaces a user's privacy settings
+func (r *UserRepository) UpdatePrivacySettings(ctx context.Context, id primitive.ObjectID, settings models.PrivacySettings) error {
+	return r.updateFields(notDeleted(id), bson.M{"settings.privacy_settings": settings})
+}
+
+// UpdateProfile sets public profile fields. Anything else, such as the
+// password hash or role, is rejected.
+func (r *UserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, profileData map[string]interface{}) error {
+	fields := bson.M{}
+	for key, value := range profileData {
+		if !profileFields[key] {
+			return fmt.Errorf("memory: %s is not a profile field", key)
+		}
+		fields[key] = value
+	}
+	return r.updateFields(notDeleted(id), fields)
+}
+
+// IncrementFollowerCount atomically adjusts the follower counter
+func (r *UserRepository) IncrementFollowerCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "follower_count", amount)
+}
+
+// IncrementFollowingCount atomically adjusts the following counter
+func (r *UserRepository) IncrementFollowingCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "following_count", amount)
+}
+
+// IncrementPostCount atomically adjusts the post counter
+func (r *UserRepository) IncrementPostCount(ctx context.Context, id primitive.ObjectID, amount int) error {
+	return r.increment(id, "post_count", amount)
+}
+
+// ChangePassword stores a new password hash
+func (r *UserRepository) ChangePassword(ctx context.Context, id primitive.ObjectID, hashedPassword string) error {
+	return r.updateFields(notDeleted(id), bson.M{"password_hash": hashedPassword})
+}
+
+// UpdateLastActive records that a user was just active
+func (r *UserRepository) UpdateLastActive(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateFields(notDeleted(id), bson.M{"last_active": time.Now()})
+}
+
+// SetEmailVerified sets whether a user's email address is verified
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id primitive.ObjectID, verified bool) error {
+	return r.updateFields(notDeleted(id), bson.M{"email_verified": verified})
+}
+
+// SetTwoFactorEnabled sets whether a user signs in with a second factor
+func (r *UserRepository) SetTwoFactorEnabled(ctx context.Context, id primitive.ObjectID, enabled bool) error {
+	return r.updateFields(notDeleted(id), bson.M{"two_factor_enabled": enabled})
+}
+
+// List retrieves users with an arbitrary filter and sort.
+// Deleted accounts are excluded unless the filter mentions deleted_at.
+func (r *UserRepository) List(ctx context.Context, filter map[string]interface{}, sort map[string]int, limit, offset int) ([]*models.User, int, error) {
+	return findPage[models.User](r.collection, listFilter(filter), mongoutil.ToSort(sort, mongoutil.NewestFirst), limit, offset)
+}
+
+// byFollowers sorts users most followed first
+var byFollowers = bson.D{{Key: "follower_count", Value: -1}, {Key: "_id", Value: -1}}
+
+// activeUser matches accounts that are active and not deleted
+func activeUser() bson.M {
+	return mongoutil.Merge(bson.M{"status": "active"}, mongoutil.NotDeleted())
+}
+
+// suggestable matches active accounts that have not opted out of being
+// suggested to other users
+func suggestable() bson.M {
+	return mongoutil.Merge(activeUser(), bson.M{"settings.privacy_settings.hide_from_suggestions": bson.M{"$ne": true}})
+}
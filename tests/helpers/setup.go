@@ -0,0 +1,210 @@
+// Package helpers builds in-memory application state for fast handler and
+// service tests that need neither MongoDB nor Redis
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Caqil/vyrall/internal/api/routes"
+	"github.com/Caqil/vyrall/internal/services"
+	"github.com/Caqil/vyrall/internal/services/auth"
+	"github.com/Caqil/vyrall/internal/services/comment"
+	"github.com/Caqil/vyrall/internal/services/event"
+	"github.com/Caqil/vyrall/internal/services/notification"
+	"github.com/Caqil/vyrall/internal/services/permission"
+	"github.com/Caqil/vyrall/internal/services/post"
+	"github.com/Caqil/vyrall/internal/services/user"
+	"github.com/Caqil/vyrall/internal/utils/eventbus"
+	"github.com/Caqil/vyrall/internal/utils/keyring"
+	"github.com/Caqil/vyrall/internal/utils/logger"
+	"github.com/Caqil/vyrall/internal/utils/webauthn"
+	"github.com/Caqil/vyrall/pkg/config"
+	"github.com/Caqil/vyrall/pkg/metrics"
+	"github.com/Caqil/vyrall/tests/helpers/fakes"
+	"github.com/Caqil/vyrall/tests/helpers/memory"
+)
+
+// Env is the state shared by one test: configuration, an in-memory database,
+// the repositories on top of it, the services built from them and the email
+// they sent
+type Env struct {
+	Config   *config.Config
+	Logger   *logger.Logger
+	DB       *memory.Database
+	Repos    *memory.Repositories
+	Files    *memory.FileStore
+	Mail     *fakes.Mailbox
+	Services *services.Services
+
+	router *gin.Engine
+}
+
+// NewEnv creates a fresh environment and closes it when the test ends
+func NewEnv(tb testing.TB) *Env {
+	tb.Helper()
+
+	cfg := TestConfig()
+	log := logger.NewNop()
+	db := memory.NewDatabase()
+	repos := memory.NewRepositories(db)
+	files := memory.NewFileStore()
+	mail := fakes.NewMailbox()
+	env := &Env{
+		Config:   cfg,
+		Logger:   log,
+		DB:       db,
+		Repos:    repos,
+		Files:    files,
+		Mail:     mail,
+		Services: NewServices(tb, cfg, log, repos, files, mail),
+	}
+	tb.Cleanup(env.Close)
+	return env
+}
+
+// TestConfig returns the default configuration for the test environment.
+// Metrics and compression are off so responses can be read directly and the
+// router does not need a metrics service.
+func TestConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Environment = config.EnvTest
+	cfg.Logging.Level = "error"
+	cfg.EnableCompression = false
+	cfg.EnableMetrics = false
+	cfg.ServeStaticFiles = false
+	cfg.EnableSwagger = false
+	cfg.JWT.Secret = "test-secret-that-is-long-enough-for-tests"
+	return cfg
+}
+
+// NewServices builds the application services on top of in-memory repositories
+// and files, sending email to mail. Services whose constructors still need a
+// live MongoDB or Redis connection, such as analytics and the job queue, are
+// left nil; tests that exercise them should set the field to a fake of their
+// own. No GeoIP database or breached-password corpus is configured; tests of
+// those checks build the services they need from the fixtures. Domain events
+// are recorded in the in-memory outbox and reach subscribers through
+// DeliverEvents.
+func NewServices(tb testing.TB, cfg *config.Config, log *logger.Logger, repos *memory.Repositories, files *memory.FileStore, mail *fakes.Mailbox) *services.Services {
+	tb.Helper()
+
+	events := eventbus.NewOutbox(repos.Outbox)
+	collector := metrics.NewCollector()
+
+	keys, err := keyring.New(repos.SigningKeys, keyring.Options{
+		Algorithm:        cfg.JWT.Algorithm,
+		Secret:           cfg.JWT.Secret,
+		RotationInterval: cfg.JWT.KeyRotationInterval,
+		GracePeriod:      cfg.JWT.KeyGracePeriod,
+	}, log)
+	if err != nil {
+		tb.Fatalf("create keyring: %v", err)
+	}
+	if err := keys.Rotate(context.Background()); err != nil {
+		tb.Fatalf("create signing key: %v", err)
+	}
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    cfg.WebAuthn.RPID,
+		RPName:  cfg.WebAuthn.RPName,
+		Origins: cfg.WebAuthn.Origins,
+		Timeout: cfg.WebAuthn.Timeout,
+	})
+	if err != nil {
+		tb.Fatalf("create webauthn relying party: %v", err)
+	}
+
+	notifier := notification.NewService(repos.Notifications)
+	authService := auth.NewService(&auth.Repositories{
+		Users:          auth.NewUserRepository(repos.Users),
+		Sessions:       repos.Sessions,
+		Verifications:  repos.Verifications,
+		TwoFactor:      repos.TwoFactor,
+		Passkeys:       repos.Passkeys,
+		LoginAttempts:  repos.LoginAttempts,
+		LoginHistory:   repos.LoginHistory,
+		OAuthApps:      repos.OAuthApps,
+		OAuthGrants:    repos.OAuthGrants,
+		PersonalTokens: repos.PersonalTokens,
+		AdminAudit:     repos.AdminAudit,
+	}, keys, rp, nil, nil, mail, notifier, repos.Transactor, events, cfg, log)
+	commentService := comment.NewService(repos.Comments, repos.Likes, repos.Posts, repos.Users, repos.Reports, notifier, repos.Transactor, events, &cfg.Comment, collector, log)
+	eventService := event.NewService(repos.Events, repos.Users, repos.Follows, repos.Groups, notifier, repos.Transactor, events, &cfg.Event, collector, log)
+
+	svc := &services.Services{
+		AuthService:         authService,
+		CommentService:      commentService,
+		EventService:        eventService,
+		NotificationService: notifier,
+		PermissionService:   permission.NewService(repos.Users, repos.Groups, repos.Posts, repos.Comments, repos.LiveStreams, repos.Events),
+		PostService:         post.NewService(repos.Posts, repos.Comments, repos.Likes, repos.Bookmarks, repos.Media, repos.Users, repos.Transactor, events),
+		UserService:         user.NewService(repos.Users, repos.Follows, repos.Transactor, events),
+		EventBus:            eventbus.NewBus(),
+		OAuthServer:         authService.OAuthServer(),
+		Keyring:             keys,
+		AccountPurgeService: user.NewPurgeService(repos.Users, repos.Media, repos.AccountPurges, files),
+		TimelineService:     post.NewTimelineService(repos.Posts, repos.Users, repos.Follows, repos.Timelines, &cfg.Timeline),
+	}
+
+	authService.Subscribe(svc.EventBus)
+	commentService.Subscribe(svc.EventBus)
+	eventService.Subscribe(svc.EventBus)
+	svc.TimelineService.Subscribe(svc.EventBus)
+	return svc
+}
+
+// Router builds the HTTP router on top of the environment's services. It is
+// built once, so set any extra services before the first call.
+func (e *Env) Router() *gin.Engine {
+	if e.router == nil {
+		gin.SetMode(gin.TestMode)
+		e.router = routes.SetupRouter(e.Config, e.Services, e.Logger)
+	}
+	return e.router
+}
+
+// Request sends a request through the router and records the response. A
+// non-nil body is encoded as JSON; a non-empty token is sent as a bearer token.
+func (e *Env) Request(tb testing.TB, method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	tb.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			tb.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	e.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+// Get sends a GET request through the router
+func (e *Env) Get(tb testing.TB, path, token string) *httptest.ResponseRecorder {
+	tb.Helper()
+	return e.Request(tb, http.MethodGet, path, nil, token)
+}
+
+// Post sends a POST request with a JSON body through the router
+func (e *Env) Post(tb testing.TB, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	tb.Helper()
+	return e.Request(tb, http.MethodPost, path, body, token)
+}